@@ -128,7 +128,7 @@ func RefreshTokenHandler(ctx context.Context, oldRefreshToken string, ab *authbo
 	}
 
 	// Delete old session
-	err = sessionStorer.Delete(ctx, claims.SessionID)
+	err = sessionStorer.DeleteUserSession(ctx, claims.UserID, claims.SessionID)
 	if err != nil {
 		return "", "", errors.Wrap(err, "[RefreshTokenHandler] could not delete old session")
 	}
@@ -138,10 +138,6 @@ func RefreshTokenHandler(ctx context.Context, oldRefreshToken string, ab *authbo
 	if err != nil {
 		return "", "", errors.Wrap(err, "[RefreshTokenHandler] could not generate new session ID")
 	}
-	err = sessionStorer.Save(ctx, strconv.FormatInt(newSessionID, 10), strconv.FormatInt(claims.UserID, 10), 24*time.Hour)
-	if err != nil {
-		return "", "", errors.Wrap(err, "[RefreshTokenHandler] could not save new session")
-	}
 
 	// Generate new access token
 	newAccessToken, err := GenerateTokenWithTTL(claims.UserID, claims.ScopeID, strconv.FormatInt(newSessionID, 10), tokenExpiryMins)
@@ -155,11 +151,17 @@ func RefreshTokenHandler(ctx context.Context, oldRefreshToken string, ab *authbo
 		return "", "", errors.Wrap(err, "[RefreshTokenHandler] could not generate new refresh token")
 	}
 
+	// The next refresh is checked against the stored token
+	err = sessionStorer.SaveUserSession(ctx, claims.UserID, strconv.FormatInt(newSessionID, 10), newRefreshToken, refreshTokenExpiryMins*time.Minute)
+	if err != nil {
+		return "", "", errors.Wrap(err, "[RefreshTokenHandler] could not save new session")
+	}
+
 	return newAccessToken, newRefreshToken, nil
 }
 
 // @Summary Register a new user
-// @Description Register a new user with email and password
+// @Description Register a new user with email and password. The personal scope is seeded with the named template, or the default one.
 // @ID register-user
 // @Accept  json
 // @Produce  json
@@ -177,6 +179,13 @@ func JWTRegisterHandler(ab *authboss.Authboss) gin.HandlerFunc {
 			return
 		}
 
+		if newUser.Template != "" {
+			if _, err := impl.GetModelsService().TemplateModel.GetTemplate(newUser.Template); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
 		exists, err := impl.GetModelsService().UserModel.UserExists(c, newUser.Username, newUser.Email, nil)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "[JWTRegisterHandler] Error checking user existence").Error()})
@@ -232,7 +241,7 @@ func JWTRegisterHandler(ab *authboss.Authboss) gin.HandlerFunc {
 			return
 		}
 
-		err = sessionStorer.Save(c.Request.Context(), sessionID, refreshToken, refreshTokenExpiryMins*time.Minute)
+		err = sessionStorer.SaveUserSession(c.Request.Context(), newUser.ID, sessionID, refreshToken, refreshTokenExpiryMins*time.Minute)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "[JWTRegisterHandler] Error storing refresh token").Error()})
 			return
@@ -316,7 +325,7 @@ func JWTLoginHandler(ab *authboss.Authboss) gin.HandlerFunc {
 			return
 		}
 
-		err = sessionStorer.Save(c.Request.Context(), sessionID, refreshToken, refreshTokenExpiryMins*time.Minute)
+		err = sessionStorer.SaveUserSession(c.Request.Context(), user.ID, sessionID, refreshToken, refreshTokenExpiryMins*time.Minute)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "[JWTLoginHandler] Error storing refresh token").Error()})
 			return
@@ -390,7 +399,7 @@ func JWTLogoutHandler(ab *authboss.Authboss) gin.HandlerFunc {
 			return
 		}
 
-		err = sessionStorer.Delete(c.Request.Context(), claims.SessionID)
+		err = sessionStorer.DeleteUserSession(c.Request.Context(), claims.UserID, claims.SessionID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "[JWTLogoutHandler] Error deleting session").Error()})
 			return
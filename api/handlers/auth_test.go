@@ -157,7 +157,7 @@ func TestJWTRegisterHandler(t *testing.T) {
 			context.Background(),
 			gomock.Any(), // matches any []byte for sessionID
 			gomock.Any(), // matches any []byte for state
-		).Return(nil)
+		).Return(nil).Times(2) // the session and its entry among the user's sessions
 
 	// Mock dependencies
 	ab := &authboss.Authboss{} // Populate with necessary mock implementation
@@ -208,7 +208,7 @@ func TestJWTLoginHandler(t *testing.T) {
 			context.Background(),
 			gomock.Any(), // matches any []byte for sessionID
 			gomock.Any(), // matches any []byte for state
-		).Return(nil)
+		).Return(nil).Times(2) // the session and its entry among the user's sessions
 	// Mock dependencies
 	ab := &authboss.Authboss{} // Populate with necessary mock implementation
 	ab.Config.Storage.Server = mockUserStorer
@@ -248,18 +248,15 @@ func TestJWTRefreshHandler(t *testing.T) {
 			gomock.Any(), // To match any []byte for sessionID
 		).Return([]byte(refreshToken), nil) // Returning the refresh token
 
-	// Set up the expected behavior of Delete method for KvClient
-	mockKV.EXPECT().
-		Delete(
-			context.Background(),
-			gomock.Any(), // To match any []byte for sessionID
-		).Return(nil) // Simulate successful deletion
+	// The old session and its entry among the user's sessions are both removed
+	mockKV.EXPECT().Delete(context.Background(), []byte(sessionID)).Return(nil)
+	mockKV.EXPECT().Delete(context.Background(), []byte("user_sessions/123/"+sessionID)).Return(nil)
 	mockKV.EXPECT().
 		Put(
 			context.Background(),
 			gomock.Any(), // matches any []byte for sessionID
 			gomock.Any(), // matches any []byte for state
-		).Return(nil)
+		).Return(nil).Times(2) // the session and its entry among the user's sessions
 
 	ab := &authboss.Authboss{} // Populate with necessary mock implementation
 	ab.Config.Storage.Server = mockUserStorer
@@ -304,7 +301,7 @@ func TestJWTLogoutHandler(t *testing.T) {
 		Delete(
 			context.Background(),
 			gomock.Any(), // To match any []byte for sessionID
-		).Return(nil) // Simulate successful deletion
+		).Return(nil).Times(2) // Simulate successful deletion of the session and its entry
 	// Create a Gin context from the request
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
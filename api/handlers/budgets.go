@@ -0,0 +1,358 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// BudgetRequest is the body for creating or updating a budget.
+// Dates use the YYYY-MM-DD format; on update, empty fields keep their current value.
+type BudgetRequest struct {
+	CategoryID int64            `json:"category_id"`
+	Amount     interfaces.Money `json:"amount"`
+	Period     string           `json:"period"`
+	StartDate  string           `json:"start_date"`
+	EndDate    string           `json:"end_date"`
+	Rollover   *bool            `json:"rollover"`
+}
+
+func getBudgetID(c *gin.Context) (int64, bool) {
+	budgetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Printf("[getBudgetID] Error: invalid budget ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid budget ID format"})
+		return 0, false
+	}
+	return budgetID, true
+}
+
+// apply copies the non-empty request fields onto budget.
+func (r BudgetRequest) apply(budget *interfaces.Budget) error {
+	if r.CategoryID != 0 {
+		budget.CategoryID = r.CategoryID
+	}
+	if r.Amount != 0 {
+		budget.Amount = r.Amount
+	}
+	if r.Period != "" {
+		budget.Period = r.Period
+	}
+	if r.Rollover != nil {
+		budget.Rollover = *r.Rollover
+	}
+	if r.StartDate != "" {
+		startDate, err := parseDate("start_date", r.StartDate)
+		if err != nil {
+			return err
+		}
+		budget.StartDate = startDate
+	}
+	if r.EndDate != "" {
+		endDate, err := parseDate("end_date", r.EndDate)
+		if err != nil {
+			return err
+		}
+		budget.EndDate = &endDate
+	}
+	return nil
+}
+
+func respondBudgetError(c *gin.Context, err error, fallback string) {
+	switch errors.Cause(err) {
+	case impl.ErrBudgetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget not found"})
+	case impl.ErrInvalidBudget:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListBudgets
+// @Summary List budgets
+// @Description Get the budgets of the active scope. Group budgets are shared by all members.
+// @ID list-budgets
+// @Produce  json
+// @Success 200 {array} interfaces.Budget
+// @Failure 500 {object} map[string]string "Unable to fetch budgets"
+// @Router /budgets [get]
+func ListBudgets(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ListBudgets] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ListBudgets] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	budgets, err := impl.GetModelsService().BudgetModel.GetBudgetsByScope(c, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[ListBudgets] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+// GetBudgetStatus
+// @Summary Budget utilization
+// @Description Get spent, remaining and percent used for the current period of every active budget of the active scope. Periods follow the user's time zone and first day of the week
+// @ID get-budget-status
+// @Produce  json
+// @Param date query string false "Report the period containing this day (YYYY-MM-DD), defaults to today"
+// @Success 200 {array} interfaces.BudgetStatus
+// @Failure 400 {object} map[string]string "Invalid date"
+// @Failure 500 {object} map[string]string "Unable to compute budget status"
+// @Router /budgets/status [get]
+func GetBudgetStatus(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[GetBudgetStatus] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[GetBudgetStatus] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	asOf := time.Now()
+	var date time.Time
+	if day := c.Query("date"); day != "" {
+		var err error
+		if date, err = parseDate("date", day); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Periods follow the calendar of the user asking, whose day the date names
+	location, weekStart := userCalendar(c, userInfo.UserID)
+	if !date.IsZero() {
+		asOf = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
+	}
+
+	statuses, err := impl.GetModelsService().BudgetModel.GetBudgetStatus(c, []int64{userInfo.UseScope}, asOf, location, weekStart)
+	if err != nil {
+		log.Printf("[GetBudgetStatus] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to compute budget status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+// CreateBudget
+// @Summary Create a budget
+// @Description Set a spending limit for a category of the active scope, renewed monthly or weekly, or for a custom date range
+// @ID create-budget
+// @Accept  json
+// @Produce  json
+// @Param budget body BudgetRequest true "Budget info"
+// @Success 201 {object} interfaces.Budget
+// @Failure 400 {object} map[string]string "Invalid budget"
+// @Failure 500 {object} map[string]string "Unable to create budget"
+// @Router /budgets [post]
+func CreateBudget(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[CreateBudget] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[CreateBudget] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	var request BudgetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[CreateBudget] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	budget := interfaces.Budget{UserID: userInfo.UserID, ScopeID: userInfo.UseScope}
+	if err := request.apply(&budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := impl.GetModelsService().BudgetModel.InsertBudget(c, &budget); err != nil {
+		log.Printf("[CreateBudget] Error: %v", err)
+		respondBudgetError(c, err, "unable to create budget")
+		return
+	}
+
+	c.JSON(http.StatusCreated, budget)
+}
+
+// GetBudget
+// @Summary Get a budget
+// @Description Get a budget of the active scope by its ID
+// @ID get-budget
+// @Produce  json
+// @Param id path int true "Budget ID"
+// @Success 200 {object} interfaces.Budget
+// @Failure 404 {object} map[string]string "Budget not found"
+// @Router /budgets/{id} [get]
+func GetBudget(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[GetBudget] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[GetBudget] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	budgetID, ok := getBudgetID(c)
+	if !ok {
+		return
+	}
+
+	budget, err := impl.GetModelsService().BudgetModel.GetBudgetByID(c, budgetID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[GetBudget] Error: %v", err)
+		respondBudgetError(c, err, "unable to fetch budget")
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// UpdateBudget
+// @Summary Update a budget
+// @Description Change the category, amount, period, dates or rollover of a budget
+// @ID update-budget
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Budget ID"
+// @Param budget body BudgetRequest true "Fields to change"
+// @Success 200 {object} interfaces.Budget
+// @Failure 400 {object} map[string]string "Invalid budget"
+// @Failure 404 {object} map[string]string "Budget not found"
+// @Router /budgets/{id} [put]
+func UpdateBudget(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[UpdateBudget] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[UpdateBudget] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	budgetID, ok := getBudgetID(c)
+	if !ok {
+		return
+	}
+
+	var request BudgetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[UpdateBudget] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	budget, err := impl.GetModelsService().BudgetModel.GetBudgetByID(c, budgetID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[UpdateBudget] Error: %v", err)
+		respondBudgetError(c, err, "unable to fetch budget")
+		return
+	}
+	if err := request.apply(budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	budget.UserID = userInfo.UserID
+
+	if err := impl.GetModelsService().BudgetModel.UpdateBudget(c, budget); err != nil {
+		log.Printf("[UpdateBudget] Error: %v", err)
+		respondBudgetError(c, err, "unable to update budget")
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// DeleteBudget
+// @Summary Delete a budget
+// @Description Delete a budget of the active scope
+// @ID delete-budget
+// @Produce  json
+// @Param id path int true "Budget ID"
+// @Success 200 {object} map[string]string "message: budget deleted successfully"
+// @Failure 404 {object} map[string]string "Budget not found"
+// @Router /budgets/{id} [delete]
+func DeleteBudget(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[DeleteBudget] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[DeleteBudget] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	budgetID, ok := getBudgetID(c)
+	if !ok {
+		return
+	}
+
+	if err := impl.GetModelsService().BudgetModel.DeleteBudget(c, budgetID, []int64{userInfo.UseScope}); err != nil {
+		log.Printf("[DeleteBudget] Error: %v", err)
+		respondBudgetError(c, err, "unable to delete budget")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "budget deleted successfully"})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func initBudgetTest(t *testing.T) *xmock.MockBudgetModel {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+
+	mockBudgetModel := new(xmock.MockBudgetModel)
+	modelsService.BudgetModel = mockBudgetModel
+	return mockBudgetModel
+}
+
+func TestCreateBudget(t *testing.T) {
+	mockBudgetModel := initBudgetTest(t)
+	defer mockBudgetModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Monthly groceries",
+			requestBody: `{"category_id":3,"amount":500,"period":"MONTHLY","start_date":"2024-01-01","rollover":true}`,
+			setupMock: func() {
+				expected := &interfaces.Budget{UserID: 1, ScopeID: 10, CategoryID: 3, Amount: interfaces.MoneyFromFloat(500), Period: "MONTHLY",
+					StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Rollover: true}
+				mockBudgetModel.On("InsertBudget", mock.Anything, expected, mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"rollover":true`,
+		},
+		{
+			name:           "Malformed end date",
+			requestBody:    `{"category_id":3,"amount":500,"period":"CUSTOM","end_date":"2024/12/31"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "YYYY-MM-DD",
+		},
+		{
+			name:        "Invalid period",
+			requestBody: `{"category_id":3,"amount":500,"period":"DAILY"}`,
+			setupMock: func() {
+				mockBudgetModel.On("InsertBudget", mock.Anything, mock.MatchedBy(func(b *interfaces.Budget) bool { return b.Period == "DAILY" }), mock.Anything).
+					Return(impl.ErrInvalidBudget).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   impl.ErrInvalidBudget.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "POST", "/budgets", tc.requestBody)
+
+			CreateBudget(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestGetBudgetStatus(t *testing.T) {
+	mockBudgetModel := initBudgetTest(t)
+	defer mockBudgetModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Given date",
+			query: "?date=2024-02-15",
+			setupMock: func() {
+				mockUserModel := impl.GetModelsService().UserModel.(*xmock.MockUserModel)
+				mockUserModel.On("GetUserByID", mock.Anything, int64(1), mock.Anything).
+					Return(&interfaces.User{ID: 1, Timezone: "Asia/Kolkata", WeekStart: "SUNDAY"}, nil).Once()
+				kolkata, _ := time.LoadLocation("Asia/Kolkata")
+				mockBudgetModel.On("GetBudgetStatus", mock.Anything, []int64{10}, time.Date(2024, 2, 15, 0, 0, 0, 0, kolkata), kolkata, time.Sunday, mock.AnythingOfType("[]*sql.Tx")).
+					Return([]interfaces.BudgetStatus{{BudgetID: 5, Available: interfaces.MoneyFromFloat(200), Spent: interfaces.MoneyFromFloat(50), Remaining: interfaces.MoneyFromFloat(150), PercentUsed: 25}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"percent_used":25`,
+		},
+		{
+			name:           "Malformed date",
+			query:          "?date=15-02-2024",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "YYYY-MM-DD",
+		},
+		{
+			name:  "Model error",
+			query: "?date=2024-03-01",
+			setupMock: func() {
+				mockUserModel := impl.GetModelsService().UserModel.(*xmock.MockUserModel)
+				mockUserModel.On("GetUserByID", mock.Anything, int64(1), mock.Anything).Return((*interfaces.User)(nil), assert.AnError).Once()
+				mockBudgetModel.On("GetBudgetStatus", mock.Anything, []int64{10}, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.UTC, time.Monday, mock.Anything).Return(nil, assert.AnError).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "unable to compute budget status",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "GET", "/budgets/status"+tc.query, "")
+
+			GetBudgetStatus(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"xspends/importer"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// ExchangeRateRequest is the body for recording an exchange rate. Date uses the YYYY-MM-DD format.
+type ExchangeRateRequest struct {
+	FromCurrency string  `json:"from_currency"`
+	ToCurrency   string  `json:"to_currency"`
+	Date         string  `json:"date"`
+	Rate         float64 `json:"rate"`
+}
+
+func respondExchangeRateError(c *gin.Context, err error, fallback string) {
+	switch errors.Cause(err) {
+	case impl.ErrExchangeRateNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "exchange rate not found"})
+	case impl.ErrInvalidExchangeRate, impl.ErrInvalidCurrency, importer.ErrInvalidFile:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListExchangeRates
+// @Summary List exchange rates
+// @Description Get the exchange rates recorded for the active scope, newest first
+// @ID list-exchange-rates
+// @Produce  json
+// @Param from query string false "Only rates from this currency"
+// @Param to query string false "Only rates to this currency"
+// @Success 200 {array} interfaces.ExchangeRate
+// @Failure 500 {object} map[string]string "Unable to fetch exchange rates"
+// @Router /exchange-rates [get]
+func ListExchangeRates(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ListExchangeRates] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ListExchangeRates] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	rates, err := impl.GetModelsService().CurrencyModel.GetExchangeRates(c, []int64{userInfo.UseScope}, c.Query("from"), c.Query("to"))
+	if err != nil {
+		log.Printf("[ListExchangeRates] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetExchangeRate
+// @Summary Record an exchange rate
+// @Description Record how many units of to_currency one unit of from_currency bought on a day. A rate already recorded for the same pair and day is replaced.
+// @ID set-exchange-rate
+// @Accept  json
+// @Produce  json
+// @Param rate body ExchangeRateRequest true "Exchange rate"
+// @Success 200 {object} interfaces.ExchangeRate
+// @Failure 400 {object} map[string]string "Invalid exchange rate"
+// @Failure 500 {object} map[string]string "Unable to save exchange rate"
+// @Router /exchange-rates [post]
+func SetExchangeRate(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[SetExchangeRate] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[SetExchangeRate] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	var request ExchangeRateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[SetExchangeRate] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := parseDate("date", request.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate := interfaces.ExchangeRate{
+		ScopeID:      userInfo.UseScope,
+		FromCurrency: request.FromCurrency,
+		ToCurrency:   request.ToCurrency,
+		RateDate:     date,
+		Rate:         request.Rate,
+	}
+	if err := impl.GetModelsService().CurrencyModel.UpsertExchangeRate(c, &rate); err != nil {
+		log.Printf("[SetExchangeRate] Error: %v", err)
+		respondExchangeRateError(c, err, "unable to save exchange rate")
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// ImportExchangeRates
+// @Summary Import exchange rates
+// @Description Record every rate of a CSV file with date, from, to and rate columns. Nothing is saved if any line is invalid.
+// @ID import-exchange-rates
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param file formData file true "CSV file of rates"
+// @Success 200 {object} map[string]int "Number of rates imported"
+// @Failure 400 {object} map[string]string "Invalid rate file"
+// @Failure 500 {object} map[string]string "Unable to import exchange rates"
+// @Router /exchange-rates/import [post]
+func ImportExchangeRates(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ImportExchangeRates] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ImportExchangeRates] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a rate file is required"})
+		return
+	}
+	if header.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "the rate file is larger than 10 MB"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		log.Printf("[ImportExchangeRates] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to read the rate file"})
+		return
+	}
+	defer file.Close()
+
+	rates, err := importer.ParseRates(file)
+	if err != nil {
+		log.Printf("[ImportExchangeRates] Error: %v", err)
+		respondExchangeRateError(c, err, "unable to parse the rate file")
+		return
+	}
+	for i := range rates {
+		rates[i].ScopeID = userInfo.UseScope
+	}
+
+	imported, err := impl.GetModelsService().CurrencyModel.ImportExchangeRates(c, rates)
+	if err != nil {
+		log.Printf("[ImportExchangeRates] Error: %v", err)
+		respondExchangeRateError(c, err, "unable to import exchange rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": imported})
+}
+
+// DeleteExchangeRate
+// @Summary Delete an exchange rate
+// @Description Delete the rate recorded for a currency pair on a day
+// @ID delete-exchange-rate
+// @Param from path string true "From currency"
+// @Param to path string true "To currency"
+// @Param date path string true "Day of the rate (YYYY-MM-DD)"
+// @Success 200 {object} map[string]string "Exchange rate deleted"
+// @Failure 404 {object} map[string]string "Exchange rate not found"
+// @Router /exchange-rates/{from}/{to}/{date} [delete]
+func DeleteExchangeRate(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[DeleteExchangeRate] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[DeleteExchangeRate] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	date, err := parseDate("date", c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := impl.GetModelsService().CurrencyModel.DeleteExchangeRate(c, userInfo.UseScope, c.Param("from"), c.Param("to"), date); err != nil {
+		log.Printf("[DeleteExchangeRate] Error: %v", err)
+		respondExchangeRateError(c, err, "unable to delete exchange rate")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "exchange rate deleted successfully"})
+}
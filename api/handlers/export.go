@@ -0,0 +1,154 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"xspends/exporter"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportTransactions
+// @Summary Export transactions
+// @Description Stream the active scope's transactions as CSV, JSON Lines or OFX, with category, source and tag names. Accepts every transaction list filter; all matching rows are exported unless page and items_per_page are given.
+// @ID export-transactions
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Produce  application/x-ofx
+// @Param format query string true "csv, jsonl or ofx"
+// @Param start_date query string false "Only transactions on or after this time"
+// @Param end_date query string false "Only transactions on or before this time"
+// @Param sort_by query string false "A transaction column, defaults to timestamp"
+// @Param sort_order query string false "ASC or DESC"
+// @Success 200 {file} file "The exported transactions"
+// @Failure 400 {object} map[string]string "Unsupported format"
+// @Router /transactions/export [get]
+func ExportTransactions(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ExportTransactions] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ExportTransactions] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	format := c.Query("format")
+	writer, err := exporter.NewWriter(format, c.Writer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := transactionFilterFromQuery(c, userInfo)
+	if c.Query("page") == "" || c.Query("items_per_page") == "" {
+		filter.Page, filter.ItemsPerPage = 0, 0
+	}
+
+	c.Header("Content-Type", exporter.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="transactions-%s.%s"`, time.Now().Format("2006-01-02"), format))
+	c.Status(http.StatusOK)
+
+	rows := 0
+	err = impl.GetModelsService().TransactionModel.ExportTransactions(c, filter, func(txn interfaces.TransactionExport) error {
+		if err := writer.Write(txn); err != nil {
+			return err
+		}
+		if rows++; rows%500 == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// The status line has been sent; the client sees a truncated download.
+		log.Printf("[ExportTransactions] Error: %v", err)
+		c.Abort()
+	}
+}
+
+// ExportArchive
+// @Summary Export an account archive
+// @Description Stream a ZIP archive with one JSON Lines file per entity the user owns: profile, group memberships, and the sources, categories, tags, transactions, budgets, recurring transactions and import batches of the personal scope.
+// @ID export-archive
+// @Produce  application/zip
+// @Success 200 {file} file "The account archive"
+// @Router /export/archive [get]
+func ExportArchive(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ExportArchive] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ExportArchive] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="xspends-archive-%s.zip"`, time.Now().Format("2006-01-02")))
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	var (
+		current string
+		encoder *json.Encoder
+	)
+	err := impl.GetModelsService().ArchiveModel.ExportArchive(c, userInfo.UserID, userInfo.OwnerScope, func(entity string, record interface{}) error {
+		if entity != current {
+			file, err := archive.Create(entity + ".jsonl")
+			if err != nil {
+				return err
+			}
+			current, encoder = entity, json.NewEncoder(file)
+		}
+		return encoder.Encode(record)
+	})
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		// The status line has been sent; the client sees a truncated download.
+		log.Printf("[ExportArchive] Error: %v", err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportTransactions(t *testing.T) {
+	mockTransactionModel := initTransactionTest(t)
+	defer mockTransactionModel.AssertExpectations(t)
+
+	t.Run("CSV with every matching row", func(t *testing.T) {
+		unpaged := mock.MatchedBy(func(filter interfaces.TransactionFilter) bool {
+			return filter.Page == 0 && filter.ItemsPerPage == 0 && filter.Category == "3" && filter.Scopes[0] == 10
+		})
+		mockTransactionModel.On("ExportTransactions", mock.Anything, unpaged, mock.Anything, mock.AnythingOfType("[]*sql.Tx")).
+			Run(func(args mock.Arguments) {
+				write := args.Get(2).(func(interfaces.TransactionExport) error)
+				_ = write(interfaces.TransactionExport{ID: 11, Timestamp: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Type: "EXPENSE", Amount: interfaces.MoneyFromFloat(4.5), Currency: "USD",
+					Description: "Coffee", Category: "Food", Source: "Wallet", Tags: []string{"work"}})
+			}).
+			Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c := newRecurringTestContext(w, "GET", "/transactions/export?format=csv&category=3", "")
+
+		ExportTransactions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), ".csv")
+		assert.Contains(t, w.Body.String(), "11,2024-01-31T00:00:00Z,EXPENSE,4.50,USD,Coffee,Food,Wallet,,work,")
+	})
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c := newRecurringTestContext(w, "GET", "/transactions/export?format=xlsx", "")
+
+		ExportTransactions(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "supported export formats")
+	})
+}
+
+func TestExportArchive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+	mockArchiveModel := new(xmock.MockArchiveModel)
+	modelsService.ArchiveModel = mockArchiveModel
+	defer mockArchiveModel.AssertExpectations(t)
+
+	mockArchiveModel.On("ExportArchive", mock.Anything, int64(1), int64(10), mock.Anything, mock.AnythingOfType("[]*sql.Tx")).
+		Run(func(args mock.Arguments) {
+			write := args.Get(3).(func(string, interface{}) error)
+			_ = write("profile", interfaces.User{ID: 1, Username: "jane"})
+			_ = write("sources", interfaces.Source{ID: 2, Name: "Wallet"})
+			_ = write("sources", interfaces.Source{ID: 3, Name: "Bank"})
+		}).
+		Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	c := newRecurringTestContext(w, "GET", "/export/archive", "")
+
+	ExportArchive(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, archive.File, 2)
+	assert.Equal(t, "sources.jsonl", archive.File[1].Name)
+	file, _ := archive.File[1].Open()
+	content, _ := io.ReadAll(file)
+	assert.Equal(t, 2, bytes.Count(content, []byte("\n")))
+	assert.Contains(t, string(content), `"name":"Bank"`)
+}
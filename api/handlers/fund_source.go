@@ -31,6 +31,7 @@ import (
 	"xspends/models/interfaces"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 // ListSources retrieves all sources for the authenticated user.
@@ -39,6 +40,8 @@ import (
 // @ID list-sources
 // @Accept  json
 // @Produce  json
+// @Param cursor query string false "Cursor of the page to read; empty for the first page. Switches to cursor paging"
+// @Param limit query int false "Items per page when paging by cursor (default 10, at most 100)"
 // @Success 200 {array} impl.Source
 // @Failure 500 {object} map[string]string "Unable to fetch sources"
 // @Router /sources [get]
@@ -59,6 +62,22 @@ func ListSources(c *gin.Context) {
 	if userInfo.GroupID != 0 {
 		useScope = append(useScope, userInfo.GroupScope)
 	}
+	params, err := cursorParamsFromQuery(c, "sources")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params != nil {
+		sources, page, err := impl.GetModelsService().SourceModel.GetSourcesByCursor(c, useScope, *params)
+		if err != nil {
+			log.Printf("[ListSources] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch sources"})
+			return
+		}
+		c.JSON(http.StatusOK, cursorPageResponse("sources", sources, page))
+		return
+	}
+
 	sources, err := impl.GetModelsService().SourceModel.GetSources(c, useScope)
 	if err != nil {
 		log.Printf("[ListSources] Error: %v", err)
@@ -145,6 +164,10 @@ func CreateSource(c *gin.Context) {
 	newSource.ScopeID = userInfo.UseScope
 	if err := impl.GetModelsService().SourceModel.InsertSource(c, &newSource); err != nil {
 		log.Printf("[CreateSource] Error: %v", err)
+		if errors.Cause(err) == impl.ErrInvalidCurrency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create source"})
 		return
 	}
@@ -184,6 +207,14 @@ func UpdateSource(c *gin.Context) {
 		return
 	}
 
+	if c.Param("id") != "" {
+		sourceID, ok := getSourceID(c)
+		if !ok {
+			return
+		}
+		updatedSource.ID = sourceID
+	}
+
 	// model verifies if the sourceID matches the scope ID and user ID, if not updation fails
 	updatedSource.UserID = userInfo.UserID
 	updatedSource.ScopeID = userInfo.UseScope
@@ -193,18 +224,71 @@ func UpdateSource(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedSource)
+	// Balances are maintained from transactions, so respond with the stored values
+	source, err := impl.GetModelsService().SourceModel.GetSourceByID(c, updatedSource.ID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[UpdateSource] Error: %v", err)
+		c.JSON(http.StatusOK, updatedSource)
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
+
+// ReconcileSource
+// @Summary Reconcile a source balance
+// @Description Recompute the balance of a source from its opening balance and transaction history, store it and report any drift
+// @ID reconcile-source
+// @Produce  json
+// @Param id path int true "Source ID"
+// @Success 200 {object} interfaces.SourceReconciliation
+// @Failure 400 {object} map[string]string "Invalid source ID"
+// @Failure 404 {object} map[string]string "Source not found"
+// @Router /sources/{id}/reconcile [post]
+func ReconcileSource(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ReconcileSource] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ReconcileSource] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	sourceID, ok := getSourceID(c)
+	if !ok {
+		log.Printf("[ReconcileSource]: Missing source ID")
+		return
+	}
+
+	reconciliation, err := impl.GetModelsService().SourceModel.ReconcileBalance(c, sourceID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[ReconcileSource] Error: %v", err)
+		if errors.Cause(err) == impl.ErrSourceNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Source not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile source"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reconciliation)
 }
 
 // DeleteSource
 // @Summary Delete a specific source
-// @Description Delete a specific source by its ID
+// @Description Move a source to the trash, from where it can be restored until it is purged. A source still used by transactions is only deleted with reassign_to, which moves them and their balance to another source of the same currency first
 // @ID delete-source
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Source ID"
+// @Param reassign_to query int false "Source that receives the transactions of the deleted one"
 // @Success 200 {object} map[string]string "message: Source deleted successfully"
-// @Failure 400 {object} map[string]string "Invalid source ID"
+// @Failure 400 {object} map[string]string "Invalid source ID or reassign_to"
+// @Failure 409 {object} map[string]interface{} "Source in use, with the number of transactions affected"
 // @Failure 500 {object} map[string]string "Failed to delete source"
 // @Router /sources/{id} [delete]
 func DeleteSource(c *gin.Context) {
@@ -229,9 +313,13 @@ func DeleteSource(c *gin.Context) {
 		log.Printf("[DeleteSource]: Missing source ID")
 		return
 	}
-	if err := impl.GetModelsService().SourceModel.DeleteSource(c, sourceID, useScope); err != nil {
+	reassignTo, ok := getReassignTo(c)
+	if !ok {
+		return
+	}
+	if err := impl.GetModelsService().SourceModel.DeleteSource(c, sourceID, reassignTo, useScope); err != nil {
 		log.Printf("[DeleteSource] Error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete source"})
+		respondDeleteError(c, err, "Failed to delete source")
 		return
 	}
 
@@ -9,11 +9,13 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"xspends/models/impl"
 	"xspends/models/interfaces"
 	xmock "xspends/models/mock"
 	"xspends/testutils"
 
 	"github.com/gin-gonic/gin"
+	pkgerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -497,7 +499,7 @@ func TestDeleteSource(t *testing.T) {
 		{
 			name: "Successful deletion",
 			setupMock: func() {
-				mockSourceModel.On("DeleteSource", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int64"), mock.AnythingOfType("[]int64"), mock.Anything).Return(nil).Once()
+				mockSourceModel.On("DeleteSource", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("[]int64"), mock.Anything).Return(nil).Once()
 
 			},
 			userID:         "1",
@@ -531,7 +533,7 @@ func TestDeleteSource(t *testing.T) {
 		{
 			name: "Error during source deletion",
 			setupMock: func() {
-				mockSourceModel.On("DeleteSource", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int64"), mock.AnythingOfType("[]int64"), mock.Anything).Return(errors.New("Failed to delete source")).Once()
+				mockSourceModel.On("DeleteSource", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("[]int64"), mock.Anything).Return(errors.New("Failed to delete source")).Once()
 			},
 			userID:         "1",
 			sourceID:       "1",
@@ -573,3 +575,85 @@ func TestDeleteSource(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteSourceInUse(t *testing.T) {
+	mockSourceModel := initSourceTest(t)
+	defer mockSourceModel.AssertExpectations(t)
+
+	mockSourceModel.On("DeleteSource", mock.Anything, int64(1), int64(2), []int64{10}, mock.Anything).
+		Return(pkgerrors.Wrap(impl.ErrInvalidReassignTarget, "source 2 holds USD, not EUR")).Once()
+	w := httptest.NewRecorder()
+	c := newRecurringTestContext(w, "DELETE", "/sources/1?reassign_to=2", "")
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+	DeleteSource(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "holds USD")
+
+	mockSourceModel.On("DeleteSource", mock.Anything, int64(1), int64(0), []int64{10}, mock.Anything).
+		Return(&impl.InUseError{Item: "source", Transactions: 5}).Once()
+	w = httptest.NewRecorder()
+	c = newRecurringTestContext(w, "DELETE", "/sources/1", "")
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+	DeleteSource(c)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"error": "source is used by 5 transactions and 0 recurring transactions; pass reassign_to to move them", "transactions": 5, "recurring_transactions": 0}`, w.Body.String())
+}
+
+func TestReconcileSource(t *testing.T) {
+	mockSourceModel := initSourceTest(t)
+	defer mockSourceModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		sourceID       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Drift reported and corrected",
+			setupMock: func() {
+				mockSourceModel.On("ReconcileBalance", mock.Anything, int64(1), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).
+					Return(&interfaces.SourceReconciliation{SourceID: 1, StoredBalance: interfaces.MoneyFromFloat(120), ComputedBalance: interfaces.MoneyFromFloat(100), Drift: interfaces.MoneyFromFloat(20), Corrected: true}, nil).Once()
+			},
+			sourceID:       "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"source_id":1,"stored_balance":120.00,"computed_balance":100.00,"drift":20.00,"corrected":true}`,
+		},
+		{
+			name: "Source not found",
+			setupMock: func() {
+				mockSourceModel.On("ReconcileBalance", mock.Anything, int64(2), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).
+					Return((*interfaces.SourceReconciliation)(nil), impl.ErrSourceNotFound).Once()
+			},
+			sourceID:       "2",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"Source not found"}`,
+		},
+		{
+			name: "Reconciliation fails",
+			setupMock: func() {
+				mockSourceModel.On("ReconcileBalance", mock.Anything, int64(3), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).
+					Return((*interfaces.SourceReconciliation)(nil), errors.New("db error")).Once()
+			},
+			sourceID:       "3",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"Failed to reconcile source"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", fmt.Sprintf("/sources/%v/reconcile", tc.sourceID), nil)
+			c.Params = gin.Params{gin.Param{Key: "id", Value: tc.sourceID}}
+			c.Set("scopeInfo", ScopeInfo{UserID: 1, OwnerScope: 10, UseScope: 10, Scopes: []int64{10}, Role: "write"})
+
+			tc.setupMock()
+			ReconcileSource(c)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,302 @@
+/*
+MIT License
+
+# Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// InvitationRequest identifies the user to invite by username or email, along with the offered role.
+type InvitationRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+func getInvitationID(c *gin.Context, param string) (int64, bool) {
+	invitationID, err := strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil {
+		log.Printf("[getInvitationID] Error: invalid invitation ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID format"})
+		return 0, false
+	}
+	return invitationID, true
+}
+
+// respondInvitationError maps invitation model errors to HTTP responses.
+func respondInvitationError(c *gin.Context, funcName string, err error) {
+	log.Printf("[%s] Error: %v", funcName, err)
+	switch errors.Cause(err) {
+	case impl.ErrInvitationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+	case impl.ErrInvitationNotPending, impl.ErrInvitationExists:
+		c.JSON(http.StatusConflict, gin.H{"error": errors.Cause(err).Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process invitation"})
+	}
+}
+
+// InviteToGroup
+// @Summary Invite a user to a group
+// @Description Invite a user (by username or email) to join a group owned by the current user. The user is added only after accepting.
+// @ID invite-to-group
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param invitation body InvitationRequest true "User to invite and role"
+// @Success 201 {object} interfaces.GroupInvitation
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 403 {object} map[string]string "Unauthorized to invite users to this group"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "User already invited or a member"
+// @Failure 500 {object} map[string]string "Failed to look up user or check group membership"
+// @Router /groups/{id}/invitations [post]
+func InviteToGroup(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request InvitationRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if request.Role != impl.RoleView && request.Role != impl.RoleWrite {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role specified"})
+		return
+	}
+	if strings.TrimSpace(request.Username) == "" && strings.TrimSpace(request.Email) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username or email is required"})
+		return
+	}
+
+	group, ok := getOwnedGroup(c, currentUserID, "invite users to this group")
+	if !ok {
+		return
+	}
+
+	var invitee *interfaces.User
+	var err error
+	if request.Username != "" {
+		invitee, err = impl.GetModelsService().UserModel.GetUserByUsername(c, strings.TrimSpace(request.Username))
+	} else {
+		invitee, err = impl.GetModelsService().UserModel.GetUserByEmail(c, strings.TrimSpace(request.Email))
+	}
+	if err != nil {
+		log.Printf("[InviteToGroup] Error: %v", err)
+		if cause := errors.Cause(err); cause == impl.ErrUserNotFound || cause == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up user"})
+		return
+	}
+	if invitee == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	_, err = impl.GetModelsService().UserScopeModel.GetUserScope(c, invitee.ID, group.ScopeID)
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this group"})
+		return
+	}
+	if errors.Cause(err) != impl.ErrUserScopeNotFound {
+		log.Printf("[InviteToGroup] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group membership"})
+		return
+	}
+
+	invitation := interfaces.GroupInvitation{
+		GroupID:   group.GroupID,
+		GroupName: group.GroupName,
+		InviterID: currentUserID,
+		InviteeID: invitee.ID,
+		Role:      request.Role,
+	}
+	if err := impl.GetModelsService().GroupInvitationModel.InsertInvitation(c, &invitation); err != nil {
+		respondInvitationError(c, "InviteToGroup", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// ListGroupInvitations
+// @Summary List invitations of a group
+// @Description List the pending invitations of a group owned by the current user
+// @ID list-group-invitations
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {array} interfaces.GroupInvitation
+// @Failure 403 {object} map[string]string "Unauthorized to view invitations of this group"
+// @Router /groups/{id}/invitations [get]
+func ListGroupInvitations(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	group, ok := getOwnedGroup(c, currentUserID, "view invitations of this group")
+	if !ok {
+		return
+	}
+
+	invitations, err := impl.GetModelsService().GroupInvitationModel.GetInvitationsByGroup(c, group.GroupID, impl.InvitationStatusPending)
+	if err != nil {
+		log.Printf("[ListGroupInvitations] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// CancelGroupInvitation
+// @Summary Cancel a pending invitation
+// @Description Cancel a pending invitation of a group owned by the current user
+// @ID cancel-group-invitation
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param invitationID path int true "Invitation ID"
+// @Success 200 {object} map[string]string "Invitation cancelled successfully"
+// @Failure 404 {object} map[string]string "Invitation not found"
+// @Failure 409 {object} map[string]string "Invitation is no longer pending"
+// @Router /groups/{id}/invitations/{invitationID} [delete]
+func CancelGroupInvitation(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	invitationID, ok := getInvitationID(c, "invitationID")
+	if !ok {
+		return
+	}
+
+	group, ok := getOwnedGroup(c, currentUserID, "cancel invitations of this group")
+	if !ok {
+		return
+	}
+
+	if err := impl.GetModelsService().GroupInvitationModel.CancelInvitation(c, invitationID, group.GroupID); err != nil {
+		respondInvitationError(c, "CancelGroupInvitation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation cancelled successfully"})
+}
+
+// ListInvitations
+// @Summary List my invitations
+// @Description List the pending group invitations addressed to the current user
+// @ID list-invitations
+// @Produce  json
+// @Success 200 {array} interfaces.GroupInvitation
+// @Failure 500 {object} map[string]string "Unable to fetch invitations"
+// @Router /invitations [get]
+func ListInvitations(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := impl.GetModelsService().GroupInvitationModel.GetInvitationsByInvitee(c, currentUserID, impl.InvitationStatusPending)
+	if err != nil {
+		log.Printf("[ListInvitations] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// AcceptInvitation
+// @Summary Accept an invitation
+// @Description Accept a pending invitation; the current user joins the group with the invited role
+// @ID accept-invitation
+// @Produce  json
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} interfaces.GroupInvitation
+// @Failure 404 {object} map[string]string "Invitation not found"
+// @Failure 409 {object} map[string]string "Invitation is no longer pending"
+// @Router /invitations/{id}/accept [post]
+func AcceptInvitation(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	invitationID, ok := getInvitationID(c, "id")
+	if !ok {
+		return
+	}
+
+	invitation, err := impl.GetModelsService().GroupInvitationModel.AcceptInvitation(c, invitationID, currentUserID)
+	if err != nil {
+		respondInvitationError(c, "AcceptInvitation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+// DeclineInvitation
+// @Summary Decline an invitation
+// @Description Decline a pending invitation addressed to the current user
+// @ID decline-invitation
+// @Produce  json
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} map[string]string "Invitation declined successfully"
+// @Failure 404 {object} map[string]string "Invitation not found"
+// @Failure 409 {object} map[string]string "Invitation is no longer pending"
+// @Router /invitations/{id}/decline [post]
+func DeclineInvitation(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	invitationID, ok := getInvitationID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := impl.GetModelsService().GroupInvitationModel.DeclineInvitation(c, invitationID, currentUserID); err != nil {
+		respondInvitationError(c, "DeclineInvitation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined successfully"})
+}
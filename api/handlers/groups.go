@@ -24,27 +24,29 @@ SOFTWARE.
 package handlers
 
 import (
+	"database/sql"
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 	"xspends/models/impl"
 	"xspends/models/interfaces"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 type GroupObject struct {
 	GroupName   string           `json:"group_name"`
 	Description string           `json:"description"`
+	Icon        string           `json:"icon"`
+	Currency    string           `json:"currency"`
+	Template    string           `json:"template"`
 	UserRoles   map[int64]string `json:"user_roles"`
 }
 
-// AddToGroupRequest represents the request payload for adding a user to a group.
-type AddToGroupRequest struct {
-	GroupID int64  `json:"group_id"`
-	UserID  int64  `json:"user_id"`
-	Role    string `json:"role"`
+// MemberRoleRequest represents the request payload for changing a member's role.
+type MemberRoleRequest struct {
+	Role string `json:"role"`
 }
 
 func getGroupID(c *gin.Context) (int64, bool) {
@@ -65,142 +67,261 @@ func getGroupID(c *gin.Context) (int64, bool) {
 	return groupID, true
 }
 
-// TODO: Cleanup inline structs
+func getMemberID(c *gin.Context) (int64, bool) {
+	memberID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+	if err != nil {
+		log.Printf("[getMemberID] Error: invalid user ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		return 0, false
+	}
+	return memberID, true
+}
 
-func CreateGroup(c *gin.Context) {
-	userID, ok := getUserFromContext(c)
+// getOwnedGroup fetches the group in the path and verifies that the current user owns it.
+func getOwnedGroup(c *gin.Context, currentUserID int64, action string) (*interfaces.Group, bool) {
+	groupID, ok := getGroupID(c)
 	if !ok {
-		log.Printf("[CreateGroup] Error: %v", "Missing user information")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user information"})
-		return
+		return nil, false
 	}
 
-	var request GroupObject
-	if err := c.BindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	group, err := impl.GetModelsService().GroupModel.GetGroupByID(c, groupID, currentUserID)
+	if err != nil {
+		log.Printf("[getOwnedGroup] Error: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return nil, false
+	}
+	if group.OwnerID != currentUserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to " + action})
+		return nil, false
+	}
+	return group, true
+}
+
+// ListGroups
+// @Summary List the groups of the current user
+// @Description Get every group the current user belongs to, along with their role in it
+// @ID list-groups
+// @Produce  json
+// @Success 200 {array} interfaces.GroupMembership
+// @Failure 500 {object} map[string]string "Unable to fetch groups"
+// @Router /groups [get]
+func ListGroups(c *gin.Context) {
+	userID, ok := getUserFromContext(c)
+	if !ok {
+		log.Printf("[ListGroups] Error: %v", "Missing user information")
 		return
 	}
 
-	// Create a new scope for the group
-	scopeID, err := impl.GetModelsService().ScopeModel.CreateScope(c, impl.ScopeTypeGroup)
+	groups, err := impl.GetModelsService().GroupModel.GetGroupsByUser(c, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create scope"})
+		log.Printf("[ListGroups] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch groups"})
 		return
 	}
 
-	// Create the group
-	group := interfaces.Group{
-		OwnerID:     userID, // Assuming a function to extract userID from context
-		ScopeID:     scopeID,
-		GroupName:   request.GroupName,
-		Description: request.Description,
-		//add missing fields
-	}
-	if err := impl.GetModelsService().GroupModel.CreateGroup(c, &group, nil); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+	c.JSON(http.StatusOK, groups)
+}
+
+// CreateGroup
+// @Summary Create a new group
+// @Description Create a group owned by the current user. Users listed in user_roles receive invitations. The group's scope is seeded with the optional template.
+// @ID create-group
+// @Accept  json
+// @Produce  json
+// @Param group body GroupObject true "Group info for creation"
+// @Success 201 {object} interfaces.Group
+// @Failure 400 {object} map[string]string "Invalid request body, unknown template or unknown user to invite"
+// @Failure 500 {object} map[string]string "Failed to create group"
+// @Router /groups [post]
+func CreateGroup(c *gin.Context) {
+	userID, ok := getUserFromContext(c)
+	if !ok {
+		log.Printf("[CreateGroup] Error: %v", "Missing user information")
 		return
 	}
 
-	// Assign roles to users including the owner
-	if err := impl.GetModelsService().UserScopeModel.UpsertUserScope(c, userID, scopeID, impl.RoleOwner); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign roles"})
+	var request GroupObject
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+
+	//additional check to ensure user is not assigned the same role twice (or role overwritten wrongly)
 	for user, role := range request.UserRoles {
-		//additional check to ensure user is not assigned the same role twice (or role overwritten wrongly)
 		if user == userID {
 			log.Printf("[CreateGroup] Warning: %v", "Owner cannot be assigned another role")
 			continue
 		}
-		//if invalid role string, skip
+		//if invalid role string, reject before anything is created
 		if role != impl.RoleView && role != impl.RoleWrite {
 			log.Printf("[CreateGroup] Warning: %v", "Role can only be view or write")
 			c.JSON(http.StatusNotAcceptable, gin.H{"error": "Invalid role: " + role})
 			return
 		}
-		if err := impl.GetModelsService().UserScopeModel.UpsertUserScope(c, user, scopeID, role); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign roles"})
+		exists, err := impl.GetModelsService().UserModel.UserIDExists(c, user)
+		if err != nil {
+			log.Printf("[CreateGroup] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown user to invite: " + strconv.FormatInt(user, 10)})
+			return
+		}
+	}
+
+	if request.Template != "" {
+		if _, err := impl.GetModelsService().TemplateModel.GetTemplate(request.Template); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// The model creates the group's scope, links the owner to it and seeds it with the template
+	group := interfaces.Group{
+		OwnerID:     userID,
+		GroupName:   request.GroupName,
+		Description: request.Description,
+		Icon:        request.Icon,
+		Currency:    request.Currency,
+		Template:    request.Template,
+	}
+	// Other users only join once they accept their invitation; the group is not created without them
+	err := impl.RunInTx(c, func(tx *sql.Tx) error {
+		if err := impl.GetModelsService().GroupModel.CreateGroup(c, &group, nil, tx); err != nil {
+			return err
+		}
+		for user, role := range request.UserRoles {
+			if user == userID {
+				continue
+			}
+			invitation := interfaces.GroupInvitation{GroupID: group.GroupID, InviterID: userID, InviteeID: user, Role: role}
+			if err := impl.GetModelsService().GroupInvitationModel.InsertInvitation(c, &invitation, tx); err != nil {
+				return errors.Wrapf(err, "inviting user %d failed", user)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[CreateGroup] Error: %v", err)
+		if errors.Cause(err) == impl.ErrInvalidCurrency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		return
 	}
-	//TODO: Evaluate if we should pass scopeID
+
 	c.JSON(http.StatusCreated, group)
 }
 
-func AddToGroup(c *gin.Context) {
-	// Step 1: Authenticate and get current userID
+// GetGroup
+// @Summary Get a specific group
+// @Description Get a group the current user belongs to
+// @ID get-group
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {object} interfaces.Group
+// @Failure 404 {object} map[string]string "Group not found"
+// @Router /groups/{id} [get]
+func GetGroup(c *gin.Context) {
 	currentUserID, ok := getUserFromContext(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user information"})
 		return
 	}
 
 	groupID, ok := getGroupID(c)
 	if !ok {
-		log.Printf("[AddToGroup] Error: %v", "invalid group ID format")
+		log.Printf("[GetGroup] Error: %v", "invalid group ID format")
 		return
 	}
 
-	// Step 2: Fetch the request payload
-	var request interfaces.UserScope
-	if err := c.BindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	group, err := impl.GetModelsService().GroupModel.GetGroupByID(c, groupID, currentUserID)
+	if err != nil || !impl.GetModelsService().UserScopeModel.ValidateUserScope(c, currentUserID, group.ScopeID, impl.RoleView) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
 		return
 	}
 
-	// Step 3: Verify if the current user is the owner of the requested GroupID
-	group, err := impl.GetModelsService().GroupModel.GetGroupByID(c, groupID, currentUserID)
-	if err != nil || group.OwnerID != currentUserID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to add members to this group"})
+	c.JSON(http.StatusOK, group)
+}
+
+// ListGroupMembers
+// @Summary List the members of a group
+// @Description Get every user linked to the group, with their role
+// @ID list-group-members
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {array} interfaces.GroupMember
+// @Failure 404 {object} map[string]string "Group not found"
+// @Router /groups/{id}/members [get]
+func ListGroupMembers(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
 		return
 	}
 
-	// Step 4: Validate role type
-	if request.Role != impl.RoleView && request.Role != impl.RoleWrite {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role specified"})
+	groupID, ok := getGroupID(c)
+	if !ok {
+		log.Printf("[ListGroupMembers] Error: %v", "invalid group ID format")
 		return
 	}
 
-	// Step 5: Add the userID tuple to the userScope table
-	if err := impl.GetModelsService().UserScopeModel.UpsertUserScope(c, request.UserID, group.ScopeID, request.Role); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add user to group"})
+	members, err := impl.GetModelsService().GroupModel.GetGroupMembers(c, groupID, currentUserID)
+	if err != nil {
+		log.Printf("[ListGroupMembers] Error: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User added to group successfully"})
+	c.JSON(http.StatusOK, members)
 }
 
+// RemoveFromGroup
+// @Summary Remove a member from a group
+// @Description The owner can remove any other member; members can remove themselves (leave the group)
+// @ID remove-group-member
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param userID path int true "User ID"
+// @Success 200 {object} map[string]string "User removed from group successfully"
+// @Failure 403 {object} map[string]string "Unauthorized to remove members from this group"
+// @Router /groups/{id}/members/{userID} [delete]
 func RemoveFromGroup(c *gin.Context) {
 	// Step 1: Authenticate and get current userID
 	currentUserID, ok := getUserFromContext(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user information"})
 		return
 	}
 
-	// Step 2: Fetch the request payload
 	groupID, ok := getGroupID(c)
 	if !ok {
 		log.Printf("[RemoveFromGroup] Error: %v", "invalid group ID format")
 		return
 	}
 
-	// Step 2: Fetch the request payload
-	var request interfaces.UserScope
-	if err := c.BindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	memberID, ok := getMemberID(c)
+	if !ok {
 		return
 	}
 
-	// Step 3: Verify if the current user is the owner of the requested GroupID
+	// Step 2: Only the owner may remove others, and the owner cannot leave their own group
 	group, err := impl.GetModelsService().GroupModel.GetGroupByID(c, groupID, currentUserID)
-	if err != nil || group.OwnerID != currentUserID {
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+	if memberID == group.OwnerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The owner cannot be removed from the group"})
+		return
+	}
+	if group.OwnerID != currentUserID && memberID != currentUserID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to remove members from this group"})
 		return
 	}
 
-	// Step 4: Remove the userID tuple from the userScope table
-	if err := impl.GetModelsService().UserScopeModel.DeleteUserScope(c, request.UserID, group.ScopeID); err != nil {
+	// Step 3: Remove the userID tuple from the userScope table
+	if err := impl.GetModelsService().UserScopeModel.DeleteUserScope(c, memberID, group.ScopeID); err != nil {
+		log.Printf("[RemoveFromGroup] Error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user from group"})
 		return
 	}
@@ -208,54 +329,73 @@ func RemoveFromGroup(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User removed from group successfully"})
 }
 
+// EditUserInGroup
+// @Summary Change a member's role
+// @Description Change the role (view or write) of an existing group member
+// @ID edit-group-member
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param userID path int true "User ID"
+// @Param role body MemberRoleRequest true "New role"
+// @Success 200 {object} map[string]string "User role updated successfully in group"
+// @Failure 400 {object} map[string]string "Invalid role specified"
+// @Failure 403 {object} map[string]string "Unauthorized to edit member roles in this group"
+// @Router /groups/{id}/members/{userID} [put]
 func EditUserInGroup(c *gin.Context) {
 	// Step 1: Authenticate and get current userID
 	currentUserID, ok := getUserFromContext(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user information"})
 		return
 	}
 
-	groupID, ok := getGroupID(c)
+	memberID, ok := getMemberID(c)
 	if !ok {
-		log.Printf("[EditUserInGroup] Error: %v", "invalid group ID format")
 		return
 	}
 
 	// Step 2: Fetch the request payload
-	var request interfaces.UserScope
+	var request MemberRoleRequest
 	if err := c.BindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
 	// Step 3: Verify if the current user is the owner of the requested GroupID
-	group, err := impl.GetModelsService().GroupModel.GetGroupByID(c, groupID, currentUserID)
-	if err != nil || group.OwnerID != currentUserID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to edit member roles in this group"})
-		return
-	}
-
-	// Step 4: Validate role type
-	if request.Role != impl.RoleView && request.Role != impl.RoleWrite {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role specified"})
+	group, ok := getOwnedGroup(c, currentUserID, "edit member roles in this group")
+	if !ok {
 		return
 	}
 
 	// Prevent the owner from downgrading their own role
-	if request.UserID == currentUserID {
+	if memberID == currentUserID {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Owners cannot downgrade their own role"})
 		return
 	}
-	// Prevent the owner from downgrading their own role
+	// Prevent the owner role from being handed out
 	if request.Role == impl.RoleOwner {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot assign Owner role to another user"})
 		return
 	}
 
-	// TODO: This could potential insert a new user into the group if the user is not already in the group
-	// Step 5: Update the user's role in the group
-	if err := impl.GetModelsService().UserScopeModel.UpsertUserScope(c, request.UserID, group.ScopeID, request.Role); err != nil {
+	// Step 4: Validate role type
+	if request.Role != impl.RoleView && request.Role != impl.RoleWrite {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role specified"})
+		return
+	}
+
+	// Step 5: Only existing members can be edited; new members join through invitations
+	if _, err := impl.GetModelsService().UserScopeModel.GetUserScope(c, memberID, group.ScopeID); err != nil {
+		if errors.Cause(err) == impl.ErrUserScopeNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+			return
+		}
+		log.Printf("[EditUserInGroup] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to edit user role in group"})
+		return
+	}
+	if err := impl.GetModelsService().UserScopeModel.UpsertUserScope(c, memberID, group.ScopeID, request.Role); err != nil {
+		log.Printf("[EditUserInGroup] Error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to edit user role in group"})
 		return
 	}
@@ -263,11 +403,22 @@ func EditUserInGroup(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User role updated successfully in group"})
 }
 
+// UpdateGroup
+// @Summary Update a group
+// @Description Update the name, description, icon or base currency of a group owned by the current user
+// @ID update-group
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param group body interfaces.Group true "Group info for update"
+// @Success 200 {object} interfaces.Group
+// @Failure 403 {object} map[string]string "Unauthorized to update this group"
+// @Failure 404 {object} map[string]string "Group not found"
+// @Router /groups/{id} [put]
 func UpdateGroup(c *gin.Context) {
 	// Step 1: Authenticate and get current currentUserID
 	currentUserID, ok := getUserFromContext(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user information"})
 		return
 	}
 
@@ -279,49 +430,63 @@ func UpdateGroup(c *gin.Context) {
 	}
 
 	// Step 3: Fetch the group to ensure it exists and the current user is the owner
-	group, err := impl.GetModelsService().GroupModel.GetGroupByID(c, request.GroupID, currentUserID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
-		return
-	}
-	if group.OwnerID != currentUserID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update this group"})
+	group, ok := getOwnedGroup(c, currentUserID, "update this group")
+	if !ok {
 		return
 	}
 
 	// Step 4: Update the group details
-	request.CreatedAt = group.CreatedAt
-	request.UpdatedAt = time.Now()
-	request.OwnerID = currentUserID
-	request.ScopeID = group.ScopeID
-
-	//TODO: Implement updateGroup method
+	if request.GroupName != "" {
+		group.GroupName = request.GroupName
+	}
+	if request.Description != "" {
+		group.Description = request.Description
+	}
+	if request.Icon != "" {
+		group.Icon = request.Icon
+	}
+	if request.Currency != "" {
+		group.Currency = request.Currency
+	}
 
 	if err := impl.GetModelsService().GroupModel.UpdateGroup(c, group, currentUserID); err != nil {
+		log.Printf("[UpdateGroup] Error: %v", err)
+		if errors.Cause(err) == impl.ErrInvalidCurrency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update group"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Group updated successfully", "group": group})
+	c.JSON(http.StatusOK, group)
 }
 
+// DeleteGroup
+// @Summary Delete a group
+// @Description Delete a group owned by the current user, along with its memberships and invitations
+// @ID delete-group
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {object} map[string]string "Group deleted successfully"
+// @Failure 403 {object} map[string]string "Unauthorized to delete this group"
+// @Router /groups/{id} [delete]
 func DeleteGroup(c *gin.Context) {
 	// Step 1: Authenticate and get current userID
 	userID, ok := getUserFromContext(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user information"})
 		return
 	}
 
-	// Step 2: Fetch the groupID from the URL
-	groupID, ok := getGroupID(c)
+	// Step 2: Ensure the group exists and belongs to the current user
+	group, ok := getOwnedGroup(c, userID, "delete this group")
 	if !ok {
-		log.Printf("[DeleteGroup] Error: %v", "invalid group ID format")
 		return
 	}
 
 	// Step 3: Delete the group
-	if err := impl.GetModelsService().GroupModel.DeleteGroup(c, groupID, userID); err != nil {
+	if err := impl.GetModelsService().GroupModel.DeleteGroup(c, group.GroupID, userID); err != nil {
+		log.Printf("[DeleteGroup] Error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group"})
 		return
 	}
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type groupTestMocks struct {
+	group      *xmock.MockGroupModel
+	invitation *xmock.MockGroupInvitationModel
+	user       *xmock.MockUserModel
+	userScope  *xmock.MockUserScopeModel
+}
+
+func initGroupTest(t *testing.T) *groupTestMocks {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	t.Cleanup(tearDown)
+
+	mocks := &groupTestMocks{
+		group:      new(xmock.MockGroupModel),
+		invitation: new(xmock.MockGroupInvitationModel),
+		user:       new(xmock.MockUserModel),
+		userScope:  new(xmock.MockUserScopeModel),
+	}
+	modelsService.GroupModel = mocks.group
+	modelsService.GroupInvitationModel = mocks.invitation
+	modelsService.UserModel = mocks.user
+	modelsService.UserScopeModel = mocks.userScope
+	return mocks
+}
+
+func newGroupTestContext(method, body string, userID int64, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/dummy-url", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	if userID != 0 {
+		c.Set("userID", userID)
+	}
+	return c, w
+}
+
+func TestListGroups(t *testing.T) {
+	mocks := initGroupTest(t)
+	defer mocks.group.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		userID         int64
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Successful retrieval",
+			setupMock: func() {
+				mocks.group.On("GetGroupsByUser", mock.Anything, int64(1), mock.AnythingOfType("[]*sql.Tx")).
+					Return([]interfaces.GroupMembership{{Group: interfaces.Group{GroupID: 10, GroupName: "Family"}, Role: impl.RoleWrite}}, nil).Once()
+			},
+			userID:         1,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"role":"write"`,
+		},
+		{
+			name: "Internal server error",
+			setupMock: func() {
+				mocks.group.On("GetGroupsByUser", mock.Anything, int64(1), mock.AnythingOfType("[]*sql.Tx")).
+					Return([]interfaces.GroupMembership(nil), errors.New("db error")).Once()
+			},
+			userID:         1,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"unable to fetch groups"}`,
+		},
+		{
+			name:           "Unauthorized access",
+			setupMock:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"user not authenticated"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, w := newGroupTestContext("GET", "", tc.userID, nil)
+			tc.setupMock()
+			ListGroups(c)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+// useUserScopeDB replaces the mocked user scope model with the real one, reading from the returned
+// sqlmock, for tests of the role checks themselves. The mock is put back when the test ends.
+func useUserScopeDB(t *testing.T) sqlmock.Sqlmock {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %s", err)
+	}
+	dbService, userScopeModel := impl.GetModelsService().DBService, impl.GetModelsService().UserScopeModel
+	t.Cleanup(func() {
+		impl.GetModelsService().DBService, impl.GetModelsService().UserScopeModel = dbService, userScopeModel
+		db.Close()
+	})
+	*impl.GetQueryBuilder() = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Question)
+	impl.GetModelsService().DBService = &impl.DBService{Executor: db}
+	impl.GetModelsService().UserScopeModel = impl.NewUserScopeModel()
+	return sqlMock
+}
+
+// expectRoleCheck answers a role check like the database would for a member holding memberRole:
+// the row comes back only if the query asks for that role.
+func expectRoleCheck(sqlMock sqlmock.Sqlmock, userID, scopeID int64, memberRole string, queriedRoles ...string) {
+	args := []driver.Value{scopeID, userID}
+	rows := sqlmock.NewRows([]string{"user_id", "scope_id", "role"})
+	for _, role := range queriedRoles {
+		args = append(args, role)
+		if role == memberRole {
+			rows.AddRow(userID, scopeID, role)
+		}
+	}
+	sqlMock.ExpectQuery("^SELECT user_id, scope_id, role FROM user_scopes").WithArgs(args...).WillReturnRows(rows)
+}
+
+func TestGetGroup(t *testing.T) {
+	mocks := initGroupTest(t)
+	group := &interfaces.Group{GroupID: 10, OwnerID: 1, ScopeID: 100, GroupName: "Flat"}
+
+	// Every role can read the group
+	for _, role := range []string{impl.RoleOwner, impl.RoleWrite, impl.RoleView} {
+		t.Run(role, func(t *testing.T) {
+			sqlMock := useUserScopeDB(t)
+			expectRoleCheck(sqlMock, 2, 100, role, impl.RoleOwner, impl.RoleView, impl.RoleWrite)
+			mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(2), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+
+			c, w := newGroupTestContext("GET", "", 2, gin.Params{{Key: "id", Value: "10"}})
+			GetGroup(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"group_name":"Flat"`)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateGroup(t *testing.T) {
+	mocks := initGroupTest(t)
+	defer mocks.group.AssertExpectations(t)
+	defer mocks.invitation.AssertExpectations(t)
+	defer mocks.user.AssertExpectations(t)
+	templates := impl.GetModelsService().TemplateModel.(*xmock.MockTemplateModel)
+	defer templates.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "With a template",
+			setupMock: func() {
+				templates.On("GetTemplate", "household").Return(&interfaces.SeedTemplate{Name: "household"}, nil).Once()
+				mocks.group.On("CreateGroup", mock.Anything, mock.MatchedBy(func(g *interfaces.Group) bool {
+					return g.OwnerID == 1 && g.GroupName == "Family" && g.Template == "household"
+				}), []int64(nil), mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			body:           `{"group_name":"Family","template":"household"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"template":"household"`,
+		},
+		{
+			name: "Without a template",
+			setupMock: func() {
+				mocks.group.On("CreateGroup", mock.Anything, mock.MatchedBy(func(g *interfaces.Group) bool {
+					return g.GroupName == "Trip" && g.Template == ""
+				}), []int64(nil), mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			body:           `{"group_name":"Trip"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"group_name":"Trip"`,
+		},
+		{
+			name: "Invites members with the group",
+			setupMock: func() {
+				mocks.user.On("UserIDExists", mock.Anything, int64(2), mock.AnythingOfType("[]*sql.Tx")).Return(true, nil).Once()
+				mocks.group.On("CreateGroup", mock.Anything, mock.MatchedBy(func(g *interfaces.Group) bool {
+					return g.GroupName == "Flat"
+				}), []int64(nil), mock.AnythingOfType("[]*sql.Tx")).Run(func(args mock.Arguments) {
+					args.Get(1).(*interfaces.Group).GroupID = 10
+				}).Return(nil).Once()
+				mocks.invitation.On("InsertInvitation", mock.Anything, mock.MatchedBy(func(i *interfaces.GroupInvitation) bool {
+					return i.GroupID == 10 && i.InviterID == 1 && i.InviteeID == 2 && i.Role == impl.RoleWrite
+				}), mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			body:           `{"group_name":"Flat","user_roles":{"2":"write"}}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"group_name":"Flat"`,
+		},
+		{
+			name: "A failed invitation fails the group",
+			setupMock: func() {
+				mocks.user.On("UserIDExists", mock.Anything, int64(3), mock.AnythingOfType("[]*sql.Tx")).Return(true, nil).Once()
+				mocks.group.On("CreateGroup", mock.Anything, mock.MatchedBy(func(g *interfaces.Group) bool {
+					return g.GroupName == "Club"
+				}), []int64(nil), mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+				mocks.invitation.On("InsertInvitation", mock.Anything, mock.MatchedBy(func(i *interfaces.GroupInvitation) bool {
+					return i.InviteeID == 3
+				}), mock.AnythingOfType("[]*sql.Tx")).Return(errors.New("connection lost")).Once()
+			},
+			body:           `{"group_name":"Club","user_roles":{"3":"view"}}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"Failed to create group"}`,
+		},
+		{
+			name: "Unknown user to invite",
+			setupMock: func() {
+				mocks.user.On("UserIDExists", mock.Anything, int64(4), mock.AnythingOfType("[]*sql.Tx")).Return(false, nil).Once()
+			},
+			body:           `{"group_name":"Team","user_roles":{"4":"view"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Unknown user to invite: 4"}`,
+		},
+		{
+			name: "Unknown template",
+			setupMock: func() {
+				templates.On("GetTemplate", "unknown").Return(nil, impl.ErrTemplateNotFound).Once()
+			},
+			body:           `{"group_name":"Family","template":"unknown"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   impl.ErrTemplateNotFound.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, w := newGroupTestContext("POST", tc.body, 1, nil)
+			tc.setupMock()
+			CreateGroup(c)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestInviteToGroup(t *testing.T) {
+	mocks := initGroupTest(t)
+	defer mocks.group.AssertExpectations(t)
+	defer mocks.invitation.AssertExpectations(t)
+
+	group := &interfaces.Group{GroupID: 10, OwnerID: 1, ScopeID: 100, GroupName: "Family"}
+	invitee := &interfaces.User{ID: 2, Username: "bob", Email: "bob@example.com"}
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		userID         int64
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Invite by username",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.user.On("GetUserByUsername", mock.Anything, "bob", mock.AnythingOfType("[]*sql.Tx")).Return(invitee, nil).Once()
+				mocks.userScope.On("GetUserScope", mock.Anything, int64(2), int64(100), mock.AnythingOfType("[]*sql.Tx")).Return((*interfaces.UserScope)(nil), impl.ErrUserScopeNotFound).Once()
+				mocks.invitation.On("InsertInvitation", mock.Anything, mock.MatchedBy(func(i *interfaces.GroupInvitation) bool {
+					return i.GroupID == 10 && i.InviteeID == 2 && i.Role == impl.RoleView
+				}), mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			userID:         1,
+			body:           `{"username":"bob","role":"view"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"invitee_id":2`,
+		},
+		{
+			name: "Invite by email when already invited",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.user.On("GetUserByEmail", mock.Anything, "bob@example.com", mock.AnythingOfType("[]*sql.Tx")).Return(invitee, nil).Once()
+				mocks.userScope.On("GetUserScope", mock.Anything, int64(2), int64(100), mock.AnythingOfType("[]*sql.Tx")).Return((*interfaces.UserScope)(nil), impl.ErrUserScopeNotFound).Once()
+				mocks.invitation.On("InsertInvitation", mock.Anything, mock.Anything, mock.AnythingOfType("[]*sql.Tx")).Return(impl.ErrInvitationExists).Once()
+			},
+			userID:         1,
+			body:           `{"email":"bob@example.com","role":"write"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   impl.ErrInvitationExists.Error(),
+		},
+		{
+			name: "Unknown invitee",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.user.On("GetUserByUsername", mock.Anything, "nobody", mock.AnythingOfType("[]*sql.Tx")).Return((*interfaces.User)(nil), impl.ErrUserNotFound).Once()
+			},
+			userID:         1,
+			body:           `{"username":"nobody","role":"view"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"User not found"}`,
+		},
+		{
+			name: "Invitee lookup fails",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.user.On("GetUserByUsername", mock.Anything, "carol", mock.AnythingOfType("[]*sql.Tx")).Return((*interfaces.User)(nil), errors.New("connection lost")).Once()
+			},
+			userID:         1,
+			body:           `{"username":"carol","role":"view"}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"Failed to look up user"}`,
+		},
+		{
+			name: "Existing member",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.user.On("GetUserByUsername", mock.Anything, "bob", mock.AnythingOfType("[]*sql.Tx")).Return(invitee, nil).Once()
+				mocks.userScope.On("GetUserScope", mock.Anything, int64(2), int64(100), mock.AnythingOfType("[]*sql.Tx")).Return(&interfaces.UserScope{UserID: 2, ScopeID: 100, Role: impl.RoleView}, nil).Once()
+			},
+			userID:         1,
+			body:           `{"username":"bob","role":"view"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"error":"User is already a member of this group"}`,
+		},
+		{
+			name: "Membership check fails",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.user.On("GetUserByUsername", mock.Anything, "bob", mock.AnythingOfType("[]*sql.Tx")).Return(invitee, nil).Once()
+				mocks.userScope.On("GetUserScope", mock.Anything, int64(2), int64(100), mock.AnythingOfType("[]*sql.Tx")).Return((*interfaces.UserScope)(nil), errors.New("connection lost")).Once()
+			},
+			userID:         1,
+			body:           `{"username":"bob","role":"view"}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"Failed to check group membership"}`,
+		},
+		{
+			name: "Not the owner",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(3), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+			},
+			userID:         3,
+			body:           `{"username":"bob","role":"view"}`,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"Unauthorized to invite users to this group"}`,
+		},
+		{
+			name:           "Owner role cannot be offered",
+			setupMock:      func() {},
+			userID:         1,
+			body:           `{"username":"bob","role":"owner"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Invalid role specified"}`,
+		},
+		{
+			name:           "Missing invitee",
+			setupMock:      func() {},
+			userID:         1,
+			body:           `{"role":"view"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"username or email is required"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, w := newGroupTestContext("POST", tc.body, tc.userID, gin.Params{{Key: "id", Value: "10"}})
+			tc.setupMock()
+			InviteToGroup(c)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	mocks := initGroupTest(t)
+	defer mocks.invitation.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		invitationID   string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Successful accept",
+			setupMock: func() {
+				mocks.invitation.On("AcceptInvitation", mock.Anything, int64(5), int64(2), mock.AnythingOfType("[]*sql.Tx")).
+					Return(&interfaces.GroupInvitation{InvitationID: 5, GroupID: 10, InviteeID: 2, Role: impl.RoleView, Status: impl.InvitationStatusAccepted}, nil).Once()
+			},
+			invitationID:   "5",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"status":"accepted"`,
+		},
+		{
+			name: "Invitation not found",
+			setupMock: func() {
+				mocks.invitation.On("AcceptInvitation", mock.Anything, int64(6), int64(2), mock.AnythingOfType("[]*sql.Tx")).
+					Return((*interfaces.GroupInvitation)(nil), impl.ErrInvitationNotFound).Once()
+			},
+			invitationID:   "6",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"Invitation not found"}`,
+		},
+		{
+			name: "Invitation already answered",
+			setupMock: func() {
+				mocks.invitation.On("AcceptInvitation", mock.Anything, int64(7), int64(2), mock.AnythingOfType("[]*sql.Tx")).
+					Return((*interfaces.GroupInvitation)(nil), impl.ErrInvitationNotPending).Once()
+			},
+			invitationID:   "7",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   impl.ErrInvitationNotPending.Error(),
+		},
+		{
+			name:           "Invalid invitation ID",
+			setupMock:      func() {},
+			invitationID:   "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid invitation ID format"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, w := newGroupTestContext("POST", "", 2, gin.Params{{Key: "id", Value: tc.invitationID}})
+			tc.setupMock()
+			AcceptInvitation(c)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
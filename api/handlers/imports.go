@@ -0,0 +1,316 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"xspends/importer"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const maxImportFileSize = 10 << 20
+
+func getImportBatchID(c *gin.Context) (int64, bool) {
+	batchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Printf("[getImportBatchID] Error: invalid import batch ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import batch ID format"})
+		return 0, false
+	}
+	return batchID, true
+}
+
+func respondImportError(c *gin.Context, err error, fallback string) {
+	switch errors.Cause(err) {
+	case impl.ErrImportNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "import batch not found"})
+	case impl.ErrImportAlreadyUndone:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case impl.ErrInvalidImport, importer.ErrInvalidFile, importer.ErrUnsupportedFormat:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseImportUpload reads the multipart form shared by preview and commit: the statement
+// "file", the "source_id" to import into, an optional "format" (guessed from the file name
+// otherwise) and, for CSV, the column "mapping" as JSON. It responds and returns false on error.
+func parseImportUpload(c *gin.Context, userInfo ScopeInfo) (interfaces.ImportBatch, []interfaces.ImportRow, bool) {
+	batch := interfaces.ImportBatch{UserID: userInfo.UserID, ScopeID: userInfo.UseScope}
+
+	sourceID, err := strconv.ParseInt(c.PostForm("source_id"), 10, 64)
+	if err != nil || sourceID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source_id is required"})
+		return batch, nil, false
+	}
+	batch.SourceID = sourceID
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a statement file is required"})
+		return batch, nil, false
+	}
+	if header.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "the statement file is larger than 10 MB"})
+		return batch, nil, false
+	}
+	batch.FileName = header.Filename
+	batch.Format = c.PostForm("format")
+	if batch.Format == "" {
+		batch.Format = importer.FormatFromFileName(header.Filename)
+	}
+
+	var mapping importer.CSVMapping
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object"})
+			return batch, nil, false
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		log.Printf("[parseImportUpload] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to read the statement file"})
+		return batch, nil, false
+	}
+	defer file.Close()
+
+	rows, err := importer.Parse(batch.Format, file, mapping)
+	if err != nil {
+		log.Printf("[parseImportUpload] Error: %v", err)
+		respondImportError(c, err, "unable to parse the statement file")
+		return batch, nil, false
+	}
+	return batch, rows, true
+}
+
+// PreviewImport
+// @Summary Preview a statement import
+// @Description Parse a CSV, OFX/QFX or QIF statement without saving anything. Each row carries a suggested category and is flagged when it duplicates an existing transaction of the source.
+// @ID preview-import
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param file formData file true "Statement file"
+// @Param source_id formData int true "Source to import into"
+// @Param format formData string false "CSV, OFX, QFX or QIF, guessed from the file name by default"
+// @Param mapping formData string false "CSV column mapping as JSON"
+// @Success 200 {array} interfaces.ImportRow
+// @Failure 400 {object} map[string]string "Invalid file or mapping"
+// @Router /imports/preview [post]
+func PreviewImport(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[PreviewImport] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[PreviewImport] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	batch, rows, ok := parseImportUpload(c, userInfo)
+	if !ok {
+		return
+	}
+
+	preview, err := impl.GetModelsService().ImportBatchModel.PreviewImport(c, batch, rows)
+	if err != nil {
+		log.Printf("[PreviewImport] Error: %v", err)
+		respondImportError(c, err, "unable to preview import")
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// CommitImport
+// @Summary Import a statement
+// @Description Parse a statement and insert its rows into the source in one SQL transaction. Rows use their suggested category, or default_category_id. Duplicates are skipped unless skip_duplicates is false.
+// @ID commit-import
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param file formData file true "Statement file"
+// @Param source_id formData int true "Source to import into"
+// @Param format formData string false "CSV, OFX, QFX or QIF, guessed from the file name by default"
+// @Param mapping formData string false "CSV column mapping as JSON"
+// @Param default_category_id formData int false "Category for rows without a suggestion"
+// @Param skip_duplicates formData bool false "Leave out likely duplicates, defaults to true"
+// @Success 201 {object} interfaces.ImportBatch
+// @Failure 400 {object} map[string]string "Invalid file, mapping or missing category"
+// @Router /imports [post]
+func CommitImport(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[CommitImport] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[CommitImport] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	batch, rows, ok := parseImportUpload(c, userInfo)
+	if !ok {
+		return
+	}
+	options := interfaces.ImportCommitOptions{SkipDuplicates: c.DefaultPostForm("skip_duplicates", "true") != "false"}
+	if raw := c.PostForm("default_category_id"); raw != "" {
+		categoryID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid default_category_id"})
+			return
+		}
+		options.DefaultCategoryID = categoryID
+	}
+
+	if err := impl.GetModelsService().ImportBatchModel.CommitImport(c, &batch, rows, options); err != nil {
+		log.Printf("[CommitImport] Error: %v", err)
+		respondImportError(c, err, "unable to import transactions")
+		return
+	}
+
+	c.JSON(http.StatusCreated, batch)
+}
+
+// ListImports
+// @Summary List import batches
+// @Description List the statement imports of the active scope, newest first
+// @ID list-imports
+// @Produce  json
+// @Success 200 {array} interfaces.ImportBatch
+// @Router /imports [get]
+func ListImports(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ListImports] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ListImports] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	batches, err := impl.GetModelsService().ImportBatchModel.GetImportBatchesByScope(c, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[ListImports] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch import batches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, batches)
+}
+
+// GetImport
+// @Summary Get an import batch
+// @Description Get a statement import of the active scope by its ID
+// @ID get-import
+// @Produce  json
+// @Param id path int true "Import batch ID"
+// @Success 200 {object} interfaces.ImportBatch
+// @Failure 404 {object} map[string]string "Import batch not found"
+// @Router /imports/{id} [get]
+func GetImport(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[GetImport] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[GetImport] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	batchID, ok := getImportBatchID(c)
+	if !ok {
+		return
+	}
+
+	batch, err := impl.GetModelsService().ImportBatchModel.GetImportBatchByID(c, batchID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[GetImport] Error: %v", err)
+		respondImportError(c, err, "unable to fetch import batch")
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// UndoImport
+// @Summary Undo an import
+// @Description Permanently delete every transaction created by an import batch and restore the source balance
+// @ID undo-import
+// @Produce  json
+// @Param id path int true "Import batch ID"
+// @Success 200 {object} interfaces.ImportBatch
+// @Failure 404 {object} map[string]string "Import batch not found"
+// @Failure 409 {object} map[string]string "Already undone"
+// @Router /imports/{id}/undo [post]
+func UndoImport(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[UndoImport] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[UndoImport] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	batchID, ok := getImportBatchID(c)
+	if !ok {
+		return
+	}
+
+	batch, err := impl.GetModelsService().ImportBatchModel.UndoImport(c, batchID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[UndoImport] Error: %v", err)
+		respondImportError(c, err, "unable to undo import")
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func initImportTest(t *testing.T) *xmock.MockImportBatchModel {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+
+	mockImportModel := new(xmock.MockImportBatchModel)
+	modelsService.ImportBatchModel = mockImportModel
+	return mockImportModel
+}
+
+// newImportTestContext builds a multipart upload of file named fileName with the given form fields.
+func newImportTestContext(w *httptest.ResponseRecorder, path, fileName, file string, fields map[string]string) *gin.Context {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		_ = writer.WriteField(name, value)
+	}
+	if fileName != "" {
+		part, _ := writer.CreateFormFile("file", fileName)
+		_, _ = part.Write([]byte(file))
+	}
+	_ = writer.Close()
+
+	c := newRecurringTestContext(w, "POST", path, "")
+	c.Request = httptest.NewRequest("POST", path, body)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+	return c
+}
+
+func TestPreviewImport(t *testing.T) {
+	mockImportModel := initImportTest(t)
+	defer mockImportModel.AssertExpectations(t)
+
+	qif := "!Type:Bank\nD1/31/2024\nT-4.50\nPCoffee\n^\n"
+	tests := []struct {
+		name           string
+		fileName       string
+		fields         map[string]string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:     "QIF guessed from the file name",
+			fileName: "january.qif",
+			fields:   map[string]string{"source_id": "2"},
+			setupMock: func() {
+				batch := interfaces.ImportBatch{UserID: 1, ScopeID: 10, SourceID: 2, Format: "QIF", FileName: "january.qif"}
+				mockImportModel.On("PreviewImport", mock.Anything, batch, mock.MatchedBy(func(rows []interfaces.ImportRow) bool {
+					return len(rows) == 1 && rows[0].Description == "Coffee"
+				}), mock.AnythingOfType("[]*sql.Tx")).
+					Return([]interfaces.ImportRow{{Line: 2, Amount: interfaces.MoneyFromFloat(4.5), Description: "Coffee", Duplicate: true, DuplicateOf: 99}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"duplicate_of":99`,
+		},
+		{
+			name:           "Missing source",
+			fileName:       "january.qif",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "source_id is required",
+		},
+		{
+			name:           "Unsupported format",
+			fileName:       "january.xlsx",
+			fields:         map[string]string{"source_id": "2"},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "supported import formats",
+		},
+		{
+			name:           "CSV without mapping",
+			fileName:       "january.csv",
+			fields:         map[string]string{"source_id": "2"},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "mapping needs a date column",
+		},
+		{
+			name:     "Unknown source",
+			fileName: "january.qif",
+			fields:   map[string]string{"source_id": "3"},
+			setupMock: func() {
+				mockImportModel.On("PreviewImport", mock.Anything, mock.MatchedBy(func(batch interfaces.ImportBatch) bool { return batch.SourceID == 3 }), mock.Anything, mock.Anything).
+					Return(nil, impl.ErrInvalidImport).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   impl.ErrInvalidImport.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newImportTestContext(w, "/imports/preview", tc.fileName, qif, tc.fields)
+
+			PreviewImport(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestUndoImport(t *testing.T) {
+	mockImportModel := initImportTest(t)
+	defer mockImportModel.AssertExpectations(t)
+
+	mockImportModel.On("UndoImport", mock.Anything, int64(5), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).
+		Return(nil, impl.ErrImportAlreadyUndone).Once()
+
+	w := httptest.NewRecorder()
+	c := newRecurringTestContext(w, "POST", "/imports/5/undo", "")
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "5"}}
+
+	UndoImport(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), impl.ErrImportAlreadyUndone.Error())
+}
@@ -0,0 +1,132 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCursorLimit = 10
+	maxCursorLimit     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorKey signs the cursors handed out by the list endpoints so that clients cannot forge
+// positions. It is read from CURSOR_KEY and defaults to the JWT key.
+var cursorKey = getCursorKey()
+
+func getCursorKey() []byte {
+	if key := os.Getenv("CURSOR_KEY"); key != "" {
+		return []byte(key)
+	}
+	return JwtKey
+}
+
+// signCursor returns the MAC of a cursor payload for list, so that a cursor only works on the list it came from.
+func signCursor(list string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write([]byte(list + "\x00"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// encodeCursor turns a cursor of list into an opaque token, or nil when there is no cursor.
+func encodeCursor(list string, cursor *interfaces.Cursor) interface{} {
+	if cursor == nil {
+		return nil
+	}
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signCursor(list, payload))
+}
+
+// decodeCursor reads a token made by encodeCursor for list and checks its signature.
+func decodeCursor(list, token string) (*interfaces.Cursor, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, signCursor(list, payload)) {
+		return nil, errInvalidCursor
+	}
+
+	var cursor interfaces.Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// cursorParamsFromQuery reads the cursor and limit query parameters of list. It returns nil when
+// the request has no cursor parameter and pages by offset instead; an empty cursor asks for the
+// first page.
+func cursorParamsFromQuery(c *gin.Context, list string) (*interfaces.CursorParams, error) {
+	token, paged := c.GetQuery("cursor")
+	if !paged {
+		return nil, nil
+	}
+
+	params := &interfaces.CursorParams{Limit: defaultCursorLimit}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxCursorLimit {
+			return nil, errors.Errorf("limit must be between 1 and %d", maxCursorLimit)
+		}
+		params.Limit = n
+	}
+	if token != "" {
+		cursor, err := decodeCursor(list, token)
+		if err != nil {
+			return nil, err
+		}
+		params.Cursor = cursor
+	}
+	return params, nil
+}
+
+// cursorPageResponse is the body of a page of list read by cursor.
+func cursorPageResponse(list string, items interface{}, page interfaces.CursorPage) gin.H {
+	return gin.H{
+		list:          items,
+		"next_cursor": encodeCursor(list, page.Next),
+		"prev_cursor": encodeCursor(list, page.Prev),
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCursorEncoding(t *testing.T) {
+	cursor := &interfaces.Cursor{Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ID: 42, Backward: true}
+	token := encodeCursor("transactions", cursor).(string)
+
+	decoded, err := decodeCursor("transactions", token)
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	t.Run("Rejects a cursor of another list", func(t *testing.T) {
+		_, err := decodeCursor("tags", token)
+		assert.Equal(t, errInvalidCursor, err)
+	})
+
+	t.Run("Rejects a tampered cursor", func(t *testing.T) {
+		forged := encodeCursor("transactions", &interfaces.Cursor{Time: cursor.Time, ID: 1}).(string)
+		payload, mac := token[:len(token)-43], forged[len(forged)-43:]
+		_, err := decodeCursor("transactions", payload+mac)
+		assert.Equal(t, errInvalidCursor, err)
+		_, err = decodeCursor("transactions", "garbage")
+		assert.Equal(t, errInvalidCursor, err)
+	})
+
+	assert.Nil(t, encodeCursor("transactions", nil))
+}
+
+func TestListTransactionsByCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+	mockTransactionModel := new(xmock.MockTransactionModel)
+	modelsService.TransactionModel = mockTransactionModel
+	defer mockTransactionModel.AssertExpectations(t)
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	next := &interfaces.Cursor{Time: at, ID: 7}
+	token := encodeCursor("transactions", next).(string)
+
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "First page",
+			path: "/transactions?cursor=&limit=1&type=EXPENSE",
+			setupMock: func() {
+				mockTransactionModel.On("GetTransactionsByCursor", mock.Anything, mock.MatchedBy(func(f interfaces.TransactionFilter) bool {
+					return f.Type == "EXPENSE" && f.Scopes[0] == 10
+				}), interfaces.CursorParams{Limit: 1}, mock.Anything).
+					Return([]interfaces.Transaction{{ID: 7, Timestamp: at}}, interfaces.CursorPage{Next: next}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"next_cursor":"` + token + `","prev_cursor":null`,
+		},
+		{
+			name: "Following page",
+			path: "/transactions?cursor=" + url.QueryEscape(token),
+			setupMock: func() {
+				mockTransactionModel.On("GetTransactionsByCursor", mock.Anything, mock.Anything, interfaces.CursorParams{Cursor: next, Limit: defaultCursorLimit}, mock.Anything).
+					Return([]interfaces.Transaction{}, interfaces.CursorPage{}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"transactions":[]`,
+		},
+		{
+			name:           "Invalid cursor",
+			path:           "/transactions?cursor=" + url.QueryEscape(encodeCursor("tags", next).(string)),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid cursor",
+		},
+		{
+			name:           "Limit too large",
+			path:           "/transactions?cursor=&limit=1000",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "limit must be between 1 and 100",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "GET", tc.path, "")
+
+			ListTransactions(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestListTagsByCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+	mockTagModel := new(xmock.MockTagModel)
+	modelsService.TagModel = mockTagModel
+	defer mockTagModel.AssertExpectations(t)
+
+	prev := &interfaces.Cursor{Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ID: 3, Backward: true}
+	mockTagModel.On("GetTagsByCursor", mock.Anything, []int64{10}, interfaces.CursorParams{Limit: 2}, mock.Anything).
+		Return([]interfaces.Tag{{ID: 3, Name: "food"}}, interfaces.CursorPage{Prev: prev}, nil).Once()
+
+	w := httptest.NewRecorder()
+	c := newRecurringTestContext(w, "GET", "/tags?cursor&limit=2", "")
+	ListTags(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Tags       []interfaces.Tag `json:"tags"`
+		NextCursor *string          `json:"next_cursor"`
+		PrevCursor *string          `json:"prev_cursor"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Tags, 1)
+	assert.Nil(t, body.NextCursor)
+	if assert.NotNil(t, body.PrevCursor) {
+		decoded, err := decodeCursor("tags", *body.PrevCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, prev, decoded)
+	}
+}
@@ -30,6 +30,9 @@ import (
 	"xspends/models/interfaces"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/volatiletech/authboss/v3"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type ScopeInfo struct {
@@ -60,6 +63,13 @@ func getUserFromContext(c *gin.Context) (int64, bool) {
 	return intUserID, true
 }
 
+// @Summary Get the profile
+// @Description Get the profile and preferences of the current user
+// @ID get-profile
+// @Produce  json
+// @Success 200 {object} interfaces.User
+// @Failure 404 {object} map[string]string "User not found"
+// @Router /me [get]
 func GetUserProfile(c *gin.Context) {
 	userID, ok := getUserFromContext(c)
 	if !ok {
@@ -77,6 +87,52 @@ func GetUserProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// ProfileRequest holds the profile fields to change; fields left empty keep their values.
+type ProfileRequest struct {
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Currency        string `json:"currency"`
+	Timezone        string `json:"timezone"`
+	Locale          string `json:"locale"`
+	WeekStart       string `json:"week_start"`
+	CurrencyDisplay string `json:"currency_display"`
+}
+
+func (r ProfileRequest) apply(user *interfaces.User) {
+	if r.Name != "" {
+		user.Name = r.Name
+	}
+	if r.Email != "" {
+		user.Email = r.Email
+	}
+	if r.Currency != "" {
+		user.Currency = r.Currency
+	}
+	if r.Timezone != "" {
+		user.Timezone = r.Timezone
+	}
+	if r.Locale != "" {
+		user.Locale = r.Locale
+	}
+	if r.WeekStart != "" {
+		user.WeekStart = r.WeekStart
+	}
+	if r.CurrencyDisplay != "" {
+		user.CurrencyDisplay = r.CurrencyDisplay
+	}
+}
+
+// @Summary Update the profile
+// @Description Change the name, email and preferences of the current user; fields left out keep their values
+// @ID update-profile
+// @Accept  json
+// @Produce  json
+// @Param profile body ProfileRequest true "Profile fields to change"
+// @Success 200 {object} interfaces.User
+// @Failure 400 {object} map[string]string "Invalid preference"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Unable to update user"
+// @Router /me [put]
 func UpdateUserProfile(c *gin.Context) {
 	userID, ok := getUserFromContext(c)
 	if !ok {
@@ -84,17 +140,29 @@ func UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
-	var updatedUser interfaces.User
-	if err := c.ShouldBindJSON(&updatedUser); err != nil {
+	var request ProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Printf("[UpdateUserProfile] Error: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user json"})
 		return
 	}
 
+	updatedUser, err := impl.GetModelsService().UserModel.GetUserByID(c, userID, nil)
+	if err != nil {
+		log.Printf("[UpdateUserProfile] Error: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
 	updatedUser.ID = userID
+	request.apply(updatedUser)
 
-	if err := impl.GetModelsService().UserModel.UpdateUser(c, &updatedUser, nil); err != nil {
+	if err := impl.GetModelsService().UserModel.UpdateUser(c, updatedUser, nil); err != nil {
 		log.Printf("[UpdateUserProfile] Error: %v", err)
+		switch errors.Cause(err) {
+		case impl.ErrInvalidTimezone, impl.ErrInvalidLocale, impl.ErrInvalidWeekStart, impl.ErrInvalidCurrencyDisplay:
+			c.JSON(http.StatusBadRequest, gin.H{"error": errors.Cause(err).Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to update user"})
 		return
 	}
@@ -102,18 +170,120 @@ func UpdateUserProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedUser)
 }
 
-func DeleteUser(c *gin.Context) {
-	userID, ok := getUserFromContext(c)
-	if !ok {
-		log.Printf("[DeleteUser] Error: %v", "Missing user information")
-		return
-	}
+// PasswordChangeRequest holds the current password, which must match, and the new one.
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
 
-	if err := impl.GetModelsService().UserModel.DeleteUser(c, userID, nil); err != nil {
-		log.Printf("[DeleteUser] Error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to delete user"})
-		return
+// @Summary Change the password
+// @Description Change the password of the current user, who must give the current one. Every other session of the user is signed out.
+// @ID change-password
+// @Accept  json
+// @Produce  json
+// @Param password body PasswordChangeRequest true "Current and new password"
+// @Success 200 {object} map[string]interface{} "Password changed, with the number of sessions signed out"
+// @Failure 400 {object} map[string]string "Missing password"
+// @Failure 401 {object} map[string]string "Current password is incorrect"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Unable to change password"
+// @Router /me/password [post]
+func ChangePassword(ab *authboss.Authboss) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := getUserFromContext(c)
+		if !ok {
+			log.Printf("[ChangePassword] Error: %v", "Missing user information")
+			return
+		}
+
+		var request PasswordChangeRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			log.Printf("[ChangePassword] Error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "current_password and new_password are required"})
+			return
+		}
+
+		sessionStorer, ok := ab.Config.Storage.SessionState.(*impl.SessionStorer)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "[ChangePassword] Session storage configuration error"})
+			return
+		}
+
+		user, err := impl.GetModelsService().UserModel.GetUserByID(c, userID, nil)
+		if err != nil {
+			log.Printf("[ChangePassword] Error: %v", err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.CurrentPassword)); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "current password is incorrect"})
+			return
+		}
+
+		hashedPassword, err := hashPassword(request.NewPassword)
+		if err != nil {
+			log.Printf("[ChangePassword] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrHashingPassword.Error()})
+			return
+		}
+		user.ID = userID
+		user.Password = hashedPassword
+		if err := impl.GetModelsService().UserModel.UpdateUser(c, user, nil); err != nil {
+			log.Printf("[ChangePassword] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to change password"})
+			return
+		}
+
+		// The session making the change stays signed in
+		revoked, err := sessionStorer.RevokeUserSessions(c.Request.Context(), userID, c.GetString("sessionID"))
+		if err != nil {
+			log.Printf("[ChangePassword] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "password changed, but other sessions could not be signed out"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "password changed successfully", "revoked_sessions": revoked})
 	}
+}
+
+// @Summary Delete the account
+// @Description Delete the current user's account and every session of it. Everything in the personal scope is deleted.
+// @Description Groups the user owns pass to another member, a writer if there is one, or are closed with their data when the user is their only member.
+// @ID delete-account
+// @Produce  json
+// @Success 200 {object} map[string]string "User deleted"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Unable to delete user"
+// @Router /me [delete]
+func DeleteUser(ab *authboss.Authboss) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := getUserFromContext(c)
+		if !ok {
+			log.Printf("[DeleteUser] Error: %v", "Missing user information")
+			return
+		}
 
-	c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
+		sessionStorer, ok := ab.Config.Storage.SessionState.(*impl.SessionStorer)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "[DeleteUser] Session storage configuration error"})
+			return
+		}
+
+		if err := impl.GetModelsService().UserModel.DeleteUser(c, userID, nil); err != nil {
+			log.Printf("[DeleteUser] Error: %v", err)
+			if errors.Cause(err) == impl.ErrUserNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to delete user"})
+			return
+		}
+
+		// The account is already deleted, so sessions left behind are only logged and expire on their own
+		if _, err := sessionStorer.RevokeUserSessions(c.Request.Context(), userID, ""); err != nil {
+			log.Printf("[DeleteUser] Error: revoking sessions: %v", err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
+	}
 }
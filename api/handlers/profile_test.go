@@ -7,14 +7,19 @@ import (
 	"strings"
 	"testing"
 	"time"
+	ymock "xspends/kvstore/mock"
+	"xspends/models/impl"
 	"xspends/models/interfaces"
 	xmock "xspends/models/mock"
 	"xspends/testutils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/volatiletech/authboss/v3"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Initialize mock and context for the tests
@@ -63,6 +68,10 @@ func TestGetUserProfile(t *testing.T) {
 				"user_id": 1,
 				"name": "John Doe",
 				"scope_id":1,
+				"timezone": "",
+				"locale": "",
+				"week_start": "",
+				"currency_display": "",
 				"updated_at": "0001-01-01T00:00:00Z",
 				"username": ""
 			}`,
@@ -127,21 +136,29 @@ func TestUpdateUserProfile(t *testing.T) {
 		{
 			name:   "Successful update",
 			userID: "1",
-			body:   `{"name":"Jane Doe"}`, // Assume this is a valid JSON for updating the user
+			body:   `{"name":"Jane Doe","timezone":"Europe/Paris","week_start":"sunday"}`,
 			setupMock: func(userID int64) {
-				updatedUser := interfaces.User{ID: userID, Name: "Jane Doe"} // Construct expected updated user
+				mockUserModel.On("GetUserByID", mock.Anything, userID, mock.Anything).
+					Return(&interfaces.User{ID: userID, Name: "John Doe", Email: "jane@example.com", Password: "hash", Locale: "en-US"}, nil).Once()
+				// Fields left out keep their values, including the password
+				updatedUser := interfaces.User{ID: userID, Name: "Jane Doe", Email: "jane@example.com", Password: "hash",
+					Timezone: "Europe/Paris", Locale: "en-US", WeekStart: "sunday"}
 				mockUserModel.On("UpdateUser", mock.Anything, &updatedUser, mock.Anything).Return(nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: `{
 				"created_at": "0001-01-01T00:00:00Z",
 				"currency": "",
-				"email": "",
+				"currency_display": "",
+				"email": "jane@example.com",
 				"user_id": 1,
+				"locale": "en-US",
 				"name": "Jane Doe",
 				"scope_id":0,
+				"timezone": "Europe/Paris",
 				"updated_at": "0001-01-01T00:00:00Z",
-				"username": ""
+				"username": "",
+				"week_start": "sunday"
 			}`,
 		},
 		{
@@ -151,11 +168,23 @@ func TestUpdateUserProfile(t *testing.T) {
 			setupMock:      nil,
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `{"error": "Invalid user json"}`,
+		}, {
+			name:   "Invalid preference",
+			userID: "1",
+			body:   `{"timezone":"Mars/Olympus"}`,
+			setupMock: func(userID int64) {
+				mockUserModel.On("GetUserByID", mock.Anything, userID, mock.Anything).Return(&interfaces.User{ID: userID}, nil).Once()
+				mockUserModel.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *interfaces.User) bool { return u.Timezone == "Mars/Olympus" }), mock.Anything).
+					Return(impl.ErrInvalidTimezone).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error": "timezone must be an IANA time zone name, such as Europe/Paris"}`,
 		}, {
 			name:   "Failed to update",
 			userID: "1",
 			body:   `{"name":"Jane Doe"}`, // Assume this is a valid JSON for updating the user
 			setupMock: func(userID int64) {
+				mockUserModel.On("GetUserByID", mock.Anything, userID, mock.Anything).Return(&interfaces.User{ID: userID}, nil).Once()
 				updatedUser := interfaces.User{ID: userID, Name: "Jane Doe"} // Construct expected updated user
 				mockUserModel.On("UpdateUser", mock.Anything, &updatedUser, mock.Anything).Return(errors.New("unable to update user")).Once()
 			},
@@ -195,9 +224,88 @@ func TestUpdateUserProfile(t *testing.T) {
 	})
 }
 
+// newSessionTestAuthboss returns an Authboss keeping sessions in a mocked key-value store.
+func newSessionTestAuthboss(t *testing.T) (*authboss.Authboss, *ymock.MockRawKVClientInterface) {
+	mockKV := ymock.NewMockRawKVClientInterface(gomock.NewController(t))
+	ab := &authboss.Authboss{}
+	ab.Config.Storage.SessionState = impl.NewSessionStorer(mockKV)
+	return ab, mockKV
+}
+
+func TestChangePassword(t *testing.T) {
+	mockUserModel := initUserProfileTest(t)
+	defer mockUserModel.AssertExpectations(t)
+	ab, mockKV := newSessionTestAuthboss(t)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old-secret"), bcrypt.MinCost)
+	user := func() *interfaces.User {
+		return &interfaces.User{ID: 1, Username: "john", Password: string(hash)}
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Signs out the other sessions",
+			requestBody: `{"current_password":"old-secret","new_password":"new-secret"}`,
+			setupMock: func() {
+				mockUserModel.On("GetUserByID", mock.Anything, int64(1), mock.Anything).Return(user(), nil).Once()
+				mockUserModel.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *interfaces.User) bool {
+					return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("new-secret")) == nil
+				}), mock.Anything).Return(nil).Once()
+				mockKV.EXPECT().Scan(gomock.Any(), []byte("user_sessions/1/"), []byte("user_sessions/10"), gomock.Any()).
+					Return([][]byte{[]byte("user_sessions/1/7"), []byte("user_sessions/1/8")}, [][]byte{[]byte("7"), []byte("8")}, nil)
+				mockKV.EXPECT().Get(gomock.Any(), []byte("7")).Return([]byte("refresh-token"), nil)
+				mockKV.EXPECT().Delete(gomock.Any(), []byte("7")).Return(nil)
+				mockKV.EXPECT().Delete(gomock.Any(), []byte("user_sessions/1/7")).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"message":"password changed successfully","revoked_sessions":1}`,
+		},
+		{
+			name:        "Wrong current password",
+			requestBody: `{"current_password":"guess","new_password":"new-secret"}`,
+			setupMock: func() {
+				mockUserModel.On("GetUserByID", mock.Anything, int64(1), mock.Anything).Return(user(), nil).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"current password is incorrect"}`,
+		},
+		{
+			name:           "Missing new password",
+			requestBody:    `{"current_password":"old-secret"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"current_password and new_password are required"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/me/password", strings.NewReader(tc.requestBody))
+			c.Set("userID", int64(1))
+			c.Set("sessionID", "8")
+
+			ChangePassword(ab)(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.JSONEq(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	mockUserModel := initUserProfileTest(t)
 	defer mockUserModel.AssertExpectations(t)
+	ab, mockKV := newSessionTestAuthboss(t)
 
 	tests := []struct {
 		name           string
@@ -211,6 +319,12 @@ func TestDeleteUser(t *testing.T) {
 			userID: "1",
 			setupMock: func(userID int64) {
 				mockUserModel.On("DeleteUser", mock.Anything, userID, mock.Anything).Return(nil).Once()
+				// Every session goes, the one making the request too
+				mockKV.EXPECT().Scan(gomock.Any(), []byte("user_sessions/1/"), gomock.Any(), gomock.Any()).
+					Return([][]byte{[]byte("user_sessions/1/7")}, [][]byte{[]byte("7")}, nil)
+				mockKV.EXPECT().Get(gomock.Any(), []byte("7")).Return([]byte("refresh-token"), nil)
+				mockKV.EXPECT().Delete(gomock.Any(), []byte("7")).Return(nil)
+				mockKV.EXPECT().Delete(gomock.Any(), []byte("user_sessions/1/7")).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"message":"user deleted successfully"}`,
@@ -222,6 +336,14 @@ func TestDeleteUser(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"error": "unable to delete user"}`,
+		}, {
+			name:   "Already deleted",
+			userID: "1",
+			setupMock: func(userID int64) {
+				mockUserModel.On("DeleteUser", mock.Anything, userID, mock.Anything).Return(impl.ErrUserNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error": "user not found"}`,
 		},
 		// ... other test cases
 	}
@@ -231,13 +353,15 @@ func TestDeleteUser(t *testing.T) {
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
 
+			c.Request = httptest.NewRequest("DELETE", "/me", nil)
+
 			userIDInt, _ := strconv.ParseInt(tc.userID, 10, 64)
 			c.Set("userID", userIDInt)
 			if tc.setupMock != nil {
 				tc.setupMock(userIDInt)
 			}
 
-			DeleteUser(c)
+			DeleteUser(ab)(c)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
 			assert.JSONEq(t, tc.expectedBody, w.Body.String())
@@ -248,7 +372,7 @@ func TestDeleteUser(t *testing.T) {
 		c, _ := gin.CreateTestContext(w)
 
 		c.Set("userID", "invalid")
-		DeleteUser(c)
+		DeleteUser(ab)(c)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.JSONEq(t, `{"error":"failed to convert userID to int64"}`, w.Body.String())
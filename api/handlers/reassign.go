@@ -0,0 +1,74 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"xspends/models/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// getReassignTo reads the optional reassign_to query parameter of a delete request; 0 means none.
+func getReassignTo(c *gin.Context) (int64, bool) {
+	value := c.Query("reassign_to")
+	if value == "" {
+		return 0, true
+	}
+	reassignTo, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || reassignTo <= 0 {
+		log.Printf("[getReassignTo] Error: invalid reassign_to %q", value)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reassign_to must be a numeric ID"})
+		return 0, false
+	}
+	return reassignTo, true
+}
+
+// respondDeleteError reports a category or source still in use as a 409 with how many transactions
+// and recurring transactions, and for a category rules and budgets, use it, and an unusable
+// reassign_to target as a 400.
+func respondDeleteError(c *gin.Context, err error, fallback string) {
+	var inUse *impl.InUseError
+	switch {
+	case errors.As(err, &inUse):
+		body := gin.H{
+			"error":                  inUse.Error(),
+			"transactions":           inUse.Transactions,
+			"recurring_transactions": inUse.RecurringTransactions,
+		}
+		// Only categories are assigned by rules and tracked by budgets
+		if inUse.Item == "category" {
+			body["rules"], body["budgets"] = inUse.Rules, inUse.Budgets
+		}
+		c.JSON(http.StatusConflict, body)
+	case errors.Cause(err) == impl.ErrInvalidReassignTarget:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
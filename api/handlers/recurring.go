@@ -0,0 +1,379 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const dateLayout = "2006-01-02"
+
+// RecurringTransactionRequest is the body for creating or updating a recurring transaction.
+// Dates use the YYYY-MM-DD format; on update, empty fields keep their current value.
+type RecurringTransactionRequest struct {
+	SourceID    int64            `json:"source_id"`
+	CategoryID  int64            `json:"category_id"`
+	Amount      interfaces.Money `json:"amount"`
+	Type        string           `json:"type"`
+	Description string           `json:"description"`
+	Tags        []string         `json:"tags"`
+	Frequency   string           `json:"frequency"`
+	Interval    int              `json:"interval"`
+	StartDate   string           `json:"start_date"`
+	EndDate     string           `json:"end_date"`
+	Count       int              `json:"count"`
+}
+
+// parseDate parses a YYYY-MM-DD request value; name is the field reported in the error.
+func parseDate(name, value string) (time.Time, error) {
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, errors.New(name + " must use the YYYY-MM-DD format")
+	}
+	return date, nil
+}
+
+func getRecurringID(c *gin.Context) (int64, bool) {
+	recurringID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Printf("[getRecurringID] Error: invalid recurring transaction ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recurring transaction ID format"})
+		return 0, false
+	}
+	return recurringID, true
+}
+
+// apply copies the non-empty request fields onto rt.
+func (r RecurringTransactionRequest) apply(rt *interfaces.RecurringTransaction) error {
+	if r.SourceID != 0 {
+		rt.SourceID = r.SourceID
+	}
+	if r.CategoryID != 0 {
+		rt.CategoryID = r.CategoryID
+	}
+	if r.Amount != 0 {
+		rt.Amount = r.Amount
+	}
+	if r.Type != "" {
+		rt.Type = r.Type
+	}
+	if r.Description != "" {
+		rt.Description = r.Description
+	}
+	if r.Tags != nil {
+		rt.Tags = r.Tags
+	}
+	if r.Frequency != "" {
+		rt.Frequency = r.Frequency
+	}
+	if r.Interval != 0 {
+		rt.Interval = r.Interval
+	}
+	if r.Count != 0 {
+		rt.Count = r.Count
+	}
+	if r.StartDate != "" {
+		startDate, err := parseDate("start_date", r.StartDate)
+		if err != nil {
+			return err
+		}
+		rt.StartDate = startDate
+	}
+	if r.EndDate != "" {
+		endDate, err := parseDate("end_date", r.EndDate)
+		if err != nil {
+			return err
+		}
+		rt.EndDate = &endDate
+	}
+	return nil
+}
+
+func respondRecurringError(c *gin.Context, err error, fallback string) {
+	switch errors.Cause(err) {
+	case impl.ErrRecurringNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "recurring transaction not found"})
+	case impl.ErrInvalidRecurrence:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case impl.ErrRecurrenceFinished:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListRecurringTransactions
+// @Summary List recurring transactions
+// @Description Get the recurring transactions of the active scope, soonest next occurrence first
+// @ID list-recurring-transactions
+// @Produce  json
+// @Success 200 {array} interfaces.RecurringTransaction
+// @Failure 500 {object} map[string]string "Unable to fetch recurring transactions"
+// @Router /recurring [get]
+func ListRecurringTransactions(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ListRecurringTransactions] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ListRecurringTransactions] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	recurring, err := impl.GetModelsService().RecurringTransactionModel.GetRecurringTransactionsByScope(c, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[ListRecurringTransactions] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch recurring transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// CreateRecurringTransaction
+// @Summary Create a recurring transaction
+// @Description Create a transaction template repeated daily, weekly, monthly or yearly, optionally limited by an end date or a count
+// @ID create-recurring-transaction
+// @Accept  json
+// @Produce  json
+// @Param recurring body RecurringTransactionRequest true "Recurring transaction info"
+// @Success 201 {object} interfaces.RecurringTransaction
+// @Failure 400 {object} map[string]string "Invalid recurring transaction"
+// @Failure 500 {object} map[string]string "Unable to create recurring transaction"
+// @Router /recurring [post]
+func CreateRecurringTransaction(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[CreateRecurringTransaction] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[CreateRecurringTransaction] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	var request RecurringTransactionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[CreateRecurringTransaction] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	recurring := interfaces.RecurringTransaction{UserID: userInfo.UserID, ScopeID: userInfo.UseScope}
+	if err := request.apply(&recurring); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := impl.GetModelsService().RecurringTransactionModel.InsertRecurringTransaction(c, &recurring); err != nil {
+		log.Printf("[CreateRecurringTransaction] Error: %v", err)
+		respondRecurringError(c, err, "unable to create recurring transaction")
+		return
+	}
+
+	c.JSON(http.StatusCreated, recurring)
+}
+
+// GetRecurringTransaction
+// @Summary Get a recurring transaction
+// @Description Get a recurring transaction of the active scope by its ID
+// @ID get-recurring-transaction
+// @Produce  json
+// @Param id path int true "Recurring transaction ID"
+// @Success 200 {object} interfaces.RecurringTransaction
+// @Failure 404 {object} map[string]string "Recurring transaction not found"
+// @Router /recurring/{id} [get]
+func GetRecurringTransaction(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[GetRecurringTransaction] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[GetRecurringTransaction] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	recurringID, ok := getRecurringID(c)
+	if !ok {
+		return
+	}
+
+	recurring, err := impl.GetModelsService().RecurringTransactionModel.GetRecurringTransactionByID(c, recurringID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[GetRecurringTransaction] Error: %v", err)
+		respondRecurringError(c, err, "unable to fetch recurring transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// UpdateRecurringTransaction
+// @Summary Update a recurring transaction
+// @Description Change the template or schedule of a recurring transaction. Occurrences already created or skipped are kept.
+// @ID update-recurring-transaction
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Recurring transaction ID"
+// @Param recurring body RecurringTransactionRequest true "Fields to change"
+// @Success 200 {object} interfaces.RecurringTransaction
+// @Failure 400 {object} map[string]string "Invalid recurring transaction"
+// @Failure 404 {object} map[string]string "Recurring transaction not found"
+// @Router /recurring/{id} [put]
+func UpdateRecurringTransaction(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[UpdateRecurringTransaction] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[UpdateRecurringTransaction] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	recurringID, ok := getRecurringID(c)
+	if !ok {
+		return
+	}
+
+	var request RecurringTransactionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[UpdateRecurringTransaction] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	recurring, err := impl.GetModelsService().RecurringTransactionModel.GetRecurringTransactionByID(c, recurringID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[UpdateRecurringTransaction] Error: %v", err)
+		respondRecurringError(c, err, "unable to fetch recurring transaction")
+		return
+	}
+	if err := request.apply(recurring); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	recurring.UserID = userInfo.UserID
+
+	if err := impl.GetModelsService().RecurringTransactionModel.UpdateRecurringTransaction(c, recurring); err != nil {
+		log.Printf("[UpdateRecurringTransaction] Error: %v", err)
+		respondRecurringError(c, err, "unable to update recurring transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// DeleteRecurringTransaction
+// @Summary Delete a recurring transaction
+// @Description Stop and remove a recurring transaction. Transactions it already created are kept.
+// @ID delete-recurring-transaction
+// @Produce  json
+// @Param id path int true "Recurring transaction ID"
+// @Success 200 {object} map[string]string "message: recurring transaction deleted successfully"
+// @Failure 404 {object} map[string]string "Recurring transaction not found"
+// @Router /recurring/{id} [delete]
+func DeleteRecurringTransaction(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[DeleteRecurringTransaction] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[DeleteRecurringTransaction] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	recurringID, ok := getRecurringID(c)
+	if !ok {
+		return
+	}
+
+	if err := impl.GetModelsService().RecurringTransactionModel.DeleteRecurringTransaction(c, recurringID, []int64{userInfo.UseScope}); err != nil {
+		log.Printf("[DeleteRecurringTransaction] Error: %v", err)
+		respondRecurringError(c, err, "unable to delete recurring transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "recurring transaction deleted successfully"})
+}
+
+// SkipRecurringTransaction
+// @Summary Skip the next occurrence
+// @Description Mark the upcoming occurrence of a recurring transaction as skipped so it is never created
+// @ID skip-recurring-transaction
+// @Produce  json
+// @Param id path int true "Recurring transaction ID"
+// @Success 200 {object} interfaces.RecurringTransaction
+// @Failure 404 {object} map[string]string "Recurring transaction not found"
+// @Failure 409 {object} map[string]string "No upcoming occurrence"
+// @Router /recurring/{id}/skip [post]
+func SkipRecurringTransaction(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[SkipRecurringTransaction] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[SkipRecurringTransaction] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	recurringID, ok := getRecurringID(c)
+	if !ok {
+		return
+	}
+
+	recurring, err := impl.GetModelsService().RecurringTransactionModel.SkipNextOccurrence(c, recurringID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[SkipRecurringTransaction] Error: %v", err)
+		respondRecurringError(c, err, "unable to skip occurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func initRecurringTest(t *testing.T) *xmock.MockRecurringTransactionModel {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+
+	mockRecurringModel := new(xmock.MockRecurringTransactionModel)
+	modelsService.RecurringTransactionModel = mockRecurringModel
+	return mockRecurringModel
+}
+
+func newRecurringTestContext(w *httptest.ResponseRecorder, method, path, body string) *gin.Context {
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, path, strings.NewReader(body))
+	c.Set("scopeInfo", ScopeInfo{UserID: 1, OwnerScope: 10, UseScope: 10, Scopes: []int64{10}, Role: "write"})
+	return c
+}
+
+func TestCreateRecurringTransaction(t *testing.T) {
+	mockRecurringModel := initRecurringTest(t)
+	defer mockRecurringModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Monthly rent",
+			requestBody: `{"source_id":2,"category_id":3,"amount":1200,"type":"EXPENSE","description":"Rent","frequency":"MONTHLY","start_date":"2024-01-31","count":12}`,
+			setupMock: func() {
+				expected := &interfaces.RecurringTransaction{UserID: 1, ScopeID: 10, SourceID: 2, CategoryID: 3, Amount: interfaces.MoneyFromFloat(1200), Type: "EXPENSE",
+					Description: "Rent", Frequency: "MONTHLY", StartDate: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Count: 12}
+				mockRecurringModel.On("InsertRecurringTransaction", mock.Anything, expected, mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"frequency":"MONTHLY"`,
+		},
+		{
+			name:           "Malformed start date",
+			requestBody:    `{"amount":10,"type":"EXPENSE","frequency":"DAILY","start_date":"31/01/2024"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "YYYY-MM-DD",
+		},
+		{
+			name:        "Invalid schedule",
+			requestBody: `{"amount":10,"type":"EXPENSE","frequency":"HOURLY"}`,
+			setupMock: func() {
+				mockRecurringModel.On("InsertRecurringTransaction", mock.Anything, mock.MatchedBy(func(rt *interfaces.RecurringTransaction) bool { return rt.Frequency == "HOURLY" }), mock.Anything).
+					Return(impl.ErrInvalidRecurrence).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   impl.ErrInvalidRecurrence.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "POST", "/recurring", tc.requestBody)
+
+			CreateRecurringTransaction(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestSkipRecurringTransaction(t *testing.T) {
+	mockRecurringModel := initRecurringTest(t)
+	defer mockRecurringModel.AssertExpectations(t)
+
+	next := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		recurringID    string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Skipped",
+			recurringID: "7",
+			setupMock: func() {
+				mockRecurringModel.On("SkipNextOccurrence", mock.Anything, int64(7), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).
+					Return(&interfaces.RecurringTransaction{ID: 7, Occurrences: 2, NextOccurrence: &next}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"next_occurrence":"2024-03-31T00:00:00Z"`,
+		},
+		{
+			name:        "Schedule ended",
+			recurringID: "8",
+			setupMock: func() {
+				mockRecurringModel.On("SkipNextOccurrence", mock.Anything, int64(8), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).
+					Return(nil, impl.ErrRecurrenceFinished).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   impl.ErrRecurrenceFinished.Error(),
+		},
+		{
+			name:        "Not found",
+			recurringID: "9",
+			setupMock: func() {
+				mockRecurringModel.On("SkipNextOccurrence", mock.Anything, int64(9), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).
+					Return(nil, impl.ErrRecurringNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "recurring transaction not found",
+		},
+		{
+			name:           "Invalid ID",
+			recurringID:    "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid recurring transaction ID format",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "POST", "/recurring/"+tc.recurringID+"/skip", "")
+			c.Params = gin.Params{gin.Param{Key: "id", Value: tc.recurringID}}
+
+			SkipRecurringTransaction(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"xspends/models/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// GetReport
+// @Summary Aggregated transaction report
+// @Description Total income, expense and net of the active scope's transactions, bucketed by category, tag, source, member, day, week or month. Days, weeks and months are those of the user's time zone, and weeks start on the user's first day of the week. Accepts the same filters as the transaction list. Category reports can be rolled up to a level of the category tree. Transfers are not counted. Amounts are converted to the requested currency, by default the scope's base currency, at the exchange rate of each transaction's date.
+// @ID get-report
+// @Produce  json
+// @Param group_by path string true "category, tag, source, member, day, week or month"
+// @Param start_date query string false "Only transactions on or after this time"
+// @Param end_date query string false "Only transactions on or before this time"
+// @Param category query string false "Category ID"
+// @Param include_descendants query bool false "Also match the subcategories of the category"
+// @Param type query string false "INCOME or EXPENSE"
+// @Param tags query []string false "Tag IDs"
+// @Param source_id query int false "Source ID"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param currency query string false "Currency to report in, defaults to the base currency of the scope"
+// @Param level query int false "With group_by=category, add subcategories to their ancestor at this level of the tree, 1 being the top level"
+// @Success 200 {array} interfaces.ReportRow
+// @Failure 400 {object} map[string]string "Unsupported grouping, currency or level"
+// @Failure 500 {object} map[string]string "Unable to compute report"
+// @Router /reports/{group_by} [get]
+func GetReport(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[GetReport] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[GetReport] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	currency := c.Query("currency")
+	if currency == "" {
+		var err error
+		if currency, err = impl.GetModelsService().CurrencyModel.GetBaseCurrency(c, userInfo.UseScope); err != nil {
+			log.Printf("[GetReport] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to compute report"})
+			return
+		}
+	}
+
+	level := 0
+	if levelStr := c.Query("level"); levelStr != "" {
+		var err error
+		if level, err = strconv.Atoi(levelStr); err != nil || level < 1 || c.Param("group_by") != impl.ReportGroupByCategory {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "level must be a positive number and needs group_by category"})
+			return
+		}
+	}
+
+	filter := transactionFilterFromQuery(c, userInfo)
+	switch c.Param("group_by") {
+	case impl.ReportGroupByDay, impl.ReportGroupByWeek, impl.ReportGroupByMonth:
+		if filter.Location == nil {
+			applyUserPreferences(c, &filter, userInfo.UserID)
+		}
+	}
+	report, err := impl.GetModelsService().TransactionModel.GetTransactionReport(c, filter, c.Param("group_by"), currency)
+	if err != nil {
+		log.Printf("[GetReport] Error: %v", err)
+		switch errors.Cause(err) {
+		case impl.ErrInvalidReportGrouping, impl.ErrInvalidCurrency:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to compute report"})
+		return
+	}
+	if level > 0 {
+		if report, err = impl.GetModelsService().CategoryModel.RollUpReport(c, report, level, filter.Scopes); err != nil {
+			log.Printf("[GetReport] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to compute report"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
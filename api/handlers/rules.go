@@ -0,0 +1,385 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// RuleRequest is the body for creating or replacing a rule. Enabled defaults to true on
+// create and keeps its current value on update when left out.
+type RuleRequest struct {
+	Name           string                    `json:"name"`
+	Priority       int                       `json:"priority"`
+	Enabled        *bool                     `json:"enabled"`
+	StopProcessing bool                      `json:"stop_processing"`
+	Conditions     interfaces.RuleConditions `json:"conditions"`
+	Actions        interfaces.RuleActions    `json:"actions"`
+}
+
+// RuleRunRequest names the rule to test or apply: a saved rule by ID, or an unsaved rule.
+type RuleRunRequest struct {
+	RuleID int64        `json:"rule_id"`
+	Rule   *RuleRequest `json:"rule"`
+}
+
+func getRuleID(c *gin.Context) (int64, bool) {
+	ruleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Printf("[getRuleID] Error: invalid rule ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID format"})
+		return 0, false
+	}
+	return ruleID, true
+}
+
+// apply copies the request onto rule.
+func (r RuleRequest) apply(rule *interfaces.Rule) {
+	rule.Name = r.Name
+	rule.Priority = r.Priority
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+	rule.StopProcessing = r.StopProcessing
+	rule.Conditions = r.Conditions
+	rule.Actions = r.Actions
+}
+
+func respondRuleError(c *gin.Context, err error, fallback string) {
+	switch errors.Cause(err) {
+	case impl.ErrRuleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+	case impl.ErrInvalidRule:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListRules
+// @Summary List rules
+// @Description Get the categorization rules of the active scope, in the order they are evaluated
+// @ID list-rules
+// @Produce  json
+// @Success 200 {array} interfaces.Rule
+// @Failure 500 {object} map[string]string "Unable to fetch rules"
+// @Router /rules [get]
+func ListRules(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ListRules] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ListRules] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	rules, err := impl.GetModelsService().RuleModel.GetRulesByScope(c, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[ListRules] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule
+// @Summary Create a rule
+// @Description Add a rule that categorizes, tags or renames new transactions of the active scope
+// @ID create-rule
+// @Accept  json
+// @Produce  json
+// @Param rule body RuleRequest true "Rule info"
+// @Success 201 {object} interfaces.Rule
+// @Failure 400 {object} map[string]string "Invalid rule"
+// @Failure 500 {object} map[string]string "Unable to create rule"
+// @Router /rules [post]
+func CreateRule(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[CreateRule] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[CreateRule] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	var request RuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[CreateRule] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule := interfaces.Rule{UserID: userInfo.UserID, ScopeID: userInfo.UseScope, Enabled: true}
+	request.apply(&rule)
+
+	if err := impl.GetModelsService().RuleModel.InsertRule(c, &rule); err != nil {
+		log.Printf("[CreateRule] Error: %v", err)
+		respondRuleError(c, err, "unable to create rule")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetRule
+// @Summary Get a rule
+// @Description Get a rule of the active scope by its ID
+// @ID get-rule
+// @Produce  json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} interfaces.Rule
+// @Failure 404 {object} map[string]string "Rule not found"
+// @Router /rules/{id} [get]
+func GetRule(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[GetRule] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[GetRule] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	ruleID, ok := getRuleID(c)
+	if !ok {
+		return
+	}
+
+	rule, err := impl.GetModelsService().RuleModel.GetRuleByID(c, ruleID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[GetRule] Error: %v", err)
+		respondRuleError(c, err, "unable to fetch rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule
+// @Summary Replace a rule
+// @Description Replace the name, priority, conditions and actions of a rule
+// @ID update-rule
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Rule ID"
+// @Param rule body RuleRequest true "Rule info"
+// @Success 200 {object} interfaces.Rule
+// @Failure 400 {object} map[string]string "Invalid rule"
+// @Failure 404 {object} map[string]string "Rule not found"
+// @Router /rules/{id} [put]
+func UpdateRule(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[UpdateRule] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[UpdateRule] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	ruleID, ok := getRuleID(c)
+	if !ok {
+		return
+	}
+
+	var request RuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[UpdateRule] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := impl.GetModelsService().RuleModel.GetRuleByID(c, ruleID, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[UpdateRule] Error: %v", err)
+		respondRuleError(c, err, "unable to fetch rule")
+		return
+	}
+	request.apply(rule)
+	rule.UserID = userInfo.UserID
+
+	if err := impl.GetModelsService().RuleModel.UpdateRule(c, rule); err != nil {
+		log.Printf("[UpdateRule] Error: %v", err)
+		respondRuleError(c, err, "unable to update rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule
+// @Summary Delete a rule
+// @Description Delete a rule of the active scope. Transactions it already changed are kept as they are.
+// @ID delete-rule
+// @Produce  json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} map[string]string "message: rule deleted successfully"
+// @Failure 404 {object} map[string]string "Rule not found"
+// @Router /rules/{id} [delete]
+func DeleteRule(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[DeleteRule] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[DeleteRule] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	ruleID, ok := getRuleID(c)
+	if !ok {
+		return
+	}
+
+	if err := impl.GetModelsService().RuleModel.DeleteRule(c, ruleID, []int64{userInfo.UseScope}); err != nil {
+		log.Printf("[DeleteRule] Error: %v", err)
+		respondRuleError(c, err, "unable to delete rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "rule deleted successfully"})
+}
+
+// TestRule
+// @Summary Preview a rule
+// @Description List the existing transactions a saved or unsaved rule matches and what it would change, without changing anything.
+// @Description Transactions are selected with the filters of GET /transactions; paging is ignored and transfers are left out.
+// @ID test-rule
+// @Accept  json
+// @Produce  json
+// @Param rule body RuleRunRequest true "Rule to test"
+// @Param start_date query string false "Start Date"
+// @Param end_date query string false "End Date"
+// @Param category query string false "Category"
+// @Param source_id query int false "Source ID"
+// @Success 200 {object} map[string]interface{} "matched: count, matches: the matched transactions"
+// @Failure 400 {object} map[string]string "Invalid rule"
+// @Failure 404 {object} map[string]string "Rule not found"
+// @Router /rules/test [post]
+func TestRule(c *gin.Context) {
+	runRule(c, "TestRule", false)
+}
+
+// ApplyRule
+// @Summary Apply a rule to existing transactions
+// @Description Run a saved or unsaved rule on the existing transactions matching the filters of GET /transactions, in one SQL transaction.
+// @Description Categories set by the rule replace the current ones. Paging is ignored and transfers are left out.
+// @ID apply-rule
+// @Accept  json
+// @Produce  json
+// @Param rule body RuleRunRequest true "Rule to apply"
+// @Param start_date query string false "Start Date"
+// @Param end_date query string false "End Date"
+// @Param category query string false "Category"
+// @Param source_id query int false "Source ID"
+// @Success 200 {object} map[string]interface{} "matched: count, matches: the matched transactions"
+// @Failure 400 {object} map[string]string "Invalid rule"
+// @Failure 404 {object} map[string]string "Rule not found"
+// @Router /rules/apply [post]
+func ApplyRule(c *gin.Context) {
+	runRule(c, "ApplyRule", true)
+}
+
+// runRule resolves the rule of a RuleRunRequest and tests or applies it on the transactions selected by the query.
+func runRule(c *gin.Context, name string, apply bool) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[%s] Error: %v", name, "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[%s] Error: %v", name, "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	var request RuleRunRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[%s] Error: %v", name, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var rule interfaces.Rule
+	switch {
+	case request.RuleID != 0 && request.Rule == nil:
+		saved, err := impl.GetModelsService().RuleModel.GetRuleByID(c, request.RuleID, []int64{userInfo.UseScope})
+		if err != nil {
+			log.Printf("[%s] Error: %v", name, err)
+			respondRuleError(c, err, "unable to fetch rule")
+			return
+		}
+		rule = *saved
+	case request.RuleID == 0 && request.Rule != nil:
+		rule = interfaces.Rule{UserID: userInfo.UserID, ScopeID: userInfo.UseScope}
+		request.Rule.apply(&rule)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pass either rule_id or rule"})
+		return
+	}
+
+	filter := transactionFilterFromQuery(c, userInfo)
+	filter.Page = 0
+	var matches []interfaces.RuleMatch
+	var err error
+	if apply {
+		matches, err = impl.GetModelsService().RuleModel.ApplyRule(c, rule, filter)
+	} else {
+		matches, err = impl.GetModelsService().RuleModel.TestRule(c, rule, filter)
+	}
+	if err != nil {
+		log.Printf("[%s] Error: %v", name, err)
+		respondRuleError(c, err, "unable to run rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"matched": len(matches), "matches": matches})
+}
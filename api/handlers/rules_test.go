@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func initRuleTest(t *testing.T) *xmock.MockRuleModel {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+
+	mockRuleModel := new(xmock.MockRuleModel)
+	modelsService.RuleModel = mockRuleModel
+	return mockRuleModel
+}
+
+func TestCreateRule(t *testing.T) {
+	mockRuleModel := initRuleTest(t)
+	defer mockRuleModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Enabled by default",
+			requestBody: `{"name":"Coffee","priority":1,"conditions":{"description_contains":"coffee"},"actions":{"set_category_id":4,"add_tags":["cafe"]}}`,
+			setupMock: func() {
+				expected := &interfaces.Rule{UserID: 1, ScopeID: 10, Name: "Coffee", Priority: 1, Enabled: true,
+					Conditions: interfaces.RuleConditions{DescriptionContains: "coffee"},
+					Actions:    interfaces.RuleActions{SetCategoryID: 4, AddTags: []string{"cafe"}}}
+				mockRuleModel.On("InsertRule", mock.Anything, expected, mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"enabled":true`,
+		},
+		{
+			name:        "Invalid regex",
+			requestBody: `{"name":"Broken","conditions":{"description_regex":"("},"actions":{"add_tags":["x"]}}`,
+			setupMock: func() {
+				mockRuleModel.On("InsertRule", mock.Anything, mock.MatchedBy(func(r *interfaces.Rule) bool { return r.Name == "Broken" }), mock.Anything).
+					Return(impl.ErrInvalidRule).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   impl.ErrInvalidRule.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "POST", "/rules", tc.requestBody)
+
+			CreateRule(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestUpdateRule(t *testing.T) {
+	mockRuleModel := initRuleTest(t)
+	defer mockRuleModel.AssertExpectations(t)
+
+	existing := &interfaces.Rule{ID: 5, UserID: 2, ScopeID: 10, Name: "Old", Enabled: false}
+	mockRuleModel.On("GetRuleByID", mock.Anything, int64(5), []int64{10}, mock.Anything).Return(existing, nil).Once()
+	mockRuleModel.On("UpdateRule", mock.Anything, mock.MatchedBy(func(r *interfaces.Rule) bool {
+		return r.ID == 5 && r.UserID == 1 && r.Name == "Rent" && !r.Enabled && r.Actions.SetDescription == "Rent"
+	}), mock.Anything).Return(nil).Once()
+	mockRuleModel.On("GetRuleByID", mock.Anything, int64(6), []int64{10}, mock.Anything).Return(nil, impl.ErrRuleNotFound).Once()
+
+	w := httptest.NewRecorder()
+	c := newRecurringTestContext(w, "PUT", "/rules/5", `{"name":"Rent","conditions":{"description_contains":"rent"},"actions":{"set_description":"Rent"}}`)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	UpdateRule(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	c = newRecurringTestContext(w, "PUT", "/rules/6", `{"name":"Rent"}`)
+	c.Params = gin.Params{{Key: "id", Value: "6"}}
+	UpdateRule(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRunRule(t *testing.T) {
+	mockRuleModel := initRuleTest(t)
+	defer mockRuleModel.AssertExpectations(t)
+
+	saved := &interfaces.Rule{ID: 5, UserID: 2, ScopeID: 10, Name: "Rent",
+		Conditions: interfaces.RuleConditions{DescriptionContains: "rent"},
+		Actions:    interfaces.RuleActions{SetCategoryID: 3}}
+	matches := []interfaces.RuleMatch{{TransactionID: 1, Description: "RENT JAN", NewCategoryID: 3}}
+
+	tests := []struct {
+		name           string
+		handler        gin.HandlerFunc
+		path           string
+		requestBody    string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Test an unsaved rule",
+			handler:     TestRule,
+			path:        "/rules/test?start_date=2024-01-01&page=2",
+			requestBody: `{"rule":{"name":"Rent","conditions":{"description_contains":"rent"},"actions":{"set_category_id":3}}}`,
+			setupMock: func() {
+				mockUserModel := impl.GetModelsService().UserModel.(*xmock.MockUserModel)
+				mockUserModel.On("GetUserByID", mock.Anything, int64(1), mock.Anything).Return(&interfaces.User{ID: 1, Timezone: "UTC"}, nil).Once()
+				mockRuleModel.On("TestRule", mock.Anything, mock.MatchedBy(func(r interfaces.Rule) bool {
+					return r.ID == 0 && r.UserID == 1 && r.ScopeID == 10 && r.Actions.SetCategoryID == 3
+				}), mock.MatchedBy(func(f interfaces.TransactionFilter) bool {
+					return f.StartDate == "2024-01-01" && f.Page == 0 && f.UserID == 1
+				}), mock.Anything).Return(matches, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"matched":1`,
+		},
+		{
+			name:        "Apply a saved rule",
+			handler:     ApplyRule,
+			path:        "/rules/apply?category=7",
+			requestBody: `{"rule_id":5}`,
+			setupMock: func() {
+				mockRuleModel.On("GetRuleByID", mock.Anything, int64(5), []int64{10}, mock.Anything).Return(saved, nil).Once()
+				mockRuleModel.On("ApplyRule", mock.Anything, *saved, mock.MatchedBy(func(f interfaces.TransactionFilter) bool {
+					return f.Category == "7"
+				}), mock.Anything).Return(matches, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"new_category_id":3`,
+		},
+		{
+			name:           "Neither rule nor ID",
+			handler:        ApplyRule,
+			path:           "/rules/apply",
+			requestBody:    `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "rule_id",
+		},
+		{
+			name:        "Unknown rule",
+			handler:     ApplyRule,
+			path:        "/rules/apply",
+			requestBody: `{"rule_id":6}`,
+			setupMock: func() {
+				mockRuleModel.On("GetRuleByID", mock.Anything, int64(6), []int64{10}, mock.Anything).Return(nil, impl.ErrRuleNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "rule not found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "POST", tc.path, tc.requestBody)
+
+			tc.handler(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
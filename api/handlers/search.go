@@ -0,0 +1,82 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"xspends/models/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// SearchTransactions
+// @Summary Search transactions
+// @Description Full-text search of the active scope's transactions by description and by tag, category and source names, ranked by relevance. Supports "quoted phrases", prefix* words and the qualifiers tag:, category:, source:, description:, amount: (>100, <=50, 10..20) and type:. Every transaction list filter applies as well.
+// @ID search-transactions
+// @Produce  json
+// @Param q query string true "The search query"
+// @Param page query int false "Page Number"
+// @Param items_per_page query int false "Items Per Page"
+// @Success 200 {object} map[string]interface{} "The total number of matches and the requested page of results"
+// @Failure 400 {object} map[string]string "Missing or invalid query"
+// @Failure 500 {object} map[string]string "Unable to search transactions"
+// @Router /transactions/search [get]
+func SearchTransactions(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[SearchTransactions] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[SearchTransactions] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	filter := transactionFilterFromQuery(c, userInfo)
+	results, total, err := impl.GetModelsService().TransactionSearchModel.SearchTransactions(c, filter, query)
+	if err != nil {
+		if errors.Cause(err) == impl.ErrInvalidSearchQuery {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("[SearchTransactions] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to search transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": total, "results": results})
+}
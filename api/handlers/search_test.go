@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchTransactions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+	mockSearchModel := new(xmock.MockTransactionSearchModel)
+	modelsService.TransactionSearchModel = mockSearchModel
+	defer mockSearchModel.AssertExpectations(t)
+
+	results := []interfaces.TransactionSearchResult{{
+		TransactionExport: interfaces.TransactionExport{ID: 7, Description: "Hotel in Rome", Tags: []string{"travel"}},
+		Score:             5,
+		Highlights:        map[string][]string{"description": {"<mark>Hotel</mark> in Rome"}},
+	}}
+
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Ranked results",
+			path: "/transactions/search?q=tag%3Atravel+hotel*&start_date=2024-01-01&page=2",
+			setupMock: func() {
+				mockUserModel := impl.GetModelsService().UserModel.(*xmock.MockUserModel)
+				mockUserModel.On("GetUserByID", mock.Anything, int64(1), mock.Anything).Return(&interfaces.User{ID: 1}, nil).Once()
+				mockSearchModel.On("SearchTransactions", mock.Anything, mock.MatchedBy(func(f interfaces.TransactionFilter) bool {
+					return f.StartDate == "2024-01-01" && f.Page == 2 && f.Scopes[0] == 10
+				}), "tag:travel hotel*", mock.Anything).Return(results, 11, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"total":11`,
+		},
+		{
+			name:           "Missing query",
+			path:           "/transactions/search?q=+",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "q is required",
+		},
+		{
+			name: "Invalid query",
+			path: "/transactions/search?q=amount%3Alots",
+			setupMock: func() {
+				mockSearchModel.On("SearchTransactions", mock.Anything, mock.Anything, "amount:lots", mock.Anything).
+					Return(nil, 0, errors.Wrap(impl.ErrInvalidSearchQuery, `amount "lots"`)).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid search query",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "GET", tc.path, "")
+
+			SearchTransactions(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
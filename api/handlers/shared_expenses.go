@@ -0,0 +1,373 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// SharedExpenseRequest sets who paid a group expense and how its cost is split.
+// Values hold exact amounts, percentages or shares, per split method; an equal split ignores them.
+type SharedExpenseRequest struct {
+	PayerID     int64                     `json:"payer_id"`
+	SplitMethod string                    `json:"split_method"`
+	Shares      []interfaces.ExpenseShare `json:"shares"`
+}
+
+// SettlementRequest records a payment between two members of a group. The currency defaults to the
+// group's base currency and the date (YYYY-MM-DD) to today.
+type SettlementRequest struct {
+	FromUserID int64            `json:"from_user_id"`
+	ToUserID   int64            `json:"to_user_id"`
+	Amount     interfaces.Money `json:"amount"`
+	Currency   string           `json:"currency"`
+	Note       string           `json:"note"`
+	SettledAt  string           `json:"settled_at"`
+}
+
+func respondSharedExpenseError(c *gin.Context, err error, fallback string) {
+	switch errors.Cause(err) {
+	case impl.ErrSharedExpenseNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "shared expense not found"})
+	case impl.ErrSettlementNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "settlement not found"})
+	case impl.ErrInvalidSharedExpense, impl.ErrInvalidSettlement, impl.ErrInvalidCurrency:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// getMemberGroup loads the group of the request for a member holding role. Groups the user does
+// not belong to are reported as not found; members without the role are refused.
+func getMemberGroup(c *gin.Context, currentUserID int64, role string) (*interfaces.Group, bool) {
+	groupID, ok := getGroupID(c)
+	if !ok {
+		return nil, false
+	}
+
+	group, err := impl.GetModelsService().GroupModel.GetGroupByID(c, groupID, currentUserID)
+	if err != nil || !impl.GetModelsService().UserScopeModel.ValidateUserScope(c, currentUserID, group.ScopeID, impl.RoleView) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return nil, false
+	}
+	if role != impl.RoleView && !impl.GetModelsService().UserScopeModel.ValidateUserScope(c, currentUserID, group.ScopeID, role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to change this group"})
+		return nil, false
+	}
+	return group, true
+}
+
+// GetGroupBalances
+// @Summary Balances of a group
+// @Description Get the net position of each member from the shared expenses and settlements of the group, per currency, and the fewest payments that settle them
+// @ID get-group-balances
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {object} interfaces.GroupBalances
+// @Failure 404 {object} map[string]string "Group not found"
+// @Failure 500 {object} map[string]string "Unable to compute balances"
+// @Router /groups/{id}/balances [get]
+func GetGroupBalances(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+	group, ok := getMemberGroup(c, currentUserID, impl.RoleView)
+	if !ok {
+		return
+	}
+
+	balances, plan, err := impl.GetModelsService().SharedExpenseModel.GetBalances(c, group.ScopeID)
+	if err != nil {
+		log.Printf("[GetGroupBalances] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to compute balances"})
+		return
+	}
+
+	c.JSON(http.StatusOK, interfaces.GroupBalances{GroupID: group.GroupID, Balances: balances, SettleUp: plan})
+}
+
+// ListSettlements
+// @Summary List the settlements of a group
+// @Description Get the payments recorded between members of the group, latest first
+// @ID list-settlements
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Success 200 {array} interfaces.Settlement
+// @Failure 404 {object} map[string]string "Group not found"
+// @Router /groups/{id}/settlements [get]
+func ListSettlements(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+	group, ok := getMemberGroup(c, currentUserID, impl.RoleView)
+	if !ok {
+		return
+	}
+
+	settlements, err := impl.GetModelsService().SharedExpenseModel.GetSettlements(c, group.ScopeID)
+	if err != nil {
+		log.Printf("[ListSettlements] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch settlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settlements)
+}
+
+// CreateSettlement
+// @Summary Record a settlement
+// @Description Record a payment between two members. Members can record payments they made or received; recording payments between others requires write access.
+// @ID create-settlement
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param settlement body SettlementRequest true "Payment to record"
+// @Success 201 {object} interfaces.Settlement
+// @Failure 400 {object} map[string]string "Invalid settlement"
+// @Failure 403 {object} map[string]string "Unauthorized to change this group"
+// @Failure 404 {object} map[string]string "Group not found"
+// @Router /groups/{id}/settlements [post]
+func CreateSettlement(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request SettlementRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[CreateSettlement] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid settlement data"})
+		return
+	}
+
+	settlement := interfaces.Settlement{
+		FromUserID: request.FromUserID,
+		ToUserID:   request.ToUserID,
+		Amount:     request.Amount,
+		Currency:   request.Currency,
+		Note:       request.Note,
+		CreatedBy:  currentUserID,
+	}
+	if request.SettledAt != "" {
+		settledAt, err := parseDate("settled_at", request.SettledAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		settlement.SettledAt = settledAt
+	}
+
+	role := impl.RoleWrite
+	if request.FromUserID == currentUserID || request.ToUserID == currentUserID {
+		role = impl.RoleView
+	}
+	group, ok := getMemberGroup(c, currentUserID, role)
+	if !ok {
+		return
+	}
+	settlement.ScopeID = group.ScopeID
+
+	if err := impl.GetModelsService().SharedExpenseModel.InsertSettlement(c, &settlement); err != nil {
+		log.Printf("[CreateSettlement] Error: %v", err)
+		respondSharedExpenseError(c, err, "unable to record settlement")
+		return
+	}
+
+	c.JSON(http.StatusCreated, settlement)
+}
+
+// DeleteSettlement
+// @Summary Delete a settlement
+// @Description Delete a settlement recorded by mistake; the balances it covered are owed again
+// @ID delete-settlement
+// @Produce  json
+// @Param id path int true "Group ID"
+// @Param settlementID path int true "Settlement ID"
+// @Success 200 {object} map[string]string "message: settlement deleted successfully"
+// @Failure 403 {object} map[string]string "Unauthorized to change this group"
+// @Failure 404 {object} map[string]string "Settlement not found"
+// @Router /groups/{id}/settlements/{settlementID} [delete]
+func DeleteSettlement(c *gin.Context) {
+	currentUserID, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+	settlementID, err := strconv.ParseInt(c.Param("settlementID"), 10, 64)
+	if err != nil {
+		log.Printf("[DeleteSettlement] Error: invalid settlement ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid settlement ID format"})
+		return
+	}
+	group, ok := getMemberGroup(c, currentUserID, impl.RoleWrite)
+	if !ok {
+		return
+	}
+
+	if err := impl.GetModelsService().SharedExpenseModel.DeleteSettlement(c, settlementID, group.ScopeID); err != nil {
+		log.Printf("[DeleteSettlement] Error: %v", err)
+		respondSharedExpenseError(c, err, "unable to delete settlement")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "settlement deleted successfully"})
+}
+
+// GetTransactionShares
+// @Summary Get the split of a shared expense
+// @Description Get who paid a group expense and each member's share of it
+// @ID get-transaction-shares
+// @Produce  json
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} interfaces.SharedExpense
+// @Failure 404 {object} map[string]string "Shared expense not found"
+// @Router /transactions/{id}/shares [get]
+func GetTransactionShares(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[GetTransactionShares] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[GetTransactionShares] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	transactionID, ok := getTransactionID(c)
+	if !ok {
+		return
+	}
+
+	expense, err := impl.GetModelsService().SharedExpenseModel.GetSharedExpense(c, transactionID, userInfo.UseScope)
+	if err != nil {
+		log.Printf("[GetTransactionShares] Error: %v", err)
+		respondSharedExpenseError(c, err, "unable to fetch shared expense")
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// SetTransactionShares
+// @Summary Share a group expense
+// @Description Set who paid an expense of a group scope and how it is split between members: EQUAL, EXACT amounts, PERCENT or SHARES. The payer defaults to the member who recorded it.
+// @ID set-transaction-shares
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Transaction ID"
+// @Param shares body SharedExpenseRequest true "Payer and split rule"
+// @Success 200 {object} interfaces.SharedExpense
+// @Failure 400 {object} map[string]string "Invalid shared expense"
+// @Router /transactions/{id}/shares [put]
+func SetTransactionShares(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[SetTransactionShares] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[SetTransactionShares] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	transactionID, ok := getTransactionID(c)
+	if !ok {
+		return
+	}
+
+	var request SharedExpenseRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[SetTransactionShares] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shared expense data"})
+		return
+	}
+
+	expense := interfaces.SharedExpense{
+		TransactionID: transactionID,
+		ScopeID:       userInfo.UseScope,
+		PayerID:       request.PayerID,
+		SplitMethod:   request.SplitMethod,
+		Shares:        request.Shares,
+	}
+	if err := impl.GetModelsService().SharedExpenseModel.SetSharedExpense(c, &expense); err != nil {
+		log.Printf("[SetTransactionShares] Error: %v", err)
+		respondSharedExpenseError(c, err, "unable to share expense")
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// DeleteTransactionShares
+// @Summary Stop sharing an expense
+// @Description Remove the payer and split rule from a transaction; the transaction is kept
+// @ID delete-transaction-shares
+// @Produce  json
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} map[string]string "message: shared expense removed successfully"
+// @Failure 404 {object} map[string]string "Shared expense not found"
+// @Router /transactions/{id}/shares [delete]
+func DeleteTransactionShares(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[DeleteTransactionShares] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[DeleteTransactionShares] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	transactionID, ok := getTransactionID(c)
+	if !ok {
+		return
+	}
+
+	if err := impl.GetModelsService().SharedExpenseModel.DeleteSharedExpense(c, transactionID, userInfo.UseScope); err != nil {
+		log.Printf("[DeleteTransactionShares] Error: %v", err)
+		respondSharedExpenseError(c, err, "unable to remove shared expense")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "shared expense removed successfully"})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetGroupBalances(t *testing.T) {
+	mocks := initGroupTest(t)
+	mockSharedExpenseModel := new(xmock.MockSharedExpenseModel)
+	impl.GetModelsService().SharedExpenseModel = mockSharedExpenseModel
+	defer mockSharedExpenseModel.AssertExpectations(t)
+
+	group := &interfaces.Group{GroupID: 10, OwnerID: 1, ScopeID: 100, GroupName: "Trip"}
+	balances := []interfaces.MemberBalance{
+		{UserID: 1, Currency: "USD", Paid: 9000, Share: 3000, Net: 6000},
+		{UserID: 2, Currency: "USD", Share: 6000, Net: -6000},
+	}
+	plan := []interfaces.SettleUpPayment{{FromUserID: 2, ToUserID: 1, Currency: "USD", Amount: 6000}}
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		userID         int64
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Member sees the balances and the settle-up plan",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(2), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.userScope.On("ValidateUserScope", mock.Anything, int64(2), int64(100), impl.RoleView, mock.AnythingOfType("[]*sql.Tx")).Return(true).Once()
+				mockSharedExpenseModel.On("GetBalances", mock.Anything, int64(100), mock.AnythingOfType("[]*sql.Tx")).Return(balances, plan, nil).Once()
+			},
+			userID:         2,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"settle_up":[{"from_user_id":2,"to_user_id":1,"currency":"USD","amount":60.00}]`,
+		},
+		{
+			name: "Not a member",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(3), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.userScope.On("ValidateUserScope", mock.Anything, int64(3), int64(100), impl.RoleView, mock.AnythingOfType("[]*sql.Tx")).Return(false).Once()
+			},
+			userID:         3,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"Group not found"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, w := newGroupTestContext("GET", "", tc.userID, gin.Params{{Key: "id", Value: "10"}})
+			tc.setupMock()
+			GetGroupBalances(c)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+	t.Run("The owner sees the balances", func(t *testing.T) {
+		sqlMock := useUserScopeDB(t)
+		expectRoleCheck(sqlMock, 1, 100, impl.RoleOwner, impl.RoleOwner, impl.RoleView, impl.RoleWrite)
+		mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+		mockSharedExpenseModel.On("GetBalances", mock.Anything, int64(100), mock.AnythingOfType("[]*sql.Tx")).Return(balances, plan, nil).Once()
+
+		c, w := newGroupTestContext("GET", "", 1, gin.Params{{Key: "id", Value: "10"}})
+		GetGroupBalances(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateSettlement(t *testing.T) {
+	mocks := initGroupTest(t)
+	mockSharedExpenseModel := new(xmock.MockSharedExpenseModel)
+	impl.GetModelsService().SharedExpenseModel = mockSharedExpenseModel
+	defer mockSharedExpenseModel.AssertExpectations(t)
+
+	group := &interfaces.Group{GroupID: 10, OwnerID: 1, ScopeID: 100, GroupName: "Trip"}
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		userID         int64
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Member records a payment they made",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(2), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.userScope.On("ValidateUserScope", mock.Anything, int64(2), int64(100), impl.RoleView, mock.AnythingOfType("[]*sql.Tx")).Return(true).Once()
+				mockSharedExpenseModel.On("InsertSettlement", mock.Anything, mock.MatchedBy(func(s *interfaces.Settlement) bool {
+					return s.ScopeID == 100 && s.FromUserID == 2 && s.ToUserID == 1 && s.Amount == 6000 && s.CreatedBy == 2
+				}), mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			userID:         2,
+			body:           `{"from_user_id":2,"to_user_id":1,"amount":60,"settled_at":"2024-05-01"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"from_user_id":2`,
+		},
+		{
+			name: "Viewer cannot record payments between others",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(3), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.userScope.On("ValidateUserScope", mock.Anything, int64(3), int64(100), impl.RoleView, mock.AnythingOfType("[]*sql.Tx")).Return(true).Once()
+				mocks.userScope.On("ValidateUserScope", mock.Anything, int64(3), int64(100), impl.RoleWrite, mock.AnythingOfType("[]*sql.Tx")).Return(false).Once()
+			},
+			userID:         3,
+			body:           `{"from_user_id":2,"to_user_id":1,"amount":60}`,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"Unauthorized to change this group"}`,
+		},
+		{
+			name: "Invalid settlement",
+			setupMock: func() {
+				mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+				mocks.userScope.On("ValidateUserScope", mock.Anything, int64(1), int64(100), impl.RoleView, mock.AnythingOfType("[]*sql.Tx")).Return(true).Once()
+				mockSharedExpenseModel.On("InsertSettlement", mock.Anything, mock.Anything, mock.AnythingOfType("[]*sql.Tx")).Return(impl.ErrInvalidSettlement).Once()
+			},
+			userID:         1,
+			body:           `{"from_user_id":1,"to_user_id":1,"amount":60}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   impl.ErrInvalidSettlement.Error(),
+		},
+		{
+			name:           "Invalid date",
+			setupMock:      func() {},
+			userID:         2,
+			body:           `{"from_user_id":2,"to_user_id":1,"amount":60,"settled_at":"May 1"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "settled_at must use the YYYY-MM-DD format",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, w := newGroupTestContext("POST", tc.body, tc.userID, gin.Params{{Key: "id", Value: "10"}})
+			tc.setupMock()
+			CreateSettlement(c)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+	t.Run("The owner records payments between others", func(t *testing.T) {
+		sqlMock := useUserScopeDB(t)
+		expectRoleCheck(sqlMock, 1, 100, impl.RoleOwner, impl.RoleOwner, impl.RoleView, impl.RoleWrite)
+		expectRoleCheck(sqlMock, 1, 100, impl.RoleOwner, impl.RoleOwner, impl.RoleWrite)
+		mocks.group.On("GetGroupByID", mock.Anything, int64(10), int64(1), mock.AnythingOfType("[]*sql.Tx")).Return(group, nil).Once()
+		mockSharedExpenseModel.On("InsertSettlement", mock.Anything, mock.MatchedBy(func(s *interfaces.Settlement) bool {
+			return s.FromUserID == 2 && s.ToUserID == 3 && s.CreatedBy == 1
+		}), mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+
+		c, w := newGroupTestContext("POST", `{"from_user_id":2,"to_user_id":3,"amount":60}`, 1, gin.Params{{Key: "id", Value: "10"}})
+		CreateSettlement(c)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
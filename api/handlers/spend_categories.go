@@ -32,6 +32,7 @@ import (
 	"xspends/models/interfaces"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 const defaultItemsPerPage = 10
@@ -54,6 +55,15 @@ func getCategoryID(c *gin.Context) (int64, bool) {
 	return categoryID, true
 }
 
+func respondCategoryError(c *gin.Context, err error, fallback string) {
+	switch errors.Cause(err) {
+	case impl.ErrCategoryParentNotFound, impl.ErrCategoryCycle, impl.ErrCategoryTooDeep:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
 // ListCategories
 // @Summary List all categories
 // @Description Get a list of all categories with optional pagination
@@ -62,6 +72,8 @@ func getCategoryID(c *gin.Context) (int64, bool) {
 // @Produce  json
 // @Param page query int false "Page number"
 // @Param items_per_page query int false "Items per page"
+// @Param cursor query string false "Cursor of the page to read; empty for the first page. Switches to cursor paging"
+// @Param limit query int false "Items per page when paging by cursor (default 10, at most 100)"
 // @Success 200 {array} impl.Category
 // @Failure 500 {object} map[string]string "Unable to fetch categories"
 // @Router /categories [get]
@@ -79,6 +91,22 @@ func ListCategories(c *gin.Context) {
 		return
 	}
 
+	params, err := cursorParamsFromQuery(c, "categories")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params != nil {
+		categories, page, err := impl.GetModelsService().CategoryModel.GetCategoriesByCursor(c, []int64{userInfo.UseScope}, *params)
+		if err != nil {
+			log.Printf("[ListCategories] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch categories"})
+			return
+		}
+		c.JSON(http.StatusOK, cursorPageResponse("categories", categories, page))
+		return
+	}
+
 	//TODO: Extract literals like this to constants
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	//TODO: Extract literals like this to constants
@@ -99,6 +127,38 @@ func ListCategories(c *gin.Context) {
 	c.JSON(http.StatusOK, categories)
 }
 
+// GetCategoryTree
+// @Summary List categories as a tree
+// @Description Get the categories of the active scope with their subcategories nested under them, each level sorted by name
+// @ID get-category-tree
+// @Produce  json
+// @Success 200 {array} interfaces.CategoryNode
+// @Failure 500 {object} map[string]string "Unable to fetch categories"
+// @Router /categories/tree [get]
+func GetCategoryTree(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[GetCategoryTree] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[GetCategoryTree] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	tree, err := impl.GetModelsService().CategoryModel.GetCategoryTree(c, []int64{userInfo.UseScope})
+	if err != nil {
+		log.Printf("[GetCategoryTree] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
 // GetCategory
 // @Summary Get a specific category
 // @Description Get a specific category by its ID
@@ -175,7 +235,7 @@ func CreateCategory(c *gin.Context) {
 	newCategory.ScopeID = userInfo.UseScope
 	if err := impl.GetModelsService().CategoryModel.InsertCategory(c, &newCategory, nil); err != nil {
 		log.Printf("[CreateCategory] Error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to create category"})
+		respondCategoryError(c, err, "unable to create category")
 		return
 	}
 
@@ -220,7 +280,7 @@ func UpdateCategory(c *gin.Context) {
 	updatedCategory.ScopeID = userInfo.UseScope
 	if err := impl.GetModelsService().CategoryModel.UpdateCategory(c, &updatedCategory, nil); err != nil {
 		log.Printf("[UpdateCategory] Error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to update category"})
+		respondCategoryError(c, err, "unable to update category")
 		return
 	}
 
@@ -229,12 +289,15 @@ func UpdateCategory(c *gin.Context) {
 
 // DeleteCategory
 // @Summary Delete a specific category
-// @Description Delete a specific category by its ID
+// @Description Move a category to the trash, from where it can be restored until it is purged. A category still used by transactions is only deleted with reassign_to, which moves them to another category first
 // @ID delete-category
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Category ID"
+// @Param reassign_to query int false "Category that receives the transactions of the deleted one"
 // @Success 200 {object} map[string]string "Message: Category deleted successfully"
+// @Failure 400 {object} map[string]string "Invalid reassign_to"
+// @Failure 409 {object} map[string]interface{} "Category in use, with the number of transactions affected"
 // @Failure 500 {object} map[string]string "Unable to delete category"
 // @Router /categories/{id} [delete]
 func DeleteCategory(c *gin.Context) {
@@ -257,9 +320,14 @@ func DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := impl.GetModelsService().CategoryModel.DeleteCategory(c, categoryID, []int64{userInfo.UseScope}, nil); err != nil {
+	reassignTo, ok := getReassignTo(c)
+	if !ok {
+		return
+	}
+
+	if err := impl.GetModelsService().CategoryModel.DeleteCategory(c, categoryID, reassignTo, []int64{userInfo.UseScope}, nil); err != nil {
 		log.Printf("[DeleteCategory] Error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to delete category"})
+		respondDeleteError(c, err, "unable to delete category")
 		return
 	}
 
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"xspends/models/impl"
 	"xspends/models/interfaces"
 	xmock "xspends/models/mock"
 	"xspends/testutils"
@@ -511,7 +512,7 @@ func TestDeleteCategory(t *testing.T) {
 		{
 			name: "Successful deletion",
 			setupMock: func() {
-				mockCategoryModel.On("DeleteCategory", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int64"), mock.AnythingOfType("[]int64"), mock.AnythingOfType("[]*sql.Tx")).
+				mockCategoryModel.On("DeleteCategory", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("[]int64"), mock.AnythingOfType("[]*sql.Tx")).
 					Return(nil).Once()
 			},
 			userID:         "1",
@@ -545,7 +546,7 @@ func TestDeleteCategory(t *testing.T) {
 		{
 			name: "Error during category deletion",
 			setupMock: func() {
-				mockCategoryModel.On("DeleteCategory", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int64"), mock.AnythingOfType("[]int64"), mock.AnythingOfType("[]*sql.Tx")).
+				mockCategoryModel.On("DeleteCategory", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("[]int64"), mock.AnythingOfType("[]*sql.Tx")).
 					Return(errors.New("unable to delete category")).Once()
 			},
 			userID:         "1",
@@ -585,3 +586,130 @@ func TestDeleteCategory(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteCategoryInUse(t *testing.T) {
+	mockCategoryModel := initCategoryTest(t)
+	defer mockCategoryModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "No target",
+			query: "",
+			setupMock: func() {
+				mockCategoryModel.On("DeleteCategory", mock.Anything, int64(3), int64(0), []int64{10}, mock.Anything).
+					Return(&impl.InUseError{Item: "category", Transactions: 12, RecurringTransactions: 1}).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"transactions":12`,
+		},
+		{
+			name:  "Used by rules and budgets",
+			query: "",
+			setupMock: func() {
+				mockCategoryModel.On("DeleteCategory", mock.Anything, int64(3), int64(0), []int64{10}, mock.Anything).
+					Return(&impl.InUseError{Item: "category", Rules: 2, Budgets: 1}).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"budgets":1`,
+		},
+		{
+			name:  "Reassigned",
+			query: "?reassign_to=4",
+			setupMock: func() {
+				mockCategoryModel.On("DeleteCategory", mock.Anything, int64(3), int64(4), []int64{10}, mock.Anything).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "category deleted successfully",
+		},
+		{
+			name:           "Invalid target",
+			query:          "?reassign_to=food",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "reassign_to must be a numeric ID",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "DELETE", "/categories/3"+tc.query, "")
+			c.Params = gin.Params{gin.Param{Key: "id", Value: "3"}}
+
+			DeleteCategory(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestGetCategoryTree(t *testing.T) {
+	mockCategoryModel := initCategoryTest(t)
+	defer mockCategoryModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Nested categories",
+			setupMock: func() {
+				tree := []interfaces.CategoryNode{{
+					Category: interfaces.Category{ID: 1, Name: "Food"},
+					Children: []interfaces.CategoryNode{{Category: interfaces.Category{ID: 2, Name: "Groceries", ParentID: 1}, Children: []interfaces.CategoryNode{}}},
+				}}
+				mockCategoryModel.On("GetCategoryTree", mock.Anything, []int64{10}, mock.Anything).Return(tree, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"children":[{"category_id":2`,
+		},
+		{
+			name: "Database error",
+			setupMock: func() {
+				mockCategoryModel.On("GetCategoryTree", mock.Anything, []int64{10}, mock.Anything).Return(nil, errors.New("database error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "unable to fetch categories",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "GET", "/categories/tree", "")
+
+			GetCategoryTree(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestCreateCategoryWithInvalidParent(t *testing.T) {
+	mockCategoryModel := initCategoryTest(t)
+	defer mockCategoryModel.AssertExpectations(t)
+
+	mockCategoryModel.On("InsertCategory", mock.Anything, mock.MatchedBy(func(category *interfaces.Category) bool {
+		return category.ParentID == 7
+	}), mock.Anything).Return(impl.ErrCategoryTooDeep).Once()
+
+	w := httptest.NewRecorder()
+	c := newRecurringTestContext(w, "POST", "/categories", `{"name":"Fruit","parent_id":7}`)
+
+	CreateCategory(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), impl.ErrCategoryTooDeep.Error())
+}
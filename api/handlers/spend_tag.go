@@ -60,7 +60,8 @@ func getTagID(c *gin.Context) (int64, bool) {
 // @ID list-tags
 // @Accept  json
 // @Produce  json
-// @Param limit query int false "Limit number of tags returned"
+// @Param limit query int false "Limit number of tags returned (at most 100 when paging by cursor)"
+// @Param cursor query string false "Cursor of the page to read; empty for the first page. Switches to cursor paging"
 // @Param offset query int false "Offset for tags returned"
 // @Success 200 {array} impl.Tag
 // @Failure 500 {object} map[string]string "Unable to fetch tags"
@@ -83,6 +84,22 @@ func ListTags(c *gin.Context) {
 		useScope = append(useScope, userInfo.GroupScope)
 	}
 
+	params, err := cursorParamsFromQuery(c, "tags")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params != nil {
+		tags, page, err := impl.GetModelsService().TagModel.GetTagsByCursor(c, useScope, *params)
+		if err != nil {
+			log.Printf("[ListTags] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch tags"})
+			return
+		}
+		c.JSON(http.StatusOK, cursorPageResponse("tags", tags, page))
+		return
+	}
+
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
 	offset, _ := strconv.Atoi(c.Query("offset"))
 
@@ -240,7 +257,7 @@ func UpdateTag(c *gin.Context) {
 
 // DeleteTag
 // @Summary Delete a specific tag
-// @Description Delete a specific tag by its ID
+// @Description Move a tag to the trash, from where it can be restored until it is purged
 // @ID delete-tag
 // @Accept  json
 // @Produce  json
@@ -0,0 +1,49 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"net/http"
+	"xspends/models/impl"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTemplates
+// @Summary List seed templates
+// @Description Get the templates a new user or group can start with, with the categories and sources each creates
+// @ID list-templates
+// @Produce  json
+// @Success 200 {object} map[string]interface{} "Templates and the name of the default one"
+// @Router /templates [get]
+func ListTemplates(c *gin.Context) {
+	templates := impl.GetModelsService().TemplateModel
+	defaultTemplate, err := templates.GetTemplate("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch templates"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"default": defaultTemplate.Name, "templates": templates.ListTemplates()})
+}
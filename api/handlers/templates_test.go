@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, _, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+	templates := impl.GetModelsService().TemplateModel.(*xmock.MockTemplateModel)
+	defer templates.AssertExpectations(t)
+
+	templates.On("GetTemplate", "").Return(&interfaces.SeedTemplate{Name: impl.TemplateDefault}, nil).Once()
+	templates.On("ListTemplates").Return([]interfaces.SeedTemplate{
+		{Name: impl.TemplateDefault, Sources: []interfaces.SeedSource{{Name: "Cash", Type: "SAVINGS"}}},
+		{Name: impl.TemplateNone},
+	}).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/templates", nil)
+	ListTemplates(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"default":"default"`)
+	assert.Contains(t, w.Body.String(), `"sources":[{"name":"Cash","type":"SAVINGS"}]`)
+}
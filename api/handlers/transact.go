@@ -28,11 +28,13 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 	"xspends/models/impl"
 	"xspends/models/interfaces"
 	"xspends/util"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 func getTransactionID(c *gin.Context) (int64, bool) {
@@ -55,7 +57,7 @@ func getTransactionID(c *gin.Context) (int64, bool) {
 
 // CreateTransaction
 // @Summary Create a new transaction
-// @Description Create a new transaction with the provided information
+// @Description Create a new transaction with the provided information. timestamp dates it, with its time zone offset, and defaults to now
 // @ID create-transaction
 // @Accept  json
 // @Produce  json
@@ -88,6 +90,11 @@ func CreateTransaction(c *gin.Context) {
 	newTransaction.ScopeID = userInfo.UseScope
 	if err := impl.GetModelsService().TransactionModel.InsertTransaction(c, newTransaction); err != nil {
 		log.Printf("[CreateTransaction] Error: %v", err)
+		switch errors.Cause(err) {
+		case impl.ErrInvalidTransfer, impl.ErrInvalidSplit, impl.ErrCurrencyMismatch, impl.ErrExchangeRateNotFound, impl.ErrInvalidTimestamp:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to create transaction"})
 		return
 	}
@@ -136,7 +143,7 @@ func GetTransaction(c *gin.Context) {
 
 // UpdateTransaction
 // @Summary Update a specific transaction
-// @Description Update a specific transaction by its ID
+// @Description Update a specific transaction by its ID. A timestamp moves it to another date
 // @ID update-transaction
 // @Accept  json
 // @Produce  json
@@ -193,14 +200,34 @@ func UpdateTransaction(c *gin.Context) {
 	if uTxn.Type != "" {
 		oTxn.Type = uTxn.Type
 	}
-	if uTxn.SourceID != 0 {
+	if uTxn.SourceID != 0 && uTxn.SourceID != oTxn.SourceID {
+		// Moving to another source takes that source's currency
 		oTxn.SourceID = uTxn.SourceID
+		oTxn.Currency = ""
+	}
+	if uTxn.Currency != "" {
+		oTxn.Currency = uTxn.Currency
 	}
 	if uTxn.CategoryID != 0 {
 		oTxn.CategoryID = uTxn.CategoryID
 	}
+	if !uTxn.Timestamp.IsZero() {
+		oTxn.Timestamp = uTxn.Timestamp
+	}
+	// An empty list of splits turns a split transaction back into a single category one
+	if uTxn.Splits != nil {
+		oTxn.Splits = uTxn.Splits
+	}
 	if err := impl.GetModelsService().TransactionModel.UpdateTransaction(c, *oTxn); err != nil {
 		log.Printf("[UpdateTransaction] Error: %v", err)
+		if errors.Cause(err) == impl.ErrTransferNotEditable {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if cause := errors.Cause(err); cause == impl.ErrCurrencyMismatch || cause == impl.ErrInvalidSplit || cause == impl.ErrInvalidTimestamp {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to update transaction"})
 		return
 	}
@@ -210,7 +237,7 @@ func UpdateTransaction(c *gin.Context) {
 
 // DeleteTransaction
 // @Summary Delete a specific transaction
-// @Description Delete a specific transaction by its ID
+// @Description Move a transaction to the trash and take its amount off the source balance; it can be restored until it is purged
 // @ID delete-transaction
 // @Accept  json
 // @Produce  json
@@ -252,17 +279,23 @@ func DeleteTransaction(c *gin.Context) {
 // @ID list-transactions
 // @Accept  json
 // @Produce  json
-// @Param start_date query string false "Start Date"
-// @Param end_date query string false "End Date"
+// @Param start_date query string false "Start Date, YYYY-MM-DD in the user's time zone or an RFC 3339 time"
+// @Param end_date query string false "End Date, inclusive; YYYY-MM-DD in the user's time zone or an RFC 3339 time"
 // @Param category query string false "Category"
+// @Param include_descendants query bool false "Also match the subcategories of the category"
 // @Param type query string false "Transaction Type"
 // @Param tags query []string false "Tags"
 // @Param min_amount query number false "Minimum Amount"
 // @Param max_amount query number false "Maximum Amount"
+// @Param source_id query int false "Source ID"
+// @Param transfer_id query int false "Transfer ID"
+// @Param exclude_transfers query bool false "Exclude transfer legs"
 // @Param sort_by query string false "Sort By"
 // @Param sort_order query string false "Sort Order"
 // @Param page query int false "Page Number"
 // @Param items_per_page query int false "Items Per Page"
+// @Param cursor query string false "Cursor of the page to read; empty for the first page. Switches to cursor paging"
+// @Param limit query int false "Items per page when paging by cursor (default 10, at most 100)"
 // @Success 200 {array} impl.Transaction
 // @Failure 500 {object} map[string]string "Unable to fetch transactions"
 // @Router /transactions [get]
@@ -280,7 +313,42 @@ func ListTransactions(c *gin.Context) {
 		return
 	}
 
-	// Create a filter from the query parameters.
+	filter := transactionFilterFromQuery(c, userInfo)
+
+	params, err := cursorParamsFromQuery(c, "transactions")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params != nil {
+		transactions, page, err := impl.GetModelsService().TransactionModel.GetTransactionsByCursor(c, filter, *params)
+		if err != nil {
+			log.Printf("[ListTransactions] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch transactions"})
+			return
+		}
+		c.JSON(http.StatusOK, cursorPageResponse("transactions", transactions, page))
+		return
+	}
+
+	transactions, err := impl.GetModelsService().TransactionModel.GetTransactionsByFilter(c, filter)
+	if err != nil {
+		log.Printf("[ListTransactions] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch transactions"})
+		return
+	}
+
+	if len(transactions) == 0 {
+		log.Printf("[ListTransactions] Info: %v", "no transactions found")
+		c.JSON(http.StatusOK, gin.H{"message": "no transactions found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactions)
+}
+
+// transactionFilterFromQuery builds a filter for the active scope from the query parameters.
+func transactionFilterFromQuery(c *gin.Context, userInfo ScopeInfo) interfaces.TransactionFilter {
 	filter := interfaces.TransactionFilter{
 		UserID:       userInfo.UserID,
 		Scopes:       []int64{userInfo.UseScope},
@@ -289,26 +357,47 @@ func ListTransactions(c *gin.Context) {
 		Category:     c.DefaultQuery("category", ""),
 		Type:         c.DefaultQuery("type", ""),
 		Tags:         c.QueryArray("tags"),
-		MinAmount:    util.GetFloatFromQuery(c, "min_amount", 0),
-		MaxAmount:    util.GetFloatFromQuery(c, "max_amount", 0),
+		MinAmount:    util.GetMoneyFromQuery(c, "min_amount", 0),
+		MaxAmount:    util.GetMoneyFromQuery(c, "max_amount", 0),
 		SortBy:       c.DefaultQuery("sort_by", "timestamp"), // defaulting to timestamp
 		SortOrder:    c.DefaultQuery("sort_order", "DESC"),   // defaulting to descending
 		Page:         util.GetIntFromQuery(c, "page", 1),
 		ItemsPerPage: util.GetIntFromQuery(c, "items_per_page", 10), // defaulting to 10 items per page
 	}
+	filter.SourceID, _ = util.GetUserIDFromQuery(c, "source_id")
+	filter.TransferID, _ = util.GetUserIDFromQuery(c, "transfer_id")
+	filter.ExcludeTransfers = c.Query("exclude_transfers") == "true"
+	filter.IncludeDescendants = c.Query("include_descendants") == "true"
+	if filter.StartDate != "" || filter.EndDate != "" {
+		applyUserPreferences(c, &filter, userInfo.UserID)
+	}
+	return filter
+}
 
-	transactions, err := impl.GetModelsService().TransactionModel.GetTransactionsByFilter(c, filter)
+// applyUserPreferences reads the dates of filter in the user's time zone and starts its weeks on
+// the user's first day of the week.
+func applyUserPreferences(c *gin.Context, filter *interfaces.TransactionFilter, userID int64) {
+	filter.Location, filter.WeekStart = userCalendar(c, userID)
+}
+
+// userCalendar returns the user's time zone and first day of the week. A user that cannot be
+// loaded gets UTC weeks from Monday, so that lists and reports still answer.
+func userCalendar(c *gin.Context, userID int64) (*time.Location, time.Weekday) {
+	location, weekStart := time.UTC, time.Monday
+	user, err := impl.GetModelsService().UserModel.GetUserByID(c, userID)
 	if err != nil {
-		log.Printf("[ListTransactions] Error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to fetch transactions"})
-		return
+		log.Printf("[userCalendar] Error: %v", err)
+		return location, weekStart
 	}
-
-	if len(transactions) == 0 {
-		log.Printf("[ListTransactions] Info: %v", "no transactions found")
-		c.JSON(http.StatusOK, gin.H{"message": "no transactions found"})
-		return
+	if loaded, err := impl.LoadUserLocation(user.Timezone); err == nil {
+		location = loaded
+	} else {
+		log.Printf("[userCalendar] Error: user %d has timezone %q: %v", userID, user.Timezone, err)
 	}
-
-	c.JSON(http.StatusOK, transactions)
+	if loaded, err := impl.LoadUserWeekStart(user.WeekStart); err == nil {
+		weekStart = loaded
+	} else {
+		log.Printf("[userCalendar] Error: user %d has week start %q: %v", userID, user.WeekStart, err)
+	}
+	return location, weekStart
 }
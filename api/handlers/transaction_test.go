@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
+	"xspends/models/impl"
 	"xspends/models/interfaces"
 	xmock "xspends/models/mock"
 	"xspends/testutils"
@@ -119,7 +121,7 @@ func TestGetTransaction(t *testing.T) {
 					UserID:      userID,
 					ScopeID:     1,
 					Description: "Sample Transaction",
-					Amount:      100,
+					Amount:      interfaces.MoneyFromFloat(100),
 				}, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
@@ -293,8 +295,8 @@ func TestListTransactions(t *testing.T) {
 			setupMock: func(userID int64, filter interfaces.TransactionFilter) {
 				// Ensure the mock setup matches the actual method call including all parameters
 				mockTransactionModel.On("GetTransactionsByFilter", mock.AnythingOfType("*gin.Context"), filter, mock.AnythingOfType("[]*sql.Tx")).Return([]interfaces.Transaction{
-					{ID: 1, UserID: userID, Amount: 100, ScopeID: 1, Description: "Transaction 1"},
-					{ID: 2, UserID: userID, Amount: 200, ScopeID: 1, Description: "Transaction 2"},
+					{ID: 1, UserID: userID, Amount: interfaces.MoneyFromFloat(100), ScopeID: 1, Description: "Transaction 1"},
+					{ID: 2, UserID: userID, Amount: interfaces.MoneyFromFloat(200), ScopeID: 1, Description: "Transaction 2"},
 				}, nil).Once()
 			},
 
@@ -376,3 +378,167 @@ func generateQueryParams(params map[string]string) string {
 	}
 	return strings.Join(queryParams, "&")
 }
+
+func TestCreateTransfer(t *testing.T) {
+	mockTransactionModel := initTransactionTest(t)
+	defer mockTransactionModel.AssertExpectations(t)
+
+	transfer := interfaces.Transaction{UserID: 1, ScopeID: 10, SourceID: 1, DestinationSourceID: 2, Amount: interfaces.MoneyFromFloat(50), Type: impl.TransactionTypeTransfer}
+	tests := []struct {
+		name           string
+		requestBody    string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Successful transfer",
+			requestBody: `{"source_id":1,"destination_source_id":2,"amount":50}`,
+			setupMock: func() {
+				outgoing, incoming := transfer, transfer
+				outgoing.ID, outgoing.TransferID = 11, 9001
+				incoming.ID, incoming.TransferID, incoming.SourceID = 12, 9001, 2
+				mockTransactionModel.On("InsertTransfer", mock.Anything, transfer, mock.AnythingOfType("[]*sql.Tx")).
+					Return([]interfaces.Transaction{outgoing, incoming}, nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"transfer_id":9001`,
+		},
+		{
+			name:        "Same source on both sides",
+			requestBody: `{"source_id":1,"destination_source_id":1,"amount":50}`,
+			setupMock: func() {
+				invalid := transfer
+				invalid.DestinationSourceID = 1
+				mockTransactionModel.On("InsertTransfer", mock.Anything, invalid, mock.AnythingOfType("[]*sql.Tx")).
+					Return(nil, impl.ErrInvalidTransfer).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   impl.ErrInvalidTransfer.Error(),
+		},
+		{
+			name:           "Missing destination",
+			requestBody:    `{"source_id":1,"amount":50}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "DestinationSourceID",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/transfers", strings.NewReader(tc.requestBody))
+			c.Set("scopeInfo", ScopeInfo{UserID: 1, OwnerScope: 10, UseScope: 10, Scopes: []int64{10}, Role: "write"})
+
+			CreateTransfer(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	mockTransactionModel := initTransactionTest(t)
+	defer mockTransactionModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		groupBy        string
+		query          string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "Monthly totals with filters",
+			groupBy: "month",
+			query:   "?start_date=2024-01-01&source_id=2",
+			setupMock: func() {
+				matchesFilter := mock.MatchedBy(func(filter interfaces.TransactionFilter) bool {
+					return filter.StartDate == "2024-01-01" && filter.SourceID == 2 && filter.Scopes[0] == 10 &&
+						filter.Location.String() == "Europe/Paris"
+				})
+				mockUserModel := impl.GetModelsService().UserModel.(*xmock.MockUserModel)
+				mockUserModel.On("GetUserByID", mock.Anything, int64(1), mock.Anything).Return(&interfaces.User{ID: 1, Timezone: "Europe/Paris"}, nil).Once()
+				mockCurrencyModel := impl.GetModelsService().CurrencyModel.(*xmock.MockCurrencyModel)
+				mockCurrencyModel.On("GetBaseCurrency", mock.Anything, int64(10), mock.Anything).Return("USD", nil).Once()
+				mockTransactionModel.On("GetTransactionReport", mock.Anything, matchesFilter, "month", "USD", mock.AnythingOfType("[]*sql.Tx")).
+					Return([]interfaces.ReportRow{{Key: "2024-01-01", Income: interfaces.MoneyFromFloat(100), Expense: interfaces.MoneyFromFloat(40), Net: interfaces.MoneyFromFloat(60), Count: 3}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"net":60`,
+		},
+		{
+			name:    "Weeks of the user",
+			groupBy: "week",
+			query:   "?currency=USD",
+			setupMock: func() {
+				matchesFilter := mock.MatchedBy(func(filter interfaces.TransactionFilter) bool {
+					return filter.Location.String() == "America/New_York" && filter.WeekStart == time.Sunday
+				})
+				mockUserModel := impl.GetModelsService().UserModel.(*xmock.MockUserModel)
+				mockUserModel.On("GetUserByID", mock.Anything, int64(1), mock.Anything).
+					Return(&interfaces.User{ID: 1, Timezone: "America/New_York", WeekStart: "SUNDAY"}, nil).Once()
+				mockTransactionModel.On("GetTransactionReport", mock.Anything, matchesFilter, "week", "USD", mock.Anything).
+					Return([]interfaces.ReportRow{{Key: "2024-03-03", Expense: interfaces.MoneyFromFloat(20), Net: interfaces.MoneyFromFloat(-20), Count: 1}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"key":"2024-03-03"`,
+		},
+		{
+			name:    "Unsupported grouping",
+			groupBy: "year",
+			query:   "?currency=EUR",
+			setupMock: func() {
+				mockTransactionModel.On("GetTransactionReport", mock.Anything, mock.Anything, "year", "EUR", mock.Anything).
+					Return(nil, impl.ErrInvalidReportGrouping).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   impl.ErrInvalidReportGrouping.Error(),
+		},
+		{
+			name:    "Category totals rolled up to the top level",
+			groupBy: "category",
+			query:   "?currency=USD&category=1&include_descendants=true&level=1",
+			setupMock: func() {
+				matchesFilter := mock.MatchedBy(func(filter interfaces.TransactionFilter) bool {
+					return filter.Category == "1" && filter.IncludeDescendants
+				})
+				report := []interfaces.ReportRow{{Key: "2", Name: "Groceries", Expense: 1200, Net: -1200, Count: 3}}
+				mockTransactionModel.On("GetTransactionReport", mock.Anything, matchesFilter, "category", "USD", mock.Anything).Return(report, nil).Once()
+				mockCategoryModel := impl.GetModelsService().CategoryModel.(*xmock.MockCategoryModel)
+				mockCategoryModel.On("RollUpReport", mock.Anything, report, 1, []int64{10}, mock.Anything).
+					Return([]interfaces.ReportRow{{Key: "1", Name: "Food", Expense: 1200, Net: -1200, Count: 3}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"name":"Food"`,
+		},
+		{
+			name:           "Level without category grouping",
+			groupBy:        "month",
+			query:          "?currency=USD&level=1",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "level must be a positive number",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "GET", "/reports/"+tc.groupBy+tc.query, "")
+			c.Params = gin.Params{gin.Param{Key: "group_by", Value: tc.groupBy}}
+
+			GetReport(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
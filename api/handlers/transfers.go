@@ -0,0 +1,107 @@
+/*
+MIT License
+
+# Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// TransferRequest describes a movement of money between two sources of the active scope.
+type TransferRequest struct {
+	SourceID            int64            `json:"source_id" binding:"required"`
+	DestinationSourceID int64            `json:"destination_source_id" binding:"required"`
+	Amount              interfaces.Money `json:"amount" binding:"required"`
+	CategoryID          int64            `json:"category_id"`
+	Description         string           `json:"description"`
+	Tags                []string         `json:"tags"`
+	// Timestamp dates the transfer, now when left out.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// CreateTransfer
+// @Summary Transfer money between sources
+// @Description Record a transfer as two linked TRANSFER transactions and move the amount between both source balances. Between sources of different currencies the incoming amount is converted at the exchange rate of the day
+// @ID create-transfer
+// @Accept  json
+// @Produce  json
+// @Param transfer body TransferRequest true "Transfer info"
+// @Success 201 {object} map[string]interface{} "Transfer ID and both legs"
+// @Failure 400 {object} map[string]string "Invalid transfer data"
+// @Failure 500 {object} map[string]string "Unable to create transfer"
+// @Router /transfers [post]
+func CreateTransfer(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[CreateTransfer] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[CreateTransfer] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	var request TransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Printf("[CreateTransfer] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer := interfaces.Transaction{
+		UserID:              userInfo.UserID,
+		ScopeID:             userInfo.UseScope,
+		SourceID:            request.SourceID,
+		DestinationSourceID: request.DestinationSourceID,
+		CategoryID:          request.CategoryID,
+		Amount:              request.Amount,
+		Type:                impl.TransactionTypeTransfer,
+		Description:         request.Description,
+		Tags:                request.Tags,
+		Timestamp:           request.Timestamp,
+	}
+	legs, err := impl.GetModelsService().TransactionModel.InsertTransfer(c, transfer)
+	if err != nil {
+		log.Printf("[CreateTransfer] Error: %v", err)
+		switch errors.Cause(err) {
+		case impl.ErrInvalidTransfer, impl.ErrCurrencyMismatch, impl.ErrExchangeRateNotFound, impl.ErrInvalidTimestamp:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to create transfer"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"transfer_id": legs[0].TransferID, "legs": legs})
+}
@@ -0,0 +1,123 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"xspends/models/impl"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func respondTrashError(c *gin.Context, err error, fallback string) {
+	switch errors.Cause(err) {
+	case impl.ErrTrashItemNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found in the trash"})
+	case impl.ErrInvalidTrashType:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case impl.ErrTrashConflict, impl.ErrTrashNameTaken:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListTrash
+// @Summary List the trash
+// @Description Get the deleted transactions, categories, sources and tags of the scope that can still be restored, most recently deleted first
+// @ID list-trash
+// @Produce  json
+// @Param type query string false "Only list one type: transaction, category, source or tag"
+// @Success 200 {array} interfaces.TrashItem
+// @Failure 400 {object} map[string]string "Invalid type"
+// @Failure 500 {object} map[string]string "Unable to fetch the trash"
+// @Router /trash [get]
+func ListTrash(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[ListTrash] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[ListTrash] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+
+	items, err := impl.GetModelsService().TrashModel.GetTrash(c, []int64{userInfo.UseScope}, c.Query("type"))
+	if err != nil {
+		log.Printf("[ListTrash] Error: %v", err)
+		respondTrashError(c, err, "unable to fetch the trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// RestoreTrashItem
+// @Summary Restore a deleted item
+// @Description Take a transaction, category, source or tag out of the trash. A restored transaction counts towards its source balance again; it cannot be restored while its source is in the trash. A category, source or tag cannot be restored while another one in use has its name
+// @ID restore-trash-item
+// @Produce  json
+// @Param type path string true "Item type: transaction, category, source or tag"
+// @Param id path int true "Item ID"
+// @Success 200 {object} map[string]string "Item restored successfully"
+// @Failure 400 {object} map[string]string "Invalid type or ID"
+// @Failure 404 {object} map[string]string "Item not found in the trash"
+// @Failure 409 {object} map[string]string "The item depends on another item in the trash, or its name is taken"
+// @Router /trash/{type}/{id}/restore [post]
+func RestoreTrashItem(c *gin.Context) {
+	scopeInfo, ok := c.Get("scopeInfo")
+	if !ok {
+		log.Printf("[RestoreTrashItem] Error: %v", "Missing user or scope information")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user or scope information"})
+		return
+	}
+	userInfo, ok := scopeInfo.(ScopeInfo)
+	if !ok {
+		log.Printf("[RestoreTrashItem] Error: %v", "Failed to typecast scope information")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to typecast scope information"})
+		return
+	}
+	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Printf("[RestoreTrashItem] Error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item ID format"})
+		return
+	}
+
+	if err := impl.GetModelsService().TrashModel.RestoreItem(c, c.Param("type"), itemID, []int64{userInfo.UseScope}); err != nil {
+		log.Printf("[RestoreTrashItem] Error: %v", err)
+		respondTrashError(c, err, "unable to restore the item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "item restored successfully"})
+}
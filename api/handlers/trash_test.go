@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func initTrashTest(t *testing.T) *xmock.MockTrashModel {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+
+	mockTrashModel := new(xmock.MockTrashModel)
+	modelsService.TrashModel = mockTrashModel
+	return mockTrashModel
+}
+
+func TestListTrash(t *testing.T) {
+	mockTrashModel := initTrashTest(t)
+	defer mockTrashModel.AssertExpectations(t)
+
+	deletedAt := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	mockTrashModel.On("GetTrash", mock.Anything, []int64{10}, "tag", mock.AnythingOfType("[]*sql.Tx")).
+		Return([]interfaces.TrashItem{{Type: impl.TrashTypeTag, ID: 2, Name: "gifts", ScopeID: 10, DeletedAt: deletedAt}}, nil).Once()
+
+	w := httptest.NewRecorder()
+	c := newRecurringTestContext(w, "GET", "/trash?type=tag", "")
+
+	ListTrash(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"gifts"`)
+}
+
+func TestRestoreTrashItem(t *testing.T) {
+	mockTrashModel := initTrashTest(t)
+	defer mockTrashModel.AssertExpectations(t)
+
+	tests := []struct {
+		name           string
+		itemType       string
+		itemID         string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:     "Restored",
+			itemType: "category",
+			itemID:   "3",
+			setupMock: func() {
+				mockTrashModel.On("RestoreItem", mock.Anything, "category", int64(3), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "item restored successfully",
+		},
+		{
+			name:     "Source still in the trash",
+			itemType: "transaction",
+			itemID:   "7",
+			setupMock: func() {
+				mockTrashModel.On("RestoreItem", mock.Anything, "transaction", int64(7), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).
+					Return(errors.Wrap(impl.ErrTrashConflict, "restore source 2 first")).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "restore source 2 first",
+		},
+		{
+			name:     "Name taken",
+			itemType: "category",
+			itemID:   "3",
+			setupMock: func() {
+				mockTrashModel.On("RestoreItem", mock.Anything, "category", int64(3), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).Return(impl.ErrTrashNameTaken).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "rename it before restoring this one",
+		},
+		{
+			name:     "Not in the trash",
+			itemType: "tag",
+			itemID:   "4",
+			setupMock: func() {
+				mockTrashModel.On("RestoreItem", mock.Anything, "tag", int64(4), []int64{10}, mock.AnythingOfType("[]*sql.Tx")).Return(impl.ErrTrashItemNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "item not found in the trash",
+		},
+		{
+			name:           "Invalid ID",
+			itemType:       "tag",
+			itemID:         "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid item ID format",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupMock != nil {
+				tc.setupMock()
+			}
+			w := httptest.NewRecorder()
+			c := newRecurringTestContext(w, "POST", "/trash/"+tc.itemType+"/"+tc.itemID+"/restore", "")
+			c.Params = gin.Params{gin.Param{Key: "type", Value: tc.itemType}, gin.Param{Key: "id", Value: tc.itemID}}
+
+			RestoreTrashItem(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
@@ -75,6 +75,8 @@ func SetupRoutes(r *gin.Engine, kvClient kvstore.RawKVClientInterface) {
 		auth.POST("/refresh", handlers.JWTRefreshHandler(ab))   // Refresh JWT token
 		auth.POST("/logout", handlers.JWTLogoutHandler(ab))     // Logout a user
 	}
+	// Seed templates can be chosen at registration, so listing them needs no login
+	r.GET("/templates", handlers.ListTemplates)
 
 	// TODO:
 	// txns - Scope only to the current scope
@@ -85,40 +87,46 @@ func SetupRoutes(r *gin.Engine, kvClient kvstore.RawKVClientInterface) {
 
 	// All other routes should be protected by the AuthMiddleware
 	// These routes are used for managing sources, categories, tags, and transactions.
+	// A request acts in the user's personal scope, or in a group when the X-Group-ID header is set.
+	// Each route validates the active scope for the role it needs: reads need view, changes need write.
 	apiRoutes := r.Group("/")
-	apiRoutes.Use(middleware.AuthMiddleware(ab), middleware.ScopeMiddleware(impl.RoleWrite), middleware.EnsureUserID(), middleware.EnsureScopeID())
+	apiRoutes.Use(middleware.AuthMiddleware(ab), middleware.GroupMiddleware(), middleware.EnsureUserID(), middleware.EnsureScopeID())
+	canView := middleware.ScopeMiddleware(impl.RoleView)
+	canWrite := middleware.ScopeMiddleware(impl.RoleWrite)
 	// Source routes
 	// These routes are used for managing sources.
 	// Sources can a mixed scope relationship
 	sources := apiRoutes.Group("/sources")
 	{
-		sources.GET("", handlers.ListSources).Use(middleware.ScopeMiddleware(impl.RoleView))
-		sources.POST("", handlers.CreateSource)
-		sources.GET("/:id", handlers.GetSource).Use(middleware.ScopeMiddleware(impl.RoleView))
-		sources.PUT("/:id", handlers.UpdateSource)
-		sources.DELETE("/:id", handlers.DeleteSource)
+		sources.GET("", canView, handlers.ListSources)
+		sources.POST("", canWrite, handlers.CreateSource)
+		sources.GET("/:id", canView, handlers.GetSource)
+		sources.PUT("/:id", canWrite, handlers.UpdateSource)
+		sources.DELETE("/:id", canWrite, handlers.DeleteSource)
+		sources.POST("/:id/reconcile", canWrite, handlers.ReconcileSource)
 	}
 	// Category routes
 	// These routes are used for managing categories.
 	// Catgegories will be a strict Scope relationship
 	categories := apiRoutes.Group("/categories")
 	{
-		categories.GET("", handlers.ListCategories).Use(middleware.ScopeMiddleware(impl.RoleView))
-		categories.POST("", handlers.CreateCategory)
-		categories.GET("/:id", handlers.GetCategory).Use(middleware.ScopeMiddleware(impl.RoleView))
-		categories.PUT("/:id", handlers.UpdateCategory)
-		categories.DELETE("/:id", handlers.DeleteCategory)
+		categories.GET("", canView, handlers.ListCategories)
+		categories.GET("/tree", canView, handlers.GetCategoryTree)
+		categories.POST("", canWrite, handlers.CreateCategory)
+		categories.GET("/:id", canView, handlers.GetCategory)
+		categories.PUT("/:id", canWrite, handlers.UpdateCategory)
+		categories.DELETE("/:id", canWrite, handlers.DeleteCategory)
 	}
 	// Tag routes
 	// These routes are used for managing tags.
 	// Tag doesn't depend on scope, it is at a user level
 	tags := apiRoutes.Group("/tags")
 	{
-		tags.GET("", handlers.ListTags)
-		tags.POST("", handlers.CreateTag)
-		tags.GET("/:id", handlers.GetTag)
-		tags.PUT("/:id", handlers.UpdateTag)
-		tags.DELETE("/:id", handlers.DeleteTag)
+		tags.GET("", canView, handlers.ListTags)
+		tags.POST("", canWrite, handlers.CreateTag)
+		tags.GET("/:id", canView, handlers.GetTag)
+		tags.PUT("/:id", canWrite, handlers.UpdateTag)
+		tags.DELETE("/:id", canWrite, handlers.DeleteTag)
 	}
 	// Transaction routes
 	// These routes are used for managing transactions and transaction tags.
@@ -126,14 +134,127 @@ func SetupRoutes(r *gin.Engine, kvClient kvstore.RawKVClientInterface) {
 	transactions := apiRoutes.Group("/transactions")
 	{
 		//add method to get all txns owner by current user
-		transactions.GET("", handlers.ListTransactions).Use(middleware.ScopeMiddleware(impl.RoleView))
-		transactions.POST("", handlers.CreateTransaction)
-		transactions.GET("/:id", handlers.GetTransaction).Use(middleware.ScopeMiddleware(impl.RoleView))
-		transactions.PUT("/:id", handlers.UpdateTransaction)
-		transactions.DELETE("/:id", handlers.DeleteTransaction)
-		transactions.GET("/:id/tags", handlers.ListTransactionTags).Use(middleware.ScopeMiddleware(impl.RoleView))
-		transactions.POST("/:id/tags", handlers.AddTagToTransaction)
-		transactions.DELETE("/:id/tags/:tagID", handlers.RemoveTagFromTransaction)
+		transactions.GET("", canView, handlers.ListTransactions)
+		transactions.GET("/export", canView, handlers.ExportTransactions)
+		transactions.GET("/search", canView, handlers.SearchTransactions)
+		transactions.POST("", canWrite, handlers.CreateTransaction)
+		transactions.GET("/:id", canView, handlers.GetTransaction)
+		transactions.PUT("/:id", canWrite, handlers.UpdateTransaction)
+		transactions.DELETE("/:id", canWrite, handlers.DeleteTransaction)
+		transactions.GET("/:id/tags", canView, handlers.ListTransactionTags)
+		transactions.POST("/:id/tags", canWrite, handlers.AddTagToTransaction)
+		transactions.DELETE("/:id/tags/:tagID", canWrite, handlers.RemoveTagFromTransaction)
+		transactions.GET("/:id/shares", canView, handlers.GetTransactionShares)
+		transactions.PUT("/:id/shares", canWrite, handlers.SetTransactionShares)
+		transactions.DELETE("/:id/shares", canWrite, handlers.DeleteTransactionShares)
+	}
+	// Transfer routes
+	// A transfer is stored as two linked transactions, one per source
+	apiRoutes.POST("/transfers", canWrite, handlers.CreateTransfer)
+	// Recurring transaction routes
+	// Due occurrences are created by the background scheduler
+	recurring := apiRoutes.Group("/recurring")
+	{
+		recurring.GET("", canView, handlers.ListRecurringTransactions)
+		recurring.POST("", canWrite, handlers.CreateRecurringTransaction)
+		recurring.GET("/:id", canView, handlers.GetRecurringTransaction)
+		recurring.PUT("/:id", canWrite, handlers.UpdateRecurringTransaction)
+		recurring.DELETE("/:id", canWrite, handlers.DeleteRecurringTransaction)
+		recurring.POST("/:id/skip", canWrite, handlers.SkipRecurringTransaction)
+	}
+	// Budget routes
+	// Budgets belong to the active scope, so group budgets are shared by all members
+	budgets := apiRoutes.Group("/budgets")
+	{
+		budgets.GET("", canView, handlers.ListBudgets)
+		budgets.POST("", canWrite, handlers.CreateBudget)
+		budgets.GET("/status", canView, handlers.GetBudgetStatus)
+		budgets.GET("/:id", canView, handlers.GetBudget)
+		budgets.PUT("/:id", canWrite, handlers.UpdateBudget)
+		budgets.DELETE("/:id", canWrite, handlers.DeleteBudget)
+	}
+	// Categorization rule routes
+	// Rules of the active scope run on new and imported transactions; test only previews
+	rules := apiRoutes.Group("/rules")
+	{
+		rules.GET("", canView, handlers.ListRules)
+		rules.POST("", canWrite, handlers.CreateRule)
+		rules.POST("/test", canView, handlers.TestRule)
+		rules.POST("/apply", canWrite, handlers.ApplyRule)
+		rules.GET("/:id", canView, handlers.GetRule)
+		rules.PUT("/:id", canWrite, handlers.UpdateRule)
+		rules.DELETE("/:id", canWrite, handlers.DeleteRule)
+	}
+	// Report routes
+	reports := apiRoutes.Group("/reports")
+	{
+		reports.GET("/:group_by", canView, handlers.GetReport)
+	}
+	// Import routes
+	imports := apiRoutes.Group("/imports")
+	{
+		imports.GET("", canView, handlers.ListImports)
+		imports.POST("", canWrite, handlers.CommitImport)
+		imports.POST("/preview", canWrite, handlers.PreviewImport)
+		imports.GET("/:id", canView, handlers.GetImport)
+		imports.POST("/:id/undo", canWrite, handlers.UndoImport)
+	}
+	// Exchange rate routes
+	// Rates belong to the active scope and are used to convert its reports
+	rates := apiRoutes.Group("/exchange-rates")
+	{
+		rates.GET("", canView, handlers.ListExchangeRates)
+		rates.POST("", canWrite, handlers.SetExchangeRate)
+		rates.POST("/import", canWrite, handlers.ImportExchangeRates)
+		rates.DELETE("/:from/:to/:date", canWrite, handlers.DeleteExchangeRate)
+	}
+	// Trash routes
+	// Deleted transactions, categories, sources and tags stay here until the purge job removes them
+	trash := apiRoutes.Group("/trash")
+	{
+		trash.GET("", canView, handlers.ListTrash)
+		trash.POST("/:type/:id/restore", canWrite, handlers.RestoreTrashItem)
+	}
+	// Archive of everything the current user owns, for data portability.
+	// It always covers the personal scope, whatever group the request acts in
+	apiRoutes.GET("/export/archive", canView, handlers.ExportArchive)
+	// Group routes
+	// These routes are used for managing groups, their members and invitations.
+	// Membership is checked per group inside the handlers
+	groups := apiRoutes.Group("/groups")
+	{
+		groups.GET("", handlers.ListGroups)
+		groups.POST("", handlers.CreateGroup)
+		groups.GET("/:id", handlers.GetGroup)
+		groups.PUT("/:id", handlers.UpdateGroup)
+		groups.DELETE("/:id", handlers.DeleteGroup)
+		groups.GET("/:id/members", handlers.ListGroupMembers)
+		groups.PUT("/:id/members/:userID", handlers.EditUserInGroup)
+		groups.DELETE("/:id/members/:userID", handlers.RemoveFromGroup)
+		groups.GET("/:id/invitations", handlers.ListGroupInvitations)
+		groups.POST("/:id/invitations", handlers.InviteToGroup)
+		groups.DELETE("/:id/invitations/:invitationID", handlers.CancelGroupInvitation)
+		groups.GET("/:id/balances", handlers.GetGroupBalances)
+		groups.GET("/:id/settlements", handlers.ListSettlements)
+		groups.POST("/:id/settlements", handlers.CreateSettlement)
+		groups.DELETE("/:id/settlements/:settlementID", handlers.DeleteSettlement)
+	}
+	// Profile routes
+	// These routes let the current user manage their own account
+	me := apiRoutes.Group("/me")
+	{
+		me.GET("", handlers.GetUserProfile)
+		me.PUT("", handlers.UpdateUserProfile)
+		me.DELETE("", handlers.DeleteUser(ab))
+		me.POST("/password", handlers.ChangePassword(ab))
+	}
+	// Invitation routes
+	// These routes let the current user review and answer group invitations.
+	invitations := apiRoutes.Group("/invitations")
+	{
+		invitations.GET("", handlers.ListInvitations)
+		invitations.POST("/:id/accept", handlers.AcceptInvitation)
+		invitations.POST("/:id/decline", handlers.DeclineInvitation)
 	}
 }
 
@@ -8,11 +8,17 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"xspends/api/handlers"
 	"xspends/kvstore/mock"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 )
 
 // The health check endpoint should return a 200 status code with a JSON response containing the "status" field set to "UP".
@@ -128,7 +134,7 @@ func TestSetupRoutes(t *testing.T) {
 	// For a more detailed test, you would integrate with the handlers and test end-to-end functionality.
 
 	// Example: Check if a specific route exists
-	expectedRoutes := []string{"/auth/register", "/auth/login", "/auth/refresh", "/auth/logout", "/sources"}
+	expectedRoutes := []string{"/auth/register", "/auth/login", "/auth/refresh", "/auth/logout", "/sources", "/groups", "/groups/:id/members", "/invitations", "/transfers", "/recurring", "/budgets/status", "/reports/:group_by", "/imports/preview", "/transactions/export", "/export/archive", "/exchange-rates", "/exchange-rates/import"}
 	for _, route := range expectedRoutes {
 		found := false
 		for _, info := range r.Routes() {
@@ -147,3 +153,33 @@ func TestSetupRoutes(t *testing.T) {
 	// If SetupRoutes or any middleware/handlers it uses makes calls to the kvClient methods, you will need to set
 	// up expectations and return values for those calls on the mockKVClient.
 }
+
+// The archive is exported through the full middleware chain, which must give the handler
+// the user's scope information.
+func TestExportArchiveRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockKVClient := mock.NewMockRawKVClientInterface(ctrl)
+	r := gin.New()
+	SetupRoutes(r, mockKVClient)
+
+	mockKVClient.EXPECT().Get(gomock.Any(), []byte("7")).Return([]byte("refresh-token"), nil)
+	modelsService.UserScopeModel.(*xmock.MockUserScopeModel).On("GetUserScopesByRole", testifymock.Anything, int64(1), impl.RoleView, testifymock.Anything).
+		Return([]interfaces.UserScope{}, nil).Once()
+	modelsService.ArchiveModel.(*xmock.MockArchiveModel).On("ExportArchive", testifymock.Anything, int64(1), int64(11), testifymock.Anything, testifymock.Anything).
+		Return(nil).Once()
+
+	token, _ := handlers.GenerateTokenWithTTL(1, 11, "7", 30)
+	req, _ := http.NewRequest("GET", "/export/archive", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	modelsService.ArchiveModel.(*xmock.MockArchiveModel).AssertExpectations(t)
+}
@@ -0,0 +1,83 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"xspends/models/interfaces"
+)
+
+var csvHeader = []string{"transaction_id", "date", "type", "amount", "currency", "description", "category", "source", "destination_source", "tags", "transfer_id"}
+
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+// Write writes one CSV record; tags are joined with "; ".
+func (cw *csvWriter) Write(txn interfaces.TransactionExport) error {
+	if !cw.headerWritten {
+		if err := cw.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		cw.headerWritten = true
+	}
+	transferID := ""
+	if txn.TransferID != 0 {
+		transferID = strconv.FormatInt(txn.TransferID, 10)
+	}
+	return cw.writer.Write([]string{
+		strconv.FormatInt(txn.ID, 10),
+		txn.Timestamp.Format(time.RFC3339),
+		txn.Type,
+		txn.Amount.String(),
+		txn.Currency,
+		txn.Description,
+		txn.Category,
+		txn.Source,
+		txn.DestinationSource,
+		strings.Join(txn.Tags, "; "),
+		transferID,
+	})
+}
+
+// Close writes the header if no rows were written and flushes buffered records.
+func (cw *csvWriter) Close() error {
+	if !cw.headerWritten {
+		if err := cw.writer.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
@@ -0,0 +1,75 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package exporter writes transactions in the formats offered by the export endpoint.
+package exporter
+
+import (
+	"io"
+	"strings"
+	"xspends/models/interfaces"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatOFX   = "ofx"
+)
+
+var ErrUnsupportedFormat = errors.New("supported export formats are csv, jsonl and ofx")
+
+// Writer writes transactions one at a time. Close writes any trailer the format needs;
+// it does not close the underlying io.Writer.
+type Writer interface {
+	Write(txn interfaces.TransactionExport) error
+	Close() error
+}
+
+// NewWriter returns a Writer for format, which is case-insensitive.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatOFX:
+		return newOFXWriter(w), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ContentType returns the MIME type of a supported format.
+func ContentType(format string) string {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatOFX:
+		return "application/x-ofx"
+	}
+	return "application/octet-stream"
+}
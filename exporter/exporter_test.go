@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var exportRows = []interfaces.TransactionExport{
+	{ID: 11, Timestamp: time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC), Type: "EXPENSE", Amount: interfaces.MoneyFromFloat(4.5), Currency: "EUR", Description: "Coffee, large",
+		Category: "Food", Source: "Wallet", Tags: []string{"morning", "work"}, SourceID: 1},
+	{ID: 12, Timestamp: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Type: "TRANSFER", Amount: interfaces.MoneyFromFloat(100), Currency: "EUR", Source: "Savings",
+		DestinationSource: "Savings", Tags: []string{}, SourceID: 2, DestinationSourceID: 2, TransferID: 9001},
+}
+
+func export(t *testing.T, format string) string {
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf)
+	assert.NoError(t, err)
+	for _, row := range exportRows {
+		assert.NoError(t, writer.Write(row))
+	}
+	assert.NoError(t, writer.Close())
+	return buf.String()
+}
+
+func TestCSVWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(export(t, "CSV")), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "transaction_id,date,type,amount,currency,description,category,source,destination_source,tags,transfer_id", lines[0])
+	assert.Equal(t, `11,2024-01-31T09:30:00Z,EXPENSE,4.50,EUR,"Coffee, large",Food,Wallet,,morning; work,`, lines[1])
+	assert.Equal(t, "12,2024-02-01T00:00:00Z,TRANSFER,100.00,EUR,,,Savings,Savings,,9001", lines[2])
+
+	var empty bytes.Buffer
+	writer, _ := NewWriter(FormatCSV, &empty)
+	assert.NoError(t, writer.Close())
+	assert.True(t, strings.HasPrefix(empty.String(), "transaction_id,"), "an empty export still has a header")
+}
+
+func TestJSONLWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(export(t, FormatJSONL)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"category":"Food"`)
+	assert.Contains(t, lines[0], `"tags":["morning","work"]`)
+}
+
+func TestOFXWriter(t *testing.T) {
+	ofx := export(t, FormatOFX)
+	assert.Contains(t, ofx, "<OFX>")
+	assert.Contains(t, ofx, "<CURDEF>EUR</CURDEF>", "the statement takes the currency of the first transaction")
+	assert.Contains(t, ofx, "<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20240131093000</DTPOSTED><TRNAMT>-4.50</TRNAMT><FITID>11</FITID><NAME>Coffee, large</NAME><MEMO>Wallet / Food</MEMO>")
+	assert.Contains(t, ofx, "<TRNTYPE>CREDIT</TRNTYPE>", "incoming transfer leg")
+	assert.True(t, strings.HasSuffix(ofx, "</OFX>\n"))
+}
+
+func TestNewWriterUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("xlsx", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
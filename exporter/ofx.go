@@ -0,0 +1,129 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package exporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>%s</CURDEF><BANKACCTFROM><BANKID>XSPENDS</BANKID><ACCTID>EXPORT</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST>
+`
+
+const ofxTrailer = `</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+// ofxWriter writes an OFX 2 bank statement. Transactions of every source end up in one
+// statement; the source and category names are kept in each transaction's memo. The statement
+// currency is the currency of the first transaction, so exports that mix currencies should be
+// filtered by source.
+type ofxWriter struct {
+	w             io.Writer
+	headerWritten bool
+}
+
+func newOFXWriter(w io.Writer) *ofxWriter {
+	return &ofxWriter{w: w}
+}
+
+func (ow *ofxWriter) writeHeader(currency string) error {
+	if ow.headerWritten {
+		return nil
+	}
+	ow.headerWritten = true
+	if currency == "" {
+		currency = impl.DefaultCurrency
+	}
+	_, err := fmt.Fprintf(ow.w, ofxHeader, ofxDate(time.Now()), currency)
+	return err
+}
+
+// Write writes one STMTTRN. Income and incoming transfer legs are credits, everything else is a debit.
+func (ow *ofxWriter) Write(txn interfaces.TransactionExport) error {
+	if err := ow.writeHeader(txn.Currency); err != nil {
+		return err
+	}
+	trnType, amount := "DEBIT", -txn.Amount
+	incomingLeg := txn.TransferID != 0 && txn.SourceID == txn.DestinationSourceID
+	if strings.ToUpper(txn.Type) == impl.TransactionTypeIncome || incomingLeg {
+		trnType, amount = "CREDIT", txn.Amount
+	}
+	memo := strings.Join(nonEmpty(txn.Source, txn.Category), " / ")
+
+	_, err := fmt.Fprintf(ow.w, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%d</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n",
+		trnType, ofxDate(txn.Timestamp), amount, txn.ID, escapeXML(truncate(txn.Description, 32)), escapeXML(memo))
+	return err
+}
+
+func (ow *ofxWriter) Close() error {
+	if err := ow.writeHeader(""); err != nil {
+		return err
+	}
+	_, err := io.WriteString(ow.w, ofxTrailer)
+	return err
+}
+
+func ofxDate(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
+
+func escapeXML(value string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(value))
+	return buf.String()
+}
+
+// truncate shortens value to at most n runes; OFX limits NAME to 32 characters.
+func truncate(value string, n int) string {
+	runes := []rune(value)
+	if len(runes) <= n {
+		return value
+	}
+	return string(runes[:n])
+}
+
+func nonEmpty(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/pkg/errors"
+)
+
+const defaultDateFormat = "2006-01-02"
+
+// CSVMapping tells which CSV columns hold which fields. Columns are matched against the
+// header row by name, case-insensitively, or are 1-based column numbers when NoHeader is set.
+// Amount is a signed column (negative for expenses); alternatively Debit and Credit hold
+// unsigned outgoing and incoming amounts. Type, when mapped, overrides the sign and accepts
+// INCOME/EXPENSE or CREDIT/DEBIT.
+type CSVMapping struct {
+	Date        string `json:"date"`
+	Amount      string `json:"amount"`
+	Debit       string `json:"debit"`
+	Credit      string `json:"credit"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	DateFormat  string `json:"date_format"` // a Go time layout, defaults to 2006-01-02
+	Delimiter   string `json:"delimiter"`   // defaults to a comma
+	NoHeader    bool   `json:"no_header"`
+}
+
+// ParseCSV reads a CSV statement using mapping.
+func ParseCSV(r io.Reader, mapping CSVMapping) ([]interfaces.ImportRow, error) {
+	if mapping.Date == "" || (mapping.Amount == "" && mapping.Debit == "" && mapping.Credit == "") {
+		return nil, errors.Wrap(ErrInvalidFile, "the CSV mapping needs a date column and an amount, debit or credit column")
+	}
+	if mapping.DateFormat == "" {
+		mapping.DateFormat = defaultDateFormat
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if mapping.Delimiter != "" {
+		reader.Comma = []rune(mapping.Delimiter)[0]
+	}
+
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidFile, err.Error())
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	var header []string
+	if !mapping.NoHeader {
+		if len(records) == 0 {
+			return nil, errors.Wrap(ErrInvalidFile, "the CSV file has no header row")
+		}
+		header, records, lines = records[0], records[1:], lines[1:]
+	}
+
+	columns := map[string]int{}
+	for field, name := range map[string]string{"date": mapping.Date, "amount": mapping.Amount, "debit": mapping.Debit,
+		"credit": mapping.Credit, "type": mapping.Type, "description": mapping.Description, "category": mapping.Category} {
+		if name == "" {
+			continue
+		}
+		index, err := columnIndex(header, name, mapping.NoHeader)
+		if err != nil {
+			return nil, err
+		}
+		columns[field] = index
+	}
+
+	rows := make([]interfaces.ImportRow, 0, len(records))
+	for i, record := range records {
+		line := lines[i]
+		if isBlank(record) {
+			continue
+		}
+		value := func(field string) string {
+			index, ok := columns[field]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		row := interfaces.ImportRow{Line: line, Description: value("description"), Category: value("category")}
+		var err error
+		row.Date, err = time.Parse(mapping.DateFormat, value("date"))
+		if err != nil {
+			return nil, lineError(line, errors.Errorf("%q does not match the date format %s", value("date"), mapping.DateFormat))
+		}
+
+		var amount interfaces.Money
+		if _, ok := columns["amount"]; ok {
+			if amount, err = parseAmount(value("amount")); err != nil {
+				return nil, lineError(line, err)
+			}
+		} else {
+			debit, credit := value("debit"), value("credit")
+			switch {
+			case credit != "":
+				amount, err = parseAmount(credit)
+			case debit != "":
+				amount, err = parseAmount(debit)
+				amount = -amount
+			default:
+				err = errors.New("neither debit nor credit is set")
+			}
+			if err != nil {
+				return nil, lineError(line, err)
+			}
+		}
+		signedRow(&row, amount)
+
+		if txnType := strings.ToUpper(value("type")); txnType != "" {
+			switch txnType {
+			case impl.TransactionTypeIncome, "CREDIT":
+				row.Type = impl.TransactionTypeIncome
+			case impl.TransactionTypeExpense, "DEBIT":
+				row.Type = impl.TransactionTypeExpense
+			default:
+				return nil, lineError(line, errors.Errorf("unknown type %q", value("type")))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// columnIndex resolves a mapped column to its 0-based index.
+func columnIndex(header []string, name string, noHeader bool) (int, error) {
+	if noHeader {
+		index, err := strconv.Atoi(name)
+		if err != nil || index < 1 {
+			return 0, errors.Wrapf(ErrInvalidFile, "column %q must be a column number when the file has no header", name)
+		}
+		return index - 1, nil
+	}
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(column), name) {
+			return i, nil
+		}
+	}
+	return 0, errors.Wrapf(ErrInvalidFile, "column %q is not in the header", name)
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Run("Signed amount column", func(t *testing.T) {
+		file := "Posted,Details,Value,Group\n31/01/2024,Coffee Shop,\"-1,204.50\",Food\n\n01/02/2024,Salary,3000,\n"
+		rows, err := ParseCSV(strings.NewReader(file), CSVMapping{Date: "posted", Amount: "value", Description: "details", Category: "group", DateFormat: "02/01/2006"})
+		assert.NoError(t, err)
+		assert.Len(t, rows, 2)
+		assert.Equal(t, 2, rows[0].Line)
+		assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), rows[0].Date)
+		assert.Equal(t, interfaces.MoneyFromFloat(1204.5), rows[0].Amount)
+		assert.Equal(t, impl.TransactionTypeExpense, rows[0].Type)
+		assert.Equal(t, "Food", rows[0].Category)
+		assert.Equal(t, 4, rows[1].Line, "blank lines keep the line numbering")
+		assert.Equal(t, impl.TransactionTypeIncome, rows[1].Type)
+	})
+
+	t.Run("Debit and credit columns without header", func(t *testing.T) {
+		file := "2024-03-01;Rent;800;\n2024-03-02;Refund;;(25.00)\n"
+		rows, err := ParseCSV(strings.NewReader(file), CSVMapping{Date: "1", Description: "2", Debit: "3", Credit: "4", Delimiter: ";", NoHeader: true})
+		assert.NoError(t, err)
+		assert.Equal(t, impl.TransactionTypeExpense, rows[0].Type)
+		assert.Equal(t, interfaces.MoneyFromFloat(800.0), rows[0].Amount)
+		assert.Equal(t, impl.TransactionTypeExpense, rows[1].Type, "parentheses mark a negative credit")
+	})
+
+	t.Run("Unknown column", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("Date,Amount\n"), CSVMapping{Date: "date", Amount: "total"})
+		assert.ErrorIs(t, err, ErrInvalidFile)
+	})
+
+	t.Run("Bad date reports the line", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("Date,Amount\n2024-01-01,5\nyesterday,5\n"), CSVMapping{Date: "date", Amount: "amount"})
+		assert.ErrorIs(t, err, ErrInvalidFile)
+		assert.Contains(t, err.Error(), "line 3")
+	})
+}
@@ -0,0 +1,105 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package importer parses bank statements into rows for the transaction import.
+package importer
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatCSV = "CSV"
+	FormatOFX = "OFX"
+	FormatQFX = "QFX"
+	FormatQIF = "QIF"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("supported import formats are CSV, OFX, QFX and QIF")
+	ErrInvalidFile       = errors.New("invalid import file")
+)
+
+// Parse reads a statement in the given format. mapping is only used for CSV.
+// Every returned row has a date, a positive amount and a type.
+func Parse(format string, r io.Reader, mapping CSVMapping) ([]interfaces.ImportRow, error) {
+	switch strings.ToUpper(format) {
+	case FormatCSV:
+		return ParseCSV(r, mapping)
+	case FormatOFX, FormatQFX:
+		return ParseOFX(r)
+	case FormatQIF:
+		return ParseQIF(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// FormatFromFileName guesses the format from the file extension, e.g. "statement.qfx" is QFX.
+func FormatFromFileName(name string) string {
+	return strings.ToUpper(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// parseAmount reads a signed amount, ignoring currency symbols, spaces and thousands
+// separators. Amounts in parentheses are negative.
+func parseAmount(value string) (interfaces.Money, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	cleaned := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '+' {
+			return r
+		}
+		return -1
+	}, value)
+	if cleaned == "" {
+		return 0, errors.Errorf("%q is not an amount", value)
+	}
+	amount, err := interfaces.ParseMoney(cleaned)
+	if err != nil {
+		return 0, errors.Errorf("%q is not an amount", value)
+	}
+	if negative {
+		amount = -amount.Abs()
+	}
+	return amount, nil
+}
+
+// signedRow fills the amount and type of row from a signed amount: negative amounts are expenses.
+func signedRow(row *interfaces.ImportRow, amount interfaces.Money) {
+	row.Type = impl.TransactionTypeIncome
+	if amount < 0 {
+		row.Type = impl.TransactionTypeExpense
+	}
+	row.Amount = amount.Abs()
+}
+
+// lineError wraps ErrInvalidFile with the line that could not be parsed.
+func lineError(line int, err error) error {
+	return errors.Wrapf(ErrInvalidFile, "line %d: %v", line, err)
+}
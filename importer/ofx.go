@@ -0,0 +1,96 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package importer
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ofxTransaction = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxElement     = regexp.MustCompile(`(?i)<([A-Z0-9.]+)>([^<\r\n]*)`)
+)
+
+// ParseOFX reads the STMTTRN records of an OFX or QFX statement. Both the SGML flavour
+// (OFX 1.x, where elements have no closing tags) and the XML flavour (OFX 2.x) are accepted.
+func ParseOFX(r io.Reader) ([]interfaces.ImportRow, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading OFX file failed")
+	}
+	text := string(content)
+	if !strings.Contains(strings.ToUpper(text), "<OFX>") {
+		return nil, errors.Wrap(ErrInvalidFile, "no <OFX> element found")
+	}
+
+	matches := ofxTransaction.FindAllStringSubmatchIndex(text, -1)
+	rows := make([]interfaces.ImportRow, 0, len(matches))
+	for _, match := range matches {
+		line := strings.Count(text[:match[0]], "\n") + 1
+		fields := map[string]string{}
+		for _, element := range ofxElement.FindAllStringSubmatch(text[match[2]:match[3]], -1) {
+			fields[strings.ToUpper(element[1])] = strings.TrimSpace(element[2])
+		}
+
+		row := interfaces.ImportRow{Line: line, ExternalID: fields["FITID"]}
+		if row.Date, err = parseOFXDate(fields["DTPOSTED"]); err != nil {
+			return nil, lineError(line, err)
+		}
+		amount, err := parseAmount(fields["TRNAMT"])
+		if err != nil {
+			return nil, lineError(line, err)
+		}
+		signedRow(&row, amount)
+
+		row.Description = fields["NAME"]
+		if memo := fields["MEMO"]; memo != "" && memo != row.Description {
+			if row.Description == "" {
+				row.Description = memo
+			} else {
+				row.Description += " - " + memo
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseOFXDate reads the date part of an OFX datetime such as 20240131120000.000[-5:EST].
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, errors.Errorf("%q is not an OFX date", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, errors.Errorf("%q is not an OFX date", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240131120000.000[-5:EST]
+<TRNAMT>-42.10
+<FITID>A1
+<NAME>GROCER
+<MEMO>Weekly shop
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240201
+<TRNAMT>3000.00
+<FITID>A2
+<NAME>ACME PAYROLL
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+func TestParseOFX(t *testing.T) {
+	rows, err := ParseOFX(strings.NewReader(sgmlStatement))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), rows[0].Date)
+	assert.Equal(t, interfaces.MoneyFromFloat(42.1), rows[0].Amount)
+	assert.Equal(t, impl.TransactionTypeExpense, rows[0].Type)
+	assert.Equal(t, "GROCER - Weekly shop", rows[0].Description)
+	assert.Equal(t, "A1", rows[0].ExternalID)
+	assert.Equal(t, impl.TransactionTypeIncome, rows[1].Type)
+
+	xml := `<?xml version="1.0"?><OFX><STMTTRN><DTPOSTED>20240305</DTPOSTED><TRNAMT>-5</TRNAMT><NAME>Bus</NAME></STMTTRN></OFX>`
+	rows, err = ParseOFX(strings.NewReader(xml))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bus", rows[0].Description)
+
+	_, err = ParseOFX(strings.NewReader("Date,Amount"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+}
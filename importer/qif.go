@@ -0,0 +1,131 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package importer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/pkg/errors"
+)
+
+// qifDateLayouts are the US-style date layouts written by common finance programs,
+// after normalizing the apostrophe some of them use before the year to a slash.
+var qifDateLayouts = []string{"1/2/2006", "1/2/06", "2006-01-02", "1-2-2006", "1-2-06"}
+
+// ParseQIF reads the records of a QIF bank or credit card statement. Records end with "^";
+// the D (date), T or U (amount), P (payee), M (memo) and L (category) fields are used.
+// Split lines and investment accounts are not supported.
+func ParseQIF(r io.Reader) ([]interfaces.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	rows := make([]interfaces.ImportRow, 0)
+
+	var (
+		row       interfaces.ImportRow
+		amount    string
+		memo      string
+		hasFields bool
+		line      int
+	)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "!") {
+			if !strings.HasPrefix(strings.ToUpper(text), "!TYPE:") && !strings.HasPrefix(strings.ToUpper(text), "!OPTION") {
+				return nil, lineError(line, errors.Errorf("unsupported QIF section %q", text))
+			}
+			continue
+		}
+		if !hasFields {
+			row = interfaces.ImportRow{Line: line}
+			amount, memo = "", ""
+		}
+
+		field, value := text[0], strings.TrimSpace(text[1:])
+		switch field {
+		case 'D':
+			date, err := parseQIFDate(value)
+			if err != nil {
+				return nil, lineError(line, err)
+			}
+			row.Date = date
+		case 'T', 'U':
+			amount = value
+		case 'P':
+			row.Description = value
+		case 'M':
+			memo = value
+		case 'L':
+			// Transfers to other accounts are written as [Account]; only plain categories are kept.
+			if !strings.HasPrefix(value, "[") {
+				row.Category = value
+			}
+		case '^':
+			if !hasFields {
+				continue
+			}
+			if row.Date.IsZero() || amount == "" {
+				return nil, lineError(row.Line, errors.New("a QIF record needs a date and an amount"))
+			}
+			value, err := parseAmount(amount)
+			if err != nil {
+				return nil, lineError(row.Line, err)
+			}
+			signedRow(&row, value)
+			if row.Description == "" {
+				row.Description = memo
+			} else if memo != "" {
+				row.Description += " - " + memo
+			}
+			rows = append(rows, row)
+			hasFields = false
+			continue
+		}
+		hasFields = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading QIF file failed")
+	}
+	if hasFields {
+		return nil, lineError(row.Line, errors.New("the last QIF record is not terminated with ^"))
+	}
+	return rows, nil
+}
+
+func parseQIFDate(value string) (time.Time, error) {
+	normalized := strings.ReplaceAll(strings.ReplaceAll(value, "'", "/"), " ", "")
+	for _, layout := range qifDateLayouts {
+		if date, err := time.Parse(layout, normalized); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("%q is not a QIF date", value)
+}
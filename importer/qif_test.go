@@ -0,0 +1,31 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQIF(t *testing.T) {
+	file := "!Type:Bank\nD1/31'2024\nT-1,250.00\nPLandlord\nMJanuary\nLHousing\n^\nD02/01/24\nU3000\nPSalary\nL[Savings]\n^\n"
+	rows, err := ParseQIF(strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), rows[0].Date)
+	assert.Equal(t, interfaces.MoneyFromFloat(1250.0), rows[0].Amount)
+	assert.Equal(t, impl.TransactionTypeExpense, rows[0].Type)
+	assert.Equal(t, "Landlord - January", rows[0].Description)
+	assert.Equal(t, "Housing", rows[0].Category)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), rows[1].Date)
+	assert.Empty(t, rows[1].Category, "account transfers are not categories")
+
+	_, err = ParseQIF(strings.NewReader("!Type:Bank\nD1/31/2024\nT-5\n"))
+	assert.ErrorIs(t, err, ErrInvalidFile, "unterminated record")
+
+	_, err = ParseQIF(strings.NewReader("!Type:Invst\nD1/31/2024\n^\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/pkg/errors"
+)
+
+// rateColumns are the header names accepted for each column of an exchange rate file.
+var rateColumns = map[string][]string{
+	"date": {"date", "rate_date"},
+	"from": {"from", "from_currency", "base"},
+	"to":   {"to", "to_currency", "quote"},
+	"rate": {"rate"},
+}
+
+// ParseRates reads exchange rates from a CSV file with a header row naming its date, from, to
+// and rate columns, in any order. Dates use the 2006-01-02 layout; one unit of the from currency
+// buys rate units of the to currency. Currencies are checked when the rates are stored.
+func ParseRates(r io.Reader) ([]interfaces.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.Wrap(ErrInvalidFile, "the rate file has no header row")
+	}
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidFile, err.Error())
+	}
+	columns := map[string]int{}
+	for field, names := range rateColumns {
+		index := -1
+		for _, name := range names {
+			if i, err := columnIndex(header, name, false); err == nil {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, errors.Wrapf(ErrInvalidFile, "the rate file needs a %q column", field)
+		}
+		columns[field] = index
+	}
+
+	rates := make([]interfaces.ExchangeRate, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidFile, err.Error())
+		}
+		line, _ := reader.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+		value := func(field string) string {
+			if index := columns[field]; index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+
+		rate := interfaces.ExchangeRate{FromCurrency: value("from"), ToCurrency: value("to")}
+		if rate.RateDate, err = time.Parse(defaultDateFormat, value("date")); err != nil {
+			return nil, lineError(line, errors.Errorf("%q does not match the date format %s", value("date"), defaultDateFormat))
+		}
+		if rate.Rate, err = strconv.ParseFloat(value("rate"), 64); err != nil || rate.Rate <= 0 {
+			return nil, lineError(line, errors.Errorf("%q is not a positive rate", value("rate")))
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
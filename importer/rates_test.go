@@ -0,0 +1,35 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRates(t *testing.T) {
+	t.Run("Columns in any order", func(t *testing.T) {
+		file := "Rate,From,To,Date\n0.92,USD,EUR,2024-01-31\n\n83.1,usd,inr,2024-02-01\n"
+		rates, err := ParseRates(strings.NewReader(file))
+		assert.NoError(t, err)
+		assert.Len(t, rates, 2)
+		assert.Equal(t, "USD", rates[0].FromCurrency)
+		assert.Equal(t, "EUR", rates[0].ToCurrency)
+		assert.Equal(t, 0.92, rates[0].Rate)
+		assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), rates[0].RateDate)
+		assert.Equal(t, "usd", rates[1].FromCurrency, "currencies are normalized when stored")
+	})
+
+	t.Run("Missing column", func(t *testing.T) {
+		_, err := ParseRates(strings.NewReader("date,from,rate\n"))
+		assert.ErrorIs(t, err, ErrInvalidFile)
+		assert.Contains(t, err.Error(), `"to"`)
+	})
+
+	t.Run("Invalid rate reports its line", func(t *testing.T) {
+		_, err := ParseRates(strings.NewReader("date,from,to,rate\n2024-01-31,USD,EUR,0.92\n2024-02-01,USD,EUR,-1\n"))
+		assert.ErrorIs(t, err, ErrInvalidFile)
+		assert.Contains(t, err.Error(), "line 3")
+	})
+}
@@ -27,14 +27,19 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"xspends/api"
 	"xspends/kvstore"
 	"xspends/models/impl"
+	"xspends/scheduler"
 	"xspends/util"
 
 	"github.com/gin-gonic/gin"
 )
 
+// searchIndexBatchSize is the number of transactions read at a time when filling in the search index.
+const searchIndexBatchSize = 500
+
 // SetupRoutes configures all the routes for the application.
 // It takes a gin Engine, a sql DB, and a kvstore RawKVClientInterface as parameters.
 // @title XSpends API
@@ -43,6 +48,10 @@ import (
 // @host localhost:8080
 // @BasePath /
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	r := gin.Default()
 	util.InitializeSnowflake()
 
@@ -51,25 +60,55 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
+	templateModel, err := impl.NewTemplateModelFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load seed templates: %v", err)
+	}
 	realConfig := &impl.ModelsConfig{
-		DBService:           dbService,
-		CategoryModel:       impl.NewCategoryModel(), // Initialize other models as needed
-		SourceModel:         impl.NewSourceModel(),
-		UserModel:           impl.NewUserModel(),
-		TagModel:            impl.NewTagModel(),
-		TransactionTagModel: impl.NewTransactionTagModel(),
-		TransactionModel:    impl.NewTransactionModel(),
-		ScopeModel:          impl.NewScopeModel(),
-		GroupModel:          impl.NewGroupModel(),
-		UserScopeModel:      impl.NewUserScopeModel(),
+		DBService:                 dbService,
+		CategoryModel:             impl.NewCategoryModel(), // Initialize other models as needed
+		SourceModel:               impl.NewSourceModel(),
+		UserModel:                 impl.NewUserModel(),
+		TagModel:                  impl.NewTagModel(),
+		TransactionTagModel:       impl.NewTransactionTagModel(),
+		TransactionModel:          impl.NewTransactionModel(),
+		ScopeModel:                impl.NewScopeModel(),
+		GroupModel:                impl.NewGroupModel(),
+		UserScopeModel:            impl.NewUserScopeModel(),
+		GroupInvitationModel:      impl.NewGroupInvitationModel(),
+		RecurringTransactionModel: impl.NewRecurringTransactionModel(),
+		BudgetModel:               impl.NewBudgetModel(),
+		ImportBatchModel:          impl.NewImportBatchModel(),
+		ArchiveModel:              impl.NewArchiveModel(),
+		CurrencyModel:             impl.NewCurrencyModel(),
+		TransactionSplitModel:     impl.NewTransactionSplitModel(),
+		SharedExpenseModel:        impl.NewSharedExpenseModel(),
+		TrashModel:                impl.NewTrashModel(),
+		TemplateModel:             templateModel,
+		RuleModel:                 impl.NewRuleModel(),
+		TransactionSearchModel:    impl.NewTransactionSearchModel(),
 	}
 
 	// Initialize ModelsService with real configuration
 	impl.InitModelsService(realConfig)
+	go indexMissingTransactions(context.Background())
 	//TODO: Should move the KVstore initialization inside model ?
 	kvstore.SetupKV(context.Background(), false)
 	kv := kvstore.GetClientFromPool()
 	api.SetupRoutes(r, kv) // refactored (impl.getDB() removed)
+	scheduler.NewRecurringSchedulerFromEnv().Start(context.Background())
+	scheduler.NewTrashPurgerFromEnv().Start(context.Background())
 
 	r.Run() // Defaults to :8080
 }
+
+// indexMissingTransactions adds the transactions recorded before the search index existed to it.
+func indexMissingTransactions(ctx context.Context) {
+	indexed, err := impl.GetModelsService().TransactionSearchModel.IndexMissingTransactions(ctx, searchIndexBatchSize)
+	if err != nil {
+		log.Printf("[SearchIndex] Error: %v", err)
+	}
+	if indexed > 0 {
+		log.Printf("[SearchIndex] Indexed %d transactions", indexed)
+	}
+}
@@ -27,6 +27,7 @@ package middleware
 import (
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"xspends/api/handlers"
@@ -44,8 +45,12 @@ var ab *authboss.Authboss
 const scopeIDKey = "scopeID"
 const userIDKey = "userID"
 const groupIDKey = "groupID"
+const sessionIDKey = "sessionID"
 const authKey = "Authorization"
 
+// groupHeader lets a client act inside one of its groups for the duration of a request.
+const groupHeader = "X-Group-ID"
+
 func AuthMiddleware(ab *authboss.Authboss) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from the Authorization header
@@ -76,14 +81,51 @@ func AuthMiddleware(ab *authboss.Authboss) gin.HandlerFunc {
 			return
 		}
 
+		// Tokens of a session that was logged out or revoked stop working with it
+		if ab != nil {
+			if sessionStorer, ok := ab.Config.Storage.SessionState.(*impl.SessionStorer); ok {
+				state, err := sessionStorer.Load(c.Request.Context(), claims.SessionID)
+				if err != nil || state == "" {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has ended"})
+					c.Abort()
+					return
+				}
+			}
+		}
+
 		// If the token is valid, store the user data (from the JWT claims) in the context
 		c.Set(userIDKey, claims.UserID)
 		c.Set(scopeIDKey, claims.ScopeID)
+		c.Set(sessionIDKey, claims.SessionID)
 		// Continue with the request
 		c.Next()
 	}
 }
 
+// GroupMiddleware reads the active group from the X-Group-ID header.
+// Requests without the header act in the user's personal scope.
+// Membership is validated later by ScopeMiddleware, against the role of the route.
+func GroupMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		groupIDStr := strings.TrimSpace(c.GetHeader(groupHeader))
+		if groupIDStr == "" {
+			c.Next()
+			return
+		}
+
+		groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+		if err != nil || groupID <= 0 {
+			log.Printf("[GroupMiddleware] Error: invalid %s header: %q", groupHeader, groupIDStr)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + groupHeader + " header"})
+			c.Abort()
+			return
+		}
+
+		c.Set(groupIDKey, groupID)
+		c.Next()
+	}
+}
+
 func ScopeMiddleware(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get(userIDKey)
@@ -102,7 +144,10 @@ func ScopeMiddleware(role string) gin.HandlerFunc {
 		scopeInfo, ok := GetScopeInfo(c, userID.(int64), groupID.(int64), role)
 		if !ok {
 			log.Printf("[ScopeMiddleware] Error: %v", "Missing scope information")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing scope information"})
+			// GetScopeInfo may already have responded with a more specific error
+			if !c.Writer.Written() {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing scope information"})
+			}
 			c.Abort()
 			return
 		}
@@ -122,9 +167,11 @@ func GetScopeInfo(c *gin.Context, userID int64, groupID int64, role string) (han
 	groupScope := int64(0)
 	if groupID != 0 {
 		var okGroup bool
-		groupScope, okGroup = getGroupScope(c, userID, groupID)
+		groupScope, okGroup = getGroupScope(c, userID, groupID, role)
 		if !okGroup {
 			log.Printf("[GetScopeInfo] Error: %v", "Missing Group scope information")
+			c.JSON(http.StatusForbidden, gin.H{"error": "not permitted to act in this group"})
+			return handlers.ScopeInfo{}, false
 		}
 	}
 
@@ -144,12 +191,18 @@ func GetScopeInfo(c *gin.Context, userID int64, groupID int64, role string) (han
 	}
 	return scopeInfo, true
 }
-func getGroupScope(c *gin.Context, userID int64, groupID int64) (int64, bool) {
+
+// getGroupScope resolves the scope of a group and checks that the user holds at least role in it.
+func getGroupScope(c *gin.Context, userID int64, groupID int64, role string) (int64, bool) {
 	group, ok := impl.GetModelsService().GroupModel.GetGroupByID(c, groupID, userID)
 	if ok != nil {
 		log.Printf("[getGroupScope] Error: %v", "Group does not exist")
 		return 0, false
 	}
+	if !impl.GetModelsService().UserScopeModel.ValidateUserScope(c, userID, group.ScopeID, role) {
+		log.Printf("[getGroupScope] Error: user %d lacks %s access to group %d", userID, role, groupID)
+		return 0, false
+	}
 	return group.ScopeID, true
 }
 
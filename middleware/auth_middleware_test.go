@@ -1,13 +1,22 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"xspends/api/handlers"
+	ymock "xspends/kvstore/mock"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/testutils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/volatiletech/authboss/v3"
 )
 
 func TestEnsureUserID(t *testing.T) {
@@ -98,3 +107,158 @@ func TestAuthMiddleware(t *testing.T) {
 	// Add more tests for failure scenarios as needed
 	// ...
 }
+
+func TestAuthMiddlewareSessions(t *testing.T) {
+	mockKV := ymock.NewMockRawKVClientInterface(gomock.NewController(t))
+	sessionAB := &authboss.Authboss{}
+	sessionAB.Config.Storage.SessionState = impl.NewSessionStorer(mockKV)
+
+	router := gin.New()
+	router.Use(AuthMiddleware(sessionAB))
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(sessionIDKey))
+	})
+
+	tests := []struct {
+		name           string
+		session        string
+		state          []byte
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "Live session", session: "7", state: []byte("refresh-token"), expectedStatus: http.StatusOK, expectedBody: "7"},
+		{name: "Logged out or revoked session", session: "8", expectedStatus: http.StatusUnauthorized, expectedBody: `{"error":"Session has ended"}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockKV.EXPECT().Get(gomock.Any(), []byte(tc.session)).Return(tc.state, nil)
+			token, _ := handlers.GenerateTokenWithTTL(123, 123, tc.session, 30)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestGroupMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(GroupMiddleware())
+	router.GET("/test", func(c *gin.Context) {
+		groupID, exists := c.Get(groupIDKey)
+		if !exists {
+			groupID = int64(0)
+		}
+		c.JSON(http.StatusOK, gin.H{"group_id": groupID})
+	})
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "No header", header: "", expectedStatus: http.StatusOK, expectedBody: `{"group_id":0}`},
+		{name: "Valid header", header: "42", expectedStatus: http.StatusOK, expectedBody: `{"group_id":42}`},
+		{name: "Invalid header", header: "abc", expectedStatus: http.StatusBadRequest, expectedBody: `{"error":"invalid X-Group-ID header"}`},
+		{name: "Negative header", header: "-1", expectedStatus: http.StatusBadRequest, expectedBody: `{"error":"invalid X-Group-ID header"}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+			if tc.header != "" {
+				req.Header.Set(groupHeader, tc.header)
+			}
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestScopeMiddlewareWithGroup(t *testing.T) {
+	_, modelsService, _, _, tearDown := testutils.SetupModelTestEnvironment(t)
+	defer tearDown()
+
+	mockGroupModel := new(xmock.MockGroupModel)
+	mockUserScopeModel := new(xmock.MockUserScopeModel)
+	modelsService.GroupModel = mockGroupModel
+	modelsService.UserScopeModel = mockUserScopeModel
+
+	group := &interfaces.Group{GroupID: 42, ScopeID: 420}
+	mockUserScopeModel.On("GetUserScopesByRole", mock.Anything, int64(123), impl.RoleWrite, mock.Anything).Return([]interfaces.UserScope{{UserID: 123, ScopeID: 420, Role: impl.RoleWrite}}, nil)
+	mockGroupModel.On("GetGroupByID", mock.Anything, int64(42), int64(123), mock.Anything).Return(group, nil)
+	mockGroupModel.On("GetGroupByID", mock.Anything, int64(43), int64(123), mock.Anything).Return((*interfaces.Group)(nil), errors.New("group not found"))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(userIDKey, int64(123))
+		c.Set(scopeIDKey, int64(123))
+		c.Next()
+	}, GroupMiddleware(), ScopeMiddleware(impl.RoleWrite))
+	router.GET("/test", func(c *gin.Context) {
+		scopeInfo, _ := c.Get("scopeInfo")
+		c.JSON(http.StatusOK, gin.H{"use_scope": scopeInfo.(handlers.ScopeInfo).UseScope})
+	})
+
+	tests := []struct {
+		name           string
+		header         string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Personal scope without header",
+			setupMock:      func() {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"use_scope":123}`,
+		},
+		{
+			name:   "Group member with write access",
+			header: "42",
+			setupMock: func() {
+				mockUserScopeModel.On("ValidateUserScope", mock.Anything, int64(123), int64(420), impl.RoleWrite, mock.Anything).Return(true).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"use_scope":420}`,
+		},
+		{
+			name:   "Group member without write access",
+			header: "42",
+			setupMock: func() {
+				mockUserScopeModel.On("ValidateUserScope", mock.Anything, int64(123), int64(420), impl.RoleWrite, mock.Anything).Return(false).Once()
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"not permitted to act in this group"}`,
+		},
+		{
+			name:           "Unknown group",
+			header:         "43",
+			setupMock:      func() {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"not permitted to act in this group"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+			if tc.header != "" {
+				req.Header.Set(groupHeader, tc.header)
+			}
+			tc.setupMock()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
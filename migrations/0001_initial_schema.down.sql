@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS `transaction_tags`;
+DROP TABLE IF EXISTS `transactions`;
+DROP TABLE IF EXISTS `tags`;
+DROP TABLE IF EXISTS `sources`;
+DROP TABLE IF EXISTS `categories`;
+DROP TABLE IF EXISTS `user_groups`;
+DROP TABLE IF EXISTS `user_scopes`;
+DROP TABLE IF EXISTS `scopes`;
+DROP TABLE IF EXISTS `users`;
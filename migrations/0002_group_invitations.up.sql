@@ -0,0 +1,17 @@
+-- Invitations to join a group; the invitee becomes a member once they accept.
+CREATE TABLE IF NOT EXISTS `group_invitations` (
+    `invitation_id` BIGINT NOT NULL,
+    `group_id` BIGINT NOT NULL,
+    `inviter_id` BIGINT NOT NULL,
+    `invitee_id` BIGINT NOT NULL,
+    `role` VARCHAR(255) NOT NULL,
+    `status` VARCHAR(64) NOT NULL DEFAULT 'pending',
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`invitation_id`),
+    FOREIGN KEY (`group_id`) REFERENCES `user_groups`(`group_id`),
+    FOREIGN KEY (`inviter_id`) REFERENCES `users`(`user_id`),
+    FOREIGN KEY (`invitee_id`) REFERENCES `users`(`user_id`)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_invitations_invitee ON group_invitations(invitee_id, status);
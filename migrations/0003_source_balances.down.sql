@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_sourceid ON transactions;
+
+ALTER TABLE `sources` DROP COLUMN IF EXISTS `opening_balance`;
@@ -0,0 +1,4 @@
+-- Source balances are derived from the opening balance and the source's transactions.
+ALTER TABLE `sources` ADD COLUMN IF NOT EXISTS `opening_balance` DECIMAL(10, 2) NOT NULL DEFAULT 0.00;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_sourceid ON transactions(source_id);
@@ -0,0 +1,6 @@
+-- Both legs of each transfer stay behind as ordinary transactions.
+DROP INDEX IF EXISTS idx_transactions_transferid ON transactions;
+
+ALTER TABLE `transactions` DROP FOREIGN KEY IF EXISTS `fk_transactions_destination_source`;
+ALTER TABLE `transactions` DROP COLUMN IF EXISTS `transfer_id`;
+ALTER TABLE `transactions` DROP COLUMN IF EXISTS `destination_source_id`;
@@ -0,0 +1,7 @@
+-- A transfer is two linked transaction legs sharing a transfer_id; the outgoing leg names
+-- the destination source.
+ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `destination_source_id` BIGINT NULL DEFAULT NULL;
+ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `transfer_id` BIGINT NULL DEFAULT NULL;
+ALTER TABLE `transactions` ADD CONSTRAINT `fk_transactions_destination_source` FOREIGN KEY IF NOT EXISTS (`destination_source_id`) REFERENCES `sources`(`source_id`);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_transferid ON transactions(transfer_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `recurring_occurrences`;
+DROP TABLE IF EXISTS `recurring_transactions`;
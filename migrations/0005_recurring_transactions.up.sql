@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS `recurring_transactions` (
+    `recurring_id` BIGINT NOT NULL,
+    `user_id` BIGINT NOT NULL,
+    `scope_id` BIGINT NOT NULL,
+    `source_id` BIGINT NOT NULL,
+    `category_id` BIGINT NOT NULL,
+    `amount` DECIMAL(10, 2) NOT NULL,
+    `type` VARCHAR(255) NOT NULL,
+    `description` TEXT,
+    `tags` TEXT,
+    `frequency` VARCHAR(16) NOT NULL,
+    `interval_count` INT NOT NULL DEFAULT 1,
+    `start_date` DATE NOT NULL,
+    `end_date` DATE,
+    `max_occurrences` INT NOT NULL DEFAULT 0,
+    `occurrences` INT NOT NULL DEFAULT 0,
+    `next_occurrence` DATE,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`recurring_id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`user_id`),
+    FOREIGN KEY (`scope_id`) REFERENCES `scopes`(`scope_id`),
+    FOREIGN KEY (`source_id`) REFERENCES `sources`(`source_id`),
+    FOREIGN KEY (`category_id`) REFERENCES `categories`(`category_id`)
+);
+
+-- One row per materialized or skipped occurrence; the primary key keeps the scheduler idempotent.
+CREATE TABLE IF NOT EXISTS `recurring_occurrences` (
+    `recurring_id` BIGINT NOT NULL,
+    `occurrence_date` DATE NOT NULL,
+    `status` VARCHAR(16) NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`recurring_id`, `occurrence_date`),
+    FOREIGN KEY (`recurring_id`) REFERENCES `recurring_transactions`(`recurring_id`)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_next_occurrence ON recurring_transactions(next_occurrence);
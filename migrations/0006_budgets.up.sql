@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `budgets` (
+    `budget_id` BIGINT NOT NULL,
+    `user_id` BIGINT NOT NULL,
+    `scope_id` BIGINT NOT NULL,
+    `category_id` BIGINT NOT NULL,
+    `amount` DECIMAL(10, 2) NOT NULL,
+    `period` VARCHAR(16) NOT NULL,
+    `start_date` DATE NOT NULL,
+    `end_date` DATE,
+    `rollover` BOOLEAN NOT NULL DEFAULT FALSE,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`budget_id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`user_id`),
+    FOREIGN KEY (`scope_id`) REFERENCES `scopes`(`scope_id`),
+    FOREIGN KEY (`category_id`) REFERENCES `categories`(`category_id`)
+);
+
+CREATE INDEX IF NOT EXISTS idx_budgets_scope_category ON budgets(scope_id, category_id);
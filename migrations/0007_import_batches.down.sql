@@ -0,0 +1,7 @@
+-- Imported transactions stay behind, no longer tied to a batch that can be undone.
+DROP INDEX IF EXISTS idx_transactions_import_batch ON transactions;
+
+ALTER TABLE `transactions` DROP FOREIGN KEY IF EXISTS `fk_transactions_import_batch`;
+ALTER TABLE `transactions` DROP COLUMN IF EXISTS `import_batch_id`;
+
+DROP TABLE IF EXISTS `import_batches`;
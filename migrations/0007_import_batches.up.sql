@@ -0,0 +1,22 @@
+-- One row per committed statement import; its transactions point back to it through import_batch_id.
+CREATE TABLE IF NOT EXISTS `import_batches` (
+    `batch_id` BIGINT NOT NULL,
+    `user_id` BIGINT NOT NULL,
+    `scope_id` BIGINT NOT NULL,
+    `source_id` BIGINT NOT NULL,
+    `format` VARCHAR(16) NOT NULL,
+    `file_name` VARCHAR(255) NOT NULL DEFAULT '',
+    `row_count` INT NOT NULL DEFAULT 0,
+    `status` VARCHAR(16) NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `undone_at` DATETIME,
+    PRIMARY KEY (`batch_id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`user_id`),
+    FOREIGN KEY (`scope_id`) REFERENCES `scopes`(`scope_id`),
+    FOREIGN KEY (`source_id`) REFERENCES `sources`(`source_id`)
+);
+
+ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `import_batch_id` BIGINT NULL DEFAULT NULL;
+ALTER TABLE `transactions` ADD CONSTRAINT `fk_transactions_import_batch` FOREIGN KEY IF NOT EXISTS (`import_batch_id`) REFERENCES `import_batches`(`batch_id`);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_import_batch ON transactions(import_batch_id);
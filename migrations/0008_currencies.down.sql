@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `exchange_rates`;
+
+ALTER TABLE `transactions` DROP COLUMN IF EXISTS `currency`;
+ALTER TABLE `sources` DROP COLUMN IF EXISTS `currency`;
+ALTER TABLE `user_groups` DROP COLUMN IF EXISTS `currency`;
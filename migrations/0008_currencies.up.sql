@@ -0,0 +1,18 @@
+-- Sources and transactions carry their own currency, and groups a base currency for reports.
+-- Existing sources and transactions default to USD.
+ALTER TABLE `user_groups` ADD COLUMN IF NOT EXISTS `currency` VARCHAR(3) NULL DEFAULT NULL;
+ALTER TABLE `sources` ADD COLUMN IF NOT EXISTS `currency` VARCHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `currency` VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+-- A rate holds from rate_date until the next rate recorded for the same scope and pair.
+CREATE TABLE IF NOT EXISTS `exchange_rates` (
+    `scope_id` BIGINT NOT NULL,
+    `from_currency` VARCHAR(3) NOT NULL,
+    `to_currency` VARCHAR(3) NOT NULL,
+    `rate_date` DATE NOT NULL,
+    `rate` DECIMAL(18, 8) NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`scope_id`, `from_currency`, `to_currency`, `rate_date`),
+    FOREIGN KEY (`scope_id`) REFERENCES `scopes`(`scope_id`)
+);
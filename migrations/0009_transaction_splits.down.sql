@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `transaction_split_tags`;
+DROP TABLE IF EXISTS `transaction_splits`;
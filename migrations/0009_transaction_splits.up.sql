@@ -0,0 +1,26 @@
+-- The lines of split transactions, each with its own category, amount, description and tags.
+-- A split transaction keeps its total and source; its category column is left NULL.
+CREATE TABLE IF NOT EXISTS `transaction_splits` (
+    `split_id` BIGINT NOT NULL,
+    `transaction_id` BIGINT NOT NULL,
+    `category_id` BIGINT NOT NULL,
+    `amount` DECIMAL(10, 2) NOT NULL,
+    `description` TEXT,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`split_id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`transaction_id`) ON DELETE CASCADE,
+    FOREIGN KEY (`category_id`) REFERENCES `categories`(`category_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `transaction_split_tags` (
+    `split_id` BIGINT NOT NULL,
+    `tag_id` BIGINT NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (`split_id`) REFERENCES `transaction_splits`(`split_id`) ON DELETE CASCADE,
+    FOREIGN KEY (`tag_id`) REFERENCES `tags`(`tag_id`),
+    PRIMARY KEY (`split_id`, `tag_id`)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_transaction ON transaction_splits(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_category ON transaction_splits(category_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `settlements`;
+DROP TABLE IF EXISTS `shared_expense_shares`;
+DROP TABLE IF EXISTS `shared_expenses`;
@@ -0,0 +1,45 @@
+-- Shared expenses: who paid a group expense and how its cost is split between members.
+-- Shares keep the input of the split rule; the amounts are recomputed from the transaction.
+CREATE TABLE IF NOT EXISTS `shared_expenses` (
+    `transaction_id` BIGINT NOT NULL,
+    `scope_id` BIGINT NOT NULL,
+    `payer_id` BIGINT NOT NULL,
+    `split_method` VARCHAR(16) NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`transaction_id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`transaction_id`) ON DELETE CASCADE,
+    FOREIGN KEY (`scope_id`) REFERENCES `scopes`(`scope_id`),
+    FOREIGN KEY (`payer_id`) REFERENCES `users`(`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `shared_expense_shares` (
+    `transaction_id` BIGINT NOT NULL,
+    `user_id` BIGINT NOT NULL,
+    `share_value` DECIMAL(12, 4) NOT NULL DEFAULT 0,
+    PRIMARY KEY (`transaction_id`, `user_id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `shared_expenses`(`transaction_id`) ON DELETE CASCADE,
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`user_id`)
+);
+
+-- Payments between members that settle shared expenses
+CREATE TABLE IF NOT EXISTS `settlements` (
+    `settlement_id` BIGINT NOT NULL,
+    `scope_id` BIGINT NOT NULL,
+    `from_user_id` BIGINT NOT NULL,
+    `to_user_id` BIGINT NOT NULL,
+    `amount` DECIMAL(10, 2) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `note` TEXT,
+    `settled_at` DATETIME NOT NULL,
+    `created_by` BIGINT NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`settlement_id`),
+    FOREIGN KEY (`scope_id`) REFERENCES `scopes`(`scope_id`),
+    FOREIGN KEY (`from_user_id`) REFERENCES `users`(`user_id`),
+    FOREIGN KEY (`to_user_id`) REFERENCES `users`(`user_id`),
+    FOREIGN KEY (`created_by`) REFERENCES `users`(`user_id`)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shared_expenses_scope ON shared_expenses(scope_id);
+CREATE INDEX IF NOT EXISTS idx_settlements_scope ON settlements(scope_id);
@@ -0,0 +1,26 @@
+-- Rows still in the trash become visible again, and their names must not clash with rows in use.
+CREATE UNIQUE INDEX IF NOT EXISTS `name` ON tags(name);
+CREATE UNIQUE INDEX IF NOT EXISTS `user_id` ON tags(user_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS `user_id` ON sources(user_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS `user_id` ON categories(user_id, name);
+
+DROP INDEX IF EXISTS uniq_tags_user_name ON tags;
+DROP INDEX IF EXISTS uniq_sources_user_name ON sources;
+DROP INDEX IF EXISTS uniq_categories_user_name ON categories;
+DROP INDEX IF EXISTS idx_tags_user ON tags;
+DROP INDEX IF EXISTS idx_sources_user ON sources;
+DROP INDEX IF EXISTS idx_categories_user ON categories;
+
+ALTER TABLE `tags` DROP COLUMN IF EXISTS `live`;
+ALTER TABLE `sources` DROP COLUMN IF EXISTS `live`;
+ALTER TABLE `categories` DROP COLUMN IF EXISTS `live`;
+
+DROP INDEX IF EXISTS idx_tags_scope_deleted ON tags;
+DROP INDEX IF EXISTS idx_sources_scope_deleted ON sources;
+DROP INDEX IF EXISTS idx_categories_scope_deleted ON categories;
+DROP INDEX IF EXISTS idx_transactions_scope_deleted ON transactions;
+
+ALTER TABLE `tags` DROP COLUMN IF EXISTS `deleted_at`;
+ALTER TABLE `sources` DROP COLUMN IF EXISTS `deleted_at`;
+ALTER TABLE `categories` DROP COLUMN IF EXISTS `deleted_at`;
+ALTER TABLE `transactions` DROP COLUMN IF EXISTS `deleted_at`;
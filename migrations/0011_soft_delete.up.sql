@@ -0,0 +1,31 @@
+-- Deleted ledger rows are kept in the trash, marked with deleted_at, until they are restored
+-- or purged after the retention period. Every read leaves out rows with deleted_at set.
+ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `deleted_at` DATETIME NULL DEFAULT NULL;
+ALTER TABLE `categories` ADD COLUMN IF NOT EXISTS `deleted_at` DATETIME NULL DEFAULT NULL;
+ALTER TABLE `sources` ADD COLUMN IF NOT EXISTS `deleted_at` DATETIME NULL DEFAULT NULL;
+ALTER TABLE `tags` ADD COLUMN IF NOT EXISTS `deleted_at` DATETIME NULL DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_scope_deleted ON transactions(scope_id, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_categories_scope_deleted ON categories(scope_id, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_sources_scope_deleted ON sources(scope_id, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_tags_scope_deleted ON tags(scope_id, deleted_at);
+
+-- Names only have to be unique among the rows in use, so a trashed item does not hold on to its
+-- name. live is 1 for those rows and NULL in the trash, which unique keys do not compare.
+ALTER TABLE `categories` ADD COLUMN IF NOT EXISTS `live` TINYINT AS (IF(`deleted_at` IS NULL, 1, NULL)) VIRTUAL;
+ALTER TABLE `sources` ADD COLUMN IF NOT EXISTS `live` TINYINT AS (IF(`deleted_at` IS NULL, 1, NULL)) VIRTUAL;
+ALTER TABLE `tags` ADD COLUMN IF NOT EXISTS `live` TINYINT AS (IF(`deleted_at` IS NULL, 1, NULL)) VIRTUAL;
+
+-- The user_id foreign keys keep an index of their own once the old unique keys are dropped
+CREATE INDEX IF NOT EXISTS idx_categories_user ON categories(user_id);
+CREATE INDEX IF NOT EXISTS idx_sources_user ON sources(user_id);
+CREATE INDEX IF NOT EXISTS idx_tags_user ON tags(user_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_categories_user_name ON categories(user_id, name, live);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_sources_user_name ON sources(user_id, name, live);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_tags_user_name ON tags(user_id, name, live);
+
+DROP INDEX IF EXISTS `user_id` ON categories;
+DROP INDEX IF EXISTS `user_id` ON sources;
+DROP INDEX IF EXISTS `user_id` ON tags;
+DROP INDEX IF EXISTS `name` ON tags;
@@ -0,0 +1,4 @@
+-- Every category becomes a top-level category again.
+ALTER TABLE `categories` DROP FOREIGN KEY IF EXISTS `fk_categories_parent`;
+DROP INDEX IF EXISTS idx_categories_parent ON categories;
+ALTER TABLE `categories` DROP COLUMN IF EXISTS `parent_id`;
@@ -0,0 +1,6 @@
+-- Categories form a tree through an optional parent in the same scope. Purging a parent
+-- leaves its remaining children at the top level.
+ALTER TABLE `categories` ADD COLUMN IF NOT EXISTS `parent_id` BIGINT NULL DEFAULT NULL;
+ALTER TABLE `categories` ADD CONSTRAINT `fk_categories_parent` FOREIGN KEY IF NOT EXISTS (`parent_id`) REFERENCES `categories`(`category_id`) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
@@ -0,0 +1,8 @@
+-- Fails while a user has two items of the same name in different scopes.
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_tags_user_name ON tags(user_id, name, live);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_sources_user_name ON sources(user_id, name, live);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_categories_user_name ON categories(user_id, name, live);
+
+DROP INDEX IF EXISTS uniq_tags_scope_name ON tags;
+DROP INDEX IF EXISTS uniq_sources_scope_name ON sources;
+DROP INDEX IF EXISTS uniq_categories_scope_name ON categories;
@@ -0,0 +1,9 @@
+-- Categories, sources and tags are named uniquely within their scope instead of per user, so a
+-- group owner's personal names do not clash with the group's, as when a template seeds both.
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_categories_scope_name ON categories(scope_id, name, live);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_sources_scope_name ON sources(scope_id, name, live);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_tags_scope_name ON tags(scope_id, name, live);
+
+DROP INDEX IF EXISTS uniq_categories_user_name ON categories;
+DROP INDEX IF EXISTS uniq_sources_user_name ON sources;
+DROP INDEX IF EXISTS uniq_tags_user_name ON tags;
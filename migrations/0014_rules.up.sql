@@ -0,0 +1,29 @@
+-- Per-scope categorization rules, applied to new and imported transactions in priority order.
+-- Zero amounts and empty strings mean the condition or action is not used.
+CREATE TABLE IF NOT EXISTS `rules` (
+    `rule_id` BIGINT NOT NULL,
+    `user_id` BIGINT NOT NULL,
+    `scope_id` BIGINT NOT NULL,
+    `name` VARCHAR(255) NOT NULL,
+    `priority` INT NOT NULL DEFAULT 0,
+    `enabled` BOOLEAN NOT NULL DEFAULT TRUE,
+    `stop_processing` BOOLEAN NOT NULL DEFAULT FALSE,
+    `description_contains` VARCHAR(255) NOT NULL DEFAULT '',
+    `description_regex` VARCHAR(255) NOT NULL DEFAULT '',
+    `min_amount` DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    `max_amount` DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    `source_id` BIGINT NULL,
+    `type` VARCHAR(255) NOT NULL DEFAULT '',
+    `set_category_id` BIGINT NULL,
+    `add_tags` TEXT,
+    `set_description` TEXT,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`rule_id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`user_id`),
+    FOREIGN KEY (`scope_id`) REFERENCES `scopes`(`scope_id`),
+    FOREIGN KEY (`source_id`) REFERENCES `sources`(`source_id`) ON DELETE SET NULL,
+    FOREIGN KEY (`set_category_id`) REFERENCES `categories`(`category_id`) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rules_scope_priority ON rules(scope_id, priority);
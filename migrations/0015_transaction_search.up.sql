@@ -0,0 +1,12 @@
+-- Text index of transaction descriptions: one row per distinct lower-cased word. Searches look
+-- words up by scope and term, exactly or by prefix; category, source and tag names are matched
+-- when the query runs so that renaming them needs no reindexing.
+CREATE TABLE IF NOT EXISTS `transaction_terms` (
+    `scope_id` BIGINT NOT NULL,
+    `term` VARCHAR(64) NOT NULL,
+    `transaction_id` BIGINT NOT NULL,
+    PRIMARY KEY (`scope_id`, `term`, `transaction_id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`transaction_id`) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_terms_transaction ON transaction_terms(transaction_id);
@@ -0,0 +1,4 @@
+-- Transaction dates stay as they are; only the user time zone is dropped.
+DROP INDEX IF EXISTS idx_transactions_scope_timestamp ON transactions;
+
+ALTER TABLE `users` DROP COLUMN IF EXISTS `timezone`;
@@ -0,0 +1,6 @@
+-- Transactions carry the date they happened in timestamp, which clients may set and edit, and
+-- the time they were recorded in created_at. Date-only filters and reports are read in the
+-- user's time zone, an IANA name.
+ALTER TABLE `users` ADD COLUMN IF NOT EXISTS `timezone` VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_scope_timestamp ON transactions(scope_id, timestamp);
@@ -0,0 +1,3 @@
+ALTER TABLE `users` DROP COLUMN IF EXISTS `currency_display`;
+ALTER TABLE `users` DROP COLUMN IF EXISTS `week_start`;
+ALTER TABLE `users` DROP COLUMN IF EXISTS `locale`;
@@ -0,0 +1,5 @@
+-- Display preferences of each user, next to the time zone added in 0016. Clients format
+-- with the locale and currency display; weeks in reports start on week_start.
+ALTER TABLE `users` ADD COLUMN IF NOT EXISTS `locale` VARCHAR(35) NOT NULL DEFAULT 'en-US';
+ALTER TABLE `users` ADD COLUMN IF NOT EXISTS `week_start` VARCHAR(9) NOT NULL DEFAULT 'MONDAY';
+ALTER TABLE `users` ADD COLUMN IF NOT EXISTS `currency_display` VARCHAR(6) NOT NULL DEFAULT 'SYMBOL';
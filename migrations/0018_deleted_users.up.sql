@@ -0,0 +1,3 @@
+-- A deleted account keeps its users row, renamed and without a password, so records it left
+-- in groups that live on still have an author. Lookups leave out rows with deleted_at set.
+ALTER TABLE `users` ADD COLUMN IF NOT EXISTS `deleted_at` DATETIME NULL DEFAULT NULL;
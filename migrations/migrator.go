@@ -0,0 +1,266 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// lockName is the advisory lock held while migrating, so that several instances
+	// started at once apply each migration only once.
+	lockName           = "xspends.schema_migrations"
+	defaultLockTimeout = time.Minute
+
+	createTableSQL = "CREATE TABLE IF NOT EXISTS schema_migrations (" +
+		"version BIGINT NOT NULL, " +
+		"name VARCHAR(255) NOT NULL, " +
+		"dirty BOOLEAN NOT NULL DEFAULT FALSE, " +
+		"applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+		"PRIMARY KEY (version))"
+)
+
+var (
+	ErrSchemaOutdated = errors.New("the database schema is outdated, run `xspends migrate up`")
+	ErrDirtySchema    = errors.New("a migration failed part way; repair the schema by hand and delete its row from schema_migrations")
+	ErrLocked         = errors.New("another process is migrating the database")
+)
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt *time.Time
+}
+
+// Migrator applies and reverts migrations. Progress is recorded in the schema_migrations
+// table: a row is written as dirty before a migration runs and cleared once it succeeds,
+// because MySQL commits DDL statements immediately and a failed migration cannot be rolled back.
+type Migrator struct {
+	db          *sql.DB
+	migrations  []Migration
+	LockTimeout time.Duration
+}
+
+// NewMigrator returns a Migrator for the migrations embedded in the binary.
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, LockTimeout: defaultLockTimeout}, nil
+}
+
+type appliedMigration struct {
+	dirty     bool
+	appliedAt time.Time
+}
+
+// Up applies every pending migration in version order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := current[migration.Version]; ok {
+				continue
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, TRUE)", migration.Version, migration.Name); err != nil {
+				return errors.Wrapf(err, "recording migration %d failed", migration.Version)
+			}
+			if err := execStatements(ctx, conn, migration.Up); err != nil {
+				return errors.Wrapf(err, "applying migration %d_%s failed", migration.Version, migration.Name)
+			}
+			if _, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET dirty = FALSE WHERE version = ?", migration.Version); err != nil {
+				return errors.Wrapf(err, "recording migration %d failed", migration.Version)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns the ones reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := current[migration.Version]; !ok {
+				continue
+			}
+			if _, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET dirty = TRUE WHERE version = ?", migration.Version); err != nil {
+				return errors.Wrapf(err, "recording migration %d failed", migration.Version)
+			}
+			if err := execStatements(ctx, conn, migration.Down); err != nil {
+				return errors.Wrapf(err, "reverting migration %d_%s failed", migration.Version, migration.Name)
+			}
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version); err != nil {
+				return errors.Wrapf(err, "recording migration %d failed", migration.Version)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration with its state.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening database connection failed")
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, createTableSQL); err != nil {
+		return nil, errors.Wrap(err, "creating schema_migrations failed")
+	}
+	current, err := m.readApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := current[migration.Version]; ok {
+			appliedAt := row.appliedAt
+			status.Applied, status.Dirty, status.AppliedAt = !row.dirty, row.dirty, &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// CheckCurrent returns ErrSchemaOutdated when a migration has not been applied yet and
+// ErrDirtySchema when one failed. It only reads, so it is safe to call while another
+// process migrates.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "opening database connection failed")
+	}
+	defer conn.Close()
+
+	current, err := m.readApplied(ctx, conn)
+	if err != nil {
+		return errors.Wrap(ErrSchemaOutdated, err.Error())
+	}
+	for _, row := range current {
+		if row.dirty {
+			return ErrDirtySchema
+		}
+	}
+	for _, migration := range m.migrations {
+		if _, ok := current[migration.Version]; !ok {
+			return errors.Wrapf(ErrSchemaOutdated, "migration %d_%s is pending", migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration lock.
+// MySQL advisory locks belong to a connection, so everything runs on the same one.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "opening database connection failed")
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(m.LockTimeout.Seconds())).Scan(&locked); err != nil {
+		return errors.Wrap(err, "acquiring migration lock failed")
+	}
+	if locked.Int64 != 1 {
+		return ErrLocked
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+
+	return fn(conn)
+}
+
+// applied creates schema_migrations if needed and refuses to go on from a dirty state.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	if _, err := conn.ExecContext(ctx, createTableSQL); err != nil {
+		return nil, errors.Wrap(err, "creating schema_migrations failed")
+	}
+	current, err := m.readApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for version, row := range current {
+		if row.dirty {
+			return nil, errors.Wrapf(ErrDirtySchema, "version %d", version)
+		}
+	}
+	return current, nil
+}
+
+func (m *Migrator) readApplied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, dirty, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "reading schema_migrations failed")
+	}
+	defer rows.Close()
+
+	current := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.dirty, &row.appliedAt); err != nil {
+			return nil, errors.Wrap(err, "scanning schema_migrations failed")
+		}
+		current[version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading schema_migrations failed")
+	}
+	return current, nil
+}
+
+func execStatements(ctx context.Context, conn *sql.Conn, statements []string) error {
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return errors.Wrapf(err, "executing %.60q", statement)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package migrations
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "initial_schema", Up: []string{"CREATE TABLE a (id BIGINT)"}, Down: []string{"DROP TABLE a"}},
+	{Version: 2, Name: "add_b", Up: []string{"CREATE TABLE b (id BIGINT)", "CREATE INDEX idx_b ON b(id)"}, Down: []string{"DROP TABLE b"}},
+}
+
+var appliedColumns = []string{"version", "dirty", "applied_at"}
+
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &Migrator{db: db, migrations: testMigrations, LockTimeout: time.Second}, sqlMock
+}
+
+func expectLock(sqlMock sqlmock.Sqlmock, result int) {
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+		WithArgs(lockName, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(result))
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "initial_schema", migrations[0].Name)
+	for i := 1; i < len(migrations); i++ {
+		assert.Less(t, migrations[i-1].Version, migrations[i].Version)
+	}
+
+	_, err = loadFrom(fstest.MapFS{"0002_orphan.up.sql": {Data: []byte("SELECT 1;")}})
+	assert.ErrorIs(t, err, ErrInvalidMigration, "a migration without a down file")
+
+	_, err = loadFrom(fstest.MapFS{"add_column.up.sql": {Data: []byte("SELECT 1;")}})
+	assert.ErrorIs(t, err, ErrInvalidMigration, "a file without a version")
+}
+
+func TestSplitStatements(t *testing.T) {
+	content := "-- a comment\nCREATE TABLE a (\n    note VARCHAR(10) DEFAULT ';'\n);\n\nALTER TABLE a ADD COLUMN id BIGINT;\nDROP TABLE c"
+	assert.Equal(t, []string{"CREATE TABLE a (\n    note VARCHAR(10) DEFAULT ';'\n)", "ALTER TABLE a ADD COLUMN id BIGINT", "DROP TABLE c"}, splitStatements(content))
+	assert.Equal(t, []string{"SELECT 1", "SELECT 2"}, splitStatements("SELECT 1;\nSELECT 2;\n"))
+}
+
+func TestUp(t *testing.T) {
+	t.Run("Applies pending migrations in order", func(t *testing.T) {
+		migrator, sqlMock := newTestMigrator(t)
+		expectLock(sqlMock, 1)
+		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery("SELECT version, dirty, applied_at FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows(appliedColumns).AddRow(1, false, time.Now()))
+		sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, TRUE)")).
+			WithArgs(int64(2), "add_b").WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id BIGINT)")).WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec(regexp.QuoteMeta("CREATE INDEX idx_b ON b(id)")).WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE schema_migrations SET dirty = FALSE WHERE version = ?")).
+			WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectExec(regexp.QuoteMeta("SELECT RELEASE_LOCK(?)")).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := migrator.Up(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, applied, 1)
+		assert.Equal(t, int64(2), applied[0].Version)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Lock held by another process", func(t *testing.T) {
+		migrator, sqlMock := newTestMigrator(t)
+		expectLock(sqlMock, 0)
+
+		_, err := migrator.Up(context.Background())
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Refuses to run on a dirty schema", func(t *testing.T) {
+		migrator, sqlMock := newTestMigrator(t)
+		expectLock(sqlMock, 1)
+		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery("SELECT version, dirty, applied_at FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows(appliedColumns).AddRow(1, true, time.Now()))
+		sqlMock.ExpectExec(regexp.QuoteMeta("SELECT RELEASE_LOCK(?)")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := migrator.Up(context.Background())
+		assert.ErrorIs(t, err, ErrDirtySchema)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestUpFromBaseline(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+
+	// The schema added since the original scripts/setup.sql, which databases created from it lack
+	added := []string{
+		"CREATE TABLE IF NOT EXISTS `group_invitations`",
+		"CREATE TABLE IF NOT EXISTS `import_batches`",
+		"CREATE TABLE IF NOT EXISTS `recurring_transactions`",
+		"CREATE TABLE IF NOT EXISTS `recurring_occurrences`",
+		"CREATE TABLE IF NOT EXISTS `budgets`",
+		"CREATE TABLE IF NOT EXISTS `exchange_rates`",
+		"ALTER TABLE `sources` ADD COLUMN IF NOT EXISTS `opening_balance`",
+		"ALTER TABLE `sources` ADD COLUMN IF NOT EXISTS `currency`",
+		"ALTER TABLE `user_groups` ADD COLUMN IF NOT EXISTS `currency`",
+		"ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `currency`",
+		"ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `destination_source_id`",
+		"ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `transfer_id`",
+		"ALTER TABLE `transactions` ADD COLUMN IF NOT EXISTS `import_batch_id`",
+		// Names are unique per scope, not per user, so templates can seed group scopes
+		"CREATE UNIQUE INDEX IF NOT EXISTS uniq_categories_scope_name ON categories(scope_id, name, live)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS uniq_sources_scope_name ON sources(scope_id, name, live)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS uniq_tags_scope_name ON tags(scope_id, name, live)",
+		"DROP INDEX IF EXISTS `name` ON tags",
+	}
+	baseline := strings.Join(migrations[0].Up, "\n")
+	for _, name := range []string{"group_invitations", "import_batches", "recurring_", "budgets", "exchange_rates",
+		"opening_balance", "`currency` VARCHAR(3)", "destination_source_id", "transfer_id", "import_batch_id"} {
+		assert.NotContains(t, baseline, name, "the first migration is the baseline schema only")
+	}
+
+	// A database created from scripts/setup.sql has the baseline tables and no schema_migrations rows
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	migrator := &Migrator{db: db, migrations: migrations, LockTimeout: time.Second}
+
+	sqlMock.ExpectQuery("SELECT GET_LOCK(?, ?)").WithArgs(lockName, 1).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	sqlMock.ExpectExec(createTableSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery("SELECT version, dirty, applied_at FROM schema_migrations").WillReturnRows(sqlmock.NewRows(appliedColumns))
+	var later []string
+	for _, migration := range migrations {
+		sqlMock.ExpectExec("INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, TRUE)").
+			WithArgs(migration.Version, migration.Name).WillReturnResult(sqlmock.NewResult(0, 1))
+		for _, statement := range migration.Up {
+			sqlMock.ExpectExec(statement).WillReturnResult(sqlmock.NewResult(0, 0))
+			if migration.Version > 1 {
+				later = append(later, statement)
+			}
+		}
+		sqlMock.ExpectExec("UPDATE schema_migrations SET dirty = FALSE WHERE version = ?").
+			WithArgs(migration.Version).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	sqlMock.ExpectExec("SELECT RELEASE_LOCK(?)").WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	for _, statement := range added {
+		found := false
+		for _, ran := range later {
+			found = found || strings.HasPrefix(ran, statement)
+		}
+		assert.True(t, found, "a later migration runs %s", statement)
+	}
+}
+
+func TestMigrationsAreIdempotent(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+
+	// Databases created from a version of scripts/setup.sql, or from the first migration before it
+	// was reduced to the baseline, may already have any part of the schema
+	guarded := map[string]string{
+		"CREATE TABLE ":        "CREATE TABLE IF NOT EXISTS ",
+		"CREATE INDEX ":        "CREATE INDEX IF NOT EXISTS ",
+		"CREATE UNIQUE INDEX ": "CREATE UNIQUE INDEX IF NOT EXISTS ",
+		"ADD COLUMN ":          "ADD COLUMN IF NOT EXISTS ",
+		"DROP TABLE ":          "DROP TABLE IF EXISTS ",
+		"DROP INDEX ":          "DROP INDEX IF EXISTS ",
+		"DROP COLUMN ":         "DROP COLUMN IF EXISTS ",
+		"DROP FOREIGN KEY ":    "DROP FOREIGN KEY IF EXISTS ",
+	}
+	for _, migration := range migrations {
+		for _, statement := range append(append([]string{}, migration.Up...), migration.Down...) {
+			for plain, guard := range guarded {
+				assert.Equal(t, strings.Count(statement, plain), strings.Count(statement, guard),
+					"migration %d: %s", migration.Version, statement)
+			}
+			if strings.HasPrefix(statement, "ALTER TABLE") {
+				assert.NotContains(t, statement, "FOREIGN KEY (", "migration %d adds a foreign key without IF NOT EXISTS", migration.Version)
+			}
+		}
+	}
+}
+
+func TestDown(t *testing.T) {
+	migrator, sqlMock := newTestMigrator(t)
+	expectLock(sqlMock, 1)
+	sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery("SELECT version, dirty, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows(appliedColumns).AddRow(1, false, time.Now()).AddRow(2, false, time.Now()))
+	sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE schema_migrations SET dirty = TRUE WHERE version = ?")).
+		WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta("DROP TABLE b")).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = ?")).
+		WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta("SELECT RELEASE_LOCK(?)")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := migrator.Down(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.Equal(t, "add_b", reverted[0].Name)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestStatus(t *testing.T) {
+	migrator, sqlMock := newTestMigrator(t)
+	sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery("SELECT version, dirty, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows(appliedColumns).AddRow(1, false, time.Now()))
+
+	statuses, err := migrator.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Applied)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.False(t, statuses[1].Applied)
+	assert.Nil(t, statuses[1].AppliedAt)
+}
+
+func TestCheckCurrent(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     *sqlmock.Rows
+		expected error
+	}{
+		{"Up to date", sqlmock.NewRows(appliedColumns).AddRow(1, false, time.Now()).AddRow(2, false, time.Now()), nil},
+		{"Pending migration", sqlmock.NewRows(appliedColumns).AddRow(1, false, time.Now()), ErrSchemaOutdated},
+		{"Failed migration", sqlmock.NewRows(appliedColumns).AddRow(1, false, time.Now()).AddRow(2, true, time.Now()), ErrDirtySchema},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			migrator, sqlMock := newTestMigrator(t)
+			sqlMock.ExpectQuery("SELECT version, dirty, applied_at FROM schema_migrations").WillReturnRows(tc.rows)
+
+			err := migrator.CheckCurrent(context.Background())
+			if tc.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expected)
+			}
+		})
+	}
+
+	t.Run("Never migrated", func(t *testing.T) {
+		migrator, sqlMock := newTestMigrator(t)
+		sqlMock.ExpectQuery("SELECT version, dirty, applied_at FROM schema_migrations").
+			WillReturnError(assert.AnError)
+
+		assert.ErrorIs(t, migrator.CheckCurrent(context.Background()), ErrSchemaOutdated)
+	})
+}
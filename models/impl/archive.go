@@ -0,0 +1,224 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+
+	"xspends/models/interfaces"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	ArchiveEntityProfile      = "profile"
+	ArchiveEntityGroups       = "groups"
+	ArchiveEntitySources      = "sources"
+	ArchiveEntityCategories   = "categories"
+	ArchiveEntityTags         = "tags"
+	ArchiveEntityTransactions = "transactions"
+	ArchiveEntityBudgets      = "budgets"
+	ArchiveEntityRecurring    = "recurring_transactions"
+	ArchiveEntityImports      = "import_batches"
+	ArchiveEntityRates        = "exchange_rates"
+)
+
+type ArchiveModel struct{}
+
+func NewArchiveModel() *ArchiveModel {
+	return &ArchiveModel{}
+}
+
+// ExportArchive writes the user's profile and group memberships, then everything stored in the
+// user's personal scope: sources, categories, tags, transactions (streamed, with names resolved),
+// budgets, recurring transactions, import batches and exchange rates. Data of group scopes is shared with the
+// other members and is not included.
+func (am *ArchiveModel) ExportArchive(ctx context.Context, userID int64, scopeID int64, write func(entity string, record interface{}) error, otx ...*sql.Tx) error {
+	scopes := []int64{scopeID}
+
+	profile, err := am.getProfile(ctx, userID, otx...)
+	if err != nil {
+		return err
+	}
+	if err := write(ArchiveEntityProfile, profile); err != nil {
+		return err
+	}
+
+	groups, err := GetModelsService().GroupModel.GetGroupsByUser(ctx, userID, otx...)
+	if err != nil {
+		return errors.Wrap(err, "fetching groups for archive failed")
+	}
+	for _, group := range groups {
+		if err := write(ArchiveEntityGroups, group); err != nil {
+			return err
+		}
+	}
+
+	sources, err := GetModelsService().SourceModel.GetSources(ctx, scopes, otx...)
+	if err != nil {
+		return errors.Wrap(err, "fetching sources for archive failed")
+	}
+	for _, source := range sources {
+		if err := write(ArchiveEntitySources, source); err != nil {
+			return err
+		}
+	}
+
+	if err := am.writeCategories(ctx, scopes, write, otx...); err != nil {
+		return err
+	}
+	if err := am.writeTags(ctx, scopes, write, otx...); err != nil {
+		return err
+	}
+
+	err = GetModelsService().TransactionModel.ExportTransactions(ctx, interfaces.TransactionFilter{Scopes: scopes}, func(txn interfaces.TransactionExport) error {
+		return write(ArchiveEntityTransactions, txn)
+	}, otx...)
+	if err != nil {
+		return err
+	}
+
+	budgets, err := GetModelsService().BudgetModel.GetBudgetsByScope(ctx, scopes, otx...)
+	if err != nil {
+		return errors.Wrap(err, "fetching budgets for archive failed")
+	}
+	for _, budget := range budgets {
+		if err := write(ArchiveEntityBudgets, budget); err != nil {
+			return err
+		}
+	}
+
+	recurring, err := GetModelsService().RecurringTransactionModel.GetRecurringTransactionsByScope(ctx, scopes, otx...)
+	if err != nil {
+		return errors.Wrap(err, "fetching recurring transactions for archive failed")
+	}
+	for _, rt := range recurring {
+		if err := write(ArchiveEntityRecurring, rt); err != nil {
+			return err
+		}
+	}
+
+	batches, err := GetModelsService().ImportBatchModel.GetImportBatchesByScope(ctx, scopes, otx...)
+	if err != nil {
+		return errors.Wrap(err, "fetching import batches for archive failed")
+	}
+	for _, batch := range batches {
+		if err := write(ArchiveEntityImports, batch); err != nil {
+			return err
+		}
+	}
+
+	rates, err := GetModelsService().CurrencyModel.GetExchangeRates(ctx, scopes, "", "", otx...)
+	if err != nil {
+		return errors.Wrap(err, "fetching exchange rates for archive failed")
+	}
+	for _, rate := range rates {
+		if err := write(ArchiveEntityRates, rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getProfile reads the user's account details; the password hash is never exported.
+func (am *ArchiveModel) getProfile(ctx context.Context, userID int64, otx ...*sql.Tx) (*interfaces.User, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select("user_id", "username", "name", "email", "COALESCE(currency, '')", "scope_id", "created_at", "updated_at").
+		From("users").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building profile query failed")
+	}
+
+	user := &interfaces.User{}
+	err = executor.QueryRowContext(ctx, query, args...).
+		Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Currency, &user.Scope, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying profile for archive failed")
+	}
+	return user, nil
+}
+
+func (am *ArchiveModel) writeCategories(ctx context.Context, scopes []int64, write func(entity string, record interface{}) error, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select("category_id", "user_id", "name", "COALESCE(description, '')", "COALESCE(icon, '')", "scope_id", "created_at", "updated_at").
+		From("categories").
+		Where(squirrel.Eq{"scope_id": scopes, "deleted_at": nil}).
+		OrderBy("category_id").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building category archive query failed")
+	}
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "querying categories for archive failed")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category interfaces.Category
+		if err := rows.Scan(&category.ID, &category.UserID, &category.Name, &category.Description, &category.Icon, &category.ScopeID, &category.CreatedAt, &category.UpdatedAt); err != nil {
+			return errors.Wrap(err, "scanning category for archive failed")
+		}
+		if err := write(ArchiveEntityCategories, category); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(rows.Err(), "processing category archive rows failed")
+}
+
+func (am *ArchiveModel) writeTags(ctx context.Context, scopes []int64, write func(entity string, record interface{}) error, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select("tag_id", "user_id", "name", "scope_id", "created_at", "updated_at").
+		From("tags").
+		Where(squirrel.Eq{"scope_id": scopes, "deleted_at": nil}).
+		OrderBy("tag_id").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building tag archive query failed")
+	}
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "querying tags for archive failed")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag interfaces.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.ScopeID, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return errors.Wrap(err, "scanning tag for archive failed")
+		}
+		if err := write(ArchiveEntityTags, tag); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(rows.Err(), "processing tag archive rows failed")
+}
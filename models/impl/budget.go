@@ -0,0 +1,398 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	BudgetPeriodMonthly = "MONTHLY"
+	BudgetPeriodWeekly  = "WEEKLY"
+	BudgetPeriodCustom  = "CUSTOM"
+)
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+	ErrInvalidBudget  = errors.New("invalid budget")
+)
+
+type BudgetModel struct {
+	TableBudgets     string
+	ColumnID         string
+	ColumnUserID     string
+	ColumnScope      string
+	ColumnCategoryID string
+	ColumnAmount     string
+	ColumnPeriod     string
+	ColumnStartDate  string
+	ColumnEndDate    string
+	ColumnRollover   string
+	ColumnCreatedAt  string
+	ColumnUpdatedAt  string
+}
+
+func NewBudgetModel() *BudgetModel {
+	return &BudgetModel{
+		TableBudgets:     "budgets",
+		ColumnID:         "budget_id",
+		ColumnUserID:     "user_id",
+		ColumnScope:      "scope_id",
+		ColumnCategoryID: "category_id",
+		ColumnAmount:     "amount",
+		ColumnPeriod:     "period",
+		ColumnStartDate:  "start_date",
+		ColumnEndDate:    "end_date",
+		ColumnRollover:   "rollover",
+		ColumnCreatedAt:  "created_at",
+		ColumnUpdatedAt:  "updated_at",
+	}
+}
+
+func (bm *BudgetModel) selectColumns() []string {
+	return []string{bm.ColumnID, bm.ColumnUserID, bm.ColumnScope, bm.ColumnCategoryID, bm.ColumnAmount, bm.ColumnPeriod, bm.ColumnStartDate, bm.ColumnEndDate, bm.ColumnRollover, bm.ColumnCreatedAt, bm.ColumnUpdatedAt}
+}
+
+func scanBudget(scanner interface{ Scan(...interface{}) error }, budget *interfaces.Budget) error {
+	var endDate sql.NullTime
+	if err := scanner.Scan(&budget.ID, &budget.UserID, &budget.ScopeID, &budget.CategoryID, &budget.Amount, &budget.Period, &budget.StartDate, &endDate, &budget.Rollover, &budget.CreatedAt, &budget.UpdatedAt); err != nil {
+		return err
+	}
+	if endDate.Valid {
+		budget.EndDate = &endDate.Time
+	}
+	return nil
+}
+
+// InsertBudget validates and stores a new budget for a category of the budget's scope.
+func (bm *BudgetModel) InsertBudget(ctx context.Context, budget *interfaces.Budget, otx ...*sql.Tx) error {
+	if err := bm.validateBudget(ctx, budget, otx...); err != nil {
+		return err
+	}
+	isExternalTx, executor := getExecutor(otx...)
+
+	id, err := util.GenerateSnowflakeID()
+	if err != nil {
+		return errors.Wrap(err, "generating Snowflake ID for budget failed")
+	}
+	budget.ID = id
+	budget.CreatedAt, budget.UpdatedAt = time.Now(), time.Now()
+
+	query, args, err := GetQueryBuilder().Insert(bm.TableBudgets).
+		Columns(bm.selectColumns()...).
+		Values(budget.ID, budget.UserID, budget.ScopeID, budget.CategoryID, budget.Amount, budget.Period, budget.StartDate, budget.EndDate, budget.Rollover, budget.CreatedAt, budget.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building budget insert query failed")
+	}
+
+	_, err = executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "inserting budget failed")
+	}
+
+	commitOrRollback(executor, isExternalTx, err)
+	return nil
+}
+
+// UpdateBudget changes the category, amount, period or rollover setting of a budget.
+func (bm *BudgetModel) UpdateBudget(ctx context.Context, budget *interfaces.Budget, otx ...*sql.Tx) error {
+	if err := bm.validateBudget(ctx, budget, otx...); err != nil {
+		return err
+	}
+	isExternalTx, executor := getExecutor(otx...)
+
+	budget.UpdatedAt = time.Now()
+	query, args, err := GetQueryBuilder().Update(bm.TableBudgets).
+		Set(bm.ColumnCategoryID, budget.CategoryID).
+		Set(bm.ColumnAmount, budget.Amount).
+		Set(bm.ColumnPeriod, budget.Period).
+		Set(bm.ColumnStartDate, budget.StartDate).
+		Set(bm.ColumnEndDate, budget.EndDate).
+		Set(bm.ColumnRollover, budget.Rollover).
+		Set(bm.ColumnUpdatedAt, budget.UpdatedAt).
+		Where(squirrel.Eq{bm.ColumnID: budget.ID, bm.ColumnScope: budget.ScopeID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building budget update query failed")
+	}
+
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "updating budget failed")
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrBudgetNotFound
+	}
+
+	commitOrRollback(executor, isExternalTx, err)
+	return nil
+}
+
+func (bm *BudgetModel) DeleteBudget(ctx context.Context, budgetID int64, scopes []int64, otx ...*sql.Tx) error {
+	isExternalTx, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Delete(bm.TableBudgets).
+		Where(squirrel.Eq{bm.ColumnID: budgetID, bm.ColumnScope: scopes}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building budget delete query failed")
+	}
+
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "deleting budget failed")
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrBudgetNotFound
+	}
+
+	commitOrRollback(executor, isExternalTx, err)
+	return nil
+}
+
+func (bm *BudgetModel) GetBudgetByID(ctx context.Context, budgetID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Budget, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(bm.selectColumns()...).
+		From(bm.TableBudgets).
+		Where(squirrel.Eq{bm.ColumnID: budgetID, bm.ColumnScope: scopes}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building budget select query failed")
+	}
+
+	budget := &interfaces.Budget{}
+	if err := scanBudget(executor.QueryRowContext(ctx, query, args...), budget); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBudgetNotFound
+		}
+		return nil, errors.Wrap(err, "querying budget by ID failed")
+	}
+	return budget, nil
+}
+
+func (bm *BudgetModel) GetBudgetsByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.Budget, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(bm.selectColumns()...).
+		From(bm.TableBudgets).
+		Where(squirrel.Eq{bm.ColumnScope: scopes}).
+		OrderBy(bm.ColumnCategoryID, bm.ColumnStartDate).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building budget list query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying budgets failed")
+	}
+	defer rows.Close()
+
+	budgets := make([]interfaces.Budget, 0)
+	for rows.Next() {
+		var budget interfaces.Budget
+		if err := scanBudget(rows, &budget); err != nil {
+			return nil, errors.Wrap(err, "scanning budget failed")
+		}
+		budgets = append(budgets, budget)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing budget rows failed")
+	}
+	return budgets, nil
+}
+
+// GetBudgetStatus reports spent, remaining and percent used for every budget of the scopes
+// that has a period containing asOf. Periods follow the calendar of location, the user's time
+// zone, and weeks start on weekStart. Spending is the sum of EXPENSE transactions of the
+// budget's category and scope in that period, whoever recorded them. With rollover, the
+// amount left unused in the previous period is added to the current one.
+func (bm *BudgetModel) GetBudgetStatus(ctx context.Context, scopes []int64, asOf time.Time, location *time.Location, weekStart time.Weekday, otx ...*sql.Tx) ([]interfaces.BudgetStatus, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	budgets, err := bm.GetBudgetsByScope(ctx, scopes, otx...)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]interfaces.BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		start, end, ok := budgetPeriod(budget, asOf, location, weekStart)
+		if !ok {
+			continue
+		}
+		status := interfaces.BudgetStatus{
+			BudgetID:    budget.ID,
+			CategoryID:  budget.CategoryID,
+			ScopeID:     budget.ScopeID,
+			Period:      budget.Period,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Amount:      budget.Amount,
+		}
+
+		if budget.Rollover {
+			if prevStart, prevEnd, ok := budgetPeriod(budget, start.AddDate(0, 0, -1), location, weekStart); ok {
+				prevSpent, err := bm.spentInPeriod(ctx, budget, prevStart, prevEnd, otx...)
+				if err != nil {
+					return nil, err
+				}
+				if unused := budget.Amount - prevSpent; unused > 0 {
+					status.Rollover = unused
+				}
+			}
+		}
+
+		status.Spent, err = bm.spentInPeriod(ctx, budget, start, end, otx...)
+		if err != nil {
+			return nil, err
+		}
+		status.Available = status.Amount + status.Rollover
+		status.Remaining = status.Available - status.Spent
+		if status.Available > 0 {
+			status.PercentUsed = math.Round(float64(status.Spent)/float64(status.Available)*10000) / 100
+		} else if status.Spent > 0 {
+			status.PercentUsed = 100
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// spentInPeriod sums the EXPENSE transactions of a budget's category and scope between start (inclusive) and end (exclusive).
+// Split transactions count with the lines in the budget's category.
+func (bm *BudgetModel) spentInPeriod(ctx context.Context, budget interfaces.Budget, start, end time.Time, otx ...*sql.Tx) (interfaces.Money, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select("COALESCE(SUM("+reportLineAmount+"), 0)").
+		From("transactions t").
+		JoinClause(reportSplitLines).
+		Where(squirrel.Eq{reportLineCategory: budget.CategoryID, "t.scope_id": budget.ScopeID}).
+		Where("UPPER(t.type) = ? AND t.deleted_at IS NULL", TransactionTypeExpense).
+		Where(squirrel.GtOrEq{"t.timestamp": start}).
+		Where(squirrel.Lt{"t.timestamp": end}).
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "building budget spending query failed")
+	}
+
+	var spent interfaces.Money
+	if err := executor.QueryRowContext(ctx, query, args...).Scan(&spent); err != nil {
+		return 0, errors.Wrap(err, "querying budget spending failed")
+	}
+	return spent, nil
+}
+
+// validateBudget normalizes period and dates and checks the budget's scope and category.
+// The start date defaults to today.
+func (bm *BudgetModel) validateBudget(ctx context.Context, budget *interfaces.Budget, otx ...*sql.Tx) error {
+	budget.Period = strings.ToUpper(budget.Period)
+	switch budget.Period {
+	case BudgetPeriodMonthly, BudgetPeriodWeekly, BudgetPeriodCustom:
+	default:
+		return errors.Wrap(ErrInvalidBudget, "period must be MONTHLY, WEEKLY or CUSTOM")
+	}
+	if budget.Amount <= 0 {
+		return errors.Wrap(ErrInvalidBudget, "amount must be positive")
+	}
+	if budget.StartDate.IsZero() {
+		budget.StartDate = time.Now()
+	}
+	budget.StartDate = truncateToDate(budget.StartDate)
+	if budget.EndDate != nil {
+		endDate := truncateToDate(*budget.EndDate)
+		budget.EndDate = &endDate
+	}
+	if budget.Period == BudgetPeriodCustom && budget.EndDate == nil {
+		return errors.Wrap(ErrInvalidBudget, "a CUSTOM budget needs an end date")
+	}
+	if budget.EndDate != nil && budget.EndDate.Before(budget.StartDate) {
+		return errors.Wrap(ErrInvalidBudget, "end date must not precede the start date")
+	}
+
+	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, budget.UserID, budget.ScopeID, RoleWrite) {
+		return errors.New("Scope validating failed")
+	}
+	exists, err := GetModelsService().CategoryModel.CategoryIDExists(ctx, budget.CategoryID, []int64{budget.ScopeID}, otx...)
+	if err != nil {
+		return errors.Wrap(err, "error checking if category exists")
+	}
+	if !exists {
+		return errors.Wrap(ErrInvalidBudget, "category does not exist")
+	}
+	return nil
+}
+
+// budgetPeriod returns the [start, end) period of a budget that contains day, from midnight to
+// midnight in location. Monthly periods are calendar months and weekly periods start on
+// weekStart; both are clipped to the budget's start and end dates, which are days of the
+// location's calendar. A CUSTOM budget has a single period. ok is false when the budget is
+// not active on day.
+func budgetPeriod(budget interfaces.Budget, day time.Time, location *time.Location, weekStart time.Weekday) (start, end time.Time, ok bool) {
+	// Periods are worked out on calendar days, held as UTC midnights like the budget's dates
+	local := day.In(location)
+	day = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	budgetStart := truncateToDate(budget.StartDate)
+	var budgetEnd *time.Time
+	if budget.EndDate != nil {
+		e := truncateToDate(*budget.EndDate).AddDate(0, 0, 1)
+		budgetEnd = &e
+	}
+	if day.Before(budgetStart) || (budgetEnd != nil && !day.Before(*budgetEnd)) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	switch budget.Period {
+	case BudgetPeriodCustom:
+		start, end = budgetStart, *budgetEnd
+	case BudgetPeriodWeekly:
+		start = day.AddDate(0, 0, -((int(day.Weekday()) - int(weekStart) + 7) % 7))
+		end = start.AddDate(0, 0, 7)
+	default:
+		start = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 1, 0)
+	}
+	if start.Before(budgetStart) {
+		start = budgetStart
+	}
+	if budgetEnd != nil && end.After(*budgetEnd) {
+		end = *budgetEnd
+	}
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location),
+		time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, location), true
+}
@@ -0,0 +1,124 @@
+package impl
+
+import (
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var budgetColumns = []string{"budget_id", "user_id", "scope_id", "category_id", "amount", "period", "start_date", "end_date", "rollover", "created_at", "updated_at"}
+
+func TestBudgetPeriod(t *testing.T) {
+	monthly := interfaces.Budget{Period: BudgetPeriodMonthly, StartDate: date(2024, 1, 10)}
+	start, end, ok := budgetPeriod(monthly, date(2024, 2, 29), time.UTC, time.Monday)
+	assert.True(t, ok)
+	assert.Equal(t, date(2024, 2, 1), start)
+	assert.Equal(t, date(2024, 3, 1), end)
+
+	start, _, ok = budgetPeriod(monthly, date(2024, 1, 20), time.UTC, time.Monday)
+	assert.True(t, ok)
+	assert.Equal(t, date(2024, 1, 10), start, "first period clipped to the start date")
+
+	_, _, ok = budgetPeriod(monthly, date(2024, 1, 9), time.UTC, time.Monday)
+	assert.False(t, ok, "before the start date")
+
+	weekly := interfaces.Budget{Period: BudgetPeriodWeekly, StartDate: date(2024, 1, 1)}
+	start, end, ok = budgetPeriod(weekly, time.Date(2024, 1, 14, 18, 0, 0, 0, time.UTC), time.UTC, time.Monday) // a Sunday
+	assert.True(t, ok)
+	assert.Equal(t, date(2024, 1, 8), start)
+	assert.Equal(t, date(2024, 1, 15), end)
+
+	start, end, ok = budgetPeriod(weekly, date(2024, 1, 14), time.UTC, time.Sunday)
+	assert.True(t, ok)
+	assert.Equal(t, date(2024, 1, 14), start, "weeks of a user starting on Sunday")
+	assert.Equal(t, date(2024, 1, 21), end)
+
+	// 20:00 UTC on February 29 is already March 1 in Kolkata, whose month starts at 18:30 UTC
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	start, end, ok = budgetPeriod(monthly, time.Date(2024, 2, 29, 20, 0, 0, 0, time.UTC), kolkata, time.Monday)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, kolkata), start)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, kolkata), end)
+	assert.Equal(t, time.Date(2024, 2, 29, 18, 30, 0, 0, time.UTC), start.UTC())
+
+	endDate := date(2024, 3, 20)
+	custom := interfaces.Budget{Period: BudgetPeriodCustom, StartDate: date(2024, 3, 1), EndDate: &endDate}
+	start, end, ok = budgetPeriod(custom, date(2024, 3, 20), time.UTC, time.Monday)
+	assert.True(t, ok)
+	assert.Equal(t, date(2024, 3, 1), start)
+	assert.Equal(t, date(2024, 3, 21), end)
+
+	_, _, ok = budgetPeriod(custom, date(2024, 3, 21), time.UTC, time.Monday)
+	assert.False(t, ok, "after the end date")
+}
+
+func TestGetBudgetStatus(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	ModelsService = &ModelsServiceContainer{
+		DBService:   &DBService{Executor: db},
+		BudgetModel: NewBudgetModel(),
+	}
+
+	sqlMock.ExpectQuery("^SELECT (.+) FROM budgets WHERE scope_id IN \\(\\?\\) ORDER BY category_id, start_date").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows(budgetColumns).
+			AddRow(1, 1, 10, 3, 500.0, BudgetPeriodMonthly, date(2024, 1, 1), nil, true, time.Now(), time.Now()).
+			AddRow(2, 1, 10, 4, 100.0, BudgetPeriodMonthly, date(2024, 3, 1), nil, false, time.Now(), time.Now()))
+
+	spendingQuery := "^SELECT COALESCE\\(SUM\\(COALESCE\\(sp.amount, t.amount\\)\\), 0\\) FROM transactions t LEFT JOIN transaction_splits sp ON sp.transaction_id = t.transaction_id " +
+		"WHERE COALESCE\\(sp.category_id, t.category_id\\) = \\? AND t.scope_id = \\? AND UPPER\\(t.type\\) = \\? AND t.deleted_at IS NULL AND t.timestamp >= \\? AND t.timestamp < \\?"
+	sqlMock.ExpectQuery(spendingQuery).
+		WithArgs(int64(3), int64(10), TransactionTypeExpense, date(2024, 1, 1), date(2024, 2, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"spent"}).AddRow(380.0))
+	sqlMock.ExpectQuery(spendingQuery).
+		WithArgs(int64(3), int64(10), TransactionTypeExpense, date(2024, 2, 1), date(2024, 3, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"spent"}).AddRow(155.0))
+
+	statuses, err := ModelsService.BudgetModel.GetBudgetStatus(ctx, []int64{10}, date(2024, 2, 15), time.UTC, time.Monday)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 1, "the second budget has not started yet")
+	assert.Equal(t, interfaces.MoneyFromFloat(120.0), statuses[0].Rollover)
+	assert.Equal(t, interfaces.MoneyFromFloat(620.0), statuses[0].Available)
+	assert.Equal(t, interfaces.MoneyFromFloat(155.0), statuses[0].Spent)
+	assert.Equal(t, interfaces.MoneyFromFloat(465.0), statuses[0].Remaining)
+	assert.Equal(t, 25.0, statuses[0].PercentUsed)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestGetBudgetStatusInUserTimeZone(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	ModelsService = &ModelsServiceContainer{
+		DBService:   &DBService{Executor: db},
+		BudgetModel: NewBudgetModel(),
+	}
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	sqlMock.ExpectQuery("^SELECT (.+) FROM budgets WHERE scope_id IN \\(\\?\\)").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows(budgetColumns).
+			AddRow(1, 1, 10, 3, 500.0, BudgetPeriodMonthly, date(2024, 1, 1), nil, false, time.Now(), time.Now()))
+	// 02:00 UTC on March 1 is still February 29 in New York: the February period runs from
+	// midnight there, 05:00 UTC, to the next month's
+	sqlMock.ExpectQuery("^SELECT COALESCE\\(SUM").
+		WithArgs(int64(3), int64(10), TransactionTypeExpense, time.Date(2024, 2, 1, 0, 0, 0, 0, newYork), time.Date(2024, 3, 1, 0, 0, 0, 0, newYork)).
+		WillReturnRows(sqlmock.NewRows([]string{"spent"}).AddRow(125.0))
+
+	statuses, err := ModelsService.BudgetModel.GetBudgetStatus(ctx, []int64{10}, time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC), newYork, time.Monday)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, time.Date(2024, 2, 1, 5, 0, 0, 0, time.UTC), statuses[0].PeriodStart.UTC())
+	assert.Equal(t, time.Date(2024, 3, 1, 5, 0, 0, 0, time.UTC), statuses[0].PeriodEnd.UTC())
+	assert.Equal(t, 25.0, statuses[0].PercentUsed)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -38,6 +38,15 @@ import (
 const ErrInvalidInput = "invalid input: user ID must be numeric, name must not be empty or exceed max length, description must not exceed max length"
 const ErrInvalidScope = "Invalid scope presented for the request"
 
+// MaxCategoryDepth is how many levels categories can be nested, counting the top level.
+const MaxCategoryDepth = 5
+
+var (
+	ErrCategoryParentNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("a category cannot be placed under itself or one of its subcategories")
+	ErrCategoryTooDeep        = errors.Errorf("categories can be nested at most %d levels deep", MaxCategoryDepth)
+)
+
 type CategoryModel struct {
 	TableCategories              string
 	ColumnID                     string
@@ -46,8 +55,10 @@ type CategoryModel struct {
 	ColumnDescription            string
 	ColumnIcon                   string
 	ColumnScopeID                string
+	ColumnParentID               string
 	ColumnCreatedAt              string
 	ColumnUpdatedAt              string
+	ColumnDeletedAt              string
 	MaxCategoryNameLength        int
 	MaxCategoryDescriptionLength int
 }
@@ -61,8 +72,10 @@ func NewCategoryModel() *CategoryModel {
 		ColumnDescription:            "description",
 		ColumnIcon:                   "icon",
 		ColumnScopeID:                "scope_id",
+		ColumnParentID:               "parent_id",
 		ColumnCreatedAt:              "created_at",
 		ColumnUpdatedAt:              "updated_at",
+		ColumnDeletedAt:              "deleted_at",
 		MaxCategoryNameLength:        100, // Adjust as per your requirement
 		MaxCategoryDescriptionLength: 512, // Adjust as per your requirement
 	}
@@ -86,6 +99,9 @@ func (cm *CategoryModel) InsertCategory(ctx context.Context, category *interface
 	if err := cm.validateCategoryInput(ctx, category, RoleWrite); err != nil {
 		return err
 	}
+	if err := cm.validateParent(ctx, category, otx...); err != nil {
+		return err
+	}
 
 	var err error
 	category.ID, err = util.GenerateSnowflakeID()
@@ -95,8 +111,8 @@ func (cm *CategoryModel) InsertCategory(ctx context.Context, category *interface
 	category.CreatedAt, category.UpdatedAt = time.Now(), time.Now()
 
 	query, args, err := GetQueryBuilder().Insert(cm.TableCategories).
-		Columns(cm.ColumnID, cm.ColumnUserID, cm.ColumnName, cm.ColumnDescription, cm.ColumnIcon, cm.ColumnScopeID, cm.ColumnCreatedAt, cm.ColumnUpdatedAt).
-		Values(category.ID, category.UserID, category.Name, category.Description, category.Icon, category.ScopeID, category.CreatedAt, category.UpdatedAt).
+		Columns(cm.ColumnID, cm.ColumnUserID, cm.ColumnName, cm.ColumnDescription, cm.ColumnIcon, cm.ColumnScopeID, cm.ColumnParentID, cm.ColumnCreatedAt, cm.ColumnUpdatedAt).
+		Values(category.ID, category.UserID, category.Name, category.Description, category.Icon, category.ScopeID, sql.NullInt64{Int64: category.ParentID, Valid: category.ParentID > 0}, category.CreatedAt, category.UpdatedAt).
 		ToSql()
 	if err != nil {
 		return errors.Wrap(err, "preparing insert statement failed")
@@ -112,13 +128,17 @@ func (cm *CategoryModel) InsertCategory(ctx context.Context, category *interface
 	return nil
 }
 
-// UpdateCategory updates an existing category in the database.
+// UpdateCategory updates an existing category in the database. ParentID moves the category, with
+// its subcategories, under another category; zero makes it a top-level category.
 func (cm *CategoryModel) UpdateCategory(ctx context.Context, category *interfaces.Category, otx ...*sql.Tx) error {
 	isExternalTx, executor := getExecutor(otx...)
 
 	if err := cm.validateCategoryInput(ctx, category, RoleWrite); err != nil {
 		return err
 	}
+	if err := cm.validateParent(ctx, category, otx...); err != nil {
+		return err
+	}
 
 	category.UpdatedAt = time.Now()
 
@@ -126,8 +146,10 @@ func (cm *CategoryModel) UpdateCategory(ctx context.Context, category *interface
 		Set(cm.ColumnName, category.Name).
 		Set(cm.ColumnDescription, category.Description).
 		Set(cm.ColumnIcon, category.Icon).
+		Set(cm.ColumnParentID, sql.NullInt64{Int64: category.ParentID, Valid: category.ParentID > 0}).
 		Set(cm.ColumnUpdatedAt, category.UpdatedAt).
 		Where(squirrel.Eq{cm.ColumnID: category.ID, cm.ColumnScopeID: category.ScopeID}).
+		Where(squirrel.Eq{cm.ColumnDeletedAt: nil}).
 		ToSql()
 	if err != nil {
 		return errors.Wrap(err, "preparing update statement failed")
@@ -142,49 +164,145 @@ func (cm *CategoryModel) UpdateCategory(ctx context.Context, category *interface
 	return nil
 }
 
-// DeleteCategory deletes a category from the database.
-func (cm *CategoryModel) DeleteCategory(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
+// DeleteCategory moves a category to the trash. A category used by transactions, split lines or
+// recurring transactions is only deleted when reassignTo names another category of the scopes:
+// they are all moved to it in the same SQL transaction. Without a target an *InUseError reports
+// how many use it. Subcategories move up to the parent of the deleted category. Deleting a
+// category that does not exist in the given scopes is a no-op.
+func (cm *CategoryModel) DeleteCategory(ctx context.Context, categoryID int64, reassignTo int64, scopes []int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		exists, err := cm.CategoryIDExists(ctx, categoryID, scopes, tx)
+		if err != nil || !exists {
+			return err
+		}
+		usage, err := cm.getCategoryUsage(ctx, categoryID, scopes, tx)
+		if err != nil {
+			return err
+		}
+		if usage.inUse() {
+			if reassignTo == 0 {
+				return usage
+			}
+			if err := cm.reassignCategory(ctx, categoryID, reassignTo, scopes, tx); err != nil {
+				return err
+			}
+		}
+		if err := cm.promoteChildren(ctx, categoryID, tx); err != nil {
+			return err
+		}
 
-	// if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, category.UserID, scopes, RoleWrite) {
-	// 	return errors.New(ErrInvalidScope)
-	// }
-	//TODO: No check if the current user has access to the scope (will happen in handler, but no double check)
-	//We can add validation here as well
-	query, args, err := GetQueryBuilder().Delete(cm.TableCategories).
-		Where(squirrel.Eq{cm.ColumnID: categoryID, cm.ColumnScopeID: scopes}).
-		ToSql()
+		query, args, err := GetQueryBuilder().Update(cm.TableCategories).
+			Set(cm.ColumnDeletedAt, time.Now()).
+			Where(squirrel.Eq{cm.ColumnID: categoryID, cm.ColumnScopeID: scopes}).
+			Where(squirrel.Eq{cm.ColumnDeletedAt: nil}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "preparing delete statement failed")
+		}
+
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "executing delete statement failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// getCategoryUsage counts the transactions, directly or through a split line, the recurring
+// transactions, the rules setting and the budgets tracking a category. Transactions in the trash
+// are not counted.
+func (cm *CategoryModel) getCategoryUsage(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) (*InUseError, error) {
+	_, executor := getExecutor(otx...)
+	usage := &InUseError{Item: "category"}
+
+	var err error
+	usage.Transactions, err = countRows(ctx, executor, GetQueryBuilder().Select("COUNT(DISTINCT t.transaction_id)").
+		From("transactions t").
+		LeftJoin("transaction_splits sp ON sp.transaction_id = t.transaction_id").
+		Where(squirrel.Eq{"t.scope_id": scopes}).
+		Where("t.deleted_at IS NULL").
+		Where(squirrel.Or{squirrel.Eq{"t.category_id": categoryID}, squirrel.Eq{"sp.category_id": categoryID}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "counting category transactions failed")
+	}
+	usage.RecurringTransactions, err = countRows(ctx, executor, GetQueryBuilder().Select("COUNT(*)").
+		From("recurring_transactions").
+		Where(squirrel.Eq{"category_id": categoryID, "scope_id": scopes}))
+	if err != nil {
+		return nil, errors.Wrap(err, "counting category recurring transactions failed")
+	}
+	usage.Rules, err = countRows(ctx, executor, GetQueryBuilder().Select("COUNT(*)").
+		From("rules").
+		Where(squirrel.Eq{"set_category_id": categoryID, "scope_id": scopes}))
+	if err != nil {
+		return nil, errors.Wrap(err, "counting category rules failed")
+	}
+	usage.Budgets, err = countRows(ctx, executor, GetQueryBuilder().Select("COUNT(*)").
+		From("budgets").
+		Where(squirrel.Eq{"category_id": categoryID, "scope_id": scopes}))
 	if err != nil {
-		return errors.Wrap(err, "preparing delete statement failed")
+		return nil, errors.Wrap(err, "counting category budgets failed")
 	}
+	return usage, nil
+}
 
-	_, err = executor.ExecContext(ctx, query, args...)
+// reassignCategory moves the transactions, split lines, recurring transactions, rules and budgets of
+// a category, including transactions in the trash, to another category of the scopes.
+func (cm *CategoryModel) reassignCategory(ctx context.Context, categoryID, reassignTo int64, scopes []int64, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	if reassignTo == categoryID {
+		return ErrInvalidReassignTarget
+	}
+	exists, err := cm.CategoryIDExists(ctx, reassignTo, scopes, otx...)
 	if err != nil {
-		return errors.Wrap(err, "executing delete statement failed")
+		return err
 	}
-	commitOrRollback(executor, isExternalTx, err)
-	return nil
+	if !exists {
+		return errors.Wrapf(ErrInvalidReassignTarget, "category %d not found", reassignTo)
+	}
+
+	if err := reassignColumn(ctx, executor, "transactions", "category_id", categoryID, reassignTo, squirrel.Eq{"scope_id": scopes}); err != nil {
+		return err
+	}
+	// Split lines belong to transactions of the category's scope, which the category ID already implies
+	if err := reassignColumn(ctx, executor, "transaction_splits", "category_id", categoryID, reassignTo, nil); err != nil {
+		return err
+	}
+	inScopes := squirrel.Eq{"scope_id": scopes}
+	if err := reassignColumn(ctx, executor, "recurring_transactions", "category_id", categoryID, reassignTo, inScopes); err != nil {
+		return err
+	}
+	// Rules run before the category of a new transaction is checked, so they must not keep pointing here
+	if err := reassignColumn(ctx, executor, "rules", "set_category_id", categoryID, reassignTo, inScopes); err != nil {
+		return err
+	}
+	return reassignColumn(ctx, executor, "budgets", "category_id", categoryID, reassignTo, inScopes)
 }
 
 func (cm *CategoryModel) GetCategoryByID(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Category, error) {
 	_, executor := getExecutor(otx...)
 
-	query, args, err := sqlBuilder.Select(cm.ColumnID, cm.ColumnUserID, cm.ColumnScopeID, cm.ColumnName, cm.ColumnDescription, cm.ColumnIcon, cm.ColumnScopeID, cm.ColumnCreatedAt, cm.ColumnUpdatedAt).
+	query, args, err := sqlBuilder.Select(cm.ColumnID, cm.ColumnUserID, cm.ColumnScopeID, cm.ColumnName, cm.ColumnDescription, cm.ColumnIcon, cm.ColumnParentID, cm.ColumnCreatedAt, cm.ColumnUpdatedAt).
 		From(cm.TableCategories).
 		Where(squirrel.Eq{cm.ColumnID: categoryID, cm.ColumnScopeID: scopes}).
+		Where(squirrel.Eq{cm.ColumnDeletedAt: nil}).
 		ToSql()
 	if err != nil {
 		return nil, errors.Wrap(err, "preparing select statement for a category by ID failed")
 	}
 
 	var category interfaces.Category
-	err = executor.QueryRowContext(ctx, query, args...).Scan(&category.ID, &category.UserID, &category.ScopeID, &category.Name, &category.Description, &category.Icon, &category.CreatedAt, &category.UpdatedAt)
+	var parentID sql.NullInt64
+	err = executor.QueryRowContext(ctx, query, args...).Scan(&category.ID, &category.UserID, &category.ScopeID, &category.Name, &category.Description, &category.Icon, &parentID, &category.CreatedAt, &category.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("category not found")
 		}
 		return nil, errors.Wrap(err, "querying category by ID failed")
 	}
+	category.ParentID = parentID.Int64
 
 	return &category, nil
 }
@@ -197,6 +315,7 @@ func (cm *CategoryModel) GetScopedCategories(ctx context.Context, page int, item
 	query, args, err := sqlBuilder.Select(cm.ColumnID, cm.ColumnUserID, cm.ColumnName, cm.ColumnDescription, cm.ColumnIcon, cm.ColumnScopeID, cm.ColumnCreatedAt, cm.ColumnUpdatedAt).
 		From(cm.TableCategories).
 		Where(squirrel.Eq{cm.ColumnUserID: scopes}).
+		Where(squirrel.Eq{cm.ColumnDeletedAt: nil}).
 		Limit(uint64(itemsPerPage)).
 		Offset(uint64(offset)).
 		ToSql()
@@ -222,11 +341,51 @@ func (cm *CategoryModel) GetScopedCategories(ctx context.Context, page int, item
 	return categories, nil
 }
 
+// GetCategoriesByCursor retrieves a page of the categories in scopes, oldest first.
+func (cm *CategoryModel) GetCategoriesByCursor(ctx context.Context, scopes []int64, params interfaces.CursorParams, otx ...*sql.Tx) ([]interfaces.Category, interfaces.CursorPage, error) {
+	_, executor := getExecutor(otx...)
+
+	selection := GetQueryBuilder().Select(cm.ColumnID, cm.ColumnUserID, cm.ColumnScopeID, cm.ColumnName, cm.ColumnDescription, cm.ColumnIcon, cm.ColumnParentID, cm.ColumnCreatedAt, cm.ColumnUpdatedAt).
+		From(cm.TableCategories).
+		Where(squirrel.Eq{cm.ColumnScopeID: scopes}).
+		Where(squirrel.Eq{cm.ColumnDeletedAt: nil})
+	query, args, err := applyCursor(selection, cm.ColumnCreatedAt, cm.ColumnID, false, params).ToSql()
+	if err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "preparing select statement for a page of categories failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "querying a page of categories failed")
+	}
+	defer rows.Close()
+
+	categories := make([]interfaces.Category, 0)
+	for rows.Next() {
+		var category interfaces.Category
+		var parentID sql.NullInt64
+		if err := rows.Scan(&category.ID, &category.UserID, &category.ScopeID, &category.Name, &category.Description, &category.Icon, &parentID, &category.CreatedAt, &category.UpdatedAt); err != nil {
+			return nil, interfaces.CursorPage{}, errors.Wrap(err, "scanning category row failed")
+		}
+		category.ParentID = parentID.Int64
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "processing category rows failed")
+	}
+
+	categories, page := pageByCursor(categories, func(category interfaces.Category) interfaces.Cursor {
+		return interfaces.Cursor{Time: category.CreatedAt, ID: category.ID}
+	}, params)
+	return categories, page, nil
+}
+
 func (cm *CategoryModel) CategoryIDExists(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) (bool, error) {
 	_, executor := getExecutor(otx...)
 	query, args, err := sqlBuilder.Select("1").
 		From(cm.TableCategories).
 		Where(squirrel.Eq{cm.ColumnID: categoryID, cm.ColumnScopeID: scopes}).
+		Where(squirrel.Eq{cm.ColumnDeletedAt: nil}).
 		Limit(1).
 		ToSql()
 	if err != nil {
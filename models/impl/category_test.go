@@ -71,12 +71,43 @@ func TestDeleteCategoryWithDatabaseError(t *testing.T) {
 	})
 	defer tearDown()
 
-	mockExecutor.EXPECT().ExecContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
-
-	err := ModelsService.CategoryModel.DeleteCategory(ctx, 1, []int64{1})
+	_, mock := setupNewMock(t)
+	mock.ExpectBegin()
+	expectCategoryUsage(mock, 1, 0, 0, 0, 0)
+	expectPromoteChildren(mock)
+	mock.ExpectExec("^UPDATE categories SET deleted_at").WillReturnError(errors.New("database error"))
+	mock.ExpectRollback()
+
+	err := ModelsService.CategoryModel.DeleteCategory(ctx, 1, 0, []int64{1})
 	assert.EqualError(t, err, "executing delete statement failed: database error")
 }
 
+// expectCategoryUsage expects the existence check and usage counts of category 1 in scope 1.
+func expectCategoryUsage(mock sqlmock.Sqlmock, exists, transactions, recurring, rules, budgets int) {
+	mock.ExpectQuery("^SELECT 1 FROM categories WHERE category_id = \\? AND scope_id IN \\(\\?\\) AND deleted_at IS NULL").
+		WithArgs(int64(1), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(exists))
+	mock.ExpectQuery("^SELECT COUNT\\(DISTINCT t.transaction_id\\) FROM transactions t LEFT JOIN transaction_splits sp (.+) WHERE t.scope_id IN \\(\\?\\) AND t.deleted_at IS NULL AND \\(t.category_id = \\? OR sp.category_id = \\?\\)").
+		WithArgs(int64(1), int64(1), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(transactions))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM recurring_transactions WHERE category_id = \\? AND scope_id IN \\(\\?\\)").
+		WithArgs(int64(1), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(recurring))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM rules WHERE scope_id IN \\(\\?\\) AND set_category_id = \\?").
+		WithArgs(int64(1), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(rules))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM budgets WHERE category_id = \\? AND scope_id IN \\(\\?\\)").
+		WithArgs(int64(1), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(budgets))
+}
+
+// expectPromoteChildren expects the subcategories of category 1 to move up to its parent.
+func expectPromoteChildren(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("^UPDATE categories c JOIN categories p ON p.category_id = c.parent_id SET c.parent_id = p.parent_id WHERE p.category_id = \\?").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
 // TestGetAllCategoriesWithDatabaseError verifies that the function returns an error for database errors
 
 // TestGetCategoryByIDWithCategoryNotFound verifies that the function returns an error for a non-existent category.
@@ -195,8 +226,8 @@ func TestGetCategoryByIDWithEmptyIcon(t *testing.T) {
 		UpdatedAt:   time.Now(),
 	}
 
-	rows := sqlmock.NewRows([]string{"category_id", "user_id", "scope_id", "name", "description", "icon", "created_at", "updated_at"}).
-		AddRow(expectedCategory.ID, expectedCategory.UserID, expectedCategory.ScopeID, expectedCategory.Name, expectedCategory.Description, "", expectedCategory.CreatedAt, expectedCategory.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"category_id", "user_id", "scope_id", "name", "description", "icon", "parent_id", "created_at", "updated_at"}).
+		AddRow(expectedCategory.ID, expectedCategory.UserID, expectedCategory.ScopeID, expectedCategory.Name, expectedCategory.Description, "", nil, expectedCategory.CreatedAt, expectedCategory.UpdatedAt)
 
 	mock.ExpectQuery("^SELECT (.+) FROM categories WHERE").WillReturnRows(rows)
 
@@ -266,23 +297,98 @@ func TestDeleteCategoryWithDatabase(t *testing.T) {
 	})
 	defer tearDown()
 
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer db.Close()
-
-	ModelsService.DBService.Executor = db
-
 	categoryID := int64(1)
 	scopeID := int64(1)
 
-	mock.ExpectExec("^DELETE FROM categories WHERE").
-		WithArgs(categoryID, scopeID).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	t.Run("Unused category", func(t *testing.T) {
+		_, mock := setupNewMock(t)
+		mock.ExpectBegin()
+		expectCategoryUsage(mock, 1, 0, 0, 0, 0)
+		expectPromoteChildren(mock)
+		mock.ExpectExec("^UPDATE categories SET deleted_at = \\? WHERE category_id = \\? AND scope_id IN \\(\\?\\) AND deleted_at IS NULL").
+			WithArgs(sqlmock.AnyArg(), categoryID, scopeID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := ModelsService.CategoryModel.DeleteCategory(ctx, categoryID, 0, []int64{scopeID})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 
-	err = ModelsService.CategoryModel.DeleteCategory(ctx, categoryID, []int64{scopeID})
-	assert.NoError(t, err)
+	t.Run("In use without a target", func(t *testing.T) {
+		_, mock := setupNewMock(t)
+		mock.ExpectBegin()
+		expectCategoryUsage(mock, 1, 12, 1, 0, 0)
+		mock.ExpectRollback()
+
+		err := ModelsService.CategoryModel.DeleteCategory(ctx, categoryID, 0, []int64{scopeID})
+		var inUse *InUseError
+		assert.True(t, errors.As(err, &inUse))
+		assert.Equal(t, int64(12), inUse.Transactions)
+		assert.Equal(t, int64(1), inUse.RecurringTransactions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Used only by rules and budgets", func(t *testing.T) {
+		_, mock := setupNewMock(t)
+		mock.ExpectBegin()
+		expectCategoryUsage(mock, 1, 0, 0, 2, 1)
+		mock.ExpectRollback()
+
+		err := ModelsService.CategoryModel.DeleteCategory(ctx, categoryID, 0, []int64{scopeID})
+		var inUse *InUseError
+		assert.True(t, errors.As(err, &inUse))
+		assert.Equal(t, int64(2), inUse.Rules)
+		assert.Equal(t, int64(1), inUse.Budgets)
+		assert.EqualError(t, err, "category is used by 0 transactions, 0 recurring transactions, 2 rules and 1 budgets; pass reassign_to to move them")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reassigned to another category", func(t *testing.T) {
+		_, mock := setupNewMock(t)
+		mock.ExpectBegin()
+		expectCategoryUsage(mock, 1, 12, 0, 2, 1)
+		mock.ExpectQuery("^SELECT 1 FROM categories WHERE category_id = \\?").
+			WithArgs(int64(2), scopeID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectExec("^UPDATE transactions SET category_id = \\? WHERE category_id = \\? AND scope_id IN \\(\\?\\)").
+			WithArgs(int64(2), categoryID, scopeID).
+			WillReturnResult(sqlmock.NewResult(0, 12))
+		mock.ExpectExec("^UPDATE transaction_splits SET category_id = \\? WHERE category_id = \\?$").
+			WithArgs(int64(2), categoryID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("^UPDATE recurring_transactions SET category_id = \\? WHERE category_id = \\? AND scope_id IN \\(\\?\\)").
+			WithArgs(int64(2), categoryID, scopeID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("^UPDATE rules SET set_category_id = \\? WHERE set_category_id = \\? AND scope_id IN \\(\\?\\)").
+			WithArgs(int64(2), categoryID, scopeID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("^UPDATE budgets SET category_id = \\? WHERE category_id = \\? AND scope_id IN \\(\\?\\)").
+			WithArgs(int64(2), categoryID, scopeID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectPromoteChildren(mock)
+		mock.ExpectExec("^UPDATE categories SET deleted_at = \\?").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := ModelsService.CategoryModel.DeleteCategory(ctx, categoryID, 2, []int64{scopeID})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Target not found", func(t *testing.T) {
+		_, mock := setupNewMock(t)
+		mock.ExpectBegin()
+		expectCategoryUsage(mock, 1, 3, 0, 0, 0)
+		mock.ExpectQuery("^SELECT 1 FROM categories WHERE category_id = \\?").
+			WithArgs(int64(2), scopeID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}))
+		mock.ExpectRollback()
+
+		err := ModelsService.CategoryModel.DeleteCategory(ctx, categoryID, 2, []int64{scopeID})
+		assert.Equal(t, ErrInvalidReassignTarget, errors.Cause(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 // TestGetCategoryByIDWithDatabase tests retrieval of a category by ID using a mock database.
@@ -314,8 +420,8 @@ func TestGetCategoryByIDWithDatabase(t *testing.T) {
 		UpdatedAt:   time.Now(),
 	}
 
-	rows := sqlmock.NewRows([]string{"scope_id", "user_id", "scope_id", "name", "description", "icon", "created_at", "updated_at"}).
-		AddRow(expectedCategory.ID, expectedCategory.UserID, expectedCategory.ScopeID, expectedCategory.Name, expectedCategory.Description, expectedCategory.Icon, expectedCategory.CreatedAt, expectedCategory.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"category_id", "user_id", "scope_id", "name", "description", "icon", "parent_id", "created_at", "updated_at"}).
+		AddRow(expectedCategory.ID, expectedCategory.UserID, expectedCategory.ScopeID, expectedCategory.Name, expectedCategory.Description, expectedCategory.Icon, nil, expectedCategory.CreatedAt, expectedCategory.UpdatedAt)
 
 	mock.ExpectQuery("^SELECT (.+) FROM categories WHERE").
 		WithArgs(categoryID, scopeID).
@@ -398,7 +504,7 @@ func TestUpdateCategoryWithValidInput(t *testing.T) {
 
 	category := &interfaces.Category{ID: 1, UserID: 1, ScopeID: 1, Name: "Updated Category", Description: "Updated Description"}
 
-	mock.ExpectExec("^UPDATE categories SET").WithArgs(category.Name, category.Description, category.Icon, sqlmock.AnyArg(), category.ID, category.UserID).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("^UPDATE categories SET").WithArgs(category.Name, category.Description, category.Icon, nil, sqlmock.AnyArg(), category.ID, category.UserID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = ModelsService.CategoryModel.UpdateCategory(ctx, category)
 	assert.NoError(t, err)
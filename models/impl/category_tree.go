@@ -0,0 +1,282 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"xspends/models/interfaces"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// categoryLink is the place of a category in its tree.
+type categoryLink struct {
+	parentID int64
+	name     string
+}
+
+// getCategoryLinks loads the parent and name of every category of the scopes that is not in the trash.
+func (cm *CategoryModel) getCategoryLinks(ctx context.Context, scopes []int64, otx ...*sql.Tx) (map[int64]categoryLink, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(cm.ColumnID, cm.ColumnParentID, cm.ColumnName).
+		From(cm.TableCategories).
+		Where(squirrel.Eq{cm.ColumnScopeID: scopes}).
+		Where(squirrel.Eq{cm.ColumnDeletedAt: nil}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "preparing select statement for category parents failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying category parents failed")
+	}
+	defer rows.Close()
+
+	links := make(map[int64]categoryLink)
+	for rows.Next() {
+		var id int64
+		var parentID sql.NullInt64
+		var link categoryLink
+		if err := rows.Scan(&id, &parentID, &link.name); err != nil {
+			return nil, errors.Wrap(err, "scanning category parent row failed")
+		}
+		link.parentID = parentID.Int64
+		links[id] = link
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing category parent rows failed")
+	}
+	return links, nil
+}
+
+// ancestry returns a category followed by its parent, grandparent and so on up to its top-level category.
+func ancestry(links map[int64]categoryLink, categoryID int64) []int64 {
+	chain := []int64{categoryID}
+	for len(chain) <= len(links) {
+		link, ok := links[chain[len(chain)-1]]
+		if !ok || link.parentID == 0 {
+			break
+		}
+		chain = append(chain, link.parentID)
+	}
+	return chain
+}
+
+// subtreeHeight counts the levels of a category and its subcategories, a category without any being one.
+func subtreeHeight(children map[int64][]int64, categoryID int64, limit int) int {
+	height := 1
+	if limit <= 1 {
+		return height
+	}
+	for _, child := range children[categoryID] {
+		if h := 1 + subtreeHeight(children, child, limit-1); h > height {
+			height = h
+		}
+	}
+	return height
+}
+
+// validateParent checks that the parent of a category exists in its scope, is not the category or one
+// of its subcategories, and that the category with its subcategories fits under it within MaxCategoryDepth.
+func (cm *CategoryModel) validateParent(ctx context.Context, category *interfaces.Category, otx ...*sql.Tx) error {
+	if category.ParentID == 0 {
+		return nil
+	}
+	if category.ParentID == category.ID {
+		return ErrCategoryCycle
+	}
+
+	links, err := cm.getCategoryLinks(ctx, []int64{category.ScopeID}, otx...)
+	if err != nil {
+		return err
+	}
+	if _, ok := links[category.ParentID]; !ok {
+		return ErrCategoryParentNotFound
+	}
+	ancestors := ancestry(links, category.ParentID)
+	for _, id := range ancestors {
+		if id == category.ID {
+			return ErrCategoryCycle
+		}
+	}
+
+	height := 1
+	if category.ID != 0 {
+		children := make(map[int64][]int64)
+		for id, link := range links {
+			children[link.parentID] = append(children[link.parentID], id)
+		}
+		height = subtreeHeight(children, category.ID, MaxCategoryDepth+1)
+	}
+	if len(ancestors)+height > MaxCategoryDepth {
+		return ErrCategoryTooDeep
+	}
+	return nil
+}
+
+// promoteChildren moves the subcategories of a category up to its parent.
+func (cm *CategoryModel) promoteChildren(ctx context.Context, categoryID int64, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().
+		Update(cm.TableCategories+" c JOIN "+cm.TableCategories+" p ON p."+cm.ColumnID+" = c."+cm.ColumnParentID).
+		Set("c."+cm.ColumnParentID, squirrel.Expr("p."+cm.ColumnParentID)).
+		Where(squirrel.Eq{"p." + cm.ColumnID: categoryID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "preparing statement to move subcategories failed")
+	}
+
+	if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "moving subcategories failed")
+	}
+	return nil
+}
+
+// subtreeQuery selects the IDs of a category and of all its subcategories. The tree is walked with
+// one self join per level, which MaxCategoryDepth bounds.
+func (cm *CategoryModel) subtreeQuery(categoryID interface{}) squirrel.SelectBuilder {
+	query := GetQueryBuilder().Select("c1." + cm.ColumnID).From(cm.TableCategories + " c1")
+	ancestors := []string{"c1." + cm.ColumnID}
+	for level := 2; level <= MaxCategoryDepth; level++ {
+		alias := fmt.Sprintf("c%d", level)
+		query = query.LeftJoin(fmt.Sprintf("%s %s ON %s.%s = c%d.%s", cm.TableCategories, alias, alias, cm.ColumnID, level-1, cm.ColumnParentID))
+		ancestors = append(ancestors, alias+"."+cm.ColumnID)
+	}
+	return query.Where("? IN ("+strings.Join(ancestors, ", ")+")", categoryID)
+}
+
+// categoryCondition matches column against the category of filter, and against its subcategories
+// when the filter includes descendants.
+func categoryCondition(column string, filter interfaces.TransactionFilter) squirrel.Sqlizer {
+	if filter.IncludeDescendants {
+		return squirrel.Expr(column+" IN (?)", NewCategoryModel().subtreeQuery(filter.Category))
+	}
+	return squirrel.Eq{column: filter.Category}
+}
+
+// GetCategoryTree lists the categories of the scopes as trees, top-level categories first and
+// subcategories under their parent, each level sorted by name.
+func (cm *CategoryModel) GetCategoryTree(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.CategoryNode, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(cm.ColumnID, cm.ColumnUserID, cm.ColumnScopeID, cm.ColumnName, cm.ColumnDescription, cm.ColumnIcon, cm.ColumnParentID, cm.ColumnCreatedAt, cm.ColumnUpdatedAt).
+		From(cm.TableCategories).
+		Where(squirrel.Eq{cm.ColumnScopeID: scopes}).
+		Where(squirrel.Eq{cm.ColumnDeletedAt: nil}).
+		OrderBy(cm.ColumnName, cm.ColumnID).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "preparing select statement for the category tree failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying the category tree failed")
+	}
+	defer rows.Close()
+
+	var categories []interfaces.Category
+	known := make(map[int64]bool)
+	for rows.Next() {
+		var category interfaces.Category
+		var parentID sql.NullInt64
+		if err := rows.Scan(&category.ID, &category.UserID, &category.ScopeID, &category.Name, &category.Description, &category.Icon, &parentID, &category.CreatedAt, &category.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, "scanning category tree row failed")
+		}
+		category.ParentID = parentID.Int64
+		categories = append(categories, category)
+		known[category.ID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing category tree rows failed")
+	}
+
+	// Categories whose parent is not listed are shown at the top level
+	children := make(map[int64][]interfaces.Category)
+	for _, category := range categories {
+		parentID := category.ParentID
+		if !known[parentID] {
+			parentID = 0
+		}
+		children[parentID] = append(children[parentID], category)
+	}
+	return buildCategoryNodes(children, 0), nil
+}
+
+func buildCategoryNodes(children map[int64][]interfaces.Category, parentID int64) []interfaces.CategoryNode {
+	nodes := make([]interfaces.CategoryNode, 0, len(children[parentID]))
+	for _, category := range children[parentID] {
+		nodes = append(nodes, interfaces.CategoryNode{Category: category, Children: buildCategoryNodes(children, category.ID)})
+	}
+	return nodes
+}
+
+// RollUpReport aggregates a report grouped by category at a level of the category tree, 1 being the
+// top level: the rows of deeper categories are added to their ancestor at that level. Rows of
+// categories at or above the level, and uncategorized rows, are kept as they are. The rows keep the
+// order in which their category first appears.
+func (cm *CategoryModel) RollUpReport(ctx context.Context, report []interfaces.ReportRow, level int, scopes []int64, otx ...*sql.Tx) ([]interfaces.ReportRow, error) {
+	if level <= 0 {
+		return report, nil
+	}
+	links, err := cm.getCategoryLinks(ctx, scopes, otx...)
+	if err != nil {
+		return nil, err
+	}
+
+	rolledUp := make([]interfaces.ReportRow, 0, len(report))
+	positions := make(map[string]int)
+	for _, row := range report {
+		if categoryID, err := strconv.ParseInt(row.Key, 10, 64); err == nil {
+			if ancestors := ancestry(links, categoryID); len(ancestors) > level {
+				ancestor := ancestors[len(ancestors)-level]
+				row.Key = strconv.FormatInt(ancestor, 10)
+				row.Name = links[ancestor].name
+			}
+		}
+
+		position, ok := positions[row.Key]
+		if !ok {
+			positions[row.Key] = len(rolledUp)
+			rolledUp = append(rolledUp, row)
+			continue
+		}
+		total := &rolledUp[position]
+		total.Income += row.Income
+		total.Expense += row.Expense
+		total.Net += row.Net
+		total.Count += row.Count
+		total.Unconverted += row.Unconverted
+	}
+	return rolledUp, nil
+}
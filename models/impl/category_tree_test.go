@@ -0,0 +1,158 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var categoryLinkColumns = []string{"category_id", "parent_id", "name"}
+
+// expectCategoryLinks expects the tree of scope 1: Food (1) > Groceries (2) > Fruit (3), and Travel (4).
+func expectCategoryLinks(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("^SELECT category_id, parent_id, name FROM categories WHERE scope_id IN \\(\\?\\) AND deleted_at IS NULL").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(categoryLinkColumns).
+			AddRow(1, nil, "Food").
+			AddRow(2, 1, "Groceries").
+			AddRow(3, 2, "Fruit").
+			AddRow(4, nil, "Travel"))
+}
+
+func TestValidateParent(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.CategoryModel = NewCategoryModel()
+	})
+	defer tearDown()
+	cm := NewCategoryModel()
+
+	tests := []struct {
+		name     string
+		category interfaces.Category
+		expected error
+	}{
+		{"New subcategory", interfaces.Category{ScopeID: 1, ParentID: 3}, nil},
+		{"Moved under another tree", interfaces.Category{ID: 1, ScopeID: 1, ParentID: 4}, nil},
+		{"Parent not found", interfaces.Category{ScopeID: 1, ParentID: 9}, ErrCategoryParentNotFound},
+		{"Under one of its subcategories", interfaces.Category{ID: 1, ScopeID: 1, ParentID: 3}, ErrCategoryCycle},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, mock := setupNewMock(t)
+			expectCategoryLinks(mock)
+
+			assert.Equal(t, tc.expected, cm.validateParent(context.Background(), &tc.category))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("Under itself", func(t *testing.T) {
+		err := cm.validateParent(context.Background(), &interfaces.Category{ID: 2, ScopeID: 1, ParentID: 2})
+		assert.Equal(t, ErrCategoryCycle, err)
+	})
+
+	t.Run("Too deep", func(t *testing.T) {
+		_, mock := setupNewMock(t)
+		for i := 0; i < 2; i++ {
+			rows := sqlmock.NewRows(categoryLinkColumns).AddRow(1, nil, "Level 1")
+			for id := 2; id <= MaxCategoryDepth; id++ {
+				rows.AddRow(id, id-1, "Level")
+			}
+			rows.AddRow(10, nil, "Travel").AddRow(11, 10, "Flights")
+			mock.ExpectQuery("^SELECT category_id, parent_id, name FROM categories").WillReturnRows(rows)
+		}
+
+		err := cm.validateParent(context.Background(), &interfaces.Category{ScopeID: 1, ParentID: MaxCategoryDepth})
+		assert.Equal(t, ErrCategoryTooDeep, err)
+		err = cm.validateParent(context.Background(), &interfaces.Category{ID: 10, ScopeID: 1, ParentID: MaxCategoryDepth - 1})
+		assert.Equal(t, ErrCategoryTooDeep, err, "the subcategories of a moved category count too")
+	})
+}
+
+func TestGetCategoryTree(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.CategoryModel = NewCategoryModel()
+	})
+	defer tearDown()
+	_, mock := setupNewMock(t)
+
+	now := time.Now()
+	mock.ExpectQuery("^SELECT category_id, user_id, scope_id, name, description, icon, parent_id, created_at, updated_at FROM categories WHERE scope_id IN \\(\\?\\) AND deleted_at IS NULL ORDER BY name, category_id").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "user_id", "scope_id", "name", "description", "icon", "parent_id", "created_at", "updated_at"}).
+			AddRow(1, 1, 1, "Food", "", "", nil, now, now).
+			AddRow(2, 1, 1, "Groceries", "", "", 1, now, now).
+			AddRow(5, 1, 1, "Orphan", "", "", 99, now, now).
+			AddRow(3, 1, 1, "Restaurants", "", "", 1, now, now))
+
+	tree, err := ModelsService.CategoryModel.GetCategoryTree(context.Background(), []int64{1})
+	assert.NoError(t, err)
+	assert.Len(t, tree, 2)
+	assert.Equal(t, "Food", tree[0].Name)
+	assert.Len(t, tree[0].Children, 2)
+	assert.Equal(t, "Groceries", tree[0].Children[0].Name)
+	assert.Equal(t, int64(1), tree[0].Children[0].ParentID)
+	assert.Equal(t, "Restaurants", tree[0].Children[1].Name)
+	assert.Empty(t, tree[0].Children[1].Children)
+	assert.Equal(t, "Orphan", tree[1].Name, "a category whose parent is not listed is shown at the top")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRollUpReport(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.CategoryModel = NewCategoryModel()
+	})
+	defer tearDown()
+
+	report := []interfaces.ReportRow{
+		{Key: "1", Name: "Food", Currency: "USD", Expense: 500, Net: -500, Count: 1},
+		{Key: "2", Name: "Groceries", Currency: "USD", Expense: 1200, Net: -1200, Count: 3},
+		{Key: "3", Name: "Fruit", Currency: "USD", Expense: 300, Net: -300, Count: 2, Unconverted: 1},
+		{Key: "4", Name: "Travel", Currency: "USD", Expense: 9000, Net: -9000, Count: 1},
+	}
+
+	t.Run("Top level", func(t *testing.T) {
+		_, mock := setupNewMock(t)
+		expectCategoryLinks(mock)
+
+		rolledUp, err := ModelsService.CategoryModel.RollUpReport(context.Background(), report, 1, []int64{1})
+		assert.NoError(t, err)
+		assert.Equal(t, []interfaces.ReportRow{
+			{Key: "1", Name: "Food", Currency: "USD", Expense: 2000, Net: -2000, Count: 6, Unconverted: 1},
+			{Key: "4", Name: "Travel", Currency: "USD", Expense: 9000, Net: -9000, Count: 1},
+		}, rolledUp)
+	})
+
+	t.Run("Second level", func(t *testing.T) {
+		_, mock := setupNewMock(t)
+		expectCategoryLinks(mock)
+
+		rolledUp, err := ModelsService.CategoryModel.RollUpReport(context.Background(), report, 2, []int64{1})
+		assert.NoError(t, err)
+		assert.Equal(t, []interfaces.ReportRow{
+			report[0],
+			{Key: "2", Name: "Groceries", Currency: "USD", Expense: 1500, Net: -1500, Count: 5, Unconverted: 1},
+			report[3],
+		}, rolledUp)
+	})
+}
+
+func TestCategoryConditionWithDescendants(t *testing.T) {
+	filter := interfaces.TransactionFilter{Category: "7", IncludeDescendants: true}
+	query, args, err := categoryCondition("t.category_id", filter).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "t.category_id IN (SELECT c1.category_id FROM categories c1 "+
+		"LEFT JOIN categories c2 ON c2.category_id = c1.parent_id LEFT JOIN categories c3 ON c3.category_id = c2.parent_id "+
+		"LEFT JOIN categories c4 ON c4.category_id = c3.parent_id LEFT JOIN categories c5 ON c5.category_id = c4.parent_id "+
+		"WHERE ? IN (c1.category_id, c2.category_id, c3.category_id, c4.category_id, c5.category_id))", query)
+	assert.Equal(t, []interface{}{"7"}, args)
+
+	filter.IncludeDescendants = false
+	query, _, err = categoryCondition("t.category_id", filter).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "t.category_id = ?", query)
+}
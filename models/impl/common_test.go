@@ -31,16 +31,28 @@ func setUp(t *testing.T, modifyConfig func(*ModelsConfig)) func() {
 	// Create mocks for each service
 	mockExecutor = mock.NewMockDBExecutor(ctrl)
 	mockConfig := &ModelsConfig{
-		DBService:           &DBService{Executor: mockExecutor},
-		CategoryModel:       new(mock.MockCategoryModel),
-		SourceModel:         new(mock.MockSourceModel),
-		UserModel:           new(mock.MockUserModel),
-		TagModel:            new(mock.MockTagModel),
-		ScopeModel:          new(mock.MockScopeModel),
-		UserScopeModel:      new(mock.MockUserScopeModel),
-		GroupModel:          new(mock.MockGroupModel),
-		TransactionTagModel: new(mock.MockTransactionTagModel),
-		TransactionModel:    new(mock.MockTransactionModel),
+		DBService:                 &DBService{Executor: mockExecutor},
+		CategoryModel:             new(mock.MockCategoryModel),
+		SourceModel:               new(mock.MockSourceModel),
+		UserModel:                 new(mock.MockUserModel),
+		TagModel:                  new(mock.MockTagModel),
+		ScopeModel:                new(mock.MockScopeModel),
+		UserScopeModel:            new(mock.MockUserScopeModel),
+		GroupModel:                new(mock.MockGroupModel),
+		TransactionTagModel:       new(mock.MockTransactionTagModel),
+		TransactionModel:          new(mock.MockTransactionModel),
+		GroupInvitationModel:      new(mock.MockGroupInvitationModel),
+		RecurringTransactionModel: new(mock.MockRecurringTransactionModel),
+		BudgetModel:               new(mock.MockBudgetModel),
+		ImportBatchModel:          new(mock.MockImportBatchModel),
+		ArchiveModel:              new(mock.MockArchiveModel),
+		CurrencyModel:             new(mock.MockCurrencyModel),
+		TransactionSplitModel:     new(mock.MockTransactionSplitModel),
+		SharedExpenseModel:        new(mock.MockSharedExpenseModel),
+		TrashModel:                new(mock.MockTrashModel),
+		TemplateModel:             new(mock.MockTemplateModel),
+		RuleModel:                 newNoRulesMock(),
+		TransactionSearchModel:    newNoSearchIndexMock(),
 	}
 
 	// Allow tests to modify the mock configuration as needed
@@ -0,0 +1,287 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	"xspends/models/interfaces"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// DefaultCurrency is used when neither a scope nor its owner has a currency.
+const DefaultCurrency = "USD"
+
+var (
+	ErrInvalidCurrency      = errors.New("currency must be a three letter ISO 4217 code")
+	ErrCurrencyMismatch     = errors.New("a transaction must be in the currency of its source")
+	ErrInvalidExchangeRate  = errors.New("invalid exchange rate")
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormalizeCurrency upper-cases a currency code and checks that it is three letters long.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCodePattern.MatchString(code) {
+		return "", errors.Wrapf(ErrInvalidCurrency, "got %q", code)
+	}
+	return code, nil
+}
+
+type CurrencyModel struct {
+	TableExchangeRates string
+	ColumnScope        string
+	ColumnFrom         string
+	ColumnTo           string
+	ColumnRateDate     string
+	ColumnRate         string
+	ColumnCreatedAt    string
+	ColumnUpdatedAt    string
+}
+
+func NewCurrencyModel() *CurrencyModel {
+	return &CurrencyModel{
+		TableExchangeRates: "exchange_rates",
+		ColumnScope:        "scope_id",
+		ColumnFrom:         "from_currency",
+		ColumnTo:           "to_currency",
+		ColumnRateDate:     "rate_date",
+		ColumnRate:         "rate",
+		ColumnCreatedAt:    "created_at",
+		ColumnUpdatedAt:    "updated_at",
+	}
+}
+
+func (cm *CurrencyModel) selectColumns() []string {
+	return []string{cm.ColumnScope, cm.ColumnFrom, cm.ColumnTo, cm.ColumnRateDate, cm.ColumnRate, cm.ColumnCreatedAt, cm.ColumnUpdatedAt}
+}
+
+func (cm *CurrencyModel) validateExchangeRate(rate *interfaces.ExchangeRate) error {
+	var err error
+	if rate.FromCurrency, err = NormalizeCurrency(rate.FromCurrency); err != nil {
+		return err
+	}
+	if rate.ToCurrency, err = NormalizeCurrency(rate.ToCurrency); err != nil {
+		return err
+	}
+	switch {
+	case rate.ScopeID <= 0:
+		return errors.Wrap(ErrInvalidExchangeRate, "scope is required")
+	case rate.FromCurrency == rate.ToCurrency:
+		return errors.Wrap(ErrInvalidExchangeRate, "from and to currencies must differ")
+	case rate.Rate <= 0:
+		return errors.Wrap(ErrInvalidExchangeRate, "rate must be positive")
+	case rate.RateDate.IsZero():
+		return errors.Wrap(ErrInvalidExchangeRate, "rate date is required")
+	}
+	rate.RateDate = truncateToDate(rate.RateDate)
+	return nil
+}
+
+// UpsertExchangeRate stores a rate, replacing any rate of the same scope, pair and date.
+func (cm *CurrencyModel) UpsertExchangeRate(ctx context.Context, rate *interfaces.ExchangeRate, otx ...*sql.Tx) error {
+	if err := cm.validateExchangeRate(rate); err != nil {
+		return err
+	}
+	isExternalTx, executor := getExecutor(otx...)
+
+	rate.CreatedAt, rate.UpdatedAt = time.Now(), time.Now()
+	query, args, err := GetQueryBuilder().Insert(cm.TableExchangeRates).
+		Columns(cm.selectColumns()...).
+		Values(rate.ScopeID, rate.FromCurrency, rate.ToCurrency, rate.RateDate, rate.Rate, rate.CreatedAt, rate.UpdatedAt).
+		Suffix("ON DUPLICATE KEY UPDATE " + cm.ColumnRate + " = VALUES(" + cm.ColumnRate + "), " + cm.ColumnUpdatedAt + " = VALUES(" + cm.ColumnUpdatedAt + ")").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building exchange rate upsert query failed")
+	}
+
+	_, err = executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "storing exchange rate failed")
+	}
+
+	commitOrRollback(executor, isExternalTx, err)
+	return nil
+}
+
+// ImportExchangeRates stores a batch of rates in one SQL transaction and returns how many were stored.
+// Nothing is stored if any rate is invalid.
+func (cm *CurrencyModel) ImportExchangeRates(ctx context.Context, rates []interfaces.ExchangeRate, otx ...*sql.Tx) (int, error) {
+	for i := range rates {
+		if err := cm.validateExchangeRate(&rates[i]); err != nil {
+			return 0, errors.Wrapf(err, "rate %d", i+1)
+		}
+	}
+
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		for i := range rates {
+			if err := cm.UpsertExchangeRate(ctx, &rates[i], tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, otx...)
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// DeleteExchangeRate removes the rate of a scope for one pair and date.
+func (cm *CurrencyModel) DeleteExchangeRate(ctx context.Context, scopeID int64, fromCurrency, toCurrency string, rateDate time.Time, otx ...*sql.Tx) error {
+	isExternalTx, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Delete(cm.TableExchangeRates).
+		Where(squirrel.Eq{
+			cm.ColumnScope:    scopeID,
+			cm.ColumnFrom:     strings.ToUpper(fromCurrency),
+			cm.ColumnTo:       strings.ToUpper(toCurrency),
+			cm.ColumnRateDate: truncateToDate(rateDate),
+		}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building exchange rate delete query failed")
+	}
+
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "deleting exchange rate failed")
+	}
+	commitOrRollback(executor, isExternalTx, err)
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrExchangeRateNotFound
+	}
+	return nil
+}
+
+// GetExchangeRates lists the rates of the given scopes, newest first.
+// Empty currencies match every pair.
+func (cm *CurrencyModel) GetExchangeRates(ctx context.Context, scopes []int64, fromCurrency, toCurrency string, otx ...*sql.Tx) ([]interfaces.ExchangeRate, error) {
+	_, executor := getExecutor(otx...)
+
+	query := GetQueryBuilder().Select(cm.selectColumns()...).
+		From(cm.TableExchangeRates).
+		Where(squirrel.Eq{cm.ColumnScope: scopes})
+	if fromCurrency != "" {
+		query = query.Where(squirrel.Eq{cm.ColumnFrom: strings.ToUpper(fromCurrency)})
+	}
+	if toCurrency != "" {
+		query = query.Where(squirrel.Eq{cm.ColumnTo: strings.ToUpper(toCurrency)})
+	}
+	sqlQuery, args, err := query.OrderBy(cm.ColumnRateDate+" DESC", cm.ColumnFrom, cm.ColumnTo).ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building exchange rates query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying exchange rates failed")
+	}
+	defer rows.Close()
+
+	rates := make([]interfaces.ExchangeRate, 0)
+	for rows.Next() {
+		var rate interfaces.ExchangeRate
+		if err := rows.Scan(&rate.ScopeID, &rate.FromCurrency, &rate.ToCurrency, &rate.RateDate, &rate.Rate, &rate.CreatedAt, &rate.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, "scanning exchange rate failed")
+		}
+		rates = append(rates, rate)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing exchange rate rows failed")
+	}
+	return rates, nil
+}
+
+// GetExchangeRate returns how many units of toCurrency one unit of fromCurrency bought on date,
+// using the latest rate of the scope dated on or before it. When only the opposite pair has been
+// recorded its inverse is used.
+func (cm *CurrencyModel) GetExchangeRate(ctx context.Context, scopeID int64, fromCurrency, toCurrency string, date time.Time, otx ...*sql.Tx) (float64, error) {
+	fromCurrency, toCurrency = strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency)
+	if fromCurrency == toCurrency {
+		return 1, nil
+	}
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select().
+		Column("COALESCE(("+cm.rateLookup("?", "?", "?", "?")+"), 1 / ("+cm.rateLookup("?", "?", "?", "?")+"))",
+			scopeID, fromCurrency, toCurrency, truncateToDate(date), scopeID, toCurrency, fromCurrency, truncateToDate(date)).
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "building exchange rate lookup query failed")
+	}
+
+	var rate sql.NullFloat64
+	if err := executor.QueryRowContext(ctx, query, args...).Scan(&rate); err != nil {
+		return 0, errors.Wrap(err, "looking up exchange rate failed")
+	}
+	if !rate.Valid {
+		return 0, errors.Wrapf(ErrExchangeRateNotFound, "%s to %s on %s", fromCurrency, toCurrency, date.Format("2006-01-02"))
+	}
+	return rate.Float64, nil
+}
+
+// rateLookup is a subquery selecting the latest rate of a scope and pair dated on or before
+// a date. Its arguments are SQL expressions for the scope, the two currencies and the date.
+func (cm *CurrencyModel) rateLookup(scope, fromCurrency, toCurrency, date string) string {
+	return "SELECT r." + cm.ColumnRate + " FROM " + cm.TableExchangeRates + " r" +
+		" WHERE r." + cm.ColumnScope + " = " + scope +
+		" AND r." + cm.ColumnFrom + " = " + fromCurrency +
+		" AND r." + cm.ColumnTo + " = " + toCurrency +
+		" AND r." + cm.ColumnRateDate + " <= " + date +
+		" ORDER BY r." + cm.ColumnRateDate + " DESC LIMIT 1"
+}
+
+// GetBaseCurrency returns the currency amounts of a scope are reported in: the group's own
+// currency, else the group owner's, else the currency of the user whose personal scope it is.
+func (cm *CurrencyModel) GetBaseCurrency(ctx context.Context, scopeID int64, otx ...*sql.Tx) (string, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select().
+		Column("COALESCE("+
+			"(SELECT NULLIF(g.currency, '') FROM user_groups g WHERE g.scope_id = ?), "+
+			"(SELECT NULLIF(u.currency, '') FROM user_groups g JOIN users u ON u.user_id = g.owner_id WHERE g.scope_id = ?), "+
+			"(SELECT NULLIF(u.currency, '') FROM users u WHERE u.scope_id = ?), ?)",
+			scopeID, scopeID, scopeID, DefaultCurrency).
+		ToSql()
+	if err != nil {
+		return "", errors.Wrap(err, "building base currency query failed")
+	}
+
+	var currency string
+	if err := executor.QueryRowContext(ctx, query, args...).Scan(&currency); err != nil {
+		return "", errors.Wrap(err, "querying base currency failed")
+	}
+	return strings.ToUpper(currency), nil
+}
@@ -0,0 +1,84 @@
+package impl
+
+import (
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpCurrencyTest(t *testing.T) sqlmock.Sqlmock {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ModelsService = &ModelsServiceContainer{
+		DBService:     &DBService{Executor: db},
+		CurrencyModel: NewCurrencyModel(),
+	}
+	return sqlMock
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+	code, err := NormalizeCurrency(" eur ")
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", code)
+
+	_, err = NormalizeCurrency("EURO")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+}
+
+func TestUpsertExchangeRate(t *testing.T) {
+	sqlMock := setUpCurrencyTest(t)
+
+	rate := &interfaces.ExchangeRate{ScopeID: 10, FromCurrency: "usd", ToCurrency: "eur", RateDate: time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC), Rate: 0.92}
+	sqlMock.ExpectExec("^INSERT INTO exchange_rates (.+) ON DUPLICATE KEY UPDATE rate = VALUES\\(rate\\)").
+		WithArgs(int64(10), "USD", "EUR", date(2024, 3, 1), 0.92, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, ModelsService.CurrencyModel.UpsertExchangeRate(ctx, rate))
+
+	invalid := []interfaces.ExchangeRate{
+		{ScopeID: 10, FromCurrency: "USD", ToCurrency: "USD", RateDate: date(2024, 3, 1), Rate: 1},
+		{ScopeID: 10, FromCurrency: "USD", ToCurrency: "EUR", RateDate: date(2024, 3, 1), Rate: -1},
+		{ScopeID: 10, FromCurrency: "USD", ToCurrency: "EUR", Rate: 0.9},
+	}
+	for _, r := range invalid {
+		assert.ErrorIs(t, ModelsService.CurrencyModel.UpsertExchangeRate(ctx, &r), ErrInvalidExchangeRate)
+	}
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	sqlMock := setUpCurrencyTest(t)
+	day := date(2024, 3, 5)
+
+	rate, err := ModelsService.CurrencyModel.GetExchangeRate(ctx, 10, "usd", "USD", day)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, rate, "no lookup for the same currency")
+
+	sqlMock.ExpectQuery("^SELECT COALESCE\\(\\(SELECT r.rate FROM exchange_rates r (.+)\\), 1 / \\(SELECT r.rate FROM exchange_rates r (.+)\\)\\)").
+		WithArgs(int64(10), "USD", "EUR", day, int64(10), "EUR", "USD", day).
+		WillReturnRows(sqlmock.NewRows([]string{"rate"}).AddRow(0.9))
+	rate, err = ModelsService.CurrencyModel.GetExchangeRate(ctx, 10, "USD", "EUR", day)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.9, rate)
+
+	sqlMock.ExpectQuery("^SELECT COALESCE").
+		WillReturnRows(sqlmock.NewRows([]string{"rate"}).AddRow(nil))
+	_, err = ModelsService.CurrencyModel.GetExchangeRate(ctx, 10, "USD", "JPY", day)
+	assert.ErrorIs(t, err, ErrExchangeRateNotFound)
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestDeleteExchangeRateNotFound(t *testing.T) {
+	sqlMock := setUpCurrencyTest(t)
+
+	sqlMock.ExpectExec("^DELETE FROM exchange_rates WHERE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	err := ModelsService.CurrencyModel.DeleteExchangeRate(ctx, 10, "USD", "EUR", date(2024, 3, 1))
+	assert.ErrorIs(t, err, ErrExchangeRateNotFound)
+}
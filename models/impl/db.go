@@ -32,6 +32,7 @@ import (
 	"os"
 	"strconv"
 	"time"
+	"xspends/migrations"
 
 	"github.com/Masterminds/squirrel"
 	_ "github.com/go-sql-driver/mysql"
@@ -65,8 +66,35 @@ func GetDBService() *DBService {
 	return nil // or handle the error/nil case appropriately
 }
 
+// InitDB connects to the database and refuses to go on unless every migration has been
+// applied, so that an instance never serves traffic on an outdated schema.
 func InitDB() (*DBService, error) {
+	DB, err := OpenDB()
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := migrations.NewMigrator(DB)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading migrations")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := migrator.CheckCurrent(ctx); err != nil {
+		return nil, errors.Wrap(err, "checking the database schema")
+	}
 
+	db := &DBService{
+		Executor: DB,
+	}
+
+	sqlBuilder = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Question)
+	return db, nil
+}
+
+// OpenDB connects to the database named by DB_DSN and configures the connection pool
+// from DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME.
+func OpenDB() (*sql.DB, error) {
 	dsn := os.Getenv("DB_DSN")
 	fmt.Println(dsn)
 	if dsn == "" {
@@ -116,13 +144,7 @@ func InitDB() (*DBService, error) {
 			DB.SetConnMaxLifetime(maxConnLifetimeMin)
 		}
 	}
-
-	db := &DBService{
-		Executor: DB,
-	}
-
-	sqlBuilder = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Question)
-	return db, nil
+	return DB, nil
 }
 
 func GetQueryBuilder() *squirrel.StatementBuilderType {
@@ -144,6 +166,42 @@ func getExecutor(otx ...*sql.Tx) (bool, DBExecutor) {
 	}
 }
 
+// txStarter is satisfied by executors that can open a SQL transaction, such as *sql.DB.
+type txStarter interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// RunInTx runs fn inside a single SQL transaction.
+// An externally supplied transaction is reused and left for the caller to commit.
+// Otherwise a new one is opened on the global executor and committed or rolled back
+// based on fn's result. Executors that cannot open transactions (e.g. test doubles)
+// run fn with a nil *sql.Tx, which getExecutor resolves to the global executor.
+func RunInTx(ctx context.Context, fn func(tx *sql.Tx) error, otx ...*sql.Tx) error {
+	if len(otx) > 0 && otx[0] != nil {
+		return fn(otx[0])
+	}
+
+	starter, ok := GetModelsService().DBService.Executor.(txStarter)
+	if !ok {
+		return fn(nil)
+	}
+
+	tx, err := starter.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "error starting transaction")
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Wrap(rbErr, "error rolling back transaction")
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "error committing transaction")
+	}
+	return nil
+}
+
 func commitOrRollback(executor DBExecutor, isExternalTx bool, actionErr error) error {
 	if !isExternalTx {
 		tx, ok := executor.(*sql.Tx)
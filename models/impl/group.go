@@ -11,6 +11,12 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	GroupStatusActive = "active"
+	ErrGroupNotOwner  = "only the group owner can perform this action"
+	ErrGroupNotMember = "user is not a member of this group"
+)
+
 type GroupModel struct {
 	TableGroups               string
 	ColumnGroupID             string
@@ -20,6 +26,7 @@ type GroupModel struct {
 	ColumnDescription         string
 	ColumnIcon                string
 	ColumnStatus              string
+	ColumnCurrency            string
 	ColumnCreatedAt           string
 	ColumnUpdatedAt           string
 	MaxGroupNameLength        int
@@ -36,6 +43,7 @@ func NewGroupModel() *GroupModel {
 		ColumnDescription:         "description",
 		ColumnIcon:                "icon",
 		ColumnStatus:              "status",
+		ColumnCurrency:            "currency",
 		ColumnCreatedAt:           "created_at",
 		ColumnUpdatedAt:           "updated_at",
 		MaxGroupNameLength:        100, // Adjust as per your requirement
@@ -46,68 +54,78 @@ func (gm *GroupModel) validateGroupInput(group *interfaces.Group) error {
 	if group.OwnerID <= 0 || group.GroupName == "" || len(group.GroupName) > gm.MaxGroupNameLength || len(group.Description) > gm.MaxGroupDescriptionLength {
 		return errors.New(ErrInvalidInput)
 	}
+	if group.Currency != "" {
+		currency, err := NormalizeCurrency(group.Currency)
+		if err != nil {
+			return err
+		}
+		group.Currency = currency
+	}
 	return nil
 }
-func (gm *GroupModel) CreateGroup(ctx context.Context, group *interfaces.Group, userIDs []int64, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
 
+// CreateGroup creates the group's scope, the group row and the owner's membership in one transaction.
+// Any additional userIDs are linked to the scope with view access. When the group names a template,
+// its categories and sources are created in the scope on the owner's behalf in the same transaction.
+func (gm *GroupModel) CreateGroup(ctx context.Context, group *interfaces.Group, userIDs []int64, otx ...*sql.Tx) error {
 	if err := gm.validateGroupInput(group); err != nil {
 		return err
 	}
 
-	// Initialize ScopeModel and create a new scope
-	scopeID, err := GetModelsService().ScopeModel.CreateScope(ctx, ScopeTypeGroup, otx...)
-	if err != nil {
-		commitOrRollback(executor, isExternalTx, err)
-		return errors.Wrap(err, "creating new scope failed")
-	}
-
-	// Generate Snowflake ID for the group
-	group.GroupID, err = util.GenerateSnowflakeID()
-	if err != nil {
-		commitOrRollback(executor, isExternalTx, err)
-		return errors.Wrap(err, "generating Snowflake ID for group failed")
-	}
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
 
-	group.CreatedAt, group.UpdatedAt = time.Now(), time.Now()
+		// Initialize ScopeModel and create a new scope
+		scopeID, err := GetModelsService().ScopeModel.CreateScope(ctx, ScopeTypeGroup, tx)
+		if err != nil {
+			return errors.Wrap(err, "creating new scope failed")
+		}
 
-	// Insert into groups table
-	groupsQuery, groupsArgs, err := GetQueryBuilder().Insert(gm.TableGroups).
-		Columns(gm.ColumnGroupID, gm.ColumnOwnerID, gm.ColumnScopeID, gm.ColumnGroupName, gm.ColumnDescription, gm.ColumnIcon, gm.ColumnStatus, gm.ColumnCreatedAt, gm.ColumnUpdatedAt).
-		Values(group.GroupID, group.OwnerID, scopeID, group.GroupName, group.Description, group.Icon, group.Status, group.CreatedAt, group.UpdatedAt).
-		ToSql()
-	if err != nil {
-		commitOrRollback(executor, isExternalTx, err)
-		return errors.Wrap(err, "building groups insert query failed")
-	}
+		// Generate Snowflake ID for the group
+		group.GroupID, err = util.GenerateSnowflakeID()
+		if err != nil {
+			return errors.Wrap(err, "generating Snowflake ID for group failed")
+		}
 
-	_, err = executor.ExecContext(ctx, groupsQuery, groupsArgs...)
-	if err != nil {
-		commitOrRollback(executor, isExternalTx, err)
-		return errors.Wrap(err, "inserting into groups failed")
-	}
+		group.ScopeID = scopeID
+		group.CreatedAt, group.UpdatedAt = time.Now(), time.Now()
+		if group.Status == "" {
+			group.Status = GroupStatusActive
+		}
 
-	//TODO: To be separated out to scope insert
-	// Link users to the group's scope
-	for _, userID := range userIDs {
-		userScopesQuery, userScopesArgs, err := GetQueryBuilder().Insert("user_scopes").
-			Columns("user_id", "scope_id").
-			Values(userID, scopeID).
+		// Insert into groups table
+		groupsQuery, groupsArgs, err := GetQueryBuilder().Insert(gm.TableGroups).
+			Columns(gm.ColumnGroupID, gm.ColumnOwnerID, gm.ColumnScopeID, gm.ColumnGroupName, gm.ColumnDescription, gm.ColumnIcon, gm.ColumnStatus, gm.ColumnCurrency, gm.ColumnCreatedAt, gm.ColumnUpdatedAt).
+			Values(group.GroupID, group.OwnerID, scopeID, group.GroupName, group.Description, group.Icon, group.Status, sql.NullString{String: group.Currency, Valid: group.Currency != ""}, group.CreatedAt, group.UpdatedAt).
 			ToSql()
 		if err != nil {
-			commitOrRollback(executor, isExternalTx, err)
-			return errors.Wrap(err, "building user_scopes insert query failed")
+			return errors.Wrap(err, "building groups insert query failed")
 		}
 
-		_, err = executor.ExecContext(ctx, userScopesQuery, userScopesArgs...)
-		if err != nil {
-			commitOrRollback(executor, isExternalTx, err)
-			return errors.Wrap(err, "inserting into user_scopes failed")
+		if _, err = executor.ExecContext(ctx, groupsQuery, groupsArgs...); err != nil {
+			return errors.Wrap(err, "inserting into groups failed")
 		}
-	}
 
-	commitOrRollback(executor, isExternalTx, err)
-	return nil
+		// Link the owner and any other users to the group's scope
+		if err := GetModelsService().UserScopeModel.UpsertUserScope(ctx, group.OwnerID, scopeID, RoleOwner, tx); err != nil {
+			return errors.Wrap(err, "linking owner to group scope failed")
+		}
+		for _, userID := range userIDs {
+			if userID == group.OwnerID {
+				continue
+			}
+			if err := GetModelsService().UserScopeModel.UpsertUserScope(ctx, userID, scopeID, RoleView, tx); err != nil {
+				return errors.Wrap(err, "linking user to group scope failed")
+			}
+		}
+
+		if group.Template != "" {
+			if err := GetModelsService().TemplateModel.ApplyTemplate(ctx, group.Template, group.OwnerID, scopeID, tx); err != nil {
+				return errors.Wrap(err, "seeding group scope failed")
+			}
+		}
+		return nil
+	}, otx...)
 }
 
 func (gm *GroupModel) UpdateGroup(ctx context.Context, group *interfaces.Group, requestingUserID int64, otx ...*sql.Tx) error {
@@ -124,6 +142,7 @@ func (gm *GroupModel) UpdateGroup(ctx context.Context, group *interfaces.Group,
 		Set(gm.ColumnGroupName, group.GroupName).
 		Set(gm.ColumnDescription, group.Description).
 		Set(gm.ColumnIcon, group.Icon).
+		Set(gm.ColumnCurrency, sql.NullString{String: group.Currency, Valid: group.Currency != ""}).
 		Set(gm.ColumnUpdatedAt, group.UpdatedAt).
 		Where(squirrel.Eq{gm.ColumnGroupID: group.GroupID, gm.ColumnOwnerID: requestingUserID}).
 		ToSql()
@@ -142,32 +161,53 @@ func (gm *GroupModel) UpdateGroup(ctx context.Context, group *interfaces.Group,
 	return nil
 }
 
+// DeleteGroup removes a group owned by requestingUserID along with its invitations and memberships.
+// Data recorded under the group's scope is left untouched.
 func (gm *GroupModel) DeleteGroup(ctx context.Context, groupID int64, requestingUserID int64, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
 
-	groupDeleteQuery, groupDeleteArgs, err := GetQueryBuilder().Delete(gm.TableGroups).
-		Where(squirrel.Eq{gm.ColumnGroupID: groupID, gm.ColumnOwnerID: requestingUserID}).
-		ToSql()
-	if err != nil {
-		commitOrRollback(executor, isExternalTx, err)
-		return errors.Wrap(err, "building group delete query failed")
-	}
+		group, err := gm.GetGroupByID(ctx, groupID, requestingUserID, tx)
+		if err != nil {
+			return err
+		}
+		if group.OwnerID != requestingUserID {
+			return errors.New(ErrGroupNotOwner)
+		}
 
-	_, err = executor.ExecContext(ctx, groupDeleteQuery, groupDeleteArgs...)
-	if err != nil {
-		commitOrRollback(executor, isExternalTx, err)
-		return errors.Wrap(err, "deleting group failed")
-	}
+		if err := GetModelsService().GroupInvitationModel.DeleteInvitationsByGroup(ctx, groupID, tx); err != nil {
+			return errors.Wrap(err, "deleting group invitations failed")
+		}
 
-	commitOrRollback(executor, isExternalTx, err)
-	return nil
+		membersDeleteQuery, membersDeleteArgs, err := GetQueryBuilder().Delete("user_scopes").
+			Where(squirrel.Eq{"scope_id": group.ScopeID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building group members delete query failed")
+		}
+		if _, err = executor.ExecContext(ctx, membersDeleteQuery, membersDeleteArgs...); err != nil {
+			return errors.Wrap(err, "deleting group members failed")
+		}
+
+		groupDeleteQuery, groupDeleteArgs, err := GetQueryBuilder().Delete(gm.TableGroups).
+			Where(squirrel.Eq{gm.ColumnGroupID: groupID, gm.ColumnOwnerID: requestingUserID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building group delete query failed")
+		}
+
+		if _, err = executor.ExecContext(ctx, groupDeleteQuery, groupDeleteArgs...); err != nil {
+			return errors.Wrap(err, "deleting group failed")
+		}
+		return nil
+	}, otx...)
 }
 
 func (gm *GroupModel) GetGroupByID(ctx context.Context, groupID int64, requestingUserID int64, otx ...*sql.Tx) (*interfaces.Group, error) {
 	_, executor := getExecutor(otx...)
 
 	// Fetch group details
-	groupSelectQuery, groupSelectArgs, err := GetQueryBuilder().Select(gm.ColumnGroupID, gm.ColumnOwnerID, gm.ColumnScopeID, gm.ColumnGroupName, gm.ColumnDescription, gm.ColumnIcon, gm.ColumnStatus, gm.ColumnCreatedAt, gm.ColumnUpdatedAt).
+	groupSelectQuery, groupSelectArgs, err := GetQueryBuilder().Select(gm.ColumnGroupID, gm.ColumnOwnerID, gm.ColumnScopeID, gm.ColumnGroupName, gm.ColumnDescription, gm.ColumnIcon, gm.ColumnStatus, "COALESCE("+gm.ColumnCurrency+", '')", gm.ColumnCreatedAt, gm.ColumnUpdatedAt).
 		From(gm.TableGroups).
 		Where(squirrel.Eq{gm.ColumnGroupID: groupID}).
 		ToSql()
@@ -177,7 +217,7 @@ func (gm *GroupModel) GetGroupByID(ctx context.Context, groupID int64, requestin
 
 	row := executor.QueryRowContext(ctx, groupSelectQuery, groupSelectArgs...)
 	group := interfaces.Group{}
-	if err := row.Scan(&group.GroupID, &group.OwnerID, &group.ScopeID, &group.GroupName, &group.Description, &group.Icon, &group.Status, &group.CreatedAt, &group.UpdatedAt); err != nil {
+	if err := row.Scan(&group.GroupID, &group.OwnerID, &group.ScopeID, &group.GroupName, &group.Description, &group.Icon, &group.Status, &group.Currency, &group.CreatedAt, &group.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("group not found")
 		}
@@ -191,7 +231,7 @@ func (gm *GroupModel) GetGroupByScope(ctx context.Context, scopeID int64, reques
 	_, executor := getExecutor(otx...)
 
 	// Fetch group details by scope ID
-	groupSelectQuery, groupSelectArgs, err := GetQueryBuilder().Select(gm.ColumnGroupID, gm.ColumnOwnerID, gm.ColumnScopeID, gm.ColumnGroupName, gm.ColumnDescription, gm.ColumnIcon, gm.ColumnStatus, gm.ColumnCreatedAt, gm.ColumnUpdatedAt).
+	groupSelectQuery, groupSelectArgs, err := GetQueryBuilder().Select(gm.ColumnGroupID, gm.ColumnOwnerID, gm.ColumnScopeID, gm.ColumnGroupName, gm.ColumnDescription, gm.ColumnIcon, gm.ColumnStatus, "COALESCE("+gm.ColumnCurrency+", '')", gm.ColumnCreatedAt, gm.ColumnUpdatedAt).
 		From(gm.TableGroups).
 		Where(squirrel.Eq{gm.ColumnScopeID: scopeID}).
 		ToSql()
@@ -201,7 +241,7 @@ func (gm *GroupModel) GetGroupByScope(ctx context.Context, scopeID int64, reques
 
 	row := executor.QueryRowContext(ctx, groupSelectQuery, groupSelectArgs...)
 	group := interfaces.Group{}
-	if err := row.Scan(&group.GroupID, &group.OwnerID, &group.ScopeID, &group.GroupName, &group.Description, &group.Icon, &group.Status, &group.CreatedAt, &group.UpdatedAt); err != nil {
+	if err := row.Scan(&group.GroupID, &group.OwnerID, &group.ScopeID, &group.GroupName, &group.Description, &group.Icon, &group.Status, &group.Currency, &group.CreatedAt, &group.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("group not found for the given scope")
 		}
@@ -211,5 +251,80 @@ func (gm *GroupModel) GetGroupByScope(ctx context.Context, scopeID int64, reques
 	return &group, nil
 }
 
-//Add to groups method
-//get users in a group method
+// GetGroupsByUser lists every group the user belongs to, with the role they hold in it.
+func (gm *GroupModel) GetGroupsByUser(ctx context.Context, userID int64, otx ...*sql.Tx) ([]interfaces.GroupMembership, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select("g."+gm.ColumnGroupID, "g."+gm.ColumnOwnerID, "g."+gm.ColumnScopeID, "g."+gm.ColumnGroupName, "g."+gm.ColumnDescription, "g."+gm.ColumnIcon, "g."+gm.ColumnStatus, "COALESCE(g."+gm.ColumnCurrency+", '')", "g."+gm.ColumnCreatedAt, "g."+gm.ColumnUpdatedAt, "us.role").
+		From(gm.TableGroups + " g").
+		Join("user_scopes us ON us.scope_id = g." + gm.ColumnScopeID).
+		Where(squirrel.Eq{"us.user_id": userID}).
+		OrderBy("g." + gm.ColumnGroupName).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building groups by user query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying groups by user failed")
+	}
+	defer rows.Close()
+
+	memberships := make([]interfaces.GroupMembership, 0)
+	for rows.Next() {
+		var m interfaces.GroupMembership
+		if err := rows.Scan(&m.GroupID, &m.OwnerID, &m.ScopeID, &m.GroupName, &m.Description, &m.Icon, &m.Status, &m.Currency, &m.CreatedAt, &m.UpdatedAt, &m.Role); err != nil {
+			return nil, errors.Wrap(err, "scanning group membership row failed")
+		}
+		memberships = append(memberships, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing group membership rows failed")
+	}
+
+	return memberships, nil
+}
+
+// GetGroupMembers lists the users linked to a group's scope. The requesting user must be a member.
+func (gm *GroupModel) GetGroupMembers(ctx context.Context, groupID int64, requestingUserID int64, otx ...*sql.Tx) ([]interfaces.GroupMember, error) {
+	_, executor := getExecutor(otx...)
+
+	group, err := gm.GetGroupByID(ctx, groupID, requestingUserID, otx...)
+	if err != nil {
+		return nil, err
+	}
+	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, requestingUserID, group.ScopeID, RoleView, otx...) {
+		return nil, errors.New(ErrGroupNotMember)
+	}
+
+	query, args, err := GetQueryBuilder().Select("u.user_id", "u.username", "u.name", "us.role").
+		From("user_scopes us").
+		Join("users u ON u.user_id = us.user_id").
+		Where(squirrel.Eq{"us.scope_id": group.ScopeID}).
+		OrderBy("u.username").
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building group members query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying group members failed")
+	}
+	defer rows.Close()
+
+	members := make([]interfaces.GroupMember, 0)
+	for rows.Next() {
+		var member interfaces.GroupMember
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Name, &member.Role); err != nil {
+			return nil, errors.Wrap(err, "scanning group member row failed")
+		}
+		members = append(members, member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing group member rows failed")
+	}
+
+	return members, nil
+}
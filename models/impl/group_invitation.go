@@ -0,0 +1,308 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	InvitationStatusPending   = "pending"
+	InvitationStatusAccepted  = "accepted"
+	InvitationStatusDeclined  = "declined"
+	InvitationStatusCancelled = "cancelled"
+)
+
+var (
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvitationNotPending = errors.New("invitation is no longer pending")
+	ErrInvitationExists     = errors.New("a pending invitation already exists for this user")
+)
+
+type GroupInvitationModel struct {
+	TableInvitations string
+	ColumnID         string
+	ColumnGroupID    string
+	ColumnInviterID  string
+	ColumnInviteeID  string
+	ColumnRole       string
+	ColumnStatus     string
+	ColumnCreatedAt  string
+	ColumnUpdatedAt  string
+}
+
+func NewGroupInvitationModel() *GroupInvitationModel {
+	return &GroupInvitationModel{
+		TableInvitations: "group_invitations",
+		ColumnID:         "invitation_id",
+		ColumnGroupID:    "group_id",
+		ColumnInviterID:  "inviter_id",
+		ColumnInviteeID:  "invitee_id",
+		ColumnRole:       "role",
+		ColumnStatus:     "status",
+		ColumnCreatedAt:  "created_at",
+		ColumnUpdatedAt:  "updated_at",
+	}
+}
+
+func (im *GroupInvitationModel) selectColumns() []string {
+	return []string{"i." + im.ColumnID, "i." + im.ColumnGroupID, "g.group_name", "i." + im.ColumnInviterID, "i." + im.ColumnInviteeID, "i." + im.ColumnRole, "i." + im.ColumnStatus, "i." + im.ColumnCreatedAt, "i." + im.ColumnUpdatedAt}
+}
+
+func (im *GroupInvitationModel) selectQuery() squirrel.SelectBuilder {
+	return GetQueryBuilder().Select(im.selectColumns()...).
+		From(im.TableInvitations + " i").
+		Join("user_groups g ON g.group_id = i." + im.ColumnGroupID)
+}
+
+func scanInvitation(scanner interface{ Scan(...interface{}) error }, invitation *interfaces.GroupInvitation) error {
+	return scanner.Scan(&invitation.InvitationID, &invitation.GroupID, &invitation.GroupName, &invitation.InviterID, &invitation.InviteeID, &invitation.Role, &invitation.Status, &invitation.CreatedAt, &invitation.UpdatedAt)
+}
+
+// InsertInvitation records a pending invitation. Only one pending invitation per group and invitee is allowed.
+func (im *GroupInvitationModel) InsertInvitation(ctx context.Context, invitation *interfaces.GroupInvitation, otx ...*sql.Tx) error {
+	isExternalTx, executor := getExecutor(otx...)
+
+	if invitation.GroupID <= 0 || invitation.InviterID <= 0 || invitation.InviteeID <= 0 {
+		return errors.New(ErrInvalidInput)
+	}
+	if invitation.Role != RoleView && invitation.Role != RoleWrite {
+		return errors.New("invalid role: role must be view or write")
+	}
+
+	pending, err := im.getPending(ctx, invitation.GroupID, invitation.InviteeID, otx...)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return ErrInvitationExists
+	}
+
+	invitation.InvitationID, err = util.GenerateSnowflakeID()
+	if err != nil {
+		return errors.Wrap(err, "generating Snowflake ID for invitation failed")
+	}
+	invitation.Status = InvitationStatusPending
+	invitation.CreatedAt, invitation.UpdatedAt = time.Now(), time.Now()
+
+	query, args, err := GetQueryBuilder().Insert(im.TableInvitations).
+		Columns(im.ColumnID, im.ColumnGroupID, im.ColumnInviterID, im.ColumnInviteeID, im.ColumnRole, im.ColumnStatus, im.ColumnCreatedAt, im.ColumnUpdatedAt).
+		Values(invitation.InvitationID, invitation.GroupID, invitation.InviterID, invitation.InviteeID, invitation.Role, invitation.Status, invitation.CreatedAt, invitation.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building invitation insert query failed")
+	}
+
+	_, err = executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "inserting invitation failed")
+	}
+
+	commitOrRollback(executor, isExternalTx, err)
+	return nil
+}
+
+func (im *GroupInvitationModel) getPending(ctx context.Context, groupID, inviteeID int64, otx ...*sql.Tx) (bool, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select("1").
+		From(im.TableInvitations).
+		Where(squirrel.Eq{im.ColumnGroupID: groupID, im.ColumnInviteeID: inviteeID, im.ColumnStatus: InvitationStatusPending}).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "building pending invitation query failed")
+	}
+
+	var exists int
+	err = executor.QueryRowContext(ctx, query, args...).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "checking pending invitation failed")
+	}
+	return exists == 1, nil
+}
+
+func (im *GroupInvitationModel) GetInvitationByID(ctx context.Context, invitationID int64, otx ...*sql.Tx) (*interfaces.GroupInvitation, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := im.selectQuery().
+		Where(squirrel.Eq{"i." + im.ColumnID: invitationID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building invitation select query failed")
+	}
+
+	invitation := &interfaces.GroupInvitation{}
+	if err := scanInvitation(executor.QueryRowContext(ctx, query, args...), invitation); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, errors.Wrap(err, "querying invitation by ID failed")
+	}
+	return invitation, nil
+}
+
+// GetInvitationsByGroup lists a group's invitations, optionally restricted to a status.
+func (im *GroupInvitationModel) GetInvitationsByGroup(ctx context.Context, groupID int64, status string, otx ...*sql.Tx) ([]interfaces.GroupInvitation, error) {
+	return im.listInvitations(ctx, squirrel.Eq{"i." + im.ColumnGroupID: groupID}, status, otx...)
+}
+
+// GetInvitationsByInvitee lists the invitations addressed to a user, optionally restricted to a status.
+func (im *GroupInvitationModel) GetInvitationsByInvitee(ctx context.Context, inviteeID int64, status string, otx ...*sql.Tx) ([]interfaces.GroupInvitation, error) {
+	return im.listInvitations(ctx, squirrel.Eq{"i." + im.ColumnInviteeID: inviteeID}, status, otx...)
+}
+
+func (im *GroupInvitationModel) listInvitations(ctx context.Context, where squirrel.Eq, status string, otx ...*sql.Tx) ([]interfaces.GroupInvitation, error) {
+	_, executor := getExecutor(otx...)
+
+	if status != "" {
+		where["i."+im.ColumnStatus] = status
+	}
+	query, args, err := im.selectQuery().
+		Where(where).
+		OrderBy("i." + im.ColumnCreatedAt + " DESC").
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building invitation list query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying invitations failed")
+	}
+	defer rows.Close()
+
+	invitations := make([]interfaces.GroupInvitation, 0)
+	for rows.Next() {
+		var invitation interfaces.GroupInvitation
+		if err := scanInvitation(rows, &invitation); err != nil {
+			return nil, errors.Wrap(err, "scanning invitation row failed")
+		}
+		invitations = append(invitations, invitation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing invitation rows failed")
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation links the invitee to the group's scope with the invited role and
+// marks the invitation accepted, both in the same transaction.
+func (im *GroupInvitationModel) AcceptInvitation(ctx context.Context, invitationID int64, inviteeID int64, otx ...*sql.Tx) (*interfaces.GroupInvitation, error) {
+	var invitation *interfaces.GroupInvitation
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		invitation, err = im.getPendingForInvitee(ctx, invitationID, inviteeID, tx)
+		if err != nil {
+			return err
+		}
+
+		group, err := GetModelsService().GroupModel.GetGroupByID(ctx, invitation.GroupID, inviteeID, tx)
+		if err != nil {
+			return errors.Wrap(err, "fetching invited group failed")
+		}
+		if err := GetModelsService().UserScopeModel.UpsertUserScope(ctx, inviteeID, group.ScopeID, invitation.Role, tx); err != nil {
+			return errors.Wrap(err, "linking invitee to group scope failed")
+		}
+		if err := im.updateStatus(ctx, invitationID, InvitationStatusAccepted, tx); err != nil {
+			return err
+		}
+		invitation.Status = InvitationStatusAccepted
+		return nil
+	}, otx...)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// DeclineInvitation marks a pending invitation addressed to inviteeID as declined.
+func (im *GroupInvitationModel) DeclineInvitation(ctx context.Context, invitationID int64, inviteeID int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		if _, err := im.getPendingForInvitee(ctx, invitationID, inviteeID, tx); err != nil {
+			return err
+		}
+		return im.updateStatus(ctx, invitationID, InvitationStatusDeclined, tx)
+	}, otx...)
+}
+
+// CancelInvitation withdraws a pending invitation of the given group.
+func (im *GroupInvitationModel) CancelInvitation(ctx context.Context, invitationID int64, groupID int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		invitation, err := im.GetInvitationByID(ctx, invitationID, tx)
+		if err != nil {
+			return err
+		}
+		if invitation.GroupID != groupID {
+			return ErrInvitationNotFound
+		}
+		if invitation.Status != InvitationStatusPending {
+			return ErrInvitationNotPending
+		}
+		return im.updateStatus(ctx, invitationID, InvitationStatusCancelled, tx)
+	}, otx...)
+}
+
+// DeleteInvitationsByGroup removes every invitation of a group, regardless of status.
+func (im *GroupInvitationModel) DeleteInvitationsByGroup(ctx context.Context, groupID int64, otx ...*sql.Tx) error {
+	isExternalTx, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Delete(im.TableInvitations).
+		Where(squirrel.Eq{im.ColumnGroupID: groupID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building invitation delete query failed")
+	}
+
+	_, err = executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "deleting invitations failed")
+	}
+
+	commitOrRollback(executor, isExternalTx, err)
+	return nil
+}
+
+func (im *GroupInvitationModel) getPendingForInvitee(ctx context.Context, invitationID int64, inviteeID int64, otx ...*sql.Tx) (*interfaces.GroupInvitation, error) {
+	invitation, err := im.GetInvitationByID(ctx, invitationID, otx...)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.InviteeID != inviteeID {
+		return nil, ErrInvitationNotFound
+	}
+	if invitation.Status != InvitationStatusPending {
+		return nil, ErrInvitationNotPending
+	}
+	return invitation, nil
+}
+
+func (im *GroupInvitationModel) updateStatus(ctx context.Context, invitationID int64, status string, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Update(im.TableInvitations).
+		Set(im.ColumnStatus, status).
+		Set(im.ColumnUpdatedAt, time.Now()).
+		Where(squirrel.Eq{im.ColumnID: invitationID, im.ColumnStatus: InvitationStatusPending}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building invitation status update query failed")
+	}
+
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "updating invitation status failed")
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrInvitationNotPending
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package impl
+
+import (
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var invitationColumns = []string{"invitation_id", "group_id", "group_name", "inviter_id", "invitee_id", "role", "status", "created_at", "updated_at"}
+
+func setUpInvitationTest(t *testing.T) (sqlmock.Sqlmock, *xmock.MockGroupModel, *xmock.MockUserScopeModel) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mockGroupModel := new(xmock.MockGroupModel)
+	mockUserScopeModel := new(xmock.MockUserScopeModel)
+	ModelsService = &ModelsServiceContainer{
+		DBService:            &DBService{Executor: db},
+		GroupModel:           mockGroupModel,
+		UserScopeModel:       mockUserScopeModel,
+		GroupInvitationModel: NewGroupInvitationModel(),
+	}
+	return sqlMock, mockGroupModel, mockUserScopeModel
+}
+
+func TestAcceptInvitationLinksInvitee(t *testing.T) {
+	sqlMock, mockGroupModel, mockUserScopeModel := setUpInvitationTest(t)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("^SELECT (.+) FROM group_invitations i JOIN user_groups g").
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows(invitationColumns).AddRow(5, 10, "Family", 1, 2, RoleWrite, InvitationStatusPending, time.Now(), time.Now()))
+	mockGroupModel.On("GetGroupByID", mock.Anything, int64(10), int64(2), mock.Anything).Return(&interfaces.Group{GroupID: 10, ScopeID: 100}, nil).Once()
+	mockUserScopeModel.On("UpsertUserScope", mock.Anything, int64(2), int64(100), RoleWrite, mock.Anything).Return(nil).Once()
+	sqlMock.ExpectExec("^UPDATE group_invitations SET status").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	invitation, err := ModelsService.GroupInvitationModel.AcceptInvitation(ctx, 5, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, InvitationStatusAccepted, invitation.Status)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	mockUserScopeModel.AssertExpectations(t)
+}
+
+func TestAcceptInvitationForAnotherUser(t *testing.T) {
+	sqlMock, _, mockUserScopeModel := setUpInvitationTest(t)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("^SELECT (.+) FROM group_invitations i JOIN user_groups g").
+		WillReturnRows(sqlmock.NewRows(invitationColumns).AddRow(5, 10, "Family", 1, 3, RoleView, InvitationStatusPending, time.Now(), time.Now()))
+	sqlMock.ExpectRollback()
+
+	_, err := ModelsService.GroupInvitationModel.AcceptInvitation(ctx, 5, 2)
+	assert.Equal(t, ErrInvitationNotFound, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	mockUserScopeModel.AssertNotCalled(t, "UpsertUserScope")
+}
+
+func TestDeclineAnsweredInvitation(t *testing.T) {
+	sqlMock, _, _ := setUpInvitationTest(t)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("^SELECT (.+) FROM group_invitations i JOIN user_groups g").
+		WillReturnRows(sqlmock.NewRows(invitationColumns).AddRow(5, 10, "Family", 1, 2, RoleView, InvitationStatusAccepted, time.Now(), time.Now()))
+	sqlMock.ExpectRollback()
+
+	err := ModelsService.GroupInvitationModel.DeclineInvitation(ctx, 5, 2)
+	assert.Equal(t, ErrInvitationNotPending, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestInsertInvitationRejectsDuplicate(t *testing.T) {
+	sqlMock, _, _ := setUpInvitationTest(t)
+
+	sqlMock.ExpectQuery("^SELECT 1 FROM group_invitations").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	err := ModelsService.GroupInvitationModel.InsertInvitation(ctx, &interfaces.GroupInvitation{GroupID: 10, InviterID: 1, InviteeID: 2, Role: RoleView})
+	assert.Equal(t, ErrInvitationExists, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var groupColumns = []string{"group_id", "owner_id", "scope_id", "group_name", "description", "icon", "status", "currency", "created_at", "updated_at"}
+
+func TestGetGroupMembers(t *testing.T) {
+	// Owners and writers can list the members just like viewers
+	for _, role := range []string{RoleOwner, RoleWrite, RoleView} {
+		t.Run(role, func(t *testing.T) {
+			sqlMock := setUpUserScopeTest(t)
+			ModelsService.GroupModel = NewGroupModel()
+
+			sqlMock.ExpectQuery("^SELECT (.+) FROM user_groups WHERE group_id = \\?").
+				WithArgs(int64(5)).
+				WillReturnRows(sqlmock.NewRows(groupColumns).AddRow(5, 1, 10, "Flat", "", "", "active", "EUR", time.Now(), time.Now()))
+			expectRoleQuery(sqlMock, 1, 10, role, []string{RoleOwner, RoleView, RoleWrite})
+			sqlMock.ExpectQuery("^SELECT u.user_id, u.username, u.name, us.role FROM user_scopes us JOIN users u").
+				WithArgs(int64(10)).
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "name", "role"}).AddRow(1, "john", "John", role))
+
+			members, err := ModelsService.GroupModel.GetGroupMembers(ctx, 5, 1)
+			assert.NoError(t, err)
+			assert.Len(t, members, 1)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+// sameScope matches the ID of the scope a test creates: the first value it sees is taken as the
+// scope ID and every later one must equal it.
+type sameScope struct{ id *int64 }
+
+func (s sameScope) Match(v driver.Value) bool {
+	id, ok := v.(int64)
+	if ok && *s.id == 0 {
+		*s.id = id
+	}
+	return ok && id == *s.id
+}
+
+func TestCreateGroupWithTemplate(t *testing.T) {
+	util.InitializeSnowflake()
+	sqlMock := setUpUserScopeTest(t)
+	ModelsService.GroupModel = NewGroupModel()
+	ModelsService.ScopeModel = NewScopeModel()
+	ModelsService.CurrencyModel = NewCurrencyModel()
+	templates := NewTemplateModel()
+	templates.addTemplate(interfaces.SeedTemplate{
+		Name:       "flat",
+		Categories: []interfaces.SeedCategory{{Name: "Food"}},
+		Sources:    []interfaces.SeedSource{{Name: "Cash", Type: "SAVINGS"}},
+	})
+	ModelsService.TemplateModel = templates
+
+	// The owner's personal scope already has a Food category and a Cash source. Names are unique
+	// per scope, so the group's copies, created on the owner's behalf, only need a scope of their own.
+	var scopeID int64
+	scope := sameScope{&scopeID}
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("^INSERT INTO scopes \\(scope_id,type\\)").
+		WithArgs(scope, ScopeTypeGroup).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectExec("^INSERT INTO user_groups").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectExec("^INSERT INTO user_scopes \\(user_id,scope_id,role\\)").
+		WithArgs(int64(1), scope, RoleOwner).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectExec("^INSERT INTO categories \\(category_id,user_id,name,description,icon,scope_id,parent_id,created_at,updated_at\\)").
+		WithArgs(sqlmock.AnyArg(), int64(1), "Food", "", "", scope, sql.NullInt64{}, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectQuery("^SELECT COALESCE\\(").
+		WithArgs(scope, scope, scope, DefaultCurrency).
+		WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("EUR"))
+	sqlMock.ExpectExec("^INSERT INTO sources").
+		WithArgs(sqlmock.AnyArg(), int64(1), "Cash", "SAVINGS", interfaces.Money(0), interfaces.Money(0), "EUR", scope, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+
+	group := &interfaces.Group{OwnerID: 1, GroupName: "Flat", Template: "flat"}
+	assert.NoError(t, ModelsService.GroupModel.CreateGroup(ctx, group, nil))
+	assert.Equal(t, group.ScopeID, scopeID)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
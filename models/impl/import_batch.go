@@ -0,0 +1,523 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	ImportStatusCommitted = "COMMITTED"
+	ImportStatusUndone    = "UNDONE"
+)
+
+var (
+	ErrImportNotFound      = errors.New("import batch not found")
+	ErrInvalidImport       = errors.New("invalid import")
+	ErrImportAlreadyUndone = errors.New("import batch has already been undone")
+)
+
+type ImportBatchModel struct {
+	TableImportBatches string
+	ColumnID           string
+	ColumnUserID       string
+	ColumnScope        string
+	ColumnSourceID     string
+	ColumnFormat       string
+	ColumnFileName     string
+	ColumnRowCount     string
+	ColumnStatus       string
+	ColumnCreatedAt    string
+	ColumnUndoneAt     string
+}
+
+func NewImportBatchModel() *ImportBatchModel {
+	return &ImportBatchModel{
+		TableImportBatches: "import_batches",
+		ColumnID:           "batch_id",
+		ColumnUserID:       "user_id",
+		ColumnScope:        "scope_id",
+		ColumnSourceID:     "source_id",
+		ColumnFormat:       "format",
+		ColumnFileName:     "file_name",
+		ColumnRowCount:     "row_count",
+		ColumnStatus:       "status",
+		ColumnCreatedAt:    "created_at",
+		ColumnUndoneAt:     "undone_at",
+	}
+}
+
+func (im *ImportBatchModel) selectColumns() []string {
+	return []string{im.ColumnID, im.ColumnUserID, im.ColumnScope, im.ColumnSourceID, im.ColumnFormat, im.ColumnFileName, im.ColumnRowCount, im.ColumnStatus, im.ColumnCreatedAt, im.ColumnUndoneAt}
+}
+
+func scanImportBatch(scanner interface{ Scan(...interface{}) error }, batch *interfaces.ImportBatch) error {
+	var undoneAt sql.NullTime
+	if err := scanner.Scan(&batch.ID, &batch.UserID, &batch.ScopeID, &batch.SourceID, &batch.Format, &batch.FileName, &batch.RowCount, &batch.Status, &batch.CreatedAt, &undoneAt); err != nil {
+		return err
+	}
+	if undoneAt.Valid {
+		batch.UndoneAt = &undoneAt.Time
+	}
+	return nil
+}
+
+// PreviewImport checks the target source and annotates parsed rows without writing anything.
+// The scope's categorization rules are applied to each row first. Each row then gets a suggested
+// category: the scope's category named by the file, otherwise the category set by the rules,
+// otherwise the category most often used for the same description. Rows matching an existing transaction of
+// the source on the same day, with the same amount and type, are flagged as duplicates; each
+// existing transaction matches at most one row.
+func (im *ImportBatchModel) PreviewImport(ctx context.Context, batch interfaces.ImportBatch, rows []interfaces.ImportRow, otx ...*sql.Tx) ([]interfaces.ImportRow, error) {
+	exists, err := GetModelsService().SourceModel.SourceIDExists(ctx, batch.SourceID, []int64{batch.ScopeID}, otx...)
+	if err != nil {
+		return nil, errors.Wrap(err, "error checking if source exists")
+	}
+	if !exists {
+		return nil, errors.Wrap(ErrInvalidImport, "source does not exist")
+	}
+
+	preview := make([]interfaces.ImportRow, len(rows))
+	copy(preview, rows)
+	if len(preview) == 0 {
+		return preview, nil
+	}
+	if err := im.applyRules(ctx, batch, preview, otx...); err != nil {
+		return nil, err
+	}
+	if err := im.suggestCategories(ctx, batch.ScopeID, preview, otx...); err != nil {
+		return nil, err
+	}
+	if err := im.markDuplicates(ctx, batch, preview, otx...); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// applyRules rewrites the descriptions of rows and fills in their tags, matched rules and,
+// in SuggestedCategoryID, the category set by the rules.
+func (im *ImportBatchModel) applyRules(ctx context.Context, batch interfaces.ImportBatch, rows []interfaces.ImportRow, otx ...*sql.Tx) error {
+	rules, err := GetModelsService().RuleModel.GetRulesByScope(ctx, []int64{batch.ScopeID}, otx...)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		txn := interfaces.Transaction{
+			SourceID:    batch.SourceID,
+			Amount:      rows[i].Amount,
+			Type:        rows[i].Type,
+			Description: rows[i].Description,
+		}
+		matched := evaluateRules(rules, &txn, true)
+		if len(matched) > 0 {
+			rows[i].MatchedRules = matched
+		}
+		rows[i].Description, rows[i].Tags, rows[i].SuggestedCategoryID = txn.Description, txn.Tags, txn.CategoryID
+	}
+	return nil
+}
+
+func (im *ImportBatchModel) suggestCategories(ctx context.Context, scopeID int64, rows []interfaces.ImportRow, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	byName := map[string]int64{}
+	query, args, err := GetQueryBuilder().Select("category_id", "name").
+		From("categories").
+		Where(squirrel.Eq{"scope_id": scopeID}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building category lookup query failed")
+	}
+	categoryRows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "querying categories failed")
+	}
+	defer categoryRows.Close()
+	for categoryRows.Next() {
+		var id int64
+		var name string
+		if err := categoryRows.Scan(&id, &name); err != nil {
+			return errors.Wrap(err, "scanning category failed")
+		}
+		byName[strings.ToLower(name)] = id
+	}
+	if err := categoryRows.Err(); err != nil {
+		return errors.Wrap(err, "processing category rows failed")
+	}
+
+	descriptions := make([]string, 0)
+	seen := map[string]bool{}
+	for _, row := range rows {
+		description := strings.ToLower(row.Description)
+		if description != "" && !seen[description] {
+			seen[description] = true
+			descriptions = append(descriptions, description)
+		}
+	}
+	byDescription := map[string]int64{}
+	if len(descriptions) > 0 {
+		query, args, err = GetQueryBuilder().Select("LOWER(description) AS normalized", "category_id", "COUNT(*) AS uses").
+			From("transactions").
+			Where(squirrel.Eq{"scope_id": scopeID, "LOWER(description)": descriptions}).
+			Where("category_id IS NOT NULL AND deleted_at IS NULL").
+			GroupBy("normalized", "category_id").
+			OrderBy("uses DESC").
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building category history query failed")
+		}
+		historyRows, err := executor.QueryContext(ctx, query, args...)
+		if err != nil {
+			return errors.Wrap(err, "querying category history failed")
+		}
+		defer historyRows.Close()
+		for historyRows.Next() {
+			var description string
+			var categoryID int64
+			var uses int
+			if err := historyRows.Scan(&description, &categoryID, &uses); err != nil {
+				return errors.Wrap(err, "scanning category history failed")
+			}
+			if _, ok := byDescription[description]; !ok {
+				byDescription[description] = categoryID
+			}
+		}
+		if err := historyRows.Err(); err != nil {
+			return errors.Wrap(err, "processing category history rows failed")
+		}
+	}
+
+	for i := range rows {
+		if id, ok := byName[strings.ToLower(rows[i].Category)]; ok && rows[i].Category != "" {
+			rows[i].SuggestedCategoryID = id
+		} else if rows[i].SuggestedCategoryID == 0 {
+			rows[i].SuggestedCategoryID = byDescription[strings.ToLower(rows[i].Description)]
+		}
+	}
+	return nil
+}
+
+func (im *ImportBatchModel) markDuplicates(ctx context.Context, batch interfaces.ImportBatch, rows []interfaces.ImportRow, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	from, to := truncateToDate(rows[0].Date), truncateToDate(rows[0].Date)
+	for _, row := range rows {
+		day := truncateToDate(row.Date)
+		if day.Before(from) {
+			from = day
+		}
+		if day.After(to) {
+			to = day
+		}
+	}
+
+	query, args, err := GetQueryBuilder().Select("transaction_id", "timestamp", "amount", "type").
+		From("transactions").
+		Where(squirrel.Eq{"scope_id": batch.ScopeID, "source_id": batch.SourceID}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where(squirrel.GtOrEq{"timestamp": from}).
+		Where(squirrel.Lt{"timestamp": to.AddDate(0, 0, 1)}).
+		OrderBy("timestamp").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building duplicate lookup query failed")
+	}
+	existingRows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "querying existing transactions failed")
+	}
+	defer existingRows.Close()
+
+	existing := map[string][]int64{}
+	for existingRows.Next() {
+		var id int64
+		var timestamp time.Time
+		var amount interfaces.Money
+		var txnType string
+		if err := existingRows.Scan(&id, &timestamp, &amount, &txnType); err != nil {
+			return errors.Wrap(err, "scanning existing transaction failed")
+		}
+		key := duplicateKey(timestamp, amount, txnType)
+		existing[key] = append(existing[key], id)
+	}
+	if err := existingRows.Err(); err != nil {
+		return errors.Wrap(err, "processing existing transaction rows failed")
+	}
+
+	for i := range rows {
+		key := duplicateKey(rows[i].Date, rows[i].Amount, rows[i].Type)
+		if ids := existing[key]; len(ids) > 0 {
+			rows[i].Duplicate, rows[i].DuplicateOf = true, ids[0]
+			existing[key] = ids[1:]
+		}
+	}
+	return nil
+}
+
+func duplicateKey(day time.Time, amount interfaces.Money, txnType string) string {
+	return fmt.Sprintf("%s|%s|%s", day.Format("2006-01-02"), amount, strings.ToUpper(txnType))
+}
+
+// CommitImport previews rows again and inserts them through TransactionModel.InsertTransaction,
+// together with the batch record, in a single SQL transaction: either every row is imported or none.
+// Rows are imported as the preview left them, with the descriptions and tags set by the rules.
+// Rows without a suggested category get options.DefaultCategoryID; duplicates are left out when
+// options.SkipDuplicates is set. batch.ID, RowCount, Status and CreatedAt are filled in.
+func (im *ImportBatchModel) CommitImport(ctx context.Context, batch *interfaces.ImportBatch, rows []interfaces.ImportRow, options interfaces.ImportCommitOptions, otx ...*sql.Tx) error {
+	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, batch.UserID, batch.ScopeID, RoleWrite) {
+		return errors.New("Scope validating failed")
+	}
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		preview, err := im.PreviewImport(ctx, *batch, rows, tx)
+		if err != nil {
+			return err
+		}
+
+		transactions := make([]interfaces.Transaction, 0, len(preview))
+		for _, row := range preview {
+			if row.Duplicate && options.SkipDuplicates {
+				continue
+			}
+			categoryID := row.SuggestedCategoryID
+			if categoryID == 0 {
+				categoryID = options.DefaultCategoryID
+			}
+			if categoryID == 0 {
+				return errors.Wrapf(ErrInvalidImport, "line %d has no category, pass a default category", row.Line)
+			}
+			transactions = append(transactions, interfaces.Transaction{
+				UserID:      batch.UserID,
+				ScopeID:     batch.ScopeID,
+				SourceID:    batch.SourceID,
+				CategoryID:  categoryID,
+				Timestamp:   row.Date,
+				Amount:      row.Amount,
+				Type:        row.Type,
+				Description: row.Description,
+				Tags:        row.Tags,
+				// The rules were applied by the preview
+				RulesApplied: true,
+			})
+		}
+		if len(transactions) == 0 {
+			return errors.Wrap(ErrInvalidImport, "there are no new transactions to import")
+		}
+
+		batch.ID, err = util.GenerateSnowflakeID()
+		if err != nil {
+			return errors.Wrap(err, "generating Snowflake ID for import batch failed")
+		}
+		batch.Format = strings.ToUpper(batch.Format)
+		batch.RowCount = len(transactions)
+		batch.Status = ImportStatusCommitted
+		batch.CreatedAt = time.Now()
+		batch.UndoneAt = nil
+
+		query, args, err := GetQueryBuilder().Insert(im.TableImportBatches).
+			Columns(im.selectColumns()...).
+			Values(batch.ID, batch.UserID, batch.ScopeID, batch.SourceID, batch.Format, batch.FileName, batch.RowCount, batch.Status, batch.CreatedAt, nil).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building import batch insert query failed")
+		}
+		_, executor := getExecutor(tx)
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "inserting import batch failed")
+		}
+
+		for i, txn := range transactions {
+			txn.ImportBatchID = batch.ID
+			if err := GetModelsService().TransactionModel.InsertTransaction(ctx, txn, tx); err != nil {
+				return errors.Wrapf(err, "importing transaction %d of %d failed", i+1, len(transactions))
+			}
+		}
+		return nil
+	}, otx...)
+}
+
+// UndoImport deletes every transaction created by a committed batch, reverting source
+// balances through TransactionModel.DeleteTransaction, and marks the batch as undone.
+// The transactions are then purged with their tags, so they cannot be restored from the
+// trash one by one; splits, shares and search terms go with them through their foreign keys.
+func (im *ImportBatchModel) UndoImport(ctx context.Context, batchID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.ImportBatch, error) {
+	var batch *interfaces.ImportBatch
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		batch, err = im.getImportBatch(ctx, batchID, scopes, true, tx)
+		if err != nil {
+			return err
+		}
+		if batch.Status == ImportStatusUndone {
+			return ErrImportAlreadyUndone
+		}
+		_, executor := getExecutor(tx)
+
+		query, args, err := GetQueryBuilder().Select("transaction_id").
+			From("transactions").
+			Where(squirrel.Eq{"import_batch_id": batch.ID}).
+			Where(squirrel.Eq{"deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building import batch transactions query failed")
+		}
+		rows, err := executor.QueryContext(ctx, query, args...)
+		if err != nil {
+			return errors.Wrap(err, "querying import batch transactions failed")
+		}
+		transactionIDs := make([]int64, 0, batch.RowCount)
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return errors.Wrap(err, "scanning import batch transaction failed")
+			}
+			transactionIDs = append(transactionIDs, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(err, "processing import batch transaction rows failed")
+		}
+
+		for _, id := range transactionIDs {
+			if err := GetModelsService().TransactionModel.DeleteTransaction(ctx, id, []int64{batch.ScopeID}, tx); err != nil {
+				return errors.Wrapf(err, "deleting imported transaction %d failed", id)
+			}
+		}
+
+		batchTransactions := GetQueryBuilder().Select("transaction_id").
+			From("transactions").
+			Where(squirrel.Eq{"import_batch_id": batch.ID})
+		query, args, err = GetQueryBuilder().Delete("transaction_tags").
+			Where(squirrel.Expr("transaction_id IN (?)", batchTransactions)).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building import batch tags purge query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "purging import batch tags failed")
+		}
+		query, args, err = GetQueryBuilder().Delete("transactions").
+			Where(squirrel.Eq{"import_batch_id": batch.ID}).
+			Where(squirrel.NotEq{"deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building import batch transactions purge query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "purging import batch transactions failed")
+		}
+
+		now := time.Now()
+		query, args, err = GetQueryBuilder().Update(im.TableImportBatches).
+			Set(im.ColumnStatus, ImportStatusUndone).
+			Set(im.ColumnUndoneAt, now).
+			Where(squirrel.Eq{im.ColumnID: batch.ID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building import batch update query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "updating import batch failed")
+		}
+		batch.Status, batch.UndoneAt = ImportStatusUndone, &now
+		return nil
+	}, otx...)
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (im *ImportBatchModel) GetImportBatchByID(ctx context.Context, batchID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.ImportBatch, error) {
+	return im.getImportBatch(ctx, batchID, scopes, false, otx...)
+}
+
+func (im *ImportBatchModel) getImportBatch(ctx context.Context, batchID int64, scopes []int64, forUpdate bool, otx ...*sql.Tx) (*interfaces.ImportBatch, error) {
+	_, executor := getExecutor(otx...)
+
+	builder := GetQueryBuilder().Select(im.selectColumns()...).
+		From(im.TableImportBatches).
+		Where(squirrel.Eq{im.ColumnID: batchID, im.ColumnScope: scopes})
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building import batch select query failed")
+	}
+
+	batch := &interfaces.ImportBatch{}
+	if err := scanImportBatch(executor.QueryRowContext(ctx, query, args...), batch); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrImportNotFound
+		}
+		return nil, errors.Wrap(err, "querying import batch by ID failed")
+	}
+	return batch, nil
+}
+
+func (im *ImportBatchModel) GetImportBatchesByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.ImportBatch, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(im.selectColumns()...).
+		From(im.TableImportBatches).
+		Where(squirrel.Eq{im.ColumnScope: scopes}).
+		OrderBy(im.ColumnCreatedAt + " DESC").
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building import batch list query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying import batches failed")
+	}
+	defer rows.Close()
+
+	batches := make([]interfaces.ImportBatch, 0)
+	for rows.Next() {
+		var batch interfaces.ImportBatch
+		if err := scanImportBatch(rows, &batch); err != nil {
+			return nil, errors.Wrap(err, "scanning import batch failed")
+		}
+		batches = append(batches, batch)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing import batch rows failed")
+	}
+	return batches, nil
+}
@@ -0,0 +1,202 @@
+package impl
+
+import (
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+	"xspends/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type importTestMocks struct {
+	sql          sqlmock.Sqlmock
+	sources      *xmock.MockSourceModel
+	transactions *xmock.MockTransactionModel
+	userScopes   *xmock.MockUserScopeModel
+}
+
+func setUpImportTest(t *testing.T) importTestMocks {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	util.InitializeSnowflake()
+
+	mocks := importTestMocks{
+		sql:          sqlMock,
+		sources:      new(xmock.MockSourceModel),
+		transactions: new(xmock.MockTransactionModel),
+		userScopes:   new(xmock.MockUserScopeModel),
+	}
+	ModelsService = &ModelsServiceContainer{
+		DBService:        &DBService{Executor: db},
+		SourceModel:      mocks.sources,
+		TransactionModel: mocks.transactions,
+		UserScopeModel:   mocks.userScopes,
+		ImportBatchModel: NewImportBatchModel(),
+		RuleModel:        newNoRulesMock(),
+	}
+	return mocks
+}
+
+// expectPreviewQueries expects the category lookups and the duplicate lookup for rows dated 2024-01-31.
+// The scope has a "Housing" category (3), "coffee" was last categorized as 4, and source 2 already
+// holds a 4.50 coffee on that day.
+func expectPreviewQueries(sqlMock sqlmock.Sqlmock) {
+	sqlMock.ExpectQuery("^SELECT category_id, name FROM categories WHERE scope_id = \\?").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "name"}).AddRow(3, "Housing"))
+	sqlMock.ExpectQuery("^SELECT LOWER\\(description\\) AS normalized, category_id, COUNT\\(\\*\\) AS uses FROM transactions").
+		WithArgs("coffee", "rent", int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"normalized", "category_id", "uses"}).AddRow("coffee", 4, 7).AddRow("coffee", 5, 1))
+	sqlMock.ExpectQuery("^SELECT transaction_id, timestamp, amount, type FROM transactions WHERE scope_id = \\? AND source_id = \\?").
+		WithArgs(int64(10), int64(2), date(2024, 1, 31), date(2024, 2, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "timestamp", "amount", "type"}).
+			AddRow(99, time.Date(2024, 1, 31, 9, 15, 0, 0, time.UTC), 4.5, TransactionTypeExpense))
+}
+
+func importRows() []interfaces.ImportRow {
+	return []interfaces.ImportRow{
+		{Line: 2, Date: date(2024, 1, 31), Amount: interfaces.MoneyFromFloat(4.5), Type: TransactionTypeExpense, Description: "Coffee"},
+		{Line: 3, Date: date(2024, 1, 31), Amount: interfaces.MoneyFromFloat(4.5), Type: TransactionTypeExpense, Description: "Coffee"},
+		{Line: 4, Date: date(2024, 1, 31), Amount: interfaces.MoneyFromFloat(800), Type: TransactionTypeExpense, Description: "Rent", Category: "housing"},
+	}
+}
+
+func TestPreviewImport(t *testing.T) {
+	mocks := setUpImportTest(t)
+	batch := interfaces.ImportBatch{UserID: 1, ScopeID: 10, SourceID: 2}
+	mocks.sources.On("SourceIDExists", mock.Anything, int64(2), []int64{10}, mock.Anything).Return(true, nil)
+	expectPreviewQueries(mocks.sql)
+
+	preview, err := ModelsService.ImportBatchModel.PreviewImport(ctx, batch, importRows())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), preview[0].SuggestedCategoryID, "most used category for the description")
+	assert.True(t, preview[0].Duplicate)
+	assert.Equal(t, int64(99), preview[0].DuplicateOf)
+	assert.False(t, preview[1].Duplicate, "an existing transaction matches a single row")
+	assert.Equal(t, int64(3), preview[2].SuggestedCategoryID, "category named by the file")
+	assert.NoError(t, mocks.sql.ExpectationsWereMet())
+}
+
+func TestPreviewImportWithRules(t *testing.T) {
+	mocks := setUpImportTest(t)
+	batch := interfaces.ImportBatch{UserID: 1, ScopeID: 10, SourceID: 2}
+	mocks.sources.On("SourceIDExists", mock.Anything, int64(2), []int64{10}, mock.Anything).Return(true, nil)
+	mockRuleModel := new(xmock.MockRuleModel)
+	mockRuleModel.On("GetRulesByScope", mock.Anything, []int64{10}, mock.Anything).Return([]interfaces.Rule{
+		{ID: 7, Enabled: true, Conditions: interfaces.RuleConditions{DescriptionContains: "coffee"},
+			Actions: interfaces.RuleActions{SetCategoryID: 8, AddTags: []string{"cafe"}}},
+		{ID: 9, Enabled: true, Conditions: interfaces.RuleConditions{DescriptionContains: "rent"},
+			Actions: interfaces.RuleActions{SetCategoryID: 8}},
+	}, nil)
+	ModelsService.RuleModel = mockRuleModel
+	expectPreviewQueries(mocks.sql)
+
+	preview, err := ModelsService.ImportBatchModel.PreviewImport(ctx, batch, importRows())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8), preview[0].SuggestedCategoryID, "rules come before the category history")
+	assert.Equal(t, []string{"cafe"}, preview[0].Tags)
+	assert.Equal(t, []int64{7}, preview[0].MatchedRules)
+	assert.Equal(t, int64(3), preview[2].SuggestedCategoryID, "the category named by the file comes first")
+	assert.NoError(t, mocks.sql.ExpectationsWereMet())
+}
+
+func TestPreviewImportUnknownSource(t *testing.T) {
+	mocks := setUpImportTest(t)
+	mocks.sources.On("SourceIDExists", mock.Anything, int64(2), []int64{10}, mock.Anything).Return(false, nil)
+
+	_, err := ModelsService.ImportBatchModel.PreviewImport(ctx, interfaces.ImportBatch{ScopeID: 10, SourceID: 2}, importRows())
+	assert.ErrorIs(t, err, ErrInvalidImport)
+}
+
+func TestCommitImport(t *testing.T) {
+	mocks := setUpImportTest(t)
+	mocks.userScopes.On("ValidateUserScope", mock.Anything, int64(1), int64(10), RoleWrite, mock.Anything).Return(true)
+	mocks.sources.On("SourceIDExists", mock.Anything, int64(2), []int64{10}, mock.Anything).Return(true, nil)
+
+	mocks.sql.ExpectBegin()
+	expectPreviewQueries(mocks.sql)
+	mocks.sql.ExpectExec("^INSERT INTO import_batches").
+		WithArgs(sqlmock.AnyArg(), int64(1), int64(10), int64(2), "CSV", "january.csv", 2, ImportStatusCommitted, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mocks.transactions.On("InsertTransaction", mock.Anything, mock.MatchedBy(func(txn interfaces.Transaction) bool {
+		return txn.Description == "Coffee" && txn.CategoryID == 4 && txn.Timestamp.Equal(date(2024, 1, 31)) && txn.ImportBatchID != 0 && txn.RulesApplied
+	}), mock.Anything).Return(nil).Once()
+	mocks.transactions.On("InsertTransaction", mock.Anything, mock.MatchedBy(func(txn interfaces.Transaction) bool {
+		return txn.Description == "Rent" && txn.CategoryID == 3
+	}), mock.Anything).Return(nil).Once()
+	mocks.sql.ExpectCommit()
+
+	batch := &interfaces.ImportBatch{UserID: 1, ScopeID: 10, SourceID: 2, Format: "csv", FileName: "january.csv"}
+	err := ModelsService.ImportBatchModel.CommitImport(ctx, batch, importRows(), interfaces.ImportCommitOptions{SkipDuplicates: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, batch.RowCount, "the duplicate is skipped")
+	assert.Equal(t, ImportStatusCommitted, batch.Status)
+	mocks.transactions.AssertExpectations(t)
+	assert.NoError(t, mocks.sql.ExpectationsWereMet())
+}
+
+func TestCommitImportNeedsCategory(t *testing.T) {
+	mocks := setUpImportTest(t)
+	mocks.userScopes.On("ValidateUserScope", mock.Anything, int64(1), int64(10), RoleWrite, mock.Anything).Return(true)
+	mocks.sources.On("SourceIDExists", mock.Anything, int64(2), []int64{10}, mock.Anything).Return(true, nil)
+
+	mocks.sql.ExpectBegin()
+	expectPreviewQueries(mocks.sql)
+	mocks.sql.ExpectRollback()
+
+	rows := importRows()
+	rows[2].Category = "" // no history for "rent" either
+	batch := &interfaces.ImportBatch{UserID: 1, ScopeID: 10, SourceID: 2, Format: "CSV"}
+	err := ModelsService.ImportBatchModel.CommitImport(ctx, batch, rows, interfaces.ImportCommitOptions{SkipDuplicates: true})
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	assert.Contains(t, err.Error(), "line 4")
+	mocks.transactions.AssertNotCalled(t, "InsertTransaction", mock.Anything, mock.Anything, mock.Anything)
+	assert.NoError(t, mocks.sql.ExpectationsWereMet())
+}
+
+func TestUndoImport(t *testing.T) {
+	mocks := setUpImportTest(t)
+	batchColumns := []string{"batch_id", "user_id", "scope_id", "source_id", "format", "file_name", "row_count", "status", "created_at", "undone_at"}
+
+	mocks.sql.ExpectBegin()
+	mocks.sql.ExpectQuery("^SELECT (.+) FROM import_batches WHERE batch_id = \\? AND scope_id IN \\(\\?\\) FOR UPDATE").
+		WithArgs(int64(5), int64(10)).
+		WillReturnRows(sqlmock.NewRows(batchColumns).AddRow(5, 1, 10, 2, "CSV", "january.csv", 2, ImportStatusCommitted, time.Now(), nil))
+	mocks.sql.ExpectQuery("^SELECT transaction_id FROM transactions WHERE import_batch_id = \\?").
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}).AddRow(41).AddRow(42))
+	mocks.transactions.On("DeleteTransaction", mock.Anything, int64(41), []int64{10}, mock.Anything).Return(nil).Once()
+	mocks.transactions.On("DeleteTransaction", mock.Anything, int64(42), []int64{10}, mock.Anything).Return(nil).Once()
+	mocks.sql.ExpectExec("^DELETE FROM transaction_tags WHERE transaction_id IN \\(SELECT transaction_id FROM transactions WHERE import_batch_id = \\?\\)").
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mocks.sql.ExpectExec("^DELETE FROM transactions WHERE import_batch_id = \\? AND deleted_at IS NOT NULL").
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mocks.sql.ExpectExec("^UPDATE import_batches SET status = \\?, undone_at = \\? WHERE batch_id = \\?").
+		WithArgs(ImportStatusUndone, sqlmock.AnyArg(), int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mocks.sql.ExpectCommit()
+
+	batch, err := ModelsService.ImportBatchModel.UndoImport(ctx, 5, []int64{10})
+	assert.NoError(t, err)
+	assert.Equal(t, ImportStatusUndone, batch.Status)
+	assert.NotNil(t, batch.UndoneAt)
+	mocks.transactions.AssertExpectations(t)
+	assert.NoError(t, mocks.sql.ExpectationsWereMet())
+
+	mocks.sql.ExpectBegin()
+	mocks.sql.ExpectQuery("^SELECT (.+) FROM import_batches").
+		WillReturnRows(sqlmock.NewRows(batchColumns).AddRow(5, 1, 10, 2, "CSV", "january.csv", 2, ImportStatusUndone, time.Now(), time.Now()))
+	mocks.sql.ExpectRollback()
+
+	_, err = ModelsService.ImportBatchModel.UndoImport(ctx, 5, []int64{10})
+	assert.ErrorIs(t, err, ErrImportAlreadyUndone)
+}
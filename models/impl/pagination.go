@@ -0,0 +1,85 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"xspends/models/interfaces"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// applyCursor orders query by timeColumn and idColumn, descending when descending is set, and
+// keeps the rows after params.Cursor. A backward cursor reads the rows before it, in reverse
+// order, for pageByCursor to put back. One row more than the limit is read to tell whether the
+// list goes on.
+func applyCursor(query squirrel.SelectBuilder, timeColumn, idColumn string, descending bool, params interfaces.CursorParams) squirrel.SelectBuilder {
+	backward := params.Cursor != nil && params.Cursor.Backward
+	operator, order := ">", SortOrderAsc
+	if descending != backward {
+		operator, order = "<", SortOrderDesc
+	}
+
+	if params.Cursor != nil {
+		at := params.Cursor.Time
+		query = query.Where("("+timeColumn+" "+operator+" ? OR ("+timeColumn+" = ? AND "+idColumn+" "+operator+" ?))", at, at, params.Cursor.ID)
+	}
+	return query.OrderBy(timeColumn+" "+order, idColumn+" "+order).Limit(uint64(params.Limit + 1))
+}
+
+// pageByCursor trims the rows read with applyCursor to the page, in list order, and returns the
+// cursors of the pages around it. key gives the position of a row.
+func pageByCursor[T any](rows []T, key func(T) interfaces.Cursor, params interfaces.CursorParams) ([]T, interfaces.CursorPage) {
+	var page interfaces.CursorPage
+	backward := params.Cursor != nil && params.Cursor.Backward
+	more := len(rows) > params.Limit
+	if more {
+		rows = rows[:params.Limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, page
+	}
+
+	first, last := key(rows[0]), key(rows[len(rows)-1])
+	first.Backward = true
+	if backward {
+		page.Next = &last
+		if more {
+			page.Prev = &first
+		}
+	} else {
+		if more {
+			page.Next = &last
+		}
+		if params.Cursor != nil {
+			page.Prev = &first
+		}
+	}
+	return rows, page
+}
@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageByCursor(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	key := func(id int64) interfaces.Cursor { return interfaces.Cursor{Time: at, ID: id} }
+
+	t.Run("First page of a longer list", func(t *testing.T) {
+		rows, page := pageByCursor([]int64{1, 2, 3}, key, interfaces.CursorParams{Limit: 2})
+		assert.Equal(t, []int64{1, 2}, rows)
+		assert.Equal(t, &interfaces.Cursor{Time: at, ID: 2}, page.Next)
+		assert.Nil(t, page.Prev)
+	})
+
+	t.Run("Last page", func(t *testing.T) {
+		rows, page := pageByCursor([]int64{3}, key, interfaces.CursorParams{Cursor: &interfaces.Cursor{Time: at, ID: 2}, Limit: 2})
+		assert.Equal(t, []int64{3}, rows)
+		assert.Nil(t, page.Next)
+		assert.Equal(t, &interfaces.Cursor{Time: at, ID: 3, Backward: true}, page.Prev)
+	})
+
+	t.Run("Backward page is put back in list order", func(t *testing.T) {
+		cursor := &interfaces.Cursor{Time: at, ID: 4, Backward: true}
+		rows, page := pageByCursor([]int64{3, 2, 1}, key, interfaces.CursorParams{Cursor: cursor, Limit: 2})
+		assert.Equal(t, []int64{2, 3}, rows)
+		assert.Equal(t, &interfaces.Cursor{Time: at, ID: 3}, page.Next)
+		assert.Equal(t, &interfaces.Cursor{Time: at, ID: 2, Backward: true}, page.Prev)
+	})
+
+	t.Run("Empty page", func(t *testing.T) {
+		rows, page := pageByCursor([]int64{}, key, interfaces.CursorParams{Limit: 2})
+		assert.Empty(t, rows)
+		assert.Equal(t, interfaces.CursorPage{}, page)
+	})
+}
+
+func TestGetTagsByCursor(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TagModel = NewTagModel()
+	})
+	defer tearDown()
+	_, sqlMock := setupNewMock(t)
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"tag_id", "user_id", "name", "scope_id", "created_at", "updated_at"}
+	cursor := &interfaces.Cursor{Time: at, ID: 5, Backward: true}
+
+	sqlMock.ExpectQuery("^SELECT (.+) FROM tags WHERE scope_id IN \\(\\?\\) AND deleted_at IS NULL "+
+		"AND \\(created_at < \\? OR \\(created_at = \\? AND tag_id < \\?\\)\\) ORDER BY created_at DESC, tag_id DESC LIMIT 3").
+		WithArgs(int64(10), at, at, int64(5)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(4, 1, "travel", 10, at, at).
+			AddRow(3, 1, "food", 10, at, at))
+
+	tags, page, err := ModelsService.TagModel.GetTagsByCursor(ctx, []int64{10}, interfaces.CursorParams{Cursor: cursor, Limit: 2})
+	assert.NoError(t, err)
+	if assert.Len(t, tags, 2) {
+		assert.Equal(t, "food", tags[0].Name)
+		assert.Equal(t, "travel", tags[1].Name)
+	}
+	assert.Equal(t, &interfaces.Cursor{Time: at, ID: 4}, page.Next)
+	assert.Nil(t, page.Prev)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
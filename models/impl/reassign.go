@@ -0,0 +1,90 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidReassignTarget = errors.New("reassign_to must be another item of the same scope")
+
+// InUseError is returned when a category or source that is still in use is deleted without a
+// reassignment target. It reports how many transactions and recurring transactions use it and,
+// for a category, how many rules assign it and budgets track it.
+type InUseError struct {
+	Item                  string `json:"-"`
+	Transactions          int64  `json:"transactions"`
+	RecurringTransactions int64  `json:"recurring_transactions"`
+	Rules                 int64  `json:"rules"`
+	Budgets               int64  `json:"budgets"`
+}
+
+func (e *InUseError) Error() string {
+	if e.Rules == 0 && e.Budgets == 0 {
+		return fmt.Sprintf("%s is used by %d transactions and %d recurring transactions; pass reassign_to to move them",
+			e.Item, e.Transactions, e.RecurringTransactions)
+	}
+	return fmt.Sprintf("%s is used by %d transactions, %d recurring transactions, %d rules and %d budgets; pass reassign_to to move them",
+		e.Item, e.Transactions, e.RecurringTransactions, e.Rules, e.Budgets)
+}
+
+func (e *InUseError) inUse() bool {
+	return e.Transactions > 0 || e.RecurringTransactions > 0 || e.Rules > 0 || e.Budgets > 0
+}
+
+// countRows runs a SELECT COUNT query.
+func countRows(ctx context.Context, executor DBExecutor, builder squirrel.SelectBuilder) (int64, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "building count query failed")
+	}
+	var count int64
+	if err := executor.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, errors.Wrap(err, "counting rows failed")
+	}
+	return count, nil
+}
+
+// reassignColumn points every row of table whose column holds from at to instead.
+func reassignColumn(ctx context.Context, executor DBExecutor, table, column string, from, to int64, where squirrel.Sqlizer) error {
+	builder := GetQueryBuilder().Update(table).
+		Set(column, to).
+		Where(squirrel.Eq{column: from})
+	if where != nil {
+		builder = builder.Where(where)
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrapf(err, "building %s reassignment query failed", table)
+	}
+	if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrapf(err, "reassigning %s.%s failed", table, column)
+	}
+	return nil
+}
@@ -0,0 +1,555 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+	FrequencyYearly  = "YEARLY"
+
+	OccurrenceStatusCreated = "created"
+	OccurrenceStatusSkipped = "skipped"
+)
+
+var (
+	ErrRecurringNotFound  = errors.New("recurring transaction not found")
+	ErrInvalidRecurrence  = errors.New("invalid recurring transaction")
+	ErrRecurrenceFinished = errors.New("recurring transaction has no upcoming occurrence")
+)
+
+type RecurringTransactionModel struct {
+	TableRecurring       string
+	TableOccurrences     string
+	ColumnID             string
+	ColumnUserID         string
+	ColumnScope          string
+	ColumnSourceID       string
+	ColumnCategoryID     string
+	ColumnAmount         string
+	ColumnType           string
+	ColumnDescription    string
+	ColumnTags           string
+	ColumnFrequency      string
+	ColumnInterval       string
+	ColumnStartDate      string
+	ColumnEndDate        string
+	ColumnCount          string
+	ColumnOccurrences    string
+	ColumnNextOccurrence string
+	ColumnCreatedAt      string
+	ColumnUpdatedAt      string
+	ColumnOccurrenceDate string
+	ColumnStatus         string
+}
+
+func NewRecurringTransactionModel() *RecurringTransactionModel {
+	return &RecurringTransactionModel{
+		TableRecurring:       "recurring_transactions",
+		TableOccurrences:     "recurring_occurrences",
+		ColumnID:             "recurring_id",
+		ColumnUserID:         "user_id",
+		ColumnScope:          "scope_id",
+		ColumnSourceID:       "source_id",
+		ColumnCategoryID:     "category_id",
+		ColumnAmount:         "amount",
+		ColumnType:           "type",
+		ColumnDescription:    "description",
+		ColumnTags:           "tags",
+		ColumnFrequency:      "frequency",
+		ColumnInterval:       "interval_count",
+		ColumnStartDate:      "start_date",
+		ColumnEndDate:        "end_date",
+		ColumnCount:          "max_occurrences",
+		ColumnOccurrences:    "occurrences",
+		ColumnNextOccurrence: "next_occurrence",
+		ColumnCreatedAt:      "created_at",
+		ColumnUpdatedAt:      "updated_at",
+		ColumnOccurrenceDate: "occurrence_date",
+		ColumnStatus:         "status",
+	}
+}
+
+func (rm *RecurringTransactionModel) selectColumns() []string {
+	return []string{rm.ColumnID, rm.ColumnUserID, rm.ColumnScope, rm.ColumnSourceID, rm.ColumnCategoryID, rm.ColumnAmount, rm.ColumnType, rm.ColumnDescription, rm.ColumnTags,
+		rm.ColumnFrequency, rm.ColumnInterval, rm.ColumnStartDate, rm.ColumnEndDate, rm.ColumnCount, rm.ColumnOccurrences, rm.ColumnNextOccurrence, rm.ColumnCreatedAt, rm.ColumnUpdatedAt}
+}
+
+func scanRecurringTransaction(scanner interface{ Scan(...interface{}) error }, rt *interfaces.RecurringTransaction) error {
+	var tags sql.NullString
+	var endDate, nextOccurrence sql.NullTime
+	if err := scanner.Scan(&rt.ID, &rt.UserID, &rt.ScopeID, &rt.SourceID, &rt.CategoryID, &rt.Amount, &rt.Type, &rt.Description, &tags,
+		&rt.Frequency, &rt.Interval, &rt.StartDate, &endDate, &rt.Count, &rt.Occurrences, &nextOccurrence, &rt.CreatedAt, &rt.UpdatedAt); err != nil {
+		return err
+	}
+	if tags.Valid && tags.String != "" {
+		if err := json.Unmarshal([]byte(tags.String), &rt.Tags); err != nil {
+			return errors.Wrap(err, "decoding recurring transaction tags failed")
+		}
+	}
+	if endDate.Valid {
+		rt.EndDate = &endDate.Time
+	}
+	if nextOccurrence.Valid {
+		rt.NextOccurrence = &nextOccurrence.Time
+	}
+	return nil
+}
+
+// InsertRecurringTransaction validates and stores a new schedule. Nothing is materialized here;
+// the scheduler picks the schedule up once its first occurrence is due.
+func (rm *RecurringTransactionModel) InsertRecurringTransaction(ctx context.Context, rt *interfaces.RecurringTransaction, otx ...*sql.Tx) error {
+	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, rt.UserID, rt.ScopeID, RoleWrite) {
+		return errors.New("Scope validating failed")
+	}
+	if err := normalizeRecurrence(rt); err != nil {
+		return err
+	}
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		if err := validateForeignKeyReferences(ctx, recurringTemplate(*rt), tx); err != nil {
+			return errors.Wrap(err, "validating foreign key references failed")
+		}
+
+		id, err := util.GenerateSnowflakeID()
+		if err != nil {
+			return errors.Wrap(err, "generating Snowflake ID for recurring transaction failed")
+		}
+		tags, err := json.Marshal(rt.Tags)
+		if err != nil {
+			return errors.Wrap(err, "encoding recurring transaction tags failed")
+		}
+		rt.ID = id
+		rt.Occurrences = 0
+		rt.NextOccurrence = nextOccurrence(*rt)
+		rt.CreatedAt, rt.UpdatedAt = time.Now(), time.Now()
+
+		query, args, err := GetQueryBuilder().Insert(rm.TableRecurring).
+			Columns(rm.selectColumns()...).
+			Values(rt.ID, rt.UserID, rt.ScopeID, rt.SourceID, rt.CategoryID, rt.Amount, rt.Type, rt.Description, string(tags),
+				rt.Frequency, rt.Interval, rt.StartDate, rt.EndDate, rt.Count, rt.Occurrences, rt.NextOccurrence, rt.CreatedAt, rt.UpdatedAt).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building recurring transaction insert query failed")
+		}
+
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "inserting recurring transaction failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// UpdateRecurringTransaction replaces the template and schedule of an existing recurring transaction.
+// Occurrences already materialized or skipped are kept; the next occurrence is recomputed from them.
+func (rm *RecurringTransactionModel) UpdateRecurringTransaction(ctx context.Context, rt *interfaces.RecurringTransaction, otx ...*sql.Tx) error {
+	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, rt.UserID, rt.ScopeID, RoleWrite) {
+		return errors.New("Scope validating failed")
+	}
+	if err := normalizeRecurrence(rt); err != nil {
+		return err
+	}
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		if err := validateForeignKeyReferences(ctx, recurringTemplate(*rt), tx); err != nil {
+			return errors.Wrap(err, "validating foreign key references failed")
+		}
+
+		existing, err := rm.getRecurringTransaction(ctx, rt.ID, []int64{rt.ScopeID}, true, tx)
+		if err != nil {
+			return err
+		}
+		tags, err := json.Marshal(rt.Tags)
+		if err != nil {
+			return errors.Wrap(err, "encoding recurring transaction tags failed")
+		}
+		rt.Occurrences = existing.Occurrences
+		rt.NextOccurrence = nextOccurrence(*rt)
+		rt.CreatedAt, rt.UpdatedAt = existing.CreatedAt, time.Now()
+
+		query, args, err := GetQueryBuilder().Update(rm.TableRecurring).
+			Set(rm.ColumnSourceID, rt.SourceID).
+			Set(rm.ColumnCategoryID, rt.CategoryID).
+			Set(rm.ColumnAmount, rt.Amount).
+			Set(rm.ColumnType, rt.Type).
+			Set(rm.ColumnDescription, rt.Description).
+			Set(rm.ColumnTags, string(tags)).
+			Set(rm.ColumnFrequency, rt.Frequency).
+			Set(rm.ColumnInterval, rt.Interval).
+			Set(rm.ColumnStartDate, rt.StartDate).
+			Set(rm.ColumnEndDate, rt.EndDate).
+			Set(rm.ColumnCount, rt.Count).
+			Set(rm.ColumnNextOccurrence, rt.NextOccurrence).
+			Set(rm.ColumnUpdatedAt, rt.UpdatedAt).
+			Where(squirrel.Eq{rm.ColumnID: rt.ID, rm.ColumnScope: rt.ScopeID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building recurring transaction update query failed")
+		}
+
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "updating recurring transaction failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// DeleteRecurringTransaction removes a schedule and its occurrence log.
+// Transactions it already created are kept.
+func (rm *RecurringTransactionModel) DeleteRecurringTransaction(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		if _, err := rm.getRecurringTransaction(ctx, recurringID, scopes, true, tx); err != nil {
+			return err
+		}
+
+		query, args, err := GetQueryBuilder().Delete(rm.TableOccurrences).
+			Where(squirrel.Eq{rm.ColumnID: recurringID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building occurrence delete query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "deleting recurring occurrences failed")
+		}
+
+		query, args, err = GetQueryBuilder().Delete(rm.TableRecurring).
+			Where(squirrel.Eq{rm.ColumnID: recurringID, rm.ColumnScope: scopes}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building recurring transaction delete query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "deleting recurring transaction failed")
+		}
+		return nil
+	}, otx...)
+}
+
+func (rm *RecurringTransactionModel) GetRecurringTransactionByID(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.RecurringTransaction, error) {
+	return rm.getRecurringTransaction(ctx, recurringID, scopes, false, otx...)
+}
+
+// getRecurringTransaction fetches one schedule, optionally locking it for the rest of the transaction.
+// A nil scopes slice skips the scope check; only the scheduler uses that.
+func (rm *RecurringTransactionModel) getRecurringTransaction(ctx context.Context, recurringID int64, scopes []int64, forUpdate bool, otx ...*sql.Tx) (*interfaces.RecurringTransaction, error) {
+	_, executor := getExecutor(otx...)
+
+	where := squirrel.Eq{rm.ColumnID: recurringID}
+	if scopes != nil {
+		where[rm.ColumnScope] = scopes
+	}
+	builder := GetQueryBuilder().Select(rm.selectColumns()...).
+		From(rm.TableRecurring).
+		Where(where)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building recurring transaction select query failed")
+	}
+
+	rt := &interfaces.RecurringTransaction{}
+	if err := scanRecurringTransaction(executor.QueryRowContext(ctx, query, args...), rt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecurringNotFound
+		}
+		return nil, errors.Wrap(err, "querying recurring transaction by ID failed")
+	}
+	return rt, nil
+}
+
+func (rm *RecurringTransactionModel) GetRecurringTransactionsByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.RecurringTransaction, error) {
+	return rm.listRecurringTransactions(ctx, squirrel.Eq{rm.ColumnScope: scopes}, otx...)
+}
+
+// GetDueRecurringTransactions lists the schedules, across all scopes, whose next occurrence is on or before asOf.
+func (rm *RecurringTransactionModel) GetDueRecurringTransactions(ctx context.Context, asOf time.Time, otx ...*sql.Tx) ([]interfaces.RecurringTransaction, error) {
+	return rm.listRecurringTransactions(ctx, squirrel.And{
+		squirrel.NotEq{rm.ColumnNextOccurrence: nil},
+		squirrel.LtOrEq{rm.ColumnNextOccurrence: truncateToDate(asOf)},
+	}, otx...)
+}
+
+func (rm *RecurringTransactionModel) listRecurringTransactions(ctx context.Context, where squirrel.Sqlizer, otx ...*sql.Tx) ([]interfaces.RecurringTransaction, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(rm.selectColumns()...).
+		From(rm.TableRecurring).
+		Where(where).
+		OrderBy(rm.ColumnNextOccurrence).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building recurring transaction list query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying recurring transactions failed")
+	}
+	defer rows.Close()
+
+	recurring := make([]interfaces.RecurringTransaction, 0)
+	for rows.Next() {
+		var rt interfaces.RecurringTransaction
+		if err := scanRecurringTransaction(rows, &rt); err != nil {
+			return nil, errors.Wrap(err, "scanning recurring transaction failed")
+		}
+		recurring = append(recurring, rt)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing recurring transaction rows failed")
+	}
+	return recurring, nil
+}
+
+// SkipNextOccurrence marks the upcoming occurrence as skipped so the scheduler never creates it.
+func (rm *RecurringTransactionModel) SkipNextOccurrence(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.RecurringTransaction, error) {
+	var rt *interfaces.RecurringTransaction
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		rt, err = rm.getRecurringTransaction(ctx, recurringID, scopes, true, tx)
+		if err != nil {
+			return err
+		}
+		next := nextOccurrence(*rt)
+		if next == nil {
+			return ErrRecurrenceFinished
+		}
+		if _, err := rm.recordOccurrence(ctx, rt.ID, *next, OccurrenceStatusSkipped, tx); err != nil {
+			return err
+		}
+		rt.Occurrences++
+		return rm.saveProgress(ctx, rt, tx)
+	}, otx...)
+	if err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// MaterializeOccurrences creates a transaction, through TransactionModel.InsertTransaction, for every
+// occurrence due on or before asOf, dated on its occurrence, and returns how many were created.
+// The schedule row is locked and every occurrence is logged under a unique (recurring_id, occurrence_date)
+// key in the same SQL transaction, so running it again, concurrently or after a restart never duplicates
+// a transaction. Without catchUp only the latest due occurrence is created and earlier missed ones are
+// logged as skipped.
+func (rm *RecurringTransactionModel) MaterializeOccurrences(ctx context.Context, recurringID int64, asOf time.Time, catchUp bool, otx ...*sql.Tx) (int, error) {
+	created := 0
+	asOf = truncateToDate(asOf)
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		rt, err := rm.getRecurringTransaction(ctx, recurringID, nil, true, tx)
+		if err != nil {
+			return err
+		}
+
+		for next := nextOccurrence(*rt); next != nil && !next.After(asOf); next = nextOccurrence(*rt) {
+			rt.Occurrences++
+			following := nextOccurrence(*rt)
+			missed := !catchUp && following != nil && !following.After(asOf)
+
+			status := OccurrenceStatusCreated
+			if missed {
+				status = OccurrenceStatusSkipped
+			}
+			recorded, err := rm.recordOccurrence(ctx, rt.ID, *next, status, tx)
+			if err != nil {
+				return err
+			}
+			if !recorded || missed {
+				continue
+			}
+			occurrence := recurringTemplate(*rt)
+			occurrence.Timestamp = *next
+			if err := GetModelsService().TransactionModel.InsertTransaction(ctx, occurrence, tx); err != nil {
+				return errors.Wrap(err, "inserting recurring occurrence failed")
+			}
+			created++
+		}
+		return rm.saveProgress(ctx, rt, tx)
+	}, otx...)
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+// recordOccurrence logs an occurrence and reports whether it was new.
+func (rm *RecurringTransactionModel) recordOccurrence(ctx context.Context, recurringID int64, date time.Time, status string, otx ...*sql.Tx) (bool, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Insert(rm.TableOccurrences).
+		Options("IGNORE").
+		Columns(rm.ColumnID, rm.ColumnOccurrenceDate, rm.ColumnStatus, rm.ColumnCreatedAt).
+		Values(recurringID, date, status, time.Now()).
+		ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "building occurrence insert query failed")
+	}
+
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "recording recurring occurrence failed")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "reading recorded occurrence count failed")
+	}
+	return affected > 0, nil
+}
+
+func (rm *RecurringTransactionModel) saveProgress(ctx context.Context, rt *interfaces.RecurringTransaction, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	rt.NextOccurrence = nextOccurrence(*rt)
+	rt.UpdatedAt = time.Now()
+	query, args, err := GetQueryBuilder().Update(rm.TableRecurring).
+		Set(rm.ColumnOccurrences, rt.Occurrences).
+		Set(rm.ColumnNextOccurrence, rt.NextOccurrence).
+		Set(rm.ColumnUpdatedAt, rt.UpdatedAt).
+		Where(squirrel.Eq{rm.ColumnID: rt.ID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building recurring progress update query failed")
+	}
+
+	if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "updating recurring progress failed")
+	}
+	return nil
+}
+
+// normalizeRecurrence validates a schedule and fills in its defaults: an interval of 1 and a start date of today.
+func normalizeRecurrence(rt *interfaces.RecurringTransaction) error {
+	rt.Frequency = strings.ToUpper(rt.Frequency)
+	rt.Type = strings.ToUpper(rt.Type)
+	switch rt.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+	default:
+		return errors.Wrap(ErrInvalidRecurrence, "frequency must be DAILY, WEEKLY, MONTHLY or YEARLY")
+	}
+	if rt.Type != TransactionTypeIncome && rt.Type != TransactionTypeExpense {
+		return errors.Wrap(ErrInvalidRecurrence, "type must be INCOME or EXPENSE")
+	}
+	if rt.Amount <= 0 {
+		return errors.Wrap(ErrInvalidRecurrence, "amount must be positive")
+	}
+	if rt.Interval == 0 {
+		rt.Interval = 1
+	}
+	if rt.Interval < 0 || rt.Count < 0 {
+		return errors.Wrap(ErrInvalidRecurrence, "interval and count must not be negative")
+	}
+	if rt.StartDate.IsZero() {
+		rt.StartDate = time.Now()
+	}
+	rt.StartDate = truncateToDate(rt.StartDate)
+	if rt.EndDate != nil {
+		endDate := truncateToDate(*rt.EndDate)
+		rt.EndDate = &endDate
+	}
+	if rt.EndDate != nil && rt.EndDate.Before(rt.StartDate) {
+		return errors.Wrap(ErrInvalidRecurrence, "end date must not precede the start date")
+	}
+	return nil
+}
+
+// recurringTemplate is the transaction every occurrence of rt creates.
+func recurringTemplate(rt interfaces.RecurringTransaction) interfaces.Transaction {
+	return interfaces.Transaction{
+		UserID:      rt.UserID,
+		ScopeID:     rt.ScopeID,
+		SourceID:    rt.SourceID,
+		CategoryID:  rt.CategoryID,
+		Amount:      rt.Amount,
+		Type:        rt.Type,
+		Description: rt.Description,
+		Tags:        rt.Tags,
+	}
+}
+
+// nextOccurrence returns the date of the first occurrence not yet materialized or skipped,
+// or nil once the count or end date has been reached.
+func nextOccurrence(rt interfaces.RecurringTransaction) *time.Time {
+	if rt.Count > 0 && rt.Occurrences >= rt.Count {
+		return nil
+	}
+	date := occurrenceDate(rt, rt.Occurrences)
+	if rt.EndDate != nil && date.After(truncateToDate(*rt.EndDate)) {
+		return nil
+	}
+	return &date
+}
+
+// occurrenceDate returns the date of the n-th occurrence (from zero). Monthly and yearly schedules
+// are computed from the start date, so a schedule starting on the 31st falls on the last day of
+// shorter months without drifting.
+func occurrenceDate(rt interfaces.RecurringTransaction, n int) time.Time {
+	start := truncateToDate(rt.StartDate)
+	step := n * rt.Interval
+	switch rt.Frequency {
+	case FrequencyWeekly:
+		return start.AddDate(0, 0, 7*step)
+	case FrequencyMonthly:
+		return addMonthsClamped(start, step)
+	case FrequencyYearly:
+		return addMonthsClamped(start, 12*step)
+	}
+	return start.AddDate(0, 0, step)
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	day := t.Day()
+	if lastDay := first.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+func truncateToDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,191 @@
+package impl
+
+import (
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var recurringColumns = []string{"recurring_id", "user_id", "scope_id", "source_id", "category_id", "amount", "type", "description", "tags",
+	"frequency", "interval_count", "start_date", "end_date", "max_occurrences", "occurrences", "next_occurrence", "created_at", "updated_at"}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func setUpRecurringTest(t *testing.T) (sqlmock.Sqlmock, *xmock.MockTransactionModel) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mockTransactionModel := new(xmock.MockTransactionModel)
+	ModelsService = &ModelsServiceContainer{
+		DBService:                 &DBService{Executor: db},
+		TransactionModel:          mockTransactionModel,
+		RecurringTransactionModel: NewRecurringTransactionModel(),
+	}
+	return sqlMock, mockTransactionModel
+}
+
+// expectRecurringRow expects the locking select of a monthly rent schedule that started on 2024-01-31.
+func expectRecurringRow(sqlMock sqlmock.Sqlmock, occurrences int) {
+	sqlMock.ExpectQuery("^SELECT (.+) FROM recurring_transactions WHERE recurring_id = \\? FOR UPDATE").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(recurringColumns).AddRow(7, 1, 10, 2, 3, 1200.0, TransactionTypeExpense, "Rent", `["home"]`,
+			FrequencyMonthly, 1, date(2024, 1, 31), nil, 0, occurrences, date(2024, 1, 31), time.Now(), time.Now()))
+}
+
+func TestOccurrenceDate(t *testing.T) {
+	monthly := interfaces.RecurringTransaction{Frequency: FrequencyMonthly, Interval: 1, StartDate: date(2024, 1, 31)}
+	assert.Equal(t, date(2024, 1, 31), occurrenceDate(monthly, 0))
+	assert.Equal(t, date(2024, 2, 29), occurrenceDate(monthly, 1))
+	assert.Equal(t, date(2024, 3, 31), occurrenceDate(monthly, 2))
+
+	biweekly := interfaces.RecurringTransaction{Frequency: FrequencyWeekly, Interval: 2, StartDate: date(2024, 1, 1)}
+	assert.Equal(t, date(2024, 1, 29), occurrenceDate(biweekly, 2))
+
+	yearly := interfaces.RecurringTransaction{Frequency: FrequencyYearly, Interval: 1, StartDate: date(2024, 2, 29)}
+	assert.Equal(t, date(2025, 2, 28), occurrenceDate(yearly, 1))
+
+	daily := interfaces.RecurringTransaction{Frequency: FrequencyDaily, Interval: 3, StartDate: date(2024, 1, 1)}
+	assert.Equal(t, date(2024, 1, 7), occurrenceDate(daily, 2))
+}
+
+func TestNextOccurrence(t *testing.T) {
+	rt := interfaces.RecurringTransaction{Frequency: FrequencyDaily, Interval: 1, StartDate: date(2024, 1, 1), Count: 2}
+	assert.Equal(t, date(2024, 1, 2), *nextOccurrence(interfaces.RecurringTransaction{Frequency: rt.Frequency, Interval: 1, StartDate: rt.StartDate, Occurrences: 1}))
+
+	rt.Occurrences = 2
+	assert.Nil(t, nextOccurrence(rt), "count reached")
+
+	endDate := date(2024, 1, 2)
+	rt = interfaces.RecurringTransaction{Frequency: FrequencyDaily, Interval: 1, StartDate: date(2024, 1, 1), EndDate: &endDate, Occurrences: 2}
+	assert.Nil(t, nextOccurrence(rt), "end date passed")
+}
+
+func TestNormalizeRecurrence(t *testing.T) {
+	rt := interfaces.RecurringTransaction{Frequency: "monthly", Type: "expense", Amount: interfaces.MoneyFromFloat(10), StartDate: time.Date(2024, 1, 31, 15, 30, 0, 0, time.UTC)}
+	assert.NoError(t, normalizeRecurrence(&rt))
+	assert.Equal(t, FrequencyMonthly, rt.Frequency)
+	assert.Equal(t, TransactionTypeExpense, rt.Type)
+	assert.Equal(t, 1, rt.Interval)
+	assert.Equal(t, date(2024, 1, 31), rt.StartDate)
+
+	invalid := []interfaces.RecurringTransaction{
+		{Frequency: "HOURLY", Type: TransactionTypeExpense, Amount: interfaces.MoneyFromFloat(10)},
+		{Frequency: FrequencyDaily, Type: TransactionTypeTransfer, Amount: interfaces.MoneyFromFloat(10)},
+		{Frequency: FrequencyDaily, Type: TransactionTypeExpense, Amount: 0},
+		{Frequency: FrequencyDaily, Type: TransactionTypeExpense, Amount: interfaces.MoneyFromFloat(10), Interval: -1},
+	}
+	for _, rt := range invalid {
+		assert.ErrorIs(t, normalizeRecurrence(&rt), ErrInvalidRecurrence)
+	}
+}
+
+func TestMaterializeOccurrencesCatchUp(t *testing.T) {
+	sqlMock, mockTransactionModel := setUpRecurringTest(t)
+
+	sqlMock.ExpectBegin()
+	expectRecurringRow(sqlMock, 0)
+	for _, occurrence := range []time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31)} {
+		sqlMock.ExpectExec("^INSERT IGNORE INTO recurring_occurrences").
+			WithArgs(int64(7), occurrence, OccurrenceStatusCreated, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	sqlMock.ExpectExec("^UPDATE recurring_transactions SET occurrences = \\?, next_occurrence = \\?").
+		WithArgs(3, date(2024, 4, 30), sqlmock.AnyArg(), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	// Each transaction is dated on its occurrence, not on the day it was caught up
+	template := interfaces.Transaction{UserID: 1, ScopeID: 10, SourceID: 2, CategoryID: 3, Amount: interfaces.MoneyFromFloat(1200), Type: TransactionTypeExpense, Description: "Rent", Tags: []string{"home"}}
+	for _, occurrence := range []time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31)} {
+		template.Timestamp = occurrence
+		mockTransactionModel.On("InsertTransaction", mock.Anything, template, mock.Anything).Return(nil).Once()
+	}
+
+	created, err := ModelsService.RecurringTransactionModel.MaterializeOccurrences(ctx, 7, date(2024, 4, 15), true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, created)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	mockTransactionModel.AssertExpectations(t)
+}
+
+func TestMaterializeOccurrencesWithoutCatchUp(t *testing.T) {
+	sqlMock, mockTransactionModel := setUpRecurringTest(t)
+
+	sqlMock.ExpectBegin()
+	expectRecurringRow(sqlMock, 0)
+	// Missed occurrences are logged as skipped, only the latest one is created
+	sqlMock.ExpectExec("^INSERT IGNORE INTO recurring_occurrences").
+		WithArgs(int64(7), date(2024, 1, 31), OccurrenceStatusSkipped, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("^INSERT IGNORE INTO recurring_occurrences").
+		WithArgs(int64(7), date(2024, 2, 29), OccurrenceStatusSkipped, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("^INSERT IGNORE INTO recurring_occurrences").
+		WithArgs(int64(7), date(2024, 3, 31), OccurrenceStatusCreated, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("^UPDATE recurring_transactions").
+		WithArgs(3, date(2024, 4, 30), sqlmock.AnyArg(), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	mockTransactionModel.On("InsertTransaction", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	created, err := ModelsService.RecurringTransactionModel.MaterializeOccurrences(ctx, 7, date(2024, 4, 15), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	mockTransactionModel.AssertExpectations(t)
+}
+
+func TestMaterializeOccurrencesIsIdempotent(t *testing.T) {
+	sqlMock, mockTransactionModel := setUpRecurringTest(t)
+
+	sqlMock.ExpectBegin()
+	expectRecurringRow(sqlMock, 0)
+	// The occurrence was already logged by an earlier run, so no transaction is created again
+	sqlMock.ExpectExec("^INSERT IGNORE INTO recurring_occurrences").
+		WithArgs(int64(7), date(2024, 1, 31), OccurrenceStatusCreated, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("^UPDATE recurring_transactions").
+		WithArgs(1, date(2024, 2, 29), sqlmock.AnyArg(), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	created, err := ModelsService.RecurringTransactionModel.MaterializeOccurrences(ctx, 7, date(2024, 2, 1), true)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, created)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	mockTransactionModel.AssertNotCalled(t, "InsertTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSkipNextOccurrence(t *testing.T) {
+	sqlMock, _ := setUpRecurringTest(t)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("^SELECT (.+) FROM recurring_transactions WHERE recurring_id = \\? AND scope_id IN \\(\\?\\) FOR UPDATE").
+		WithArgs(int64(7), int64(10)).
+		WillReturnRows(sqlmock.NewRows(recurringColumns).AddRow(7, 1, 10, 2, 3, 1200.0, TransactionTypeExpense, "Rent", nil,
+			FrequencyMonthly, 1, date(2024, 1, 31), nil, 0, 1, date(2024, 2, 29), time.Now(), time.Now()))
+	sqlMock.ExpectExec("^INSERT IGNORE INTO recurring_occurrences").
+		WithArgs(int64(7), date(2024, 2, 29), OccurrenceStatusSkipped, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("^UPDATE recurring_transactions").
+		WithArgs(2, date(2024, 3, 31), sqlmock.AnyArg(), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	rt, err := ModelsService.RecurringTransactionModel.SkipNextOccurrence(ctx, 7, []int64{10})
+	assert.NoError(t, err)
+	assert.Equal(t, date(2024, 3, 31), *rt.NextOccurrence)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,522 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	MaxRuleNameLength    = 255
+	MaxRulePatternLength = 255
+)
+
+var (
+	ErrRuleNotFound = errors.New("rule not found")
+	ErrInvalidRule  = errors.New("invalid rule")
+)
+
+type RuleModel struct {
+	TableRules                string
+	ColumnID                  string
+	ColumnUserID              string
+	ColumnScope               string
+	ColumnName                string
+	ColumnPriority            string
+	ColumnEnabled             string
+	ColumnStopProcessing      string
+	ColumnDescriptionContains string
+	ColumnDescriptionRegex    string
+	ColumnMinAmount           string
+	ColumnMaxAmount           string
+	ColumnSourceID            string
+	ColumnType                string
+	ColumnSetCategoryID       string
+	ColumnAddTags             string
+	ColumnSetDescription      string
+	ColumnCreatedAt           string
+	ColumnUpdatedAt           string
+}
+
+func NewRuleModel() *RuleModel {
+	return &RuleModel{
+		TableRules:                "rules",
+		ColumnID:                  "rule_id",
+		ColumnUserID:              "user_id",
+		ColumnScope:               "scope_id",
+		ColumnName:                "name",
+		ColumnPriority:            "priority",
+		ColumnEnabled:             "enabled",
+		ColumnStopProcessing:      "stop_processing",
+		ColumnDescriptionContains: "description_contains",
+		ColumnDescriptionRegex:    "description_regex",
+		ColumnMinAmount:           "min_amount",
+		ColumnMaxAmount:           "max_amount",
+		ColumnSourceID:            "source_id",
+		ColumnType:                "type",
+		ColumnSetCategoryID:       "set_category_id",
+		ColumnAddTags:             "add_tags",
+		ColumnSetDescription:      "set_description",
+		ColumnCreatedAt:           "created_at",
+		ColumnUpdatedAt:           "updated_at",
+	}
+}
+
+func (rm *RuleModel) selectColumns() []string {
+	return []string{rm.ColumnID, rm.ColumnUserID, rm.ColumnScope, rm.ColumnName, rm.ColumnPriority, rm.ColumnEnabled, rm.ColumnStopProcessing,
+		rm.ColumnDescriptionContains, rm.ColumnDescriptionRegex, rm.ColumnMinAmount, rm.ColumnMaxAmount, rm.ColumnSourceID, rm.ColumnType,
+		rm.ColumnSetCategoryID, rm.ColumnAddTags, rm.ColumnSetDescription, rm.ColumnCreatedAt, rm.ColumnUpdatedAt}
+}
+
+func scanRule(scanner interface{ Scan(...interface{}) error }, rule *interfaces.Rule) error {
+	var sourceID, categoryID sql.NullInt64
+	var tags, description sql.NullString
+	conditions := &rule.Conditions
+	if err := scanner.Scan(&rule.ID, &rule.UserID, &rule.ScopeID, &rule.Name, &rule.Priority, &rule.Enabled, &rule.StopProcessing,
+		&conditions.DescriptionContains, &conditions.DescriptionRegex, &conditions.MinAmount, &conditions.MaxAmount, &sourceID, &conditions.Type,
+		&categoryID, &tags, &description, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		return err
+	}
+	conditions.SourceID = sourceID.Int64
+	rule.Actions.SetCategoryID = categoryID.Int64
+	rule.Actions.SetDescription = description.String
+	if tags.Valid && tags.String != "" {
+		if err := json.Unmarshal([]byte(tags.String), &rule.Actions.AddTags); err != nil {
+			return errors.Wrap(err, "decoding rule tags failed")
+		}
+	}
+	return nil
+}
+
+// InsertRule validates and stores a new rule for the rule's scope.
+func (rm *RuleModel) InsertRule(ctx context.Context, rule *interfaces.Rule, otx ...*sql.Tx) error {
+	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, rule.UserID, rule.ScopeID, RoleWrite) {
+		return errors.New("Scope validating failed")
+	}
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		if err := validateRule(ctx, rule, tx); err != nil {
+			return err
+		}
+		tags, err := json.Marshal(rule.Actions.AddTags)
+		if err != nil {
+			return errors.Wrap(err, "encoding rule tags failed")
+		}
+		rule.ID, err = util.GenerateSnowflakeID()
+		if err != nil {
+			return errors.Wrap(err, "generating Snowflake ID for rule failed")
+		}
+		rule.CreatedAt, rule.UpdatedAt = time.Now(), time.Now()
+
+		conditions, actions := rule.Conditions, rule.Actions
+		query, args, err := GetQueryBuilder().Insert(rm.TableRules).
+			Columns(rm.selectColumns()...).
+			Values(rule.ID, rule.UserID, rule.ScopeID, rule.Name, rule.Priority, rule.Enabled, rule.StopProcessing,
+				conditions.DescriptionContains, conditions.DescriptionRegex, conditions.MinAmount, conditions.MaxAmount,
+				sql.NullInt64{Int64: conditions.SourceID, Valid: conditions.SourceID > 0}, conditions.Type,
+				sql.NullInt64{Int64: actions.SetCategoryID, Valid: actions.SetCategoryID > 0}, string(tags), actions.SetDescription,
+				rule.CreatedAt, rule.UpdatedAt).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building rule insert query failed")
+		}
+
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "inserting rule failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// UpdateRule replaces the name, priority, settings, conditions and actions of a rule.
+func (rm *RuleModel) UpdateRule(ctx context.Context, rule *interfaces.Rule, otx ...*sql.Tx) error {
+	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, rule.UserID, rule.ScopeID, RoleWrite) {
+		return errors.New("Scope validating failed")
+	}
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		if err := validateRule(ctx, rule, tx); err != nil {
+			return err
+		}
+		tags, err := json.Marshal(rule.Actions.AddTags)
+		if err != nil {
+			return errors.Wrap(err, "encoding rule tags failed")
+		}
+		rule.UpdatedAt = time.Now()
+
+		conditions, actions := rule.Conditions, rule.Actions
+		query, args, err := GetQueryBuilder().Update(rm.TableRules).
+			Set(rm.ColumnName, rule.Name).
+			Set(rm.ColumnPriority, rule.Priority).
+			Set(rm.ColumnEnabled, rule.Enabled).
+			Set(rm.ColumnStopProcessing, rule.StopProcessing).
+			Set(rm.ColumnDescriptionContains, conditions.DescriptionContains).
+			Set(rm.ColumnDescriptionRegex, conditions.DescriptionRegex).
+			Set(rm.ColumnMinAmount, conditions.MinAmount).
+			Set(rm.ColumnMaxAmount, conditions.MaxAmount).
+			Set(rm.ColumnSourceID, sql.NullInt64{Int64: conditions.SourceID, Valid: conditions.SourceID > 0}).
+			Set(rm.ColumnType, conditions.Type).
+			Set(rm.ColumnSetCategoryID, sql.NullInt64{Int64: actions.SetCategoryID, Valid: actions.SetCategoryID > 0}).
+			Set(rm.ColumnAddTags, string(tags)).
+			Set(rm.ColumnSetDescription, actions.SetDescription).
+			Set(rm.ColumnUpdatedAt, rule.UpdatedAt).
+			Where(squirrel.Eq{rm.ColumnID: rule.ID, rm.ColumnScope: rule.ScopeID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building rule update query failed")
+		}
+
+		result, err := executor.ExecContext(ctx, query, args...)
+		if err != nil {
+			return errors.Wrap(err, "updating rule failed")
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrRuleNotFound
+		}
+		return nil
+	}, otx...)
+}
+
+// DeleteRule removes a rule. Transactions it already changed are kept as they are.
+func (rm *RuleModel) DeleteRule(ctx context.Context, ruleID int64, scopes []int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		query, args, err := GetQueryBuilder().Delete(rm.TableRules).
+			Where(squirrel.Eq{rm.ColumnID: ruleID, rm.ColumnScope: scopes}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building rule delete query failed")
+		}
+
+		result, err := executor.ExecContext(ctx, query, args...)
+		if err != nil {
+			return errors.Wrap(err, "deleting rule failed")
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrRuleNotFound
+		}
+		return nil
+	}, otx...)
+}
+
+func (rm *RuleModel) GetRuleByID(ctx context.Context, ruleID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Rule, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(rm.selectColumns()...).
+		From(rm.TableRules).
+		Where(squirrel.Eq{rm.ColumnID: ruleID, rm.ColumnScope: scopes}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building rule select query failed")
+	}
+
+	rule := &interfaces.Rule{}
+	if err := scanRule(executor.QueryRowContext(ctx, query, args...), rule); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRuleNotFound
+		}
+		return nil, errors.Wrap(err, "querying rule by ID failed")
+	}
+	return rule, nil
+}
+
+// GetRulesByScope returns the rules of the scopes in the order they are evaluated.
+func (rm *RuleModel) GetRulesByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.Rule, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(rm.selectColumns()...).
+		From(rm.TableRules).
+		Where(squirrel.Eq{rm.ColumnScope: scopes}).
+		OrderBy(rm.ColumnPriority, rm.ColumnCreatedAt, rm.ColumnID).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building rule list query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying rules failed")
+	}
+	defer rows.Close()
+
+	rules := make([]interfaces.Rule, 0)
+	for rows.Next() {
+		var rule interfaces.Rule
+		if err := scanRule(rows, &rule); err != nil {
+			return nil, errors.Wrap(err, "scanning rule failed")
+		}
+		rules = append(rules, rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing rule rows failed")
+	}
+	return rules, nil
+}
+
+// ApplyRules runs the enabled rules of the transaction's scope on txn before it is stored.
+// A category set by the caller is kept; rules only fill in a missing one.
+func (rm *RuleModel) ApplyRules(ctx context.Context, txn *interfaces.Transaction, otx ...*sql.Tx) error {
+	rules, err := rm.GetRulesByScope(ctx, []int64{txn.ScopeID}, otx...)
+	if err != nil {
+		return err
+	}
+	evaluateRules(rules, txn, true)
+	txn.RulesApplied = true
+	return nil
+}
+
+// TestRule previews which transactions matching filter the rule would change, without
+// writing anything. The rule is evaluated whether it is enabled or not, and may be unsaved.
+func (rm *RuleModel) TestRule(ctx context.Context, rule interfaces.Rule, filter interfaces.TransactionFilter, otx ...*sql.Tx) ([]interfaces.RuleMatch, error) {
+	if err := validateRule(ctx, &rule, otx...); err != nil {
+		return nil, err
+	}
+	matches, _, err := rm.matchTransactions(ctx, rule, filter, otx...)
+	return matches, err
+}
+
+// ApplyRule runs a rule on the existing transactions matching filter, replacing their category
+// where the rule sets one, in a single SQL transaction. Changes are made on behalf of filter.UserID.
+// Transfers are left out. The changed transactions are returned as by TestRule.
+func (rm *RuleModel) ApplyRule(ctx context.Context, rule interfaces.Rule, filter interfaces.TransactionFilter, otx ...*sql.Tx) ([]interfaces.RuleMatch, error) {
+	if err := validateRule(ctx, &rule, otx...); err != nil {
+		return nil, err
+	}
+
+	var matches []interfaces.RuleMatch
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		var changed []interfaces.Transaction
+		var err error
+		matches, changed, err = rm.matchTransactions(ctx, rule, filter, tx)
+		if err != nil {
+			return err
+		}
+		for _, txn := range changed {
+			txn.UserID = filter.UserID
+			if err := GetModelsService().TransactionModel.UpdateTransaction(ctx, txn, tx); err != nil {
+				return errors.Wrapf(err, "applying rule to transaction %d failed", txn.ID)
+			}
+		}
+		return nil
+	}, otx...)
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// matchTransactions evaluates rule against the transactions matching filter, overriding their
+// categories. It returns a match for each transaction the rule matches and the changed transactions.
+func (rm *RuleModel) matchTransactions(ctx context.Context, rule interfaces.Rule, filter interfaces.TransactionFilter, otx ...*sql.Tx) ([]interfaces.RuleMatch, []interfaces.Transaction, error) {
+	filter.ExcludeTransfers = true
+	transactions, err := GetModelsService().TransactionModel.GetTransactionsByFilter(ctx, filter, otx...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rule.Enabled, rule.StopProcessing = true, false
+	rules := []interfaces.Rule{rule}
+	matches := make([]interfaces.RuleMatch, 0)
+	changed := make([]interfaces.Transaction, 0)
+	for _, original := range transactions {
+		txn := original
+		txn.Tags = append([]string(nil), original.Tags...)
+		if len(evaluateRules(rules, &txn, false)) == 0 {
+			continue
+		}
+
+		match := interfaces.RuleMatch{
+			TransactionID: original.ID,
+			Timestamp:     original.Timestamp,
+			Amount:        original.Amount,
+			Description:   original.Description,
+			CategoryID:    original.CategoryID,
+		}
+		if len(txn.Tags) > len(original.Tags) {
+			match.AddedTags = txn.Tags[len(original.Tags):]
+		}
+		if txn.Description != original.Description {
+			match.NewDescription = txn.Description
+		}
+		if txn.CategoryID != original.CategoryID {
+			match.NewCategoryID = txn.CategoryID
+		}
+		matches = append(matches, match)
+		if match.NewDescription != "" || match.NewCategoryID != 0 || len(match.AddedTags) > 0 {
+			changed = append(changed, txn)
+		}
+	}
+	return matches, changed, nil
+}
+
+// evaluateRules applies the actions of the enabled rules matching txn, in the order given.
+// Conditions are checked against txn as the earlier rules left it. The first matching rule
+// that sets a category or a description wins; tags of all matching rules are added. With
+// keepCategory, a category already set on txn is not replaced. Split transactions keep
+// their categories on the lines. It returns the IDs of the matching rules.
+func evaluateRules(rules []interfaces.Rule, txn *interfaces.Transaction, keepCategory bool) []int64 {
+	matched := make([]int64, 0)
+	categorySet := keepCategory && txn.CategoryID > 0 || len(txn.Splits) > 0
+	descriptionSet := false
+	for _, rule := range rules {
+		if !rule.Enabled || !ruleMatches(rule.Conditions, *txn) {
+			continue
+		}
+		matched = append(matched, rule.ID)
+
+		actions := rule.Actions
+		if actions.SetCategoryID > 0 && !categorySet {
+			txn.CategoryID, categorySet = actions.SetCategoryID, true
+		}
+		if actions.SetDescription != "" && !descriptionSet {
+			txn.Description, descriptionSet = actions.SetDescription, true
+		}
+		for _, tag := range actions.AddTags {
+			if !containsFold(txn.Tags, tag) {
+				txn.Tags = append(txn.Tags, tag)
+			}
+		}
+		if rule.StopProcessing {
+			break
+		}
+	}
+	return matched
+}
+
+func ruleMatches(conditions interfaces.RuleConditions, txn interfaces.Transaction) bool {
+	if conditions.DescriptionContains != "" && !strings.Contains(strings.ToLower(txn.Description), strings.ToLower(conditions.DescriptionContains)) {
+		return false
+	}
+	if conditions.DescriptionRegex != "" {
+		pattern, err := regexp.Compile(conditions.DescriptionRegex)
+		if err != nil || !pattern.MatchString(txn.Description) {
+			return false
+		}
+	}
+	if conditions.MinAmount > 0 && txn.Amount < conditions.MinAmount {
+		return false
+	}
+	if conditions.MaxAmount > 0 && txn.Amount > conditions.MaxAmount {
+		return false
+	}
+	if conditions.SourceID > 0 && txn.SourceID != conditions.SourceID {
+		return false
+	}
+	if conditions.Type != "" && !strings.EqualFold(txn.Type, conditions.Type) {
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRule normalizes a rule and checks that it has at least one condition and one action,
+// that its pattern compiles and that the source and category it refers to exist in its scope.
+func validateRule(ctx context.Context, rule *interfaces.Rule, otx ...*sql.Tx) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" || len(rule.Name) > MaxRuleNameLength {
+		return errors.Wrapf(ErrInvalidRule, "name is required and must be at most %d characters", MaxRuleNameLength)
+	}
+	if rule.Priority < 0 {
+		return errors.Wrap(ErrInvalidRule, "priority must not be negative")
+	}
+
+	conditions, actions := &rule.Conditions, &rule.Actions
+	conditions.Type = strings.ToUpper(conditions.Type)
+	if conditions.Type != "" && conditions.Type != TransactionTypeIncome && conditions.Type != TransactionTypeExpense {
+		return errors.Wrap(ErrInvalidRule, "type must be INCOME or EXPENSE")
+	}
+	if len(conditions.DescriptionContains) > MaxRulePatternLength || len(conditions.DescriptionRegex) > MaxRulePatternLength {
+		return errors.Wrapf(ErrInvalidRule, "description patterns must be at most %d characters", MaxRulePatternLength)
+	}
+	if conditions.DescriptionRegex != "" {
+		if _, err := regexp.Compile(conditions.DescriptionRegex); err != nil {
+			return errors.Wrapf(ErrInvalidRule, "invalid description regex: %v", err)
+		}
+	}
+	if conditions.MinAmount < 0 || conditions.MaxAmount < 0 {
+		return errors.Wrap(ErrInvalidRule, "amounts must not be negative")
+	}
+	if conditions.MinAmount > 0 && conditions.MaxAmount > 0 && conditions.MinAmount > conditions.MaxAmount {
+		return errors.Wrap(ErrInvalidRule, "min amount must not exceed max amount")
+	}
+	if *conditions == (interfaces.RuleConditions{}) {
+		return errors.Wrap(ErrInvalidRule, "at least one condition is required")
+	}
+
+	tags := make([]string, 0, len(actions.AddTags))
+	for _, tag := range actions.AddTags {
+		if tag = strings.TrimSpace(tag); tag != "" && !containsFold(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	actions.AddTags = tags
+	if actions.SetCategoryID == 0 && len(actions.AddTags) == 0 && actions.SetDescription == "" {
+		return errors.Wrap(ErrInvalidRule, "at least one action is required")
+	}
+
+	if conditions.SourceID != 0 {
+		exists, err := GetModelsService().SourceModel.SourceIDExists(ctx, conditions.SourceID, []int64{rule.ScopeID}, otx...)
+		if err != nil {
+			return errors.Wrap(err, "error checking if source exists")
+		}
+		if !exists {
+			return errors.Wrap(ErrInvalidRule, "source does not exist")
+		}
+	}
+	if actions.SetCategoryID != 0 {
+		exists, err := GetModelsService().CategoryModel.CategoryIDExists(ctx, actions.SetCategoryID, []int64{rule.ScopeID}, otx...)
+		if err != nil {
+			return errors.Wrap(err, "error checking if category exists")
+		}
+		if !exists {
+			return errors.Wrap(ErrInvalidRule, "category does not exist")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package impl
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newNoRulesMock is a rule model for scopes without rules.
+func newNoRulesMock() *xmock.MockRuleModel {
+	mockRuleModel := new(xmock.MockRuleModel)
+	mockRuleModel.On("ApplyRules", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockRuleModel.On("GetRulesByScope", mock.Anything, mock.Anything, mock.Anything).Return([]interfaces.Rule{}, nil).Maybe()
+	return mockRuleModel
+}
+
+var ruleColumns = []string{"rule_id", "user_id", "scope_id", "name", "priority", "enabled", "stop_processing", "description_contains", "description_regex",
+	"min_amount", "max_amount", "source_id", "type", "set_category_id", "add_tags", "set_description", "created_at", "updated_at"}
+
+func TestEvaluateRules(t *testing.T) {
+	coffee := interfaces.Rule{ID: 1, Enabled: true,
+		Conditions: interfaces.RuleConditions{DescriptionContains: "coffee"},
+		Actions:    interfaces.RuleActions{SetCategoryID: 4, AddTags: []string{"cafe"}}}
+	amazon := interfaces.Rule{ID: 2, Enabled: true,
+		Conditions: interfaces.RuleConditions{DescriptionRegex: `^AMZN\s`, Type: "EXPENSE"},
+		Actions:    interfaces.RuleActions{SetDescription: "Amazon"}}
+	large := interfaces.Rule{ID: 3, Enabled: true,
+		Conditions: interfaces.RuleConditions{MinAmount: interfaces.MoneyFromFloat(100), MaxAmount: interfaces.MoneyFromFloat(500), SourceID: 2},
+		Actions:    interfaces.RuleActions{SetCategoryID: 5, AddTags: []string{"Large", "cafe"}}}
+
+	tests := []struct {
+		name         string
+		rules        []interfaces.Rule
+		txn          interfaces.Transaction
+		keepCategory bool
+		matched      []int64
+		expected     interfaces.Transaction
+	}{
+		{
+			name:     "Description substring is case-insensitive",
+			rules:    []interfaces.Rule{coffee},
+			txn:      interfaces.Transaction{Description: "Morning COFFEE"},
+			matched:  []int64{1},
+			expected: interfaces.Transaction{Description: "Morning COFFEE", CategoryID: 4, Tags: []string{"cafe"}},
+		},
+		{
+			name:     "Regex and type",
+			rules:    []interfaces.Rule{amazon},
+			txn:      interfaces.Transaction{Description: "AMZN Mktp 123", Type: "expense"},
+			matched:  []int64{2},
+			expected: interfaces.Transaction{Description: "Amazon", Type: "expense"},
+		},
+		{
+			name:     "Type does not match",
+			rules:    []interfaces.Rule{amazon},
+			txn:      interfaces.Transaction{Description: "AMZN Refund", Type: "INCOME"},
+			matched:  []int64{},
+			expected: interfaces.Transaction{Description: "AMZN Refund", Type: "INCOME"},
+		},
+		{
+			name:     "Amount out of range",
+			rules:    []interfaces.Rule{large},
+			txn:      interfaces.Transaction{SourceID: 2, Amount: interfaces.MoneyFromFloat(600)},
+			matched:  []int64{},
+			expected: interfaces.Transaction{SourceID: 2, Amount: interfaces.MoneyFromFloat(600)},
+		},
+		{
+			name:     "First category wins and tags add up once",
+			rules:    []interfaces.Rule{coffee, large},
+			txn:      interfaces.Transaction{Description: "Coffee machine", SourceID: 2, Amount: interfaces.MoneyFromFloat(250), Tags: []string{"home"}},
+			matched:  []int64{1, 3},
+			expected: interfaces.Transaction{Description: "Coffee machine", SourceID: 2, Amount: interfaces.MoneyFromFloat(250), CategoryID: 4, Tags: []string{"home", "cafe", "Large"}},
+		},
+		{
+			name:     "Stop processing",
+			rules:    []interfaces.Rule{{ID: 1, Enabled: true, StopProcessing: true, Conditions: coffee.Conditions, Actions: coffee.Actions}, large},
+			txn:      interfaces.Transaction{Description: "Coffee machine", SourceID: 2, Amount: interfaces.MoneyFromFloat(250)},
+			matched:  []int64{1},
+			expected: interfaces.Transaction{Description: "Coffee machine", SourceID: 2, Amount: interfaces.MoneyFromFloat(250), CategoryID: 4, Tags: []string{"cafe"}},
+		},
+		{
+			name:         "Category set by the user is kept",
+			rules:        []interfaces.Rule{coffee},
+			txn:          interfaces.Transaction{Description: "Coffee", CategoryID: 9},
+			keepCategory: true,
+			matched:      []int64{1},
+			expected:     interfaces.Transaction{Description: "Coffee", CategoryID: 9, Tags: []string{"cafe"}},
+		},
+		{
+			name:     "Later rules see the new description",
+			rules:    []interfaces.Rule{amazon, {ID: 4, Enabled: true, Conditions: interfaces.RuleConditions{DescriptionContains: "amazon"}, Actions: interfaces.RuleActions{SetCategoryID: 6}}},
+			txn:      interfaces.Transaction{Description: "AMZN Mktp 123", Type: "EXPENSE"},
+			matched:  []int64{2, 4},
+			expected: interfaces.Transaction{Description: "Amazon", Type: "EXPENSE", CategoryID: 6},
+		},
+		{
+			name:     "Disabled rule",
+			rules:    []interfaces.Rule{{ID: 1, Conditions: coffee.Conditions, Actions: coffee.Actions}},
+			txn:      interfaces.Transaction{Description: "Coffee"},
+			matched:  []int64{},
+			expected: interfaces.Transaction{Description: "Coffee"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			txn := tc.txn
+			assert.Equal(t, tc.matched, evaluateRules(tc.rules, &txn, tc.keepCategory))
+			assert.Equal(t, tc.expected, txn)
+		})
+	}
+}
+
+func TestInsertRule(t *testing.T) {
+	mockCategoryModel := new(xmock.MockCategoryModel)
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.RuleModel = NewRuleModel()
+		config.CategoryModel = mockCategoryModel
+		config.UserScopeModel = newValidatingUserScopeMock()
+	})
+	defer tearDown()
+
+	t.Run("Success", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		mockCategoryModel.On("CategoryIDExists", mock.Anything, int64(4), []int64{10}, mock.Anything).Return(true, nil).Once()
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec("^INSERT INTO rules").
+			WithArgs(sqlmock.AnyArg(), int64(1), int64(10), "Coffee", 0, true, false, "coffee", "", interfaces.Money(0), interfaces.Money(0),
+				sql.NullInt64{}, "EXPENSE", sql.NullInt64{Int64: 4, Valid: true}, `["cafe"]`, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		rule := &interfaces.Rule{UserID: 1, ScopeID: 10, Name: " Coffee ", Enabled: true,
+			Conditions: interfaces.RuleConditions{DescriptionContains: "coffee", Type: "expense"},
+			Actions:    interfaces.RuleActions{SetCategoryID: 4, AddTags: []string{"cafe", " Cafe", ""}}}
+		assert.NoError(t, ModelsService.RuleModel.InsertRule(ctx, rule))
+		assert.NotZero(t, rule.ID)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	tests := []struct {
+		name string
+		rule interfaces.Rule
+	}{
+		{"No condition", interfaces.Rule{Name: "All", Actions: interfaces.RuleActions{AddTags: []string{"x"}}}},
+		{"No action", interfaces.Rule{Name: "Nothing", Conditions: interfaces.RuleConditions{DescriptionContains: "x"}}},
+		{"Invalid regex", interfaces.Rule{Name: "Broken", Conditions: interfaces.RuleConditions{DescriptionRegex: "(unclosed"}, Actions: interfaces.RuleActions{AddTags: []string{"x"}}}},
+		{"Inverted amounts", interfaces.Rule{Name: "Range", Conditions: interfaces.RuleConditions{MinAmount: 500, MaxAmount: 100}, Actions: interfaces.RuleActions{AddTags: []string{"x"}}}},
+		{"Transfer type", interfaces.Rule{Name: "Moves", Conditions: interfaces.RuleConditions{Type: "transfer"}, Actions: interfaces.RuleActions{AddTags: []string{"x"}}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, sqlMock := setupNewMock(t)
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectRollback()
+
+			rule := tc.rule
+			rule.UserID, rule.ScopeID = 1, 10
+			err := ModelsService.RuleModel.InsertRule(ctx, &rule)
+			assert.Equal(t, ErrInvalidRule, errors.Cause(err))
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.RuleModel = NewRuleModel()
+	})
+	defer tearDown()
+	_, sqlMock := setupNewMock(t)
+
+	now := time.Now()
+	sqlMock.ExpectQuery("^SELECT (.+) FROM rules WHERE scope_id IN \\(\\?\\) ORDER BY priority, created_at, rule_id").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows(ruleColumns).
+			AddRow(1, 1, 10, "Salary", 0, true, false, "acme", "", 0, 0, nil, "INCOME", 7, `["work"]`, "", now, now).
+			AddRow(2, 1, 10, "Disabled", 1, false, false, "acme", "", 0, 0, nil, "", 8, nil, "", now, now))
+
+	txn := interfaces.Transaction{ScopeID: 10, Type: "INCOME", Description: "ACME payroll"}
+	assert.NoError(t, ModelsService.RuleModel.ApplyRules(ctx, &txn))
+	assert.Equal(t, int64(7), txn.CategoryID)
+	assert.Equal(t, []string{"work"}, txn.Tags)
+	assert.True(t, txn.RulesApplied)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestApplyRule(t *testing.T) {
+	mockTransactionModel := new(xmock.MockTransactionModel)
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.RuleModel = NewRuleModel()
+		config.TransactionModel = mockTransactionModel
+	})
+	defer tearDown()
+
+	rule := interfaces.Rule{ID: 3, UserID: 1, ScopeID: 10, Name: "Rent",
+		Conditions: interfaces.RuleConditions{DescriptionContains: "rent"},
+		Actions:    interfaces.RuleActions{AddTags: []string{"home"}, SetDescription: "Rent"}}
+	filter := interfaces.TransactionFilter{UserID: 2, Scopes: []int64{10}, StartDate: "2024-01-01"}
+	transactions := []interfaces.Transaction{
+		{ID: 1, UserID: 1, ScopeID: 10, CategoryID: 3, Description: "RENT JAN", Tags: []string{"fixed"}},
+		{ID: 2, UserID: 1, ScopeID: 10, CategoryID: 4, Description: "Groceries"},
+		{ID: 3, UserID: 1, ScopeID: 10, CategoryID: 3, Description: "Rent", Tags: []string{"home"}},
+	}
+	expectedFilter := filter
+	expectedFilter.ExcludeTransfers = true
+
+	t.Run("Test changes nothing", func(t *testing.T) {
+		mockTransactionModel.On("GetTransactionsByFilter", mock.Anything, expectedFilter, mock.Anything).Return(transactions, nil).Once()
+
+		matches, err := ModelsService.RuleModel.TestRule(ctx, rule, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, []interfaces.RuleMatch{
+			{TransactionID: 1, Description: "RENT JAN", CategoryID: 3, NewDescription: "Rent", AddedTags: []string{"home"}},
+			{TransactionID: 3, Description: "Rent", CategoryID: 3},
+		}, matches)
+		mockTransactionModel.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Apply updates the changed transactions", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectCommit()
+		mockTransactionModel.On("GetTransactionsByFilter", mock.Anything, expectedFilter, mock.Anything).Return(transactions, nil).Once()
+		mockTransactionModel.On("UpdateTransaction", mock.Anything, interfaces.Transaction{
+			ID: 1, UserID: 2, ScopeID: 10, CategoryID: 3, Description: "Rent", Tags: []string{"fixed", "home"},
+		}, mock.Anything).Return(nil).Once()
+
+		matches, err := ModelsService.RuleModel.ApplyRule(ctx, rule, filter)
+		assert.NoError(t, err)
+		assert.Len(t, matches, 2)
+		assert.Equal(t, []string{"fixed"}, transactions[0].Tags, "the listed transactions are left alone")
+		mockTransactionModel.AssertExpectations(t)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back when an update fails", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectRollback()
+		mockTransactionModel.On("GetTransactionsByFilter", mock.Anything, expectedFilter, mock.Anything).Return(transactions, nil).Once()
+		mockTransactionModel.On("UpdateTransaction", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db error")).Once()
+
+		_, err := ModelsService.RuleModel.ApplyRule(ctx, rule, filter)
+		assert.Error(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
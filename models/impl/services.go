@@ -23,30 +23,54 @@ var (
 )
 
 type ModelsServiceContainer struct {
-	DBService           *DBService
-	CategoryModel       interfaces.CategoryService
-	SourceModel         interfaces.SourceService
-	UserModel           interfaces.UserService
-	TagModel            interfaces.TagService
-	TransactionTagModel interfaces.TransactionTagService
-	TransactionModel    interfaces.TransactionService
-	ScopeModel          interfaces.ScopeService
-	GroupModel          interfaces.GroupService
-	UserScopeModel      interfaces.UserScopeService
+	DBService                 *DBService
+	CategoryModel             interfaces.CategoryService
+	SourceModel               interfaces.SourceService
+	UserModel                 interfaces.UserService
+	TagModel                  interfaces.TagService
+	TransactionTagModel       interfaces.TransactionTagService
+	TransactionModel          interfaces.TransactionService
+	ScopeModel                interfaces.ScopeService
+	GroupModel                interfaces.GroupService
+	UserScopeModel            interfaces.UserScopeService
+	GroupInvitationModel      interfaces.GroupInvitationService
+	RecurringTransactionModel interfaces.RecurringTransactionService
+	BudgetModel               interfaces.BudgetService
+	ImportBatchModel          interfaces.ImportBatchService
+	ArchiveModel              interfaces.ArchiveService
+	CurrencyModel             interfaces.CurrencyService
+	TransactionSplitModel     interfaces.TransactionSplitService
+	SharedExpenseModel        interfaces.SharedExpenseService
+	TrashModel                interfaces.TrashService
+	TemplateModel             interfaces.TemplateService
+	RuleModel                 interfaces.RuleService
+	TransactionSearchModel    interfaces.TransactionSearchService
 }
 
 // ModelsConfig struct to group all the dependencies
 type ModelsConfig struct {
-	DBService           *DBService
-	CategoryModel       interfaces.CategoryService
-	SourceModel         interfaces.SourceService
-	UserModel           interfaces.UserService
-	TagModel            interfaces.TagService
-	TransactionTagModel interfaces.TransactionTagService
-	TransactionModel    interfaces.TransactionService
-	ScopeModel          interfaces.ScopeService
-	GroupModel          interfaces.GroupService
-	UserScopeModel      interfaces.UserScopeService
+	DBService                 *DBService
+	CategoryModel             interfaces.CategoryService
+	SourceModel               interfaces.SourceService
+	UserModel                 interfaces.UserService
+	TagModel                  interfaces.TagService
+	TransactionTagModel       interfaces.TransactionTagService
+	TransactionModel          interfaces.TransactionService
+	ScopeModel                interfaces.ScopeService
+	GroupModel                interfaces.GroupService
+	UserScopeModel            interfaces.UserScopeService
+	GroupInvitationModel      interfaces.GroupInvitationService
+	RecurringTransactionModel interfaces.RecurringTransactionService
+	BudgetModel               interfaces.BudgetService
+	ImportBatchModel          interfaces.ImportBatchService
+	ArchiveModel              interfaces.ArchiveService
+	CurrencyModel             interfaces.CurrencyService
+	TransactionSplitModel     interfaces.TransactionSplitService
+	SharedExpenseModel        interfaces.SharedExpenseService
+	TrashModel                interfaces.TrashService
+	TemplateModel             interfaces.TemplateService
+	RuleModel                 interfaces.RuleService
+	TransactionSearchModel    interfaces.TransactionSearchService
 }
 
 var isTesting bool
@@ -64,16 +88,28 @@ func InitModelsService(config *ModelsConfig) {
 
 func initializeModelsService(config *ModelsConfig) {
 	ModelsService = &ModelsServiceContainer{
-		DBService:           config.DBService,
-		CategoryModel:       config.CategoryModel,
-		SourceModel:         config.SourceModel,
-		UserModel:           config.UserModel,
-		TagModel:            config.TagModel,
-		TransactionTagModel: config.TransactionTagModel,
-		TransactionModel:    config.TransactionModel,
-		ScopeModel:          config.ScopeModel,
-		GroupModel:          config.GroupModel,
-		UserScopeModel:      config.UserScopeModel,
+		DBService:                 config.DBService,
+		CategoryModel:             config.CategoryModel,
+		SourceModel:               config.SourceModel,
+		UserModel:                 config.UserModel,
+		TagModel:                  config.TagModel,
+		TransactionTagModel:       config.TransactionTagModel,
+		TransactionModel:          config.TransactionModel,
+		ScopeModel:                config.ScopeModel,
+		GroupModel:                config.GroupModel,
+		UserScopeModel:            config.UserScopeModel,
+		GroupInvitationModel:      config.GroupInvitationModel,
+		RecurringTransactionModel: config.RecurringTransactionModel,
+		BudgetModel:               config.BudgetModel,
+		ImportBatchModel:          config.ImportBatchModel,
+		ArchiveModel:              config.ArchiveModel,
+		CurrencyModel:             config.CurrencyModel,
+		TransactionSplitModel:     config.TransactionSplitModel,
+		SharedExpenseModel:        config.SharedExpenseModel,
+		TrashModel:                config.TrashModel,
+		TemplateModel:             config.TemplateModel,
+		RuleModel:                 config.RuleModel,
+		TransactionSearchModel:    config.TransactionSearchModel,
 	}
 }
 
@@ -27,6 +27,7 @@ package impl
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 	"xspends/kvstore"
@@ -99,4 +100,66 @@ func (s *SessionStorer) Delete(ctx context.Context, sid string) error {
 	return s.kvClient.Delete(ctx, []byte(sid))
 }
 
-// Add any other methods necessary for session management here...
+// sessionScanLimit is how many of a user's sessions RevokeUserSessions reads at a time.
+const sessionScanLimit = 100
+
+// userSessionsPrefix starts the keys that list a user's sessions, one key per session
+// holding its ID, so that they can be found and revoked together.
+func userSessionsPrefix(userID int64) string {
+	return "user_sessions/" + strconv.FormatInt(userID, 10) + "/"
+}
+
+// SaveUserSession saves a session like Save and lists it among the user's sessions.
+func (s *SessionStorer) SaveUserSession(ctx context.Context, userID int64, sid string, state string, ttl time.Duration) error {
+	if err := s.Save(ctx, sid, state, ttl); err != nil {
+		return err
+	}
+	return s.kvClient.Put(ctx, []byte(userSessionsPrefix(userID)+sid), []byte(sid))
+}
+
+// DeleteUserSession removes a session and its entry among the user's sessions.
+func (s *SessionStorer) DeleteUserSession(ctx context.Context, userID int64, sid string) error {
+	if err := s.Delete(ctx, sid); err != nil {
+		return err
+	}
+	return s.kvClient.Delete(ctx, []byte(userSessionsPrefix(userID)+sid))
+}
+
+// RevokeUserSessions removes every session of the user but keepSID, which may be empty,
+// and returns how many it removed. Entries of sessions that are already gone are dropped
+// without being counted.
+func (s *SessionStorer) RevokeUserSessions(ctx context.Context, userID int64, keepSID string) (int, error) {
+	prefix := userSessionsPrefix(userID)
+	// The prefix ends in "/", so every key listing the user's sessions sorts before the same prefix ending in "0"
+	start, end := []byte(prefix), []byte(strings.TrimSuffix(prefix, "/")+"0")
+	revoked := 0
+	for {
+		keys, sids, err := s.kvClient.Scan(ctx, start, end, sessionScanLimit)
+		if err != nil {
+			return revoked, errors.Wrap(err, "listing the user's sessions failed")
+		}
+		for _, sid := range sids {
+			if string(sid) == keepSID {
+				continue
+			}
+			state, err := s.Load(ctx, string(sid))
+			if err != nil {
+				return revoked, errors.Wrap(err, "loading a session failed")
+			}
+			if state == "" {
+				if err := s.kvClient.Delete(ctx, []byte(prefix+string(sid))); err != nil {
+					return revoked, errors.Wrap(err, "dropping an ended session failed")
+				}
+				continue
+			}
+			if err := s.DeleteUserSession(ctx, userID, string(sid)); err != nil {
+				return revoked, errors.Wrap(err, "revoking a session failed")
+			}
+			revoked++
+		}
+		if len(keys) < sessionScanLimit {
+			return revoked, nil
+		}
+		start = append(append([]byte{}, keys[len(keys)-1]...), 0)
+	}
+}
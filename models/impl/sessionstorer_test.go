@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -67,5 +68,62 @@ func TestSessionStorer(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("SaveUserSession", func(t *testing.T) {
+		ctx := context.Background()
+		mockKVClient.EXPECT().Put(ctx, []byte("7"), []byte("refresh-token")).Return(nil)
+		mockKVClient.EXPECT().Put(ctx, []byte("user_sessions/1/7"), []byte("7")).Return(nil)
+
+		err := sessionStorer.SaveUserSession(ctx, 1, "7", "refresh-token", 24*time.Hour)
+		assert.NoError(t, err)
+	})
+
+	t.Run("DeleteUserSession", func(t *testing.T) {
+		ctx := context.Background()
+		mockKVClient.EXPECT().Delete(ctx, []byte("7")).Return(nil)
+		mockKVClient.EXPECT().Delete(ctx, []byte("user_sessions/1/7")).Return(nil)
+
+		err := sessionStorer.DeleteUserSession(ctx, 1, "7")
+		assert.NoError(t, err)
+	})
+
 	// Add additional test cases as needed...
 }
+
+func TestRevokeUserSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockKVClient := mock.NewMockRawKVClientInterface(ctrl)
+	sessionStorer := NewSessionStorer(mockKVClient)
+	ctx := context.Background()
+
+	// A full page is followed by a scan from just after its last key
+	keys, sids := make([][]byte, sessionScanLimit), make([][]byte, sessionScanLimit)
+	for i := range keys {
+		sid := strconv.Itoa(1000 + i)
+		keys[i], sids[i] = []byte("user_sessions/1/"+sid), []byte(sid)
+	}
+	mockKVClient.EXPECT().Scan(ctx, []byte("user_sessions/1/"), []byte("user_sessions/10"), sessionScanLimit).Return(keys, sids, nil)
+	mockKVClient.EXPECT().Scan(ctx, []byte("user_sessions/1/1099\x00"), []byte("user_sessions/10"), sessionScanLimit).
+		Return([][]byte{[]byte("user_sessions/1/2000")}, [][]byte{[]byte("2000")}, nil)
+	mockKVClient.EXPECT().Get(ctx, gomock.Any()).Return([]byte("refresh-token"), nil).Times(sessionScanLimit)
+	mockKVClient.EXPECT().Delete(ctx, gomock.Any()).Return(nil).Times(2 * sessionScanLimit)
+
+	// The session making the request is kept
+	revoked, err := sessionStorer.RevokeUserSessions(ctx, 1, "2000")
+	assert.NoError(t, err)
+	assert.Equal(t, sessionScanLimit, revoked)
+
+	// An entry whose session has already ended is dropped and not counted
+	mockKVClient.EXPECT().Scan(ctx, []byte("user_sessions/1/"), []byte("user_sessions/10"), sessionScanLimit).
+		Return([][]byte{[]byte("user_sessions/1/7"), []byte("user_sessions/1/8")}, [][]byte{[]byte("7"), []byte("8")}, nil)
+	mockKVClient.EXPECT().Get(ctx, []byte("7")).Return(nil, nil)
+	mockKVClient.EXPECT().Delete(ctx, []byte("user_sessions/1/7")).Return(nil)
+	mockKVClient.EXPECT().Get(ctx, []byte("8")).Return([]byte("refresh-token"), nil)
+	mockKVClient.EXPECT().Delete(ctx, []byte("8")).Return(nil)
+	mockKVClient.EXPECT().Delete(ctx, []byte("user_sessions/1/8")).Return(nil)
+
+	revoked, err = sessionStorer.RevokeUserSessions(ctx, 1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+}
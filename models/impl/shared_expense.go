@@ -0,0 +1,612 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	SplitMethodEqual   = "EQUAL"
+	SplitMethodExact   = "EXACT"
+	SplitMethodPercent = "PERCENT"
+	SplitMethodShares  = "SHARES"
+)
+
+var (
+	ErrSharedExpenseNotFound = errors.New("shared expense not found")
+	ErrInvalidSharedExpense  = errors.New("invalid shared expense")
+	ErrSettlementNotFound    = errors.New("settlement not found")
+	ErrInvalidSettlement     = errors.New("invalid settlement")
+)
+
+type SharedExpenseModel struct {
+	TableSharedExpenses string
+	TableShares         string
+	TableSettlements    string
+	ColumnTransactionID string
+	ColumnScope         string
+	ColumnPayerID       string
+	ColumnSplitMethod   string
+	ColumnUserID        string
+	ColumnShareValue    string
+	ColumnSettlementID  string
+	ColumnFromUserID    string
+	ColumnToUserID      string
+	ColumnAmount        string
+	ColumnCurrency      string
+	ColumnNote          string
+	ColumnSettledAt     string
+	ColumnCreatedBy     string
+	ColumnCreatedAt     string
+}
+
+func NewSharedExpenseModel() *SharedExpenseModel {
+	return &SharedExpenseModel{
+		TableSharedExpenses: "shared_expenses",
+		TableShares:         "shared_expense_shares",
+		TableSettlements:    "settlements",
+		ColumnTransactionID: "transaction_id",
+		ColumnScope:         "scope_id",
+		ColumnPayerID:       "payer_id",
+		ColumnSplitMethod:   "split_method",
+		ColumnUserID:        "user_id",
+		ColumnShareValue:    "share_value",
+		ColumnSettlementID:  "settlement_id",
+		ColumnFromUserID:    "from_user_id",
+		ColumnToUserID:      "to_user_id",
+		ColumnAmount:        "amount",
+		ColumnCurrency:      "currency",
+		ColumnNote:          "note",
+		ColumnSettledAt:     "settled_at",
+		ColumnCreatedBy:     "created_by",
+		ColumnCreatedAt:     "created_at",
+	}
+}
+
+func (sm *SharedExpenseModel) settlementColumns() []string {
+	return []string{sm.ColumnSettlementID, sm.ColumnScope, sm.ColumnFromUserID, sm.ColumnToUserID, sm.ColumnAmount, sm.ColumnCurrency, sm.ColumnNote, sm.ColumnSettledAt, sm.ColumnCreatedBy, sm.ColumnCreatedAt}
+}
+
+func scanSettlement(scanner interface{ Scan(...interface{}) error }, settlement *interfaces.Settlement) error {
+	var note sql.NullString
+	if err := scanner.Scan(&settlement.ID, &settlement.ScopeID, &settlement.FromUserID, &settlement.ToUserID, &settlement.Amount, &settlement.Currency, &note, &settlement.SettledAt, &settlement.CreatedBy, &settlement.CreatedAt); err != nil {
+		return err
+	}
+	settlement.Note = note.String
+	return nil
+}
+
+// SetSharedExpense attaches a payer and split rule to an EXPENSE transaction of a group scope,
+// replacing any rule it had. The payer and every member sharing the cost must belong to the group;
+// the payer defaults to the member who recorded the transaction. The computed shares are filled in.
+func (sm *SharedExpenseModel) SetSharedExpense(ctx context.Context, expense *interfaces.SharedExpense, otx ...*sql.Tx) error {
+	expense.SplitMethod = strings.ToUpper(expense.SplitMethod)
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		txn, err := GetModelsService().TransactionModel.GetTransactionByID(ctx, expense.TransactionID, []int64{expense.ScopeID}, tx)
+		if err != nil {
+			return errors.Wrap(ErrInvalidSharedExpense, "transaction not found")
+		}
+		if strings.ToUpper(txn.Type) != TransactionTypeExpense || txn.Amount <= 0 {
+			return errors.Wrap(ErrInvalidSharedExpense, "only expenses can be shared")
+		}
+		if _, err := GetModelsService().GroupModel.GetGroupByScope(ctx, expense.ScopeID, txn.UserID, tx); err != nil {
+			return errors.Wrap(ErrInvalidSharedExpense, "only group transactions can be shared")
+		}
+		if expense.PayerID == 0 {
+			expense.PayerID = txn.UserID
+		}
+		expense.Amount, expense.Currency = txn.Amount, txn.Currency
+		if err := allocateShares(expense, true); err != nil {
+			return err
+		}
+		for _, userID := range append([]int64{expense.PayerID}, shareUserIDs(expense.Shares)...) {
+			if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, userID, expense.ScopeID, RoleView, tx) {
+				return errors.Wrapf(ErrInvalidSharedExpense, "user %d is not a member of the group", userID)
+			}
+		}
+
+		query, args, err := GetQueryBuilder().Insert(sm.TableSharedExpenses).
+			Columns(sm.ColumnTransactionID, sm.ColumnScope, sm.ColumnPayerID, sm.ColumnSplitMethod).
+			Values(expense.TransactionID, expense.ScopeID, expense.PayerID, expense.SplitMethod).
+			Suffix("ON DUPLICATE KEY UPDATE " + sm.ColumnPayerID + " = VALUES(" + sm.ColumnPayerID + "), " + sm.ColumnSplitMethod + " = VALUES(" + sm.ColumnSplitMethod + ")").
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building shared expense upsert query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "storing shared expense failed")
+		}
+
+		query, args, err = GetQueryBuilder().Delete(sm.TableShares).
+			Where(squirrel.Eq{sm.ColumnTransactionID: expense.TransactionID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building shares delete query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "deleting shares failed")
+		}
+
+		insert := GetQueryBuilder().Insert(sm.TableShares).Columns(sm.ColumnTransactionID, sm.ColumnUserID, sm.ColumnShareValue)
+		for _, share := range expense.Shares {
+			insert = insert.Values(expense.TransactionID, share.UserID, share.Value)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building shares insert query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "storing shares failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// GetSharedExpense returns the split rule of a transaction with the shares computed from its current amount.
+func (sm *SharedExpenseModel) GetSharedExpense(ctx context.Context, transactionID int64, scopeID int64, otx ...*sql.Tx) (*interfaces.SharedExpense, error) {
+	expenses, err := sm.getSharedExpenses(ctx, squirrel.Eq{"se." + sm.ColumnTransactionID: transactionID, "se." + sm.ColumnScope: scopeID}, otx...)
+	if err != nil {
+		return nil, err
+	}
+	if len(expenses) == 0 {
+		return nil, ErrSharedExpenseNotFound
+	}
+	return &expenses[0], nil
+}
+
+// DeleteSharedExpense detaches the split rule from a transaction; the transaction itself is kept.
+func (sm *SharedExpenseModel) DeleteSharedExpense(ctx context.Context, transactionID int64, scopeID int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		query, args, err := GetQueryBuilder().Delete(sm.TableShares).
+			Where(squirrel.Expr(sm.ColumnTransactionID+" IN (?)", GetQueryBuilder().Select(sm.ColumnTransactionID).
+				From(sm.TableSharedExpenses).
+				Where(squirrel.Eq{sm.ColumnTransactionID: transactionID, sm.ColumnScope: scopeID}))).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building shares delete query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "deleting shares failed")
+		}
+
+		query, args, err = GetQueryBuilder().Delete(sm.TableSharedExpenses).
+			Where(squirrel.Eq{sm.ColumnTransactionID: transactionID, sm.ColumnScope: scopeID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building shared expense delete query failed")
+		}
+		result, err := executor.ExecContext(ctx, query, args...)
+		if err != nil {
+			return errors.Wrap(err, "deleting shared expense failed")
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrSharedExpenseNotFound
+		}
+		return nil
+	}, otx...)
+}
+
+// getSharedExpenses loads the shared EXPENSE transactions matching where, with their shares.
+func (sm *SharedExpenseModel) getSharedExpenses(ctx context.Context, where squirrel.Sqlizer, otx ...*sql.Tx) ([]interfaces.SharedExpense, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select("se."+sm.ColumnTransactionID, "se."+sm.ColumnScope, "se."+sm.ColumnPayerID, "se."+sm.ColumnSplitMethod, "t.amount", "t.currency", "sh."+sm.ColumnUserID, "sh."+sm.ColumnShareValue).
+		From(sm.TableSharedExpenses+" se").
+		Join("transactions t ON t.transaction_id = se."+sm.ColumnTransactionID).
+		Join(sm.TableShares+" sh ON sh."+sm.ColumnTransactionID+" = se."+sm.ColumnTransactionID).
+		Where(where).
+		Where("UPPER(t.type) = ? AND t.deleted_at IS NULL", TransactionTypeExpense).
+		OrderBy("se."+sm.ColumnTransactionID, "sh."+sm.ColumnUserID).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building shared expenses query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying shared expenses failed")
+	}
+	defer rows.Close()
+
+	expenses := make([]interfaces.SharedExpense, 0)
+	for rows.Next() {
+		var expense interfaces.SharedExpense
+		var share interfaces.ExpenseShare
+		if err := rows.Scan(&expense.TransactionID, &expense.ScopeID, &expense.PayerID, &expense.SplitMethod, &expense.Amount, &expense.Currency, &share.UserID, &share.Value); err != nil {
+			return nil, errors.Wrap(err, "scanning shared expense failed")
+		}
+		if n := len(expenses); n > 0 && expenses[n-1].TransactionID == expense.TransactionID {
+			expenses[n-1].Shares = append(expenses[n-1].Shares, share)
+			continue
+		}
+		expense.Shares = []interfaces.ExpenseShare{share}
+		expenses = append(expenses, expense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing shared expense rows failed")
+	}
+
+	for i := range expenses {
+		if err := allocateShares(&expenses[i], false); err != nil {
+			return nil, err
+		}
+	}
+	return expenses, nil
+}
+
+// InsertSettlement records a payment between two members of a group. The currency defaults to
+// the group's base currency and the settlement date to now.
+func (sm *SharedExpenseModel) InsertSettlement(ctx context.Context, settlement *interfaces.Settlement, otx ...*sql.Tx) error {
+	if settlement.FromUserID <= 0 || settlement.ToUserID <= 0 || settlement.FromUserID == settlement.ToUserID || settlement.Amount <= 0 {
+		return errors.Wrap(ErrInvalidSettlement, "a settlement needs a positive amount between two different members")
+	}
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		for _, userID := range []int64{settlement.FromUserID, settlement.ToUserID} {
+			if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, userID, settlement.ScopeID, RoleView, tx) {
+				return errors.Wrapf(ErrInvalidSettlement, "user %d is not a member of the group", userID)
+			}
+		}
+		var err error
+		if settlement.Currency == "" {
+			settlement.Currency, err = GetModelsService().CurrencyModel.GetBaseCurrency(ctx, settlement.ScopeID, tx)
+		} else {
+			settlement.Currency, err = NormalizeCurrency(settlement.Currency)
+		}
+		if err != nil {
+			return err
+		}
+		settlement.Amount = settlement.Amount.Round(settlement.Currency)
+		if settlement.SettledAt.IsZero() {
+			settlement.SettledAt = time.Now()
+		}
+		settlement.CreatedAt = time.Now()
+		if settlement.ID, err = util.GenerateSnowflakeID(); err != nil {
+			return errors.Wrap(err, "generating Snowflake ID for settlement failed")
+		}
+
+		query, args, err := GetQueryBuilder().Insert(sm.TableSettlements).
+			Columns(sm.settlementColumns()...).
+			Values(settlement.ID, settlement.ScopeID, settlement.FromUserID, settlement.ToUserID, settlement.Amount, settlement.Currency,
+				sql.NullString{String: settlement.Note, Valid: settlement.Note != ""}, settlement.SettledAt, settlement.CreatedBy, settlement.CreatedAt).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building settlement insert query failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "inserting settlement failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// GetSettlements lists the settlements of a group scope, latest first.
+func (sm *SharedExpenseModel) GetSettlements(ctx context.Context, scopeID int64, otx ...*sql.Tx) ([]interfaces.Settlement, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(sm.settlementColumns()...).
+		From(sm.TableSettlements).
+		Where(squirrel.Eq{sm.ColumnScope: scopeID}).
+		OrderBy(sm.ColumnSettledAt+" DESC", sm.ColumnSettlementID+" DESC").
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "building settlements query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying settlements failed")
+	}
+	defer rows.Close()
+
+	settlements := make([]interfaces.Settlement, 0)
+	for rows.Next() {
+		var settlement interfaces.Settlement
+		if err := scanSettlement(rows, &settlement); err != nil {
+			return nil, errors.Wrap(err, "scanning settlement failed")
+		}
+		settlements = append(settlements, settlement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing settlement rows failed")
+	}
+	return settlements, nil
+}
+
+// DeleteSettlement removes a settlement recorded by mistake; the balances it covered are owed again.
+func (sm *SharedExpenseModel) DeleteSettlement(ctx context.Context, settlementID int64, scopeID int64, otx ...*sql.Tx) error {
+	isExternalTx, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Delete(sm.TableSettlements).
+		Where(squirrel.Eq{sm.ColumnSettlementID: settlementID, sm.ColumnScope: scopeID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building settlement delete query failed")
+	}
+
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "deleting settlement failed")
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrSettlementNotFound
+	}
+
+	commitOrRollback(executor, isExternalTx, err)
+	return nil
+}
+
+// GetBalances computes each member's net position per currency from the shared expenses and
+// settlements of a group scope, and the payments that would settle them. Members without any
+// shared expense or settlement are left out.
+func (sm *SharedExpenseModel) GetBalances(ctx context.Context, scopeID int64, otx ...*sql.Tx) ([]interfaces.MemberBalance, []interfaces.SettleUpPayment, error) {
+	expenses, err := sm.getSharedExpenses(ctx, squirrel.Eq{"se." + sm.ColumnScope: scopeID}, otx...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type balanceKey struct {
+		userID   int64
+		currency string
+	}
+	positions := make(map[balanceKey]*interfaces.MemberBalance)
+	position := func(userID int64, currency string) *interfaces.MemberBalance {
+		key := balanceKey{userID, currency}
+		if positions[key] == nil {
+			positions[key] = &interfaces.MemberBalance{UserID: userID, Currency: currency}
+		}
+		return positions[key]
+	}
+
+	for _, expense := range expenses {
+		position(expense.PayerID, expense.Currency).Paid += expense.Amount
+		for _, share := range expense.Shares {
+			position(share.UserID, expense.Currency).Share += share.Amount
+		}
+	}
+
+	_, executor := getExecutor(otx...)
+	query, args, err := GetQueryBuilder().Select(sm.ColumnFromUserID, sm.ColumnToUserID, sm.ColumnCurrency, "SUM("+sm.ColumnAmount+")").
+		From(sm.TableSettlements).
+		Where(squirrel.Eq{sm.ColumnScope: scopeID}).
+		GroupBy(sm.ColumnFromUserID, sm.ColumnToUserID, sm.ColumnCurrency).
+		ToSql()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "building settlement totals query failed")
+	}
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "querying settlement totals failed")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fromUserID, toUserID int64
+		var currency string
+		var amount interfaces.Money
+		if err := rows.Scan(&fromUserID, &toUserID, &currency, &amount); err != nil {
+			return nil, nil, errors.Wrap(err, "scanning settlement totals failed")
+		}
+		position(fromUserID, currency).Sent += amount
+		position(toUserID, currency).Received += amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "processing settlement totals failed")
+	}
+
+	balances := make([]interfaces.MemberBalance, 0, len(positions))
+	for _, balance := range positions {
+		balance.Net = balance.Paid - balance.Share + balance.Sent - balance.Received
+		balances = append(balances, *balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Currency != balances[j].Currency {
+			return balances[i].Currency < balances[j].Currency
+		}
+		return balances[i].UserID < balances[j].UserID
+	})
+	return balances, settleUp(balances), nil
+}
+
+// settleUp plans the payments that bring the balances to zero, one currency at a time. The member
+// who owes the most pays the member who is owed the most until either is settled, so there is at
+// most one payment fewer than members with a balance.
+func settleUp(balances []interfaces.MemberBalance) []interfaces.SettleUpPayment {
+	type position struct {
+		userID int64
+		amount interfaces.Money
+	}
+	byCurrency := make(map[string][2][]position)
+	var currencies []string
+	for _, balance := range balances {
+		if balance.Net == 0 {
+			continue
+		}
+		sides, seen := byCurrency[balance.Currency]
+		if !seen {
+			currencies = append(currencies, balance.Currency)
+		}
+		if balance.Net > 0 {
+			sides[0] = append(sides[0], position{balance.UserID, balance.Net})
+		} else {
+			sides[1] = append(sides[1], position{balance.UserID, -balance.Net})
+		}
+		byCurrency[balance.Currency] = sides
+	}
+	sort.Strings(currencies)
+
+	largestFirst := func(positions []position) {
+		sort.SliceStable(positions, func(i, j int) bool { return positions[i].amount > positions[j].amount })
+	}
+	plan := make([]interfaces.SettleUpPayment, 0)
+	for _, currency := range currencies {
+		creditors, debtors := byCurrency[currency][0], byCurrency[currency][1]
+		for len(creditors) > 0 && len(debtors) > 0 {
+			largestFirst(creditors)
+			largestFirst(debtors)
+			amount := creditors[0].amount
+			if debtors[0].amount < amount {
+				amount = debtors[0].amount
+			}
+			plan = append(plan, interfaces.SettleUpPayment{FromUserID: debtors[0].userID, ToUserID: creditors[0].userID, Currency: currency, Amount: amount})
+			creditors[0].amount -= amount
+			debtors[0].amount -= amount
+			if creditors[0].amount == 0 {
+				creditors = creditors[1:]
+			}
+			if debtors[0].amount == 0 {
+				debtors = debtors[1:]
+			}
+		}
+	}
+	return plan
+}
+
+// allocateShares computes what each member owes of a shared expense from its split rule. The
+// amounts are whole minor units of the currency and add up to the expense amount; the remainder
+// of an uneven split goes to the members with the largest fractions. With strict, exact amounts
+// must add up to the expense and percentages to 100. Otherwise the transaction may have changed
+// since the rule was set, and its amount is split in proportion to the stored values.
+func allocateShares(expense *interfaces.SharedExpense, strict bool) error {
+	if len(expense.Shares) == 0 {
+		return errors.Wrap(ErrInvalidSharedExpense, "at least one member must share the cost")
+	}
+	seen := make(map[int64]bool, len(expense.Shares))
+	weights := make([]float64, len(expense.Shares))
+	var total float64
+	var exactTotal interfaces.Money
+	wholeUnits := true
+	for i, share := range expense.Shares {
+		if share.UserID <= 0 || seen[share.UserID] || share.Value < 0 || math.IsNaN(share.Value) || math.IsInf(share.Value, 0) {
+			return errors.Wrap(ErrInvalidSharedExpense, "each member may share once, with a value that is not negative")
+		}
+		seen[share.UserID] = true
+		weights[i] = share.Value
+		if expense.SplitMethod == SplitMethodEqual {
+			weights[i] = 1
+		}
+		total += weights[i]
+		exact := interfaces.MoneyFromFloat(share.Value)
+		exactTotal += exact
+		wholeUnits = wholeUnits && exact == exact.Round(expense.Currency)
+	}
+
+	switch expense.SplitMethod {
+	case SplitMethodEqual, SplitMethodShares:
+	case SplitMethodExact:
+		if strict && (exactTotal != expense.Amount || !wholeUnits) {
+			return errors.Wrapf(ErrInvalidSharedExpense, "the exact amounts add up to %s in whole units of %s, the expense is %s", exactTotal, expense.Currency, expense.Amount)
+		}
+	case SplitMethodPercent:
+		if strict && math.Abs(total-100) > 1e-6 {
+			return errors.Wrapf(ErrInvalidSharedExpense, "the percentages add up to %g, not 100", total)
+		}
+	default:
+		return errors.Wrap(ErrInvalidSharedExpense, "the split method must be EQUAL, EXACT, PERCENT or SHARES")
+	}
+	if total <= 0 {
+		return errors.Wrap(ErrInvalidSharedExpense, "the values of the shares add up to zero")
+	}
+
+	amounts := splitByWeight(expense.Amount, weights, interfaces.MinorUnit(expense.Currency))
+	if expense.SplitMethod == SplitMethodExact && exactTotal == expense.Amount && wholeUnits {
+		for i, share := range expense.Shares {
+			amounts[i] = interfaces.MoneyFromFloat(share.Value)
+		}
+	}
+	for i := range expense.Shares {
+		expense.Shares[i].Amount = amounts[i]
+	}
+	return nil
+}
+
+// splitByWeight divides amount in proportion to weights, in whole units, by the largest remainder method.
+func splitByWeight(amount interfaces.Money, weights []float64, unit interfaces.Money) []interfaces.Money {
+	if amount < 0 {
+		amounts := splitByWeight(-amount, weights, unit)
+		for i := range amounts {
+			amounts[i] = -amounts[i]
+		}
+		return amounts
+	}
+
+	var total float64
+	for _, weight := range weights {
+		total += weight
+	}
+	units := int64(amount / unit)
+	amounts := make([]interfaces.Money, len(weights))
+	fractions := make([]float64, len(weights))
+	allocated := int64(0)
+	for i, weight := range weights {
+		exact := float64(units) * weight / total
+		whole := math.Floor(exact)
+		amounts[i] = interfaces.Money(whole)
+		fractions[i] = exact - whole
+		allocated += int64(whole)
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return fractions[order[i]] > fractions[order[j]] })
+	for k := int64(0); k < units-allocated; k++ {
+		amounts[order[k%int64(len(order))]]++
+	}
+
+	for i := range amounts {
+		amounts[i] *= unit
+	}
+	// Amounts that are not a whole number of units keep the odd hundredths on the first share
+	amounts[0] += amount - interfaces.Money(units)*unit
+	return amounts
+}
+
+func shareUserIDs(shares []interfaces.ExpenseShare) []int64 {
+	ids := make([]int64, len(shares))
+	for i, share := range shares {
+		ids[i] = share.UserID
+	}
+	return ids
+}
@@ -0,0 +1,251 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAllocateShares(t *testing.T) {
+	shares := func(values ...float64) []interfaces.ExpenseShare {
+		result := make([]interfaces.ExpenseShare, len(values))
+		for i, value := range values {
+			result[i] = interfaces.ExpenseShare{UserID: int64(i + 1), Value: value}
+		}
+		return result
+	}
+	amounts := func(expense interfaces.SharedExpense) []interfaces.Money {
+		result := make([]interfaces.Money, len(expense.Shares))
+		for i, share := range expense.Shares {
+			result[i] = share.Amount
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		expense  interfaces.SharedExpense
+		expected []interfaces.Money
+	}{
+		{"Equal split gives the odd cent to the first member",
+			interfaces.SharedExpense{SplitMethod: SplitMethodEqual, Amount: 10000, Currency: "USD", Shares: shares(0, 0, 0)},
+			[]interfaces.Money{3334, 3333, 3333}},
+		{"Equal split in whole yen",
+			interfaces.SharedExpense{SplitMethod: SplitMethodEqual, Amount: 100000, Currency: "JPY", Shares: shares(0, 0, 0)},
+			[]interfaces.Money{33400, 33300, 33300}},
+		{"Exact amounts are kept",
+			interfaces.SharedExpense{SplitMethod: SplitMethodExact, Amount: 10000, Currency: "USD", Shares: shares(12.34, 87.66)},
+			[]interfaces.Money{1234, 8766}},
+		{"Percentages",
+			interfaces.SharedExpense{SplitMethod: SplitMethodPercent, Amount: 9999, Currency: "USD", Shares: shares(50, 25, 25)},
+			[]interfaces.Money{4999, 2500, 2500}},
+		{"Shares go to the largest remainders",
+			interfaces.SharedExpense{SplitMethod: SplitMethodShares, Amount: 1000, Currency: "USD", Shares: shares(1, 2)},
+			[]interfaces.Money{333, 667}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, allocateShares(&tc.expense, true))
+			assert.Equal(t, tc.expected, amounts(tc.expense))
+		})
+	}
+
+	t.Run("Exact amounts of an edited expense are scaled", func(t *testing.T) {
+		expense := interfaces.SharedExpense{SplitMethod: SplitMethodExact, Amount: 5000, Currency: "USD", Shares: shares(60, 40)}
+		assert.ErrorIs(t, allocateShares(&expense, true), ErrInvalidSharedExpense)
+		assert.NoError(t, allocateShares(&expense, false))
+		assert.Equal(t, []interfaces.Money{3000, 2000}, amounts(expense))
+	})
+
+	invalid := map[string]interfaces.SharedExpense{
+		"No members":            {SplitMethod: SplitMethodEqual, Amount: 100},
+		"Unknown method":        {SplitMethod: "HALF", Amount: 100, Shares: shares(1, 1)},
+		"Percentages under 100": {SplitMethod: SplitMethodPercent, Amount: 100, Shares: shares(50, 40)},
+		"No shares":             {SplitMethod: SplitMethodShares, Amount: 100, Shares: shares(0, 0)},
+		"Negative value":        {SplitMethod: SplitMethodShares, Amount: 100, Shares: shares(2, -1)},
+		"Fractional yen":        {SplitMethod: SplitMethodExact, Amount: 100000, Currency: "JPY", Shares: shares(499.5, 500.5)},
+		"Member twice": {SplitMethod: SplitMethodEqual, Amount: 100, Shares: []interfaces.ExpenseShare{
+			{UserID: 1}, {UserID: 1}}},
+	}
+	for name, expense := range invalid {
+		assert.ErrorIs(t, allocateShares(&expense, true), ErrInvalidSharedExpense, name)
+	}
+}
+
+func TestSettleUp(t *testing.T) {
+	balances := []interfaces.MemberBalance{
+		{UserID: 1, Currency: "EUR", Net: 2000},
+		{UserID: 2, Currency: "EUR", Net: -2000},
+		{UserID: 1, Currency: "USD", Net: 6000},
+		{UserID: 2, Currency: "USD", Net: -1000},
+		{UserID: 3, Currency: "USD", Net: -4000},
+		{UserID: 4, Currency: "USD", Net: -2000},
+		{UserID: 5, Currency: "USD", Net: 1000},
+		{UserID: 6, Currency: "USD", Net: 0},
+	}
+	assert.Equal(t, []interfaces.SettleUpPayment{
+		{FromUserID: 2, ToUserID: 1, Currency: "EUR", Amount: 2000},
+		{FromUserID: 3, ToUserID: 1, Currency: "USD", Amount: 4000},
+		{FromUserID: 4, ToUserID: 1, Currency: "USD", Amount: 2000},
+		{FromUserID: 2, ToUserID: 5, Currency: "USD", Amount: 1000},
+	}, settleUp(balances))
+	assert.Empty(t, settleUp(nil))
+}
+
+func TestGetBalances(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.SharedExpenseModel = NewSharedExpenseModel()
+	})
+	defer tearDown()
+
+	_, mockM := setupNewMock(t)
+	// Dinner of 90.00 paid by 1 and split equally by 1, 2 and 3; a 30.00 taxi paid by 2 for 3
+	mockM.ExpectQuery("^SELECT se.transaction_id, (.+) FROM shared_expenses se JOIN transactions t (.+) JOIN shared_expense_shares sh (.+) WHERE se.scope_id = \\? AND UPPER\\(t.type\\) = \\? AND t.deleted_at IS NULL ORDER BY se.transaction_id, sh.user_id").
+		WithArgs(int64(10), TransactionTypeExpense).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "scope_id", "payer_id", "split_method", "amount", "currency", "user_id", "share_value"}).
+			AddRow(7, 10, 1, SplitMethodEqual, "90.00", "USD", 1, 0).
+			AddRow(7, 10, 1, SplitMethodEqual, "90.00", "USD", 2, 0).
+			AddRow(7, 10, 1, SplitMethodEqual, "90.00", "USD", 3, 0).
+			AddRow(8, 10, 2, SplitMethodExact, "30.00", "USD", 3, 30))
+	// 3 has already paid 2 back
+	mockM.ExpectQuery("^SELECT from_user_id, to_user_id, currency, SUM\\(amount\\) FROM settlements WHERE scope_id = \\? GROUP BY from_user_id, to_user_id, currency").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"from_user_id", "to_user_id", "currency", "amount"}).AddRow(3, 2, "USD", "30.00"))
+
+	balances, plan, err := ModelsService.SharedExpenseModel.GetBalances(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []interfaces.MemberBalance{
+		{UserID: 1, Currency: "USD", Paid: 9000, Share: 3000, Net: 6000},
+		{UserID: 2, Currency: "USD", Paid: 3000, Share: 3000, Received: 3000, Net: -3000},
+		{UserID: 3, Currency: "USD", Share: 6000, Sent: 3000, Net: -3000},
+	}, balances)
+	assert.Equal(t, []interfaces.SettleUpPayment{
+		{FromUserID: 2, ToUserID: 1, Currency: "USD", Amount: 3000},
+		{FromUserID: 3, ToUserID: 1, Currency: "USD", Amount: 3000},
+	}, plan)
+	assert.NoError(t, mockM.ExpectationsWereMet())
+}
+
+func TestSetSharedExpense(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.SharedExpenseModel = NewSharedExpenseModel()
+	})
+	defer tearDown()
+
+	_, mockM := setupNewMock(t)
+	mockTransactionModel := new(xmock.MockTransactionModel)
+	mockGroupModel := new(xmock.MockGroupModel)
+	ModelsService.TransactionModel = mockTransactionModel
+	ModelsService.GroupModel = mockGroupModel
+	ModelsService.UserScopeModel = newValidatingUserScopeMock()
+
+	mockTransactionModel.On("GetTransactionByID", mock.Anything, int64(7), []int64{10}, mock.Anything).
+		Return(&interfaces.Transaction{ID: 7, UserID: 1, ScopeID: 10, Type: "expense", Amount: 9000, Currency: "USD"}, nil)
+	mockGroupModel.On("GetGroupByScope", mock.Anything, int64(10), int64(1), mock.Anything).
+		Return(&interfaces.Group{GroupID: 3, ScopeID: 10}, nil)
+
+	mockM.ExpectBegin()
+	mockM.ExpectExec("^INSERT INTO shared_expenses \\(transaction_id,scope_id,payer_id,split_method\\) VALUES \\(\\?,\\?,\\?,\\?\\) ON DUPLICATE KEY UPDATE").
+		WithArgs(int64(7), int64(10), int64(1), SplitMethodPercent).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockM.ExpectExec("^DELETE FROM shared_expense_shares WHERE transaction_id = \\?").
+		WithArgs(int64(7)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockM.ExpectExec("^INSERT INTO shared_expense_shares \\(transaction_id,user_id,share_value\\) VALUES \\(\\?,\\?,\\?\\),\\(\\?,\\?,\\?\\)").
+		WithArgs(int64(7), int64(1), 40.0, int64(7), int64(2), 60.0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mockM.ExpectCommit()
+
+	expense := interfaces.SharedExpense{TransactionID: 7, ScopeID: 10, SplitMethod: "percent", Shares: []interfaces.ExpenseShare{
+		{UserID: 1, Value: 40}, {UserID: 2, Value: 60}}}
+	assert.NoError(t, ModelsService.SharedExpenseModel.SetSharedExpense(context.Background(), &expense))
+	assert.Equal(t, int64(1), expense.PayerID, "the member who recorded the expense paid it")
+	assert.Equal(t, interfaces.Money(3600), expense.Shares[0].Amount)
+	assert.Equal(t, interfaces.Money(5400), expense.Shares[1].Amount)
+	assert.NoError(t, mockM.ExpectationsWereMet())
+
+	t.Run("Only expenses can be shared", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockTransactionModel.On("GetTransactionByID", mock.Anything, int64(8), []int64{10}, mock.Anything).
+			Return(&interfaces.Transaction{ID: 8, UserID: 1, ScopeID: 10, Type: "income", Amount: 9000, Currency: "USD"}, nil)
+		mockM.ExpectBegin()
+		mockM.ExpectRollback()
+
+		income := interfaces.SharedExpense{TransactionID: 8, ScopeID: 10, SplitMethod: SplitMethodEqual, Shares: []interfaces.ExpenseShare{{UserID: 1}}}
+		assert.ErrorIs(t, ModelsService.SharedExpenseModel.SetSharedExpense(context.Background(), &income), ErrInvalidSharedExpense)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("The group owner pays", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		ModelsService.UserScopeModel = NewUserScopeModel()
+		viewOrHigher := []string{RoleOwner, RoleView, RoleWrite}
+
+		mockM.ExpectBegin()
+		// The payer, then each member sharing the cost
+		expectRoleQuery(mockM, 1, 10, RoleOwner, viewOrHigher)
+		expectRoleQuery(mockM, 1, 10, RoleOwner, viewOrHigher)
+		expectRoleQuery(mockM, 2, 10, RoleWrite, viewOrHigher)
+		mockM.ExpectExec("^INSERT INTO shared_expenses").WillReturnResult(sqlmock.NewResult(0, 1))
+		mockM.ExpectExec("^DELETE FROM shared_expense_shares").WillReturnResult(sqlmock.NewResult(0, 0))
+		mockM.ExpectExec("^INSERT INTO shared_expense_shares").WillReturnResult(sqlmock.NewResult(0, 2))
+		mockM.ExpectCommit()
+
+		expense := interfaces.SharedExpense{TransactionID: 7, ScopeID: 10, SplitMethod: SplitMethodEqual, Shares: []interfaces.ExpenseShare{{UserID: 1}, {UserID: 2}}}
+		assert.NoError(t, ModelsService.SharedExpenseModel.SetSharedExpense(context.Background(), &expense))
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+}
+
+func TestInsertSettlement(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.SharedExpenseModel = NewSharedExpenseModel()
+	})
+	defer tearDown()
+
+	_, mockM := setupNewMock(t)
+	mockCurrencyModel := new(xmock.MockCurrencyModel)
+	ModelsService.CurrencyModel = mockCurrencyModel
+	ModelsService.UserScopeModel = newValidatingUserScopeMock()
+	mockCurrencyModel.On("GetBaseCurrency", mock.Anything, int64(10), mock.Anything).Return("EUR", nil)
+
+	mockM.ExpectBegin()
+	mockM.ExpectExec("^INSERT INTO settlements \\(settlement_id,scope_id,from_user_id,to_user_id,amount,currency,note,settled_at,created_by,created_at\\)").
+		WithArgs(sqlmock.AnyArg(), int64(10), int64(3), int64(1), interfaces.Money(3000), "EUR", sqlmock.AnyArg(), sqlmock.AnyArg(), int64(3), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockM.ExpectCommit()
+
+	settlement := interfaces.Settlement{ScopeID: 10, FromUserID: 3, ToUserID: 1, Amount: 3000, CreatedBy: 3}
+	assert.NoError(t, ModelsService.SharedExpenseModel.InsertSettlement(context.Background(), &settlement))
+	assert.NotZero(t, settlement.ID)
+	assert.False(t, settlement.SettledAt.IsZero())
+	assert.NoError(t, mockM.ExpectationsWereMet())
+
+	for name, invalid := range map[string]interfaces.Settlement{
+		"Paying oneself":  {ScopeID: 10, FromUserID: 1, ToUserID: 1, Amount: 3000},
+		"Nothing paid":    {ScopeID: 10, FromUserID: 3, ToUserID: 1},
+		"Negative amount": {ScopeID: 10, FromUserID: 3, ToUserID: 1, Amount: -3000},
+	} {
+		assert.ErrorIs(t, ModelsService.SharedExpenseModel.InsertSettlement(context.Background(), &invalid), ErrInvalidSettlement, name)
+	}
+
+	t.Run("The group owner settles up", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		ModelsService.UserScopeModel = NewUserScopeModel()
+		viewOrHigher := []string{RoleOwner, RoleView, RoleWrite}
+
+		mockM.ExpectBegin()
+		expectRoleQuery(mockM, 1, 10, RoleOwner, viewOrHigher)
+		expectRoleQuery(mockM, 3, 10, RoleWrite, viewOrHigher)
+		mockM.ExpectExec("^INSERT INTO settlements").WillReturnResult(sqlmock.NewResult(0, 1))
+		mockM.ExpectCommit()
+
+		settlement := interfaces.Settlement{ScopeID: 10, FromUserID: 1, ToUserID: 3, Amount: 3000, CreatedBy: 1}
+		assert.NoError(t, ModelsService.SharedExpenseModel.InsertSettlement(context.Background(), &settlement))
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+}
@@ -36,6 +36,9 @@ import (
 	"github.com/pkg/errors"
 )
 
+// ErrSourceNotFound is returned when a source does not exist in the requested scopes.
+var ErrSourceNotFound = errors.New("source not found")
+
 type SourceModel struct {
 	TableSources      string
 	ColumnID          string
@@ -43,9 +46,12 @@ type SourceModel struct {
 	ColumnName        string
 	ColumnType        string
 	ColumnBalance     string
+	ColumnOpening     string
+	ColumnCurrency    string
 	ColumnScope       string
 	ColumnCreatedAt   string
 	ColumnUpdatedAt   string
+	ColumnDeletedAt   string
 	SourceTypeCredit  string
 	SourceTypeSavings string
 }
@@ -58,14 +64,25 @@ func NewSourceModel() *SourceModel {
 		ColumnName:        "name",
 		ColumnType:        "type",
 		ColumnBalance:     "balance",
+		ColumnOpening:     "opening_balance",
+		ColumnCurrency:    "currency",
 		ColumnScope:       "scope_id",
 		ColumnCreatedAt:   "created_at",
 		ColumnUpdatedAt:   "updated_at",
+		ColumnDeletedAt:   "deleted_at",
 		SourceTypeCredit:  "CREDIT",
 		SourceTypeSavings: "SAVINGS",
 	}
 }
 
+func (sm *SourceModel) selectColumns() []string {
+	return []string{sm.ColumnID, sm.ColumnUserID, sm.ColumnName, sm.ColumnType, sm.ColumnBalance, sm.ColumnOpening, sm.ColumnCurrency, sm.ColumnScope, sm.ColumnCreatedAt, sm.ColumnUpdatedAt}
+}
+
+func scanSource(scanner interface{ Scan(...interface{}) error }, source *interfaces.Source) error {
+	return scanner.Scan(&source.ID, &source.UserID, &source.Name, &source.Type, &source.Balance, &source.OpeningBalance, &source.Currency, &source.ScopeID, &source.CreatedAt, &source.UpdatedAt)
+}
+
 func (cm *SourceModel) validateSourceInput(ctx context.Context, source *interfaces.Source, role string) error {
 	if source.ScopeID <= 0 || source.UserID <= 0 || source.Name == "" {
 		return errors.New(ErrInvalidInput)
@@ -95,10 +112,25 @@ func (sm *SourceModel) InsertSource(ctx context.Context, source *interfaces.Sour
 	}
 	source.CreatedAt = time.Now()
 	source.UpdatedAt = source.CreatedAt
+	// Sources hold the scope's base currency unless told otherwise
+	if source.Currency == "" {
+		if source.Currency, err = GetModelsService().CurrencyModel.GetBaseCurrency(ctx, source.ScopeID, otx...); err != nil {
+			return errors.Wrap(err, "resolving currency for source")
+		}
+	}
+	if source.Currency, err = NormalizeCurrency(source.Currency); err != nil {
+		return err
+	}
+	// A new source starts at its opening balance; older clients only send balance
+	if source.OpeningBalance == 0 {
+		source.OpeningBalance = source.Balance
+	}
+	source.OpeningBalance = source.OpeningBalance.Round(source.Currency)
+	source.Balance = source.OpeningBalance
 
 	query, args, err := GetQueryBuilder().Insert(sm.TableSources).
-		Columns(sm.ColumnID, sm.ColumnUserID, sm.ColumnName, sm.ColumnType, sm.ColumnBalance, sm.ColumnScope, sm.ColumnCreatedAt, sm.ColumnUpdatedAt).
-		Values(source.ID, source.UserID, source.Name, source.Type, source.Balance, source.ScopeID, source.CreatedAt, source.UpdatedAt).
+		Columns(sm.selectColumns()...).
+		Values(source.ID, source.UserID, source.Name, source.Type, source.Balance, source.OpeningBalance, source.Currency, source.ScopeID, source.CreatedAt, source.UpdatedAt).
 		ToSql()
 
 	if err != nil {
@@ -122,12 +154,14 @@ func (sm *SourceModel) UpdateSource(ctx context.Context, source *interfaces.Sour
 
 	source.UpdatedAt = time.Now()
 
+	// Balance is derived from transactions and is never overwritten here.
+	// Currency is fixed at creation since every transaction of the source is recorded in it.
 	query, args, err := GetQueryBuilder().Update(sm.TableSources).
 		Set(sm.ColumnName, source.Name).
 		Set(sm.ColumnType, source.Type).
-		Set(sm.ColumnBalance, source.Balance).
 		Set(sm.ColumnUpdatedAt, source.UpdatedAt).
-		Where(squirrel.Eq{sm.ColumnID: source.ID, sm.ColumnUserID: source.ScopeID}).
+		Where(squirrel.Eq{sm.ColumnID: source.ID, sm.ColumnScope: source.ScopeID}).
+		Where(squirrel.Eq{sm.ColumnDeletedAt: nil}).
 		ToSql()
 
 	if err != nil {
@@ -142,29 +176,143 @@ func (sm *SourceModel) UpdateSource(ctx context.Context, source *interfaces.Sour
 	return nil
 }
 
-func (sm *SourceModel) DeleteSource(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
-	query, args, err := GetQueryBuilder().Delete(sm.TableSources).
-		Where(squirrel.Eq{sm.ColumnID: sourceID, sm.ColumnScope: scopes}).
-		ToSql()
+// DeleteSource moves a source to the trash. A source used by transactions, as source or transfer
+// destination, or by recurring transactions is only deleted when reassignTo names another source of
+// the scopes in the same currency: they are moved to it, with their effect on the balance, in the
+// same SQL transaction. Without a target an *InUseError reports how many use it.
+// Deleting a source that does not exist in the given scopes is a no-op.
+func (sm *SourceModel) DeleteSource(ctx context.Context, sourceID int64, reassignTo int64, scopes []int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		exists, err := sm.SourceIDExists(ctx, sourceID, scopes, tx)
+		if err != nil || !exists {
+			return err
+		}
+		usage, err := sm.getSourceUsage(ctx, sourceID, scopes, tx)
+		if err != nil {
+			return err
+		}
+		if usage.inUse() {
+			if reassignTo == 0 {
+				return usage
+			}
+			if err := sm.reassignSource(ctx, sourceID, reassignTo, scopes, tx); err != nil {
+				return err
+			}
+		}
+
+		query, args, err := GetQueryBuilder().Update(sm.TableSources).
+			Set(sm.ColumnDeletedAt, time.Now()).
+			Where(squirrel.Eq{sm.ColumnID: sourceID, sm.ColumnScope: scopes}).
+			Where(squirrel.Eq{sm.ColumnDeletedAt: nil}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "preparing delete SQL for source")
+		}
+
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "executing delete for source")
+		}
+		return nil
+	}, otx...)
+}
+
+// getSourceUsage counts the transactions recorded against or transferring into a source and the
+// recurring transactions drawing on it. Transactions in the trash are not counted.
+func (sm *SourceModel) getSourceUsage(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (*InUseError, error) {
+	_, executor := getExecutor(otx...)
+	usage := &InUseError{Item: "source"}
 
+	var err error
+	usage.Transactions, err = countRows(ctx, executor, GetQueryBuilder().Select("COUNT(*)").
+		From("transactions").
+		Where(squirrel.Eq{"scope_id": scopes}).
+		Where("deleted_at IS NULL").
+		Where(squirrel.Or{squirrel.Eq{"source_id": sourceID}, squirrel.Eq{"destination_source_id": sourceID}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "counting source transactions failed")
+	}
+	usage.RecurringTransactions, err = countRows(ctx, executor, GetQueryBuilder().Select("COUNT(*)").
+		From("recurring_transactions").
+		Where(squirrel.Eq{"scope_id": scopes, "source_id": sourceID}))
 	if err != nil {
-		return errors.Wrap(err, "preparing delete SQL for source")
+		return nil, errors.Wrap(err, "counting source recurring transactions failed")
 	}
+	return usage, nil
+}
 
-	_, err = executor.ExecContext(ctx, query, args...)
+// reassignSource moves the transactions, including those in the trash, recurring transactions and
+// import batches of a source to another source of the scopes, and carries the balance effect of the
+// moved transactions over. Transfers between the two sources would become transfers of a source to
+// itself, so the target cannot be a source the deleted one has transfers with.
+func (sm *SourceModel) reassignSource(ctx context.Context, sourceID, reassignTo int64, scopes []int64, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	if reassignTo == sourceID {
+		return ErrInvalidReassignTarget
+	}
+	currency, err := sm.GetSourceCurrency(ctx, sourceID, scopes, otx...)
 	if err != nil {
-		return errors.Wrap(err, "executing delete for source")
+		return err
 	}
-	commitOrRollback(executor, isExternalTx, err)
-	return nil
+	targetCurrency, err := sm.GetSourceCurrency(ctx, reassignTo, scopes, otx...)
+	if errors.Cause(err) == ErrSourceNotFound {
+		return errors.Wrapf(ErrInvalidReassignTarget, "source %d not found", reassignTo)
+	}
+	if err != nil {
+		return err
+	}
+	if targetCurrency != currency {
+		return errors.Wrapf(ErrInvalidReassignTarget, "source %d holds %s, not %s", reassignTo, targetCurrency, currency)
+	}
+
+	transfers, err := countRows(ctx, executor, GetQueryBuilder().Select("COUNT(*)").
+		From("transactions").
+		Where(squirrel.Eq{"scope_id": scopes}).
+		Where("transfer_id IS NOT NULL").
+		Where(squirrel.Or{
+			squirrel.Eq{"source_id": sourceID, "destination_source_id": reassignTo},
+			squirrel.Eq{"source_id": reassignTo, "destination_source_id": sourceID},
+		}))
+	if err != nil {
+		return errors.Wrap(err, "counting transfers between sources failed")
+	}
+	if transfers > 0 {
+		return errors.Wrapf(ErrInvalidReassignTarget, "source %d has transfers with source %d", sourceID, reassignTo)
+	}
+
+	net, err := GetModelsService().TransactionModel.GetNetAmountBySource(ctx, sourceID, otx...)
+	if err != nil {
+		return errors.Wrap(err, "computing source net amount failed")
+	}
+
+	inScopes := squirrel.Eq{"scope_id": scopes}
+	if err := reassignColumn(ctx, executor, "transactions", "source_id", sourceID, reassignTo, inScopes); err != nil {
+		return err
+	}
+	if err := reassignColumn(ctx, executor, "transactions", "destination_source_id", sourceID, reassignTo, inScopes); err != nil {
+		return err
+	}
+	if err := reassignColumn(ctx, executor, "recurring_transactions", "source_id", sourceID, reassignTo, inScopes); err != nil {
+		return err
+	}
+	if err := reassignColumn(ctx, executor, "import_batches", "source_id", sourceID, reassignTo, inScopes); err != nil {
+		return err
+	}
+
+	if err := sm.AdjustBalance(ctx, sourceID, -net, otx...); err != nil {
+		return errors.Wrap(err, "updating source balance failed")
+	}
+	return sm.AdjustBalance(ctx, reassignTo, net, otx...)
 }
 
 func (sm *SourceModel) GetSourceByID(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Source, error) {
 	_, executor := getExecutor(otx...)
-	query, args, err := GetQueryBuilder().Select(sm.ColumnID, sm.ColumnUserID, sm.ColumnName, sm.ColumnType, sm.ColumnBalance, sm.ColumnScope, sm.ColumnCreatedAt, sm.ColumnUpdatedAt).
+	query, args, err := GetQueryBuilder().Select(sm.selectColumns()...).
 		From(sm.TableSources).
 		Where(squirrel.Eq{sm.ColumnID: sourceID, sm.ColumnScope: scopes}).
+		Where(squirrel.Eq{sm.ColumnDeletedAt: nil}).
 		ToSql()
 
 	if err != nil {
@@ -172,10 +320,10 @@ func (sm *SourceModel) GetSourceByID(ctx context.Context, sourceID int64, scopes
 	}
 
 	source := &interfaces.Source{}
-	err = executor.QueryRowContext(ctx, query, args...).Scan(&source.ID, &source.UserID, &source.Name, &source.Type, &source.Balance, &source.ScopeID, &source.CreatedAt, &source.UpdatedAt)
+	err = scanSource(executor.QueryRowContext(ctx, query, args...), source)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("source not found")
+			return nil, ErrSourceNotFound
 		}
 		return nil, errors.Wrap(err, "querying source by ID")
 	}
@@ -189,9 +337,10 @@ func (sm *SourceModel) GetScopedSources(ctx context.Context, page int, itemsPerP
 
 	offset := (page - 1) * itemsPerPage
 
-	query, args, err := GetQueryBuilder().Select(sm.ColumnID, sm.ColumnUserID, sm.ColumnName, sm.ColumnType, sm.ColumnBalance, sm.ColumnScope, sm.ColumnCreatedAt, sm.ColumnUpdatedAt).
+	query, args, err := GetQueryBuilder().Select(sm.selectColumns()...).
 		From(sm.TableSources).
 		Where(squirrel.Eq{sm.ColumnScope: scopes}).
+		Where(squirrel.Eq{sm.ColumnDeletedAt: nil}).
 		Limit(uint64(itemsPerPage)).
 		Offset(uint64(offset)).
 		ToSql()
@@ -208,7 +357,7 @@ func (sm *SourceModel) GetScopedSources(ctx context.Context, page int, itemsPerP
 	var sources []interfaces.Source
 	for rows.Next() {
 		var source interfaces.Source
-		if err = rows.Scan(&source.ID, &source.UserID, &source.Name, &source.Type, &source.Balance, &source.ScopeID, &source.CreatedAt, &source.UpdatedAt); err != nil {
+		if err = scanSource(rows, &source); err != nil {
 			return nil, errors.Wrap(err, "scanning paginated source row failed")
 		}
 		sources = append(sources, source)
@@ -223,9 +372,10 @@ func (sm *SourceModel) GetScopedSources(ctx context.Context, page int, itemsPerP
 
 func (sm *SourceModel) GetSources(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.Source, error) {
 	_, executor := getExecutor(otx...)
-	query, args, err := GetQueryBuilder().Select(sm.ColumnID, sm.ColumnUserID, sm.ColumnName, sm.ColumnType, sm.ColumnBalance, sm.ColumnScope, sm.ColumnCreatedAt, sm.ColumnUpdatedAt).
+	query, args, err := GetQueryBuilder().Select(sm.selectColumns()...).
 		From(sm.TableSources).
 		Where(squirrel.Eq{sm.ColumnScope: scopes}).
+		Where(squirrel.Eq{sm.ColumnDeletedAt: nil}).
 		ToSql()
 
 	if err != nil {
@@ -241,7 +391,7 @@ func (sm *SourceModel) GetSources(ctx context.Context, scopes []int64, otx ...*s
 	var sources []interfaces.Source
 	for rows.Next() {
 		var source interfaces.Source
-		if err = rows.Scan(&source.ID, &source.UserID, &source.Name, &source.Type, &source.Balance, &source.ScopeID, &source.CreatedAt, &source.UpdatedAt); err != nil {
+		if err = scanSource(rows, &source); err != nil {
 			return nil, errors.Wrap(err, "scanning source row")
 		}
 		sources = append(sources, source)
@@ -254,12 +404,50 @@ func (sm *SourceModel) GetSources(ctx context.Context, scopes []int64, otx ...*s
 	return sources, nil
 }
 
+// GetSourcesByCursor retrieves a page of the sources in scopes, oldest first.
+func (sm *SourceModel) GetSourcesByCursor(ctx context.Context, scopes []int64, params interfaces.CursorParams, otx ...*sql.Tx) ([]interfaces.Source, interfaces.CursorPage, error) {
+	_, executor := getExecutor(otx...)
+
+	query := GetQueryBuilder().Select(sm.selectColumns()...).
+		From(sm.TableSources).
+		Where(squirrel.Eq{sm.ColumnScope: scopes}).
+		Where(squirrel.Eq{sm.ColumnDeletedAt: nil})
+	sql, args, err := applyCursor(query, sm.ColumnCreatedAt, sm.ColumnID, false, params).ToSql()
+	if err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "preparing select SQL for a page of sources")
+	}
+
+	rows, err := executor.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "querying a page of sources")
+	}
+	defer rows.Close()
+
+	sources := make([]interfaces.Source, 0)
+	for rows.Next() {
+		var source interfaces.Source
+		if err = scanSource(rows, &source); err != nil {
+			return nil, interfaces.CursorPage{}, errors.Wrap(err, "scanning source row")
+		}
+		sources = append(sources, source)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "during row processing for a page of sources")
+	}
+
+	sources, page := pageByCursor(sources, func(source interfaces.Source) interfaces.Cursor {
+		return interfaces.Cursor{Time: source.CreatedAt, ID: source.ID}
+	}, params)
+	return sources, page, nil
+}
+
 func (sm *SourceModel) SourceIDExists(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (bool, error) {
 	_, executor := getExecutor(otx...)
 
 	query, args, err := GetQueryBuilder().Select("1").
 		From(sm.TableSources).
 		Where(squirrel.Eq{sm.ColumnID: sourceID, sm.ColumnScope: scopes}).
+		Where(squirrel.Eq{sm.ColumnDeletedAt: nil}).
 		Limit(1).
 		ToSql()
 
@@ -278,3 +466,110 @@ func (sm *SourceModel) SourceIDExists(ctx context.Context, sourceID int64, scope
 
 	return exists == 1, nil
 }
+
+// GetSourceCurrency returns the currency a source holds its balance in.
+func (sm *SourceModel) GetSourceCurrency(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (string, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(sm.ColumnCurrency).
+		From(sm.TableSources).
+		Where(squirrel.Eq{sm.ColumnID: sourceID, sm.ColumnScope: scopes}).
+		Where(squirrel.Eq{sm.ColumnDeletedAt: nil}).
+		ToSql()
+	if err != nil {
+		return "", errors.Wrap(err, "preparing select SQL for source currency")
+	}
+
+	var currency string
+	if err := executor.QueryRowContext(ctx, query, args...).Scan(&currency); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrSourceNotFound
+		}
+		return "", errors.Wrap(err, "querying source currency")
+	}
+	return currency, nil
+}
+
+// AdjustBalance moves the stored balance of a source by delta.
+// Transaction writes call it with the same *sql.Tx so the balance and the history change together.
+func (sm *SourceModel) AdjustBalance(ctx context.Context, sourceID int64, delta interfaces.Money, otx ...*sql.Tx) error {
+	if sourceID <= 0 || delta == 0 {
+		return nil
+	}
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Update(sm.TableSources).
+		Set(sm.ColumnBalance, squirrel.Expr(sm.ColumnBalance+" + CAST(? AS DECIMAL(12, 2))", delta)).
+		Set(sm.ColumnUpdatedAt, time.Now()).
+		Where(squirrel.Eq{sm.ColumnID: sourceID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "preparing balance update SQL for source")
+	}
+
+	if _, err = executor.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "executing balance update for source")
+	}
+	return nil
+}
+
+// ReconcileBalance recomputes a source's balance from its opening balance and transaction history,
+// stores the recomputed value and reports how far the stored balance had drifted.
+func (sm *SourceModel) ReconcileBalance(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.SourceReconciliation, error) {
+	var result *interfaces.SourceReconciliation
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		// Lock the source so concurrent transaction writes wait for the reconciliation
+		query, args, err := GetQueryBuilder().Select(sm.ColumnBalance, sm.ColumnOpening).
+			From(sm.TableSources).
+			Where(squirrel.Eq{sm.ColumnID: sourceID, sm.ColumnScope: scopes}).
+			Where(squirrel.Eq{sm.ColumnDeletedAt: nil}).
+			Suffix("FOR UPDATE").
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "preparing select SQL for source balance")
+		}
+
+		var stored, opening interfaces.Money
+		if err := executor.QueryRowContext(ctx, query, args...).Scan(&stored, &opening); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrSourceNotFound
+			}
+			return errors.Wrap(err, "querying source balance")
+		}
+
+		net, err := GetModelsService().TransactionModel.GetNetAmountBySource(ctx, sourceID, tx)
+		if err != nil {
+			return errors.Wrap(err, "computing net amount for source")
+		}
+
+		result = &interfaces.SourceReconciliation{
+			SourceID:        sourceID,
+			StoredBalance:   stored,
+			ComputedBalance: opening + net,
+		}
+		result.Drift = stored - result.ComputedBalance
+		if result.Drift == 0 {
+			return nil
+		}
+
+		query, args, err = GetQueryBuilder().Update(sm.TableSources).
+			Set(sm.ColumnBalance, result.ComputedBalance).
+			Set(sm.ColumnUpdatedAt, time.Now()).
+			Where(squirrel.Eq{sm.ColumnID: sourceID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "preparing balance correction SQL for source")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "executing balance correction for source")
+		}
+		result.Corrected = true
+		return nil
+	}, otx...)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -7,26 +7,31 @@ import (
 	"testing"
 	"time"
 	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestInsertSource(t *testing.T) {
+	mockCurrencyModel := new(xmock.MockCurrencyModel)
 	tearDown := setUp(t, func(config *ModelsConfig) {
 		// Replace the mocked CategoryModel with a real one just for this test
 		config.SourceModel = NewSourceModel()
+		config.CurrencyModel = mockCurrencyModel
 	})
 	defer tearDown()
+	mockCurrencyModel.On("GetBaseCurrency", mock.Anything, int64(1), mock.Anything).Return("eur", nil)
 
 	source := &interfaces.Source{
 		UserID:    1,
 		ScopeID:   1,
 		Name:      "Test Source",
 		Type:      "CREDIT",
-		Balance:   100.0,
+		Balance:   interfaces.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -38,6 +43,7 @@ func TestInsertSource(t *testing.T) {
 
 	err := ModelsService.SourceModel.InsertSource(ctx, source)
 	assert.NoError(t, err)
+	assert.Equal(t, "EUR", source.Currency) // defaults to the scope's base currency
 	//test for generic query error
 	mockExecutor.EXPECT().
 		ExecContext(gomock.Any(), gomock.Any(), gomock.Any()).
@@ -52,7 +58,7 @@ func TestInsertSource(t *testing.T) {
 		ScopeID:   1,
 		Name:      "Test Source",
 		Type:      "Invalid type",
-		Balance:   100.0,
+		Balance:   interfaces.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -66,7 +72,7 @@ func TestInsertSource(t *testing.T) {
 		ScopeID:   1,
 		Name:      "",
 		Type:      "Invalid type",
-		Balance:   100.0,
+		Balance:   interfaces.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -78,7 +84,7 @@ func TestInsertSource(t *testing.T) {
 	source = &interfaces.Source{
 		Name:      "Source name",
 		Type:      "Invalid type",
-		Balance:   100.0,
+		Balance:   interfaces.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -102,7 +108,7 @@ func TestUpdateSource(t *testing.T) {
 		ScopeID:   1,
 		Name:      "Updated Source",
 		Type:      "SAVINGS",
-		Balance:   200.0,
+		Balance:   interfaces.MoneyFromFloat(200.0),
 		UpdatedAt: time.Now(),
 	}
 
@@ -128,7 +134,7 @@ func TestUpdateSource(t *testing.T) {
 		ScopeID:   1,
 		Name:      "Test Source",
 		Type:      "Invalid type",
-		Balance:   100.0,
+		Balance:   interfaces.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -142,7 +148,7 @@ func TestUpdateSource(t *testing.T) {
 		ScopeID:   1,
 		Name:      "",
 		Type:      "Invalid type",
-		Balance:   100.0,
+		Balance:   interfaces.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -154,7 +160,7 @@ func TestUpdateSource(t *testing.T) {
 	source = &interfaces.Source{
 		Name:      "Source name",
 		Type:      "Invalid type",
-		Balance:   100.0,
+		Balance:   interfaces.MoneyFromFloat(100.0),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -165,29 +171,110 @@ func TestUpdateSource(t *testing.T) {
 }
 
 func TestDeleteSource(t *testing.T) {
+	mockTransactionModel := new(xmock.MockTransactionModel)
 	tearDown := setUp(t, func(config *ModelsConfig) {
 		// Replace the mocked CategoryModel with a real one just for this test
 		config.SourceModel = NewSourceModel()
+		config.TransactionModel = mockTransactionModel
 	})
 	defer tearDown()
 
 	sourceID := int64(1)
 	scopeID := []int64{1}
+	expectUsage := func(mockM sqlmock.Sqlmock, transactions, recurring int) {
+		mockM.ExpectQuery(`SELECT 1 FROM sources WHERE scope_id IN \(\?\) AND source_id = \? AND deleted_at IS NULL LIMIT 1`).
+			WithArgs(int64(1), sourceID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mockM.ExpectQuery(`^SELECT COUNT\(\*\) FROM transactions WHERE scope_id IN \(\?\) AND deleted_at IS NULL AND \(source_id = \? OR destination_source_id = \?\)`).
+			WithArgs(int64(1), sourceID, sourceID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(transactions))
+		mockM.ExpectQuery(`^SELECT COUNT\(\*\) FROM recurring_transactions WHERE scope_id IN \(\?\) AND source_id = \?`).
+			WithArgs(int64(1), sourceID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(recurring))
+	}
 
-	//test for successful delete
-	mockExecutor.EXPECT().
-		ExecContext(gomock.Any(), gomock.Any(), gomock.Any()).
-		Return(sql.Result(nil), nil).
-		Times(1)
-	err := ModelsService.SourceModel.DeleteSource(ctx, sourceID, scopeID)
-	assert.NoError(t, err)
+	t.Run("Unused source", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		expectUsage(mockM, 0, 0)
+		mockM.ExpectExec(`^UPDATE sources SET deleted_at = \? WHERE scope_id IN \(\?\) AND source_id = \? AND deleted_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), int64(1), sourceID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockM.ExpectCommit()
+
+		assert.NoError(t, ModelsService.SourceModel.DeleteSource(ctx, sourceID, 0, scopeID))
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
 
-	//test for generic query error
-	mockExecutor.EXPECT().ExecContext(gomock.Any(), gomock.Any(), gomock.Any()).
-		Return(nil, errors.New("database error")).
-		Times(1)
-	err = ModelsService.SourceModel.DeleteSource(ctx, sourceID, scopeID)
-	assert.EqualError(t, err, "executing delete for source: database error")
+	t.Run("Database error", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		expectUsage(mockM, 0, 0)
+		mockM.ExpectExec(`^UPDATE sources SET deleted_at`).WillReturnError(errors.New("database error"))
+		mockM.ExpectRollback()
+
+		err := ModelsService.SourceModel.DeleteSource(ctx, sourceID, 0, scopeID)
+		assert.EqualError(t, err, "executing delete for source: database error")
+	})
+
+	t.Run("In use without a target", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		expectUsage(mockM, 5, 0)
+		mockM.ExpectRollback()
+
+		err := ModelsService.SourceModel.DeleteSource(ctx, sourceID, 0, scopeID)
+		var inUse *InUseError
+		assert.True(t, errors.As(err, &inUse))
+		assert.Equal(t, int64(5), inUse.Transactions)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Reassigned with its balance", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		expectUsage(mockM, 5, 1)
+		mockM.ExpectQuery(`^SELECT currency FROM sources`).WithArgs(int64(1), sourceID).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("EUR"))
+		mockM.ExpectQuery(`^SELECT currency FROM sources`).WithArgs(int64(1), int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("EUR"))
+		mockM.ExpectQuery(`^SELECT COUNT\(\*\) FROM transactions WHERE scope_id IN \(\?\) AND transfer_id IS NOT NULL`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		for _, column := range []string{"transactions SET source_id", "transactions SET destination_source_id", "recurring_transactions SET source_id", "import_batches SET source_id"} {
+			mockM.ExpectExec(`^UPDATE `+column+` = \?`).WithArgs(int64(2), sourceID, int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		// The 120.50 the source holds from its transactions moves with them
+		mockM.ExpectExec(`^UPDATE sources SET balance = balance \+ CAST\(\? AS DECIMAL\(12, 2\)\)`).
+			WithArgs(interfaces.MoneyFromFloat(-120.5), sqlmock.AnyArg(), sourceID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockM.ExpectExec(`^UPDATE sources SET balance = balance \+ CAST\(\? AS DECIMAL\(12, 2\)\)`).
+			WithArgs(interfaces.MoneyFromFloat(120.5), sqlmock.AnyArg(), int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockM.ExpectExec(`^UPDATE sources SET deleted_at = \?`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockM.ExpectCommit()
+		mockTransactionModel.On("GetNetAmountBySource", mock.Anything, sourceID, mock.Anything).Return(interfaces.MoneyFromFloat(120.5), nil).Once()
+
+		assert.NoError(t, ModelsService.SourceModel.DeleteSource(ctx, sourceID, 2, scopeID))
+		assert.NoError(t, mockM.ExpectationsWereMet())
+		mockTransactionModel.AssertExpectations(t)
+	})
+
+	t.Run("Target in another currency", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		expectUsage(mockM, 5, 0)
+		mockM.ExpectQuery(`^SELECT currency FROM sources`).WithArgs(int64(1), sourceID).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("EUR"))
+		mockM.ExpectQuery(`^SELECT currency FROM sources`).WithArgs(int64(1), int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("USD"))
+		mockM.ExpectRollback()
+
+		err := ModelsService.SourceModel.DeleteSource(ctx, sourceID, 2, scopeID)
+		assert.Equal(t, ErrInvalidReassignTarget, errors.Cause(err))
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
 }
 
 func TestGetSourceByID(t *testing.T) {
@@ -204,8 +291,8 @@ func TestGetSourceByID(t *testing.T) {
 	}
 	ModelsService = mockModelService
 	// Set up expectations
-	rows := sqlmock.NewRows([]string{"source_id", "user_id", "name", "type", "balance", "scope_id", "created_at", "updated_at"}).
-		AddRow(1, 1, "Test Source", "CREDIT", 100.0, 1, time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"source_id", "user_id", "name", "type", "balance", "opening_balance", "currency", "scope_id", "created_at", "updated_at"}).
+		AddRow(1, 1, "Test Source", "CREDIT", 100.0, 100.0, "USD", 1, time.Now(), time.Now())
 	mock.ExpectQuery("^SELECT (.+) FROM sources WHERE").WithArgs(1, 1).WillReturnRows(rows)
 
 	// Call the function under test
@@ -219,7 +306,7 @@ func TestGetSourceByID(t *testing.T) {
 	assert.Equal(t, int64(1), source.UserID)
 	assert.Equal(t, "Test Source", source.Name)
 	assert.Equal(t, "CREDIT", source.Type)
-	assert.Equal(t, 100.0, source.Balance)
+	assert.Equal(t, interfaces.MoneyFromFloat(100), source.Balance)
 	assert.Equal(t, int64(1), source.ScopeID)
 
 	// Ensure all expectations were met
@@ -268,12 +355,12 @@ func TestGetSources(t *testing.T) {
 	}
 	ModelsService = mockModelService
 
-	mockRows := sqlmock.NewRows([]string{"source_id", "user_id", "name", "type", "balance", "scope_id", "created_at", "updated_at"}).
-		AddRow(1, userID, "Source 1", "CREDIT", 100.00, scopes[0], time.Now(), time.Now()).
-		AddRow(2, userID, "Source 2", "SAVINGS", 200.00, scopes[0], time.Now(), time.Now())
+	mockRows := sqlmock.NewRows([]string{"source_id", "user_id", "name", "type", "balance", "opening_balance", "currency", "scope_id", "created_at", "updated_at"}).
+		AddRow(1, userID, "Source 1", "CREDIT", 100.00, 100.00, "USD", scopes[0], time.Now(), time.Now()).
+		AddRow(2, userID, "Source 2", "SAVINGS", 200.00, 0.00, "USD", scopes[0], time.Now(), time.Now())
 
 	// Set up the expected query with sqlmock
-	mock.ExpectQuery(`SELECT source_id, user_id, name, type, balance, scope_id, created_at, updated_at FROM sources WHERE scope_id IN (?)`).
+	mock.ExpectQuery(`SELECT source_id, user_id, name, type, balance, opening_balance, currency, scope_id, created_at, updated_at FROM sources WHERE scope_id IN (?)`).
 		WithArgs(scopes[0]).
 		WillReturnRows(mockRows)
 
@@ -289,7 +376,7 @@ func TestGetSources(t *testing.T) {
 	}
 
 	//test for generic query error
-	mock.ExpectQuery(`SELECT source_id, user_id, name, type, balance, scope_id, created_at, updated_at FROM sources WHERE scope_id IN (?)`).
+	mock.ExpectQuery(`SELECT source_id, user_id, name, type, balance, opening_balance, currency, scope_id, created_at, updated_at FROM sources WHERE scope_id IN (?)`).
 		WithArgs(scopes[0]).
 		WillReturnError(errors.New("query execution error"))
 
@@ -298,12 +385,12 @@ func TestGetSources(t *testing.T) {
 	assert.Error(t, err)
 
 	//test row processing error
-	rows := sqlmock.NewRows([]string{"source_id", "user_id", "name", "type", "balance", "scope_id", "created_at", "updated_at"}).
-		AddRow(1, userID, "Source 1", "CREDIT", 100.00, scopes[0], time.Now(), time.Now()).
-		AddRow(2, userID, "Source 2", "SAVINGS", 200.00, scopes[0], time.Now(), time.Now()).
+	rows := sqlmock.NewRows([]string{"source_id", "user_id", "name", "type", "balance", "opening_balance", "currency", "scope_id", "created_at", "updated_at"}).
+		AddRow(1, userID, "Source 1", "CREDIT", 100.00, 100.00, "USD", scopes[0], time.Now(), time.Now()).
+		AddRow(2, userID, "Source 2", "SAVINGS", 200.00, 0.00, "USD", scopes[0], time.Now(), time.Now()).
 		RowError(1, errors.New("row processing error"))
 
-	mock.ExpectQuery(`SELECT source_id, user_id, name, type, balance, scope_id, created_at, updated_at FROM sources WHERE scope_id IN (?)`).
+	mock.ExpectQuery(`SELECT source_id, user_id, name, type, balance, opening_balance, currency, scope_id, created_at, updated_at FROM sources WHERE scope_id IN (?)`).
 		WithArgs(scopes[0]).
 		WillReturnRows(rows)
 
@@ -345,7 +432,7 @@ func TestSourceIDExists(t *testing.T) {
 	{
 		mockRows := sqlmock.NewRows([]string{"1"}).AddRow(1) // Assuming the source exists
 		args := prepareArgsForSQLMock(scopes, sourceID)
-		mock.ExpectQuery(`SELECT 1 FROM sources WHERE .+ AND source_id = \? LIMIT 1`).
+		mock.ExpectQuery(`SELECT 1 FROM sources WHERE .+ AND source_id = \? AND deleted_at IS NULL LIMIT 1`).
 			WithArgs(args...).
 			WillReturnRows(mockRows)
 
@@ -357,7 +444,7 @@ func TestSourceIDExists(t *testing.T) {
 	// Test case 2: No rows found
 	{
 		args := prepareArgsForSQLMock(scopes, sourceID)
-		mock.ExpectQuery(`SELECT 1 FROM sources WHERE .+ AND source_id = \? LIMIT 1`).
+		mock.ExpectQuery(`SELECT 1 FROM sources WHERE .+ AND source_id = \? AND deleted_at IS NULL LIMIT 1`).
 			WithArgs(args...).
 			WillReturnError(sql.ErrNoRows)
 
@@ -369,7 +456,7 @@ func TestSourceIDExists(t *testing.T) {
 	// Test case 3: Generic query execution error
 	{
 		args := prepareArgsForSQLMock(scopes, sourceID)
-		mock.ExpectQuery(`SELECT 1 FROM sources WHERE .+ AND source_id = \? LIMIT 1`).
+		mock.ExpectQuery(`SELECT 1 FROM sources WHERE .+ AND source_id = \? AND deleted_at IS NULL LIMIT 1`).
 			WithArgs(args...).
 			WillReturnError(errors.New("query execution error"))
 
@@ -392,3 +479,86 @@ func prepareArgsForSQLMock(scopes []int64, sourceID int64) []driver.Value {
 	args = append(args, sourceID)
 	return args
 }
+
+func TestAdjustBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	ModelsService = &ModelsServiceContainer{
+		DBService:   &DBService{Executor: db},
+		SourceModel: NewSourceModel(),
+	}
+
+	mock.ExpectExec("^UPDATE sources SET balance = balance \\+ CAST\\(\\? AS DECIMAL\\(12, 2\\)\\)").
+		WithArgs(interfaces.MoneyFromFloat(-25.5), sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = ModelsService.SourceModel.AdjustBalance(context.Background(), 1, interfaces.MoneyFromFloat(-25.5))
+	assert.NoError(t, err)
+
+	// A zero delta or a transaction without a source leaves balances untouched
+	assert.NoError(t, ModelsService.SourceModel.AdjustBalance(context.Background(), 1, 0))
+	assert.NoError(t, ModelsService.SourceModel.AdjustBalance(context.Background(), 0, 10))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReconcileBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	ModelsService = &ModelsServiceContainer{
+		DBService:        &DBService{Executor: db},
+		SourceModel:      NewSourceModel(),
+		TransactionModel: NewTransactionModel(),
+	}
+	ctx := context.Background()
+
+	t.Run("Drifted balance is corrected", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("^SELECT balance, opening_balance FROM sources WHERE (.+) FOR UPDATE").
+			WithArgs(int64(1), int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "opening_balance"}).AddRow(120.0, 100.0))
+		mock.ExpectQuery("^SELECT COALESCE\\(SUM\\(CASE (.+) FROM transactions WHERE source_id = \\?").
+			WithArgs(TransactionTypeIncome, TransactionTypeExpense, TransactionTypeTransfer, TransactionTypeTransfer, int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"net"}).AddRow(-30.25))
+		mock.ExpectExec("^UPDATE sources SET balance = \\?").
+			WithArgs(interfaces.MoneyFromFloat(69.75), sqlmock.AnyArg(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		result, err := ModelsService.SourceModel.ReconcileBalance(ctx, 1, []int64{1})
+		assert.NoError(t, err)
+		assert.Equal(t, &interfaces.SourceReconciliation{SourceID: 1, StoredBalance: interfaces.MoneyFromFloat(120.0), ComputedBalance: interfaces.MoneyFromFloat(69.75), Drift: interfaces.MoneyFromFloat(50.25), Corrected: true}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Balance in sync", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("^SELECT balance, opening_balance FROM sources WHERE (.+) FOR UPDATE").
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "opening_balance"}).AddRow(150.0, 100.0))
+		mock.ExpectQuery("^SELECT COALESCE\\(SUM\\(CASE (.+) FROM transactions").
+			WillReturnRows(sqlmock.NewRows([]string{"net"}).AddRow(50.0))
+		mock.ExpectCommit()
+
+		result, err := ModelsService.SourceModel.ReconcileBalance(ctx, 1, []int64{1})
+		assert.NoError(t, err)
+		assert.False(t, result.Corrected)
+		assert.Equal(t, interfaces.Money(0), result.Drift)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Source not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("^SELECT balance, opening_balance FROM sources WHERE").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := ModelsService.SourceModel.ReconcileBalance(ctx, 1, []int64{1})
+		assert.Equal(t, ErrSourceNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
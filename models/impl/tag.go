@@ -43,6 +43,7 @@ type TagModel struct {
 	ColumnScope      string
 	ColumnCreatedAt  string
 	ColumnUpdatedAt  string
+	ColumnDeletedAt  string
 	MaxTagNameLength int
 }
 
@@ -55,6 +56,7 @@ func NewTagModel() *TagModel {
 		ColumnScope:      "scope_id",
 		ColumnCreatedAt:  "created_at",
 		ColumnUpdatedAt:  "updated_at",
+		ColumnDeletedAt:  "deleted_at",
 		MaxTagNameLength: 255, // Adjust as per your requirement
 	}
 }
@@ -102,6 +104,7 @@ func (tm *TagModel) UpdateTag(ctx context.Context, tag *interfaces.Tag, otx ...*
 		Set(tm.ColumnName, tag.Name).
 		Set(tm.ColumnUpdatedAt, tag.UpdatedAt).
 		Where(squirrel.Eq{tm.ColumnID: tag.ID, tm.ColumnScope: tag.ScopeID}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil}).
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 
@@ -118,11 +121,14 @@ func (tm *TagModel) UpdateTag(ctx context.Context, tag *interfaces.Tag, otx ...*
 	return nil
 }
 
+// DeleteTag moves a tag to the trash. Transactions keep the tag but no longer show it until it is restored.
 func (tm *TagModel) DeleteTag(ctx context.Context, tagID int64, scopes []int64, otx ...*sql.Tx) error {
 	isExternalTx, executor := getExecutor(otx...)
 
-	query, args, err := squirrel.Delete(tm.TableTags).
+	query, args, err := squirrel.Update(tm.TableTags).
+		Set(tm.ColumnDeletedAt, time.Now()).
 		Where(squirrel.Eq{tm.ColumnID: tagID, tm.ColumnScope: scopes}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil}).
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 
@@ -146,6 +152,7 @@ func (tm *TagModel) GetTagByID(ctx context.Context, tagID int64, scopes []int64,
 	query, args, err := squirrel.Select(tm.ColumnID, tm.ColumnUserID, tm.ColumnName, tm.ColumnScope, tm.ColumnCreatedAt, tm.ColumnUpdatedAt).
 		From(tm.TableTags).
 		Where(squirrel.Eq{tm.ColumnID: tagID, tm.ColumnScope: scopes}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil}).
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 
@@ -172,6 +179,7 @@ func (tm *TagModel) GetScopedTags(ctx context.Context, scopes []int64, paginatio
 	query, args, err := squirrel.Select(tm.ColumnID, tm.ColumnUserID, tm.ColumnName, tm.ColumnScope, tm.ColumnCreatedAt, tm.ColumnUpdatedAt).
 		From(tm.TableTags).
 		Where(squirrel.Eq{tm.ColumnScope: scopes}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil}).
 		Limit(uint64(pagination.Limit)).
 		Offset(uint64(pagination.Offset)).
 		PlaceholderFormat(squirrel.Question).
@@ -204,12 +212,50 @@ func (tm *TagModel) GetScopedTags(ctx context.Context, scopes []int64, paginatio
 	return tags, nil
 }
 
+// GetTagsByCursor retrieves a page of the tags in scopes, oldest first.
+func (tm *TagModel) GetTagsByCursor(ctx context.Context, scopes []int64, params interfaces.CursorParams, otx ...*sql.Tx) ([]interfaces.Tag, interfaces.CursorPage, error) {
+	_, executor := getExecutor(otx...)
+
+	query := GetQueryBuilder().Select(tm.ColumnID, tm.ColumnUserID, tm.ColumnName, tm.ColumnScope, tm.ColumnCreatedAt, tm.ColumnUpdatedAt).
+		From(tm.TableTags).
+		Where(squirrel.Eq{tm.ColumnScope: scopes}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil})
+	sql, args, err := applyCursor(query, tm.ColumnCreatedAt, tm.ColumnID, false, params).ToSql()
+	if err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "failed to build query for a page of tags")
+	}
+
+	rows, err := executor.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "failed to retrieve a page of tags")
+	}
+	defer rows.Close()
+
+	tags := make([]interfaces.Tag, 0)
+	for rows.Next() {
+		var tag interfaces.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.ScopeID, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, interfaces.CursorPage{}, errors.Wrap(err, "failed to scan tag")
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, interfaces.CursorPage{}, errors.Wrap(err, "failed to iterate over a page of tags")
+	}
+
+	tags, page := pageByCursor(tags, func(tag interfaces.Tag) interfaces.Cursor {
+		return interfaces.Cursor{Time: tag.CreatedAt, ID: tag.ID}
+	}, params)
+	return tags, page, nil
+}
+
 func (tm *TagModel) GetTagByName(ctx context.Context, name string, scopes []int64, otx ...*sql.Tx) (*interfaces.Tag, error) {
 	_, executor := getExecutor(otx...)
 
 	query, args, err := squirrel.Select(tm.ColumnID, tm.ColumnUserID, tm.ColumnName, tm.ColumnScope, tm.ColumnCreatedAt, tm.ColumnUpdatedAt).
 		From(tm.TableTags).
 		Where(squirrel.Eq{tm.ColumnName: name, tm.ColumnScope: scopes}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil}).
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 
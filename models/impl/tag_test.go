@@ -195,7 +195,7 @@ func TestRetrieveAllTagsForUser(t *testing.T) {
 
 		// Use a lenient regular expression that focuses on key parts of the query
 		// Update ExpectQuery to reflect the actual arguments used in GetAllTags
-	mock.ExpectQuery(`SELECT tag_id, user_id, name, scope_id, created_at, updated_at FROM tags WHERE scope_id IN \(\?,\?,\?\) LIMIT 10 OFFSET 0`).
+	mock.ExpectQuery(`SELECT tag_id, user_id, name, scope_id, created_at, updated_at FROM tags WHERE scope_id IN \(\?,\?,\?\) AND deleted_at IS NULL LIMIT 10 OFFSET 0`).
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()). // Only include userID if that's the only argument used
 		WillReturnRows(mockRows)
 
@@ -0,0 +1,309 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// TemplateDefault is the built-in template new users start with unless told otherwise.
+	TemplateDefault = "default"
+	// TemplateNone is the built-in empty template, for scopes that should start with nothing.
+	TemplateNone = "none"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("seed template not found")
+	ErrInvalidTemplate  = errors.New("invalid seed template")
+)
+
+// builtInTemplates are always available. A template file can replace them by reusing their names.
+var builtInTemplates = []interfaces.SeedTemplate{
+	{
+		Name:        TemplateDefault,
+		Description: "Common household categories with cash, bank and credit card sources",
+		Categories: []interfaces.SeedCategory{
+			{Name: "Food", Icon: "restaurant", Children: []interfaces.SeedCategory{
+				{Name: "Groceries", Icon: "cart"},
+				{Name: "Dining Out", Icon: "restaurant"},
+			}},
+			{Name: "Housing", Icon: "home", Children: []interfaces.SeedCategory{
+				{Name: "Rent", Icon: "home"},
+				{Name: "Utilities", Icon: "bolt"},
+			}},
+			{Name: "Transport", Icon: "car", Children: []interfaces.SeedCategory{
+				{Name: "Fuel", Icon: "fuel"},
+				{Name: "Public Transport", Icon: "bus"},
+			}},
+			{Name: "Health", Icon: "health"},
+			{Name: "Shopping", Icon: "bag"},
+			{Name: "Entertainment", Icon: "movie"},
+			{Name: "Income", Icon: "wallet", Children: []interfaces.SeedCategory{
+				{Name: "Salary", Icon: "wallet"},
+			}},
+			{Name: "Other", Icon: "other"},
+		},
+		Sources: []interfaces.SeedSource{
+			{Name: "Cash", Type: "SAVINGS"},
+			{Name: "Bank Account", Type: "SAVINGS"},
+			{Name: "Credit Card", Type: "CREDIT"},
+		},
+	},
+	{
+		Name:        TemplateNone,
+		Description: "No categories or sources",
+	},
+}
+
+// TemplateModel holds the templates new scopes can be seeded with: the built-in ones and
+// those an administrator defines in a JSON or YAML file.
+type TemplateModel struct {
+	// DefaultTemplate is applied to the personal scope of users who register without naming one.
+	DefaultTemplate string
+	templates       map[string]interfaces.SeedTemplate
+	names           []string
+	categories      *CategoryModel
+	sources         *SourceModel
+}
+
+// NewTemplateModel returns a model with only the built-in templates.
+func NewTemplateModel() *TemplateModel {
+	tm := &TemplateModel{
+		DefaultTemplate: TemplateDefault,
+		templates:       make(map[string]interfaces.SeedTemplate),
+		categories:      NewCategoryModel(),
+		sources:         NewSourceModel(),
+	}
+	for _, template := range builtInTemplates {
+		tm.addTemplate(template)
+	}
+	return tm
+}
+
+// NewTemplateModelFromEnv adds the templates of the file named by SEED_TEMPLATES_FILE, if set,
+// to the built-in ones. SEED_TEMPLATE_DEFAULT names the template new users get.
+func NewTemplateModelFromEnv() (*TemplateModel, error) {
+	tm := NewTemplateModel()
+	if path := os.Getenv("SEED_TEMPLATES_FILE"); path != "" {
+		if err := tm.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if name := os.Getenv("SEED_TEMPLATE_DEFAULT"); name != "" {
+		if _, ok := tm.templates[name]; !ok {
+			return nil, errors.Wrapf(ErrTemplateNotFound, "SEED_TEMPLATE_DEFAULT %q", name)
+		}
+		tm.DefaultTemplate = name
+	}
+	return tm, nil
+}
+
+// LoadFile reads a list of templates from a .json, .yaml or .yml file.
+// A template with the name of an existing one replaces it.
+func (tm *TemplateModel) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "reading seed templates file failed")
+	}
+
+	var templates []interfaces.SeedTemplate
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &templates)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &templates)
+	default:
+		return errors.Errorf("seed templates file must be .json, .yaml or .yml, got %q", path)
+	}
+	if err != nil {
+		return errors.Wrap(err, "parsing seed templates file failed")
+	}
+
+	seen := make(map[string]bool)
+	for i := range templates {
+		if err := tm.validateTemplate(&templates[i]); err != nil {
+			return err
+		}
+		if seen[templates[i].Name] {
+			return errors.Wrapf(ErrInvalidTemplate, "template %q is defined twice", templates[i].Name)
+		}
+		seen[templates[i].Name] = true
+	}
+	for _, template := range templates {
+		tm.addTemplate(template)
+	}
+	log.Printf("[TemplateModel] Loaded %d seed templates from %s", len(templates), path)
+	return nil
+}
+
+func (tm *TemplateModel) addTemplate(template interfaces.SeedTemplate) {
+	if _, ok := tm.templates[template.Name]; !ok {
+		tm.names = append(tm.names, template.Name)
+	}
+	tm.templates[template.Name] = template
+}
+
+// validateTemplate checks a template against the rules categories and sources are created with,
+// so that applying it cannot fail halfway through a registration.
+func (tm *TemplateModel) validateTemplate(template *interfaces.SeedTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return errors.Wrap(ErrInvalidTemplate, "template name is required")
+	}
+	if err := tm.validateCategories(template.Name, template.Categories, 1); err != nil {
+		return err
+	}
+	for i := range template.Sources {
+		source := &template.Sources[i]
+		if source.Name == "" {
+			return errors.Wrapf(ErrInvalidTemplate, "template %q: source name is required", template.Name)
+		}
+		source.Type = strings.ToUpper(source.Type)
+		if source.Type != tm.sources.SourceTypeCredit && source.Type != tm.sources.SourceTypeSavings {
+			return errors.Wrapf(ErrInvalidTemplate, "template %q: source %q must be CREDIT or SAVINGS", template.Name, source.Name)
+		}
+		if source.Currency != "" {
+			currency, err := NormalizeCurrency(source.Currency)
+			if err != nil {
+				return errors.Wrapf(err, "template %q: source %q", template.Name, source.Name)
+			}
+			source.Currency = currency
+		}
+	}
+	return nil
+}
+
+func (tm *TemplateModel) validateCategories(templateName string, categories []interfaces.SeedCategory, depth int) error {
+	if len(categories) > 0 && depth > MaxCategoryDepth {
+		return errors.Wrapf(ErrCategoryTooDeep, "template %q", templateName)
+	}
+	for _, category := range categories {
+		if category.Name == "" || len(category.Name) > tm.categories.MaxCategoryNameLength || len(category.Description) > tm.categories.MaxCategoryDescriptionLength {
+			return errors.Wrapf(ErrInvalidTemplate, "template %q: category %q must have a name of at most %d characters and a description of at most %d",
+				templateName, category.Name, tm.categories.MaxCategoryNameLength, tm.categories.MaxCategoryDescriptionLength)
+		}
+		if err := tm.validateCategories(templateName, category.Children, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTemplates returns every template, built-in ones first.
+func (tm *TemplateModel) ListTemplates() []interfaces.SeedTemplate {
+	templates := make([]interfaces.SeedTemplate, 0, len(tm.names))
+	for _, name := range tm.names {
+		templates = append(templates, tm.templates[name])
+	}
+	return templates
+}
+
+// GetTemplate returns the named template, or the default one when name is empty.
+func (tm *TemplateModel) GetTemplate(name string) (*interfaces.SeedTemplate, error) {
+	if name == "" {
+		name = tm.DefaultTemplate
+	}
+	template, ok := tm.templates[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrTemplateNotFound, "%q", name)
+	}
+	return &template, nil
+}
+
+// ApplyTemplate creates the categories and sources of a template in a scope on behalf of userID,
+// all in one SQL transaction. An empty name applies the default template.
+// Rows are written directly rather than through the category and source models, since the
+// scope and the user's access to it may only exist in the caller's uncommitted transaction.
+func (tm *TemplateModel) ApplyTemplate(ctx context.Context, name string, userID int64, scopeID int64, otx ...*sql.Tx) error {
+	template, err := tm.GetTemplate(name)
+	if err != nil {
+		return err
+	}
+	if len(template.Categories) == 0 && len(template.Sources) == 0 {
+		return nil
+	}
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		if err := tm.insertCategories(ctx, template.Categories, 0, userID, scopeID, tx); err != nil {
+			return err
+		}
+		return tm.insertSources(ctx, template.Sources, userID, scopeID, tx)
+	}, otx...)
+}
+
+func (tm *TemplateModel) insertCategories(ctx context.Context, categories []interfaces.SeedCategory, parentID int64, userID int64, scopeID int64, tx *sql.Tx) error {
+	_, executor := getExecutor(tx)
+	cm := tm.categories
+
+	for _, seed := range categories {
+		categoryID, err := util.GenerateSnowflakeID()
+		if err != nil {
+			return errors.Wrap(err, "generating Snowflake ID failed")
+		}
+		now := time.Now()
+
+		query, args, err := GetQueryBuilder().Insert(cm.TableCategories).
+			Columns(cm.ColumnID, cm.ColumnUserID, cm.ColumnName, cm.ColumnDescription, cm.ColumnIcon, cm.ColumnScopeID, cm.ColumnParentID, cm.ColumnCreatedAt, cm.ColumnUpdatedAt).
+			Values(categoryID, userID, seed.Name, seed.Description, seed.Icon, scopeID, sql.NullInt64{Int64: parentID, Valid: parentID > 0}, now, now).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "preparing seed category insert failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrapf(err, "seeding category %q failed", seed.Name)
+		}
+
+		if err := tm.insertCategories(ctx, seed.Children, categoryID, userID, scopeID, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tm *TemplateModel) insertSources(ctx context.Context, sources []interfaces.SeedSource, userID int64, scopeID int64, tx *sql.Tx) error {
+	_, executor := getExecutor(tx)
+	sm := tm.sources
+
+	var baseCurrency string
+	for _, seed := range sources {
+		currency := seed.Currency
+		if currency == "" {
+			if baseCurrency == "" {
+				var err error
+				if baseCurrency, err = GetModelsService().CurrencyModel.GetBaseCurrency(ctx, scopeID, tx); err != nil {
+					return errors.Wrap(err, "resolving currency for seed sources")
+				}
+			}
+			currency = baseCurrency
+		}
+
+		sourceID, err := util.GenerateSnowflakeID()
+		if err != nil {
+			return errors.Wrap(err, "generating Snowflake ID failed")
+		}
+		now := time.Now()
+
+		query, args, err := GetQueryBuilder().Insert(sm.TableSources).
+			Columns(sm.selectColumns()...).
+			Values(sourceID, userID, seed.Name, seed.Type, interfaces.Money(0), interfaces.Money(0), currency, scopeID, now, now).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "preparing seed source insert failed")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrapf(err, "seeding source %q failed", seed.Name)
+		}
+	}
+	return nil
+}
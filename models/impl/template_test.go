@@ -0,0 +1,181 @@
+package impl
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func writeTemplateFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing template file: %v", err)
+	}
+	return path
+}
+
+func TestLoadTemplateFile(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		tm := NewTemplateModel()
+		path := writeTemplateFile(t, "templates.yaml", `
+- name: student
+  description: On a budget
+  categories:
+    - name: Books
+    - name: Food
+      children:
+        - name: Canteen
+  sources:
+    - name: Wallet
+      type: savings
+      currency: eur
+`)
+		assert.NoError(t, tm.LoadFile(path))
+
+		names := []string{}
+		for _, template := range tm.ListTemplates() {
+			names = append(names, template.Name)
+		}
+		assert.Equal(t, []string{TemplateDefault, TemplateNone, "student"}, names)
+
+		student, err := tm.GetTemplate("student")
+		assert.NoError(t, err)
+		assert.Equal(t, "Canteen", student.Categories[1].Children[0].Name)
+		assert.Equal(t, interfaces.SeedSource{Name: "Wallet", Type: "SAVINGS", Currency: "EUR"}, student.Sources[0])
+	})
+
+	t.Run("JSON replaces a built-in template", func(t *testing.T) {
+		tm := NewTemplateModel()
+		path := writeTemplateFile(t, "templates.json", `[{"name": "default", "categories": [{"name": "Everything"}]}]`)
+		assert.NoError(t, tm.LoadFile(path))
+
+		assert.Len(t, tm.ListTemplates(), 2)
+		template, err := tm.GetTemplate("")
+		assert.NoError(t, err)
+		assert.Equal(t, []interfaces.SeedCategory{{Name: "Everything"}}, template.Categories)
+	})
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected error
+	}{
+		{"Missing name", "t.json", `[{"categories": [{"name": "Food"}]}]`, ErrInvalidTemplate},
+		{"Defined twice", "t.json", `[{"name": "a"}, {"name": "a"}]`, ErrInvalidTemplate},
+		{"Unnamed category", "t.json", `[{"name": "a", "categories": [{"icon": "x"}]}]`, ErrInvalidTemplate},
+		{"Invalid source type", "t.json", `[{"name": "a", "sources": [{"name": "Cash", "type": "LOAN"}]}]`, ErrInvalidTemplate},
+		{"Invalid currency", "t.json", `[{"name": "a", "sources": [{"name": "Cash", "type": "SAVINGS", "currency": "euro"}]}]`, ErrInvalidCurrency},
+		{"Too deep", "t.yml", "- name: a\n  categories:\n    - name: 1\n      children:\n        - name: 2\n          children:\n            - name: 3\n              children:\n                - name: 4\n                  children:\n                    - name: 5\n                      children:\n                        - name: 6\n", ErrCategoryTooDeep},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tm := NewTemplateModel()
+			err := tm.LoadFile(writeTemplateFile(t, tc.file, tc.content))
+			assert.Equal(t, tc.expected, errors.Cause(err))
+			assert.Len(t, tm.ListTemplates(), 2, "nothing is loaded from an invalid file")
+		})
+	}
+
+	t.Run("Unsupported extension", func(t *testing.T) {
+		assert.Error(t, NewTemplateModel().LoadFile(writeTemplateFile(t, "templates.txt", "[]")))
+	})
+}
+
+func TestNewTemplateModelFromEnv(t *testing.T) {
+	path := writeTemplateFile(t, "templates.json", `[{"name": "starter", "sources": [{"name": "Cash", "type": "SAVINGS"}]}]`)
+
+	t.Setenv("SEED_TEMPLATES_FILE", path)
+	t.Setenv("SEED_TEMPLATE_DEFAULT", "starter")
+	tm, err := NewTemplateModelFromEnv()
+	assert.NoError(t, err)
+	template, err := tm.GetTemplate("")
+	assert.NoError(t, err)
+	assert.Equal(t, "starter", template.Name)
+
+	t.Setenv("SEED_TEMPLATE_DEFAULT", "unknown")
+	_, err = NewTemplateModelFromEnv()
+	assert.Equal(t, ErrTemplateNotFound, errors.Cause(err))
+}
+
+func TestGetTemplate(t *testing.T) {
+	tm := NewTemplateModel()
+
+	template, err := tm.GetTemplate("")
+	assert.NoError(t, err)
+	assert.Equal(t, TemplateDefault, template.Name)
+
+	_, err = tm.GetTemplate("unknown")
+	assert.Equal(t, ErrTemplateNotFound, errors.Cause(err))
+}
+
+func TestApplyTemplate(t *testing.T) {
+	mockCurrencyModel := new(xmock.MockCurrencyModel)
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.CurrencyModel = mockCurrencyModel
+	})
+	defer tearDown()
+
+	tm := NewTemplateModel()
+	tm.addTemplate(interfaces.SeedTemplate{
+		Name:       "starter",
+		Categories: []interfaces.SeedCategory{{Name: "Food", Children: []interfaces.SeedCategory{{Name: "Groceries"}}}},
+		Sources:    []interfaces.SeedSource{{Name: "Cash", Type: "SAVINGS"}, {Name: "Travel Card", Type: "CREDIT", Currency: "EUR"}},
+	})
+
+	t.Run("Creates categories and sources in one transaction", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		mockCurrencyModel.On("GetBaseCurrency", mock.Anything, int64(20), mock.AnythingOfType("[]*sql.Tx")).Return("INR", nil).Once()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec("^INSERT INTO categories \\(category_id,user_id,name,description,icon,scope_id,parent_id,created_at,updated_at\\)").
+			WithArgs(sqlmock.AnyArg(), int64(10), "Food", "", "", int64(20), sql.NullInt64{}, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec("^INSERT INTO categories").
+			WithArgs(sqlmock.AnyArg(), int64(10), "Groceries", "", "", int64(20), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec("^INSERT INTO sources").
+			WithArgs(sqlmock.AnyArg(), int64(10), "Cash", "SAVINGS", interfaces.Money(0), interfaces.Money(0), "INR", int64(20), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec("^INSERT INTO sources").
+			WithArgs(sqlmock.AnyArg(), int64(10), "Travel Card", "CREDIT", interfaces.Money(0), interfaces.Money(0), "EUR", int64(20), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		assert.NoError(t, tm.ApplyTemplate(ctx, "starter", 10, 20))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		mockCurrencyModel.AssertExpectations(t)
+	})
+
+	t.Run("Rolls back when an insert fails", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec("^INSERT INTO categories").WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec("^INSERT INTO categories").WillReturnError(errors.New("db error"))
+		sqlMock.ExpectRollback()
+
+		assert.Error(t, tm.ApplyTemplate(ctx, "starter", 10, 20))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Empty template writes nothing", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+
+		assert.NoError(t, tm.ApplyTemplate(ctx, TemplateNone, 10, 20))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown template", func(t *testing.T) {
+		err := tm.ApplyTemplate(ctx, "unknown", 10, 20)
+		assert.Equal(t, ErrTemplateNotFound, errors.Cause(err))
+	})
+}
@@ -27,7 +27,8 @@ package impl
 import (
 	"context"
 	"database/sql"
-
+	"fmt"
+	"strings"
 	"time"
 
 	"xspends/models/interfaces"
@@ -38,10 +39,17 @@ import (
 )
 
 const (
-	TransactionTypeIncome  = "INCOME"
-	TransactionTypeExpense = "EXPENSE"
-	SortOrderAsc           = "ASC"
-	SortOrderDesc          = "DESC"
+	TransactionTypeIncome   = "INCOME"
+	TransactionTypeExpense  = "EXPENSE"
+	TransactionTypeTransfer = "TRANSFER"
+	SortOrderAsc            = "ASC"
+	SortOrderDesc           = "DESC"
+)
+
+var (
+	ErrInvalidTransfer     = errors.New("a transfer needs a positive amount and two different sources")
+	ErrTransferNotEditable = errors.New("transfers cannot be edited, delete and recreate them instead")
+	ErrInvalidTimestamp    = errors.New("timestamp must fall between 1970-01-02 and 2037-12-31")
 )
 
 type TransactionModel struct {
@@ -55,6 +63,12 @@ type TransactionModel struct {
 	ColumnType        string
 	ColumnDescription string
 	ColumnScope       string
+	ColumnDestination string
+	ColumnTransferID  string
+	ColumnImportBatch string
+	ColumnCurrency    string
+	ColumnCreatedAt   string
+	ColumnDeletedAt   string
 }
 
 func NewTransactionModel() *TransactionModel {
@@ -69,115 +83,568 @@ func NewTransactionModel() *TransactionModel {
 		ColumnType:        "type",
 		ColumnDescription: "description",
 		ColumnScope:       "scope_id",
+		ColumnDestination: "destination_source_id",
+		ColumnTransferID:  "transfer_id",
+		ColumnImportBatch: "import_batch_id",
+		ColumnCurrency:    "currency",
+		ColumnCreatedAt:   "created_at",
+		ColumnDeletedAt:   "deleted_at",
 	}
 }
 
-// InsertTransaction inserts a new transaction into the database.
-func (tm *TransactionModel) InsertTransaction(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
+func (tm *TransactionModel) selectColumns() []string {
+	return []string{tm.ColumnID, tm.ColumnUserID, tm.ColumnSourceID, tm.ColumnCategoryID, tm.ColumnTimestamp, tm.ColumnAmount, tm.ColumnType, tm.ColumnDescription, tm.ColumnScope, tm.ColumnDestination, tm.ColumnTransferID, tm.ColumnCurrency, tm.ColumnCreatedAt}
+}
+
+// scanTransaction reads a row selected with selectColumns. Category, destination and
+// transfer are nullable because transfer legs may carry no category.
+func scanTransaction(scanner interface{ Scan(...interface{}) error }, transaction *interfaces.Transaction) error {
+	var categoryID, destinationID, transferID sql.NullInt64
+	if err := scanner.Scan(&transaction.ID, &transaction.UserID, &transaction.SourceID, &categoryID, &transaction.Timestamp, &transaction.Amount, &transaction.Type, &transaction.Description, &transaction.ScopeID, &destinationID, &transferID, &transaction.Currency, &transaction.CreatedAt); err != nil {
+		return err
+	}
+	transaction.CategoryID = categoryID.Int64
+	transaction.DestinationSourceID = destinationID.Int64
+	transaction.TransferID = transferID.Int64
+	return nil
+}
 
+// InsertTransaction inserts a new transaction into the database, dated now unless txn.Timestamp is set.
+// The source balance is adjusted in the same SQL transaction as the insert.
+// The categorization rules of the scope are applied first, unless txn.RulesApplied is set.
+// The description is added to the search index in the same SQL transaction.
+// TRANSFER transactions are recorded as two linked legs, see InsertTransfer.
+// A transaction with splits is stored without a category of its own, followed by its lines.
+func (tm *TransactionModel) InsertTransaction(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
+	if strings.ToUpper(txn.Type) == TransactionTypeTransfer {
+		_, err := tm.InsertTransfer(ctx, txn, otx...)
+		return err
+	}
 	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, txn.UserID, txn.ScopeID, RoleWrite) {
 		return errors.New("Scope validating failed")
 	}
-	if err := validateForeignKeyReferences(ctx, txn, otx...); err != nil {
-		return errors.Wrap(err, "validating foreign key references failed")
+	if txn.Timestamp.IsZero() {
+		txn.Timestamp = time.Now()
+	}
+	if err := checkTimestamp(txn.Timestamp); err != nil {
+		return err
 	}
 
-	txn.ID, _ = util.GenerateSnowflakeID()
-	txn.Timestamp = time.Now()
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
 
-	query, args, err := squirrel.Insert(tm.TableTransactions).
-		Columns(tm.ColumnID, tm.ColumnUserID, tm.ColumnSourceID, tm.ColumnCategoryID, tm.ColumnTimestamp, tm.ColumnAmount, tm.ColumnType, tm.ColumnDescription, tm.ColumnScope).
-		Values(txn.ID, txn.UserID, txn.SourceID, txn.CategoryID, txn.Timestamp, txn.Amount, txn.Type, txn.Description, txn.ScopeID).
-		PlaceholderFormat(squirrel.Question).
-		ToSql()
+		if !txn.RulesApplied {
+			if err := GetModelsService().RuleModel.ApplyRules(ctx, &txn, tx); err != nil {
+				return errors.Wrap(err, "applying categorization rules failed")
+			}
+		}
+
+		if err := validateForeignKeyReferences(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "validating foreign key references failed")
+		}
+
+		currency, err := sourceCurrency(ctx, txn, tx)
+		if err != nil {
+			return err
+		}
+		txn.Currency = currency
+		txn.Amount = txn.Amount.Round(currency)
+		if err := checkSplits(&txn); err != nil {
+			return err
+		}
+		if err := validateSplitCategories(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "validating split categories failed")
+		}
+
+		txn.ID, _ = util.GenerateSnowflakeID()
+
+		query, args, err := squirrel.Insert(tm.TableTransactions).
+			Columns(tm.ColumnID, tm.ColumnUserID, tm.ColumnSourceID, tm.ColumnCategoryID, tm.ColumnTimestamp, tm.ColumnAmount, tm.ColumnType, tm.ColumnDescription, tm.ColumnScope, tm.ColumnImportBatch, tm.ColumnCurrency).
+			Values(txn.ID, txn.UserID, txn.SourceID, sql.NullInt64{Int64: txn.CategoryID, Valid: txn.CategoryID > 0}, txn.Timestamp, txn.Amount, txn.Type, txn.Description, txn.ScopeID, sql.NullInt64{Int64: txn.ImportBatchID, Valid: txn.ImportBatchID != 0}, txn.Currency).
+			PlaceholderFormat(squirrel.Question).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "failed to build insert query for transaction")
+		}
+
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "insert transaction failed")
+		}
+		if err := GetModelsService().TransactionSearchModel.IndexTransaction(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "indexing transaction for search failed")
+		}
+
+		if err := GetModelsService().SourceModel.AdjustBalance(ctx, txn.SourceID, balanceDelta(txn.Type, txn.Amount), tx); err != nil {
+			return errors.Wrap(err, "updating source balance failed")
+		}
+
+		if err := addMissingTags(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "handling transaction tags failed")
+		}
+		// Associate tags with the transaction
+		if err := GetModelsService().TransactionTagModel.AddTagsToTransaction(ctx, txn.ID, txn.Tags, []int64{txn.ScopeID}, tx); err != nil {
+			return errors.Wrap(err, "adding tags to transaction failed")
+		}
+
+		if len(txn.Splits) > 0 {
+			if err := GetModelsService().TransactionSplitModel.ReplaceSplits(ctx, txn, tx); err != nil {
+				return errors.Wrap(err, "storing splits failed")
+			}
+		}
+		return nil
+	}, otx...)
+}
+
+// InsertTransfer moves txn.Amount from txn.SourceID to txn.DestinationSourceID, on txn.Timestamp or now.
+// It records one TRANSFER leg per source, both sharing the destination and a transfer ID,
+// and adjusts both balances in the same SQL transaction. The leg whose source is the
+// destination is the incoming one. The returned legs are ordered outgoing, incoming.
+// Each leg is in the currency of its source; between sources of different currencies the
+// incoming amount is converted at the scope's exchange rate for the day of the transfer.
+func (tm *TransactionModel) InsertTransfer(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) ([]interfaces.Transaction, error) {
+	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, txn.UserID, txn.ScopeID, RoleWrite) {
+		return nil, errors.New("Scope validating failed")
+	}
+	if txn.Amount <= 0 || txn.SourceID <= 0 || txn.DestinationSourceID <= 0 || txn.SourceID == txn.DestinationSourceID {
+		return nil, ErrInvalidTransfer
+	}
+	if len(txn.Splits) > 0 {
+		return nil, ErrInvalidSplit
+	}
+	if txn.Timestamp.IsZero() {
+		txn.Timestamp = time.Now()
+	}
+	if err := checkTimestamp(txn.Timestamp); err != nil {
+		return nil, err
+	}
+	txn.Type = TransactionTypeTransfer
+
+	legs := make([]interfaces.Transaction, 0, 2)
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		if err := validateForeignKeyReferences(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "validating foreign key references failed")
+		}
+		if err := addMissingTags(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "handling transaction tags failed")
+		}
+
+		transferID, err := util.GenerateSnowflakeID()
+		if err != nil {
+			return errors.Wrap(err, "generating transfer ID failed")
+		}
+		outgoing, incoming := txn, txn
+		incoming.SourceID = txn.DestinationSourceID
+		timestamp := txn.Timestamp
+
+		if outgoing.Currency, err = sourceCurrency(ctx, outgoing, tx); err != nil {
+			return err
+		}
+		outgoing.Amount = outgoing.Amount.Round(outgoing.Currency)
+		incoming.Amount = outgoing.Amount
+		incoming.Currency = ""
+		if incoming.Currency, err = sourceCurrency(ctx, incoming, tx); err != nil {
+			return err
+		}
+		if incoming.Currency != outgoing.Currency {
+			rate, err := GetModelsService().CurrencyModel.GetExchangeRate(ctx, txn.ScopeID, outgoing.Currency, incoming.Currency, timestamp, tx)
+			if err != nil {
+				return err
+			}
+			incoming.Amount = outgoing.Amount.Convert(rate, incoming.Currency)
+		}
+
+		for _, leg := range []interfaces.Transaction{outgoing, incoming} {
+			leg.ID, _ = util.GenerateSnowflakeID()
+			leg.TransferID = transferID
+			leg.Timestamp = timestamp
+			if err := tm.insertTransferLeg(ctx, leg, tx); err != nil {
+				return err
+			}
+			if err := GetModelsService().SourceModel.AdjustBalance(ctx, leg.SourceID, transactionDelta(leg), tx); err != nil {
+				return errors.Wrap(err, "updating source balance failed")
+			}
+			if err := GetModelsService().TransactionTagModel.AddTagsToTransaction(ctx, leg.ID, leg.Tags, []int64{leg.ScopeID}, tx); err != nil {
+				return errors.Wrap(err, "adding tags to transaction failed")
+			}
+			legs = append(legs, leg)
+		}
+		return nil
+	}, otx...)
 	if err != nil {
-		return errors.Wrap(err, "failed to build insert query for transaction")
+		return nil, err
 	}
+	return legs, nil
+}
 
-	if _, err := executor.ExecContext(ctx, query, args...); err != nil {
-		return errors.Wrap(err, "insert transaction failed")
+func (tm *TransactionModel) insertTransferLeg(ctx context.Context, leg interfaces.Transaction, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	categoryID := sql.NullInt64{Int64: leg.CategoryID, Valid: leg.CategoryID > 0}
+	query, args, err := GetQueryBuilder().Insert(tm.TableTransactions).
+		Columns(tm.ColumnID, tm.ColumnUserID, tm.ColumnSourceID, tm.ColumnCategoryID, tm.ColumnTimestamp, tm.ColumnAmount, tm.ColumnType, tm.ColumnDescription, tm.ColumnScope, tm.ColumnDestination, tm.ColumnTransferID, tm.ColumnCurrency).
+		Values(leg.ID, leg.UserID, leg.SourceID, categoryID, leg.Timestamp, leg.Amount, leg.Type, leg.Description, leg.ScopeID, leg.DestinationSourceID, leg.TransferID, leg.Currency).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "failed to build insert query for transfer leg")
 	}
 
-	if err := addMissingTags(ctx, txn, otx...); err != nil {
-		return errors.Wrap(err, "handling transaction tags failed")
+	if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "insert transfer leg failed")
 	}
-	// Associate tags with the transaction
-	if err := GetModelsService().TransactionTagModel.AddTagsToTransaction(ctx, txn.ID, txn.Tags, []int64{txn.ScopeID}, otx...); err != nil {
-		return errors.Wrap(err, "adding tags to transaction failed")
+	if err := GetModelsService().TransactionSearchModel.IndexTransaction(ctx, leg, otx...); err != nil {
+		return errors.Wrap(err, "indexing transfer leg for search failed")
 	}
-	commitOrRollback(executor, isExternalTx, err)
 	return nil
 }
 
+// UpdateTransaction updates a transaction, moving its effect on source balances from the old values to the new ones.
+// Its date is changed to txn.Timestamp unless that is zero.
+// Transfer legs cannot be edited and a transaction cannot be turned into a transfer.
+// The stored splits are replaced by txn.Splits; without splits the transaction is no longer split.
 func (tm *TransactionModel) UpdateTransaction(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
-
 	if !GetModelsService().UserScopeModel.ValidateUserScope(ctx, txn.UserID, txn.ScopeID, RoleWrite) {
 		return errors.New("Scope validating failed")
 	}
-	// Validate foreign key references
-	if err := validateForeignKeyReferences(ctx, txn, otx...); err != nil {
-		return errors.Wrap(err, "validating foreign key references failed")
+	if strings.ToUpper(txn.Type) == TransactionTypeTransfer {
+		return ErrTransferNotEditable
+	}
+	if !txn.Timestamp.IsZero() {
+		if err := checkTimestamp(txn.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		// Validate foreign key references
+		if err := validateForeignKeyReferences(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "validating foreign key references failed")
+		}
+
+		currency, err := sourceCurrency(ctx, txn, tx)
+		if err != nil {
+			return err
+		}
+		txn.Currency = currency
+		txn.Amount = txn.Amount.Round(currency)
+		if err := checkSplits(&txn); err != nil {
+			return err
+		}
+		if err := validateSplitCategories(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "validating split categories failed")
+		}
+
+		old, err := tm.getBalanceImpact(ctx, txn.ID, []int64{txn.ScopeID}, tx)
+		if err != nil {
+			return err
+		}
+		if old.TransferID != 0 {
+			return ErrTransferNotEditable
+		}
+		oldSourceID, oldDelta := old.SourceID, transactionDelta(*old)
+
+		// Update transaction in the database
+		update := GetQueryBuilder().Update(tm.TableTransactions).
+			Set(tm.ColumnSourceID, txn.SourceID).
+			Set(tm.ColumnCategoryID, sql.NullInt64{Int64: txn.CategoryID, Valid: txn.CategoryID > 0}).
+			Set(tm.ColumnAmount, txn.Amount).
+			Set(tm.ColumnCurrency, txn.Currency).
+			Set(tm.ColumnType, txn.Type).
+			Set(tm.ColumnDescription, txn.Description)
+		if !txn.Timestamp.IsZero() {
+			update = update.Set(tm.ColumnTimestamp, txn.Timestamp)
+		}
+		query, args, err := update.
+			Where(squirrel.Eq{tm.ColumnID: txn.ID, tm.ColumnScope: txn.ScopeID}).
+			PlaceholderFormat(squirrel.Question).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "failed to build update query for transaction")
+		}
+
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "update transaction failed")
+		}
+		if err := GetModelsService().TransactionSearchModel.IndexTransaction(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "indexing transaction for search failed")
+		}
+
+		newDelta := balanceDelta(txn.Type, txn.Amount)
+		if oldSourceID == txn.SourceID {
+			err = GetModelsService().SourceModel.AdjustBalance(ctx, txn.SourceID, newDelta-oldDelta, tx)
+		} else if err = GetModelsService().SourceModel.AdjustBalance(ctx, oldSourceID, -oldDelta, tx); err == nil {
+			err = GetModelsService().SourceModel.AdjustBalance(ctx, txn.SourceID, newDelta, tx)
+		}
+		if err != nil {
+			return errors.Wrap(err, "updating source balance failed")
+		}
+
+		// Add any missing tags and update tags associated with the transaction
+		if err := addMissingTags(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "adding missing tags failed")
+		}
+		if err := GetModelsService().TransactionTagModel.UpdateTagsForTransaction(ctx, txn.ID, txn.Tags, []int64{txn.ScopeID}, tx); err != nil {
+			return errors.Wrap(err, "updating tags for transaction failed")
+		}
+		if err := GetModelsService().TransactionSplitModel.ReplaceSplits(ctx, txn, tx); err != nil {
+			return errors.Wrap(err, "updating splits failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// DeleteTransaction moves a transaction to the trash and reverses its effect on the source balance.
+// Its tags, splits and shares are kept so that RestoreTransaction can bring it back as it was.
+// Deleting either leg of a transfer deletes the whole transfer.
+// Deleting a transaction that does not exist in the given scopes is a no-op.
+func (tm *TransactionModel) DeleteTransaction(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		impact, err := tm.getBalanceImpact(ctx, transactionID, scopes, tx)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		if impact.TransferID != 0 {
+			return tm.deleteTransfer(ctx, impact.TransferID, scopes, tx)
+		}
+
+		query, args, err := GetQueryBuilder().Update(tm.TableTransactions).
+			Set(tm.ColumnDeletedAt, time.Now()).
+			Where(squirrel.Eq{tm.ColumnID: transactionID, tm.ColumnScope: scopes}).
+			PlaceholderFormat(squirrel.Question).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "failed to build delete query for transaction")
+		}
+
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "delete transaction failed")
+		}
+
+		if err := GetModelsService().SourceModel.AdjustBalance(ctx, impact.SourceID, -transactionDelta(*impact), tx); err != nil {
+			return errors.Wrap(err, "updating source balance failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// deleteTransfer moves both legs of a transfer to the trash and reverses both balance changes.
+func (tm *TransactionModel) deleteTransfer(ctx context.Context, transferID int64, scopes []int64, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+
+	legs, err := tm.getTransferLegs(ctx, transferID, scopes, false, otx...)
+	if err != nil {
+		return err
 	}
 
-	// Update transaction in the database
 	query, args, err := GetQueryBuilder().Update(tm.TableTransactions).
-		Set(tm.ColumnSourceID, txn.SourceID).
-		Set(tm.ColumnCategoryID, txn.CategoryID).
-		Set(tm.ColumnAmount, txn.Amount).
-		Set(tm.ColumnType, txn.Type).
-		Set(tm.ColumnDescription, txn.Description).
-		Where(squirrel.Eq{tm.ColumnID: txn.ID, tm.ColumnScope: txn.ScopeID}).
-		PlaceholderFormat(squirrel.Question).
+		Set(tm.ColumnDeletedAt, time.Now()).
+		Where(squirrel.Eq{tm.ColumnTransferID: transferID, tm.ColumnScope: scopes}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil}).
 		ToSql()
 	if err != nil {
-		return errors.Wrap(err, "failed to build update query for transaction")
+		return errors.Wrap(err, "failed to build delete query for transfer")
 	}
-
 	if _, err := executor.ExecContext(ctx, query, args...); err != nil {
-		return errors.Wrap(err, "update transaction failed")
+		return errors.Wrap(err, "delete transfer failed")
 	}
 
-	// Add any missing tags and update tags associated with the transaction
-	if err := addMissingTags(ctx, txn, otx...); err != nil {
-		return errors.Wrap(err, "adding missing tags failed")
+	for _, leg := range legs {
+		if err := GetModelsService().SourceModel.AdjustBalance(ctx, leg.SourceID, -transactionDelta(leg), otx...); err != nil {
+			return errors.Wrap(err, "updating source balance failed")
+		}
 	}
-	if err := GetModelsService().TransactionTagModel.UpdateTagsForTransaction(ctx, txn.ID, txn.Tags, []int64{txn.ScopeID}, otx...); err != nil {
-		return errors.Wrap(err, "updating tags for transaction failed")
+	return nil
+}
+
+// getTransferLegs locks the legs of a transfer, either those in use or those in the trash,
+// and returns the fields that decide how they affect source balances.
+func (tm *TransactionModel) getTransferLegs(ctx context.Context, transferID int64, scopes []int64, trashed bool, otx ...*sql.Tx) ([]interfaces.Transaction, error) {
+	_, executor := getExecutor(otx...)
+
+	query, args, err := GetQueryBuilder().Select(tm.ColumnID, tm.ColumnSourceID, tm.ColumnType, tm.ColumnAmount, tm.ColumnDestination).
+		From(tm.TableTransactions).
+		Where(squirrel.Eq{tm.ColumnTransferID: transferID, tm.ColumnScope: scopes}).
+		Where(trashedCondition(tm.ColumnDeletedAt, trashed)).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query for transfer legs")
 	}
 
-	commitOrRollback(executor, isExternalTx, err)
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying transfer legs failed")
+	}
+	defer rows.Close()
+	legs := make([]interfaces.Transaction, 0, 2)
+	for rows.Next() {
+		var leg interfaces.Transaction
+		var sourceID, destinationID sql.NullInt64
+		if err := rows.Scan(&leg.ID, &sourceID, &leg.Type, &leg.Amount, &destinationID); err != nil {
+			return nil, errors.Wrap(err, "scanning transfer leg failed")
+		}
+		leg.SourceID, leg.DestinationSourceID = sourceID.Int64, destinationID.Int64
+		legs = append(legs, leg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing transfer legs failed")
+	}
+	return legs, nil
+}
 
-	return nil
+// RestoreTransaction takes a transaction out of the trash and applies its effect on the source
+// balance again. Restoring either leg of a transfer restores the whole transfer. The sources of
+// the transaction must not be in the trash themselves.
+func (tm *TransactionModel) RestoreTransaction(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		impact, err := tm.getTrashedImpact(ctx, transactionID, scopes, tx)
+		if err != nil {
+			return err
+		}
+		legs := []interfaces.Transaction{*impact}
+		restore := squirrel.Eq{tm.ColumnID: transactionID, tm.ColumnScope: scopes}
+		if impact.TransferID != 0 {
+			if legs, err = tm.getTransferLegs(ctx, impact.TransferID, scopes, true, tx); err != nil {
+				return err
+			}
+			restore = squirrel.Eq{tm.ColumnTransferID: impact.TransferID, tm.ColumnScope: scopes}
+		}
+
+		for _, leg := range legs {
+			for _, sourceID := range []int64{leg.SourceID, leg.DestinationSourceID} {
+				if sourceID == 0 {
+					continue
+				}
+				exists, err := GetModelsService().SourceModel.SourceIDExists(ctx, sourceID, scopes, tx)
+				if err != nil {
+					return errors.Wrap(err, "error checking if source exists")
+				}
+				if !exists {
+					return errors.Wrapf(ErrTrashConflict, "restore source %d first", sourceID)
+				}
+			}
+		}
+
+		query, args, err := GetQueryBuilder().Update(tm.TableTransactions).
+			Set(tm.ColumnDeletedAt, nil).
+			Where(restore).
+			Where(squirrel.NotEq{tm.ColumnDeletedAt: nil}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "failed to build restore query for transaction")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "restore transaction failed")
+		}
+
+		for _, leg := range legs {
+			if err := GetModelsService().SourceModel.AdjustBalance(ctx, leg.SourceID, transactionDelta(leg), tx); err != nil {
+				return errors.Wrap(err, "updating source balance failed")
+			}
+		}
+		return nil
+	}, otx...)
 }
 
-func (tm *TransactionModel) DeleteTransaction(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
+// getBalanceImpact locks a stored transaction and returns the fields that decide how it
+// affects source balances: source, type, amount, destination and transfer ID.
+func (tm *TransactionModel) getBalanceImpact(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Transaction, error) {
+	return tm.lockBalanceImpact(ctx, transactionID, scopes, false, otx...)
+}
+
+// getTrashedImpact is getBalanceImpact for a transaction in the trash.
+func (tm *TransactionModel) getTrashedImpact(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Transaction, error) {
+	impact, err := tm.lockBalanceImpact(ctx, transactionID, scopes, true, otx...)
+	if errors.Cause(err) == sql.ErrNoRows {
+		return nil, ErrTrashItemNotFound
+	}
+	return impact, err
+}
+
+func (tm *TransactionModel) lockBalanceImpact(ctx context.Context, transactionID int64, scopes []int64, trashed bool, otx ...*sql.Tx) (*interfaces.Transaction, error) {
+	_, executor := getExecutor(otx...)
 
-	query, args, err := GetQueryBuilder().Delete(tm.TableTransactions).
+	query, args, err := GetQueryBuilder().Select(tm.ColumnSourceID, tm.ColumnType, tm.ColumnAmount, tm.ColumnDestination, tm.ColumnTransferID).
+		From(tm.TableTransactions).
 		Where(squirrel.Eq{tm.ColumnID: transactionID, tm.ColumnScope: scopes}).
-		PlaceholderFormat(squirrel.Question).
+		Where(trashedCondition(tm.ColumnDeletedAt, trashed)).
+		Suffix("FOR UPDATE").
 		ToSql()
 	if err != nil {
-		return errors.Wrap(err, "failed to build delete query for transaction")
+		return nil, errors.Wrap(err, "failed to build query for transaction balance impact")
 	}
 
-	if _, err := executor.ExecContext(ctx, query, args...); err != nil {
-		return errors.Wrap(err, "delete transaction failed")
+	var sourceID, destinationID, transferID sql.NullInt64
+	txn := &interfaces.Transaction{ID: transactionID}
+	if err := executor.QueryRowContext(ctx, query, args...).Scan(&sourceID, &txn.Type, &txn.Amount, &destinationID, &transferID); err != nil {
+		return nil, errors.Wrap(err, "fetching transaction balance impact failed")
 	}
+	txn.SourceID, txn.DestinationSourceID, txn.TransferID = sourceID.Int64, destinationID.Int64, transferID.Int64
+	return txn, nil
+}
 
-	commitOrRollback(executor, isExternalTx, err)
+// GetNetAmountBySource sums the effect of every transaction recorded against a source:
+// INCOME and incoming transfer legs add to the balance, EXPENSE and outgoing legs subtract from it.
+func (tm *TransactionModel) GetNetAmountBySource(ctx context.Context, sourceID int64, otx ...*sql.Tx) (interfaces.Money, error) {
+	_, executor := getExecutor(otx...)
 
-	return nil
+	netAmount := fmt.Sprintf("COALESCE(SUM(CASE WHEN UPPER(%[1]s) = ? THEN %[2]s WHEN UPPER(%[1]s) = ? THEN -%[2]s "+
+		"WHEN UPPER(%[1]s) = ? AND %[3]s = %[4]s THEN %[2]s WHEN UPPER(%[1]s) = ? THEN -%[2]s ELSE 0 END), 0)",
+		tm.ColumnType, tm.ColumnAmount, tm.ColumnDestination, tm.ColumnSourceID)
+	query, args, err := GetQueryBuilder().Select().
+		Column(netAmount, TransactionTypeIncome, TransactionTypeExpense, TransactionTypeTransfer, TransactionTypeTransfer).
+		From(tm.TableTransactions).
+		Where(squirrel.Eq{tm.ColumnSourceID: sourceID}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil}).
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build net amount query for source")
+	}
+
+	var net interfaces.Money
+	if err := executor.QueryRowContext(ctx, query, args...).Scan(&net); err != nil {
+		return 0, errors.Wrap(err, "computing net amount for source failed")
+	}
+	return net, nil
+}
+
+// balanceDelta returns how a transaction of the given type and amount changes its source's balance.
+func balanceDelta(txnType string, amount interfaces.Money) interfaces.Money {
+	switch strings.ToUpper(txnType) {
+	case TransactionTypeIncome:
+		return amount
+	case TransactionTypeExpense:
+		return -amount
+	}
+	return 0
+}
+
+// transactionDelta is balanceDelta for a stored transaction, taking the direction of transfer legs into account.
+func transactionDelta(txn interfaces.Transaction) interfaces.Money {
+	if strings.ToUpper(txn.Type) == TransactionTypeTransfer {
+		if txn.SourceID == txn.DestinationSourceID {
+			return txn.Amount
+		}
+		return -txn.Amount
+	}
+	return balanceDelta(txn.Type, txn.Amount)
 }
 
 func (tm *TransactionModel) GetTransactionByID(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Transaction, error) {
 	_, executor := getExecutor(otx...)
 
-	query, args, err := GetQueryBuilder().Select(tm.ColumnID, tm.ColumnUserID, tm.ColumnSourceID, tm.ColumnCategoryID, tm.ColumnTimestamp, tm.ColumnAmount, tm.ColumnType, tm.ColumnDescription, tm.ColumnScope).
+	query, args, err := GetQueryBuilder().Select(tm.selectColumns()...).
 		From(tm.TableTransactions).
 		Where(squirrel.Eq{tm.ColumnID: transactionID, tm.ColumnScope: scopes}).
+		Where(squirrel.Eq{tm.ColumnDeletedAt: nil}).
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 	if err != nil {
@@ -186,40 +653,136 @@ func (tm *TransactionModel) GetTransactionByID(ctx context.Context, transactionI
 
 	row := executor.QueryRowContext(ctx, query, args...)
 	var transaction interfaces.Transaction
-	if err := row.Scan(&transaction.ID, &transaction.UserID, &transaction.SourceID, &transaction.CategoryID, &transaction.Timestamp, &transaction.Amount, &transaction.Type, &transaction.Description, &transaction.ScopeID); err != nil {
+	if err := scanTransaction(row, &transaction); err != nil {
 		return nil, errors.Wrap(err, "get transaction by ID failed")
 	}
 
-	getTagsForTransaction(ctx, &transaction, otx...)
+	transactions := []interfaces.Transaction{transaction}
+	if err := getTagsForTransactions(ctx, transactions, otx...); err != nil {
+		return nil, err
+	}
+	if err := getSplitsForTransactions(ctx, transactions, otx...); err != nil {
+		return nil, err
+	}
 
-	return &transaction, nil
+	return &transactions[0], nil
 }
 
 // GetTransactionsByFilter retrieves a list of transactions from the database based on a set of filters.
 func (tm *TransactionModel) GetTransactionsByFilter(ctx context.Context, filter interfaces.TransactionFilter, otx ...*sql.Tx) ([]interfaces.Transaction, error) {
-	_, executor := getExecutor(otx...)
-	query := GetQueryBuilder().Select(tm.ColumnID, tm.ColumnUserID, tm.ColumnSourceID, tm.ColumnCategoryID, tm.ColumnTimestamp, tm.ColumnAmount, tm.ColumnType, tm.ColumnDescription, tm.ColumnScope).
+	query := GetQueryBuilder().Select(tm.selectColumns()...).
+		From(tm.TableTransactions).
+		Where(squirrel.Eq{tm.ColumnScope: filter.Scopes})
+
+	query = tm.applyFilter(query, filter, "")
+
+	if filter.SortBy != "" {
+		order := "ASC"
+		if filter.SortOrder == SortOrderDesc {
+			order = "DESC"
+		}
+		query = query.OrderBy(filter.SortBy + " " + order)
+	}
+
+	if filter.Page > 0 && filter.ItemsPerPage > 0 {
+		offset := uint64((filter.Page - 1) * filter.ItemsPerPage)
+		query = query.Offset(offset).Limit(uint64(filter.ItemsPerPage))
+	}
+
+	return tm.queryTransactions(ctx, query, otx...)
+}
+
+// GetTransactionsByCursor retrieves a page of the transactions matching filter, ordered by timestamp
+// and ID, most recent first unless filter.SortOrder is ASC. Paging from the position of a row keeps
+// pages stable when transactions are added meanwhile. SortBy, Page and ItemsPerPage are ignored.
+func (tm *TransactionModel) GetTransactionsByCursor(ctx context.Context, filter interfaces.TransactionFilter, params interfaces.CursorParams, otx ...*sql.Tx) ([]interfaces.Transaction, interfaces.CursorPage, error) {
+	query := GetQueryBuilder().Select(tm.selectColumns()...).
 		From(tm.TableTransactions).
 		Where(squirrel.Eq{tm.ColumnScope: filter.Scopes})
+	query = tm.applyFilter(query, filter, "")
+	query = applyCursor(query, tm.ColumnTimestamp, tm.ColumnID, strings.ToUpper(filter.SortOrder) != SortOrderAsc, params)
+
+	transactions, err := tm.queryTransactions(ctx, query, otx...)
+	if err != nil {
+		return nil, interfaces.CursorPage{}, err
+	}
+	transactions, page := pageByCursor(transactions, func(txn interfaces.Transaction) interfaces.Cursor {
+		return interfaces.Cursor{Time: txn.Timestamp, ID: txn.ID}
+	}, params)
+	return transactions, page, nil
+}
+
+// queryTransactions runs a query selecting selectColumns and fills in the tags and splits of the rows.
+func (tm *TransactionModel) queryTransactions(ctx context.Context, query squirrel.SelectBuilder, otx ...*sql.Tx) ([]interfaces.Transaction, error) {
+	_, executor := getExecutor(otx...)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing SQL query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying transactions by filter failed")
+	}
+	defer rows.Close()
+
+	transactions := make([]interfaces.Transaction, 0)
+	for rows.Next() {
+		var transaction interfaces.Transaction
+		if err := scanTransaction(rows, &transaction); err != nil {
+			return nil, errors.Wrap(err, "scanning transaction failed")
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing rows failed")
+	}
+	if err := getTagsForTransactions(ctx, transactions, otx...); err != nil {
+		return nil, err
+	}
+	if err := getSplitsForTransactions(ctx, transactions, otx...); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
 
+// applyFilter adds the WHERE conditions of filter, other than scope, to query, and leaves out
+// the transactions in the trash. prefix qualifies the transaction columns (e.g. "t.") when
+// query joins other tables.
+func (tm *TransactionModel) applyFilter(query squirrel.SelectBuilder, filter interfaces.TransactionFilter, prefix string) squirrel.SelectBuilder {
 	if filter.StartDate != "" {
-		query = query.Where(tm.ColumnTimestamp+" >= ?", filter.StartDate)
+		query = query.Where(prefix+tm.ColumnTimestamp+" >= ?", filterTime(filter.StartDate, filter.Location))
 	}
 
 	if filter.EndDate != "" {
-		query = query.Where(tm.ColumnTimestamp+" <= ?", filter.EndDate)
+		if day, ok := parsePlainDate(filter.EndDate, filter.Location); ok {
+			// A plain end date includes the whole day
+			query = query.Where(prefix+tm.ColumnTimestamp+" < ?", day.AddDate(0, 0, 1))
+		} else {
+			query = query.Where(prefix+tm.ColumnTimestamp+" <= ?", filterTime(filter.EndDate, filter.Location))
+		}
 	}
 
+	// A split transaction matches the categories of its lines
 	if filter.Category != "" {
-		query = query.Where(tm.ColumnCategoryID+" = ?", filter.Category)
+		splits := NewTransactionSplitModel()
+		splitLines := GetQueryBuilder().Select(splits.ColumnTransactionID).
+			From(splits.TableSplits).
+			Where(categoryCondition(splits.ColumnCategoryID, filter))
+		query = query.Where(squirrel.Or{
+			categoryCondition(prefix+tm.ColumnCategoryID, filter),
+			squirrel.Expr(prefix+tm.ColumnID+" IN (?)", splitLines),
+		})
 	}
 
 	if filter.Type != "" {
-		query = query.Where(tm.ColumnType+" = ?", filter.Type)
+		query = query.Where(prefix+tm.ColumnType+" = ?", filter.Type)
 	}
 
 	if filter.Description != "" {
-		query = query.Where(tm.ColumnDescription+" LIKE ?", "%"+filter.Description+"%")
+		query = query.Where(prefix+tm.ColumnDescription+" LIKE ?", "%"+filter.Description+"%")
 	}
 
 	//tags table fieldnames are a problem :-|
@@ -227,68 +790,103 @@ func (tm *TransactionModel) GetTransactionsByFilter(ctx context.Context, filter
 		tagsSubQuery := GetQueryBuilder().Select("transaction_id").
 			From("transaction_tags").
 			Where("tag_id IN ?", filter.Tags)
-		query = query.Where(tm.ColumnID+" IN ?", tagsSubQuery)
+		query = query.Where(prefix+tm.ColumnID+" IN ?", tagsSubQuery)
 	}
 
 	if filter.MinAmount > 0 {
-		query = query.Where(tm.ColumnAmount+" >= ?", filter.MinAmount)
+		query = query.Where(prefix+tm.ColumnAmount+" >= ?", filter.MinAmount)
 	}
 
 	if filter.MaxAmount > 0 {
-		query = query.Where(tm.ColumnAmount+" <= ?", filter.MaxAmount)
+		query = query.Where(prefix+tm.ColumnAmount+" <= ?", filter.MaxAmount)
 	}
 
-	if filter.SortBy != "" {
-		order := "ASC"
-		if filter.SortOrder == SortOrderDesc {
-			order = "DESC"
-		}
-		query = query.OrderBy(filter.SortBy + " " + order)
+	if filter.SourceID > 0 {
+		query = query.Where(squirrel.Eq{prefix + tm.ColumnSourceID: filter.SourceID})
 	}
 
-	if filter.Page > 0 && filter.ItemsPerPage > 0 {
-		offset := uint64((filter.Page - 1) * filter.ItemsPerPage)
-		query = query.Offset(offset).Limit(uint64(filter.ItemsPerPage))
+	if filter.TransferID > 0 {
+		query = query.Where(squirrel.Eq{prefix + tm.ColumnTransferID: filter.TransferID})
 	}
 
-	sql, args, err := query.ToSql()
-	if err != nil {
-		return nil, errors.Wrap(err, "constructing SQL query failed")
+	if filter.ExcludeTransfers {
+		query = query.Where(prefix + tm.ColumnTransferID + " IS NULL")
 	}
 
-	rows, err := executor.QueryContext(ctx, sql, args...)
-	if err != nil {
-		return nil, errors.Wrap(err, "querying transactions by filter failed")
+	return query.Where(squirrel.Eq{prefix + tm.ColumnDeletedAt: nil})
+}
+
+// parsePlainDate reads a YYYY-MM-DD date as the start of that day in location, UTC when nil.
+func parsePlainDate(value string, location *time.Location) (time.Time, bool) {
+	if location == nil {
+		location = time.UTC
 	}
-	defer rows.Close()
+	day, err := time.ParseInLocation("2006-01-02", value, location)
+	return day, err == nil
+}
 
-	transactions := make([]interfaces.Transaction, 0)
-	for rows.Next() {
-		var transaction interfaces.Transaction
-		if err := rows.Scan(&transaction.ID, &transaction.UserID, &transaction.SourceID, &transaction.CategoryID, &transaction.Timestamp, &transaction.Amount, &transaction.Type, &transaction.Description, &transaction.ScopeID); err != nil {
-			return nil, errors.Wrap(err, "scanning transaction failed")
-		}
-		getTagsForTransaction(ctx, &transaction, otx...)
-		transactions = append(transactions, transaction)
+// filterTime is the bound a date filter compares transaction dates with. Plain dates start in
+// location and RFC 3339 times carry their own offset; anything else is left for the database to read.
+func filterTime(value string, location *time.Location) interface{} {
+	if day, ok := parsePlainDate(value, location); ok {
+		return day
 	}
-	if err = rows.Err(); err != nil {
-		return nil, errors.Wrap(err, "processing rows failed")
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at
 	}
+	return value
+}
 
-	return transactions, nil
+// checkTimestamp rejects transaction dates the timestamp column cannot hold.
+func checkTimestamp(timestamp time.Time) error {
+	if timestamp.Before(time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC)) || !timestamp.Before(time.Date(2038, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		return ErrInvalidTimestamp
+	}
+	return nil
 }
 
-func getTagsForTransaction(ctx context.Context, transaction *interfaces.Transaction, otx ...*sql.Tx) error {
-	tags, err := GetModelsService().TransactionTagModel.GetTagsByTransactionID(ctx, transaction.ID, otx...)
+// getTagsForTransactions fills in the tag names of transactions with a single query.
+func getTagsForTransactions(ctx context.Context, transactions []interfaces.Transaction, otx ...*sql.Tx) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	tags, err := GetModelsService().TransactionTagModel.GetTagsByTransactionIDs(ctx, ids, otx...)
 	if err != nil {
-		return errors.Wrap(err, "Couldn't fetch tags for the transaction")
+		return errors.Wrap(err, "fetching transaction tags failed")
 	}
+	for i := range transactions {
+		transactions[i].Tags = make([]string, len(tags[transactions[i].ID]))
+		for j, tag := range tags[transactions[i].ID] {
+			transactions[i].Tags[j] = tag.Name
+		}
+	}
+	return nil
+}
 
-	transaction.Tags = make([]string, len(tags))
-	for i, tag := range tags {
-		transaction.Tags[i] = tag.Name
+// getSplitsForTransactions fills in the lines of the split transactions among transactions.
+func getSplitsForTransactions(ctx context.Context, transactions []interfaces.Transaction, otx ...*sql.Tx) error {
+	var ids []int64
+	for _, transaction := range transactions {
+		if isSplit(transaction) {
+			ids = append(ids, transaction.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
 	}
 
+	splits, err := GetModelsService().TransactionSplitModel.GetSplitsByTransactionIDs(ctx, ids, otx...)
+	if err != nil {
+		return errors.Wrap(err, "fetching transaction splits failed")
+	}
+	for i := range transactions {
+		transactions[i].Splits = splits[transactions[i].ID]
+	}
 	return nil
 }
 
@@ -312,13 +910,28 @@ func validateForeignKeyReferences(ctx context.Context, txn interfaces.Transactio
 		return errors.New("source does not exist")
 	}
 
-	// Check if the category exists
-	categoryExists, err := GetModelsService().CategoryModel.CategoryIDExists(ctx, txn.CategoryID, []int64{txn.ScopeID}, otx...)
-	if err != nil {
-		return errors.Wrap(err, "error checking if category exists")
+	isTransfer := strings.ToUpper(txn.Type) == TransactionTypeTransfer
+
+	// Check if the destination source exists
+	if isTransfer {
+		destinationExists, err := GetModelsService().SourceModel.SourceIDExists(ctx, txn.DestinationSourceID, []int64{txn.ScopeID}, otx...)
+		if err != nil {
+			return errors.Wrap(err, "error checking if destination source exists")
+		}
+		if !destinationExists {
+			return errors.New("destination source does not exist")
+		}
 	}
-	if !categoryExists {
-		return errors.New("category does not exist")
+
+	// Check if the category exists; it is optional for transfers and split transactions
+	if !isTransfer && len(txn.Splits) == 0 || txn.CategoryID > 0 {
+		categoryExists, err := GetModelsService().CategoryModel.CategoryIDExists(ctx, txn.CategoryID, []int64{txn.ScopeID}, otx...)
+		if err != nil {
+			return errors.Wrap(err, "error checking if category exists")
+		}
+		if !categoryExists {
+			return errors.New("category does not exist")
+		}
 	}
 
 	// Check if the scope exists
@@ -333,6 +946,19 @@ func validateForeignKeyReferences(ctx context.Context, txn interfaces.Transactio
 	return nil
 }
 
+// sourceCurrency returns the currency of the transaction's source. A transaction submitted
+// with a currency must use the one its source holds.
+func sourceCurrency(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) (string, error) {
+	currency, err := GetModelsService().SourceModel.GetSourceCurrency(ctx, txn.SourceID, []int64{txn.ScopeID}, otx...)
+	if err != nil {
+		return "", errors.Wrap(err, "fetching source currency failed")
+	}
+	if txn.Currency != "" && !strings.EqualFold(txn.Currency, currency) {
+		return "", errors.Wrapf(ErrCurrencyMismatch, "source holds %s, got %s", currency, txn.Currency)
+	}
+	return currency, nil
+}
+
 // addMissingTags ensures that all tags are present in the database and associates them with the user.
 func addMissingTags(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
 	// Ensure all tags are present in the database
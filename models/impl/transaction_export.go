@@ -0,0 +1,117 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// exportTagSeparator joins tag names in the export query; it cannot appear in a tag name typed by a user.
+const exportTagSeparator = "\x1f"
+
+// ExportTransactions streams the transactions matching filter to write, one at a time, with
+// category, source and tag names resolved in the same query. Rows are read from the database as
+// they are written, so the result is never held in memory. Sorting is limited to the transaction
+// columns and defaults to the timestamp; paging applies only when both page fields are set.
+// Iteration stops at the first error returned by write.
+func (tm *TransactionModel) ExportTransactions(ctx context.Context, filter interfaces.TransactionFilter, write func(interfaces.TransactionExport) error, otx ...*sql.Tx) error {
+	_, executor := getExecutor(otx...)
+	query := tm.exportQuery(filter)
+
+	sortBy := tm.ColumnTimestamp
+	if util.Contains(tm.selectColumns(), filter.SortBy) {
+		sortBy = filter.SortBy
+	}
+	order := SortOrderAsc
+	if strings.ToUpper(filter.SortOrder) == SortOrderDesc {
+		order = SortOrderDesc
+	}
+	query = query.OrderBy("t."+sortBy+" "+order, "t."+tm.ColumnID+" "+order)
+
+	if filter.Page > 0 && filter.ItemsPerPage > 0 {
+		query = query.Offset(uint64((filter.Page - 1) * filter.ItemsPerPage)).Limit(uint64(filter.ItemsPerPage))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "constructing export query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "querying transactions for export failed")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txn interfaces.TransactionExport
+		if err := scanTransactionExport(rows, &txn); err != nil {
+			return errors.Wrap(err, "scanning exported transaction failed")
+		}
+		if err := write(txn); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "processing exported transaction rows failed")
+	}
+	return nil
+}
+
+// exportQuery selects the transactions matching filter in the columns read by
+// scanTransactionExport, with category, source and tag names resolved. It is neither sorted nor paged.
+func (tm *TransactionModel) exportQuery(filter interfaces.TransactionFilter) squirrel.SelectBuilder {
+	tags := "(SELECT GROUP_CONCAT(g.name ORDER BY g.name SEPARATOR '" + exportTagSeparator + "') " +
+		"FROM transaction_tags tt JOIN tags g ON g.tag_id = tt.tag_id AND g.deleted_at IS NULL WHERE tt.transaction_id = t.transaction_id)"
+	query := GetQueryBuilder().Select("t.transaction_id", "t.timestamp", "t.type", "t.amount", "t.currency", "COALESCE(t.description, '')",
+		"COALESCE(c.name, '')", "COALESCE(s.name, '')", "COALESCE(d.name, '')", "COALESCE("+tags+", '')",
+		"t.source_id", "COALESCE(t.destination_source_id, 0)", "COALESCE(t.transfer_id, 0)").
+		From(tm.TableTransactions + " t").
+		LeftJoin("categories c ON c.category_id = t.category_id").
+		LeftJoin("sources s ON s.source_id = t.source_id").
+		LeftJoin("sources d ON d.source_id = t.destination_source_id").
+		Where(squirrel.Eq{"t." + tm.ColumnScope: filter.Scopes})
+	return tm.applyFilter(query, filter, "t.")
+}
+
+func scanTransactionExport(scanner interface{ Scan(...interface{}) error }, txn *interfaces.TransactionExport) error {
+	var tagNames string
+	if err := scanner.Scan(&txn.ID, &txn.Timestamp, &txn.Type, &txn.Amount, &txn.Currency, &txn.Description, &txn.Category, &txn.Source, &txn.DestinationSource, &tagNames, &txn.SourceID, &txn.DestinationSourceID, &txn.TransferID); err != nil {
+		return err
+	}
+	txn.Tags = []string{}
+	if tagNames != "" {
+		txn.Tags = strings.Split(tagNames, exportTagSeparator)
+	}
+	return nil
+}
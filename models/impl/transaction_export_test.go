@@ -0,0 +1,74 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var exportColumns = []string{"transaction_id", "timestamp", "type", "amount", "currency", "description", "category", "source", "destination_source", "tags", "source_id", "destination_source_id", "transfer_id"}
+
+func TestExportTransactions(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionModel = NewTransactionModel()
+	})
+	defer tearDown()
+
+	db, mockM := setupNewMock(t)
+	defer db.Close()
+
+	t.Run("Streams rows with names", func(t *testing.T) {
+		filter := interfaces.TransactionFilter{Scopes: []int64{10}, Type: TransactionTypeExpense, SortBy: "amount", SortOrder: "desc"}
+		mockM.ExpectQuery("^SELECT t.transaction_id, (.+) FROM transactions t LEFT JOIN categories c ON c.category_id = t.category_id "+
+			"LEFT JOIN sources s ON s.source_id = t.source_id LEFT JOIN sources d ON d.source_id = t.destination_source_id "+
+			"WHERE t.scope_id IN \\(\\?\\) AND t.type = \\? AND t.deleted_at IS NULL ORDER BY t.amount DESC, t.transaction_id DESC$").
+			WithArgs(int64(10), TransactionTypeExpense).
+			WillReturnRows(sqlmock.NewRows(exportColumns).
+				AddRow(11, time.Now(), TransactionTypeExpense, 4.5, "EUR", "Coffee", "Food", "Wallet", "", "morning\x1fwork", 1, 0, 0).
+				AddRow(12, time.Now(), TransactionTypeExpense, 2.0, "EUR", "Bus", "Travel", "Wallet", "", "", 1, 0, 0))
+
+		var exported []interfaces.TransactionExport
+		err := ModelsService.TransactionModel.ExportTransactions(context.Background(), filter, func(txn interfaces.TransactionExport) error {
+			exported = append(exported, txn)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, exported, 2)
+		assert.Equal(t, []string{"morning", "work"}, exported[0].Tags)
+		assert.Equal(t, "Food", exported[0].Category)
+		assert.Equal(t, "EUR", exported[0].Currency)
+		assert.Equal(t, []string{}, exported[1].Tags)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown sort column falls back to the timestamp", func(t *testing.T) {
+		filter := interfaces.TransactionFilter{Scopes: []int64{10}, SortBy: "amount; DROP TABLE users", Page: 2, ItemsPerPage: 50}
+		mockM.ExpectQuery("ORDER BY t.timestamp ASC, t.transaction_id ASC LIMIT 50 OFFSET 50$").
+			WillReturnRows(sqlmock.NewRows(exportColumns))
+
+		err := ModelsService.TransactionModel.ExportTransactions(context.Background(), filter, func(interfaces.TransactionExport) error { return nil })
+		assert.NoError(t, err)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Write error stops the export", func(t *testing.T) {
+		mockM.ExpectQuery("^SELECT t.transaction_id").
+			WillReturnRows(sqlmock.NewRows(exportColumns).
+				AddRow(11, time.Now(), TransactionTypeExpense, 4.5, "EUR", "Coffee", "Food", "Wallet", "", "", 1, 0, 0).
+				AddRow(12, time.Now(), TransactionTypeExpense, 2.0, "EUR", "Bus", "Travel", "Wallet", "", "", 1, 0, 0))
+
+		writeErr := errors.New("client went away")
+		calls := 0
+		err := ModelsService.TransactionModel.ExportTransactions(context.Background(), interfaces.TransactionFilter{Scopes: []int64{10}}, func(interfaces.TransactionExport) error {
+			calls++
+			return writeErr
+		})
+		assert.Equal(t, writeErr, err)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,196 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"xspends/models/interfaces"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	ReportGroupByCategory = "category"
+	ReportGroupByTag      = "tag"
+	ReportGroupBySource   = "source"
+	ReportGroupByMember   = "member"
+	ReportGroupByDay      = "day"
+	ReportGroupByWeek     = "week"
+	ReportGroupByMonth    = "month"
+)
+
+// reportSplitLines joins the lines of split transactions; the other transactions keep a single row.
+const (
+	reportSplitLines   = "LEFT JOIN transaction_splits sp ON sp.transaction_id = t.transaction_id"
+	reportLineCategory = "COALESCE(sp.category_id, t.category_id)"
+	reportLineAmount   = "COALESCE(sp.amount, t.amount)"
+)
+
+var ErrInvalidReportGrouping = errors.New("reports can be grouped by category, tag, source, member, day, week or month")
+
+// reportGrouping describes how to bucket transactions (aliased "t") for one report:
+// the key and name expressions and the joins they need. Groupings by category count
+// split transactions by their lines (aliased "sp"), joined before the grouping's joins.
+type reportGrouping struct {
+	key   string
+	name  string
+	joins []string
+}
+
+// reportGroupings maps each supported groupBy value to its SQL. The keys of the day, week and
+// month groupings are built by reportPeriodKey.
+var reportGroupings = map[string]reportGrouping{
+	ReportGroupByCategory: {key: reportLineCategory, name: "COALESCE(c.name, '')", joins: []string{"LEFT JOIN categories c ON c.category_id = " + reportLineCategory}},
+	ReportGroupByTag: {key: "tt.tag_id", name: "g.name", joins: []string{
+		"JOIN transaction_tags tt ON tt.transaction_id = t.transaction_id",
+		"JOIN tags g ON g.tag_id = tt.tag_id AND g.deleted_at IS NULL",
+	}},
+	ReportGroupBySource: {key: "t.source_id", name: "COALESCE(s.name, '')", joins: []string{"LEFT JOIN sources s ON s.source_id = t.source_id"}},
+	ReportGroupByMember: {key: "t.user_id", name: "COALESCE(u.name, '')", joins: []string{"LEFT JOIN users u ON u.user_id = t.user_id"}},
+	ReportGroupByDay:    {name: "''"},
+	ReportGroupByWeek:   {name: "''"},
+	ReportGroupByMonth:  {name: "''"},
+}
+
+// reportPeriodKey returns the key of a report by day, week or month and its arguments. Timestamps
+// are stored in UTC and converted to the filter's time zone first, so that a day runs from the
+// user's midnight to the next. Weeks start on the filter's WeekStart.
+func reportPeriodKey(groupBy string, filter interfaces.TransactionFilter) (string, []interface{}) {
+	date, dateArgs := "t.timestamp", []interface{}{}
+	if filter.Location != nil && filter.Location != time.UTC {
+		date, dateArgs = "CONVERT_TZ(t.timestamp, '+00:00', ?)", []interface{}{filter.Location.String()}
+	}
+	switch groupBy {
+	case ReportGroupByDay:
+		return "DATE_FORMAT(" + date + ", '%Y-%m-%d')", dateArgs
+	case ReportGroupByWeek:
+		// WEEKDAY counts from Monday; shift it so that WeekStart counts as 0
+		shift := (8 - int(filter.WeekStart)) % 7
+		args := append(append(append([]interface{}{}, dateArgs...), dateArgs...), shift)
+		return "DATE_FORMAT(DATE_SUB(" + date + ", INTERVAL MOD(WEEKDAY(" + date + ") + ?, 7) DAY), '%Y-%m-%d')", args
+	default:
+		return "DATE_FORMAT(" + date + ", '%Y-%m-01')", dateArgs
+	}
+}
+
+// GetTransactionReport totals income, expense and net of the transactions matching filter,
+// bucketed by groupBy. The aggregation runs in SQL; sorting and paging of the filter are ignored.
+// Transfers move money between sources and are left out. When grouping by tag, a transaction
+// counts towards each of its tags and untagged transactions are left out. When grouping or
+// filtering by category, split transactions count as their lines, each in its own category.
+// Amounts are converted to currency at the exchange rate of their scope on the transaction date.
+// Transactions without a rate are left out of the totals and counted as unconverted.
+// Days, weeks and months are those of the filter's time zone and week start.
+func (tm *TransactionModel) GetTransactionReport(ctx context.Context, filter interfaces.TransactionFilter, groupBy string, currency string, otx ...*sql.Tx) ([]interfaces.ReportRow, error) {
+	grouping, ok := reportGroupings[groupBy]
+	if !ok {
+		return nil, ErrInvalidReportGrouping
+	}
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	_, executor := getExecutor(otx...)
+
+	// The rate of each matching transaction is looked up once, before aggregation
+	rates := NewCurrencyModel()
+	fxRate := "CASE WHEN t." + tm.ColumnCurrency + " = ? THEN 1 ELSE COALESCE((" +
+		rates.rateLookup("t."+tm.ColumnScope, "t."+tm.ColumnCurrency, "?", "DATE(t."+tm.ColumnTimestamp+")") + "), 1 / (" +
+		rates.rateLookup("t."+tm.ColumnScope, "?", "t."+tm.ColumnCurrency, "DATE(t."+tm.ColumnTimestamp+")") + ")) END"
+	bySplitLines := groupBy == ReportGroupByCategory || filter.Category != ""
+	amount := "t." + tm.ColumnAmount
+	if bySplitLines {
+		amount = reportLineAmount + " AS " + tm.ColumnAmount
+	}
+	key, keyArgs := grouping.key, []interface{}{}
+	switch groupBy {
+	case ReportGroupByDay, ReportGroupByWeek, ReportGroupByMonth:
+		key, keyArgs = reportPeriodKey(groupBy, filter)
+	}
+	matching := GetQueryBuilder().Select().
+		Column(key+" AS report_key", keyArgs...).
+		Column(grouping.name+" AS report_name").
+		Column("UPPER(t."+tm.ColumnType+") AS type").
+		Column(amount).
+		Column(fxRate+" AS fx_rate", currency, currency, currency).
+		From(tm.TableTransactions + " t")
+	if bySplitLines {
+		matching = matching.JoinClause(reportSplitLines)
+	}
+	for _, join := range grouping.joins {
+		matching = matching.JoinClause(join)
+	}
+	matching = matching.Where(squirrel.Eq{"t." + tm.ColumnScope: filter.Scopes}).
+		Where(squirrel.Eq{"UPPER(t." + tm.ColumnType + ")": []string{TransactionTypeIncome, TransactionTypeExpense}})
+	if filter.Category != "" {
+		// Only the lines in the category count, not the whole split transaction
+		matching = matching.Where(categoryCondition(reportLineCategory, filter))
+		filter.Category = ""
+	}
+	matching = tm.applyFilter(matching, filter, "t.")
+
+	query := GetQueryBuilder().Select().
+		Column("x.report_key").
+		Column("MAX(x.report_name)").
+		Column("COALESCE(SUM(CASE WHEN x.type = ? THEN x.amount * x.fx_rate ELSE 0 END), 0) AS income", TransactionTypeIncome).
+		Column("COALESCE(SUM(CASE WHEN x.type = ? THEN x.amount * x.fx_rate ELSE 0 END), 0) AS expense", TransactionTypeExpense).
+		Column("COUNT(*)").
+		Column("COALESCE(SUM(CASE WHEN x.fx_rate IS NULL THEN 1 ELSE 0 END), 0) AS unconverted").
+		FromSelect(matching, "x").
+		GroupBy("x.report_key").
+		OrderBy("x.report_key")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing report query failed")
+	}
+
+	rows, err := executor.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying transaction report failed")
+	}
+	defer rows.Close()
+
+	report := make([]interfaces.ReportRow, 0)
+	for rows.Next() {
+		var row interfaces.ReportRow
+		row.Currency = currency
+		if err := rows.Scan(&row.Key, &row.Name, &row.Income, &row.Expense, &row.Count, &row.Unconverted); err != nil {
+			return nil, errors.Wrap(err, "scanning report row failed")
+		}
+		row.Income = row.Income.Round(currency)
+		row.Expense = row.Expense.Round(currency)
+		row.Net = row.Income - row.Expense
+		report = append(report, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing report rows failed")
+	}
+	return report, nil
+}
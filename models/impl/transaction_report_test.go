@@ -0,0 +1,121 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var reportColumns = []string{"report_key", "name", "income", "expense", "count", "unconverted"}
+
+func TestGetTransactionReport(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionModel = NewTransactionModel()
+	})
+	defer tearDown()
+
+	db, mockM := setupNewMock(t)
+	defer db.Close()
+
+	filter := interfaces.TransactionFilter{Scopes: []int64{10}, StartDate: "2024-01-01", SourceID: 2}
+
+	t.Run("By category", func(t *testing.T) {
+		mockM.ExpectQuery("^SELECT x.report_key, MAX\\(x.report_name\\), (.+) FROM \\(SELECT COALESCE\\(sp.category_id, t.category_id\\) AS report_key, COALESCE\\(c.name, ''\\) AS report_name, "+
+			"UPPER\\(t.type\\) AS type, COALESCE\\(sp.amount, t.amount\\) AS amount, (.+) FROM transactions t "+
+			"LEFT JOIN transaction_splits sp ON sp.transaction_id = t.transaction_id LEFT JOIN categories c ON c.category_id = COALESCE\\(sp.category_id, t.category_id\\) "+
+			"WHERE t.scope_id IN \\(\\?\\) AND UPPER\\(t.type\\) IN \\(\\?,\\?\\) AND t.timestamp >= \\? AND t.source_id = \\? AND t.deleted_at IS NULL\\) AS x "+
+			"GROUP BY x.report_key ORDER BY x.report_key").
+			WithArgs(TransactionTypeIncome, TransactionTypeExpense, "EUR", "EUR", "EUR", int64(10), TransactionTypeIncome, TransactionTypeExpense, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), int64(2)).
+			WillReturnRows(sqlmock.NewRows(reportColumns).
+				AddRow("3", "Groceries", 0.0, 120.456, 4, 0).
+				AddRow("5", "Salary", 3000.0, 0.0, 1, 0))
+
+		report, err := ModelsService.TransactionModel.GetTransactionReport(context.Background(), filter, ReportGroupByCategory, "eur")
+		assert.NoError(t, err)
+		assert.Equal(t, []interfaces.ReportRow{
+			{Key: "3", Name: "Groceries", Currency: "EUR", Expense: interfaces.MoneyFromFloat(120.46), Net: interfaces.MoneyFromFloat(-120.46), Count: 4},
+			{Key: "5", Name: "Salary", Currency: "EUR", Income: interfaces.MoneyFromFloat(3000), Net: interfaces.MoneyFromFloat(3000), Count: 1},
+		}, report)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Converts at the rate of the transaction date", func(t *testing.T) {
+		mockM.ExpectQuery("CASE WHEN t.currency = \\? THEN 1 ELSE COALESCE\\(\\(SELECT r.rate FROM exchange_rates r WHERE r.scope_id = t.scope_id " +
+			"AND r.from_currency = t.currency AND r.to_currency = \\? AND r.rate_date <= DATE\\(t.timestamp\\) ORDER BY r.rate_date DESC LIMIT 1\\), " +
+			"1 / \\(SELECT r.rate FROM exchange_rates r WHERE r.scope_id = t.scope_id AND r.from_currency = \\? AND r.to_currency = t.currency (.+)\\)\\) END AS fx_rate").
+			WillReturnRows(sqlmock.NewRows(reportColumns).AddRow("2024-01-01", "", 100.0, 40.0, 5, 2))
+
+		report, err := ModelsService.TransactionModel.GetTransactionReport(context.Background(), filter, ReportGroupByMonth, "USD")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report[0].Unconverted, "transactions without a rate are reported")
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("By tag joins the tag tables", func(t *testing.T) {
+		mockM.ExpectQuery("FROM transactions t JOIN transaction_tags tt ON tt.transaction_id = t.transaction_id JOIN tags g ON g.tag_id = tt.tag_id").
+			WillReturnRows(sqlmock.NewRows(reportColumns))
+
+		report, err := ModelsService.TransactionModel.GetTransactionReport(context.Background(), filter, ReportGroupByTag, "USD")
+		assert.NoError(t, err)
+		assert.Empty(t, report)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("By month", func(t *testing.T) {
+		mockM.ExpectQuery("\\(SELECT DATE_FORMAT\\(t.timestamp, '%Y-%m-01'\\) AS report_key").
+			WillReturnRows(sqlmock.NewRows(reportColumns).AddRow("2024-01-01", "", 100.0, 40.0, 3, 0))
+
+		report, err := ModelsService.TransactionModel.GetTransactionReport(context.Background(), filter, ReportGroupByMonth, "USD")
+		assert.NoError(t, err)
+		assert.Equal(t, interfaces.MoneyFromFloat(60), report[0].Net)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("By week in the user's time zone", func(t *testing.T) {
+		tokyo, _ := time.LoadLocation("Asia/Tokyo")
+		local := filter
+		local.Location = tokyo
+		local.WeekStart = time.Sunday
+		mockM.ExpectQuery("\\(SELECT DATE_FORMAT\\(DATE_SUB\\(CONVERT_TZ\\(t.timestamp, '\\+00:00', \\?\\), "+
+			"INTERVAL MOD\\(WEEKDAY\\(CONVERT_TZ\\(t.timestamp, '\\+00:00', \\?\\)\\) \\+ \\?, 7\\) DAY\\), '%Y-%m-%d'\\) AS report_key").
+			WithArgs(TransactionTypeIncome, TransactionTypeExpense, "Asia/Tokyo", "Asia/Tokyo", 1, "USD", "USD", "USD", int64(10),
+				TransactionTypeIncome, TransactionTypeExpense, time.Date(2024, 1, 1, 0, 0, 0, 0, tokyo), int64(2)).
+			WillReturnRows(sqlmock.NewRows(reportColumns).AddRow("2023-12-31", "", 0.0, 12.0, 1, 0))
+
+		report, err := ModelsService.TransactionModel.GetTransactionReport(context.Background(), local, ReportGroupByWeek, "USD")
+		assert.NoError(t, err)
+		assert.Equal(t, "2023-12-31", report[0].Key)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Category filter counts the matching split lines", func(t *testing.T) {
+		byCategory := filter
+		byCategory.Category = "3"
+		mockM.ExpectQuery("\\(SELECT DATE_FORMAT\\(t.timestamp, '%Y-%m-01'\\) AS report_key, '' AS report_name, UPPER\\(t.type\\) AS type, COALESCE\\(sp.amount, t.amount\\) AS amount, (.+) "+
+			"FROM transactions t LEFT JOIN transaction_splits sp ON sp.transaction_id = t.transaction_id "+
+			"WHERE t.scope_id IN \\(\\?\\) AND UPPER\\(t.type\\) IN \\(\\?,\\?\\) AND COALESCE\\(sp.category_id, t.category_id\\) = \\? AND t.timestamp >= \\? AND t.source_id = \\? AND t.deleted_at IS NULL\\) AS x").
+			WithArgs(TransactionTypeIncome, TransactionTypeExpense, "USD", "USD", "USD", int64(10), TransactionTypeIncome, TransactionTypeExpense, "3", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), int64(2)).
+			WillReturnRows(sqlmock.NewRows(reportColumns).AddRow("2024-01-01", "", 0.0, 25.5, 2, 0))
+
+		report, err := ModelsService.TransactionModel.GetTransactionReport(context.Background(), byCategory, ReportGroupByMonth, "USD")
+		assert.NoError(t, err)
+		assert.Equal(t, interfaces.MoneyFromFloat(25.5), report[0].Expense)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Unsupported grouping", func(t *testing.T) {
+		report, err := ModelsService.TransactionModel.GetTransactionReport(context.Background(), filter, "year", "USD")
+		assert.ErrorIs(t, err, ErrInvalidReportGrouping)
+		assert.Nil(t, report)
+	})
+
+	t.Run("Invalid currency", func(t *testing.T) {
+		report, err := ModelsService.TransactionModel.GetTransactionReport(context.Background(), filter, ReportGroupByMonth, "dollars")
+		assert.ErrorIs(t, err, ErrInvalidCurrency)
+		assert.Nil(t, report)
+	})
+}
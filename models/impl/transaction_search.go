@@ -0,0 +1,618 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"xspends/models/interfaces"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxSearchCandidates bounds the transactions ranked for one query; the most recent matches are kept.
+	MaxSearchCandidates = 1000
+	maxSearchTermLength = 64
+)
+
+const (
+	searchFieldDescription = "description"
+	searchFieldCategory    = "category"
+	searchFieldSource      = "source"
+	searchFieldTags        = "tags"
+)
+
+// searchFieldWeights rank a match in the description above one in a tag, category or source name.
+var searchFieldWeights = map[string]float64{
+	searchFieldDescription: 3,
+	searchFieldTags:        2,
+	searchFieldCategory:    1.5,
+	searchFieldSource:      1,
+}
+
+// searchQualifiers maps the field names accepted before a colon in a query to the fields they search.
+var searchQualifiers = map[string]string{
+	"description": searchFieldDescription,
+	"desc":        searchFieldDescription,
+	"category":    searchFieldCategory,
+	"source":      searchFieldSource,
+	"tag":         searchFieldTags,
+	"tags":        searchFieldTags,
+}
+
+// searchNameTables are the tables whose names are matched by the clauses of a query.
+var searchNameTables = []struct{ field, table, id string }{
+	{searchFieldCategory, "categories", "category_id"},
+	{searchFieldSource, "sources", "source_id"},
+	{searchFieldTags, "tags", "tag_id"},
+}
+
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+type TransactionSearchModel struct {
+	TableTerms          string
+	ColumnScope         string
+	ColumnTerm          string
+	ColumnTransactionID string
+}
+
+func NewTransactionSearchModel() *TransactionSearchModel {
+	return &TransactionSearchModel{
+		TableTerms:          "transaction_terms",
+		ColumnScope:         "scope_id",
+		ColumnTerm:          "term",
+		ColumnTransactionID: "transaction_id",
+	}
+}
+
+// searchToken is a word of a text, lower-cased, with its byte offsets in the text.
+type searchToken struct {
+	word       string
+	start, end int
+}
+
+// tokenize splits text into its runs of letters and digits.
+func tokenize(text string) []searchToken {
+	var tokens []searchToken
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, searchToken{word: searchTerm(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, searchToken{word: searchTerm(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// searchTerm lower-cases a word and cuts it to the length of the term column.
+func searchTerm(word string) string {
+	word = strings.ToLower(word)
+	if utf8.RuneCountInString(word) > maxSearchTermLength {
+		word = string([]rune(word)[:maxSearchTermLength])
+	}
+	return word
+}
+
+// searchClause is a word, or a phrase of consecutive words, that a matching transaction must
+// contain in field, or in any of its text fields when field is empty. With prefix set, the last
+// word also matches the words it starts.
+type searchClause struct {
+	field  string
+	words  []string
+	prefix bool
+}
+
+// find returns the positions of the tokens matched by c and whether any match was exact.
+func (c searchClause) find(tokens []searchToken) ([]int, bool) {
+	var matched []int
+	exact := false
+	last := len(c.words) - 1
+	for i := 0; i+last < len(tokens); i++ {
+		found := true
+		for j, word := range c.words {
+			token := tokens[i+j].word
+			if token != word && !(c.prefix && j == last && strings.HasPrefix(token, word)) {
+				found = false
+				break
+			}
+		}
+		if found {
+			for j := range c.words {
+				matched = append(matched, i+j)
+			}
+			exact = exact || tokens[i+last].word == c.words[last]
+		}
+	}
+	return matched, exact
+}
+
+func (c searchClause) searches(field string) bool {
+	return c.field == "" || c.field == field
+}
+
+type amountCondition struct {
+	operator string
+	amount   interfaces.Money
+}
+
+type searchQuery struct {
+	clauses []searchClause
+	amounts []amountCondition
+	txnType string
+}
+
+// parseSearchQuery reads a query made of words, "quoted phrases" and field qualifiers such as
+// tag:travel, category:"eating out", amount:>100, amount:10..20 or type:expense. A word ending
+// in * matches the words it starts. Words joined by punctuation, such as coffee-shop, form a phrase.
+func parseSearchQuery(query string) (searchQuery, error) {
+	var parsed searchQuery
+	for _, part := range splitSearchQuery(query) {
+		field, value := "", part
+		if name, rest, ok := strings.Cut(part, ":"); ok {
+			name = strings.ToLower(name)
+			switch {
+			case name == "amount":
+				amounts, err := parseAmountConditions(rest)
+				if err != nil {
+					return parsed, err
+				}
+				parsed.amounts = append(parsed.amounts, amounts...)
+				continue
+			case name == "type":
+				txnType := strings.ToUpper(rest)
+				if txnType != TransactionTypeIncome && txnType != TransactionTypeExpense && txnType != TransactionTypeTransfer {
+					return parsed, errors.Wrapf(ErrInvalidSearchQuery, "unknown type %q", rest)
+				}
+				parsed.txnType = txnType
+				continue
+			case searchQualifiers[name] != "":
+				field, value = searchQualifiers[name], rest
+			}
+		}
+
+		quoted := strings.HasPrefix(value, `"`)
+		value = strings.Trim(value, `"`)
+		prefix := !quoted && strings.HasSuffix(value, "*")
+		clause := searchClause{field: field, prefix: prefix}
+		for _, token := range tokenize(value) {
+			clause.words = append(clause.words, token.word)
+		}
+		if len(clause.words) > 0 {
+			parsed.clauses = append(parsed.clauses, clause)
+		}
+	}
+
+	if len(parsed.clauses) == 0 && len(parsed.amounts) == 0 && parsed.txnType == "" {
+		return parsed, errors.Wrap(ErrInvalidSearchQuery, "nothing to search for")
+	}
+	return parsed, nil
+}
+
+// splitSearchQuery splits query on the spaces outside double quotes.
+func splitSearchQuery(query string) []string {
+	var parts []string
+	var part strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			part.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if part.Len() > 0 {
+				parts = append(parts, part.String())
+				part.Reset()
+			}
+		default:
+			part.WriteRune(r)
+		}
+	}
+	if part.Len() > 0 {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
+
+// parseAmountConditions reads >100, >=100, <50, <=50, 10..20 or an exact amount.
+func parseAmountConditions(value string) ([]amountCondition, error) {
+	if low, high, ok := strings.Cut(value, ".."); ok {
+		lowAmount, err := interfaces.ParseMoney(low)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidSearchQuery, "amount %q", value)
+		}
+		highAmount, err := interfaces.ParseMoney(high)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidSearchQuery, "amount %q", value)
+		}
+		return []amountCondition{{">=", lowAmount}, {"<=", highAmount}}, nil
+	}
+
+	operator, text := "=", value
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, op) {
+			operator, text = op, value[len(op):]
+			break
+		}
+	}
+	amount, err := interfaces.ParseMoney(text)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidSearchQuery, "amount %q", value)
+	}
+	return []amountCondition{{operator, amount}}, nil
+}
+
+// IndexTransaction replaces the indexed words of txn with those of its description.
+func (sm *TransactionSearchModel) IndexTransaction(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		query, args, err := GetQueryBuilder().Delete(sm.TableTerms).
+			Where(squirrel.Eq{sm.ColumnTransactionID: txn.ID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "failed to build query for clearing transaction terms")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "clearing transaction terms failed")
+		}
+
+		insert := GetQueryBuilder().Insert(sm.TableTerms).Columns(sm.ColumnScope, sm.ColumnTerm, sm.ColumnTransactionID)
+		seen := make(map[string]bool)
+		for _, token := range tokenize(txn.Description) {
+			if !seen[token.word] {
+				seen[token.word] = true
+				insert = insert.Values(txn.ScopeID, token.word, txn.ID)
+			}
+		}
+		if len(seen) == 0 {
+			return nil
+		}
+
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return errors.Wrap(err, "failed to build query for indexing transaction terms")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "indexing transaction terms failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// IndexMissingTransactions indexes the transactions with a description but no indexed words,
+// such as those recorded before the index existed, batchSize at a time. It returns how many
+// transactions it indexed.
+func (sm *TransactionSearchModel) IndexMissingTransactions(ctx context.Context, batchSize int, otx ...*sql.Tx) (int, error) {
+	_, executor := getExecutor(otx...)
+	transactions := NewTransactionModel()
+
+	indexed := 0
+	var lastID int64
+	for {
+		query, args, err := GetQueryBuilder().Select("t."+transactions.ColumnID, "t."+transactions.ColumnScope, "t."+transactions.ColumnDescription).
+			From(transactions.TableTransactions + " t").
+			Where(squirrel.Gt{"t." + transactions.ColumnID: lastID}).
+			Where("t." + transactions.ColumnDescription + " <> ''").
+			Where("NOT EXISTS (SELECT 1 FROM " + sm.TableTerms + " x WHERE x." + sm.ColumnTransactionID + " = t." + transactions.ColumnID + ")").
+			OrderBy("t." + transactions.ColumnID).
+			Limit(uint64(batchSize)).
+			ToSql()
+		if err != nil {
+			return indexed, errors.Wrap(err, "failed to build query for unindexed transactions")
+		}
+
+		rows, err := executor.QueryContext(ctx, query, args...)
+		if err != nil {
+			return indexed, errors.Wrap(err, "querying unindexed transactions failed")
+		}
+		var batch []interfaces.Transaction
+		for rows.Next() {
+			var txn interfaces.Transaction
+			if err := rows.Scan(&txn.ID, &txn.ScopeID, &txn.Description); err != nil {
+				rows.Close()
+				return indexed, errors.Wrap(err, "scanning unindexed transaction failed")
+			}
+			batch = append(batch, txn)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return indexed, errors.Wrap(err, "processing unindexed transactions failed")
+		}
+
+		for _, txn := range batch {
+			if err := sm.IndexTransaction(ctx, txn, otx...); err != nil {
+				return indexed, err
+			}
+			indexed++
+			lastID = txn.ID
+		}
+		if len(batch) < batchSize {
+			return indexed, nil
+		}
+	}
+}
+
+// SearchTransactions finds the transactions matching filter and the text query, ranked by
+// relevance, and returns the requested page with the total number of matches. See
+// parseSearchQuery for the query syntax. Every word or phrase must appear in the description
+// or in a tag, category or source name; description matches weigh the most, exact words more
+// than prefixes. At most MaxSearchCandidates of the most recent matching transactions are ranked.
+func (sm *TransactionSearchModel) SearchTransactions(ctx context.Context, filter interfaces.TransactionFilter, query string, otx ...*sql.Tx) ([]interfaces.TransactionSearchResult, int, error) {
+	parsed, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	_, executor := getExecutor(otx...)
+	if parsed.txnType != "" {
+		filter.Type = parsed.txnType
+	}
+
+	names := make(map[string]map[int64]string)
+	if len(parsed.clauses) > 0 {
+		for _, table := range searchNameTables {
+			if names[table.field], err = searchNames(ctx, executor, table.table, table.id, filter.Scopes); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	transactions := NewTransactionModel()
+	selection := transactions.exportQuery(filter)
+	for _, condition := range parsed.amounts {
+		selection = selection.Where("t."+transactions.ColumnAmount+" "+condition.operator+" ?", condition.amount)
+	}
+	for _, clause := range parsed.clauses {
+		alternatives := sm.clauseConditions(clause, filter.Scopes, names)
+		if len(alternatives) == 0 {
+			return []interfaces.TransactionSearchResult{}, 0, nil
+		}
+		selection = selection.Where(alternatives)
+	}
+	selection = selection.OrderBy("t."+transactions.ColumnTimestamp+" DESC", "t."+transactions.ColumnID+" DESC").
+		Limit(MaxSearchCandidates)
+
+	sqlQuery, args, err := selection.ToSql()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "constructing search query failed")
+	}
+	rows, err := executor.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "searching transactions failed")
+	}
+	defer rows.Close()
+
+	results := make([]interfaces.TransactionSearchResult, 0)
+	for rows.Next() {
+		var txn interfaces.TransactionExport
+		if err := scanTransactionExport(rows, &txn); err != nil {
+			return nil, 0, errors.Wrap(err, "scanning search result failed")
+		}
+		if result, ok := rankSearchResult(parsed, txn); ok {
+			results = append(results, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.Wrap(err, "processing search results failed")
+	}
+
+	// Rows come most recent first, which the stable sort keeps among equal scores
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	total := len(results)
+	if filter.Page > 0 && filter.ItemsPerPage > 0 {
+		start := (filter.Page - 1) * filter.ItemsPerPage
+		if start > total {
+			start = total
+		}
+		end := start + filter.ItemsPerPage
+		if end > total {
+			end = total
+		}
+		results = results[start:end]
+	}
+	return results, total, nil
+}
+
+// searchNames loads the names of the rows of table in scopes that are not in the trash, by ID.
+func searchNames(ctx context.Context, executor DBExecutor, table, idColumn string, scopes []int64) (map[int64]string, error) {
+	query, args, err := GetQueryBuilder().Select(idColumn, "name").
+		From(table).
+		Where(squirrel.Eq{"scope_id": scopes, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build query for %s names", table)
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying %s names failed", table)
+	}
+	defer rows.Close()
+
+	names := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, errors.Wrapf(err, "scanning %s name failed", table)
+		}
+		names[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "processing %s names failed", table)
+	}
+	return names, nil
+}
+
+// clauseConditions returns the conditions under which a transaction may match clause, one per
+// field. Descriptions are looked up in the index, which holds words but not their order, so the
+// rows found are checked again by rankSearchResult. Names are matched here against names.
+func (sm *TransactionSearchModel) clauseConditions(clause searchClause, scopes []int64, names map[string]map[int64]string) squirrel.Or {
+	var alternatives squirrel.Or
+	if clause.searches(searchFieldDescription) {
+		words := squirrel.And{}
+		for i, word := range clause.words {
+			terms := GetQueryBuilder().Select(sm.ColumnTransactionID).
+				From(sm.TableTerms).
+				Where(squirrel.Eq{sm.ColumnScope: scopes})
+			if clause.prefix && i == len(clause.words)-1 {
+				terms = terms.Where(squirrel.Like{sm.ColumnTerm: word + "%"})
+			} else {
+				terms = terms.Where(squirrel.Eq{sm.ColumnTerm: word})
+			}
+			words = append(words, squirrel.Expr("t.transaction_id IN (?)", terms))
+		}
+		alternatives = append(alternatives, words)
+	}
+
+	matching := func(field string) []int64 {
+		var ids []int64
+		if !clause.searches(field) {
+			return ids
+		}
+		for id, name := range names[field] {
+			if matched, _ := clause.find(tokenize(name)); len(matched) > 0 {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+	if ids := matching(searchFieldCategory); len(ids) > 0 {
+		alternatives = append(alternatives, squirrel.Eq{"t.category_id": ids})
+	}
+	if ids := matching(searchFieldSource); len(ids) > 0 {
+		alternatives = append(alternatives, squirrel.Eq{"t.source_id": ids}, squirrel.Eq{"t.destination_source_id": ids})
+	}
+	if ids := matching(searchFieldTags); len(ids) > 0 {
+		tagged := GetQueryBuilder().Select("transaction_id").
+			From("transaction_tags").
+			Where(squirrel.Eq{"tag_id": ids})
+		alternatives = append(alternatives, squirrel.Expr("t.transaction_id IN (?)", tagged))
+	}
+	return alternatives
+}
+
+// searchField is one text of a transaction being ranked, with the positions of its matched tokens.
+type searchField struct {
+	name    string
+	text    string
+	tokens  []searchToken
+	matched map[int]bool
+}
+
+// rankSearchResult scores txn against the text clauses of query and highlights what matched.
+// Each clause adds the weight of the best field it matches, times its number of words, halved
+// when only a prefix matched. It returns false when a clause matches no field, which happens when
+// the index found the words of a phrase apart.
+func rankSearchResult(query searchQuery, txn interfaces.TransactionExport) (interfaces.TransactionSearchResult, bool) {
+	texts := []struct{ name, text string }{
+		{searchFieldDescription, txn.Description},
+		{searchFieldCategory, txn.Category},
+		{searchFieldSource, txn.Source},
+		{searchFieldSource, txn.DestinationSource},
+	}
+	for _, tag := range txn.Tags {
+		texts = append(texts, struct{ name, text string }{searchFieldTags, tag})
+	}
+	var fields []*searchField
+	for _, text := range texts {
+		if text.text != "" {
+			fields = append(fields, &searchField{name: text.name, text: text.text, tokens: tokenize(text.text), matched: make(map[int]bool)})
+		}
+	}
+
+	result := interfaces.TransactionSearchResult{TransactionExport: txn}
+	for _, clause := range query.clauses {
+		best := 0.0
+		for _, field := range fields {
+			if !clause.searches(field.name) {
+				continue
+			}
+			matched, exact := clause.find(field.tokens)
+			if len(matched) == 0 {
+				continue
+			}
+			for _, i := range matched {
+				field.matched[i] = true
+			}
+			score := searchFieldWeights[field.name] * float64(len(clause.words))
+			if !exact {
+				score /= 2
+			}
+			if score > best {
+				best = score
+			}
+		}
+		if best == 0 {
+			return result, false
+		}
+		result.Score += best
+	}
+
+	for _, field := range fields {
+		if len(field.matched) == 0 {
+			continue
+		}
+		if result.Highlights == nil {
+			result.Highlights = make(map[string][]string)
+		}
+		result.Highlights[field.name] = append(result.Highlights[field.name], highlight(field))
+	}
+	return result, true
+}
+
+// highlight escapes the text of field for HTML and wraps its matched tokens in <mark> tags.
+func highlight(field *searchField) string {
+	var text strings.Builder
+	offset := 0
+	for i, token := range field.tokens {
+		if !field.matched[i] {
+			continue
+		}
+		text.WriteString(html.EscapeString(field.text[offset:token.start]))
+		text.WriteString("<mark>" + html.EscapeString(field.text[token.start:token.end]) + "</mark>")
+		offset = token.end
+	}
+	text.WriteString(html.EscapeString(field.text[offset:]))
+	return text.String()
+}
@@ -0,0 +1,199 @@
+package impl
+
+import (
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newNoSearchIndexMock is a search model that accepts every index update.
+func newNoSearchIndexMock() *xmock.MockTransactionSearchModel {
+	mockSearchModel := new(xmock.MockTransactionSearchModel)
+	mockSearchModel.On("IndexTransaction", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	return mockSearchModel
+}
+
+var searchResultColumns = []string{"transaction_id", "timestamp", "type", "amount", "currency", "description", "category", "source",
+	"destination_source", "tags", "source_id", "destination_source_id", "transfer_id"}
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected searchQuery
+		invalid  bool
+	}{
+		{
+			name:  "Words, phrases and prefixes",
+			query: `coffee "Blue Bottle" star* coffee-shop`,
+			expected: searchQuery{clauses: []searchClause{
+				{words: []string{"coffee"}},
+				{words: []string{"blue", "bottle"}},
+				{words: []string{"star"}, prefix: true},
+				{words: []string{"coffee", "shop"}},
+			}},
+		},
+		{
+			name:  "Qualifiers",
+			query: `tag:travel Category:"eating out" desc:uber* amount:>100.5 amount:10..20 type:expense`,
+			expected: searchQuery{
+				clauses: []searchClause{
+					{field: searchFieldTags, words: []string{"travel"}},
+					{field: searchFieldCategory, words: []string{"eating", "out"}},
+					{field: searchFieldDescription, words: []string{"uber"}, prefix: true},
+				},
+				amounts: []amountCondition{
+					{">", interfaces.MoneyFromFloat(100.5)},
+					{">=", interfaces.MoneyFromFloat(10)},
+					{"<=", interfaces.MoneyFromFloat(20)},
+				},
+				txnType: TransactionTypeExpense,
+			},
+		},
+		{
+			name:     "Unknown qualifiers are searched as text",
+			query:    "http://example.com",
+			expected: searchQuery{clauses: []searchClause{{words: []string{"http", "example", "com"}}}},
+		},
+		{name: "Bad amount", query: "amount:>lots", invalid: true},
+		{name: "Bad type", query: "type:refund", invalid: true},
+		{name: "Nothing to search for", query: ` * "" `, invalid: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := parseSearchQuery(tc.query)
+			if tc.invalid {
+				assert.Equal(t, ErrInvalidSearchQuery, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, parsed)
+		})
+	}
+}
+
+func TestRankSearchResult(t *testing.T) {
+	txn := interfaces.TransactionExport{ID: 1, Description: "Coffee & cake at Blue Bottle", Category: "Coffee shops", Tags: []string{"treats"}}
+
+	t.Run("Highlights every matching field", func(t *testing.T) {
+		query, _ := parseSearchQuery(`coffee "blue bottle" treat*`)
+		result, ok := rankSearchResult(query, txn)
+		assert.True(t, ok)
+		// coffee in the description, the phrase in the description, and a tag prefix
+		assert.Equal(t, 3+3*2+2*0.5, result.Score)
+		assert.Equal(t, map[string][]string{
+			"description": {"<mark>Coffee</mark> &amp; cake at <mark>Blue</mark> <mark>Bottle</mark>"},
+			"category":    {"<mark>Coffee</mark> shops"},
+			"tags":        {"<mark>treats</mark>"},
+		}, result.Highlights)
+	})
+
+	t.Run("Qualified clauses only search their field", func(t *testing.T) {
+		query, _ := parseSearchQuery("category:cake")
+		_, ok := rankSearchResult(query, txn)
+		assert.False(t, ok)
+	})
+
+	t.Run("Phrase words apart do not match", func(t *testing.T) {
+		query, _ := parseSearchQuery(`"cake coffee"`)
+		_, ok := rankSearchResult(query, txn)
+		assert.False(t, ok)
+	})
+}
+
+func TestIndexTransaction(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionSearchModel = NewTransactionSearchModel()
+	})
+	defer tearDown()
+	_, sqlMock := setupNewMock(t)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("^DELETE FROM transaction_terms WHERE transaction_id = \\?").
+		WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectExec("^INSERT INTO transaction_terms \\(scope_id,term,transaction_id\\) VALUES \\(\\?,\\?,\\?\\),\\(\\?,\\?,\\?\\)").
+		WithArgs(int64(10), "café", int64(1), int64(10), "latte", int64(1)).WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+
+	txn := interfaces.Transaction{ID: 1, ScopeID: 10, Description: "CAFÉ latte, café!"}
+	assert.NoError(t, ModelsService.TransactionSearchModel.IndexTransaction(ctx, txn))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSearchTransactions(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionSearchModel = NewTransactionSearchModel()
+	})
+	defer tearDown()
+	filter := interfaces.TransactionFilter{Scopes: []int64{10}, Page: 1, ItemsPerPage: 10}
+	now := time.Now()
+
+	t.Run("Ranks and checks the candidates", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		sqlMock.ExpectQuery("^SELECT category_id, name FROM categories WHERE deleted_at IS NULL AND scope_id IN \\(\\?\\)").
+			WithArgs(int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"category_id", "name"}).AddRow(2, "Hotels").AddRow(3, "Food"))
+		sqlMock.ExpectQuery("^SELECT source_id, name FROM sources").
+			WithArgs(int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"source_id", "name"}).AddRow(1, "Visa"))
+		sqlMock.ExpectQuery("^SELECT tag_id, name FROM tags").
+			WithArgs(int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"tag_id", "name"}).AddRow(5, "Travel"))
+		// The tag clause has only a tag to match; hotel* may be a description word or the category
+		sqlMock.ExpectQuery("^SELECT (.+) FROM transactions t (.+) "+
+			"AND \\(t.transaction_id IN \\(SELECT transaction_id FROM transaction_tags WHERE tag_id IN \\(\\?\\)\\)\\) "+
+			"AND \\(\\(t.transaction_id IN \\(SELECT transaction_id FROM transaction_terms WHERE scope_id IN \\(\\?\\) AND term LIKE \\?\\)\\) OR t.category_id IN \\(\\?\\)\\) "+
+			"ORDER BY t.timestamp DESC, t.transaction_id DESC LIMIT 1000").
+			WithArgs(int64(10), int64(5), int64(10), "hotel%", int64(2)).
+			WillReturnRows(sqlmock.NewRows(searchResultColumns).
+				AddRow(3, now, "EXPENSE", "40.00", "EUR", "Dinner", "Hotels", "Visa", "", "Travel", 1, 0, 0).
+				AddRow(2, now, "EXPENSE", "120.00", "EUR", "Hotel in Rome", "Hotels", "Visa", "", "Travel", 1, 0, 0).
+				AddRow(1, now, "EXPENSE", "12.00", "EUR", "Taxi to the hotel", "Transport", "Visa", "", "", 1, 0, 0))
+
+		results, total, err := ModelsService.TransactionSearchModel.SearchTransactions(ctx, filter, "tag:travel hotel*")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		if assert.Len(t, results, 2) {
+			assert.Equal(t, int64(2), results[0].ID)
+			assert.Equal(t, 2+3.0, results[0].Score)
+			assert.Equal(t, int64(3), results[1].ID)
+			assert.Equal(t, 2+0.75, results[1].Score)
+			assert.Equal(t, []string{"<mark>Hotels</mark>"}, results[1].Highlights["category"])
+		}
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Nothing can match an unknown tag", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		sqlMock.ExpectQuery("^SELECT category_id, name FROM categories").WillReturnRows(sqlmock.NewRows([]string{"category_id", "name"}))
+		sqlMock.ExpectQuery("^SELECT source_id, name FROM sources").WillReturnRows(sqlmock.NewRows([]string{"source_id", "name"}))
+		sqlMock.ExpectQuery("^SELECT tag_id, name FROM tags").WillReturnRows(sqlmock.NewRows([]string{"tag_id", "name"}))
+
+		results, total, err := ModelsService.TransactionSearchModel.SearchTransactions(ctx, filter, "tag:travel")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, results)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Amounts alone need no names", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		sqlMock.ExpectQuery("^SELECT (.+) FROM transactions t (.+) AND t.type = \\? (.+) AND t.amount > \\? ORDER BY").
+			WithArgs(int64(10), TransactionTypeIncome, interfaces.MoneyFromFloat(100)).
+			WillReturnRows(sqlmock.NewRows(searchResultColumns).
+				AddRow(4, now, "INCOME", "900.00", "EUR", "Salary", "", "Visa", "", "", 1, 0, 0))
+
+		results, total, err := ModelsService.TransactionSearchModel.SearchTransactions(ctx, filter, "amount:>100 type:income")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, results, 1)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
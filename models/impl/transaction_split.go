@@ -0,0 +1,240 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"xspends/models/interfaces"
+	"xspends/util"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidSplit = errors.New("a split needs at least two lines with positive amounts that add up to the transaction amount, and cannot be used on transfers")
+
+type TransactionSplitModel struct {
+	TableSplits         string
+	TableSplitTags      string
+	ColumnID            string
+	ColumnTransactionID string
+	ColumnCategoryID    string
+	ColumnAmount        string
+	ColumnDescription   string
+	ColumnTagID         string
+}
+
+func NewTransactionSplitModel() *TransactionSplitModel {
+	return &TransactionSplitModel{
+		TableSplits:         "transaction_splits",
+		TableSplitTags:      "transaction_split_tags",
+		ColumnID:            "split_id",
+		ColumnTransactionID: "transaction_id",
+		ColumnCategoryID:    "category_id",
+		ColumnAmount:        "amount",
+		ColumnDescription:   "description",
+		ColumnTagID:         "tag_id",
+	}
+}
+
+// isSplit reports whether a stored transaction is split: split transactions leave their own
+// category empty, which no other INCOME or EXPENSE transaction may do.
+func isSplit(txn interfaces.Transaction) bool {
+	return txn.CategoryID == 0 && txn.TransferID == 0 && strings.ToUpper(txn.Type) != TransactionTypeTransfer
+}
+
+// checkSplits rounds the lines of a split transaction to its currency and checks that they add
+// up to its amount. The transaction's own category is cleared; its lines carry the categories.
+func checkSplits(txn *interfaces.Transaction) error {
+	if len(txn.Splits) == 0 {
+		return nil
+	}
+	if len(txn.Splits) < 2 || strings.ToUpper(txn.Type) == TransactionTypeTransfer {
+		return ErrInvalidSplit
+	}
+	var total interfaces.Money
+	for i := range txn.Splits {
+		line := &txn.Splits[i]
+		line.Amount = line.Amount.Round(txn.Currency)
+		if line.Amount <= 0 || line.CategoryID <= 0 {
+			return ErrInvalidSplit
+		}
+		total += line.Amount
+	}
+	if total != txn.Amount {
+		return errors.Wrapf(ErrInvalidSplit, "the lines add up to %s, the transaction is %s", total, txn.Amount)
+	}
+	txn.CategoryID = 0
+	return nil
+}
+
+// validateSplitCategories checks that the category of every line exists in the transaction's scope.
+func validateSplitCategories(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
+	checked := make(map[int64]bool, len(txn.Splits))
+	for _, line := range txn.Splits {
+		if checked[line.CategoryID] {
+			continue
+		}
+		exists, err := GetModelsService().CategoryModel.CategoryIDExists(ctx, line.CategoryID, []int64{txn.ScopeID}, otx...)
+		if err != nil {
+			return errors.Wrap(err, "error checking if category exists")
+		}
+		if !exists {
+			return errors.New("category does not exist")
+		}
+		checked[line.CategoryID] = true
+	}
+	return nil
+}
+
+// ReplaceSplits stores txn.Splits as the lines of the transaction, replacing any it had.
+// With no splits the transaction is left unsplit. Lines are expected to be checked already.
+func (sm *TransactionSplitModel) ReplaceSplits(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		splitIDs := GetQueryBuilder().Select(sm.ColumnID).
+			From(sm.TableSplits).
+			Where(squirrel.Eq{sm.ColumnTransactionID: txn.ID})
+		query, args, err := GetQueryBuilder().Delete(sm.TableSplitTags).
+			Where(squirrel.Expr(sm.ColumnID+" IN (?)", splitIDs)).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "failed to build delete query for split tags")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "deleting split tags failed")
+		}
+
+		query, args, err = GetQueryBuilder().Delete(sm.TableSplits).
+			Where(squirrel.Eq{sm.ColumnTransactionID: txn.ID}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "failed to build delete query for splits")
+		}
+		if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "deleting splits failed")
+		}
+
+		for _, line := range txn.Splits {
+			line.ID, err = util.GenerateSnowflakeID()
+			if err != nil {
+				return errors.Wrap(err, "generating split ID failed")
+			}
+			query, args, err = GetQueryBuilder().Insert(sm.TableSplits).
+				Columns(sm.ColumnID, sm.ColumnTransactionID, sm.ColumnCategoryID, sm.ColumnAmount, sm.ColumnDescription).
+				Values(line.ID, txn.ID, line.CategoryID, line.Amount, line.Description).
+				ToSql()
+			if err != nil {
+				return errors.Wrap(err, "failed to build insert query for split")
+			}
+			if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+				return errors.Wrap(err, "insert split failed")
+			}
+			if err := sm.addSplitTags(ctx, txn, line, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, otx...)
+}
+
+// addSplitTags tags a split line, creating the tags that do not exist yet in the transaction's scope.
+func (sm *TransactionSplitModel) addSplitTags(ctx context.Context, txn interfaces.Transaction, line interfaces.TransactionSplit, otx ...*sql.Tx) error {
+	if len(line.Tags) == 0 {
+		return nil
+	}
+	_, executor := getExecutor(otx...)
+
+	if err := addMissingTags(ctx, interfaces.Transaction{UserID: txn.UserID, ScopeID: txn.ScopeID, Tags: line.Tags}, otx...); err != nil {
+		return errors.Wrap(err, "handling split tags failed")
+	}
+	insert := GetQueryBuilder().Insert(sm.TableSplitTags).
+		Columns(sm.ColumnID, sm.ColumnTagID).
+		Options("IGNORE")
+	for _, tagName := range line.Tags {
+		tag, err := GetModelsService().TagModel.GetTagByName(ctx, tagName, []int64{txn.ScopeID}, otx...)
+		if err != nil {
+			return errors.Wrap(err, "error getting tag by name")
+		}
+		insert = insert.Values(line.ID, tag.ID)
+	}
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "failed to build insert query for split tags")
+	}
+	if _, err := executor.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "tagging split failed")
+	}
+	return nil
+}
+
+// GetSplitsByTransactionIDs returns the lines of the given transactions with their tag names,
+// keyed by transaction ID. Transactions that are not split have no entry.
+func (sm *TransactionSplitModel) GetSplitsByTransactionIDs(ctx context.Context, transactionIDs []int64, otx ...*sql.Tx) (map[int64][]interfaces.TransactionSplit, error) {
+	splits := make(map[int64][]interfaces.TransactionSplit)
+	if len(transactionIDs) == 0 {
+		return splits, nil
+	}
+	_, executor := getExecutor(otx...)
+
+	tags := "(SELECT GROUP_CONCAT(g.name ORDER BY g.name SEPARATOR '" + exportTagSeparator + "') " +
+		"FROM " + sm.TableSplitTags + " st JOIN tags g ON g.tag_id = st.tag_id AND g.deleted_at IS NULL WHERE st.split_id = s." + sm.ColumnID + ")"
+	query, args, err := GetQueryBuilder().Select("s."+sm.ColumnID, "s."+sm.ColumnTransactionID, "s."+sm.ColumnCategoryID, "s."+sm.ColumnAmount,
+		"COALESCE(s."+sm.ColumnDescription+", '')", "COALESCE("+tags+", '')").
+		From(sm.TableSplits+" s").
+		Where(squirrel.Eq{"s." + sm.ColumnTransactionID: transactionIDs}).
+		OrderBy("s."+sm.ColumnTransactionID, "s."+sm.ColumnID).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build query for splits")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying splits failed")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line interfaces.TransactionSplit
+		var tagNames string
+		if err := rows.Scan(&line.ID, &line.TransactionID, &line.CategoryID, &line.Amount, &line.Description, &tagNames); err != nil {
+			return nil, errors.Wrap(err, "scanning split failed")
+		}
+		line.Tags = []string{}
+		if tagNames != "" {
+			line.Tags = strings.Split(tagNames, exportTagSeparator)
+		}
+		splits[line.TransactionID] = append(splits[line.TransactionID], line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "processing splits failed")
+	}
+	return splits, nil
+}
@@ -0,0 +1,234 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckSplits(t *testing.T) {
+	txn := interfaces.Transaction{
+		Type: "expense", Currency: "USD", CategoryID: 1, Amount: interfaces.MoneyFromFloat(100),
+		Splits: []interfaces.TransactionSplit{
+			{CategoryID: 2, Amount: interfaces.MoneyFromFloat(60.004)},
+			{CategoryID: 3, Amount: interfaces.MoneyFromFloat(40)},
+		},
+	}
+	assert.NoError(t, checkSplits(&txn))
+	assert.Equal(t, int64(0), txn.CategoryID, "the lines carry the categories")
+	assert.Equal(t, interfaces.MoneyFromFloat(60), txn.Splits[0].Amount)
+
+	unsplit := interfaces.Transaction{Type: "expense", CategoryID: 1, Amount: interfaces.MoneyFromFloat(100)}
+	assert.NoError(t, checkSplits(&unsplit))
+	assert.Equal(t, int64(1), unsplit.CategoryID)
+
+	yen := interfaces.Transaction{Type: "expense", Currency: "JPY", Amount: interfaces.MoneyFromFloat(1000),
+		Splits: []interfaces.TransactionSplit{{CategoryID: 2, Amount: interfaces.MoneyFromFloat(499.6)}, {CategoryID: 3, Amount: interfaces.MoneyFromFloat(500.4)}}}
+	assert.NoError(t, checkSplits(&yen), "lines are rounded to whole yen before they are added up")
+
+	invalid := map[string]interfaces.Transaction{
+		"Lines do not add up": {Type: "expense", Amount: interfaces.MoneyFromFloat(100), Splits: []interfaces.TransactionSplit{
+			{CategoryID: 2, Amount: interfaces.MoneyFromFloat(60)}, {CategoryID: 3, Amount: interfaces.MoneyFromFloat(30)}}},
+		"Single line": {Type: "expense", Amount: interfaces.MoneyFromFloat(100), Splits: []interfaces.TransactionSplit{
+			{CategoryID: 2, Amount: interfaces.MoneyFromFloat(100)}}},
+		"Negative line": {Type: "expense", Amount: interfaces.MoneyFromFloat(100), Splits: []interfaces.TransactionSplit{
+			{CategoryID: 2, Amount: interfaces.MoneyFromFloat(120)}, {CategoryID: 3, Amount: interfaces.MoneyFromFloat(-20)}}},
+		"Line without category": {Type: "expense", Amount: interfaces.MoneyFromFloat(100), Splits: []interfaces.TransactionSplit{
+			{CategoryID: 2, Amount: interfaces.MoneyFromFloat(50)}, {Amount: interfaces.MoneyFromFloat(50)}}},
+		"Transfer": {Type: "transfer", Amount: interfaces.MoneyFromFloat(100), Splits: []interfaces.TransactionSplit{
+			{CategoryID: 2, Amount: interfaces.MoneyFromFloat(50)}, {CategoryID: 3, Amount: interfaces.MoneyFromFloat(50)}}},
+	}
+	for name, txn := range invalid {
+		assert.ErrorIs(t, checkSplits(&txn), ErrInvalidSplit, name)
+	}
+}
+
+func TestInsertSplitTransaction(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionModel = NewTransactionModel()
+		config.UserModel = NewUserModel()
+		config.SourceModel = NewSourceModel()
+		config.CategoryModel = NewCategoryModel()
+		config.ScopeModel = NewScopeModel()
+		config.UserScopeModel = newValidatingUserScopeMock()
+	})
+	defer tearDown()
+
+	txn := interfaces.Transaction{
+		UserID: 1, SourceID: 1, ScopeID: 1, Type: "expense", Description: "Supermarket",
+		Amount: interfaces.MoneyFromFloat(80),
+		Splits: []interfaces.TransactionSplit{
+			{CategoryID: 2, Amount: interfaces.MoneyFromFloat(50), Description: "Groceries"},
+			{CategoryID: 3, Amount: interfaces.MoneyFromFloat(20), Description: "Detergent"},
+			{CategoryID: 2, Amount: interfaces.MoneyFromFloat(10), Description: "Fruit", Tags: []string{"gift"}},
+		},
+	}
+
+	_, mockM := setupNewMock(t)
+	mockTransactionTagModel := new(xmock.MockTransactionTagModel)
+	mockSplitModel := new(xmock.MockTransactionSplitModel)
+	ModelsService.TransactionTagModel = mockTransactionTagModel
+	ModelsService.TransactionSplitModel = mockSplitModel
+
+	mockM.ExpectBegin()
+	mockM.ExpectQuery("^SELECT (.+) FROM users WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+	mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+	// The split transaction has no category of its own to check
+	mockM.ExpectQuery("^SELECT (.+) FROM scopes WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+	expectSourceCurrency(mockM, txn.SourceID, "USD")
+	// Each category of the lines is checked once
+	mockM.ExpectQuery("^SELECT (.+) FROM categories WHERE").WithArgs(int64(2), sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+	mockM.ExpectQuery("^SELECT (.+) FROM categories WHERE").WithArgs(int64(3), sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+	mockM.ExpectExec("INSERT INTO transactions").
+		WithArgs(sqlmock.AnyArg(), txn.UserID, txn.SourceID, sql.NullInt64{}, sqlmock.AnyArg(), txn.Amount, txn.Type, txn.Description, txn.ScopeID, sql.NullInt64{}, "USD").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectBalanceUpdate(mockM, txn.SourceID, -txn.Amount)
+	mockM.ExpectCommit()
+
+	mockTransactionTagModel.On("AddTagsToTransaction", mock.Anything, mock.Anything, mock.Anything, []int64{txn.ScopeID}, mock.Anything).Return(nil).Once()
+	mockSplitModel.On("ReplaceSplits", mock.Anything, mock.MatchedBy(func(stored interfaces.Transaction) bool {
+		return stored.ID != 0 && stored.CategoryID == 0 && len(stored.Splits) == 3
+	}), mock.Anything).Return(nil).Once()
+
+	assert.NoError(t, ModelsService.TransactionModel.InsertTransaction(context.Background(), txn))
+	assert.NoError(t, mockM.ExpectationsWereMet())
+	mockSplitModel.AssertExpectations(t)
+
+	t.Run("Lines that do not add up", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		unbalanced := txn
+		unbalanced.Amount = interfaces.MoneyFromFloat(75)
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT (.+) FROM users WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM scopes WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		expectSourceCurrency(mockM, txn.SourceID, "USD")
+		mockM.ExpectRollback()
+
+		err := ModelsService.TransactionModel.InsertTransaction(context.Background(), unbalanced)
+		assert.ErrorIs(t, err, ErrInvalidSplit)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Transfers cannot be split", func(t *testing.T) {
+		transfer := txn
+		transfer.Type = TransactionTypeTransfer
+		transfer.DestinationSourceID = 2
+		_, err := ModelsService.TransactionModel.InsertTransfer(context.Background(), transfer)
+		assert.ErrorIs(t, err, ErrInvalidSplit)
+	})
+}
+
+func TestReplaceSplits(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionSplitModel = NewTransactionSplitModel()
+	})
+	defer tearDown()
+
+	_, mockM := setupNewMock(t)
+	mockTagModel := new(xmock.MockTagModel)
+	ModelsService.TagModel = mockTagModel
+
+	txn := interfaces.Transaction{ID: 7, UserID: 1, ScopeID: 10, Splits: []interfaces.TransactionSplit{
+		{CategoryID: 2, Amount: interfaces.MoneyFromFloat(50), Description: "Groceries"},
+		{CategoryID: 3, Amount: interfaces.MoneyFromFloat(30), Tags: []string{"gift"}},
+	}}
+	mockTagModel.On("GetTagByName", mock.Anything, "gift", []int64{10}, mock.Anything).
+		Return(&interfaces.Tag{ID: 99, Name: "gift"}, nil)
+
+	mockM.ExpectBegin()
+	mockM.ExpectExec("^DELETE FROM transaction_split_tags WHERE split_id IN \\(SELECT split_id FROM transaction_splits WHERE transaction_id = \\?\\)").
+		WithArgs(int64(7)).WillReturnResult(sqlmock.NewResult(0, 2))
+	mockM.ExpectExec("^DELETE FROM transaction_splits WHERE transaction_id = \\?").
+		WithArgs(int64(7)).WillReturnResult(sqlmock.NewResult(0, 2))
+	mockM.ExpectExec("^INSERT INTO transaction_splits \\(split_id,transaction_id,category_id,amount,description\\)").
+		WithArgs(sqlmock.AnyArg(), int64(7), int64(2), interfaces.MoneyFromFloat(50), "Groceries").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockM.ExpectExec("^INSERT INTO transaction_splits").
+		WithArgs(sqlmock.AnyArg(), int64(7), int64(3), interfaces.MoneyFromFloat(30), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockM.ExpectExec("^INSERT IGNORE INTO transaction_split_tags \\(split_id,tag_id\\)").
+		WithArgs(sqlmock.AnyArg(), int64(99)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockM.ExpectCommit()
+
+	assert.NoError(t, ModelsService.TransactionSplitModel.ReplaceSplits(context.Background(), txn))
+	assert.NoError(t, mockM.ExpectationsWereMet())
+
+	t.Run("Removing the splits", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		mockM.ExpectExec("^DELETE FROM transaction_split_tags").WillReturnResult(sqlmock.NewResult(0, 2))
+		mockM.ExpectExec("^DELETE FROM transaction_splits").WillReturnResult(sqlmock.NewResult(0, 2))
+		mockM.ExpectCommit()
+
+		assert.NoError(t, ModelsService.TransactionSplitModel.ReplaceSplits(context.Background(), interfaces.Transaction{ID: 7}))
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+}
+
+func TestGetSplitsByTransactionIDs(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionSplitModel = NewTransactionSplitModel()
+	})
+	defer tearDown()
+
+	_, mockM := setupNewMock(t)
+	mockM.ExpectQuery("^SELECT s.split_id, s.transaction_id, s.category_id, s.amount, (.+) FROM transaction_splits s WHERE s.transaction_id IN \\(\\?,\\?\\) ORDER BY s.transaction_id, s.split_id").
+		WithArgs(int64(7), int64(8)).
+		WillReturnRows(sqlmock.NewRows([]string{"split_id", "transaction_id", "category_id", "amount", "description", "tags"}).
+			AddRow(1, 7, 2, "50.00", "Groceries", "").
+			AddRow(2, 7, 3, "30.00", "", "gift"+exportTagSeparator+"home"))
+
+	splits, err := ModelsService.TransactionSplitModel.GetSplitsByTransactionIDs(context.Background(), []int64{7, 8})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][]interfaces.TransactionSplit{7: {
+		{ID: 1, TransactionID: 7, CategoryID: 2, Amount: interfaces.MoneyFromFloat(50), Description: "Groceries", Tags: []string{}},
+		{ID: 2, TransactionID: 7, CategoryID: 3, Amount: interfaces.MoneyFromFloat(30), Tags: []string{"gift", "home"}},
+	}}, splits)
+	assert.NoError(t, mockM.ExpectationsWereMet())
+}
+
+func TestGetTransactionsByCategoryMatchesSplitLines(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionModel = NewTransactionModel()
+	})
+	defer tearDown()
+
+	_, mockM := setupNewMock(t)
+	mockTransactionTagModel := new(xmock.MockTransactionTagModel)
+	mockSplitModel := new(xmock.MockTransactionSplitModel)
+	ModelsService.TransactionTagModel = mockTransactionTagModel
+	ModelsService.TransactionSplitModel = mockSplitModel
+	mockTransactionTagModel.On("GetTagsByTransactionIDs", mock.Anything, []int64{5, 7}, mock.Anything).Return(map[int64][]interfaces.Tag{}, nil).Once()
+
+	now := time.Now()
+	mockM.ExpectQuery("^SELECT (.+) FROM transactions WHERE scope_id IN \\(\\?\\) "+
+		"AND \\(category_id = \\? OR transaction_id IN \\(SELECT transaction_id FROM transaction_splits WHERE category_id = \\?\\)\\)").
+		WithArgs(int64(1), "2", "2").
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "user_id", "source_id", "category_id", "timestamp", "amount", "type", "description", "scope_id", "destination_source_id", "transfer_id", "currency", "created_at"}).
+			AddRow(5, 1, 1, 2, now, "12.00", "EXPENSE", "Bread", 1, nil, nil, "USD", now).
+			AddRow(7, 1, 1, nil, now, "80.00", "EXPENSE", "Supermarket", 1, nil, nil, "USD", now))
+	lines := []interfaces.TransactionSplit{
+		{ID: 1, TransactionID: 7, CategoryID: 2, Amount: interfaces.MoneyFromFloat(50)},
+		{ID: 2, TransactionID: 7, CategoryID: 3, Amount: interfaces.MoneyFromFloat(30)},
+	}
+	// Only the split transaction has its lines fetched
+	mockSplitModel.On("GetSplitsByTransactionIDs", mock.Anything, []int64{7}, mock.Anything).
+		Return(map[int64][]interfaces.TransactionSplit{7: lines}, nil).Once()
+
+	transactions, err := ModelsService.TransactionModel.GetTransactionsByFilter(context.Background(), interfaces.TransactionFilter{Scopes: []int64{1}, Category: "2"})
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 2)
+	assert.Nil(t, transactions[0].Splits)
+	assert.Equal(t, lines, transactions[1].Splits)
+	assert.NoError(t, mockM.ExpectationsWereMet())
+	mockSplitModel.AssertExpectations(t)
+}
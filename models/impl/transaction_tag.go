@@ -63,6 +63,7 @@ func (tm *TransactionTagModel) GetTagsByTransactionID(ctx context.Context, trans
 		From("tags t").
 		Join(tm.TableTransactionTags + " tt ON t." + tagID + " = tt." + tm.ColumnTagID).
 		Where(squirrel.Eq{"tt." + tm.ColumnTransactionID: transactionID}).
+		Where("t.deleted_at IS NULL").
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 
@@ -88,6 +89,47 @@ func (tm *TransactionTagModel) GetTagsByTransactionID(ctx context.Context, trans
 	return tags, nil
 }
 
+// GetTagsByTransactionIDs returns the tags of the given transactions in one query, keyed by
+// transaction ID and sorted by name. Transactions without tags have no entry.
+func (tm *TransactionTagModel) GetTagsByTransactionIDs(ctx context.Context, transactionIDs []int64, otx ...*sql.Tx) (map[int64][]interfaces.Tag, error) {
+	tags := make(map[int64][]interfaces.Tag)
+	if len(transactionIDs) == 0 {
+		return tags, nil
+	}
+	_, executor := getExecutor(otx...)
+
+	tagModel := NewTagModel()
+	query, args, err := GetQueryBuilder().Select("tt."+tm.ColumnTransactionID, "t."+tagModel.ColumnID, "t."+tagModel.ColumnName).
+		From(tagModel.TableTags+" t").
+		Join(tm.TableTransactionTags+" tt ON t."+tagModel.ColumnID+" = tt."+tm.ColumnTagID).
+		Where(squirrel.Eq{"tt." + tm.ColumnTransactionID: transactionIDs}).
+		Where(squirrel.Eq{"t." + tagModel.ColumnDeletedAt: nil}).
+		OrderBy("tt."+tm.ColumnTransactionID, "t."+tagModel.ColumnName).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build SQL query for GetTagsByTransactionIDs")
+	}
+
+	rows, err := executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "error querying tags for transactions")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionID int64
+		var tag interfaces.Tag
+		if err := rows.Scan(&transactionID, &tag.ID, &tag.Name); err != nil {
+			return nil, errors.Wrap(err, "error scanning tag row")
+		}
+		tags[transactionID] = append(tags[transactionID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error iterating over tags for transactions")
+	}
+	return tags, nil
+}
+
 func (tm *TransactionTagModel) InsertTransactionTag(ctx context.Context, transactionID, tagID int64, otx ...*sql.Tx) error {
 	isExternalTx, executor := getExecutor(otx...)
 
@@ -9,6 +9,7 @@ import (
 	xmock "xspends/models/mock"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -47,6 +48,32 @@ func setupForeignKeyMocks(mockM sqlmock.Sqlmock, txn interfaces.Transaction) {
 		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1")) // indicating category exists
 }
 
+func newValidatingUserScopeMock() *xmock.MockUserScopeModel {
+	mockUserScopeModel := new(xmock.MockUserScopeModel)
+	mockUserScopeModel.On("ValidateUserScope", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true)
+	return mockUserScopeModel
+}
+
+var impactColumns = []string{"source_id", "type", "amount", "destination_source_id", "transfer_id"}
+
+func expectBalanceImpact(mockM sqlmock.Sqlmock, transactionID, sourceID int64, txnType string, amount float64) {
+	mockM.ExpectQuery("^SELECT source_id, type, amount, destination_source_id, transfer_id FROM transactions WHERE (.+) FOR UPDATE").
+		WithArgs(transactionID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(impactColumns).AddRow(sourceID, txnType, amount, nil, nil))
+}
+
+func expectBalanceUpdate(mockM sqlmock.Sqlmock, sourceID int64, delta interfaces.Money) {
+	mockM.ExpectExec("^UPDATE sources SET balance = balance \\+ CAST\\(\\? AS DECIMAL\\(12, 2\\)\\)").
+		WithArgs(delta, sqlmock.AnyArg(), sourceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectSourceCurrency(mockM sqlmock.Sqlmock, sourceID int64, currency string) {
+	mockM.ExpectQuery("^SELECT currency FROM sources WHERE").
+		WithArgs(sqlmock.AnyArg(), sourceID).
+		WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow(currency))
+}
+
 func TestInsertTransactionV2(t *testing.T) {
 	tearDown := setUp(t, func(config *ModelsConfig) {
 		// Replace the mocked CategoryModel with a real one just for this test
@@ -55,6 +82,7 @@ func TestInsertTransactionV2(t *testing.T) {
 		config.SourceModel = NewSourceModel()
 		config.CategoryModel = NewCategoryModel()
 		config.ScopeModel = NewScopeModel()
+		config.UserScopeModel = newValidatingUserScopeMock()
 	})
 	defer tearDown()
 
@@ -63,7 +91,7 @@ func TestInsertTransactionV2(t *testing.T) {
 		SourceID:    1,
 		ScopeID:     1,
 		CategoryID:  1,
-		Amount:      100.0,
+		Amount:      interfaces.MoneyFromFloat(100.0),
 		Type:        "expense",
 		Description: "Groceries",
 		Tags:        []string{"groceries", "food"},
@@ -77,10 +105,15 @@ func TestInsertTransactionV2(t *testing.T) {
 		mockTransactionTagModel := new(xmock.MockTransactionTagModel)
 		ModelsService.TransactionTagModel = mockTransactionTagModel
 		ModelsService.TagModel = mockTagModel
+		mockM.ExpectBegin()
 		setupForeignKeyMocks(mockM, txn)
+		expectSourceCurrency(mockM, txn.SourceID, "USD")
 		mockM.ExpectExec("INSERT INTO transactions").
-			WithArgs(sqlmock.AnyArg(), txn.UserID, txn.SourceID, txn.CategoryID, sqlmock.AnyArg(), txn.Amount, txn.Type, txn.Description, txn.ScopeID).
+			WithArgs(sqlmock.AnyArg(), txn.UserID, txn.SourceID, txn.CategoryID, sqlmock.AnyArg(), txn.Amount, txn.Type, txn.Description, txn.ScopeID, sql.NullInt64{}, "USD").
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		// The expense debits its source in the same transaction
+		expectBalanceUpdate(mockM, txn.SourceID, -txn.Amount)
+		mockM.ExpectCommit()
 
 		// Setup for tag handling
 		for _, tag := range txn.Tags {
@@ -111,8 +144,10 @@ func TestInsertTransactionV2(t *testing.T) {
 	t.Run("Foreign Key Validation Failure - User Not Found", func(t *testing.T) {
 		// Setup new mock database for clean expectation slate
 		_, mock1 := setupNewMock(t)
+		mock1.ExpectBegin()
 		// Expect the user existence check query
 		mock1.ExpectQuery("^SELECT (.+) FROM users WHERE").WithArgs(txn.UserID).WillReturnRows(sqlmock.NewRows([]string{"exists"}))
+		mock1.ExpectRollback()
 
 		err := ModelsService.TransactionModel.InsertTransaction(context.Background(), txn)
 
@@ -123,14 +158,44 @@ func TestInsertTransactionV2(t *testing.T) {
 		assert.NoError(t, mock1.ExpectationsWereMet())
 	})
 
+	t.Run("Client Timestamp", func(t *testing.T) {
+		mockTransactionTagModel := new(xmock.MockTransactionTagModel)
+		ModelsService.TransactionTagModel = mockTransactionTagModel
+		dated := txn
+		dated.Tags = nil
+		dated.Timestamp = time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+		_, mockM = setupNewMock(t)
+		mockM.ExpectBegin()
+		setupForeignKeyMocks(mockM, dated)
+		expectSourceCurrency(mockM, dated.SourceID, "USD")
+		mockM.ExpectExec("INSERT INTO transactions").
+			WithArgs(sqlmock.AnyArg(), dated.UserID, dated.SourceID, dated.CategoryID, dated.Timestamp, dated.Amount, dated.Type, dated.Description, dated.ScopeID, sql.NullInt64{}, "USD").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectBalanceUpdate(mockM, dated.SourceID, -dated.Amount)
+		mockM.ExpectCommit()
+		mockTransactionTagModel.On("AddTagsToTransaction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		err := ModelsService.TransactionModel.InsertTransaction(context.Background(), dated)
+		assert.NoError(t, err)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+
+		dated.Timestamp = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+		err = ModelsService.TransactionModel.InsertTransaction(context.Background(), dated)
+		assert.Equal(t, ErrInvalidTimestamp, errors.Cause(err))
+	})
+
 	// Subtest 3: Insert Transaction Execution Failure
 	t.Run("Insert Transaction Execution Failure", func(t *testing.T) {
 		_, mockM = setupNewMock(t)
+		mockM.ExpectBegin()
 		setupForeignKeyMocks(mockM, txn) // Assumes a function to set up foreign key validation
+		expectSourceCurrency(mockM, txn.SourceID, "USD")
 
 		mockM.ExpectExec("INSERT INTO transactions").
-			WithArgs(sqlmock.AnyArg(), txn.UserID, txn.SourceID, txn.CategoryID, sqlmock.AnyArg(), txn.Amount, txn.Type, txn.Description, txn.ScopeID).
+			WithArgs(sqlmock.AnyArg(), txn.UserID, txn.SourceID, txn.CategoryID, sqlmock.AnyArg(), txn.Amount, txn.Type, txn.Description, txn.ScopeID, sql.NullInt64{}, "USD").
 			WillReturnError(sql.ErrConnDone) // Simulate a connection error or similar
+		mockM.ExpectRollback()
 
 		err := ModelsService.TransactionModel.InsertTransaction(context.Background(), txn)
 		assert.Error(t, err)
@@ -144,12 +209,17 @@ func TestInsertTransactionV2(t *testing.T) {
 		mockTransactionTagModel := new(xmock.MockTransactionTagModel)
 		ModelsService.TransactionTagModel = mockTransactionTagModel
 		ModelsService.TagModel = mockTagModel
+		mockM.ExpectBegin()
 		setupForeignKeyMocks(mockM, txn) // Set up foreign key validations
+		expectSourceCurrency(mockM, txn.SourceID, "USD")
 
 		// Set up mocks for successful transaction insert
 		mockM.ExpectExec("INSERT INTO transactions").
-			WithArgs(sqlmock.AnyArg(), txn.UserID, txn.SourceID, txn.CategoryID, sqlmock.AnyArg(), txn.Amount, txn.Type, txn.Description, txn.ScopeID).
+			WithArgs(sqlmock.AnyArg(), txn.UserID, txn.SourceID, txn.CategoryID, sqlmock.AnyArg(), txn.Amount, txn.Type, txn.Description, txn.ScopeID, sql.NullInt64{}, "USD").
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectBalanceUpdate(mockM, txn.SourceID, -txn.Amount)
+		// The balance change is rolled back with the failed tag update
+		mockM.ExpectRollback()
 
 		// Setup for tag handling
 		for _, tag := range txn.Tags {
@@ -174,6 +244,57 @@ func TestInsertTransactionV2(t *testing.T) {
 		assert.NoError(t, mockM.ExpectationsWereMet())
 		mockTransactionTagModel.AssertExpectations(t)
 	})
+
+	t.Run("Rules fill in the category and tags", func(t *testing.T) {
+		_, mockM = setupNewMock(t)
+		mockRuleModel := new(xmock.MockRuleModel)
+		mockTransactionTagModel := new(xmock.MockTransactionTagModel)
+		ModelsService.RuleModel = mockRuleModel
+		ModelsService.TransactionTagModel = mockTransactionTagModel
+		defer func() { ModelsService.RuleModel = newNoRulesMock() }()
+
+		uncategorized := txn
+		uncategorized.CategoryID, uncategorized.Tags = 0, nil
+		categorized := txn
+		categorized.Tags = []string{"groceries"}
+		mockRuleModel.On("ApplyRules", mock.Anything, mock.AnythingOfType("*interfaces.Transaction"), mock.Anything).Run(func(args mock.Arguments) {
+			applied := args.Get(1).(*interfaces.Transaction)
+			applied.CategoryID, applied.Tags = categorized.CategoryID, categorized.Tags
+		}).Return(nil).Once()
+
+		mockM.ExpectBegin()
+		setupForeignKeyMocks(mockM, categorized)
+		expectSourceCurrency(mockM, txn.SourceID, "USD")
+		mockM.ExpectExec("INSERT INTO transactions").
+			WithArgs(sqlmock.AnyArg(), txn.UserID, txn.SourceID, sql.NullInt64{Int64: categorized.CategoryID, Valid: true}, sqlmock.AnyArg(), txn.Amount, txn.Type, txn.Description, txn.ScopeID, sql.NullInt64{}, "USD").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectBalanceUpdate(mockM, txn.SourceID, -txn.Amount)
+		mockM.ExpectCommit()
+		ModelsService.TagModel.(*xmock.MockTagModel).On("GetTagByName", mock.Anything, "groceries", []int64{txn.ScopeID}, mock.Anything).
+			Return(&interfaces.Tag{ID: 1, Name: "groceries"}, nil).Once()
+		mockTransactionTagModel.On("AddTagsToTransaction", mock.Anything, mock.Anything, []string{"groceries"}, []int64{txn.ScopeID}, mock.Anything).Return(nil).Once()
+
+		assert.NoError(t, ModelsService.TransactionModel.InsertTransaction(context.Background(), uncategorized))
+		assert.NoError(t, mockM.ExpectationsWereMet())
+		mockRuleModel.AssertExpectations(t)
+		mockTransactionTagModel.AssertExpectations(t)
+	})
+
+	t.Run("Rules already applied", func(t *testing.T) {
+		_, mockM = setupNewMock(t)
+		mockRuleModel := new(xmock.MockRuleModel)
+		ModelsService.RuleModel = mockRuleModel
+		defer func() { ModelsService.RuleModel = newNoRulesMock() }()
+
+		applied := txn
+		applied.RulesApplied = true
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT (.+) FROM users WHERE").WillReturnError(sql.ErrConnDone)
+		mockM.ExpectRollback()
+
+		assert.Error(t, ModelsService.TransactionModel.InsertTransaction(context.Background(), applied))
+		mockRuleModel.AssertNotCalled(t, "ApplyRules", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
 
 func TestUpdateTransactionV2(t *testing.T) {
@@ -184,6 +305,7 @@ func TestUpdateTransactionV2(t *testing.T) {
 		config.SourceModel = NewSourceModel()
 		config.CategoryModel = NewCategoryModel()
 		config.ScopeModel = NewScopeModel()
+		config.UserScopeModel = newValidatingUserScopeMock()
 	})
 	defer tearDown()
 
@@ -193,7 +315,7 @@ func TestUpdateTransactionV2(t *testing.T) {
 		SourceID:    1,
 		ScopeID:     1,
 		CategoryID:  1,
-		Amount:      150.0,
+		Amount:      interfaces.MoneyFromFloat(150.0),
 		Type:        "income",
 		Description: "Updated Groceries",
 		Tags:        []string{"updatedTag1", "updatedTag2"},
@@ -207,12 +329,19 @@ func TestUpdateTransactionV2(t *testing.T) {
 		mockTransactionTagModel := new(xmock.MockTransactionTagModel)
 		ModelsService.TransactionTagModel = mockTransactionTagModel
 		ModelsService.TagModel = mockTagModel
+		mockM.ExpectBegin()
 		setupForeignKeyMocks(mockM, txn)
+		expectSourceCurrency(mockM, txn.SourceID, "USD")
+		// The stored version was a 50.0 expense on the same source
+		expectBalanceImpact(mockM, txn.ID, txn.SourceID, "EXPENSE", 50.0)
 
 		// Set up mock for successful update
 		mockM.ExpectExec("UPDATE transactions").
-			WithArgs(txn.SourceID, txn.CategoryID, txn.Amount, txn.Type, txn.Description, txn.ID, txn.ScopeID).
+			WithArgs(txn.SourceID, txn.CategoryID, txn.Amount, "USD", txn.Type, txn.Description, txn.ID, txn.ScopeID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		// Reverse the old expense and apply the new income in one adjustment
+		expectBalanceUpdate(mockM, txn.SourceID, txn.Amount+interfaces.MoneyFromFloat(50))
+		mockM.ExpectCommit()
 
 		// Set up mocks for tag handling
 		for _, tag := range txn.Tags {
@@ -231,6 +360,12 @@ func TestUpdateTransactionV2(t *testing.T) {
 			"UpdateTagsForTransaction",
 			mock.Anything, txn.ID, txn.Tags, []int64{txn.ScopeID}, mock.Anything,
 		).Return(nil).Once()
+		// An update without splits leaves the transaction unsplit
+		mockSplitModel := new(xmock.MockTransactionSplitModel)
+		ModelsService.TransactionSplitModel = mockSplitModel
+		mockSplitModel.On("ReplaceSplits", mock.Anything, mock.MatchedBy(func(updated interfaces.Transaction) bool {
+			return updated.ID == txn.ID && len(updated.Splits) == 0
+		}), mock.Anything).Return(nil).Once()
 
 		// Call the method under test
 		err := ModelsService.TransactionModel.UpdateTransaction(context.Background(), txn)
@@ -238,15 +373,18 @@ func TestUpdateTransactionV2(t *testing.T) {
 		assert.NoError(t, mockM.ExpectationsWereMet())
 		mockTagModel.AssertExpectations(t)
 		mockTransactionTagModel.AssertExpectations(t)
+		mockSplitModel.AssertExpectations(t)
 	})
 
 	t.Run("Foreign Key Validation Failure - User Not Found", func(t *testing.T) {
 		_, mockM = setupNewMock(t)
 
 		// Set up user not found scenario
+		mockM.ExpectBegin()
 		mockM.ExpectQuery("^SELECT (.+) FROM users WHERE").
 			WithArgs(txn.UserID).
 			WillReturnRows(sqlmock.NewRows(nil)) // No rows returned to simulate user not found
+		mockM.ExpectRollback()
 
 		// Call the update method and expect an error
 		err := ModelsService.TransactionModel.UpdateTransaction(context.Background(), txn)
@@ -256,12 +394,16 @@ func TestUpdateTransactionV2(t *testing.T) {
 
 	t.Run("Update Transaction Execution Failure", func(t *testing.T) {
 		_, mockM = setupNewMock(t)
+		mockM.ExpectBegin()
 		setupForeignKeyMocks(mockM, txn) // Assumes a function to set up foreign key validation
+		expectSourceCurrency(mockM, txn.SourceID, "USD")
+		expectBalanceImpact(mockM, txn.ID, txn.SourceID, "EXPENSE", 50.0)
 
 		// Simulate a failure during the execution of the update query
 		mockM.ExpectExec("UPDATE transactions").
-			WithArgs(txn.SourceID, txn.CategoryID, txn.Amount, txn.Type, txn.Description, txn.ID, txn.ScopeID).
+			WithArgs(txn.SourceID, txn.CategoryID, txn.Amount, "USD", txn.Type, txn.Description, txn.ID, txn.ScopeID).
 			WillReturnError(sql.ErrConnDone)
+		mockM.ExpectRollback()
 
 		// Call the update method and expect an error
 		err := ModelsService.TransactionModel.UpdateTransaction(context.Background(), txn)
@@ -275,12 +417,19 @@ func TestUpdateTransactionV2(t *testing.T) {
 		mockTransactionTagModel := new(xmock.MockTransactionTagModel)
 		ModelsService.TransactionTagModel = mockTransactionTagModel
 		ModelsService.TagModel = mockTagModel
+		mockM.ExpectBegin()
 		setupForeignKeyMocks(mockM, txn) // Set up foreign key validations
+		expectSourceCurrency(mockM, txn.SourceID, "USD")
+		// Moving the transaction from another source reverses it there
+		expectBalanceImpact(mockM, txn.ID, 2, "EXPENSE", 50.0)
 
 		// Set up mocks for successful transaction update
 		mockM.ExpectExec("UPDATE transactions").
-			WithArgs(txn.SourceID, txn.CategoryID, txn.Amount, txn.Type, txn.Description, txn.ID, txn.ScopeID).
+			WithArgs(txn.SourceID, txn.CategoryID, txn.Amount, "USD", txn.Type, txn.Description, txn.ID, txn.ScopeID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectBalanceUpdate(mockM, 2, interfaces.MoneyFromFloat(50))
+		expectBalanceUpdate(mockM, txn.SourceID, txn.Amount)
+		mockM.ExpectRollback()
 
 		// Setup for tag handling
 		for _, tag := range txn.Tags {
@@ -310,10 +459,11 @@ func TestUpdateTransactionV2(t *testing.T) {
 }
 
 func TestDeleteTransactionV2(t *testing.T) {
+	mockSourceModel := new(xmock.MockSourceModel)
 	tearDown := setUp(t, func(config *ModelsConfig) {
 		// Replace the mocked CategoryModel with a real one just for this test
 		config.TransactionModel = NewTransactionModel()
-
+		config.SourceModel = mockSourceModel
 	})
 	defer tearDown()
 
@@ -323,24 +473,37 @@ func TestDeleteTransactionV2(t *testing.T) {
 	defer db.Close()
 
 	t.Run("Successful Deletion", func(t *testing.T) {
-		// Set up mock for successful deletion
-		mockM.ExpectExec("DELETE FROM transactions").
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT source_id, type, amount, destination_source_id, transfer_id FROM transactions WHERE (.+) FOR UPDATE").
 			WithArgs(transactionID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(impactColumns).AddRow(7, "EXPENSE", 40.0, nil, nil))
+		// Set up mock for successful deletion
+		mockM.ExpectExec("^UPDATE transactions SET deleted_at = \\? WHERE").
+			WithArgs(sqlmock.AnyArg(), transactionID, sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockM.ExpectCommit()
+		// Deleting an expense gives the amount back to the source
+		mockSourceModel.On("AdjustBalance", mock.Anything, int64(7), interfaces.MoneyFromFloat(40), mock.Anything).Return(nil).Once()
 
 		// Call the method under test
 		err := ModelsService.TransactionModel.DeleteTransaction(context.Background(), transactionID, scopes)
 		assert.NoError(t, err)
 		assert.NoError(t, mockM.ExpectationsWereMet())
+		mockSourceModel.AssertExpectations(t)
 	})
 
 	t.Run("Delete Transaction Execution Failure", func(t *testing.T) {
 		_, mockM = setupNewMock(t)
 
-		// Simulate a failure during the execution of the delete query
-		mockM.ExpectExec("DELETE FROM transactions").
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT source_id, type, amount, destination_source_id, transfer_id FROM transactions WHERE").
 			WithArgs(transactionID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(impactColumns).AddRow(7, "INCOME", 40.0, nil, nil))
+		// Simulate a failure during the execution of the delete query
+		mockM.ExpectExec("^UPDATE transactions SET deleted_at = \\? WHERE").
+			WithArgs(sqlmock.AnyArg(), transactionID, sqlmock.AnyArg()).
 			WillReturnError(sql.ErrConnDone)
+		mockM.ExpectRollback()
 
 		// Call the delete method and expect an error
 		err := ModelsService.TransactionModel.DeleteTransaction(context.Background(), transactionID, scopes)
@@ -352,20 +515,275 @@ func TestDeleteTransactionV2(t *testing.T) {
 		_, mockM = setupNewMock(t)
 
 		// Set up mock for a deletion attempt on a non-existent transaction
-		mockM.ExpectExec("DELETE FROM transactions").
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT source_id, type, amount, destination_source_id, transfer_id FROM transactions WHERE").
 			WithArgs(transactionID, sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(0, 0)) // Indicate no rows were affected
+			WillReturnRows(sqlmock.NewRows(impactColumns))
+		mockM.ExpectCommit()
 
 		// Call the delete method and check if error or some indication of non-existence is handled
 		err := ModelsService.TransactionModel.DeleteTransaction(context.Background(), transactionID, scopes)
-		// Assert based on how your application should behave (error or just a no-op)
 		// Here it is assumed the method won't error out if no transaction was found
 		assert.NoError(t, err)
 		assert.NoError(t, mockM.ExpectationsWereMet())
 	})
+}
+
+func TestBalanceDelta(t *testing.T) {
+	amount := interfaces.MoneyFromFloat(25)
+	assert.Equal(t, amount, balanceDelta(TransactionTypeIncome, amount))
+	assert.Equal(t, -amount, balanceDelta(TransactionTypeExpense, amount))
+	assert.Equal(t, -amount, balanceDelta("expense", amount))
+	assert.Equal(t, interfaces.Money(0), balanceDelta("UNKNOWN", amount))
+}
+
+func TestTransactionDelta(t *testing.T) {
+	assert.Equal(t, -interfaces.MoneyFromFloat(25), transactionDelta(interfaces.Transaction{Type: TransactionTypeExpense, Amount: interfaces.MoneyFromFloat(25.0)}))
+	// The outgoing leg is recorded on the source the money leaves
+	assert.Equal(t, -interfaces.MoneyFromFloat(25), transactionDelta(interfaces.Transaction{Type: TransactionTypeTransfer, Amount: interfaces.MoneyFromFloat(25.0), SourceID: 1, DestinationSourceID: 2}))
+	// The incoming leg is recorded on the destination itself
+	assert.Equal(t, interfaces.MoneyFromFloat(25), transactionDelta(interfaces.Transaction{Type: "transfer", Amount: interfaces.MoneyFromFloat(25.0), SourceID: 2, DestinationSourceID: 2}))
+}
+
+func TestInsertTransfer(t *testing.T) {
+	mockTransactionTagModel := new(xmock.MockTransactionTagModel)
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionModel = NewTransactionModel()
+		config.UserModel = NewUserModel()
+		config.SourceModel = NewSourceModel()
+		config.CategoryModel = NewCategoryModel()
+		config.ScopeModel = NewScopeModel()
+		config.UserScopeModel = newValidatingUserScopeMock()
+		config.TransactionTagModel = mockTransactionTagModel
+	})
+	defer tearDown()
+
+	transfer := interfaces.Transaction{
+		UserID:              1,
+		ScopeID:             1,
+		SourceID:            1,
+		DestinationSourceID: 2,
+		Amount:              interfaces.MoneyFromFloat(75.0),
+		Type:                TransactionTypeTransfer,
+		Description:         "Move to savings",
+	}
+
+	t.Run("Successful Transfer", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT (.+) FROM users WHERE").WithArgs(transfer.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WithArgs(transfer.SourceID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WithArgs(sqlmock.AnyArg(), transfer.DestinationSourceID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		// No category check: transfers may be uncategorized
+		mockM.ExpectQuery("^SELECT (.+) FROM scopes WHERE").WithArgs(transfer.ScopeID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		expectSourceCurrency(mockM, transfer.SourceID, "USD")
+		expectSourceCurrency(mockM, transfer.DestinationSourceID, "USD")
+
+		mockM.ExpectExec("INSERT INTO transactions").
+			WithArgs(sqlmock.AnyArg(), transfer.UserID, transfer.SourceID, sql.NullInt64{}, sqlmock.AnyArg(), transfer.Amount, TransactionTypeTransfer, transfer.Description, transfer.ScopeID, transfer.DestinationSourceID, sqlmock.AnyArg(), "USD").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectBalanceUpdate(mockM, transfer.SourceID, -transfer.Amount)
+		mockM.ExpectExec("INSERT INTO transactions").
+			WithArgs(sqlmock.AnyArg(), transfer.UserID, transfer.DestinationSourceID, sql.NullInt64{}, sqlmock.AnyArg(), transfer.Amount, TransactionTypeTransfer, transfer.Description, transfer.ScopeID, transfer.DestinationSourceID, sqlmock.AnyArg(), "USD").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectBalanceUpdate(mockM, transfer.DestinationSourceID, transfer.Amount)
+		mockM.ExpectCommit()
+
+		mockTransactionTagModel.On("AddTagsToTransaction", mock.Anything, mock.Anything, mock.Anything, []int64{transfer.ScopeID}, mock.Anything).Return(nil).Twice()
+
+		legs, err := ModelsService.TransactionModel.InsertTransfer(context.Background(), transfer)
+		assert.NoError(t, err)
+		assert.Len(t, legs, 2)
+		assert.Equal(t, legs[0].TransferID, legs[1].TransferID)
+		assert.Equal(t, transfer.SourceID, legs[0].SourceID)
+		assert.Equal(t, transfer.DestinationSourceID, legs[1].SourceID)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+		mockTransactionTagModel.AssertExpectations(t)
+	})
+
+	t.Run("Between Currencies", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockCurrencyModel := new(xmock.MockCurrencyModel)
+		ModelsService.CurrencyModel = mockCurrencyModel
+		mockCurrencyModel.On("GetExchangeRate", mock.Anything, transfer.ScopeID, "USD", "EUR", mock.Anything, mock.Anything).Return(0.9, nil).Once()
+
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT (.+) FROM users WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM scopes WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		expectSourceCurrency(mockM, transfer.SourceID, "USD")
+		expectSourceCurrency(mockM, transfer.DestinationSourceID, "EUR")
+
+		mockM.ExpectExec("INSERT INTO transactions").
+			WithArgs(sqlmock.AnyArg(), transfer.UserID, transfer.SourceID, sql.NullInt64{}, sqlmock.AnyArg(), interfaces.MoneyFromFloat(75), TransactionTypeTransfer, transfer.Description, transfer.ScopeID, transfer.DestinationSourceID, sqlmock.AnyArg(), "USD").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectBalanceUpdate(mockM, transfer.SourceID, interfaces.MoneyFromFloat(-75))
+		// The incoming leg is converted into the destination's currency
+		mockM.ExpectExec("INSERT INTO transactions").
+			WithArgs(sqlmock.AnyArg(), transfer.UserID, transfer.DestinationSourceID, sql.NullInt64{}, sqlmock.AnyArg(), interfaces.MoneyFromFloat(67.5), TransactionTypeTransfer, transfer.Description, transfer.ScopeID, transfer.DestinationSourceID, sqlmock.AnyArg(), "EUR").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectBalanceUpdate(mockM, transfer.DestinationSourceID, interfaces.MoneyFromFloat(67.5))
+		mockM.ExpectCommit()
+
+		mockTransactionTagModel.On("AddTagsToTransaction", mock.Anything, mock.Anything, mock.Anything, []int64{transfer.ScopeID}, mock.Anything).Return(nil).Twice()
+
+		legs, err := ModelsService.TransactionModel.InsertTransfer(context.Background(), transfer)
+		assert.NoError(t, err)
+		assert.Equal(t, "EUR", legs[1].Currency)
+		assert.Equal(t, interfaces.MoneyFromFloat(67.5), legs[1].Amount)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+		mockCurrencyModel.AssertExpectations(t)
+	})
+
+	t.Run("Currency Other Than The Source's", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+
+		mismatched := transfer
+		mismatched.Currency = "INR"
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT (.+) FROM users WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM scopes WHERE").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		expectSourceCurrency(mockM, transfer.SourceID, "USD")
+		mockM.ExpectRollback()
+
+		legs, err := ModelsService.TransactionModel.InsertTransfer(context.Background(), mismatched)
+		assert.ErrorIs(t, err, ErrCurrencyMismatch)
+		assert.Nil(t, legs)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Same Source On Both Sides", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+
+		invalid := transfer
+		invalid.DestinationSourceID = invalid.SourceID
+		legs, err := ModelsService.TransactionModel.InsertTransfer(context.Background(), invalid)
+		assert.Equal(t, ErrInvalidTransfer, err)
+		assert.Nil(t, legs)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Destination Not Found", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT (.+) FROM users WHERE").WithArgs(transfer.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WithArgs(transfer.SourceID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow("1"))
+		mockM.ExpectQuery("^SELECT (.+) FROM sources WHERE").WithArgs(sqlmock.AnyArg(), transfer.DestinationSourceID).
+			WillReturnRows(sqlmock.NewRows([]string{"1"}))
+		mockM.ExpectRollback()
+
+		legs, err := ModelsService.TransactionModel.InsertTransfer(context.Background(), transfer)
+		assert.Error(t, err)
+		assert.Nil(t, legs)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteTransfer(t *testing.T) {
+	mockSourceModel := new(xmock.MockSourceModel)
+	mockTransactionTagModel := new(xmock.MockTransactionTagModel)
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionModel = NewTransactionModel()
+		config.SourceModel = mockSourceModel
+		config.TransactionTagModel = mockTransactionTagModel
+	})
+	defer tearDown()
+
+	transactionID, transferID := int64(11), int64(9001)
+	scopes := []int64{1}
+	_, mockM := setupNewMock(t)
+
+	mockM.ExpectBegin()
+	mockM.ExpectQuery("^SELECT source_id, type, amount, destination_source_id, transfer_id FROM transactions WHERE (.+) FOR UPDATE").
+		WithArgs(sqlmock.AnyArg(), transactionID).
+		WillReturnRows(sqlmock.NewRows(impactColumns).AddRow(1, TransactionTypeTransfer, 75.0, 2, transferID))
+	mockM.ExpectQuery("^SELECT transaction_id, source_id, type, amount, destination_source_id FROM transactions WHERE (.+) FOR UPDATE").
+		WithArgs(sqlmock.AnyArg(), transferID).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "source_id", "type", "amount", "destination_source_id"}).
+			AddRow(11, 1, TransactionTypeTransfer, 75.0, 2).
+			AddRow(12, 2, TransactionTypeTransfer, 75.0, 2))
+	mockM.ExpectExec("^UPDATE transactions SET deleted_at = \\? WHERE (.+) AND deleted_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), transferID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mockM.ExpectCommit()
+
+	// Both balances go back to where they were before the transfer
+	mockSourceModel.On("AdjustBalance", mock.Anything, int64(1), interfaces.MoneyFromFloat(75), mock.Anything).Return(nil).Once()
+	mockSourceModel.On("AdjustBalance", mock.Anything, int64(2), interfaces.MoneyFromFloat(-75), mock.Anything).Return(nil).Once()
+
+	err := ModelsService.TransactionModel.DeleteTransaction(context.Background(), transactionID, scopes)
+	assert.NoError(t, err)
+	assert.NoError(t, mockM.ExpectationsWereMet())
+	mockTransactionTagModel.AssertExpectations(t)
+	mockSourceModel.AssertExpectations(t)
+}
+
+func TestRestoreTransaction(t *testing.T) {
+	mockSourceModel := new(xmock.MockSourceModel)
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TransactionModel = NewTransactionModel()
+		config.SourceModel = mockSourceModel
+	})
+	defer tearDown()
+
+	transactionID := int64(1)
+	scopes := []int64{1}
+
+	t.Run("Restores the transaction and its balance effect", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT source_id, type, amount, destination_source_id, transfer_id FROM transactions WHERE (.+) AND deleted_at IS NOT NULL FOR UPDATE").
+			WithArgs(scopes[0], transactionID).
+			WillReturnRows(sqlmock.NewRows(impactColumns).AddRow(7, "EXPENSE", 40.0, nil, nil))
+		mockM.ExpectExec("^UPDATE transactions SET deleted_at = \\? WHERE (.+) AND deleted_at IS NOT NULL").
+			WithArgs(nil, scopes[0], transactionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockM.ExpectCommit()
+		mockSourceModel.On("SourceIDExists", mock.Anything, int64(7), scopes, mock.Anything).Return(true, nil).Once()
+		// Restoring an expense takes the amount off the source again
+		mockSourceModel.On("AdjustBalance", mock.Anything, int64(7), interfaces.MoneyFromFloat(-40), mock.Anything).Return(nil).Once()
+
+		err := ModelsService.TransactionModel.RestoreTransaction(context.Background(), transactionID, scopes)
+		assert.NoError(t, err)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+		mockSourceModel.AssertExpectations(t)
+	})
+
+	t.Run("Source in the trash", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT source_id, type, amount, destination_source_id, transfer_id FROM transactions WHERE").
+			WillReturnRows(sqlmock.NewRows(impactColumns).AddRow(7, "INCOME", 40.0, nil, nil))
+		mockM.ExpectRollback()
+		mockSourceModel.On("SourceIDExists", mock.Anything, int64(7), scopes, mock.Anything).Return(false, nil).Once()
+
+		err := ModelsService.TransactionModel.RestoreTransaction(context.Background(), transactionID, scopes)
+		assert.ErrorIs(t, err, ErrTrashConflict)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Not in the trash", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectBegin()
+		mockM.ExpectQuery("^SELECT source_id, type, amount, destination_source_id, transfer_id FROM transactions WHERE").
+			WillReturnError(sql.ErrNoRows)
+		mockM.ExpectRollback()
 
-	// Add more subtests for different edge cases if necessary
-	// Example: trying to delete with wrong user ID, etc.
+		err := ModelsService.TransactionModel.RestoreTransaction(context.Background(), transactionID, scopes)
+		assert.Equal(t, ErrTrashItemNotFound, err)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
 }
 
 func TestGetTransactionByIDV2(t *testing.T) {
@@ -392,26 +810,29 @@ func TestGetTransactionByIDV2(t *testing.T) {
 		SourceID:    1,
 		CategoryID:  1,
 		Timestamp:   time.Now(),
-		Amount:      100.0,
+		Amount:      interfaces.MoneyFromFloat(100.0),
 		Type:        "expense",
 		Description: "Mock Transaction",
+		Currency:    "USD",
+		CreatedAt:   time.Now(),
 	}
 
 	db, mockM := setupNewMock(t)
 	defer db.Close()
 
 	t.Run("Successful Retrieval", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"transaction_id", "user_id", "source_id", "category_id", "timestamp", "amount", "type", "description", "scope_id"}).
-			AddRow(mockTransaction.ID, mockTransaction.UserID, mockTransaction.SourceID, mockTransaction.CategoryID, mockTransaction.Timestamp, mockTransaction.Amount, mockTransaction.Type, mockTransaction.Description, mockTransaction.ScopeID)
+		rows := sqlmock.NewRows([]string{"transaction_id", "user_id", "source_id", "category_id", "timestamp", "amount", "type", "description", "scope_id", "destination_source_id", "transfer_id", "currency", "created_at"}).
+			AddRow(mockTransaction.ID, mockTransaction.UserID, mockTransaction.SourceID, mockTransaction.CategoryID, mockTransaction.Timestamp, mockTransaction.Amount, mockTransaction.Type, mockTransaction.Description, mockTransaction.ScopeID, nil, nil, mockTransaction.Currency, mockTransaction.CreatedAt)
 
 		mockM.ExpectQuery("SELECT (.+) FROM transactions WHERE").WithArgs(transactionID, sqlmock.AnyArg()).WillReturnRows(rows)
-
-		// Assume getTagsForTransaction is properly implemented or mocked
-		// Mock the call to getTagsForTransaction if it makes an external call
+		mockM.ExpectQuery("SELECT (.+) FROM tags t JOIN transaction_tags tt").WithArgs(transactionID).
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "tag_id", "name"}).AddRow(transactionID, 1, "Tag1"))
 
 		transaction, err := ModelsService.TransactionModel.GetTransactionByID(context.Background(), transactionID, scopes)
 		assert.NoError(t, err)
-		assert.Equal(t, mockTransaction, *transaction)
+		expected := mockTransaction
+		expected.Tags = []string{"Tag1"}
+		assert.Equal(t, expected, *transaction)
 		assert.NoError(t, mockM.ExpectationsWereMet())
 	})
 
@@ -433,7 +854,17 @@ func TestGetTransactionByIDV2(t *testing.T) {
 		assert.NoError(t, mockM.ExpectationsWereMet())
 	})
 
-	// Add more subtests if needed, for example to cover the logic within getTagsForTransaction, etc.
+	t.Run("Tag Query Error", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"transaction_id", "user_id", "source_id", "category_id", "timestamp", "amount", "type", "description", "scope_id", "destination_source_id", "transfer_id", "currency", "created_at"}).
+			AddRow(mockTransaction.ID, mockTransaction.UserID, mockTransaction.SourceID, mockTransaction.CategoryID, mockTransaction.Timestamp, mockTransaction.Amount, mockTransaction.Type, mockTransaction.Description, mockTransaction.ScopeID, nil, nil, mockTransaction.Currency, mockTransaction.CreatedAt)
+		mockM.ExpectQuery("SELECT (.+) FROM transactions WHERE").WithArgs(transactionID, sqlmock.AnyArg()).WillReturnRows(rows)
+		mockM.ExpectQuery("SELECT (.+) FROM tags t JOIN transaction_tags tt").WithArgs(transactionID).WillReturnError(sql.ErrConnDone)
+
+		transaction, err := ModelsService.TransactionModel.GetTransactionByID(context.Background(), transactionID, scopes)
+		assert.Error(t, err)
+		assert.Nil(t, transaction)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
 }
 
 func TestGetTransactionsByFilterV2(t *testing.T) {
@@ -459,9 +890,9 @@ func TestGetTransactionsByFilterV2(t *testing.T) {
 			// Define one or more mock transactions as per filter criteria
 		}
 
-		rows := sqlmock.NewRows([]string{"transaction_id", "user_id", "source_id", "category_id", "timestamp", "amount", "type", "description", "scope_id"})
+		rows := sqlmock.NewRows([]string{"transaction_id", "user_id", "source_id", "category_id", "timestamp", "amount", "type", "description", "scope_id", "destination_source_id", "transfer_id", "currency", "created_at"})
 		for _, txn := range mockTransactions {
-			rows = rows.AddRow(txn.ID, txn.UserID, txn.SourceID, txn.CategoryID, txn.Timestamp, txn.Amount, txn.Type, txn.Description, txn.ScopeID)
+			rows = rows.AddRow(txn.ID, txn.UserID, txn.SourceID, txn.CategoryID, txn.Timestamp, txn.Amount, txn.Type, txn.Description, txn.ScopeID, nil, nil, txn.Currency, txn.CreatedAt)
 		}
 
 		mockM.ExpectQuery("SELECT (.+) FROM transactions").WillReturnRows(rows)
@@ -484,8 +915,8 @@ func TestGetTransactionsByFilterV2(t *testing.T) {
 	})
 
 	t.Run("Row Scan Error", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"transaction_id", "user_id", "source_id", "category_id", "timestamp", "amount", "type", "description", "scope_id"}).
-			AddRow(1, userID, 1, 1, time.Now(), 100.0, "expense", "Description", scopes[0])
+		rows := sqlmock.NewRows([]string{"transaction_id", "user_id", "source_id", "category_id", "timestamp", "amount", "type", "description", "scope_id", "destination_source_id", "transfer_id", "currency", "created_at"}).
+			AddRow(1, userID, 1, 1, time.Now(), 100.0, "expense", "Description", scopes[0], nil, nil, "USD", time.Now())
 		mockM.ExpectQuery("SELECT (.+) FROM transactions").WillReturnRows(rows)
 
 		_ = rows.RowError(0, sql.ErrConnDone) // Simulate row scan error on the first row
@@ -495,83 +926,63 @@ func TestGetTransactionsByFilterV2(t *testing.T) {
 		assert.NoError(t, mockM.ExpectationsWereMet())
 	})
 
-	// Add more subtests if needed, for example to cover different filter criteria and edge cases
+	t.Run("Dates in the user's time zone", func(t *testing.T) {
+		paris, _ := time.LoadLocation("Europe/Paris")
+		dated := filter
+		dated.StartDate = "2024-03-01"
+		dated.EndDate = "2024-03-31"
+		dated.Location = paris
+
+		// A plain end date runs to the start of the next day
+		mockM.ExpectQuery("SELECT (.+) FROM transactions WHERE (.+) AND timestamp >= \\? AND timestamp < \\?").
+			WithArgs(time.Date(2024, 3, 1, 0, 0, 0, 0, paris), time.Date(2024, 4, 1, 0, 0, 0, 0, paris)).
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_id"}))
+
+		_, err := ModelsService.TransactionModel.GetTransactionsByFilter(context.Background(), dated)
+		assert.NoError(t, err)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
 }
 
-func TestGetTagsForTransaction(t *testing.T) {
+func TestGetTagsForTransactions(t *testing.T) {
 	tearDown := setUp(t, func(config *ModelsConfig) {
-		// Replace the mocked CategoryModel with a real one just for this test
 		config.TransactionModel = NewTransactionModel()
-		config.UserModel = NewUserModel()
-		config.SourceModel = NewSourceModel()
-		config.CategoryModel = NewCategoryModel()
 		config.TransactionTagModel = NewTransactionTagModel()
-	}) // Set up and initialization
-	defer tearDown() // Clean up after the test
-
-	// Assumed transaction details
-	transactionID := int64(1)
-	userID := int64(1)
-	scopes := []int64{1}
-	// Mock transaction to use in test
-	transaction := &interfaces.Transaction{
-		ID:      transactionID,
-		UserID:  userID,
-		ScopeID: scopes[0],
-		// Other necessary fields...
-	}
+	})
+	defer tearDown()
 
-	// Set up the mock database connection and mock expectations
-	db, mockM := setupNewMock(t)
-	defer db.Close()
+	expectedSQLPattern := `^SELECT tt\.transaction_id, t\.tag_id, t\.name FROM tags t JOIN transaction_tags tt ON t\.tag_id = tt\.tag_id ` +
+		`WHERE tt\.transaction_id IN \(\?,\?,\?\) AND t\.deleted_at IS NULL ORDER BY tt\.transaction_id, t\.name`
 
 	t.Run("Successful Tag Retrieval", func(t *testing.T) {
-		// Mock data for tags associated with the transaction
-		mockTags := []interfaces.Tag{
-			{ID: 1, Name: "Tag1"},
-			{ID: 2, Name: "Tag2"},
-			// Add more mock tags as necessary
-		}
-
-		// Set up SQL mock rows to simulate database response
-		rows := sqlmock.NewRows([]string{"transaction_id", "name"})
-		for _, tag := range mockTags {
-			rows.AddRow(tag.ID, tag.Name)
-		}
-
-		// Set up the mock expectation for SQL query
-		mockM.ExpectQuery("SELECT tag_id, name FROM tags t JOIN transaction_tags tt ON t.tag_id = tt.tag_id WHERE tt.transaction_id = ?").
-			WithArgs(transactionID).
-			WillReturnRows(rows)
-
-		// Call the function under test
-		err := getTagsForTransaction(context.Background(), transaction)
+		db, mockM := setupNewMock(t)
+		defer db.Close()
+		transactions := []interfaces.Transaction{{ID: 1}, {ID: 2}, {ID: 3}}
+
+		// One query serves the whole page
+		mockM.ExpectQuery(expectedSQLPattern).
+			WithArgs(int64(1), int64(2), int64(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "tag_id", "name"}).
+				AddRow(1, 1, "Tag1").
+				AddRow(1, 2, "Tag2").
+				AddRow(3, 2, "Tag2"))
+
+		err := getTagsForTransactions(context.Background(), transactions)
 		assert.NoError(t, err, "Fetching tags should not produce an error")
-
-		// Check if the tags are correctly assigned to the transaction
-		assert.Equal(t, len(mockTags), len(transaction.Tags), "The number of tags should match")
-		for i, tag := range mockTags {
-			assert.Equal(t, tag.Name, transaction.Tags[i], "Tag names should match")
-		}
-
-		// Ensure all expectations were met
+		assert.Equal(t, []string{"Tag1", "Tag2"}, transactions[0].Tags)
+		assert.Equal(t, []string{}, transactions[1].Tags)
+		assert.Equal(t, []string{"Tag2"}, transactions[2].Tags)
 		assert.NoError(t, mockM.ExpectationsWereMet(), "All database expectations should be met")
 	})
 
 	t.Run("Error Fetching Tags", func(t *testing.T) {
-		// Reset mock expectations for a new subtest
-		db, mockM = setupNewMock(t)
+		db, mockM := setupNewMock(t)
+		defer db.Close()
 
-		// Set up the mock expectation for SQL query with an error
-		mockM.ExpectQuery("SELECT tag_id, name FROM tags t JOIN transaction_tags tt ON t.tag_id = tt.tag_id WHERE tt.transaction_id = ?").
-			WithArgs(transactionID).
-			WillReturnError(sql.ErrConnDone) // Simulate an error scenario
+		mockM.ExpectQuery(expectedSQLPattern).WillReturnError(sql.ErrConnDone)
 
-		// Call the function under test
-		err := getTagsForTransaction(context.Background(), transaction)
+		err := getTagsForTransactions(context.Background(), []interfaces.Transaction{{ID: 1}, {ID: 2}, {ID: 3}})
 		assert.Error(t, err, "Fetching tags should produce an error")
-
-		// Ensure all expectations were met
 		assert.NoError(t, mockM.ExpectationsWereMet(), "All database expectations should be met")
 	})
 }
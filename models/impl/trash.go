@@ -0,0 +1,270 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"xspends/models/interfaces"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+const (
+	TrashTypeTransaction = "transaction"
+	TrashTypeCategory    = "category"
+	TrashTypeSource      = "source"
+	TrashTypeTag         = "tag"
+)
+
+var (
+	ErrTrashItemNotFound = errors.New("item not found in the trash")
+	ErrInvalidTrashType  = errors.New("type must be transaction, category, source or tag")
+	ErrTrashConflict     = errors.New("the item depends on another item in the trash")
+	ErrTrashNameTaken    = errors.New("an item in use has the same name; rename it before restoring this one")
+)
+
+// mysqlErrDuplicateEntry is the error number of a unique key violation.
+const mysqlErrDuplicateEntry = 1062
+
+// trashTable names the table and columns holding one type of deletable item.
+type trashTable struct {
+	table     string
+	id        string
+	name      string
+	scope     string
+	deletedAt string
+}
+
+// TrashModel lists, restores and purges the items the ledger models soft-delete.
+type TrashModel struct {
+	Transactions *TransactionModel
+	Categories   *CategoryModel
+	Sources      *SourceModel
+	Tags         *TagModel
+}
+
+func NewTrashModel() *TrashModel {
+	return &TrashModel{
+		Transactions: NewTransactionModel(),
+		Categories:   NewCategoryModel(),
+		Sources:      NewSourceModel(),
+		Tags:         NewTagModel(),
+	}
+}
+
+// trashedCondition selects the rows in the trash, or those in use.
+func trashedCondition(deletedAt string, trashed bool) squirrel.Sqlizer {
+	if trashed {
+		return squirrel.NotEq{deletedAt: nil}
+	}
+	return squirrel.Eq{deletedAt: nil}
+}
+
+func (tm *TrashModel) tables() map[string]trashTable {
+	return map[string]trashTable{
+		TrashTypeTransaction: {tm.Transactions.TableTransactions, tm.Transactions.ColumnID, "COALESCE(" + tm.Transactions.ColumnDescription + ", '')", tm.Transactions.ColumnScope, tm.Transactions.ColumnDeletedAt},
+		TrashTypeCategory:    {tm.Categories.TableCategories, tm.Categories.ColumnID, tm.Categories.ColumnName, tm.Categories.ColumnScopeID, tm.Categories.ColumnDeletedAt},
+		TrashTypeSource:      {tm.Sources.TableSources, tm.Sources.ColumnID, tm.Sources.ColumnName, tm.Sources.ColumnScope, tm.Sources.ColumnDeletedAt},
+		TrashTypeTag:         {tm.Tags.TableTags, tm.Tags.ColumnID, tm.Tags.ColumnName, tm.Tags.ColumnScope, tm.Tags.ColumnDeletedAt},
+	}
+}
+
+// GetTrash lists the deleted items of the scopes, most recently deleted first.
+// An empty itemType lists every type.
+func (tm *TrashModel) GetTrash(ctx context.Context, scopes []int64, itemType string, otx ...*sql.Tx) ([]interfaces.TrashItem, error) {
+	_, executor := getExecutor(otx...)
+
+	types := []string{TrashTypeTransaction, TrashTypeCategory, TrashTypeSource, TrashTypeTag}
+	if itemType != "" {
+		itemType = strings.ToLower(itemType)
+		if _, ok := tm.tables()[itemType]; !ok {
+			return nil, ErrInvalidTrashType
+		}
+		types = []string{itemType}
+	}
+
+	items := make([]interfaces.TrashItem, 0)
+	for _, t := range types {
+		table := tm.tables()[t]
+		query, args, err := GetQueryBuilder().Select(table.id, table.name, table.scope, table.deletedAt).
+			From(table.table).
+			Where(squirrel.Eq{table.scope: scopes}).
+			Where(trashedCondition(table.deletedAt, true)).
+			ToSql()
+		if err != nil {
+			return nil, errors.Wrapf(err, "building %s trash query failed", t)
+		}
+
+		rows, err := executor.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "querying %s trash failed", t)
+		}
+		for rows.Next() {
+			item := interfaces.TrashItem{Type: t}
+			if err := rows.Scan(&item.ID, &item.Name, &item.ScopeID, &item.DeletedAt); err != nil {
+				rows.Close()
+				return nil, errors.Wrapf(err, "scanning %s trash failed", t)
+			}
+			items = append(items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, errors.Wrapf(err, "processing %s trash rows failed", t)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	return items, nil
+}
+
+// RestoreItem takes an item out of the trash. Transactions are restored through
+// TransactionModel.RestoreTransaction, which also puts their amount back on the source balance.
+func (tm *TrashModel) RestoreItem(ctx context.Context, itemType string, itemID int64, scopes []int64, otx ...*sql.Tx) error {
+	itemType = strings.ToLower(itemType)
+	table, ok := tm.tables()[itemType]
+	if !ok {
+		return ErrInvalidTrashType
+	}
+	if itemType == TrashTypeTransaction {
+		return GetModelsService().TransactionModel.RestoreTransaction(ctx, itemID, scopes, otx...)
+	}
+
+	isExternalTx, executor := getExecutor(otx...)
+	query, args, err := GetQueryBuilder().Update(table.table).
+		Set(table.deletedAt, nil).
+		Where(squirrel.Eq{table.id: itemID, table.scope: scopes}).
+		Where(trashedCondition(table.deletedAt, true)).
+		ToSql()
+	if err != nil {
+		return errors.Wrapf(err, "building %s restore query failed", itemType)
+	}
+
+	result, err := executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		// Names are unique among the items in use only, so another one may have taken it meanwhile
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry {
+			return ErrTrashNameTaken
+		}
+		return errors.Wrapf(err, "restoring %s failed", itemType)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrTrashItemNotFound
+	}
+
+	commitOrRollback(executor, isExternalTx, err)
+	return nil
+}
+
+// PurgeTrash permanently removes the items deleted before deletedBefore, in every scope.
+// Transactions go first, with their tags, splits and shares; tags are removed from the
+// transactions still carrying them. Categories and sources that recurring transactions,
+// budgets, imports or transactions still refer to stay in the trash until they no longer do.
+func (tm *TrashModel) PurgeTrash(ctx context.Context, deletedBefore time.Time, otx ...*sql.Tx) (*interfaces.TrashPurge, error) {
+	purge := &interfaces.TrashPurge{}
+	err := RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		expired := func(table trashTable) squirrel.Sqlizer {
+			return squirrel.Lt{table.deletedAt: deletedBefore}
+		}
+		expiredIDs := func(table trashTable) squirrel.SelectBuilder {
+			return GetQueryBuilder().Select(table.id).From(table.table).Where(expired(table))
+		}
+		exec := func(builder squirrel.DeleteBuilder, what string) (int64, error) {
+			query, args, err := builder.ToSql()
+			if err != nil {
+				return 0, errors.Wrapf(err, "building purge query for %s failed", what)
+			}
+			result, err := executor.ExecContext(ctx, query, args...)
+			if err != nil {
+				return 0, errors.Wrapf(err, "purging %s failed", what)
+			}
+			affected, _ := result.RowsAffected()
+			return affected, nil
+		}
+		notUsedIn := func(table trashTable, uses ...string) squirrel.And {
+			conditions := squirrel.And{expired(table)}
+			for _, use := range uses {
+				conditions = append(conditions, squirrel.Expr("NOT EXISTS (SELECT 1 FROM "+use+" = "+table.table+"."+table.id+")"))
+			}
+			return conditions
+		}
+		tables := tm.tables()
+		transactions, tags := tables[TrashTypeTransaction], tables[TrashTypeTag]
+		categories, sources := tables[TrashTypeCategory], tables[TrashTypeSource]
+
+		var err error
+		if _, err = exec(GetQueryBuilder().Delete("transaction_tags").
+			Where(squirrel.Expr("transaction_id IN (?)", expiredIDs(transactions))), "transaction tags"); err != nil {
+			return err
+		}
+		if purge.Transactions, err = exec(GetQueryBuilder().Delete(transactions.table).Where(expired(transactions)), "transactions"); err != nil {
+			return err
+		}
+
+		if _, err = exec(GetQueryBuilder().Delete("transaction_tags").
+			Where(squirrel.Expr("tag_id IN (?)", expiredIDs(tags))), "tags of transactions"); err != nil {
+			return err
+		}
+		if _, err = exec(GetQueryBuilder().Delete("transaction_split_tags").
+			Where(squirrel.Expr("tag_id IN (?)", expiredIDs(tags))), "tags of split lines"); err != nil {
+			return err
+		}
+		if purge.Tags, err = exec(GetQueryBuilder().Delete(tags.table).Where(expired(tags)), "tags"); err != nil {
+			return err
+		}
+
+		if purge.Categories, err = exec(GetQueryBuilder().Delete(categories.table).Where(notUsedIn(categories,
+			"transactions t WHERE t.category_id",
+			"transaction_splits sp WHERE sp.category_id",
+			"recurring_transactions r WHERE r.category_id",
+			"budgets b WHERE b.category_id",
+		)), "categories"); err != nil {
+			return err
+		}
+
+		if purge.Sources, err = exec(GetQueryBuilder().Delete(sources.table).Where(notUsedIn(sources,
+			"transactions t WHERE t.source_id",
+			"transactions d WHERE d.destination_source_id",
+			"recurring_transactions r WHERE r.source_id",
+			"import_batches ib WHERE ib.source_id",
+		)), "sources"); err != nil {
+			return err
+		}
+		return nil
+	}, otx...)
+	if err != nil {
+		return nil, err
+	}
+	return purge, nil
+}
@@ -0,0 +1,124 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+	xmock "xspends/models/mock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTrash(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TrashModel = NewTrashModel()
+	})
+	defer tearDown()
+
+	_, mockM := setupNewMock(t)
+	older, newer := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	mockM.ExpectQuery("^SELECT tag_id, name, scope_id, deleted_at FROM tags WHERE scope_id IN \\(\\?\\) AND deleted_at IS NOT NULL").
+		WithArgs(int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_id", "name", "scope_id", "deleted_at"}).
+			AddRow(1, "travel", 10, older).
+			AddRow(2, "gifts", 10, newer))
+
+	items, err := ModelsService.TrashModel.GetTrash(context.Background(), []int64{10}, "Tag")
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "gifts", items[0].Name, "the most recently deleted item comes first")
+	assert.Equal(t, TrashTypeTag, items[0].Type)
+	assert.NoError(t, mockM.ExpectationsWereMet())
+
+	_, err = ModelsService.TrashModel.GetTrash(context.Background(), []int64{10}, "budget")
+	assert.Equal(t, ErrInvalidTrashType, err)
+}
+
+func TestRestoreItem(t *testing.T) {
+	mockTransactionModel := new(xmock.MockTransactionModel)
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TrashModel = NewTrashModel()
+		config.TransactionModel = mockTransactionModel
+	})
+	defer tearDown()
+
+	t.Run("Category", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectExec("^UPDATE categories SET deleted_at = \\? WHERE category_id = \\? AND scope_id IN \\(\\?\\) AND deleted_at IS NOT NULL").
+			WithArgs(nil, int64(3), int64(10)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, ModelsService.TrashModel.RestoreItem(context.Background(), TrashTypeCategory, 3, []int64{10}))
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Not in the trash", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectExec("^UPDATE sources SET deleted_at = \\?").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := ModelsService.TrashModel.RestoreItem(context.Background(), TrashTypeSource, 3, []int64{10})
+		assert.Equal(t, ErrTrashItemNotFound, err)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Name taken by an item in use", func(t *testing.T) {
+		_, mockM := setupNewMock(t)
+		mockM.ExpectExec("^UPDATE tags SET deleted_at = \\?").
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-groceries-1' for key 'uniq_tags_user_name'"})
+
+		err := ModelsService.TrashModel.RestoreItem(context.Background(), TrashTypeTag, 3, []int64{10})
+		assert.Equal(t, ErrTrashNameTaken, err)
+		assert.NoError(t, mockM.ExpectationsWereMet())
+	})
+
+	t.Run("Transactions restore their balance effect", func(t *testing.T) {
+		mockTransactionModel.On("RestoreTransaction", mock.Anything, int64(7), []int64{10}, mock.Anything).Return(nil).Once()
+
+		assert.NoError(t, ModelsService.TrashModel.RestoreItem(context.Background(), TrashTypeTransaction, 7, []int64{10}))
+		mockTransactionModel.AssertExpectations(t)
+	})
+
+	t.Run("Invalid type", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidTrashType, ModelsService.TrashModel.RestoreItem(context.Background(), "budget", 7, []int64{10}))
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	tearDown := setUp(t, func(config *ModelsConfig) {
+		config.TrashModel = NewTrashModel()
+	})
+	defer tearDown()
+
+	_, mockM := setupNewMock(t)
+	before := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mockM.ExpectBegin()
+	mockM.ExpectExec("^DELETE FROM transaction_tags WHERE transaction_id IN \\(SELECT transaction_id FROM transactions WHERE deleted_at < \\?\\)").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 3))
+	mockM.ExpectExec("^DELETE FROM transactions WHERE deleted_at < \\?").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 2))
+	mockM.ExpectExec("^DELETE FROM transaction_tags WHERE tag_id IN \\(SELECT tag_id FROM tags WHERE deleted_at < \\?\\)").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockM.ExpectExec("^DELETE FROM transaction_split_tags WHERE tag_id IN").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockM.ExpectExec("^DELETE FROM tags WHERE deleted_at < \\?").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Categories and sources still referenced elsewhere are kept
+	mockM.ExpectExec("^DELETE FROM categories WHERE \\(deleted_at < \\? AND NOT EXISTS \\(SELECT 1 FROM transactions t WHERE t.category_id = categories.category_id\\) (.+)\\)").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockM.ExpectExec("^DELETE FROM sources WHERE \\(deleted_at < \\? AND NOT EXISTS (.+) AND NOT EXISTS \\(SELECT 1 FROM import_batches ib WHERE ib.source_id = sources.source_id\\)\\)").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockM.ExpectCommit()
+
+	purged, err := ModelsService.TrashModel.PurgeTrash(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged.Transactions)
+	assert.Equal(t, int64(1), purged.Tags)
+	assert.Equal(t, int64(1), purged.Categories)
+	assert.Equal(t, int64(0), purged.Sources)
+	assert.NoError(t, mockM.ExpectationsWereMet())
+}
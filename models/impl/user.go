@@ -34,56 +34,150 @@ package impl
 import (
 	"context"
 	"database/sql"
-	"log"
-
 	"strings"
 	"time"
+	_ "time/tzdata" // time zone names resolve even where the system has no zoneinfo
 	"xspends/models/interfaces"
 	"xspends/util"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/pkg/errors"
+	"golang.org/x/text/language"
 )
 
 // User struct mirrors the users table and satisfies the AuthBoss User interface.
 
 var (
-	ErrUserNotFound  = errors.New("user not found")
-	ErrEmailExists   = errors.New("email already exists")
-	ErrUsernameTaken = errors.New("username already exists")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrEmailExists            = errors.New("email already exists")
+	ErrUsernameTaken          = errors.New("username already exists")
+	ErrInvalidTimezone        = errors.New("timezone must be an IANA time zone name, such as Europe/Paris")
+	ErrInvalidLocale          = errors.New("locale must be a BCP 47 language tag, such as en-GB")
+	ErrInvalidWeekStart       = errors.New("week_start must be a day of the week, such as MONDAY")
+	ErrInvalidCurrencyDisplay = errors.New("currency_display must be SYMBOL, CODE or NAME")
+)
+
+// Preferences of users who have not chosen their own.
+const (
+	DefaultTimezone        = "UTC"
+	DefaultLocale          = "en-US"
+	DefaultWeekStart       = "MONDAY"
+	DefaultCurrencyDisplay = CurrencyDisplaySymbol
 )
 
+const (
+	CurrencyDisplaySymbol = "SYMBOL"
+	CurrencyDisplayCode   = "CODE"
+	CurrencyDisplayName   = "NAME"
+)
+
+// LoadUserLocation returns the location of a user's IANA time zone name, UTC for an empty name.
+func LoadUserLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	// "Local" would be the server's zone, which means nothing to the user
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	return location, nil
+}
+
+// LoadUserWeekStart returns the weekday named by a user's week start, Monday for an empty name.
+func LoadUserWeekStart(name string) (time.Weekday, error) {
+	if name == "" {
+		return time.Monday, nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) {
+			return day, nil
+		}
+	}
+	return time.Sunday, ErrInvalidWeekStart
+}
+
+// normalizePreferences fills in the default of every preference left empty, checks the others
+// and brings them to their canonical spelling.
+func normalizePreferences(user *interfaces.User) error {
+	if user.Timezone == "" {
+		user.Timezone = DefaultTimezone
+	}
+	if _, err := LoadUserLocation(user.Timezone); err != nil {
+		return err
+	}
+
+	if user.Locale == "" {
+		user.Locale = DefaultLocale
+	}
+	tag, err := language.Parse(user.Locale)
+	if err != nil {
+		return errors.Wrap(ErrInvalidLocale, user.Locale)
+	}
+	user.Locale = tag.String()
+
+	if user.WeekStart == "" {
+		user.WeekStart = DefaultWeekStart
+	}
+	weekStart, err := LoadUserWeekStart(user.WeekStart)
+	if err != nil {
+		return errors.Wrap(err, user.WeekStart)
+	}
+	user.WeekStart = strings.ToUpper(weekStart.String())
+
+	if user.CurrencyDisplay == "" {
+		user.CurrencyDisplay = DefaultCurrencyDisplay
+	}
+	user.CurrencyDisplay = strings.ToUpper(user.CurrencyDisplay)
+	switch user.CurrencyDisplay {
+	case CurrencyDisplaySymbol, CurrencyDisplayCode, CurrencyDisplayName:
+	default:
+		return ErrInvalidCurrencyDisplay
+	}
+	return nil
+}
+
 type UserModel struct {
-	TableUsers      string
-	ColumnID        string
-	ColumnUsername  string
-	ColumnName      string
-	ColumnEmail     string
-	ColumnScope     string
-	ColumnCurrency  string
-	ColumnPassword  string
-	ColumnCreatedAt string
-	ColumnUpdatedAt string
+	TableUsers            string
+	ColumnID              string
+	ColumnUsername        string
+	ColumnName            string
+	ColumnEmail           string
+	ColumnScope           string
+	ColumnCurrency        string
+	ColumnPassword        string
+	ColumnTimezone        string
+	ColumnLocale          string
+	ColumnWeekStart       string
+	ColumnCurrencyDisplay string
+	ColumnCreatedAt       string
+	ColumnUpdatedAt       string
+	ColumnDeletedAt       string
 }
 
 func NewUserModel() *UserModel {
 	return &UserModel{
-		TableUsers:      "users",
-		ColumnID:        "user_id",
-		ColumnUsername:  "username",
-		ColumnName:      "name",
-		ColumnEmail:     "email",
-		ColumnScope:     "scope_id",
-		ColumnCurrency:  "currency",
-		ColumnPassword:  "password",
-		ColumnCreatedAt: "created_at",
-		ColumnUpdatedAt: "updated_at",
+		TableUsers:            "users",
+		ColumnID:              "user_id",
+		ColumnUsername:        "username",
+		ColumnName:            "name",
+		ColumnEmail:           "email",
+		ColumnScope:           "scope_id",
+		ColumnCurrency:        "currency",
+		ColumnPassword:        "password",
+		ColumnTimezone:        "timezone",
+		ColumnLocale:          "locale",
+		ColumnWeekStart:       "week_start",
+		ColumnCurrencyDisplay: "currency_display",
+		ColumnCreatedAt:       "created_at",
+		ColumnUpdatedAt:       "updated_at",
+		ColumnDeletedAt:       "deleted_at",
 	}
 }
 
+// InsertUser creates the user along with their personal scope, and seeds the scope with the
+// user's template, all in one SQL transaction.
 func (um *UserModel) InsertUser(ctx context.Context, user *interfaces.User, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
-
 	if user.Username == "" {
 		return errors.New("mandatory field missing: " + um.ColumnUsername)
 	}
@@ -94,64 +188,84 @@ func (um *UserModel) InsertUser(ctx context.Context, user *interfaces.User, otx
 		return errors.New("mandatory field missing: " + um.ColumnPassword)
 	}
 
+	if err := normalizePreferences(user); err != nil {
+		return err
+	}
+
 	var err error
 	user.CreatedAt, user.UpdatedAt = time.Now(), time.Now()
 	user.ID, err = util.GenerateSnowflakeID()
 	if err != nil {
 		return errors.Wrap(err, "generating Snowflake ID failed")
 	}
-	// Initialize ScopeModel and create a new scope
-	scopeID, err := GetModelsService().ScopeModel.CreateScope(ctx, ScopeTypeUser, otx...)
-	if err != nil {
-		commitOrRollback(executor, isExternalTx, err)
-		return errors.Wrap(err, "creating new scope failed")
-	}
-	user.Scope = scopeID
-	//Add to user_scopes table
-	GetModelsService().UserScopeModel.UpsertUserScope(ctx, user.ID, user.Scope, RoleOwner, otx...)
-	// Build and execute the SQL query using Squirrel
-	sqlquery, args, err := squirrel.Insert(um.TableUsers).
-		Columns(um.ColumnID, um.ColumnUsername, um.ColumnName, um.ColumnEmail, um.ColumnScope, um.ColumnCurrency, um.ColumnPassword, um.ColumnCreatedAt, um.ColumnUpdatedAt).
-		Values(user.ID, user.Username, user.Name, user.Email, scopeID, user.Currency, user.Password, user.CreatedAt, user.UpdatedAt).
-		PlaceholderFormat(squirrel.Question).
-		ToSql()
 
-	if err != nil {
-		return errors.Wrap(err, "building SQL query for InsertUser failed")
-	}
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
 
-	// Execute the query
-	_, err = executor.ExecContext(ctx, sqlquery, args...)
-	if err != nil {
-		if strings.Contains(err.Error(), "duplicate") {
-			if strings.Contains(err.Error(), um.ColumnUsername) {
-				return ErrUsernameTaken
-			}
-			if strings.Contains(err.Error(), um.ColumnEmail) {
-				return ErrEmailExists
-			}
+		// Initialize ScopeModel and create a new scope
+		scopeID, err := GetModelsService().ScopeModel.CreateScope(ctx, ScopeTypeUser, tx)
+		if err != nil {
+			return errors.Wrap(err, "creating new scope failed")
+		}
+		user.Scope = scopeID
+		//Add to user_scopes table
+		if err := GetModelsService().UserScopeModel.UpsertUserScope(ctx, user.ID, user.Scope, RoleOwner, tx); err != nil {
+			return errors.Wrap(err, "linking user to personal scope failed")
+		}
+		// Build and execute the SQL query using Squirrel
+		sqlquery, args, err := squirrel.Insert(um.TableUsers).
+			Columns(um.ColumnID, um.ColumnUsername, um.ColumnName, um.ColumnEmail, um.ColumnScope, um.ColumnCurrency, um.ColumnPassword,
+				um.ColumnTimezone, um.ColumnLocale, um.ColumnWeekStart, um.ColumnCurrencyDisplay, um.ColumnCreatedAt, um.ColumnUpdatedAt).
+			Values(user.ID, user.Username, user.Name, user.Email, scopeID, user.Currency, user.Password,
+				user.Timezone, user.Locale, user.WeekStart, user.CurrencyDisplay, user.CreatedAt, user.UpdatedAt).
+			PlaceholderFormat(squirrel.Question).
+			ToSql()
+
+		if err != nil {
+			return errors.Wrap(err, "building SQL query for InsertUser failed")
 		}
-		return errors.Wrap(err, "inserting user failed")
-	}
 
-	// Handle transaction commitment if it's not external
-	commitOrRollback(executor, isExternalTx, err)
+		// Execute the query
+		_, err = executor.ExecContext(ctx, sqlquery, args...)
+		if err != nil {
+			if strings.Contains(err.Error(), "duplicate") {
+				if strings.Contains(err.Error(), um.ColumnUsername) {
+					return ErrUsernameTaken
+				}
+				if strings.Contains(err.Error(), um.ColumnEmail) {
+					return ErrEmailExists
+				}
+			}
+			return errors.Wrap(err, "inserting user failed")
+		}
 
-	return nil
+		// Seeded after the user row so sources pick up the user's currency
+		if err := GetModelsService().TemplateModel.ApplyTemplate(ctx, user.Template, user.ID, scopeID, tx); err != nil {
+			return errors.Wrap(err, "seeding personal scope failed")
+		}
+		return nil
+	}, otx...)
 }
 
 func (um *UserModel) UpdateUser(ctx context.Context, user *interfaces.User, otx ...*sql.Tx) error {
+	if err := normalizePreferences(user); err != nil {
+		return err
+	}
 	isExternalTx, executor := getExecutor(otx...)
 	user.UpdatedAt = time.Now()
 
 	sqlquery, args, err := squirrel.Update(um.TableUsers).
 		SetMap(map[string]interface{}{
-			um.ColumnUsername:  user.Username,
-			um.ColumnName:      user.Name,
-			um.ColumnEmail:     user.Email,
-			um.ColumnCurrency:  user.Currency,
-			um.ColumnPassword:  user.Password,
-			um.ColumnUpdatedAt: user.UpdatedAt,
+			um.ColumnUsername:        user.Username,
+			um.ColumnName:            user.Name,
+			um.ColumnEmail:           user.Email,
+			um.ColumnCurrency:        user.Currency,
+			um.ColumnPassword:        user.Password,
+			um.ColumnTimezone:        user.Timezone,
+			um.ColumnLocale:          user.Locale,
+			um.ColumnWeekStart:       user.WeekStart,
+			um.ColumnCurrencyDisplay: user.CurrencyDisplay,
+			um.ColumnUpdatedAt:       user.UpdatedAt,
 		}).
 		Where(squirrel.Eq{um.ColumnID: user.ID}).
 		PlaceholderFormat(squirrel.Question).
@@ -170,84 +284,85 @@ func (um *UserModel) UpdateUser(ctx context.Context, user *interfaces.User, otx
 	return nil
 }
 
-func (um *UserModel) DeleteUser(ctx context.Context, id int64, otx ...*sql.Tx) error {
-	isExternalTx, executor := getExecutor(otx...)
+func (um *UserModel) GetUserByID(ctx context.Context, id int64, otx ...*sql.Tx) (*interfaces.User, error) {
+	_, executor := getExecutor(otx...)
 
-	//Delete from scopes, user_scopes table(s) as well
-	user, err := GetModelsService().UserModel.GetUserByID(ctx, id, otx...)
-	if err != nil {
-		return errors.Wrap(err, "User not found")
-	}
-	GetModelsService().UserScopeModel.DeleteUserScope(ctx, user.ID, user.Scope, otx...)
-	GetModelsService().ScopeModel.DeleteScope(ctx, user.Scope, otx...)
-	////
-	sqlquery, args, err := squirrel.Delete(um.TableUsers).
-		Where(squirrel.Eq{um.ColumnID: id}).
+	sqlquery, args, err := squirrel.Select(um.ColumnID, um.ColumnUsername, um.ColumnName, um.ColumnEmail, um.ColumnScope, um.ColumnCurrency, um.ColumnPassword,
+		um.ColumnTimezone, um.ColumnLocale, um.ColumnWeekStart, um.ColumnCurrencyDisplay).
+		From(um.TableUsers).
+		Where(squirrel.Eq{um.ColumnID: id, um.ColumnDeletedAt: nil}).
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 
 	if err != nil {
-		return errors.Wrap(err, "building SQL query for DeleteUser failed")
+		return nil, errors.Wrap(err, "building SQL query for GetUserByID failed")
 	}
 
-	_, err = executor.ExecContext(ctx, sqlquery, args...)
+	user := &interfaces.User{}
+	err = executor.QueryRowContext(ctx, sqlquery, args...).Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Scope, &user.Currency, &user.Password,
+		&user.Timezone, &user.Locale, &user.WeekStart, &user.CurrencyDisplay)
 	if err != nil {
-		log.Printf("[DeleteUser??] Error: %v", err)
-		return errors.Wrap(err, "deleting user failed")
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, "retrieving user by ID failed")
 	}
-
-	commitOrRollback(executor, isExternalTx, err)
-	return nil
+	return user, nil
 }
 
-func (um *UserModel) GetUserByID(ctx context.Context, id int64, otx ...*sql.Tx) (*interfaces.User, error) {
+func (um *UserModel) GetUserByUsername(ctx context.Context, username string, otx ...*sql.Tx) (*interfaces.User, error) {
 	_, executor := getExecutor(otx...)
 
-	sqlquery, args, err := squirrel.Select(um.ColumnID, um.ColumnUsername, um.ColumnName, um.ColumnEmail, um.ColumnScope, um.ColumnCurrency, um.ColumnPassword).
+	sqlquery, args, err := squirrel.Select(um.ColumnID, um.ColumnUsername, um.ColumnName, um.ColumnEmail, um.ColumnScope, um.ColumnCurrency, um.ColumnPassword,
+		um.ColumnTimezone, um.ColumnLocale, um.ColumnWeekStart, um.ColumnCurrencyDisplay).
 		From(um.TableUsers).
-		Where(squirrel.Eq{um.ColumnID: id}).
+		Where(squirrel.Eq{um.ColumnUsername: username, um.ColumnDeletedAt: nil}).
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 
 	if err != nil {
-		return nil, errors.Wrap(err, "building SQL query for GetUserByID failed")
+		return nil, errors.Wrap(err, "building SQL query for GetUserByUsername failed")
 	}
 
 	user := &interfaces.User{}
-	err = executor.QueryRowContext(ctx, sqlquery, args...).Scan(&user.ID, &user.Username, &user.Name, &user.Email, &um.ColumnScope, &user.Currency, &user.Password)
+
+	err = executor.QueryRowContext(ctx, sqlquery, args...).Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Scope, &user.Currency, &user.Password,
+		&user.Timezone, &user.Locale, &user.WeekStart, &user.CurrencyDisplay)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUserNotFound
 		}
-		return nil, errors.Wrap(err, "retrieving user by ID failed")
+		return nil, errors.Wrap(err, "retrieving user by username failed")
 	}
 	return user, nil
 }
-
-func (um *UserModel) GetUserByUsername(ctx context.Context, username string, otx ...*sql.Tx) (*interfaces.User, error) {
+func (um *UserModel) GetUserByEmail(ctx context.Context, email string, otx ...*sql.Tx) (*interfaces.User, error) {
 	_, executor := getExecutor(otx...)
 
-	sqlquery, args, err := squirrel.Select(um.ColumnID, um.ColumnUsername, um.ColumnName, um.ColumnEmail, um.ColumnScope, um.ColumnCurrency, um.ColumnPassword).
+	sqlquery, args, err := squirrel.Select(um.ColumnID, um.ColumnUsername, um.ColumnName, um.ColumnEmail, um.ColumnScope, um.ColumnCurrency, um.ColumnPassword,
+		um.ColumnTimezone, um.ColumnLocale, um.ColumnWeekStart, um.ColumnCurrencyDisplay).
 		From(um.TableUsers).
-		Where(squirrel.Eq{um.ColumnUsername: username}).
+		Where(squirrel.Eq{um.ColumnEmail: email, um.ColumnDeletedAt: nil}).
 		PlaceholderFormat(squirrel.Question).
 		ToSql()
 
 	if err != nil {
-		return nil, errors.Wrap(err, "building SQL query for GetUserByUsername failed")
+		return nil, errors.Wrap(err, "building SQL query for GetUserByEmail failed")
 	}
 
 	user := &interfaces.User{}
 
-	err = executor.QueryRowContext(ctx, sqlquery, args...).Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Scope, &user.Currency, &user.Password)
+	err = executor.QueryRowContext(ctx, sqlquery, args...).Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Scope, &user.Currency, &user.Password,
+		&user.Timezone, &user.Locale, &user.WeekStart, &user.CurrencyDisplay)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUserNotFound
 		}
-		return nil, errors.Wrap(err, "retrieving user by username failed")
+		return nil, errors.Wrap(err, "retrieving user by email failed")
 	}
 	return user, nil
 }
+
 func (um *UserModel) UserExists(ctx context.Context, username, email string, otx ...*sql.Tx) (bool, error) {
 	_, executor := getExecutor(otx...)
 
@@ -0,0 +1,205 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// deletedUserName is the username, and the start of the email, that a deleted account keeps,
+// which frees its own for new accounts.
+func deletedUserName(userID int64) string {
+	return "deleted-" + strconv.FormatInt(userID, 10)
+}
+
+// DeleteUser deletes a user's account in one SQL transaction. Groups the user owns pass to
+// another member, a writer if there is one, or are closed along with their data when the user
+// is their only member. The user leaves every other group and their pending invitations are
+// dropped. Everything in the personal scope is deleted with the scope. The users row stays,
+// renamed and without a password, so that records the user left in groups still have an author.
+func (um *UserModel) DeleteUser(ctx context.Context, id int64, otx ...*sql.Tx) error {
+	return RunInTx(ctx, func(tx *sql.Tx) error {
+		_, executor := getExecutor(tx)
+
+		user, err := um.GetUserByID(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+
+		memberships, err := GetModelsService().GroupModel.GetGroupsByUser(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+		for _, membership := range memberships {
+			if membership.OwnerID != id {
+				continue
+			}
+			if err := handOverGroup(ctx, executor, membership, tx); err != nil {
+				return err
+			}
+		}
+
+		exec := func(builder squirrel.Sqlizer, what string) error {
+			query, args, err := builder.ToSql()
+			if err != nil {
+				return errors.Wrapf(err, "building delete query for %s failed", what)
+			}
+			if _, err = executor.ExecContext(ctx, query, args...); err != nil {
+				return errors.Wrapf(err, "deleting %s failed", what)
+			}
+			return nil
+		}
+
+		if err := exec(GetQueryBuilder().Delete("group_invitations").
+			Where(squirrel.Eq{"status": InvitationStatusPending}).
+			Where(squirrel.Or{squirrel.Eq{"inviter_id": id}, squirrel.Eq{"invitee_id": id}}), "pending invitations"); err != nil {
+			return err
+		}
+		if err := exec(GetQueryBuilder().Delete("user_scopes").Where(squirrel.Eq{"user_id": id}), "memberships"); err != nil {
+			return err
+		}
+		if err := purgeScope(ctx, executor, user.Scope); err != nil {
+			return err
+		}
+		if err := GetModelsService().ScopeModel.DeleteScope(ctx, user.Scope, tx); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		name := deletedUserName(id)
+		query, args, err := GetQueryBuilder().Update(um.TableUsers).
+			SetMap(map[string]interface{}{
+				um.ColumnUsername:  name,
+				um.ColumnName:      "",
+				um.ColumnEmail:     name + "@deleted.invalid",
+				um.ColumnPassword:  "",
+				um.ColumnUpdatedAt: now,
+				um.ColumnDeletedAt: now,
+			}).
+			Where(squirrel.Eq{um.ColumnID: id}).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "building SQL query for DeleteUser failed")
+		}
+		if _, err = executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "deleting user failed")
+		}
+		return nil
+	}, otx...)
+}
+
+// groupSuccessor picks the member a departing owner's group passes to: the first writer,
+// else the first other member. It returns 0 when the owner is the only member.
+func groupSuccessor(members []interfaces.GroupMember, ownerID int64) int64 {
+	var successor int64
+	for _, member := range members {
+		if member.UserID == ownerID {
+			continue
+		}
+		if member.Role == RoleWrite {
+			return member.UserID
+		}
+		if successor == 0 {
+			successor = member.UserID
+		}
+	}
+	return successor
+}
+
+// handOverGroup makes the successor of the group's owner its owner, or closes the group and
+// deletes its data when nobody else belongs to it.
+func handOverGroup(ctx context.Context, executor DBExecutor, group interfaces.GroupMembership, tx *sql.Tx) error {
+	members, err := GetModelsService().GroupModel.GetGroupMembers(ctx, group.GroupID, group.OwnerID, tx)
+	if err != nil {
+		return err
+	}
+
+	successor := groupSuccessor(members, group.OwnerID)
+	if successor == 0 {
+		if err := GetModelsService().GroupModel.DeleteGroup(ctx, group.GroupID, group.OwnerID, tx); err != nil {
+			return err
+		}
+		if err := purgeScope(ctx, executor, group.ScopeID); err != nil {
+			return err
+		}
+		return GetModelsService().ScopeModel.DeleteScope(ctx, group.ScopeID, tx)
+	}
+
+	query, args, err := GetQueryBuilder().Update("user_groups").
+		Set("owner_id", successor).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"group_id": group.GroupID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "building group owner update query failed")
+	}
+	if _, err = executor.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "transferring group ownership failed")
+	}
+	return GetModelsService().UserScopeModel.UpsertUserScope(ctx, successor, group.ScopeID, RoleOwner, tx)
+}
+
+// purgeScope deletes everything recorded in a scope, children before the rows they refer to.
+// Splits, shared expenses and search terms go with their transactions.
+func purgeScope(ctx context.Context, executor DBExecutor, scopeID int64) error {
+	inScope := squirrel.Eq{"scope_id": scopeID}
+	steps := []struct {
+		what    string
+		builder squirrel.Sqlizer
+	}{
+		{"transaction tags", GetQueryBuilder().Delete("transaction_tags").
+			Where(squirrel.Expr("transaction_id IN (?)", GetQueryBuilder().Select("transaction_id").From("transactions").Where(inScope)))},
+		{"settlements", GetQueryBuilder().Delete("settlements").Where(inScope)},
+		{"transactions", GetQueryBuilder().Delete("transactions").Where(inScope)},
+		{"recurring occurrences", GetQueryBuilder().Delete("recurring_occurrences").
+			Where(squirrel.Expr("recurring_id IN (?)", GetQueryBuilder().Select("recurring_id").From("recurring_transactions").Where(inScope)))},
+		{"recurring transactions", GetQueryBuilder().Delete("recurring_transactions").Where(inScope)},
+		{"budgets", GetQueryBuilder().Delete("budgets").Where(inScope)},
+		{"rules", GetQueryBuilder().Delete("rules").Where(inScope)},
+		{"imports", GetQueryBuilder().Delete("import_batches").Where(inScope)},
+		{"tags", GetQueryBuilder().Delete("tags").Where(inScope)},
+		{"sources", GetQueryBuilder().Delete("sources").Where(inScope)},
+		{"category parents", GetQueryBuilder().Update("categories").Set("parent_id", nil).Where(inScope)},
+		{"categories", GetQueryBuilder().Delete("categories").Where(inScope)},
+		{"exchange rates", GetQueryBuilder().Delete("exchange_rates").Where(inScope)},
+	}
+	for _, step := range steps {
+		query, args, err := step.builder.ToSql()
+		if err != nil {
+			return errors.Wrapf(err, "building purge query for %s failed", step.what)
+		}
+		if _, err = executor.ExecContext(ctx, query, args...); err != nil {
+			return errors.Wrapf(err, "purging %s failed", step.what)
+		}
+	}
+	return nil
+}
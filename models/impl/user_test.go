@@ -6,10 +6,13 @@ import (
 	"testing"
 	"time"
 	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestInsertUser(t *testing.T) {
@@ -52,8 +55,12 @@ func TestInsertUser(t *testing.T) {
 		ExecContext(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(sql.Result(nil), nil).
 		Times(1)
+	templateModel := ModelsService.TemplateModel.(*xmock.MockTemplateModel)
+	templateModel.On("ApplyTemplate", ctx, "", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), []*sql.Tx{nil}).Return(nil).Once()
+
 	err := ModelsService.UserModel.InsertUser(ctx, user)
 	assert.NoError(t, err)
+	templateModel.AssertExpectations(t)
 }
 
 func TestUpdateUser(t *testing.T) {
@@ -80,40 +87,117 @@ func TestUpdateUser(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNormalizePreferences(t *testing.T) {
+	user := &interfaces.User{Locale: "en-gb", WeekStart: "sunday", CurrencyDisplay: "code"}
+	assert.NoError(t, normalizePreferences(user))
+	assert.Equal(t, interfaces.User{Timezone: DefaultTimezone, Locale: "en-GB", WeekStart: "SUNDAY", CurrencyDisplay: CurrencyDisplayCode}, *user)
+
+	user = &interfaces.User{}
+	assert.NoError(t, normalizePreferences(user))
+	assert.Equal(t, interfaces.User{Timezone: "UTC", Locale: "en-US", WeekStart: "MONDAY", CurrencyDisplay: "SYMBOL"}, *user)
+
+	for _, tc := range []struct {
+		user interfaces.User
+		err  error
+	}{
+		{interfaces.User{Timezone: "Local"}, ErrInvalidTimezone},
+		{interfaces.User{Locale: "not a locale"}, ErrInvalidLocale},
+		{interfaces.User{WeekStart: "FUNDAY"}, ErrInvalidWeekStart},
+		{interfaces.User{CurrencyDisplay: "EMOJI"}, ErrInvalidCurrencyDisplay},
+	} {
+		assert.Equal(t, tc.err, errors.Cause(normalizePreferences(&tc.user)))
+	}
+}
+
+// expectScopePurge expects every row of a scope to be deleted, children first.
+func expectScopePurge(sqlMock sqlmock.Sqlmock, scopeID int64) {
+	sqlMock.ExpectExec("^DELETE FROM transaction_tags WHERE transaction_id IN \\(SELECT transaction_id FROM transactions WHERE scope_id = \\?\\)").
+		WithArgs(scopeID).WillReturnResult(sqlmock.NewResult(0, 4))
+	sqlMock.ExpectExec("^DELETE FROM settlements WHERE scope_id = \\?").WithArgs(scopeID).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("^DELETE FROM transactions WHERE scope_id = \\?").WithArgs(scopeID).WillReturnResult(sqlmock.NewResult(0, 3))
+	sqlMock.ExpectExec("^DELETE FROM recurring_occurrences WHERE recurring_id IN \\(SELECT recurring_id FROM recurring_transactions WHERE scope_id = \\?\\)").
+		WithArgs(scopeID).WillReturnResult(sqlmock.NewResult(0, 2))
+	for _, table := range []string{"recurring_transactions", "budgets", "rules", "import_batches", "tags", "sources"} {
+		sqlMock.ExpectExec("^DELETE FROM " + table + " WHERE scope_id = \\?").WithArgs(scopeID).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	sqlMock.ExpectExec("^UPDATE categories SET parent_id = \\? WHERE scope_id = \\?").WithArgs(nil, scopeID).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("^DELETE FROM categories WHERE scope_id = \\?").WithArgs(scopeID).WillReturnResult(sqlmock.NewResult(0, 5))
+	sqlMock.ExpectExec("^DELETE FROM exchange_rates WHERE scope_id = \\?").WithArgs(scopeID).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
 func TestDeleteUser(t *testing.T) {
 	tearDown := setUp(t, func(config *ModelsConfig) {
-		// Replace the mocked CategoryModel with a real one just for this test
 		config.UserModel = NewUserModel()
-		config.ScopeModel = NewScopeModel()
-		config.UserScopeModel = NewUserScopeModel()
 	})
 	defer tearDown()
+	mockGroupModel := ModelsService.GroupModel.(*xmock.MockGroupModel)
+	mockScopeModel := ModelsService.ScopeModel.(*xmock.MockScopeModel)
+	mockUserScopeModel := ModelsService.UserScopeModel.(*xmock.MockUserScopeModel)
+	userColumns := []string{"user_id", "username", "name", "email", "scope_id", "currency", "password", "timezone", "locale", "week_start", "currency_display"}
+
+	t.Run("Hands over owned groups and deletes the personal scope", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery("^SELECT (.+) FROM users WHERE deleted_at IS NULL AND user_id = \\?").WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "john", "John", "john@example.com", 100, "USD", "hash", "UTC", "en-US", "MONDAY", "SYMBOL"))
+
+		mockGroupModel.On("GetGroupsByUser", mock.Anything, int64(1), mock.Anything).Return([]interfaces.GroupMembership{
+			{Group: interfaces.Group{GroupID: 5, OwnerID: 1, ScopeID: 200}, Role: RoleOwner},
+			{Group: interfaces.Group{GroupID: 6, OwnerID: 1, ScopeID: 300}, Role: RoleOwner},
+			{Group: interfaces.Group{GroupID: 7, OwnerID: 2, ScopeID: 400}, Role: RoleView},
+		}, nil).Once()
+
+		// Group 5 passes to its writer
+		mockGroupModel.On("GetGroupMembers", mock.Anything, int64(5), int64(1), mock.Anything).Return([]interfaces.GroupMember{
+			{UserID: 1, Role: RoleOwner}, {UserID: 2, Role: RoleView}, {UserID: 3, Role: RoleWrite},
+		}, nil).Once()
+		sqlMock.ExpectExec("^UPDATE user_groups SET owner_id = \\?, updated_at = \\? WHERE group_id = \\?").
+			WithArgs(int64(3), sqlmock.AnyArg(), int64(5)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mockUserScopeModel.On("UpsertUserScope", mock.Anything, int64(3), int64(200), RoleOwner, mock.Anything).Return(nil).Once()
+
+		// Nobody else is in group 6, so it is closed with its data
+		mockGroupModel.On("GetGroupMembers", mock.Anything, int64(6), int64(1), mock.Anything).Return([]interfaces.GroupMember{
+			{UserID: 1, Role: RoleOwner},
+		}, nil).Once()
+		mockGroupModel.On("DeleteGroup", mock.Anything, int64(6), int64(1), mock.Anything).Return(nil).Once()
+		expectScopePurge(sqlMock, 300)
+		mockScopeModel.On("DeleteScope", mock.Anything, int64(300), mock.Anything).Return(nil).Once()
+
+		sqlMock.ExpectExec("^DELETE FROM group_invitations WHERE status = \\? AND \\(inviter_id = \\? OR invitee_id = \\?\\)").
+			WithArgs(InvitationStatusPending, int64(1), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectExec("^DELETE FROM user_scopes WHERE user_id = \\?").WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 3))
+		expectScopePurge(sqlMock, 100)
+		mockScopeModel.On("DeleteScope", mock.Anything, int64(100), mock.Anything).Return(nil).Once()
+		sqlMock.ExpectExec("^UPDATE users SET deleted_at = \\?, email = \\?, name = \\?, password = \\?, updated_at = \\?, username = \\? WHERE user_id = \\?").
+			WithArgs(sqlmock.AnyArg(), "deleted-1@deleted.invalid", "", "", sqlmock.AnyArg(), "deleted-1", int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+
+		assert.NoError(t, ModelsService.UserModel.DeleteUser(ctx, 1))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		mockGroupModel.AssertExpectations(t)
+		mockScopeModel.AssertExpectations(t)
+		mockUserScopeModel.AssertExpectations(t)
+	})
 
-	userID := int64(1)
-
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer db.Close()
-	mockDBService := &DBService{Executor: db}
-	mockModelService := &ModelsServiceContainer{
-		DBService:      mockDBService,
-		UserModel:      NewUserModel(),
-		ScopeModel:     NewScopeModel(),
-		UserScopeModel: NewUserScopeModel(),
-		// Initialize other models as necessary
-	}
-	ModelsService = mockModelService
+	t.Run("Unknown user", func(t *testing.T) {
+		_, sqlMock := setupNewMock(t)
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery("^SELECT (.+) FROM users WHERE").WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows(userColumns))
+		sqlMock.ExpectRollback()
 
-	rows := sqlmock.NewRows([]string{"user_id", "username", "name", "email", "scope_id", "currency", "password"}).
-		AddRow(userID, "testuser", "Test User", "test@example.com", 1, "USD", "hashedpassword")
-	mock.ExpectQuery("^SELECT (.+) FROM users WHERE").WithArgs(userID).WillReturnRows(rows)
+		err := ModelsService.UserModel.DeleteUser(ctx, 2)
+		assert.Equal(t, ErrUserNotFound, errors.Cause(err))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
 
-	mock.ExpectExec("^DELETE FROM users WHERE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(1, 1))
-	err = ModelsService.UserModel.DeleteUser(ctx, userID)
-	assert.NoError(t, err)
+func TestGroupSuccessor(t *testing.T) {
+	assert.Equal(t, int64(3), groupSuccessor([]interfaces.GroupMember{{UserID: 2, Role: RoleView}, {UserID: 1, Role: RoleOwner}, {UserID: 3, Role: RoleWrite}}, 1))
+	assert.Equal(t, int64(2), groupSuccessor([]interfaces.GroupMember{{UserID: 1, Role: RoleOwner}, {UserID: 2, Role: RoleView}, {UserID: 4, Role: RoleView}}, 1))
+	assert.Equal(t, int64(0), groupSuccessor([]interfaces.GroupMember{{UserID: 1, Role: RoleOwner}}, 1))
 }
+
 func TestGetUserByID(t *testing.T) {
 	// Create a new sqlmock database connection
 	db, mock, err := sqlmock.New()
@@ -131,8 +215,8 @@ func TestGetUserByID(t *testing.T) {
 
 	// Set up expectations
 	userID := int64(1)
-	rows := sqlmock.NewRows([]string{"user_id", "username", "name", "email", "scope_id", "currency", "password"}).
-		AddRow(userID, "testuser", "Test User", "test@example.com", 1, "USD", "hashedpassword")
+	rows := sqlmock.NewRows([]string{"user_id", "username", "name", "email", "scope_id", "currency", "password", "timezone", "locale", "week_start", "currency_display"}).
+		AddRow(userID, "testuser", "Test User", "test@example.com", 1, "USD", "hashedpassword", "UTC", "en-US", "MONDAY", "SYMBOL")
 	mock.ExpectQuery("^SELECT (.+) FROM users WHERE").WithArgs(userID).WillReturnRows(rows)
 
 	// Call the function under test
@@ -181,8 +265,8 @@ func TestGetUserByUsername(t *testing.T) {
 		Password: "hashedpassword",
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "username", "name", "email", "scope", "currency", "password"}).
-		AddRow(expectedUser.ID, expectedUser.Username, expectedUser.Name, expectedUser.Email, expectedUser.Scope, expectedUser.Currency, expectedUser.Password)
+	rows := sqlmock.NewRows([]string{"id", "username", "name", "email", "scope", "currency", "password", "timezone", "locale", "week_start", "currency_display"}).
+		AddRow(expectedUser.ID, expectedUser.Username, expectedUser.Name, expectedUser.Email, expectedUser.Scope, expectedUser.Currency, expectedUser.Password, "Europe/Paris", "fr-FR", "MONDAY", "CODE")
 	mock.ExpectQuery("^SELECT (.+) FROM users WHERE").WithArgs(username).WillReturnRows(rows)
 
 	// Call the function under test
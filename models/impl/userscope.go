@@ -3,12 +3,15 @@ package impl
 import (
 	"context"
 	"database/sql"
+	"sort"
 	"xspends/models/interfaces"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/pkg/errors"
 )
 
+var ErrUserScopeNotFound = errors.New("user-scope relationship not found")
+
 type UserScopeModel struct {
 	TableUserScopes string
 	ColumnUserID    string
@@ -67,7 +70,7 @@ func (usm *UserScopeModel) GetUserScope(ctx context.Context, userID, scopeID int
 	err = executor.QueryRowContext(ctx, query, args...).Scan(&userScope.UserID, &userScope.ScopeID, &userScope.Role)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user-scope relationship not found")
+			return nil, ErrUserScopeNotFound
 		}
 		return nil, errors.Wrap(err, "querying user-scope relationship failed")
 	}
@@ -75,23 +78,31 @@ func (usm *UserScopeModel) GetUserScope(ctx context.Context, userID, scopeID int
 	return &userScope, nil
 }
 
-// GetUserScope retrieves a specific user-scope relationship.
-func (usm *UserScopeModel) ValidateUserScope(ctx context.Context, userID, scopeID int64, role string, otx ...*sql.Tx) bool {
-	_, executor := getExecutor(otx...)
-
-	// Determine the minimum access level required based on the provided role
+// rolesAtLeast lists the roles granting at least the access of role, in a fixed order:
+// an owner can do everything a writer can, and a writer everything a viewer can.
+func rolesAtLeast(role string) ([]string, bool) {
 	minAccessLevel, ok := roleHierarchy[role]
 	if !ok {
-		return false
+		return nil, false
 	}
-
-	// Build a slice of roles to include based on the hierarchy
-	var rolesToInclude []string
+	var roles []string
 	for r, level := range roleHierarchy {
-		if level <= minAccessLevel {
-			rolesToInclude = append(rolesToInclude, r)
+		if level >= minAccessLevel {
+			roles = append(roles, r)
 		}
 	}
+	sort.Strings(roles)
+	return roles, true
+}
+
+// ValidateUserScope reports whether the user holds role, or a higher one, in the scope.
+func (usm *UserScopeModel) ValidateUserScope(ctx context.Context, userID, scopeID int64, role string, otx ...*sql.Tx) bool {
+	_, executor := getExecutor(otx...)
+
+	rolesToInclude, ok := rolesAtLeast(role)
+	if !ok {
+		return false
+	}
 
 	query, args, err := GetQueryBuilder().
 		Select(usm.ColumnUserID, usm.ColumnScopeID, usm.ColumnRole).
@@ -115,24 +126,15 @@ func (usm *UserScopeModel) ValidateUserScope(ctx context.Context, userID, scopeI
 	return true
 }
 
-// GetUserScope retrieves user-scope relationships based on access level hierarchy.
+// GetUserScopesByRole retrieves the user's scopes in which they hold role or a higher one.
 func (usm *UserScopeModel) GetUserScopesByRole(ctx context.Context, userID int64, role string, otx ...*sql.Tx) ([]interfaces.UserScope, error) {
 	_, executor := getExecutor(otx...)
 
-	// Determine the minimum access level required based on the provided role
-	minAccessLevel, ok := roleHierarchy[role]
+	rolesToInclude, ok := rolesAtLeast(role)
 	if !ok {
 		return nil, errors.Errorf("invalid role: %s", role)
 	}
 
-	// Build a slice of roles to include based on the hierarchy
-	var rolesToInclude []string
-	for r, level := range roleHierarchy {
-		if level <= minAccessLevel {
-			rolesToInclude = append(rolesToInclude, r)
-		}
-	}
-
 	// Construct the query to select user scopes where the role is in the list of roles to include
 	query, args, err := GetQueryBuilder().
 		Select(usm.ColumnUserID, usm.ColumnScopeID, usm.ColumnRole).
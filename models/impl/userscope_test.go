@@ -0,0 +1,87 @@
+package impl
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpUserScopeTest(t *testing.T) sqlmock.Sqlmock {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ModelsService = &ModelsServiceContainer{
+		DBService:      &DBService{Executor: db},
+		UserScopeModel: NewUserScopeModel(),
+	}
+	return sqlMock
+}
+
+// expectRoleQuery answers a role check like the database would for a member holding memberRole:
+// the row comes back only if the query asks for that role.
+func expectRoleQuery(sqlMock sqlmock.Sqlmock, userID, scopeID int64, memberRole string, queriedRoles []string) {
+	args := []driver.Value{scopeID, userID}
+	rows := sqlmock.NewRows([]string{"user_id", "scope_id", "role"})
+	for _, role := range queriedRoles {
+		args = append(args, role)
+		if role == memberRole {
+			rows.AddRow(userID, scopeID, memberRole)
+		}
+	}
+	sqlMock.ExpectQuery("^SELECT user_id, scope_id, role FROM user_scopes WHERE scope_id = \\? AND user_id = \\? AND role IN").
+		WithArgs(args...).
+		WillReturnRows(rows)
+}
+
+func TestValidateUserScope(t *testing.T) {
+	// The roles each check accepts, higher roles included
+	accepted := map[string][]string{
+		RoleView:  {RoleOwner, RoleView, RoleWrite},
+		RoleWrite: {RoleOwner, RoleWrite},
+		RoleOwner: {RoleOwner},
+	}
+
+	tests := []struct {
+		memberRole string
+		allowed    map[string]bool
+	}{
+		{RoleOwner, map[string]bool{RoleView: true, RoleWrite: true, RoleOwner: true}},
+		{RoleWrite, map[string]bool{RoleView: true, RoleWrite: true, RoleOwner: false}},
+		{RoleView, map[string]bool{RoleView: true, RoleWrite: false, RoleOwner: false}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.memberRole, func(t *testing.T) {
+			for _, required := range []string{RoleView, RoleWrite, RoleOwner} {
+				sqlMock := setUpUserScopeTest(t)
+				expectRoleQuery(sqlMock, 1, 10, tc.memberRole, accepted[required])
+
+				assert.Equal(t, tc.allowed[required], ModelsService.UserScopeModel.ValidateUserScope(ctx, 1, 10, required),
+					"a %s member checked for %s", tc.memberRole, required)
+				assert.NoError(t, sqlMock.ExpectationsWereMet())
+			}
+		})
+	}
+
+	t.Run("Unknown role", func(t *testing.T) {
+		sqlMock := setUpUserScopeTest(t)
+		assert.False(t, ModelsService.UserScopeModel.ValidateUserScope(ctx, 1, 10, "admin"))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestGetUserScopesByRole(t *testing.T) {
+	sqlMock := setUpUserScopeTest(t)
+	sqlMock.ExpectQuery("^SELECT user_id, scope_id, role FROM user_scopes WHERE user_id = \\? AND role IN \\(\\?,\\?\\)").
+		WithArgs(int64(1), RoleOwner, RoleWrite).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "scope_id", "role"}).AddRow(1, 10, RoleOwner).AddRow(1, 20, RoleWrite))
+
+	scopes, err := ModelsService.UserScopeModel.GetUserScopesByRole(ctx, 1, RoleWrite)
+	assert.NoError(t, err)
+	assert.Len(t, scopes, 2)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
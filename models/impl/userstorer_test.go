@@ -2,13 +2,16 @@ package impl
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 )
 
 func TestUserStorer_Load(t *testing.T) {
@@ -32,13 +35,13 @@ func TestUserStorer_Load(t *testing.T) {
 	}
 
 	// Prepare the mock response
-	rows := sqlmock.NewRows([]string{"user_id", "username", "name", "email", "scope_id", "currency", "password"}).
-		AddRow("1", expectedUser.Username, "Test User", expectedUser.Email, expectedUser.Scope, "USD", "hashedpassword")
+	rows := sqlmock.NewRows([]string{"user_id", "username", "name", "email", "scope_id", "currency", "password", "timezone", "locale", "week_start", "currency_display"}).
+		AddRow("1", expectedUser.Username, "Test User", expectedUser.Email, expectedUser.Scope, "USD", "hashedpassword", "UTC", "en-US", "MONDAY", "SYMBOL")
 
 	// Set up the expected SQL query that will be run
 	// Note that the sqlquery variable comes from your actual GetUserByUsername method,
 	// so ensure it matches exactly with what's being executed there.
-	sqlquery := "SELECT user_id, username, name, email, scope_id, currency, password FROM users WHERE username = ?"
+	sqlquery := "SELECT user_id, username, name, email, scope_id, currency, password, timezone, locale, week_start, currency_display FROM users WHERE deleted_at IS NULL AND username = ?"
 	mock.ExpectQuery(sqlquery).
 		WithArgs(username).
 		WillReturnRows(rows)
@@ -86,7 +89,7 @@ func TestUserStorer_Save(t *testing.T) {
 	}
 
 	// This should match the actual SQL query string
-	expectedSQL := "UPDATE users SET currency = ?, email = ?, name = ?, password = ?, updated_at = ?, username = ? WHERE user_id = ?"
+	expectedSQL := "UPDATE users SET currency = ?, currency_display = ?, email = ?, locale = ?, name = ?, password = ?, timezone = ?, updated_at = ?, username = ?, week_start = ? WHERE user_id = ?"
 
 	// Mock the database call
 	mockExecutor.EXPECT().
@@ -100,6 +103,10 @@ func TestUserStorer_Save(t *testing.T) {
 			gomock.Any(),
 			gomock.Any(),
 			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
 		).Return(sqlmock.NewResult(1, 1), nil) // Assuming successful execution
 
 	userStorer := NewUserStorer()
@@ -112,11 +119,13 @@ func TestUserStorer_Save(t *testing.T) {
 }
 
 func TestUserStorer_Create(t *testing.T) {
+	templateModel := new(xmock.MockTemplateModel)
 	tearDown := setUp(t, func(config *ModelsConfig) {
 		// Replace the mocked CategoryModel with a real one just for this test
 		config.UserModel = NewUserModel()
 		config.ScopeModel = NewScopeModel()
 		config.UserScopeModel = NewUserScopeModel()
+		config.TemplateModel = templateModel
 	}) // Assume setUp properly initializes mocks and other necessary stuff
 	defer tearDown()
 	_, mock, err := sqlmock.New()
@@ -157,7 +166,7 @@ func TestUserStorer_Create(t *testing.T) {
 		Return(sqlmock.NewResult(1, 1), nil) // Simulate successful execution
 
 	// Expectation for inserting a new user
-	expectedSQL := "INSERT INTO users (user_id,username,name,email,scope_id,currency,password,created_at,updated_at) VALUES (?,?,?,?,?,?,?,?,?)"
+	expectedSQL := "INSERT INTO users (user_id,username,name,email,scope_id,currency,password,timezone,locale,week_start,currency_display,created_at,updated_at) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)"
 	// Ensure the SQL query and other arguments match exactly with those used in the InsertUser method
 	mockExecutor.EXPECT().
 		ExecContext(
@@ -172,11 +181,19 @@ func TestUserStorer_Create(t *testing.T) {
 			gomock.Any(),
 			gomock.Any(),
 			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
 		).Return(sqlmock.NewResult(1, 1), nil) // Simulate successful execution
 
+	// The personal scope is seeded with the default template in the same transaction
+	templateModel.On("ApplyTemplate", testifymock.Anything, "", testifymock.AnythingOfType("int64"), testifymock.AnythingOfType("int64"), []*sql.Tx{nil}).Return(nil).Once()
+
 	userStorer := NewUserStorer()
 	err = userStorer.Create(context.Background(), newUser)
 	assert.NoError(t, err)
+	templateModel.AssertExpectations(t)
 
 	// Verify that all expectations set on the mock were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ArchiveService collects every entity a user owns, for data portability.
+type ArchiveService interface {
+	// ExportArchive passes each record to write along with the name of its entity, e.g. "sources".
+	// Records of one entity are passed consecutively.
+	ExportArchive(ctx context.Context, userID int64, scopeID int64, write func(entity string, record interface{}) error, otx ...*sql.Tx) error
+}
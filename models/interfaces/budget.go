@@ -0,0 +1,52 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Budget is a spending limit for one category of a scope, renewed every period.
+// Budgets of a group scope are shared: every member sees them and every member's
+// expenses count against them.
+type Budget struct {
+	ID         int64      `json:"budget_id"`
+	UserID     int64      `json:"user_id"`
+	ScopeID    int64      `json:"scope_id"`
+	CategoryID int64      `json:"category_id"`
+	Amount     Money      `json:"amount"`
+	Period     string     `json:"period"` // MONTHLY, WEEKLY or CUSTOM
+	StartDate  time.Time  `json:"start_date"`
+	EndDate    *time.Time `json:"end_date,omitempty"` // required for CUSTOM, the last day of the budget
+	Rollover   bool       `json:"rollover"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// BudgetStatus reports how much of a budget has been used in its current period.
+// PeriodEnd is exclusive. Available is the budget amount plus any amount rolled over
+// from the previous period.
+type BudgetStatus struct {
+	BudgetID    int64     `json:"budget_id"`
+	CategoryID  int64     `json:"category_id"`
+	ScopeID     int64     `json:"scope_id"`
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Amount      Money     `json:"amount"`
+	Rollover    Money     `json:"rollover"`
+	Available   Money     `json:"available"`
+	Spent       Money     `json:"spent"`
+	Remaining   Money     `json:"remaining"`
+	PercentUsed float64   `json:"percent_used"`
+}
+
+// BudgetService defines the interface for budget operations.
+type BudgetService interface {
+	InsertBudget(ctx context.Context, budget *Budget, otx ...*sql.Tx) error
+	UpdateBudget(ctx context.Context, budget *Budget, otx ...*sql.Tx) error
+	DeleteBudget(ctx context.Context, budgetID int64, scopes []int64, otx ...*sql.Tx) error
+	GetBudgetByID(ctx context.Context, budgetID int64, scopes []int64, otx ...*sql.Tx) (*Budget, error)
+	GetBudgetsByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]Budget, error)
+	GetBudgetStatus(ctx context.Context, scopes []int64, asOf time.Time, location *time.Location, weekStart time.Weekday, otx ...*sql.Tx) ([]BudgetStatus, error)
+}
@@ -13,15 +13,25 @@ type Category struct {
 	Description string    `json:"description"`
 	Icon        string    `json:"icon"`
 	ScopeID     int64     `json:"scope_id"`
+	ParentID    int64     `json:"parent_id,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// CategoryNode is a category with its subcategories, as listed by the category tree.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
 type CategoryService interface {
 	InsertCategory(ctx context.Context, category *Category, otx ...*sql.Tx) error
 	UpdateCategory(ctx context.Context, category *Category, otx ...*sql.Tx) error
-	DeleteCategory(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) error
+	DeleteCategory(ctx context.Context, categoryID int64, reassignTo int64, scopes []int64, otx ...*sql.Tx) error
 	GetCategoryByID(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) (*Category, error)
 	GetScopedCategories(ctx context.Context, page int, itemsPerPage int, scopes []int64, otx ...*sql.Tx) ([]Category, error)
+	GetCategoriesByCursor(ctx context.Context, scopes []int64, params CursorParams, otx ...*sql.Tx) ([]Category, CursorPage, error)
 	CategoryIDExists(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) (bool, error)
+	GetCategoryTree(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]CategoryNode, error)
+	RollUpReport(ctx context.Context, report []ReportRow, level int, scopes []int64, otx ...*sql.Tx) ([]ReportRow, error)
 }
@@ -0,0 +1,29 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ExchangeRate is the number of units of ToCurrency one unit of FromCurrency buys on RateDate.
+// Rates belong to a scope and hold from their date until a later rate for the same pair.
+type ExchangeRate struct {
+	ScopeID      int64     `json:"scope_id"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	RateDate     time.Time `json:"rate_date"`
+	Rate         float64   `json:"rate"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CurrencyService defines the interface for exchange rates and base currencies.
+type CurrencyService interface {
+	UpsertExchangeRate(ctx context.Context, rate *ExchangeRate, otx ...*sql.Tx) error
+	ImportExchangeRates(ctx context.Context, rates []ExchangeRate, otx ...*sql.Tx) (int, error)
+	DeleteExchangeRate(ctx context.Context, scopeID int64, fromCurrency, toCurrency string, rateDate time.Time, otx ...*sql.Tx) error
+	GetExchangeRates(ctx context.Context, scopes []int64, fromCurrency, toCurrency string, otx ...*sql.Tx) ([]ExchangeRate, error)
+	GetExchangeRate(ctx context.Context, scopeID int64, fromCurrency, toCurrency string, date time.Time, otx ...*sql.Tx) (float64, error)
+	GetBaseCurrency(ctx context.Context, scopeID int64, otx ...*sql.Tx) (string, error)
+}
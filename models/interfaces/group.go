@@ -7,15 +7,34 @@ import (
 )
 
 type Group struct {
-	GroupID     int64     `json:"group_id"`
-	OwnerID     int64     `json:"owner_id"`
-	ScopeID     int64     `json:"scope_id"`
-	GroupName   string    `json:"group_name"`
-	Description string    `json:"description"`
-	Icon        string    `json:"icon"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	GroupID     int64  `json:"group_id"`
+	OwnerID     int64  `json:"owner_id"`
+	ScopeID     int64  `json:"scope_id"`
+	GroupName   string `json:"group_name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	Status      string `json:"status"`
+	// Currency is the base currency of the group's reports. Empty means the owner's currency.
+	Currency  string    `json:"currency,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Template optionally names the seed template applied to the group's scope when it is created.
+	// It is not stored.
+	Template string `json:"template,omitempty"`
+}
+
+// GroupMembership is a group as seen by one of its members, along with that member's role.
+type GroupMembership struct {
+	Group
+	Role string `json:"role"`
+}
+
+// GroupMember is a user linked to a group's scope.
+type GroupMember struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
 }
 
 type GroupService interface {
@@ -24,4 +43,6 @@ type GroupService interface {
 	DeleteGroup(ctx context.Context, groupID int64, requestingUserID int64, otx ...*sql.Tx) error
 	GetGroupByID(ctx context.Context, groupID int64, requestingUserID int64, otx ...*sql.Tx) (*Group, error)
 	GetGroupByScope(ctx context.Context, scopeID int64, requestingUserID int64, otx ...*sql.Tx) (*Group, error)
+	GetGroupsByUser(ctx context.Context, userID int64, otx ...*sql.Tx) ([]GroupMembership, error)
+	GetGroupMembers(ctx context.Context, groupID int64, requestingUserID int64, otx ...*sql.Tx) ([]GroupMember, error)
 }
@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// GroupInvitation is a pending (or resolved) offer for a user to join a group.
+// No user_scopes row exists for the invitee until the invitation is accepted.
+type GroupInvitation struct {
+	InvitationID int64     `json:"invitation_id"`
+	GroupID      int64     `json:"group_id"`
+	GroupName    string    `json:"group_name,omitempty"`
+	InviterID    int64     `json:"inviter_id"`
+	InviteeID    int64     `json:"invitee_id"`
+	Role         string    `json:"role"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// GroupInvitationService defines the interface for group invitation operations.
+type GroupInvitationService interface {
+	InsertInvitation(ctx context.Context, invitation *GroupInvitation, otx ...*sql.Tx) error
+	GetInvitationByID(ctx context.Context, invitationID int64, otx ...*sql.Tx) (*GroupInvitation, error)
+	GetInvitationsByGroup(ctx context.Context, groupID int64, status string, otx ...*sql.Tx) ([]GroupInvitation, error)
+	GetInvitationsByInvitee(ctx context.Context, inviteeID int64, status string, otx ...*sql.Tx) ([]GroupInvitation, error)
+	AcceptInvitation(ctx context.Context, invitationID int64, inviteeID int64, otx ...*sql.Tx) (*GroupInvitation, error)
+	DeclineInvitation(ctx context.Context, invitationID int64, inviteeID int64, otx ...*sql.Tx) error
+	CancelInvitation(ctx context.Context, invitationID int64, groupID int64, otx ...*sql.Tx) error
+	DeleteInvitationsByGroup(ctx context.Context, groupID int64, otx ...*sql.Tx) error
+}
@@ -0,0 +1,56 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ImportRow is one transaction parsed from an uploaded statement. Amount is always
+// positive; Type tells whether it is INCOME or EXPENSE. Category is the category
+// name given by the file, if any.
+type ImportRow struct {
+	Line        int       `json:"line"`
+	Date        time.Time `json:"date"`
+	Amount      Money     `json:"amount"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Category    string    `json:"category,omitempty"`
+	ExternalID  string    `json:"external_id,omitempty"`
+
+	// Filled in by the preview. Rules may also rewrite the description.
+	SuggestedCategoryID int64    `json:"suggested_category_id,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	MatchedRules        []int64  `json:"matched_rules,omitempty"`
+	Duplicate           bool     `json:"duplicate"`
+	DuplicateOf         int64    `json:"duplicate_of,omitempty"`
+}
+
+// ImportBatch records one committed import so that it can be undone as a whole.
+type ImportBatch struct {
+	ID        int64      `json:"batch_id"`
+	UserID    int64      `json:"user_id"`
+	ScopeID   int64      `json:"scope_id"`
+	SourceID  int64      `json:"source_id"`
+	Format    string     `json:"format"`
+	FileName  string     `json:"file_name"`
+	RowCount  int        `json:"row_count"`
+	Status    string     `json:"status"` // COMMITTED or UNDONE
+	CreatedAt time.Time  `json:"created_at"`
+	UndoneAt  *time.Time `json:"undone_at,omitempty"`
+}
+
+// ImportCommitOptions controls which previewed rows are inserted and how they are categorized.
+type ImportCommitOptions struct {
+	DefaultCategoryID int64
+	SkipDuplicates    bool
+}
+
+// ImportBatchService defines the interface for statement import operations.
+type ImportBatchService interface {
+	PreviewImport(ctx context.Context, batch ImportBatch, rows []ImportRow, otx ...*sql.Tx) ([]ImportRow, error)
+	CommitImport(ctx context.Context, batch *ImportBatch, rows []ImportRow, options ImportCommitOptions, otx ...*sql.Tx) error
+	UndoImport(ctx context.Context, batchID int64, scopes []int64, otx ...*sql.Tx) (*ImportBatch, error)
+	GetImportBatchByID(ctx context.Context, batchID int64, scopes []int64, otx ...*sql.Tx) (*ImportBatch, error)
+	GetImportBatchesByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]ImportBatch, error)
+}
@@ -0,0 +1,181 @@
+package interfaces
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Money is an exact amount in hundredths of a currency unit, the scale of the DECIMAL(10,2)
+// amount and balance columns. Arithmetic on it is integer arithmetic, so sums do not drift.
+// It is written to JSON as a number with two decimals and read from a JSON number or string.
+type Money int64
+
+var ErrInvalidMoney = errors.New("invalid amount")
+
+// maxMoneyDigits bounds the whole units of a parsed amount so that it fits in an int64.
+const maxMoneyDigits = 16
+
+// currencyDecimals lists the ISO 4217 currencies with fewer minor digits than the two
+// the amount columns store. Currencies with three minor digits are kept at two.
+var currencyDecimals = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// ParseMoney reads a decimal amount such as "-1234.5". Digits past the second decimal are
+// rounded half away from zero.
+func ParseMoney(s string) (Money, error) {
+	text := strings.TrimSpace(s)
+	negative := strings.HasPrefix(text, "-")
+	if negative || strings.HasPrefix(text, "+") {
+		text = text[1:]
+	}
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" && fraction == "" || len(whole) > maxMoneyDigits || !isDigits(whole) || !isDigits(fraction) {
+		return 0, errors.Wrapf(ErrInvalidMoney, "%q", s)
+	}
+
+	var cents int64
+	if whole != "" {
+		cents, _ = strconv.ParseInt(whole, 10, 64)
+	}
+	cents *= 100
+	fraction += "000"
+	hundredths, _ := strconv.ParseInt(fraction[:2], 10, 64)
+	cents += hundredths
+	if fraction[2] >= '5' {
+		cents++
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MoneyFromFloat converts a float amount, rounding to the nearest hundredth.
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * 100))
+}
+
+// Float64 returns the amount in currency units, for ratios and display only.
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String formats the amount with two decimals, e.g. "-12.30".
+func (m Money) String() string {
+	sign, cents := "", int64(m)
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Abs returns the amount without its sign.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// MinorUnit returns the smallest amount of a currency: one hundredth, or a whole unit for
+// currencies without minor digits such as JPY.
+func MinorUnit(currency string) Money {
+	decimals, ok := currencyDecimals[strings.ToUpper(currency)]
+	if !ok || decimals >= 2 {
+		return 1
+	}
+	return Money(math.Pow10(2 - decimals))
+}
+
+// Round rounds the amount half away from zero to the minor unit of a currency,
+// e.g. to whole yen for JPY. Unknown currencies keep two decimals.
+func (m Money) Round(currency string) Money {
+	unit := int64(MinorUnit(currency))
+	if unit == 1 {
+		return m
+	}
+	cents := int64(m.Abs())
+	cents = (cents + unit/2) / unit * unit
+	if m < 0 {
+		cents = -cents
+	}
+	return Money(cents)
+}
+
+// Convert multiplies the amount by an exchange rate and rounds the result to the minor
+// unit of the target currency.
+func (m Money) Convert(rate float64, currency string) Money {
+	return Money(math.Round(float64(m) * rate)).Round(currency)
+}
+
+// MarshalJSON writes the amount as an exact decimal number.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a decimal number or a string holding one.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	return m.scanText(strings.Trim(text, `"`))
+}
+
+// Scan reads a DECIMAL column, which drivers return as text, or a numeric column. NULL reads as zero.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		return m.scanText(string(v))
+	case string:
+		return m.scanText(v)
+	case int64:
+		*m = Money(v * 100)
+	case float64:
+		*m = MoneyFromFloat(v)
+	default:
+		return errors.Wrapf(ErrInvalidMoney, "cannot scan %T", src)
+	}
+	return nil
+}
+
+func (m *Money) scanText(text string) error {
+	// Floating point expressions such as converted sums may come back in exponent notation
+	if strings.ContainsAny(text, "eE") {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidMoney, "%q", text)
+		}
+		*m = MoneyFromFloat(f)
+		return nil
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the amount as decimal text so the database stores it exactly.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,96 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]Money{
+		"12":        1200,
+		"12.3":      1230,
+		"-12.34":    -1234,
+		"+0.05":     5,
+		".5":        50,
+		"1.005":     101,
+		"-1.005":    -101,
+		"1.00499":   100,
+		"123.4567":  12346,
+		" 99999999": 9999999900,
+	}
+	for text, expected := range cases {
+		amount, err := ParseMoney(text)
+		assert.NoError(t, err, text)
+		assert.Equal(t, expected, amount, text)
+	}
+
+	for _, text := range []string{"", "-", "abc", "1.2.3", "1e3", "12,50", "--1"} {
+		_, err := ParseMoney(text)
+		assert.ErrorIs(t, err, ErrInvalidMoney, text)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var body struct {
+		Amount Money `json:"amount"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 0.1}`), &body))
+	assert.Equal(t, Money(10), body.Amount)
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "1234.56"}`), &body))
+	assert.Equal(t, Money(123456), body.Amount)
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": "ten"}`), &body))
+
+	encoded, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":1234.56}`, string(encoded))
+
+	body.Amount = -7
+	encoded, err = json.Marshal(body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":-0.07}`, string(encoded))
+}
+
+func TestMoneySQL(t *testing.T) {
+	var amount Money
+	assert.NoError(t, amount.Scan([]byte("10.10")))
+	assert.Equal(t, Money(1010), amount)
+	assert.NoError(t, amount.Scan("-3.456789"))
+	assert.Equal(t, Money(-346), amount)
+	assert.NoError(t, amount.Scan(int64(7)))
+	assert.Equal(t, Money(700), amount)
+	assert.NoError(t, amount.Scan(1.1e-1))
+	assert.Equal(t, Money(11), amount)
+	assert.NoError(t, amount.Scan("1.5e+2"))
+	assert.Equal(t, Money(15000), amount)
+	assert.NoError(t, amount.Scan(nil))
+	assert.Equal(t, Money(0), amount)
+	assert.ErrorIs(t, amount.Scan(true), ErrInvalidMoney)
+
+	value, err := Money(-120).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "-1.20", value)
+}
+
+func TestMoneySumsWithoutDrift(t *testing.T) {
+	var sum Money
+	var floatSum float64
+	for i := 0; i < 10000; i++ {
+		sum += MoneyFromFloat(0.1)
+		floatSum += 0.1
+	}
+	assert.Equal(t, "1000.00", sum.String())
+	assert.NotEqual(t, 1000.0, floatSum)
+}
+
+func TestMoneyRound(t *testing.T) {
+	assert.Equal(t, Money(1235), Money(1235).Round("USD"))
+	assert.Equal(t, Money(1235), Money(1235).Round("BHD"), "three decimal currencies keep the stored two")
+	assert.Equal(t, Money(1200), Money(1249).Round("JPY"))
+	assert.Equal(t, Money(1300), Money(1250).Round("jpy"))
+	assert.Equal(t, Money(-1300), Money(-1250).Round("JPY"))
+
+	assert.Equal(t, Money(6750), Money(7500).Convert(0.9, "EUR"))
+	assert.Equal(t, Money(1104200), Money(7500).Convert(147.23, "JPY"), "11042.25 yen rounds to whole yen")
+}
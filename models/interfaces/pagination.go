@@ -0,0 +1,23 @@
+package interfaces
+
+import "time"
+
+// Cursor is the position of a row in a list ordered by a time and then by ID. The page read
+// from a cursor starts after its row, or ends before it when Backward is set.
+type Cursor struct {
+	Time     time.Time `json:"t"`
+	ID       int64     `json:"id"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// CursorParams asks for a page of at most Limit rows from Cursor, or for the first page when Cursor is nil.
+type CursorParams struct {
+	Cursor *Cursor
+	Limit  int
+}
+
+// CursorPage holds the cursors of the pages next to the one read. They are nil at either end of the list.
+type CursorPage struct {
+	Next *Cursor
+	Prev *Cursor
+}
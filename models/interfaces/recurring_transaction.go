@@ -0,0 +1,43 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RecurringTransaction is a transaction template repeated on a schedule.
+// Occurrences are numbered from zero starting at StartDate; Occurrences counts the ones
+// already materialized or skipped, and NextOccurrence is nil once the schedule has ended.
+type RecurringTransaction struct {
+	ID             int64      `json:"recurring_id"`
+	UserID         int64      `json:"user_id"`
+	ScopeID        int64      `json:"scope_id"`
+	SourceID       int64      `json:"source_id"`
+	CategoryID     int64      `json:"category_id"`
+	Amount         Money      `json:"amount"`
+	Type           string     `json:"type"`
+	Description    string     `json:"description"`
+	Tags           []string   `json:"tags"`
+	Frequency      string     `json:"frequency"` // DAILY, WEEKLY, MONTHLY or YEARLY
+	Interval       int        `json:"interval"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+	Count          int        `json:"count,omitempty"` // 0 means no limit
+	Occurrences    int        `json:"occurrences"`
+	NextOccurrence *time.Time `json:"next_occurrence"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// RecurringTransactionService defines the interface for recurring transaction operations.
+type RecurringTransactionService interface {
+	InsertRecurringTransaction(ctx context.Context, rt *RecurringTransaction, otx ...*sql.Tx) error
+	UpdateRecurringTransaction(ctx context.Context, rt *RecurringTransaction, otx ...*sql.Tx) error
+	DeleteRecurringTransaction(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) error
+	GetRecurringTransactionByID(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) (*RecurringTransaction, error)
+	GetRecurringTransactionsByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]RecurringTransaction, error)
+	SkipNextOccurrence(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) (*RecurringTransaction, error)
+	GetDueRecurringTransactions(ctx context.Context, asOf time.Time, otx ...*sql.Tx) ([]RecurringTransaction, error)
+	MaterializeOccurrences(ctx context.Context, recurringID int64, asOf time.Time, catchUp bool, otx ...*sql.Tx) (int, error)
+}
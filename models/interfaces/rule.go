@@ -0,0 +1,68 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Rule categorizes, tags or renames transactions of a scope automatically. A rule matches
+// a transaction when all of its set conditions hold. Rules are evaluated in ascending
+// priority; StopProcessing ends the evaluation after the rule matches.
+type Rule struct {
+	ID             int64          `json:"rule_id"`
+	UserID         int64          `json:"user_id"`
+	ScopeID        int64          `json:"scope_id"`
+	Name           string         `json:"name"`
+	Priority       int            `json:"priority"`
+	Enabled        bool           `json:"enabled"`
+	StopProcessing bool           `json:"stop_processing"`
+	Conditions     RuleConditions `json:"conditions"`
+	Actions        RuleActions    `json:"actions"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// RuleConditions are matched against a transaction; empty conditions are ignored.
+// DescriptionContains is case-insensitive, DescriptionRegex uses Go regexp syntax.
+// The amount bounds are inclusive.
+type RuleConditions struct {
+	DescriptionContains string `json:"description_contains,omitempty"`
+	DescriptionRegex    string `json:"description_regex,omitempty"`
+	MinAmount           Money  `json:"min_amount,omitempty"`
+	MaxAmount           Money  `json:"max_amount,omitempty"`
+	SourceID            int64  `json:"source_id,omitempty"`
+	Type                string `json:"type,omitempty"` // INCOME or EXPENSE
+}
+
+// RuleActions are applied to the transactions a rule matches.
+type RuleActions struct {
+	SetCategoryID  int64    `json:"set_category_id,omitempty"`
+	AddTags        []string `json:"add_tags,omitempty"`
+	SetDescription string   `json:"set_description,omitempty"`
+}
+
+// RuleMatch describes an existing transaction matched by a rule and what the rule changes.
+// The New fields are only set when the rule changes them.
+type RuleMatch struct {
+	TransactionID  int64     `json:"transaction_id"`
+	Timestamp      time.Time `json:"timestamp"`
+	Amount         Money     `json:"amount"`
+	Description    string    `json:"description"`
+	CategoryID     int64     `json:"category_id,omitempty"`
+	NewDescription string    `json:"new_description,omitempty"`
+	NewCategoryID  int64     `json:"new_category_id,omitempty"`
+	AddedTags      []string  `json:"added_tags,omitempty"`
+}
+
+// RuleService defines the interface for categorization rule operations.
+type RuleService interface {
+	InsertRule(ctx context.Context, rule *Rule, otx ...*sql.Tx) error
+	UpdateRule(ctx context.Context, rule *Rule, otx ...*sql.Tx) error
+	DeleteRule(ctx context.Context, ruleID int64, scopes []int64, otx ...*sql.Tx) error
+	GetRuleByID(ctx context.Context, ruleID int64, scopes []int64, otx ...*sql.Tx) (*Rule, error)
+	GetRulesByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]Rule, error)
+	ApplyRules(ctx context.Context, txn *Transaction, otx ...*sql.Tx) error
+	TestRule(ctx context.Context, rule Rule, filter TransactionFilter, otx ...*sql.Tx) ([]RuleMatch, error)
+	ApplyRule(ctx context.Context, rule Rule, filter TransactionFilter, otx ...*sql.Tx) ([]RuleMatch, error)
+}
@@ -0,0 +1,80 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SharedExpense attaches a payer and a split rule to an EXPENSE transaction of a group scope.
+// Amount and Currency are those of the transaction; the shares are recomputed from them.
+type SharedExpense struct {
+	TransactionID int64          `json:"transaction_id"`
+	ScopeID       int64          `json:"scope_id"`
+	PayerID       int64          `json:"payer_id"`
+	SplitMethod   string         `json:"split_method"`
+	Shares        []ExpenseShare `json:"shares"`
+	Amount        Money          `json:"amount"`
+	Currency      string         `json:"currency"`
+}
+
+// ExpenseShare is the part of a shared expense one member owes. Value is the input of the split
+// rule: an exact amount, a percentage or a number of shares; it is ignored by an equal split.
+// Amount is the resulting share of the expense.
+type ExpenseShare struct {
+	UserID int64   `json:"user_id"`
+	Value  float64 `json:"value,omitempty"`
+	Amount Money   `json:"amount"`
+}
+
+// Settlement is a payment from one member to another that settles shared expenses.
+type Settlement struct {
+	ID         int64     `json:"settlement_id"`
+	ScopeID    int64     `json:"scope_id"`
+	FromUserID int64     `json:"from_user_id"`
+	ToUserID   int64     `json:"to_user_id"`
+	Amount     Money     `json:"amount"`
+	Currency   string    `json:"currency"`
+	Note       string    `json:"note"`
+	SettledAt  time.Time `json:"settled_at"`
+	CreatedBy  int64     `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MemberBalance is a member's position in one currency. Net is what the member paid for shared
+// expenses less their shares, adjusted by settlements: positive means the member is owed money.
+type MemberBalance struct {
+	UserID   int64  `json:"user_id"`
+	Currency string `json:"currency"`
+	Paid     Money  `json:"paid"`
+	Share    Money  `json:"share"`
+	Sent     Money  `json:"settlements_sent"`
+	Received Money  `json:"settlements_received"`
+	Net      Money  `json:"net"`
+}
+
+// SettleUpPayment is one payment of the plan that brings every balance to zero.
+type SettleUpPayment struct {
+	FromUserID int64  `json:"from_user_id"`
+	ToUserID   int64  `json:"to_user_id"`
+	Currency   string `json:"currency"`
+	Amount     Money  `json:"amount"`
+}
+
+// GroupBalances is the net position of each member of a group and a plan to settle them.
+type GroupBalances struct {
+	GroupID  int64             `json:"group_id"`
+	Balances []MemberBalance   `json:"balances"`
+	SettleUp []SettleUpPayment `json:"settle_up"`
+}
+
+// SharedExpenseService defines the interface for shared expenses and settlements of group scopes.
+type SharedExpenseService interface {
+	SetSharedExpense(ctx context.Context, expense *SharedExpense, otx ...*sql.Tx) error
+	GetSharedExpense(ctx context.Context, transactionID int64, scopeID int64, otx ...*sql.Tx) (*SharedExpense, error)
+	DeleteSharedExpense(ctx context.Context, transactionID int64, scopeID int64, otx ...*sql.Tx) error
+	InsertSettlement(ctx context.Context, settlement *Settlement, otx ...*sql.Tx) error
+	GetSettlements(ctx context.Context, scopeID int64, otx ...*sql.Tx) ([]Settlement, error)
+	DeleteSettlement(ctx context.Context, settlementID int64, scopeID int64, otx ...*sql.Tx) error
+	GetBalances(ctx context.Context, scopeID int64, otx ...*sql.Tx) ([]MemberBalance, []SettleUpPayment, error)
+}
@@ -8,24 +8,43 @@ import (
 
 // Source struct as defined in your implementation.
 type Source struct {
-	ID        int64     `json:"source_id"`
-	UserID    int64     `json:"user_id"`
-	Name      string    `json:"name"`
-	Type      string    `json:"type"`
-	Balance   float64   `json:"balance"`
+	ID      int64  `json:"source_id"`
+	UserID  int64  `json:"user_id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Balance Money  `json:"balance"`
+	// OpeningBalance is the balance the source was created with.
+	// Balance is derived from it by applying every INCOME and EXPENSE recorded against the source.
+	OpeningBalance Money `json:"opening_balance"`
+	// Currency is the ISO 4217 code of the balance and of every transaction recorded against the source.
+	// It defaults to the scope's base currency and cannot be changed once the source exists.
+	Currency  string    `json:"currency"`
 	ScopeID   int64     `json:"scope_id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// SourceReconciliation reports how far a stored balance had drifted from the transaction history.
+type SourceReconciliation struct {
+	SourceID        int64 `json:"source_id"`
+	StoredBalance   Money `json:"stored_balance"`
+	ComputedBalance Money `json:"computed_balance"`
+	Drift           Money `json:"drift"`
+	Corrected       bool  `json:"corrected"`
+}
+
 // SourceService defines the interface for source operations.
 type SourceService interface {
 	InsertSource(ctx context.Context, source *Source, otx ...*sql.Tx) error
 	UpdateSource(ctx context.Context, source *Source, otx ...*sql.Tx) error
 
-	DeleteSource(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) error
+	DeleteSource(ctx context.Context, sourceID int64, reassignTo int64, scopes []int64, otx ...*sql.Tx) error
 	GetSourceByID(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (*Source, error)
 	GetScopedSources(ctx context.Context, page int, itemsPerPage int, scopes []int64, otx ...*sql.Tx) ([]Source, error)
 	GetSources(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]Source, error)
+	GetSourcesByCursor(ctx context.Context, scopes []int64, params CursorParams, otx ...*sql.Tx) ([]Source, CursorPage, error)
 	SourceIDExists(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (bool, error)
+	GetSourceCurrency(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (string, error)
+	AdjustBalance(ctx context.Context, sourceID int64, delta Money, otx ...*sql.Tx) error
+	ReconcileBalance(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (*SourceReconciliation, error)
 }
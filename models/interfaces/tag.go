@@ -27,5 +27,6 @@ type TagService interface {
 	DeleteTag(ctx context.Context, tagID int64, scopes []int64, otx ...*sql.Tx) error
 	GetTagByID(ctx context.Context, tagID int64, scopes []int64, otx ...*sql.Tx) (*Tag, error)
 	GetScopedTags(ctx context.Context, scopes []int64, pagination PaginationParams, otx ...*sql.Tx) ([]Tag, error)
+	GetTagsByCursor(ctx context.Context, scopes []int64, params CursorParams, otx ...*sql.Tx) ([]Tag, CursorPage, error)
 	GetTagByName(ctx context.Context, name string, scopes []int64, otx ...*sql.Tx) (*Tag, error)
 }
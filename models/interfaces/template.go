@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SeedTemplate is a named set of categories and sources that a new user's personal scope,
+// or a new group's scope, can start with.
+type SeedTemplate struct {
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" yaml:"description"`
+	Categories  []SeedCategory `json:"categories" yaml:"categories"`
+	Sources     []SeedSource   `json:"sources" yaml:"sources"`
+}
+
+// SeedCategory is a category of a template, with the subcategories to create under it.
+type SeedCategory struct {
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" yaml:"description"`
+	Icon        string         `json:"icon,omitempty" yaml:"icon"`
+	Children    []SeedCategory `json:"children,omitempty" yaml:"children"`
+}
+
+// SeedSource is a source of a template. Seeded sources start with a zero balance; an empty
+// currency means the base currency of the scope.
+type SeedSource struct {
+	Name     string `json:"name" yaml:"name"`
+	Type     string `json:"type" yaml:"type"`
+	Currency string `json:"currency,omitempty" yaml:"currency"`
+}
+
+type TemplateService interface {
+	ListTemplates() []SeedTemplate
+	GetTemplate(name string) (*SeedTemplate, error)
+	ApplyTemplate(ctx context.Context, name string, userID int64, scopeID int64, otx ...*sql.Tx) error
+}
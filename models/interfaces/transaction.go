@@ -13,10 +13,31 @@ type Transaction struct {
 	Tags        []string  `json:"tags"`
 	CategoryID  int64     `json:"category_id"`
 	Timestamp   time.Time `json:"timestamp"`
-	Amount      float64   `json:"amount"`
+	Amount      Money     `json:"amount"`
 	Type        string    `json:"type"`
 	Description string    `json:"description"`
 	ScopeID     int64     `json:"scope_id"`
+
+	// Currency is always the currency of the source; it is filled in when left empty.
+	Currency string `json:"currency"`
+
+	// Timestamp is when the transaction happened. Clients may set and change it, and it defaults to
+	// the time the transaction is recorded. CreatedAt is that time, set by the server.
+	CreatedAt time.Time `json:"created_at"`
+
+	// DestinationSourceID and TransferID are only set on the two legs of a TRANSFER.
+	DestinationSourceID int64 `json:"destination_source_id,omitempty"`
+	TransferID          int64 `json:"transfer_id,omitempty"`
+
+	// ImportBatchID is set on transactions created by a statement import.
+	ImportBatchID int64 `json:"import_batch_id,omitempty"`
+
+	// Splits are the lines of a split transaction. They carry its categories, so CategoryID is left empty.
+	Splits []TransactionSplit `json:"splits,omitempty"`
+
+	// RulesApplied is set when the categorization rules of the scope have already been applied,
+	// so that InsertTransaction does not apply them a second time.
+	RulesApplied bool `json:"-"`
 }
 
 type TransactionFilter struct {
@@ -28,20 +49,71 @@ type TransactionFilter struct {
 	Category     string
 	Type         string
 	Description  string
-	MinAmount    float64
-	MaxAmount    float64
+	MinAmount    Money
+	MaxAmount    Money
 	SortBy       string
 	SortOrder    string // "ASC" or "DESC"
 	Page         int
 	ItemsPerPage int
+
+	SourceID         int64
+	TransferID       int64
+	ExcludeTransfers bool // hide both legs of transfers between sources
+
+	IncludeDescendants bool // Category also matches its subcategories
+
+	// Location is the time zone of StartDate and EndDate given as plain dates (YYYY-MM-DD), UTC when nil.
+	// A plain EndDate includes the whole day. Reports by day, week and month bucket dates in it too.
+	Location *time.Location
+	// WeekStart is the first day of the weeks of a report by week.
+	WeekStart time.Weekday
+}
+
+// ReportRow is one bucket of an aggregated transaction report. Key is the category, tag,
+// source or user ID of the bucket, or the first day of its day, week or month.
+// Amounts are in Currency; Unconverted counts transactions left out for lack of an exchange rate.
+type ReportRow struct {
+	Key         string `json:"key"`
+	Name        string `json:"name,omitempty"`
+	Currency    string `json:"currency"`
+	Income      Money  `json:"income"`
+	Expense     Money  `json:"expense"`
+	Net         Money  `json:"net"`
+	Count       int    `json:"count"`
+	Unconverted int    `json:"unconverted,omitempty"`
+}
+
+// TransactionExport is a transaction with the names of its category, sources and tags
+// resolved, as written by the export.
+type TransactionExport struct {
+	ID                int64     `json:"transaction_id"`
+	Timestamp         time.Time `json:"timestamp"`
+	Type              string    `json:"type"`
+	Amount            Money     `json:"amount"`
+	Currency          string    `json:"currency"`
+	Description       string    `json:"description"`
+	Category          string    `json:"category"`
+	Source            string    `json:"source"`
+	DestinationSource string    `json:"destination_source,omitempty"`
+	Tags              []string  `json:"tags"`
+
+	SourceID            int64 `json:"source_id"`
+	DestinationSourceID int64 `json:"destination_source_id,omitempty"`
+	TransferID          int64 `json:"transfer_id,omitempty"`
 }
 
 // TransactionService defines the interface for transaction operations.
 type TransactionService interface {
 	GetTransactionsByFilter(ctx context.Context, filter TransactionFilter, otx ...*sql.Tx) ([]Transaction, error)
+	GetTransactionsByCursor(ctx context.Context, filter TransactionFilter, params CursorParams, otx ...*sql.Tx) ([]Transaction, CursorPage, error)
 
 	InsertTransaction(ctx context.Context, txn Transaction, otx ...*sql.Tx) error
+	InsertTransfer(ctx context.Context, txn Transaction, otx ...*sql.Tx) ([]Transaction, error)
 	UpdateTransaction(ctx context.Context, txn Transaction, otx ...*sql.Tx) error
 	DeleteTransaction(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) error
+	RestoreTransaction(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) error
 	GetTransactionByID(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) (*Transaction, error)
+	GetNetAmountBySource(ctx context.Context, sourceID int64, otx ...*sql.Tx) (Money, error)
+	GetTransactionReport(ctx context.Context, filter TransactionFilter, groupBy string, currency string, otx ...*sql.Tx) ([]ReportRow, error)
+	ExportTransactions(ctx context.Context, filter TransactionFilter, write func(TransactionExport) error, otx ...*sql.Tx) error
 }
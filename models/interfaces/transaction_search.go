@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TransactionSearchResult is a transaction found by a search, with its names resolved as in an
+// export. Highlights holds, for each field that matched (description, category, source or tags),
+// the matching text with the matched words wrapped in <mark> tags.
+type TransactionSearchResult struct {
+	TransactionExport
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// TransactionSearchService defines the interface for the text index of transactions and its queries.
+type TransactionSearchService interface {
+	SearchTransactions(ctx context.Context, filter TransactionFilter, query string, otx ...*sql.Tx) ([]TransactionSearchResult, int, error)
+	IndexTransaction(ctx context.Context, txn Transaction, otx ...*sql.Tx) error
+	IndexMissingTransactions(ctx context.Context, batchSize int, otx ...*sql.Tx) (int, error)
+}
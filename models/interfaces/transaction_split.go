@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TransactionSplit is one line of a split transaction. The lines of a transaction carry its
+// categories and add up to its amount, in the transaction's currency.
+type TransactionSplit struct {
+	ID            int64    `json:"split_id"`
+	TransactionID int64    `json:"transaction_id"`
+	CategoryID    int64    `json:"category_id"`
+	Amount        Money    `json:"amount"`
+	Description   string   `json:"description"`
+	Tags          []string `json:"tags"`
+}
+
+// TransactionSplitService defines the interface for storing the lines of split transactions.
+type TransactionSplitService interface {
+	ReplaceSplits(ctx context.Context, txn Transaction, otx ...*sql.Tx) error
+	GetSplitsByTransactionIDs(ctx context.Context, transactionIDs []int64, otx ...*sql.Tx) (map[int64][]TransactionSplit, error)
+}
@@ -17,6 +17,7 @@ type TransactionTag struct {
 // TransactionTagService defines the interface for operations on transaction tags.
 type TransactionTagService interface {
 	GetTagsByTransactionID(ctx context.Context, transactionID int64, otx ...*sql.Tx) ([]Tag, error)
+	GetTagsByTransactionIDs(ctx context.Context, transactionIDs []int64, otx ...*sql.Tx) (map[int64][]Tag, error)
 	InsertTransactionTag(ctx context.Context, transactionID, tagID int64, otx ...*sql.Tx) error
 	DeleteTransactionTag(ctx context.Context, transactionID, tagID int64, otx ...*sql.Tx) error
 	DeleteTagsFromTransaction(ctx context.Context, transactionID int64, otx ...*sql.Tx) error
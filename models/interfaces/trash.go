@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// TrashItem is a deleted transaction, category, source or tag that can still be restored.
+// Name is the name of a category, source or tag, and the description of a transaction.
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	ScopeID   int64     `json:"scope_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashPurge counts the items a purge removed for good.
+type TrashPurge struct {
+	Transactions int64 `json:"transactions"`
+	Categories   int64 `json:"categories"`
+	Sources      int64 `json:"sources"`
+	Tags         int64 `json:"tags"`
+}
+
+// TrashService defines the interface for listing, restoring and purging deleted items.
+type TrashService interface {
+	GetTrash(ctx context.Context, scopes []int64, itemType string, otx ...*sql.Tx) ([]TrashItem, error)
+	RestoreItem(ctx context.Context, itemType string, itemID int64, scopes []int64, otx ...*sql.Tx) error
+	PurgeTrash(ctx context.Context, deletedBefore time.Time, otx ...*sql.Tx) (*TrashPurge, error)
+}
@@ -17,6 +17,17 @@ type User struct {
 	Password  string    `json:"-"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Timezone is the IANA name of the zone the user's dates are read in, such as "Europe/Paris".
+	Timezone string `json:"timezone"`
+	// Locale is the BCP 47 language tag clients format numbers and dates with, such as "en-GB".
+	Locale string `json:"locale"`
+	// WeekStart is the first day of the user's weeks, such as "MONDAY".
+	WeekStart string `json:"week_start"`
+	// CurrencyDisplay is how clients show the currency of an amount: SYMBOL, CODE or NAME.
+	CurrencyDisplay string `json:"currency_display"`
+	// Template names the seed template applied to the personal scope when the user is created.
+	// Empty means the server's default template. It is not stored.
+	Template string `json:"template,omitempty"`
 }
 
 // Authboss methods,
@@ -43,6 +54,7 @@ type UserService interface {
 	DeleteUser(ctx context.Context, id int64, otx ...*sql.Tx) error
 	GetUserByID(ctx context.Context, id int64, otx ...*sql.Tx) (*User, error)
 	GetUserByUsername(ctx context.Context, username string, otx ...*sql.Tx) (*User, error)
+	GetUserByEmail(ctx context.Context, email string, otx ...*sql.Tx) (*User, error)
 	UserExists(ctx context.Context, username, email string, otx ...*sql.Tx) (bool, error)
 	UserIDExists(ctx context.Context, id int64, otx ...*sql.Tx) (bool, error)
 }
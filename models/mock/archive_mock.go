@@ -0,0 +1,22 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockArchiveModel is a mock implementation of the ArchiveService interface.
+type MockArchiveModel struct {
+	mock.Mock
+}
+
+// Ensure MockArchiveModel implements ArchiveService.
+var _ interfaces.ArchiveService = &MockArchiveModel{}
+
+func (m *MockArchiveModel) ExportArchive(ctx context.Context, userID int64, scopeID int64, write func(entity string, record interface{}) error, otx ...*sql.Tx) error {
+	args := m.Called(ctx, userID, scopeID, write, otx)
+	return args.Error(0)
+}
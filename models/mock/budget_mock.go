@@ -0,0 +1,57 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBudgetModel is a mock implementation of the BudgetService interface.
+type MockBudgetModel struct {
+	mock.Mock
+}
+
+// Ensure MockBudgetModel implements BudgetService.
+var _ interfaces.BudgetService = &MockBudgetModel{}
+
+func (m *MockBudgetModel) InsertBudget(ctx context.Context, budget *interfaces.Budget, otx ...*sql.Tx) error {
+	args := m.Called(ctx, budget, otx)
+	return args.Error(0)
+}
+
+func (m *MockBudgetModel) UpdateBudget(ctx context.Context, budget *interfaces.Budget, otx ...*sql.Tx) error {
+	args := m.Called(ctx, budget, otx)
+	return args.Error(0)
+}
+
+func (m *MockBudgetModel) DeleteBudget(ctx context.Context, budgetID int64, scopes []int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, budgetID, scopes, otx)
+	return args.Error(0)
+}
+
+func (m *MockBudgetModel) GetBudgetByID(ctx context.Context, budgetID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Budget, error) {
+	args := m.Called(ctx, budgetID, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Budget), args.Error(1)
+}
+
+func (m *MockBudgetModel) GetBudgetsByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.Budget, error) {
+	args := m.Called(ctx, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.Budget), args.Error(1)
+}
+
+func (m *MockBudgetModel) GetBudgetStatus(ctx context.Context, scopes []int64, asOf time.Time, location *time.Location, weekStart time.Weekday, otx ...*sql.Tx) ([]interfaces.BudgetStatus, error) {
+	args := m.Called(ctx, scopes, asOf, location, weekStart, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.BudgetStatus), args.Error(1)
+}
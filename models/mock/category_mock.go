@@ -36,8 +36,8 @@ func (m *MockCategoryModel) GetPagedCategories(ctx context.Context, page int, it
 	return args.Get(0).([]interfaces.Category), args.Error(1)
 }
 
-func (m *MockCategoryModel) DeleteCategory(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) error {
-	args := m.Called(ctx, categoryID, scopes, otx)
+func (m *MockCategoryModel) DeleteCategory(ctx context.Context, categoryID int64, reassignTo int64, scopes []int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, categoryID, reassignTo, scopes, otx)
 	return args.Error(0)
 }
 
@@ -51,11 +51,32 @@ func (m *MockCategoryModel) GetScopedCategories(ctx context.Context, page int, i
 	return args.Get(0).([]interfaces.Category), args.Error(1)
 }
 
+func (m *MockCategoryModel) GetCategoriesByCursor(ctx context.Context, scopes []int64, params interfaces.CursorParams, otx ...*sql.Tx) ([]interfaces.Category, interfaces.CursorPage, error) {
+	args := m.Called(ctx, scopes, params, otx)
+	return args.Get(0).([]interfaces.Category), args.Get(1).(interfaces.CursorPage), args.Error(2)
+}
+
 func (m *MockCategoryModel) CategoryIDExists(ctx context.Context, categoryID int64, scopes []int64, otx ...*sql.Tx) (bool, error) {
 	args := m.Called(ctx, categoryID, scopes, otx)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockCategoryModel) GetCategoryTree(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.CategoryNode, error) {
+	args := m.Called(ctx, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.CategoryNode), args.Error(1)
+}
+
+func (m *MockCategoryModel) RollUpReport(ctx context.Context, report []interfaces.ReportRow, level int, scopes []int64, otx ...*sql.Tx) ([]interfaces.ReportRow, error) {
+	args := m.Called(ctx, report, level, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.ReportRow), args.Error(1)
+}
+
 // Idiomatic interface compliance check.
 // Ensure CategoryModel implements CategoryService
 var _ interfaces.CategoryService = &MockCategoryModel{}
@@ -0,0 +1,51 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCurrencyModel is a mock implementation of the CurrencyService interface.
+type MockCurrencyModel struct {
+	mock.Mock
+}
+
+// Ensure MockCurrencyModel implements CurrencyService.
+var _ interfaces.CurrencyService = &MockCurrencyModel{}
+
+func (m *MockCurrencyModel) UpsertExchangeRate(ctx context.Context, rate *interfaces.ExchangeRate, otx ...*sql.Tx) error {
+	args := m.Called(ctx, rate, otx)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyModel) ImportExchangeRates(ctx context.Context, rates []interfaces.ExchangeRate, otx ...*sql.Tx) (int, error) {
+	args := m.Called(ctx, rates, otx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCurrencyModel) DeleteExchangeRate(ctx context.Context, scopeID int64, fromCurrency, toCurrency string, rateDate time.Time, otx ...*sql.Tx) error {
+	args := m.Called(ctx, scopeID, fromCurrency, toCurrency, rateDate, otx)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyModel) GetExchangeRates(ctx context.Context, scopes []int64, fromCurrency, toCurrency string, otx ...*sql.Tx) ([]interfaces.ExchangeRate, error) {
+	args := m.Called(ctx, scopes, fromCurrency, toCurrency, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.ExchangeRate), args.Error(1)
+}
+
+func (m *MockCurrencyModel) GetExchangeRate(ctx context.Context, scopeID int64, fromCurrency, toCurrency string, date time.Time, otx ...*sql.Tx) (float64, error) {
+	args := m.Called(ctx, scopeID, fromCurrency, toCurrency, date, otx)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockCurrencyModel) GetBaseCurrency(ctx context.Context, scopeID int64, otx ...*sql.Tx) (string, error) {
+	args := m.Called(ctx, scopeID, otx)
+	return args.String(0), args.Error(1)
+}
@@ -0,0 +1,56 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockGroupInvitationModel is a mock implementation of the GroupInvitationService interface.
+type MockGroupInvitationModel struct {
+	mock.Mock
+}
+
+var _ interfaces.GroupInvitationService = &MockGroupInvitationModel{}
+
+func (m *MockGroupInvitationModel) InsertInvitation(ctx context.Context, invitation *interfaces.GroupInvitation, otx ...*sql.Tx) error {
+	args := m.Called(ctx, invitation, otx)
+	return args.Error(0)
+}
+
+func (m *MockGroupInvitationModel) GetInvitationByID(ctx context.Context, invitationID int64, otx ...*sql.Tx) (*interfaces.GroupInvitation, error) {
+	args := m.Called(ctx, invitationID, otx)
+	return args.Get(0).(*interfaces.GroupInvitation), args.Error(1)
+}
+
+func (m *MockGroupInvitationModel) GetInvitationsByGroup(ctx context.Context, groupID int64, status string, otx ...*sql.Tx) ([]interfaces.GroupInvitation, error) {
+	args := m.Called(ctx, groupID, status, otx)
+	return args.Get(0).([]interfaces.GroupInvitation), args.Error(1)
+}
+
+func (m *MockGroupInvitationModel) GetInvitationsByInvitee(ctx context.Context, inviteeID int64, status string, otx ...*sql.Tx) ([]interfaces.GroupInvitation, error) {
+	args := m.Called(ctx, inviteeID, status, otx)
+	return args.Get(0).([]interfaces.GroupInvitation), args.Error(1)
+}
+
+func (m *MockGroupInvitationModel) AcceptInvitation(ctx context.Context, invitationID int64, inviteeID int64, otx ...*sql.Tx) (*interfaces.GroupInvitation, error) {
+	args := m.Called(ctx, invitationID, inviteeID, otx)
+	return args.Get(0).(*interfaces.GroupInvitation), args.Error(1)
+}
+
+func (m *MockGroupInvitationModel) DeclineInvitation(ctx context.Context, invitationID int64, inviteeID int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, invitationID, inviteeID, otx)
+	return args.Error(0)
+}
+
+func (m *MockGroupInvitationModel) CancelInvitation(ctx context.Context, invitationID int64, groupID int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, invitationID, groupID, otx)
+	return args.Error(0)
+}
+
+func (m *MockGroupInvitationModel) DeleteInvitationsByGroup(ctx context.Context, groupID int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, groupID, otx)
+	return args.Error(0)
+}
@@ -40,5 +40,17 @@ func (m *MockGroupModel) GetGroupByScope(ctx context.Context, scopeID int64, req
 	return args.Get(0).(*interfaces.Group), args.Error(1)
 }
 
+// Implementing GetGroupsByUser method of GroupService interface
+func (m *MockGroupModel) GetGroupsByUser(ctx context.Context, userID int64, otx ...*sql.Tx) ([]interfaces.GroupMembership, error) {
+	args := m.Called(ctx, userID, otx)
+	return args.Get(0).([]interfaces.GroupMembership), args.Error(1)
+}
+
+// Implementing GetGroupMembers method of GroupService interface
+func (m *MockGroupModel) GetGroupMembers(ctx context.Context, groupID int64, requestingUserID int64, otx ...*sql.Tx) ([]interfaces.GroupMember, error) {
+	args := m.Called(ctx, groupID, requestingUserID, otx)
+	return args.Get(0).([]interfaces.GroupMember), args.Error(1)
+}
+
 // Ensure MockGroupModel implements GroupService interface
 var _ interfaces.GroupService = &MockGroupModel{}
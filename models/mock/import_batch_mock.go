@@ -0,0 +1,54 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockImportBatchModel is a mock implementation of the ImportBatchService interface.
+type MockImportBatchModel struct {
+	mock.Mock
+}
+
+// Ensure MockImportBatchModel implements ImportBatchService.
+var _ interfaces.ImportBatchService = &MockImportBatchModel{}
+
+func (m *MockImportBatchModel) PreviewImport(ctx context.Context, batch interfaces.ImportBatch, rows []interfaces.ImportRow, otx ...*sql.Tx) ([]interfaces.ImportRow, error) {
+	args := m.Called(ctx, batch, rows, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.ImportRow), args.Error(1)
+}
+
+func (m *MockImportBatchModel) CommitImport(ctx context.Context, batch *interfaces.ImportBatch, rows []interfaces.ImportRow, options interfaces.ImportCommitOptions, otx ...*sql.Tx) error {
+	args := m.Called(ctx, batch, rows, options, otx)
+	return args.Error(0)
+}
+
+func (m *MockImportBatchModel) UndoImport(ctx context.Context, batchID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.ImportBatch, error) {
+	args := m.Called(ctx, batchID, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.ImportBatch), args.Error(1)
+}
+
+func (m *MockImportBatchModel) GetImportBatchByID(ctx context.Context, batchID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.ImportBatch, error) {
+	args := m.Called(ctx, batchID, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.ImportBatch), args.Error(1)
+}
+
+func (m *MockImportBatchModel) GetImportBatchesByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.ImportBatch, error) {
+	args := m.Called(ctx, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.ImportBatch), args.Error(1)
+}
@@ -0,0 +1,70 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRecurringTransactionModel is a mock implementation of the RecurringTransactionService interface.
+type MockRecurringTransactionModel struct {
+	mock.Mock
+}
+
+// Ensure MockRecurringTransactionModel implements RecurringTransactionService.
+var _ interfaces.RecurringTransactionService = &MockRecurringTransactionModel{}
+
+func (m *MockRecurringTransactionModel) InsertRecurringTransaction(ctx context.Context, rt *interfaces.RecurringTransaction, otx ...*sql.Tx) error {
+	args := m.Called(ctx, rt, otx)
+	return args.Error(0)
+}
+
+func (m *MockRecurringTransactionModel) UpdateRecurringTransaction(ctx context.Context, rt *interfaces.RecurringTransaction, otx ...*sql.Tx) error {
+	args := m.Called(ctx, rt, otx)
+	return args.Error(0)
+}
+
+func (m *MockRecurringTransactionModel) DeleteRecurringTransaction(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, recurringID, scopes, otx)
+	return args.Error(0)
+}
+
+func (m *MockRecurringTransactionModel) GetRecurringTransactionByID(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.RecurringTransaction, error) {
+	args := m.Called(ctx, recurringID, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.RecurringTransaction), args.Error(1)
+}
+
+func (m *MockRecurringTransactionModel) GetRecurringTransactionsByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.RecurringTransaction, error) {
+	args := m.Called(ctx, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.RecurringTransaction), args.Error(1)
+}
+
+func (m *MockRecurringTransactionModel) SkipNextOccurrence(ctx context.Context, recurringID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.RecurringTransaction, error) {
+	args := m.Called(ctx, recurringID, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.RecurringTransaction), args.Error(1)
+}
+
+func (m *MockRecurringTransactionModel) GetDueRecurringTransactions(ctx context.Context, asOf time.Time, otx ...*sql.Tx) ([]interfaces.RecurringTransaction, error) {
+	args := m.Called(ctx, asOf, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.RecurringTransaction), args.Error(1)
+}
+
+func (m *MockRecurringTransactionModel) MaterializeOccurrences(ctx context.Context, recurringID int64, asOf time.Time, catchUp bool, otx ...*sql.Tx) (int, error) {
+	args := m.Called(ctx, recurringID, asOf, catchUp, otx)
+	return args.Int(0), args.Error(1)
+}
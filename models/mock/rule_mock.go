@@ -0,0 +1,69 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRuleModel is a mock implementation of the RuleService interface.
+type MockRuleModel struct {
+	mock.Mock
+}
+
+// Ensure MockRuleModel implements RuleService.
+var _ interfaces.RuleService = &MockRuleModel{}
+
+func (m *MockRuleModel) InsertRule(ctx context.Context, rule *interfaces.Rule, otx ...*sql.Tx) error {
+	args := m.Called(ctx, rule, otx)
+	return args.Error(0)
+}
+
+func (m *MockRuleModel) UpdateRule(ctx context.Context, rule *interfaces.Rule, otx ...*sql.Tx) error {
+	args := m.Called(ctx, rule, otx)
+	return args.Error(0)
+}
+
+func (m *MockRuleModel) DeleteRule(ctx context.Context, ruleID int64, scopes []int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, ruleID, scopes, otx)
+	return args.Error(0)
+}
+
+func (m *MockRuleModel) GetRuleByID(ctx context.Context, ruleID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Rule, error) {
+	args := m.Called(ctx, ruleID, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Rule), args.Error(1)
+}
+
+func (m *MockRuleModel) GetRulesByScope(ctx context.Context, scopes []int64, otx ...*sql.Tx) ([]interfaces.Rule, error) {
+	args := m.Called(ctx, scopes, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.Rule), args.Error(1)
+}
+
+func (m *MockRuleModel) ApplyRules(ctx context.Context, txn *interfaces.Transaction, otx ...*sql.Tx) error {
+	args := m.Called(ctx, txn, otx)
+	return args.Error(0)
+}
+
+func (m *MockRuleModel) TestRule(ctx context.Context, rule interfaces.Rule, filter interfaces.TransactionFilter, otx ...*sql.Tx) ([]interfaces.RuleMatch, error) {
+	args := m.Called(ctx, rule, filter, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.RuleMatch), args.Error(1)
+}
+
+func (m *MockRuleModel) ApplyRule(ctx context.Context, rule interfaces.Rule, filter interfaces.TransactionFilter, otx ...*sql.Tx) ([]interfaces.RuleMatch, error) {
+	args := m.Called(ctx, rule, filter, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.RuleMatch), args.Error(1)
+}
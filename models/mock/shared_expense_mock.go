@@ -0,0 +1,61 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSharedExpenseModel is a mock implementation of the SharedExpenseService interface.
+type MockSharedExpenseModel struct {
+	mock.Mock
+}
+
+// Ensure MockSharedExpenseModel implements SharedExpenseService.
+var _ interfaces.SharedExpenseService = &MockSharedExpenseModel{}
+
+func (m *MockSharedExpenseModel) SetSharedExpense(ctx context.Context, expense *interfaces.SharedExpense, otx ...*sql.Tx) error {
+	args := m.Called(ctx, expense, otx)
+	return args.Error(0)
+}
+
+func (m *MockSharedExpenseModel) GetSharedExpense(ctx context.Context, transactionID int64, scopeID int64, otx ...*sql.Tx) (*interfaces.SharedExpense, error) {
+	args := m.Called(ctx, transactionID, scopeID, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.SharedExpense), args.Error(1)
+}
+
+func (m *MockSharedExpenseModel) DeleteSharedExpense(ctx context.Context, transactionID int64, scopeID int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, transactionID, scopeID, otx)
+	return args.Error(0)
+}
+
+func (m *MockSharedExpenseModel) InsertSettlement(ctx context.Context, settlement *interfaces.Settlement, otx ...*sql.Tx) error {
+	args := m.Called(ctx, settlement, otx)
+	return args.Error(0)
+}
+
+func (m *MockSharedExpenseModel) GetSettlements(ctx context.Context, scopeID int64, otx ...*sql.Tx) ([]interfaces.Settlement, error) {
+	args := m.Called(ctx, scopeID, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.Settlement), args.Error(1)
+}
+
+func (m *MockSharedExpenseModel) DeleteSettlement(ctx context.Context, settlementID int64, scopeID int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, settlementID, scopeID, otx)
+	return args.Error(0)
+}
+
+func (m *MockSharedExpenseModel) GetBalances(ctx context.Context, scopeID int64, otx ...*sql.Tx) ([]interfaces.MemberBalance, []interfaces.SettleUpPayment, error) {
+	args := m.Called(ctx, scopeID, otx)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]interfaces.MemberBalance), args.Get(1).([]interfaces.SettleUpPayment), args.Error(2)
+}
@@ -26,8 +26,8 @@ func (m *MockSourceModel) UpdateSource(ctx context.Context, source *interfaces.S
 	return args.Error(0)
 }
 
-func (m *MockSourceModel) DeleteSource(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) error {
-	args := m.Called(ctx, sourceID, scopes, otx)
+func (m *MockSourceModel) DeleteSource(ctx context.Context, sourceID int64, reassignTo int64, scopes []int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, sourceID, reassignTo, scopes, otx)
 	return args.Error(0)
 }
 
@@ -46,11 +46,31 @@ func (m *MockSourceModel) GetSources(ctx context.Context, scopes []int64, otx ..
 	return args.Get(0).([]interfaces.Source), args.Error(1)
 }
 
+func (m *MockSourceModel) GetSourcesByCursor(ctx context.Context, scopes []int64, params interfaces.CursorParams, otx ...*sql.Tx) ([]interfaces.Source, interfaces.CursorPage, error) {
+	args := m.Called(ctx, scopes, params, otx)
+	return args.Get(0).([]interfaces.Source), args.Get(1).(interfaces.CursorPage), args.Error(2)
+}
+
 func (m *MockSourceModel) SourceIDExists(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (bool, error) {
 	args := m.Called(ctx, sourceID, scopes, otx)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockSourceModel) GetSourceCurrency(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (string, error) {
+	args := m.Called(ctx, sourceID, scopes, otx)
+	return args.String(0), args.Error(1)
+}
+
 // Idiomatic interface compliance check.
 // Ensure SourceModel implements SourceService
 var _ interfaces.SourceService = &MockSourceModel{}
+
+func (m *MockSourceModel) AdjustBalance(ctx context.Context, sourceID int64, delta interfaces.Money, otx ...*sql.Tx) error {
+	args := m.Called(ctx, sourceID, delta, otx)
+	return args.Error(0)
+}
+
+func (m *MockSourceModel) ReconcileBalance(ctx context.Context, sourceID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.SourceReconciliation, error) {
+	args := m.Called(ctx, sourceID, scopes, otx)
+	return args.Get(0).(*interfaces.SourceReconciliation), args.Error(1)
+}
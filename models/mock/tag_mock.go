@@ -43,6 +43,12 @@ func (m *MockTagModel) GetScopedTags(ctx context.Context, scopes []int64, pagina
 	return args.Get(0).([]interfaces.Tag), args.Error(1)
 }
 
+// Mock implementation of GetTagsByCursor
+func (m *MockTagModel) GetTagsByCursor(ctx context.Context, scopes []int64, params interfaces.CursorParams, otx ...*sql.Tx) ([]interfaces.Tag, interfaces.CursorPage, error) {
+	args := m.Called(ctx, scopes, params, otx)
+	return args.Get(0).([]interfaces.Tag), args.Get(1).(interfaces.CursorPage), args.Error(2)
+}
+
 // Mock implementation of GetTagByNameNew
 func (m *MockTagModel) GetTagByName(ctx context.Context, name string, scopes []int64, otx ...*sql.Tx) (*interfaces.Tag, error) {
 	args := m.Called(ctx, name, scopes, otx)
@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTemplateModel is a mock implementation of the TemplateService interface.
+type MockTemplateModel struct {
+	mock.Mock
+}
+
+// Ensure MockTemplateModel implements TemplateService.
+var _ interfaces.TemplateService = &MockTemplateModel{}
+
+func (m *MockTemplateModel) ListTemplates() []interfaces.SeedTemplate {
+	args := m.Called()
+	return args.Get(0).([]interfaces.SeedTemplate)
+}
+
+func (m *MockTemplateModel) GetTemplate(name string) (*interfaces.SeedTemplate, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.SeedTemplate), args.Error(1)
+}
+
+func (m *MockTemplateModel) ApplyTemplate(ctx context.Context, name string, userID int64, scopeID int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, name, userID, scopeID, otx)
+	return args.Error(0)
+}
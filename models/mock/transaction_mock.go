@@ -21,11 +21,24 @@ func (m *MockTransactionModel) GetTransactionsByFilter(ctx context.Context, filt
 	return args.Get(0).([]interfaces.Transaction), args.Error(1)
 }
 
+func (m *MockTransactionModel) GetTransactionsByCursor(ctx context.Context, filter interfaces.TransactionFilter, params interfaces.CursorParams, otx ...*sql.Tx) ([]interfaces.Transaction, interfaces.CursorPage, error) {
+	args := m.Called(ctx, filter, params, otx)
+	return args.Get(0).([]interfaces.Transaction), args.Get(1).(interfaces.CursorPage), args.Error(2)
+}
+
 func (m *MockTransactionModel) InsertTransaction(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
 	args := m.Called(ctx, txn, otx)
 	return args.Error(0)
 }
 
+func (m *MockTransactionModel) InsertTransfer(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) ([]interfaces.Transaction, error) {
+	args := m.Called(ctx, txn, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.Transaction), args.Error(1)
+}
+
 func (m *MockTransactionModel) UpdateTransaction(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
 	args := m.Called(ctx, txn, otx)
 	return args.Error(0)
@@ -36,7 +49,30 @@ func (m *MockTransactionModel) DeleteTransaction(ctx context.Context, transactio
 	return args.Error(0)
 }
 
+func (m *MockTransactionModel) RestoreTransaction(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, transactionID, scopes, otx)
+	return args.Error(0)
+}
+
 func (m *MockTransactionModel) GetTransactionByID(ctx context.Context, transactionID int64, scopes []int64, otx ...*sql.Tx) (*interfaces.Transaction, error) {
 	args := m.Called(ctx, transactionID, scopes, otx)
 	return args.Get(0).(*interfaces.Transaction), args.Error(1)
 }
+
+func (m *MockTransactionModel) GetNetAmountBySource(ctx context.Context, sourceID int64, otx ...*sql.Tx) (interfaces.Money, error) {
+	args := m.Called(ctx, sourceID, otx)
+	return args.Get(0).(interfaces.Money), args.Error(1)
+}
+
+func (m *MockTransactionModel) GetTransactionReport(ctx context.Context, filter interfaces.TransactionFilter, groupBy string, currency string, otx ...*sql.Tx) ([]interfaces.ReportRow, error) {
+	args := m.Called(ctx, filter, groupBy, currency, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.ReportRow), args.Error(1)
+}
+
+func (m *MockTransactionModel) ExportTransactions(ctx context.Context, filter interfaces.TransactionFilter, write func(interfaces.TransactionExport) error, otx ...*sql.Tx) error {
+	args := m.Called(ctx, filter, write, otx)
+	return args.Error(0)
+}
@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTransactionSearchModel is a mock implementation of the TransactionSearchService interface.
+type MockTransactionSearchModel struct {
+	mock.Mock
+}
+
+// Ensure MockTransactionSearchModel implements TransactionSearchService.
+var _ interfaces.TransactionSearchService = &MockTransactionSearchModel{}
+
+func (m *MockTransactionSearchModel) SearchTransactions(ctx context.Context, filter interfaces.TransactionFilter, query string, otx ...*sql.Tx) ([]interfaces.TransactionSearchResult, int, error) {
+	args := m.Called(ctx, filter, query, otx)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]interfaces.TransactionSearchResult), args.Int(1), args.Error(2)
+}
+
+func (m *MockTransactionSearchModel) IndexTransaction(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
+	args := m.Called(ctx, txn, otx)
+	return args.Error(0)
+}
+
+func (m *MockTransactionSearchModel) IndexMissingTransactions(ctx context.Context, batchSize int, otx ...*sql.Tx) (int, error) {
+	args := m.Called(ctx, batchSize, otx)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,30 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTransactionSplitModel is a mock implementation of the TransactionSplitService interface.
+type MockTransactionSplitModel struct {
+	mock.Mock
+}
+
+// Ensure MockTransactionSplitModel implements TransactionSplitService.
+var _ interfaces.TransactionSplitService = &MockTransactionSplitModel{}
+
+func (m *MockTransactionSplitModel) ReplaceSplits(ctx context.Context, txn interfaces.Transaction, otx ...*sql.Tx) error {
+	args := m.Called(ctx, txn, otx)
+	return args.Error(0)
+}
+
+func (m *MockTransactionSplitModel) GetSplitsByTransactionIDs(ctx context.Context, transactionIDs []int64, otx ...*sql.Tx) (map[int64][]interfaces.TransactionSplit, error) {
+	args := m.Called(ctx, transactionIDs, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64][]interfaces.TransactionSplit), args.Error(1)
+}
@@ -19,6 +19,15 @@ func (m *MockTransactionTagModel) GetTagsByTransactionID(ctx context.Context, tr
 	return args.Get(0).([]interfaces.Tag), args.Error(1)
 }
 
+// GetTagsByTransactionIDs mocks the GetTagsByTransactionIDs method.
+func (m *MockTransactionTagModel) GetTagsByTransactionIDs(ctx context.Context, transactionIDs []int64, otx ...*sql.Tx) (map[int64][]interfaces.Tag, error) {
+	args := m.Called(ctx, transactionIDs, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64][]interfaces.Tag), args.Error(1)
+}
+
 // InsertTransactionTag mocks the InsertTransactionTag method.
 func (m *MockTransactionTagModel) InsertTransactionTag(ctx context.Context, transactionID, tagID int64, otx ...*sql.Tx) error {
 	args := m.Called(ctx, transactionID, tagID, otx)
@@ -0,0 +1,39 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"xspends/models/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTrashModel is a mock implementation of the TrashService interface.
+type MockTrashModel struct {
+	mock.Mock
+}
+
+// Ensure MockTrashModel implements TrashService.
+var _ interfaces.TrashService = &MockTrashModel{}
+
+func (m *MockTrashModel) GetTrash(ctx context.Context, scopes []int64, itemType string, otx ...*sql.Tx) ([]interfaces.TrashItem, error) {
+	args := m.Called(ctx, scopes, itemType, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.TrashItem), args.Error(1)
+}
+
+func (m *MockTrashModel) RestoreItem(ctx context.Context, itemType string, itemID int64, scopes []int64, otx ...*sql.Tx) error {
+	args := m.Called(ctx, itemType, itemID, scopes, otx)
+	return args.Error(0)
+}
+
+func (m *MockTrashModel) PurgeTrash(ctx context.Context, deletedBefore time.Time, otx ...*sql.Tx) (*interfaces.TrashPurge, error) {
+	args := m.Called(ctx, deletedBefore, otx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.TrashPurge), args.Error(1)
+}
@@ -43,6 +43,12 @@ func (m *MockUserModel) GetUserByUsername(ctx context.Context, username string,
 	return args.Get(0).(*interfaces.User), args.Error(1)
 }
 
+// GetUserByEmail mocks the GetUserByEmail method
+func (m *MockUserModel) GetUserByEmail(ctx context.Context, email string, otx ...*sql.Tx) (*interfaces.User, error) {
+	args := m.Called(ctx, email, otx)
+	return args.Get(0).(*interfaces.User), args.Error(1)
+}
+
 // UserExists mocks the UserExists method
 func (m *MockUserModel) UserExists(ctx context.Context, username, email string, otx ...*sql.Tx) (bool, error) {
 	args := m.Called(ctx, username, email, otx)
@@ -0,0 +1,120 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+	"xspends/models/impl"
+)
+
+const defaultInterval = time.Hour
+
+// RecurringScheduler periodically materializes due occurrences of recurring transactions.
+// Each schedule is handled by RecurringTransactionModel.MaterializeOccurrences, which is
+// idempotent, so restarts and several server processes running the scheduler are safe.
+type RecurringScheduler struct {
+	Interval time.Duration
+	// CatchUp creates every missed occurrence; otherwise only the latest due one is created.
+	CatchUp bool
+	now     func() time.Time
+}
+
+// NewRecurringSchedulerFromEnv reads RECURRING_SCHEDULER_INTERVAL (a duration, "0" disables the
+// scheduler, defaults to one hour) and RECURRING_CATCH_UP (a bool, defaults to true).
+func NewRecurringSchedulerFromEnv() *RecurringScheduler {
+	s := &RecurringScheduler{Interval: defaultInterval, CatchUp: true, now: time.Now}
+
+	if interval := os.Getenv("RECURRING_SCHEDULER_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil {
+			s.Interval = d
+		} else {
+			log.Printf("[RecurringScheduler] Invalid RECURRING_SCHEDULER_INTERVAL %q, using %v", interval, defaultInterval)
+		}
+	}
+	if catchUp := os.Getenv("RECURRING_CATCH_UP"); catchUp != "" {
+		if b, err := strconv.ParseBool(catchUp); err == nil {
+			s.CatchUp = b
+		} else {
+			log.Printf("[RecurringScheduler] Invalid RECURRING_CATCH_UP %q, catching up", catchUp)
+		}
+	}
+	return s
+}
+
+// Start runs the scheduler in the background until ctx is cancelled.
+// The first run happens immediately so occurrences missed while the server was down are handled at startup.
+func (s *RecurringScheduler) Start(ctx context.Context) {
+	if s.Interval <= 0 {
+		log.Println("[RecurringScheduler] Disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			s.RunOnce(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce materializes every due recurring transaction and returns how many transactions were created.
+// A failing schedule is logged and does not stop the others.
+func (s *RecurringScheduler) RunOnce(ctx context.Context) int {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	asOf := now()
+
+	model := impl.GetModelsService().RecurringTransactionModel
+	due, err := model.GetDueRecurringTransactions(ctx, asOf)
+	if err != nil {
+		log.Printf("[RecurringScheduler] Error: %v", err)
+		return 0
+	}
+
+	created := 0
+	for _, rt := range due {
+		n, err := model.MaterializeOccurrences(ctx, rt.ID, asOf, s.CatchUp)
+		if err != nil {
+			log.Printf("[RecurringScheduler] Error materializing %d: %v", rt.ID, err)
+			continue
+		}
+		created += n
+	}
+	if created > 0 {
+		log.Printf("[RecurringScheduler] Created %d transactions", created)
+	}
+	return created
+}
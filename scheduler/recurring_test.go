@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewRecurringSchedulerFromEnv(t *testing.T) {
+	t.Setenv("RECURRING_SCHEDULER_INTERVAL", "15m")
+	t.Setenv("RECURRING_CATCH_UP", "false")
+	s := NewRecurringSchedulerFromEnv()
+	assert.Equal(t, 15*time.Minute, s.Interval)
+	assert.False(t, s.CatchUp)
+
+	t.Setenv("RECURRING_SCHEDULER_INTERVAL", "soon")
+	t.Setenv("RECURRING_CATCH_UP", "")
+	s = NewRecurringSchedulerFromEnv()
+	assert.Equal(t, defaultInterval, s.Interval)
+	assert.True(t, s.CatchUp)
+}
+
+func TestRunOnce(t *testing.T) {
+	mockRecurringModel := new(xmock.MockRecurringTransactionModel)
+	impl.ModelsService = &impl.ModelsServiceContainer{RecurringTransactionModel: mockRecurringModel}
+
+	now := time.Date(2024, 4, 15, 8, 0, 0, 0, time.UTC)
+	s := &RecurringScheduler{Interval: time.Hour, CatchUp: true, now: func() time.Time { return now }}
+
+	mockRecurringModel.On("GetDueRecurringTransactions", mock.Anything, now, mock.Anything).
+		Return([]interfaces.RecurringTransaction{{ID: 1}, {ID: 2}, {ID: 3}}, nil).Once()
+	mockRecurringModel.On("MaterializeOccurrences", mock.Anything, int64(1), now, true, mock.Anything).Return(3, nil).Once()
+	// A failing schedule does not stop the others
+	mockRecurringModel.On("MaterializeOccurrences", mock.Anything, int64(2), now, true, mock.Anything).Return(0, errors.New("db error")).Once()
+	mockRecurringModel.On("MaterializeOccurrences", mock.Anything, int64(3), now, true, mock.Anything).Return(1, nil).Once()
+
+	assert.Equal(t, 4, s.RunOnce(context.Background()))
+	mockRecurringModel.AssertExpectations(t)
+}
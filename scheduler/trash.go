@@ -0,0 +1,111 @@
+/*
+MIT License
+
+Copyright (c) 2023 Narayan Babu
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+)
+
+const (
+	defaultPurgeInterval = 24 * time.Hour
+	defaultRetentionDays = 30
+	retentionDayDuration = 24 * time.Hour
+)
+
+// TrashPurger periodically removes items that have been in the trash for longer than the
+// retention period. Purging only deletes rows past the cutoff, so several server processes
+// running it at once is harmless.
+type TrashPurger struct {
+	Interval  time.Duration
+	Retention time.Duration
+	now       func() time.Time
+}
+
+// NewTrashPurgerFromEnv reads TRASH_PURGE_INTERVAL (a duration, "0" disables purging, defaults
+// to a day) and TRASH_RETENTION_DAYS (how long deleted items can be restored, defaults to 30).
+func NewTrashPurgerFromEnv() *TrashPurger {
+	p := &TrashPurger{Interval: defaultPurgeInterval, Retention: defaultRetentionDays * retentionDayDuration, now: time.Now}
+
+	if interval := os.Getenv("TRASH_PURGE_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil {
+			p.Interval = d
+		} else {
+			log.Printf("[TrashPurger] Invalid TRASH_PURGE_INTERVAL %q, using %v", interval, defaultPurgeInterval)
+		}
+	}
+	if days := os.Getenv("TRASH_RETENTION_DAYS"); days != "" {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			p.Retention = time.Duration(n) * retentionDayDuration
+		} else {
+			log.Printf("[TrashPurger] Invalid TRASH_RETENTION_DAYS %q, keeping %d days", days, defaultRetentionDays)
+		}
+	}
+	return p
+}
+
+// Start runs the purger in the background until ctx is cancelled, starting with an immediate run.
+func (p *TrashPurger) Start(ctx context.Context) {
+	if p.Interval <= 0 {
+		log.Println("[TrashPurger] Disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		for {
+			p.RunOnce(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce purges the items deleted before the retention period and returns what was removed.
+func (p *TrashPurger) RunOnce(ctx context.Context) *interfaces.TrashPurge {
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+
+	purged, err := impl.GetModelsService().TrashModel.PurgeTrash(ctx, now().Add(-p.Retention))
+	if err != nil {
+		log.Printf("[TrashPurger] Error: %v", err)
+		return nil
+	}
+	if total := purged.Transactions + purged.Categories + purged.Sources + purged.Tags; total > 0 {
+		log.Printf("[TrashPurger] Purged %d transactions, %d categories, %d sources and %d tags",
+			purged.Transactions, purged.Categories, purged.Sources, purged.Tags)
+	}
+	return purged
+}
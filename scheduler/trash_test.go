@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"xspends/models/impl"
+	"xspends/models/interfaces"
+	xmock "xspends/models/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewTrashPurgerFromEnv(t *testing.T) {
+	t.Setenv("TRASH_PURGE_INTERVAL", "6h")
+	t.Setenv("TRASH_RETENTION_DAYS", "7")
+	p := NewTrashPurgerFromEnv()
+	assert.Equal(t, 6*time.Hour, p.Interval)
+	assert.Equal(t, 7*24*time.Hour, p.Retention)
+
+	t.Setenv("TRASH_PURGE_INTERVAL", "daily")
+	t.Setenv("TRASH_RETENTION_DAYS", "-1")
+	p = NewTrashPurgerFromEnv()
+	assert.Equal(t, defaultPurgeInterval, p.Interval)
+	assert.Equal(t, defaultRetentionDays*24*time.Hour, p.Retention)
+}
+
+func TestTrashPurgerRunOnce(t *testing.T) {
+	mockTrashModel := new(xmock.MockTrashModel)
+	impl.ModelsService = &impl.ModelsServiceContainer{TrashModel: mockTrashModel}
+
+	now := time.Date(2024, 4, 15, 8, 0, 0, 0, time.UTC)
+	p := &TrashPurger{Interval: time.Hour, Retention: 30 * 24 * time.Hour, now: func() time.Time { return now }}
+
+	purged := &interfaces.TrashPurge{Transactions: 4, Tags: 1}
+	mockTrashModel.On("PurgeTrash", mock.Anything, time.Date(2024, 3, 16, 8, 0, 0, 0, time.UTC), mock.Anything).Return(purged, nil).Once()
+	assert.Equal(t, purged, p.RunOnce(context.Background()))
+
+	mockTrashModel.On("PurgeTrash", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error")).Once()
+	assert.Nil(t, p.RunOnce(context.Background()))
+	mockTrashModel.AssertExpectations(t)
+}
@@ -1,9 +1,22 @@
 use xspends;
+delete from exchange_rates;
+delete from settlements;
+delete from shared_expense_shares;
+delete from shared_expenses;
+delete from rules;
+delete from budgets;
+delete from recurring_occurrences;
+delete from recurring_transactions;
+delete from transaction_split_tags;
+delete from transaction_splits;
+delete from transaction_terms;
 delete from transaction_tags;
 delete from tags;
 delete from transactions;
+delete from import_batches;
 delete from sources;    
 delete from categories;
+delete from group_invitations;
 delete from user_groups;
 delete from user_scopes;
 delete from scopes;
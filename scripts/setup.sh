@@ -16,11 +16,14 @@ kubectl create secret generic swagger-json-path --from-literal=swagger-json-path
 kubectl create secret generic DB_MAX_OPEN_CONNS --from-literal=DB_MAX_OPEN_CONNS="25"
 kubectl create secret generic DB_MAX_IDLE_CONNS --from-literal=DB_MAX_IDLE_CONNS="25"
 kubectl create secret generic DB_CONN_MAX_LIFETIME --from-literal=DB_CONN_MAX_LIFETIME="5"
-# Create the database and tables
+# Create the database
 mysql -h 127.0.0.1 -P 4000 -u root < ./scripts/setup.sql
 #for windows
 #Get-Content .\scripts\setup.sql | mysql -h 127.0.0.1 -P 4000 -u root
 
+# Create the tables by applying the schema migrations
+DB_DSN="root:@tcp(127.0.0.1:4000)/xspends?parseTime=true" go run . migrate up
+
 # Deploy your application (assuming you have a deployment file)
 kubectl apply -f deployments/app-deployment.yaml
 
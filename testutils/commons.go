@@ -34,18 +34,42 @@ func SetupModelTestEnvironment(t *testing.T) (context.Context, *impl.ModelsServi
 	mockScopeModel := new(mock.MockScopeModel)
 	mockGroupModel := new(mock.MockGroupModel)
 	mockUserScopeModel := new(mock.MockUserScopeModel)
+	mockGroupInvitationModel := new(mock.MockGroupInvitationModel)
+	mockRecurringTransactionModel := new(mock.MockRecurringTransactionModel)
+	mockBudgetModel := new(mock.MockBudgetModel)
+	mockImportBatchModel := new(mock.MockImportBatchModel)
+	mockArchiveModel := new(mock.MockArchiveModel)
+	mockCurrencyModel := new(mock.MockCurrencyModel)
+	mockTransactionSplitModel := new(mock.MockTransactionSplitModel)
+	mockSharedExpenseModel := new(mock.MockSharedExpenseModel)
+	mockTrashModel := new(mock.MockTrashModel)
+	mockTemplateModel := new(mock.MockTemplateModel)
+	mockRuleModel := new(mock.MockRuleModel)
+	mockTransactionSearchModel := new(mock.MockTransactionSearchModel)
 	//create mockconfigs
 	mockConfig := &impl.ModelsConfig{
-		DBService:           &impl.DBService{Executor: mockExecutor},
-		CategoryModel:       mockCategoryModel,
-		SourceModel:         mockSourceModel,
-		UserModel:           mockUserModel,
-		TagModel:            mockTagModel,
-		TransactionTagModel: mockTransactionTagModel,
-		TransactionModel:    mockTransactionModel,
-		ScopeModel:          mockScopeModel,
-		GroupModel:          mockGroupModel,
-		UserScopeModel:      mockUserScopeModel,
+		DBService:                 &impl.DBService{Executor: mockExecutor},
+		CategoryModel:             mockCategoryModel,
+		SourceModel:               mockSourceModel,
+		UserModel:                 mockUserModel,
+		TagModel:                  mockTagModel,
+		TransactionTagModel:       mockTransactionTagModel,
+		TransactionModel:          mockTransactionModel,
+		ScopeModel:                mockScopeModel,
+		GroupModel:                mockGroupModel,
+		UserScopeModel:            mockUserScopeModel,
+		GroupInvitationModel:      mockGroupInvitationModel,
+		RecurringTransactionModel: mockRecurringTransactionModel,
+		BudgetModel:               mockBudgetModel,
+		ImportBatchModel:          mockImportBatchModel,
+		ArchiveModel:              mockArchiveModel,
+		CurrencyModel:             mockCurrencyModel,
+		TransactionSplitModel:     mockTransactionSplitModel,
+		SharedExpenseModel:        mockSharedExpenseModel,
+		TrashModel:                mockTrashModel,
+		TemplateModel:             mockTemplateModel,
+		RuleModel:                 mockRuleModel,
+		TransactionSearchModel:    mockTransactionSearchModel,
 	}
 	// Initialize ModelsService with mock configuration
 	impl.InitModelsService(mockConfig)
@@ -26,6 +26,7 @@ package util
 
 import (
 	"strconv"
+	"xspends/models/interfaces"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,6 +40,15 @@ func GetFloatFromQuery(c *gin.Context, key string, defaultValue float64) float64
 	return value
 }
 
+// GetMoneyFromQuery retrieves an exact amount from query parameters with a default value
+func GetMoneyFromQuery(c *gin.Context, key string, defaultValue interfaces.Money) interfaces.Money {
+	value, err := interfaces.ParseMoney(c.DefaultQuery(key, defaultValue.String()))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 // GetIntFromQuery retrieves an integer value from query parameters with a default value
 func GetIntFromQuery(c *gin.Context, key string, defaultValue int) int {
 	value, err := strconv.Atoi(c.DefaultQuery(key, strconv.Itoa(defaultValue)))